@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/YeswanthC7/bookrec/internal/server"
+)
+
+func evaluateCmd() *cobra.Command {
+	opts := server.EvaluationOptions{}
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "evaluate",
+		Short: "Measure how well recommendations predict readers' next likes",
+		Long: "Hides each sampled reader's most recent like, recommends for them as the API does " +
+			"and reports how often the hidden book comes back (hit rate@10, MRR), how many readers " +
+			"got nothing and how much of the catalogue gets recommended. Nothing is written.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if opts.Readers < 1 || opts.MinLikes < 2 {
+				log.Fatal("❌ --readers must be at least 1 and --min-likes at least 2")
+			}
+			db := openDB(false)
+			defer func() { _ = db.Close() }()
+
+			report, err := server.Evaluate(context.Background(), db, opts)
+			if err != nil {
+				log.Fatalf("❌ Evaluation failed: %v", err)
+			}
+			if asJSON {
+				_ = json.NewEncoder(os.Stdout).Encode(report)
+				return
+			}
+			if report.Readers == 0 {
+				log.Fatalf("❌ No reader has %d likes; seed or simulate some first", opts.MinLikes)
+			}
+			log.Printf("📊 %d readers: hit rate@10 %.3f (%d hits), MRR %.3f, %d without recommendations, %.1f%% of the catalogue recommended",
+				report.Readers, report.HitRate, report.Hits, report.MRR, report.Empty, 100*report.Coverage)
+		},
+	}
+	cmd.Flags().IntVar(&opts.Readers, "readers", 200, "readers to sample")
+	cmd.Flags().IntVar(&opts.MinLikes, "min-likes", 5, "only readers with at least this many likes, one of which is held out")
+	cmd.Flags().Int64Var(&opts.Seed, "seed", 1, "random seed; the same seed samples the same readers")
+	cmd.Flags().StringVar(&opts.Mode, "mode", "collaborative", "recommender to evaluate: collaborative, content, authors or hybrid")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the report as JSON, e.g. to compare runs")
+	return cmd
+}
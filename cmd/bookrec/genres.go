@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"github.com/YeswanthC7/bookrec/internal/genres"
+)
+
+func genresCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "genres",
+		Short: "Reassign every book's genres from its subjects",
+		Long: "Maps each book's subjects onto the curated genre taxonomy again, replacing what " +
+			"book_genres held. Ingestion and admin edits keep genres current; run this once after " +
+			"migrating a catalogue that predates them, or after the taxonomy changes.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			db := openDB(false)
+			defer func() { _ = db.Close() }()
+
+			ctx, stop := signalContext()
+			defer stop()
+			n, err := genres.Backfill(ctx, db)
+			if err != nil {
+				log.Fatalf("❌ Assigning genres failed after %d books: %v", n, err)
+			}
+			log.Printf("🎉 Assigned genres to %d books", n)
+		},
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"github.com/YeswanthC7/bookrec/internal/ingest"
+	"github.com/YeswanthC7/bookrec/internal/jobrun"
+)
+
+func ingestCmd() *cobra.Command {
+	p := ingest.Params{}
+	cmd := &cobra.Command{
+		Use:   "ingest",
+		Short: "Fetch a curated catalogue from Open Library or Google Books into books",
+		Long: "Fetches --categories from --source into the shared catalogue. Google Books volumes " +
+			"already in the catalogue (by ISBN or Open Library work) only fill in what their book lacks; " +
+			"GOOGLE_BOOKS_API_KEY raises Google's daily quota. POST /admin/ingest queues the same run for the workers.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !ingest.ValidSource(p.Source) {
+				log.Fatalf("❌ Unknown --source %q (want openlibrary or googlebooks)", p.Source)
+			}
+			db := openDB(false)
+			defer func() { _ = db.Close() }()
+
+			// Ctrl-C stops the fetches under way and marks the run failed
+			ctx, stop := signalContext()
+			defer stop()
+			run := jobrun.Start(db, ingest.Job, len(p.Categories))
+			total, err := ingest.Run(ctx, db, p, run)
+			if err != nil {
+				log.Fatalf("❌ Ingestion failed: %v", err)
+			}
+			enqueueWebhookEvent(db, "ingest.completed", ingest.EventData(run, p, total))
+			log.Println("🎉 Book ingestion complete!")
+		},
+	}
+	cmd.Flags().StringVar(&p.Source, "source", ingest.DefaultSource, "catalogue to fetch from: openlibrary or googlebooks")
+	cmd.Flags().StringSliceVar(&p.Categories, "categories", ingest.DefaultCategories, "comma-separated categories to fetch")
+	return cmd
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/YeswanthC7/bookrec/internal/config"
+)
+
+// envFile is the --env flag every subcommand reads its configuration from
+var envFile string
+
+func main() {
+	root := &cobra.Command{
+		Use:   "bookrec",
+		Short: "BookRec API server, background jobs and database tools",
+		// every subcommand loads the same environment and tags its log lines
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			log.SetFlags(log.LstdFlags | log.Lmsgprefix)
+			log.SetPrefix("[" + cmd.Name() + "] ")
+			config.Load(envFile)
+		},
+	}
+	root.PersistentFlags().StringVar(&envFile, "env", config.DefaultEnvFile, "environment file with the DB_* and other settings")
+	root.AddCommand(serveCmd(), workerCmd(), ingestCmd(), migrateCmd(), seedCmd(), evaluateCmd(), genresCmd())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// openDB connects to the configured database or exits
+func openDB(multiStatements bool) *sql.DB {
+	db, err := config.OpenDB(multiStatements)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	log.Println("✅ Connected to MySQL!")
+	return db
+}
+
+// signalContext is cancelled on Ctrl-C or SIGTERM, so long-running
+// subcommands stop cleanly
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/spf13/cobra"
+
+	"github.com/YeswanthC7/bookrec/db/migrations"
+)
+
+func migrateCmd() *cobra.Command {
+	up := func(cmd *cobra.Command, args []string) {
+		m := migrator()
+		defer func() { _, _ = m.Close() }()
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			log.Fatalf("❌ Migrating up failed: %v", err)
+		}
+		reportVersion(m)
+	}
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply the schema migrations built into the binary (up by default)",
+		Long: "Applies db/migrations, which are embedded in the binary. Versions are tracked in " +
+			"schema_migrations like the migrate CLI does, so either can be used on the same database.",
+		Args: cobra.NoArgs,
+		Run:  up,
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "up",
+		Short: "Apply every pending migration",
+		Args:  cobra.NoArgs,
+		Run:   up,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "down [steps]",
+		Short: "Roll back the last migration, or the last steps migrations",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			steps := 1
+			if len(args) == 1 {
+				n, err := strconv.Atoi(args[0])
+				if err != nil || n < 1 {
+					log.Fatalf("❌ steps must be a positive number, got %q", args[0])
+				}
+				steps = n
+			}
+			m := migrator()
+			defer func() { _, _ = m.Close() }()
+			if err := m.Steps(-steps); err != nil {
+				log.Fatalf("❌ Migrating down failed: %v", err)
+			}
+			reportVersion(m)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "force <version>",
+		Short: "Record version as applied and clean, after fixing a failed migration by hand",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			version, err := strconv.Atoi(args[0])
+			if err != nil {
+				log.Fatalf("❌ version must be a number, got %q", args[0])
+			}
+			m := migrator()
+			defer func() { _, _ = m.Close() }()
+			if err := m.Force(version); err != nil {
+				log.Fatalf("❌ Forcing the version failed: %v", err)
+			}
+			reportVersion(m)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "version",
+		Short: "Print the schema version the database is at",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			m := migrator()
+			defer func() { _, _ = m.Close() }()
+			reportVersion(m)
+		},
+	})
+	return cmd
+}
+
+// migrator connects to the configured database for migrations
+func migrator() *migrate.Migrate {
+	m, err := migrations.New(openDB(true))
+	if err != nil {
+		log.Fatalf("❌ Loading migrations failed: %v", err)
+	}
+	return m
+}
+
+// reportVersion logs the schema version the database is at
+func reportVersion(m *migrate.Migrate) {
+	version, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		log.Println("ℹ️  No migrations applied")
+	case err != nil:
+		log.Fatalf("❌ Reading the schema version failed: %v", err)
+	case dirty:
+		log.Printf("⚠️  Schema version %d is dirty: a migration failed halfway; fix it by hand, then run migrate force", version)
+	default:
+		log.Printf("✅ Schema version %d", version)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/YeswanthC7/bookrec/internal/authors"
+	"github.com/YeswanthC7/bookrec/internal/counters"
+	"github.com/YeswanthC7/bookrec/internal/demo"
+	"github.com/YeswanthC7/bookrec/internal/genres"
+	"github.com/YeswanthC7/bookrec/internal/ids"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// adminEmail is the seeded admin account, next to the demo readers
+const adminEmail = "admin@demo.bookrec.test"
+
+// insertBatch is the number of interactions per INSERT
+const insertBatch = 500
+
+// upsertBook inserts a demo book, or finds the one an earlier run inserted
+func upsertBook(ctx context.Context, tx *sql.Tx, b demo.Book) (int64, error) {
+	publicID := ids.New()
+	subjects, _ := json.Marshal(b.Subjects)
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO books (uuid, slug, open_library_key, title, author, subjects, published_year, formats, page_count)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 'print', ?)
+		ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)`,
+		publicID, ids.BookSlug(b.Title, publicID), b.Key, b.Title, b.Author, string(subjects), b.Year, b.Pages)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := genres.Assign(ctx, tx, id, b.Subjects); err != nil {
+		return 0, err
+	}
+	return id, authors.Link(ctx, tx, id, b.Author)
+}
+
+// upsertUser inserts a demo account in the default organization, or finds
+// the one an earlier run inserted
+func upsertUser(ctx context.Context, tx *sql.Tx, email, handle, role, passwordHash string) (int64, error) {
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO users (organization_id, email, handle, password_hash, role)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)`,
+		tenant.DefaultID, email, handle, passwordHash, role)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// insertInteractions writes interactions in multi-row batches
+func insertInteractions(ctx context.Context, tx *sql.Tx, all []demo.Interaction, userIDs, bookIDs []int64, now time.Time) error {
+	for start := 0; start < len(all); start += insertBatch {
+		end := start + insertBatch
+		if end > len(all) {
+			end = len(all)
+		}
+		rows := make([]string, 0, end-start)
+		args := make([]interface{}, 0, 6*(end-start))
+		for _, in := range all[start:end] {
+			var rating interface{}
+			if in.Rating > 0 {
+				rating = in.Rating
+			}
+			rows = append(rows, "(?, ?, ?, ?, ?, ?)")
+			args = append(args, tenant.DefaultID, userIDs[in.Reader], bookIDs[in.Book], in.Action, rating,
+				now.Add(-in.Age).Truncate(time.Second))
+		}
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO interactions (organization_id, user_id, book_id, action, rating, created_at) VALUES "+
+				strings.Join(rows, ", "), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func seedCmd() *cobra.Command {
+	var users, books int
+	var seed int64
+	var password string
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Fill the database with a demo catalogue, readers and their interactions",
+		Long: "Fills a fresh database with a demo catalogue, readers and their interactions so " +
+			"recommendations, trending and stats have something to show. Re-running it reuses the " +
+			"books and accounts and leaves readers who already have interactions alone.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if users < 1 || books < 1 {
+				log.Fatal("❌ --users and --books must be at least 1")
+			}
+			db := openDB(false)
+			defer func() { _ = db.Close() }()
+			seedDemo(db, users, books, seed, password)
+		},
+	}
+	cmd.Flags().IntVar(&users, "users", 50, "number of demo readers")
+	cmd.Flags().IntVar(&books, "books", 500, "number of demo books")
+	cmd.Flags().Int64Var(&seed, "seed", 1, "random seed; the same seed gives the same data")
+	cmd.Flags().StringVar(&password, "password", "bookrec-demo", "password of every demo account")
+	return cmd
+}
+
+// seedDemo inserts the demo data in one transaction and rebuilds the counters
+func seedDemo(db *sql.DB, users, books int, seed int64, password string) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Fatalf("❌ Hashing the demo password failed: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	catalogue := demo.Books(rng, books)
+	readers := demo.Readers(rng, users)
+	interactions := demo.Interactions(rng, readers, catalogue)
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Fatalf("❌ Begin failed: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	bookIDs := make([]int64, len(catalogue))
+	for i, b := range catalogue {
+		if bookIDs[i], err = upsertBook(ctx, tx, b); err != nil {
+			log.Fatalf("❌ Inserting book %q failed: %v", b.Title, err)
+		}
+	}
+	log.Printf("📚 %d demo books", len(bookIDs))
+
+	if _, err := upsertUser(ctx, tx, adminEmail, "demo-admin", "admin", string(hashed)); err != nil {
+		log.Fatalf("❌ Inserting the admin account failed: %v", err)
+	}
+	userIDs := make([]int64, len(readers))
+	for i, r := range readers {
+		if userIDs[i], err = upsertUser(ctx, tx, r.Email, r.Handle, "user", string(hashed)); err != nil {
+			log.Fatalf("❌ Inserting reader %s failed: %v", r.Handle, err)
+		}
+	}
+	log.Printf("👥 %d demo readers and %s", len(userIDs), adminEmail)
+
+	// readers seeded by an earlier run keep the history they have
+	fresh := []demo.Interaction{}
+	skipped := map[int]bool{}
+	for r, id := range userIDs {
+		var n int
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM interactions WHERE user_id = ?", id).Scan(&n); err != nil {
+			log.Fatalf("❌ Checking existing interactions failed: %v", err)
+		}
+		skipped[r] = n > 0
+	}
+	for _, in := range interactions {
+		if !skipped[in.Reader] {
+			fresh = append(fresh, in)
+		}
+	}
+	if err := insertInteractions(ctx, tx, fresh, userIDs, bookIDs, time.Now().UTC()); err != nil {
+		log.Fatalf("❌ Inserting interactions failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		log.Fatalf("❌ Commit failed: %v", err)
+	}
+	log.Printf("👍 %d interactions", len(fresh))
+
+	if err := counters.Rebuild(ctx, db); err != nil {
+		log.Fatalf("❌ Rebuilding book counters failed: %v", err)
+	}
+
+	log.Printf("🎉 Demo data ready. Log in as %s or reader01@demo.bookrec.test with password %q", adminEmail, password)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"github.com/YeswanthC7/bookrec/internal/server"
+)
+
+func serveCmd() *cobra.Command {
+	var addr string
+	var workers bool
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the API server",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			db := openDB(false)
+			defer func() { _ = db.Close() }()
+
+			ctx, stop := signalContext()
+			defer stop()
+			if err := server.Run(ctx, db, addr, workers); err != nil {
+				log.Fatalf("❌ %v", err)
+			}
+			log.Println("👋 Server stopped")
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", ":8080", "address to listen on")
+	cmd.Flags().BoolVar(&workers, "workers", true, "also send webhook deliveries and run queued and scheduled ingest runs; turn off when bookrec worker runs separately")
+	return cmd
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// enqueueWebhookEvent queues a delivery for every active webhook subscribed to
+// eventType. The webhook dispatcher (bookrec serve or worker) signs and sends them.
+func enqueueWebhookEvent(db *sql.DB, eventType string, data map[string]interface{}) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":       eventType,
+		"created_at": time.Now().UTC(),
+		"data":       data,
+	})
+	if err != nil {
+		log.Printf("⚠️  Could not encode %s webhook payload: %v", eventType, err)
+		return
+	}
+
+	if _, err := db.Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+		SELECT id, ?, ?
+		FROM webhooks
+		WHERE active = 1 AND FIND_IN_SET(?, events) > 0`,
+		eventType, string(payload), eventType); err != nil {
+		log.Printf("⚠️  Could not enqueue %s webhooks: %v", eventType, err)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"github.com/YeswanthC7/bookrec/internal/server"
+)
+
+func workerCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "worker",
+		Short: "Send queued webhook deliveries and run queued and scheduled ingest runs without serving the API",
+		Long: "Runs the webhook dispatcher, the runs queued with POST /admin/ingest and the nightly ingest schedule " +
+			"(INGEST_SCHEDULE_ENABLED) on their own, so they scale apart from the API (start the server with " +
+			"--workers=false). Any number of workers can run at once; each night's run is queued once.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			db := openDB(false)
+			defer func() { _ = db.Close() }()
+
+			ctx, stop := signalContext()
+			defer stop()
+			log.Println("🚀 Sending webhook deliveries and running queued ingests")
+			if err := server.RunWorkers(ctx, db); err != nil {
+				log.Fatalf("❌ %v", err)
+			}
+			log.Println("👋 Worker stopped")
+		},
+	}
+}
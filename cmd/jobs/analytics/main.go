@@ -0,0 +1,139 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/YeswanthC7/bookrec/internal/config"
+	"github.com/YeswanthC7/bookrec/internal/jobrun"
+)
+
+// rollups rebuilds one day of every rollup table, in order (later entries
+// may read earlier ones). Each entry deletes the day and re-inserts it, so
+// re-running a day is idempotent. Args are bound as (day, dayStart, dayEnd,
+// weekStart).
+var rollups = []struct {
+	table  string
+	insert string
+	args   func(day string, start, end, weekStart time.Time) []interface{}
+}{
+	{
+		table: "analytics_daily_signups",
+		insert: `
+			INSERT INTO analytics_daily_signups (organization_id, day, signups)
+			SELECT organization_id, ?, COUNT(*)
+			FROM users
+			WHERE created_at >= ? AND created_at < ? AND deleted_at IS NULL
+			GROUP BY organization_id`,
+		args: func(day string, start, end, _ time.Time) []interface{} { return []interface{}{day, start, end} },
+	},
+	{
+		table: "daily_stats",
+		insert: `
+			INSERT INTO daily_stats (organization_id, day, views, likes, ratings, unique_users)
+			SELECT organization_id, ?,
+			       SUM(action = 'view'), SUM(action = 'like'), SUM(action = 'rating'),
+			       COUNT(DISTINCT user_id)
+			FROM interactions
+			WHERE created_at >= ? AND created_at < ? AND deleted_at IS NULL
+			GROUP BY organization_id`,
+		args: func(day string, start, end, _ time.Time) []interface{} { return []interface{}{day, start, end} },
+	},
+	{
+		table: "book_daily_stats",
+		insert: `
+			INSERT INTO book_daily_stats (organization_id, day, book_id, views, likes, ratings, rating_sum, unique_users)
+			SELECT organization_id, ?, book_id,
+			       SUM(action = 'view'), SUM(action = 'like'), SUM(action = 'rating'),
+			       COALESCE(SUM(CASE WHEN action = 'rating' THEN rating END), 0),
+			       COUNT(DISTINCT user_id)
+			FROM interactions
+			WHERE created_at >= ? AND created_at < ? AND deleted_at IS NULL
+			GROUP BY organization_id, book_id`,
+		args: func(day string, start, end, _ time.Time) []interface{} { return []interface{}{day, start, end} },
+	},
+	{
+		table: "analytics_daily_active_users",
+		insert: `
+			INSERT INTO analytics_daily_active_users (organization_id, day, dau, wau)
+			SELECT organization_id, ?,
+			       COUNT(DISTINCT CASE WHEN created_at >= ? THEN user_id END),
+			       COUNT(DISTINCT user_id)
+			FROM interactions
+			WHERE created_at >= ? AND created_at < ? AND deleted_at IS NULL
+			GROUP BY organization_id`,
+		args: func(day string, start, end, weekStart time.Time) []interface{} {
+			return []interface{}{day, start, weekStart, end}
+		},
+	},
+	{
+		table: "analytics_daily_genres",
+		insert: `
+			INSERT INTO analytics_daily_genres (organization_id, day, genre, interactions)
+			SELECT s.organization_id, s.day, LEFT(g.genre, 255), SUM(s.views + s.likes + s.ratings)
+			FROM book_daily_stats s
+			JOIN books b ON b.id = s.book_id
+			JOIN JSON_TABLE(b.subjects, '$[*]' COLUMNS (genre VARCHAR(512) PATH '$')) g
+			WHERE s.day = ? AND g.genre IS NOT NULL AND g.genre <> ''
+			GROUP BY s.organization_id, s.day, LEFT(g.genre, 255)`,
+		args: func(day string, _, _, _ time.Time) []interface{} { return []interface{}{day} },
+	},
+}
+
+// rollupDay rewrites all rollups for the UTC day starting at start in one transaction
+func rollupDay(db *sql.DB, start time.Time) error {
+	end := start.AddDate(0, 0, 1)
+	weekStart := start.AddDate(0, 0, -6)
+	day := start.Format("2006-01-02")
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, r := range rollups {
+		if _, err := tx.Exec("DELETE FROM "+r.table+" WHERE day = ?", day); err != nil {
+			return fmt.Errorf("%s: %w", r.table, err)
+		}
+		if _, err := tx.Exec(r.insert, r.args(day, start, end, weekStart)...); err != nil {
+			return fmt.Errorf("%s: %w", r.table, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func main() {
+	// Run nightly (shortly after midnight UTC) to finalise yesterday; today
+	// is recomputed too so dashboards show a partial day. Use -days to backfill.
+	days := flag.Int("days", 2, "number of days to recompute, ending today (UTC)")
+	flag.Parse()
+	if *days < 1 {
+		log.Fatal("❌ -days must be at least 1")
+	}
+
+	config.Load(config.DefaultEnvFile)
+
+	db, err := config.OpenDB(false)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	run := jobrun.Start(db, "analytics", *days)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for i := 0; i < *days; i++ {
+		start := today.AddDate(0, 0, i-*days+1) // oldest first
+		if err := rollupDay(db, start); err != nil {
+			run.Finish("failed", fmt.Sprintf("%s: %v", start.Format("2006-01-02"), err))
+			log.Fatalf("❌ Rollup failed for %s: %v", start.Format("2006-01-02"), err)
+		}
+		run.Progress(i+1, "rolled up "+start.Format("2006-01-02"))
+	}
+
+	run.Finish("succeeded", fmt.Sprintf("%d days rolled up", *days))
+	log.Printf("🎉 Analytics rollup complete (%d days)", *days)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/YeswanthC7/bookrec/internal/config"
+	"github.com/YeswanthC7/bookrec/internal/counters"
+	"github.com/YeswanthC7/bookrec/internal/jobrun"
+)
+
+// duplicatesSQL counts, per action, the (user, book, action) groups with
+// more than one row and the rows beyond the first
+const duplicatesSQL = `
+	SELECT action, COUNT(*), SUM(n - 1)
+	FROM (
+		SELECT action, COUNT(*) AS n
+		FROM interactions
+		GROUP BY user_id, book_id, action
+		HAVING n > 1
+	) d
+	GROUP BY action
+	ORDER BY action`
+
+// carryRatingsSQL gives the row each rating group keeps (the earliest, or
+// the earliest not deleted) the reader's latest score, so collapsing
+// re-ratings doesn't undo them
+const carryRatingsSQL = `
+	UPDATE interactions k
+	JOIN (
+		SELECT DISTINCT
+			FIRST_VALUE(id) OVER (PARTITION BY user_id, book_id ORDER BY deleted_at IS NOT NULL, created_at, id) AS keep_id,
+			FIRST_VALUE(rating) OVER (PARTITION BY user_id, book_id ORDER BY deleted_at IS NOT NULL, created_at DESC, id DESC) AS latest
+		FROM interactions
+		WHERE action = 'rating'
+	) d ON d.keep_id = k.id
+	SET k.rating = d.latest
+	WHERE NOT (k.rating <=> d.latest)`
+
+// extraRowsSQL picks a batch of rows other than the one their group keeps
+const extraRowsSQL = `
+	SELECT id FROM (
+		SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id, book_id, action ORDER BY deleted_at IS NOT NULL, created_at, id) AS rn
+		FROM interactions
+	) d
+	WHERE rn > 1
+	ORDER BY id
+	LIMIT ?`
+
+// duplicates is the per-action summary
+type duplicates struct {
+	action string
+	groups int
+	extra  int
+}
+
+func countDuplicates(db *sql.DB) ([]duplicates, int, error) {
+	rows, err := db.Query(duplicatesSQL)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	found := []duplicates{}
+	total := 0
+	for rows.Next() {
+		var d duplicates
+		if err := rows.Scan(&d.action, &d.groups, &d.extra); err != nil {
+			return nil, 0, err
+		}
+		found = append(found, d)
+		total += d.extra
+	}
+	return found, total, rows.Err()
+}
+
+// deleteBatch removes up to batch extra rows and reports how many went
+func deleteBatch(db *sql.DB, batch int) (int64, error) {
+	rows, err := db.Query(extraRowsSQL, batch)
+	if err != nil {
+		return 0, err
+	}
+	ids := []interface{}{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	marks := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	res, err := db.Exec("DELETE FROM interactions WHERE id IN ("+marks+")", ids...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func main() {
+	// Collapses duplicate (user, book, action) interactions, keeping the
+	// earliest of each, not deleted if there is one. Safe to re-run; run it
+	// before migration 000047, whose unique key can't be added while
+	// duplicates remain.
+	dryRun := flag.Bool("dry-run", false, "only report what would be removed")
+	batch := flag.Int("batch", 1000, "rows deleted per statement")
+	flag.Parse()
+	if *batch < 1 {
+		log.Fatal("❌ -batch must be at least 1")
+	}
+
+	config.Load(config.DefaultEnvFile)
+
+	db, err := config.OpenDB(false)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	found, total, err := countDuplicates(db)
+	if err != nil {
+		log.Fatalf("❌ Counting duplicates failed: %v", err)
+	}
+	for _, d := range found {
+		log.Printf("🔎 %s: %d duplicated (user, book) pairs, %d extra rows", d.action, d.groups, d.extra)
+	}
+	if total == 0 {
+		log.Println("✅ No duplicate interactions")
+		return
+	}
+	if *dryRun {
+		log.Printf("🧪 Dry run: %d rows would be removed", total)
+		return
+	}
+
+	run := jobrun.Start(db, "dedupe", total)
+	carried, err := db.Exec(carryRatingsSQL)
+	if err != nil {
+		run.Finish("failed", fmt.Sprintf("carrying ratings: %v", err))
+		log.Fatalf("❌ Carrying latest ratings failed: %v", err)
+	}
+	if n, _ := carried.RowsAffected(); n > 0 {
+		log.Printf("⭐ %d kept ratings updated to the reader's latest score", n)
+	}
+
+	var removed int64
+	for {
+		n, err := deleteBatch(db, *batch)
+		if err != nil {
+			run.Finish("failed", fmt.Sprintf("%d rows removed, then: %v", removed, err))
+			log.Fatalf("❌ Delete failed after %d rows: %v", removed, err)
+		}
+		if n == 0 {
+			break
+		}
+		removed += n
+		run.Progress(int(removed), fmt.Sprintf("%d of %d duplicates removed", removed, total))
+	}
+
+	// the deleted rows were counted in book_counters
+	if err := counters.Rebuild(context.Background(), db); err != nil {
+		run.Finish("failed", fmt.Sprintf("%d rows removed, then rebuilding counters: %v", removed, err))
+		log.Fatalf("❌ Rebuilding book counters failed: %v", err)
+	}
+
+	summary := []string{}
+	for _, d := range found {
+		summary = append(summary, fmt.Sprintf("%s %d", d.action, d.extra))
+	}
+	run.Finish("succeeded", fmt.Sprintf("%d duplicates removed (%s)", removed, strings.Join(summary, ", ")))
+	log.Printf("🎉 Removed %d duplicate interactions (%s)", removed, strings.Join(summary, ", "))
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"embed"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/YeswanthC7/bookrec/internal/config"
+	"github.com/YeswanthC7/bookrec/internal/jobrun"
+	"github.com/YeswanthC7/bookrec/internal/mail"
+	"github.com/YeswanthC7/bookrec/internal/recommend"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// resendAfter keeps a weekly schedule from emailing anyone twice if the job
+// runs early or is re-run; failed sends don't count and are retried.
+const resendAfter = 6 * 24 * time.Hour
+
+const digestSubject = "Your weekly BookRec picks"
+
+//go:embed templates
+var templateFS embed.FS
+
+var (
+	textTemplate = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/digest.txt"))
+	htmlTemplate = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/digest.html"))
+)
+
+type recipient struct {
+	ID               int
+	OrgID            int
+	Email            string
+	Handle           string
+	UnsubscribeToken string
+}
+
+type digestBook struct {
+	ID     int
+	Title  string
+	Author string
+	URL    string
+}
+
+type digestData struct {
+	Handle         string
+	Books          []digestBook
+	UnsubscribeURL string
+}
+
+// loadRecipients returns opted-in users without a successful digest in the
+// last resendAfter
+func loadRecipients(db *sql.DB, now time.Time) ([]recipient, error) {
+	rows, err := db.Query(`
+		SELECT u.id, u.organization_id, u.email, u.handle, COALESCE(u.digest_unsubscribe_token, '')
+		FROM users u
+		WHERE u.email_digest = TRUE AND u.deleted_at IS NULL
+		  AND NOT EXISTS (
+		      SELECT 1 FROM digest_sends s
+		      WHERE s.user_id = u.id AND s.status = 'sent' AND s.created_at >= ?)
+		ORDER BY u.id`, now.Add(-resendAfter))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var out []recipient
+	for rows.Next() {
+		var r recipient
+		if err := rows.Scan(&r.ID, &r.OrgID, &r.Email, &r.Handle, &r.UnsubscribeToken); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// freshRecommendations is the server's "enjoyed the same books" scoring
+// (recommend.ScoresSQL), minus books already received in a digest
+func freshRecommendations(db *sql.DB, r recipient, limit int, baseURL string) ([]digestBook, error) {
+	args := append(recommend.Args(r.ID), r.OrgID, r.ID, limit)
+	rows, err := db.Query(`
+		SELECT b.id, b.slug, b.title, b.author, sc.score
+		FROM (`+recommend.ScoresSQL+`) sc
+		JOIN books b
+		    ON b.id = sc.book_id
+		WHERE `+tenant.BooksVisibleSQL("b")+`
+		  AND sc.book_id NOT IN (
+		      SELECT sb.book_id
+		      FROM digest_send_books sb
+		      JOIN digest_sends s ON s.id = sb.send_id
+		      WHERE s.user_id = ? AND s.status = 'sent')
+		ORDER BY sc.score DESC, sc.strongest DESC, b.id
+		LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var books []digestBook
+	for rows.Next() {
+		var b digestBook
+		var slug string
+		var score int
+		if err := rows.Scan(&b.ID, &slug, &b.Title, &b.Author, &score); err != nil {
+			return nil, err
+		}
+		b.URL = baseURL + "/books/" + url.PathEscape(slug)
+		books = append(books, b)
+	}
+	return books, rows.Err()
+}
+
+// ensureUnsubscribeToken gives users who opted in before tokens existed one
+func ensureUnsubscribeToken(db *sql.DB, r *recipient) error {
+	if r.UnsubscribeToken != "" {
+		return nil
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	if _, err := db.Exec(`UPDATE users SET digest_unsubscribe_token = ? WHERE id = ?`, token, r.ID); err != nil {
+		return err
+	}
+	r.UnsubscribeToken = token
+	return nil
+}
+
+func render(r recipient, books []digestBook, baseURL string) (mail.Message, error) {
+	unsubscribeURL := baseURL + "/digest/unsubscribe?token=" + url.QueryEscape(r.UnsubscribeToken)
+	data := digestData{Handle: r.Handle, Books: books, UnsubscribeURL: unsubscribeURL}
+
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return mail.Message{}, err
+	}
+	if err := htmlTemplate.Execute(&html, data); err != nil {
+		return mail.Message{}, err
+	}
+	return mail.Message{
+		To:      r.Email,
+		Subject: digestSubject,
+		Text:    text.String(),
+		HTML:    html.String(),
+		Headers: map[string]string{
+			// RFC 8058 one-click unsubscribe; the endpoint accepts POST too
+			"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
+
+// recordSend stores the attempt and, when it succeeded, the books it carried
+func recordSend(db *sql.DB, userID int, provider string, books []digestBook, sendErr error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	status, errText := "sent", sql.NullString{}
+	if sendErr != nil {
+		status, errText = "failed", sql.NullString{String: sendErr.Error(), Valid: true}
+	}
+	res, err := tx.Exec(`INSERT INTO digest_sends (user_id, status, provider, error) VALUES (?, ?, ?, ?)`,
+		userID, status, provider, errText)
+	if err != nil {
+		return err
+	}
+	if sendErr == nil {
+		sendID, _ := res.LastInsertId()
+		for i, b := range books {
+			if _, err := tx.Exec(`INSERT INTO digest_send_books (send_id, book_id, position) VALUES (?, ?, ?)`,
+				sendID, b.ID, i+1); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func main() {
+	// Run weekly (cron, Kubernetes CronJob, …). Users with nothing fresh to
+	// recommend are skipped without an email.
+	limit := flag.Int("limit", 5, "books per digest")
+	dryRun := flag.Bool("dry-run", false, "print emails instead of sending them and record nothing")
+	flag.Parse()
+	if *limit < 1 {
+		log.Fatal("❌ -limit must be at least 1")
+	}
+
+	config.Load(config.DefaultEnvFile)
+
+	baseURL := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	var sender mail.Sender = mail.LogSender{}
+	if !*dryRun {
+		s, err := mail.FromEnv()
+		if err != nil {
+			log.Fatalf("❌ Mail setup: %v", err)
+		}
+		sender = s
+	}
+
+	db, err := config.OpenDB(false)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	recipients, err := loadRecipients(db, time.Now().UTC())
+	if err != nil {
+		log.Fatalf("❌ Loading recipients: %v", err)
+	}
+
+	run := jobrun.Start(db, "digest", len(recipients))
+	sent, skipped, failed := 0, 0, 0
+	for i := range recipients {
+		r := &recipients[i]
+		run.Progress(i, fmt.Sprintf("%d sent, %d skipped, %d failed", sent, skipped, failed))
+
+		books, err := freshRecommendations(db, *r, *limit, baseURL)
+		if err != nil {
+			run.Finish("failed", err.Error())
+			log.Fatalf("❌ Recommendations for user %d: %v", r.ID, err)
+		}
+		if len(books) == 0 {
+			skipped++
+			continue
+		}
+		if !*dryRun {
+			if err := ensureUnsubscribeToken(db, r); err != nil {
+				run.Finish("failed", err.Error())
+				log.Fatalf("❌ Unsubscribe token for user %d: %v", r.ID, err)
+			}
+		}
+
+		msg, err := render(*r, books, baseURL)
+		if err != nil {
+			run.Finish("failed", err.Error())
+			log.Fatalf("❌ Rendering digest: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		sendErr := sender.Send(ctx, msg)
+		cancel()
+		if sendErr != nil {
+			failed++
+			log.Printf("⚠️  Digest to user %d failed: %v", r.ID, sendErr)
+		} else {
+			sent++
+		}
+		if *dryRun {
+			continue
+		}
+		if err := recordSend(db, r.ID, sender.Name(), books, sendErr); err != nil {
+			log.Printf("⚠️  Could not record digest for user %d: %v", r.ID, err)
+		}
+	}
+
+	summary := fmt.Sprintf("%d sent, %d skipped, %d failed", sent, skipped, failed)
+	run.Finish("succeeded", summary)
+	log.Printf("🎉 Digest complete: %s", summary)
+}
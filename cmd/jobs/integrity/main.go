@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/YeswanthC7/bookrec/internal/config"
+	"github.com/YeswanthC7/bookrec/internal/counters"
+	"github.com/YeswanthC7/bookrec/internal/jobrun"
+)
+
+// checks look for rows pointing at something that no longer exists. Foreign
+// keys prevent most of these, but rows written with FOREIGN_KEY_CHECKS off
+// (bulk imports, restores) or before a constraint existed slip through, and
+// content_reports.target_id and redirects.target_id have no constraint at all. find returns a
+// printable key per orphan; repair removes them all.
+var checks = []struct {
+	name   string
+	what   string
+	find   string
+	repair string
+}{
+	{
+		name: "interactions_without_user",
+		what: "interactions whose user_id points nowhere",
+		find: `
+			SELECT CAST(i.id AS CHAR) FROM interactions i
+			LEFT JOIN users u ON u.id = i.user_id
+			WHERE u.id IS NULL ORDER BY i.id`,
+		repair: `
+			DELETE i FROM interactions i
+			LEFT JOIN users u ON u.id = i.user_id
+			WHERE u.id IS NULL`,
+	},
+	{
+		// ratings are the reviews: a score a reader gave a book
+		name: "ratings_without_book",
+		what: "ratings (reviews) of books that no longer exist",
+		find: `
+			SELECT CAST(i.id AS CHAR) FROM interactions i
+			LEFT JOIN books b ON b.id = i.book_id
+			WHERE b.id IS NULL AND i.action = 'rating' ORDER BY i.id`,
+		repair: `
+			DELETE i FROM interactions i
+			LEFT JOIN books b ON b.id = i.book_id
+			WHERE b.id IS NULL AND i.action = 'rating'`,
+	},
+	{
+		name: "interactions_without_book",
+		what: "views and likes of books that no longer exist",
+		find: `
+			SELECT CAST(i.id AS CHAR) FROM interactions i
+			LEFT JOIN books b ON b.id = i.book_id
+			WHERE b.id IS NULL AND i.action <> 'rating' ORDER BY i.id`,
+		repair: `
+			DELETE i FROM interactions i
+			LEFT JOIN books b ON b.id = i.book_id
+			WHERE b.id IS NULL AND i.action <> 'rating'`,
+	},
+	{
+		name: "list_items_without_book",
+		what: "list items (list_id:book_id) for deleted books",
+		find: `
+			SELECT CONCAT(li.list_id, ':', li.book_id) FROM list_items li
+			LEFT JOIN books b ON b.id = li.book_id
+			WHERE b.id IS NULL ORDER BY li.list_id, li.book_id`,
+		repair: `
+			DELETE li FROM list_items li
+			LEFT JOIN books b ON b.id = li.book_id
+			WHERE b.id IS NULL`,
+	},
+	{
+		name: "list_items_without_list",
+		what: "list items (list_id:book_id) of deleted lists",
+		find: `
+			SELECT CONCAT(li.list_id, ':', li.book_id) FROM list_items li
+			LEFT JOIN lists l ON l.id = li.list_id
+			WHERE l.id IS NULL ORDER BY li.list_id, li.book_id`,
+		repair: `
+			DELETE li FROM list_items li
+			LEFT JOIN lists l ON l.id = li.list_id
+			WHERE l.id IS NULL`,
+	},
+	{
+		name: "content_reports_without_target",
+		what: "content reports (target_type:target_id) about deleted threads and posts",
+		find: `
+			SELECT CONCAT(cr.target_type, ':', cr.target_id) FROM content_reports cr
+			LEFT JOIN discussion_threads t ON cr.target_type = 'thread' AND t.id = cr.target_id
+			LEFT JOIN discussion_posts p ON cr.target_type = 'post' AND p.id = cr.target_id
+			WHERE t.id IS NULL AND p.id IS NULL ORDER BY cr.id`,
+		repair: `
+			DELETE cr FROM content_reports cr
+			LEFT JOIN discussion_threads t ON cr.target_type = 'thread' AND t.id = cr.target_id
+			LEFT JOIN discussion_posts p ON cr.target_type = 'post' AND p.id = cr.target_id
+			WHERE t.id IS NULL AND p.id IS NULL`,
+	},
+	{
+		name: "redirects_without_target",
+		what: "redirects (resource:old_ref) to deleted books and users",
+		find: `
+			SELECT CONCAT(r.resource, ':', r.old_ref) FROM redirects r
+			LEFT JOIN books b ON r.resource = 'book' AND b.id = r.target_id
+			LEFT JOIN users u ON r.resource = 'user' AND u.id = r.target_id
+			WHERE b.id IS NULL AND u.id IS NULL ORDER BY r.id`,
+		repair: `
+			DELETE r FROM redirects r
+			LEFT JOIN books b ON r.resource = 'book' AND b.id = r.target_id
+			LEFT JOIN users u ON r.resource = 'user' AND u.id = r.target_id
+			WHERE b.id IS NULL AND u.id IS NULL`,
+	},
+}
+
+// finding is one check's result in the report
+type finding struct {
+	Check    string   `json:"check"`
+	What     string   `json:"what"`
+	Count    int      `json:"count"`
+	Sample   []string `json:"sample"`
+	Repaired int64    `json:"repaired"`
+}
+
+// report is what the job prints (and writes with -report)
+type report struct {
+	StartedAt time.Time `json:"started_at"`
+	Repair    bool      `json:"repair"`
+	Findings  []finding `json:"findings"`
+	Total     int       `json:"total"`
+}
+
+// runCheck counts a check's orphans, keeping the first sample keys, and
+// deletes them when repair is set
+func runCheck(db *sql.DB, i, sample int, repair bool) (finding, error) {
+	c := checks[i]
+	f := finding{Check: c.name, What: c.what, Sample: []string{}}
+
+	rows, err := db.Query(c.find)
+	if err != nil {
+		return f, err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return f, err
+		}
+		if f.Count < sample {
+			f.Sample = append(f.Sample, key)
+		}
+		f.Count++
+	}
+	if err := rows.Err(); err != nil {
+		return f, err
+	}
+
+	if repair && f.Count > 0 {
+		res, err := db.Exec(c.repair)
+		if err != nil {
+			return f, err
+		}
+		f.Repaired, _ = res.RowsAffected()
+	}
+	return f, nil
+}
+
+func main() {
+	// Run it after bulk imports or restores, and before adding constraints.
+	// Without -repair nothing is changed.
+	repair := flag.Bool("repair", false, "delete the orphaned rows found")
+	reportPath := flag.String("report", "", "also write the JSON report to this file")
+	sample := flag.Int("sample", 10, "orphan keys to list per check")
+	flag.Parse()
+
+	config.Load(config.DefaultEnvFile)
+
+	db, err := config.OpenDB(false)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	run := jobrun.Start(db, "integrity", len(checks))
+	rep := report{StartedAt: time.Now().UTC(), Repair: *repair, Findings: []finding{}}
+	var repaired int64
+	for i := range checks {
+		f, err := runCheck(db, i, *sample, *repair)
+		if err != nil {
+			run.Finish("failed", fmt.Sprintf("%s: %v", checks[i].name, err))
+			log.Fatalf("❌ Check %s failed: %v", checks[i].name, err)
+		}
+		if f.Count > 0 {
+			log.Printf("⚠️  %s: %d %s (e.g. %v)", f.Check, f.Count, f.What, f.Sample)
+		} else {
+			log.Printf("✅ %s: none", f.Check)
+		}
+		rep.Findings = append(rep.Findings, f)
+		rep.Total += f.Count
+		repaired += f.Repaired
+		run.Progress(i+1, fmt.Sprintf("%s: %d", f.Check, f.Count))
+	}
+
+	if repaired > 0 {
+		// repaired interactions were counted in book_counters
+		if err := counters.Rebuild(context.Background(), db); err != nil {
+			run.Finish("failed", fmt.Sprintf("%d rows removed, then rebuilding counters: %v", repaired, err))
+			log.Fatalf("❌ Rebuilding book counters failed: %v", err)
+		}
+	}
+
+	out, _ := json.MarshalIndent(rep, "", "  ")
+	fmt.Println(string(out))
+	if *reportPath != "" {
+		if err := os.WriteFile(*reportPath, append(out, '\n'), 0o644); err != nil {
+			log.Printf("⚠️  Could not write report to %s: %v", *reportPath, err)
+		}
+	}
+
+	summary := fmt.Sprintf("%d orphaned rows found", rep.Total)
+	if *repair {
+		summary += fmt.Sprintf(", %d removed", repaired)
+	}
+	run.Finish("succeeded", summary)
+	log.Printf("🎉 Integrity audit complete: %s", summary)
+}
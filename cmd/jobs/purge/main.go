@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/YeswanthC7/bookrec/internal/config"
+	"github.com/YeswanthC7/bookrec/internal/jobrun"
+	"github.com/YeswanthC7/bookrec/internal/softdelete"
+)
+
+// countPurgeable counts, per resource, the rows deleted before cutoff
+func countPurgeable(db *sql.DB, cutoff time.Time) (map[string]int, int, error) {
+	found := map[string]int{}
+	total := 0
+	for _, resource := range softdelete.PurgeOrder {
+		var n int
+		if err := db.QueryRow("SELECT COUNT(*) FROM "+softdelete.Tables[resource]+" WHERE deleted_at < ?", cutoff).
+			Scan(&n); err != nil {
+			return nil, 0, err
+		}
+		found[resource] = n
+		total += n
+	}
+	return found, total, nil
+}
+
+func main() {
+	// Removes users, books, lists and interactions soft-deleted longer ago
+	// than the retention window; until then admins can restore them. Run it
+	// daily.
+	retentionDays := flag.Int("retention-days", 30, "days a deleted row is kept before it is purged")
+	dryRun := flag.Bool("dry-run", false, "only report what would be purged")
+	batch := flag.Int("batch", 1000, "rows deleted per statement")
+	flag.Parse()
+	if *retentionDays < 1 {
+		log.Fatal("❌ -retention-days must be at least 1")
+	}
+	if *batch < 1 {
+		log.Fatal("❌ -batch must be at least 1")
+	}
+
+	config.Load(config.DefaultEnvFile)
+
+	db, err := config.OpenDB(false)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	cutoff := time.Now().UTC().AddDate(0, 0, -*retentionDays)
+	found, total, err := countPurgeable(db, cutoff)
+	if err != nil {
+		log.Fatalf("❌ Counting deleted rows failed: %v", err)
+	}
+	for _, resource := range softdelete.PurgeOrder {
+		log.Printf("🔎 %s: %d deleted before %s", resource, found[resource], cutoff.Format(time.RFC3339))
+	}
+	if total == 0 {
+		log.Println("✅ Nothing to purge")
+		return
+	}
+	if *dryRun {
+		log.Printf("🧪 Dry run: %d rows would be purged", total)
+		return
+	}
+
+	ctx := context.Background()
+	run := jobrun.Start(db, "purge", total)
+	summary := []string{}
+	var purged int64
+	for _, resource := range softdelete.PurgeOrder {
+		var removed int64
+		for {
+			n, err := softdelete.Purge(ctx, db, softdelete.Tables[resource], cutoff, *batch)
+			if err != nil {
+				run.Finish("failed", fmt.Sprintf("%d rows purged, then %s: %v", purged, resource, err))
+				log.Fatalf("❌ Purging %s failed after %d rows: %v", resource, purged, err)
+			}
+			if n == 0 {
+				break
+			}
+			removed += n
+			purged += n
+			run.Progress(int(purged), fmt.Sprintf("%d of %d deleted rows purged", purged, total))
+		}
+		summary = append(summary, fmt.Sprintf("%s %d", resource, removed))
+	}
+
+	run.Finish("succeeded", fmt.Sprintf("%d deleted rows purged (%s)", purged, strings.Join(summary, ", ")))
+	log.Printf("🎉 Purged %d rows deleted more than %d days ago (%s)", purged, *retentionDays, strings.Join(summary, ", "))
+}
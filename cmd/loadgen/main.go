@@ -0,0 +1,45 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/YeswanthC7/bookrec/internal/config"
+)
+
+const usage = `Usage:
+  loadgen populate [flags]   bulk-insert users and interactions into the database
+  loadgen run [flags]        replay a request mix against a running server and report latencies
+
+Run "loadgen <command> -h" for the command's flags.`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "populate":
+		populate(os.Args[2:])
+	case "run":
+		run(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Println(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
+
+// openDB connects like the jobs do
+func openDB() *sql.DB {
+	config.Load(config.DefaultEnvFile)
+
+	db, err := config.OpenDB(false)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	return db
+}
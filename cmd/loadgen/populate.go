@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/YeswanthC7/bookrec/internal/counters"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// interactionActions weight what bulk interactions are: mostly views
+var interactionActions = []string{"view", "view", "view", "view", "like", "like", "rating"}
+
+// liveIDs reads the ids of query's rows
+func liveIDs(db *sql.DB, query string, args ...interface{}) ([]int64, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var found []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found = append(found, id)
+	}
+	return found, rows.Err()
+}
+
+// insertUsers adds n load-test accounts (load000001@load.bookrec.test and
+// up, after any that exist) sharing one password
+func insertUsers(db *sql.DB, n int, password string) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	var existing int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users WHERE email LIKE '%@load.bookrec.test'").Scan(&existing); err != nil {
+		return err
+	}
+	for start := 0; start < n; start += 1000 {
+		end := start + 1000
+		if end > n {
+			end = n
+		}
+		rows := make([]string, 0, end-start)
+		args := make([]interface{}, 0, 4*(end-start))
+		for i := start; i < end; i++ {
+			handle := fmt.Sprintf("load%06d", existing+i+1)
+			rows = append(rows, "(?, ?, ?, ?)")
+			args = append(args, tenant.DefaultID, handle+"@load.bookrec.test", handle, string(hashed))
+		}
+		if _, err := db.Exec("INSERT IGNORE INTO users (organization_id, email, handle, password_hash) VALUES "+
+			strings.Join(rows, ", "), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func populate(args []string) {
+	fs := flag.NewFlagSet("populate", flag.ExitOnError)
+	total := fs.Int("interactions", 1000000, "interactions to insert")
+	users := fs.Int("users", 0, "load-test accounts to add first")
+	password := fs.String("password", "bookrec-load", "password of the added accounts")
+	batch := fs.Int("batch", 2000, "rows per INSERT")
+	workers := fs.Int("workers", 4, "concurrent inserters")
+	days := fs.Int("days", 365, "spread interactions over this many past days")
+	skew := fs.Float64("skew", 1.1, "popularity skew of books (Zipf s, > 1); higher piles more onto a few books")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "random seed")
+	_ = fs.Parse(args)
+	switch {
+	case *total < 0 || *users < 0:
+		log.Fatal("❌ -interactions and -users can't be negative")
+	case *batch < 1 || *workers < 1 || *days < 1:
+		log.Fatal("❌ -batch, -workers and -days must be at least 1")
+	case *skew <= 1:
+		log.Fatal("❌ -skew must be greater than 1")
+	}
+
+	db := openDB()
+	defer func() { _ = db.Close() }()
+
+	if *users > 0 {
+		if err := insertUsers(db, *users, *password); err != nil {
+			log.Fatalf("❌ Adding users failed: %v", err)
+		}
+		log.Printf("👥 %d load-test accounts added", *users)
+	}
+
+	userIDs, err := liveIDs(db, "SELECT id FROM users WHERE organization_id = ? AND deleted_at IS NULL", tenant.DefaultID)
+	if err == nil && len(userIDs) == 0 {
+		err = fmt.Errorf("no users; pass -users or run bookrec seed first")
+	}
+	if err != nil {
+		log.Fatalf("❌ Loading users failed: %v", err)
+	}
+	bookIDs, err := liveIDs(db,
+		"SELECT id FROM books WHERE "+tenant.BooksVisibleSQL("")+" AND merged_into IS NULL ORDER BY id", tenant.DefaultID)
+	if err == nil && len(bookIDs) == 0 {
+		err = fmt.Errorf("no books; run bookrec ingest or bookrec seed first")
+	}
+	if err != nil {
+		log.Fatalf("❌ Loading books failed: %v", err)
+	}
+	log.Printf("📥 Inserting %d interactions for %d users and %d books with %d workers", *total, len(userIDs), len(bookIDs), *workers)
+
+	// batches are handed out by number so every worker stops at the total
+	var next, inserted int64
+	batches := int64((*total + *batch - 1) / *batch)
+	now := time.Now().UTC()
+	span := int64(*days) * int64(24*time.Hour)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, *workers)
+	for w := 0; w < *workers; w++ {
+		wg.Add(1)
+		go func(rng *rand.Rand) {
+			defer wg.Done()
+			zipf := rand.NewZipf(rng, *skew, 1, uint64(len(bookIDs)-1))
+			for {
+				b := atomic.AddInt64(&next, 1) - 1
+				if b >= batches {
+					return
+				}
+				n := *batch
+				if rest := *total - int(b)*(*batch); rest < n {
+					n = rest
+				}
+				rows := make([]string, n)
+				args := make([]interface{}, 0, 6*n)
+				for i := range rows {
+					action := interactionActions[rng.Intn(len(interactionActions))]
+					var rating interface{}
+					if action == "rating" {
+						rating = 1 + rng.Intn(5)
+					}
+					rows[i] = "(?, ?, ?, ?, ?, ?)"
+					args = append(args, tenant.DefaultID, userIDs[rng.Intn(len(userIDs))], bookIDs[zipf.Uint64()],
+						action, rating, now.Add(-time.Duration(rng.Int63n(span))).Truncate(time.Second))
+				}
+				// a reader has one row per book and action; repeats are skipped
+				res, err := db.Exec("INSERT IGNORE INTO interactions (organization_id, user_id, book_id, action, rating, created_at) VALUES "+
+					strings.Join(rows, ", "), args...)
+				if err != nil {
+					errs <- err
+					return
+				}
+				added, _ := res.RowsAffected()
+				if done := atomic.AddInt64(&inserted, added); done/100000 != (done-added)/100000 {
+					log.Printf("⏳ %d interactions (%.0f/s)", done, float64(done)/time.Since(start).Seconds())
+				}
+			}
+		}(rand.New(rand.NewSource(*seed + int64(w))))
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		log.Fatalf("❌ Insert failed after %d interactions: %v", atomic.LoadInt64(&inserted), err)
+	}
+
+	if err := counters.Rebuild(context.Background(), db); err != nil {
+		log.Fatalf("❌ Rebuilding book counters failed: %v", err)
+	}
+	log.Printf("🎉 Inserted %d interactions in %s", inserted, time.Since(start).Round(time.Second))
+}
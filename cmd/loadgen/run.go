@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// operations are the request kinds a mix can weight
+var operations = []string{"books", "search", "recs", "writes"}
+
+// searchTerms are what the search operation looks for
+var searchTerms = []string{"the", "love", "war", "data", "night", "star", "history", "life", "dragon", "mind"}
+
+// opStats are one operation's latencies and failures
+type opStats struct {
+	Count    int     `json:"count"`
+	Errors   int     `json:"errors"`
+	P50      float64 `json:"p50_ms"`
+	P90      float64 `json:"p90_ms"`
+	P99      float64 `json:"p99_ms"`
+	Max      float64 `json:"max_ms"`
+	PerSec   float64 `json:"per_second"`
+	samples  []time.Duration
+	firstErr string
+}
+
+// parseMix reads "books=40,search=30,recs=20,writes=10" into weights
+func parseMix(raw string) (map[string]int, error) {
+	mix := map[string]int{}
+	for _, part := range strings.Split(raw, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		n, err := strconv.Atoi(weight)
+		if !ok || err != nil || n < 0 {
+			return nil, fmt.Errorf("bad mix entry %q; want name=weight", part)
+		}
+		known := false
+		for _, op := range operations {
+			known = known || op == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown operation %q; want %s", name, strings.Join(operations, ", "))
+		}
+		mix[name] = n
+	}
+	return mix, nil
+}
+
+// percentile returns the p-th percentile of sorted in milliseconds
+func percentile(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p / 100)
+	return float64(sorted[i].Microseconds()) / 1000
+}
+
+// target is what the run's requests are about, fetched from the server
+type target struct {
+	bookIDs []string
+	userIDs []string
+	token   string
+	writer  string
+	admin   bool
+}
+
+// discover collects book and user UUIDs through the API and logs in the
+// account writes and recommendation reads are made as
+func discover(client *http.Client, base, email, password string) (*target, error) {
+	t := &target{}
+	for page := 1; page <= 10; page++ {
+		var books struct {
+			Data []struct {
+				UUID string `json:"uuid"`
+			} `json:"data"`
+		}
+		if err := getJSON(client, fmt.Sprintf("%s/books?page=%d&limit=100", base, page), &books); err != nil {
+			return nil, err
+		}
+		for _, b := range books.Data {
+			t.bookIDs = append(t.bookIDs, b.UUID)
+		}
+		if len(books.Data) < 100 {
+			break
+		}
+	}
+	for cursor := ""; ; {
+		var users struct {
+			Data []struct {
+				UUID string `json:"uuid"`
+			} `json:"data"`
+			NextCursor *string `json:"next_cursor"`
+		}
+		if err := getJSON(client, base+"/users?limit=100&cursor="+url.QueryEscape(cursor), &users); err != nil {
+			return nil, err
+		}
+		for _, u := range users.Data {
+			t.userIDs = append(t.userIDs, u.UUID)
+		}
+		if users.NextCursor == nil {
+			break
+		}
+		cursor = *users.NextCursor
+	}
+	if len(t.bookIDs) == 0 || len(t.userIDs) == 0 {
+		return nil, fmt.Errorf("the server has no books or no users; populate it first")
+	}
+
+	if email == "" {
+		return t, nil
+	}
+	resp, err := client.PostForm(base+"/login", url.Values{"email": {email}, "password": {password}})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("login as %s: %d", email, resp.StatusCode)
+	}
+	var login struct {
+		AccessToken string `json:"access_token"`
+		User        struct {
+			UUID string `json:"uuid"`
+			Role string `json:"role"`
+		} `json:"user"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&login); err != nil {
+		return nil, err
+	}
+	t.token, t.writer, t.admin = login.AccessToken, login.User.UUID, login.User.Role == "admin"
+	return t, nil
+}
+
+func getJSON(client *http.Client, u string, out interface{}) error {
+	resp, err := client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// request builds one request of op
+func (t *target) request(rng *rand.Rand, base, op string) (*http.Request, error) {
+	switch op {
+	case "books":
+		return http.NewRequest(http.MethodGet, fmt.Sprintf("%s/books?page=%d&limit=20", base, 1+rng.Intn(10)), nil)
+	case "search":
+		q := url.Values{"q": {searchTerms[rng.Intn(len(searchTerms))]}}
+		return http.NewRequest(http.MethodGet, base+"/books/search?"+q.Encode(), nil)
+	case "recs":
+		// readers may only see their own recommendations; admins anyone's
+		userID := t.writer
+		if t.admin {
+			userID = t.userIDs[rng.Intn(len(t.userIDs))]
+		}
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/recommendations/%s", base, userID), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+t.token)
+		return req, nil
+	default:
+		form := url.Values{
+			"user_id": {t.writer},
+			"book_id": {t.bookIDs[rng.Intn(len(t.bookIDs))]},
+			"action":  {"view"},
+		}
+		req, err := http.NewRequest(http.MethodPost, base+"/interactions", strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "Bearer "+t.token)
+		return req, nil
+	}
+}
+
+func run(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	base := fs.String("url", "http://localhost:8080", "server to load")
+	rawMix := fs.String("mix", "books=40,search=30,recs=20,writes=10", "relative weight of each operation: books, search, recs, writes")
+	duration := fs.Duration("duration", 30*time.Second, "how long to run")
+	concurrency := fs.Int("concurrency", 16, "requests in flight")
+	email := fs.String("email", "", "account the writes and recs are made as (required when either is > 0); an admin gets recommendations for random users")
+	password := fs.String("password", "", "password of -email")
+	asJSON := fs.Bool("json", false, "print the summary as JSON, e.g. to compare runs")
+	_ = fs.Parse(args)
+
+	mix, err := parseMix(*rawMix)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if (mix["writes"] > 0 || mix["recs"] > 0) && *email == "" {
+		log.Fatal("❌ writes and recs need -email and -password")
+	}
+	if *concurrency < 1 {
+		log.Fatal("❌ -concurrency must be at least 1")
+	}
+	// a weighted deck: drawing from it picks operations in proportion
+	deck := []string{}
+	for _, op := range operations {
+		for i := 0; i < mix[op]; i++ {
+			deck = append(deck, op)
+		}
+	}
+	if len(deck) == 0 {
+		log.Fatal("❌ the mix has no weight")
+	}
+
+	*base = strings.TrimSuffix(*base, "/")
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency},
+	}
+	t, err := discover(client, *base, *email, *password)
+	if err != nil {
+		log.Fatalf("❌ Preparing the run failed: %v", err)
+	}
+	if !*asJSON {
+		log.Printf("🚀 %s for %s at concurrency %d (%d books, %d users)", *rawMix, *duration, *concurrency, len(t.bookIDs), len(t.userIDs))
+	}
+
+	stats := map[string]*opStats{}
+	for _, op := range operations {
+		stats[op] = &opStats{}
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	deadline := time.Now().Add(*duration)
+	for w := 0; w < *concurrency; w++ {
+		wg.Add(1)
+		go func(rng *rand.Rand) {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				op := deck[rng.Intn(len(deck))]
+				req, err := t.request(rng, *base, op)
+				if err != nil {
+					log.Fatalf("❌ Building a %s request failed: %v", op, err)
+				}
+				began := time.Now()
+				resp, err := client.Do(req)
+				elapsed := time.Since(began)
+				failure := ""
+				if err != nil {
+					failure = err.Error()
+				} else {
+					_, _ = io.Copy(io.Discard, resp.Body)
+					_ = resp.Body.Close()
+					if resp.StatusCode >= 400 {
+						failure = fmt.Sprintf("%s %s: %d", req.Method, req.URL.Path, resp.StatusCode)
+					}
+				}
+
+				mu.Lock()
+				s := stats[op]
+				s.Count++
+				s.samples = append(s.samples, elapsed)
+				if failure != "" {
+					s.Errors++
+					if s.firstErr == "" {
+						s.firstErr = failure
+					}
+				}
+				mu.Unlock()
+			}
+		}(rand.New(rand.NewSource(time.Now().UnixNano() + int64(w))))
+	}
+	wg.Wait()
+
+	summary := map[string]*opStats{}
+	for _, op := range operations {
+		s := stats[op]
+		if s.Count == 0 {
+			continue
+		}
+		sort.Slice(s.samples, func(i, j int) bool { return s.samples[i] < s.samples[j] })
+		s.P50, s.P90, s.P99, s.Max = percentile(s.samples, 50), percentile(s.samples, 90),
+			percentile(s.samples, 99), percentile(s.samples, 100)
+		s.PerSec = float64(s.Count) / duration.Seconds()
+		summary[op] = s
+	}
+
+	if *asJSON {
+		_ = json.NewEncoder(os.Stdout).Encode(summary)
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "operation\trequests\terrors\treq/s\tp50 ms\tp90 ms\tp99 ms\tmax ms\t")
+	for _, op := range operations {
+		if s, ok := summary[op]; ok {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t\n", op, s.Count, s.Errors, s.PerSec, s.P50, s.P90, s.P99, s.Max)
+		}
+	}
+	_ = tw.Flush()
+	for _, op := range operations {
+		if s, ok := summary[op]; ok && s.firstErr != "" {
+			log.Printf("⚠️  %s: %d errors, first: %s", op, s.Errors, s.firstErr)
+		}
+	}
+}
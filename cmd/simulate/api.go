@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YeswanthC7/bookrec/internal/demo"
+)
+
+// apiClient signs simulated readers up and posts their interactions
+type apiClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+// postForm posts form to path and decodes a JSON answer into out (when
+// non-nil). Statuses other than want are errors.
+func (a *apiClient) postForm(path, token string, form url.Values, out interface{}, want ...int) error {
+	req, err := http.NewRequest(http.MethodPost, a.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	for _, code := range want {
+		if resp.StatusCode == code {
+			if out != nil {
+				return json.NewDecoder(resp.Body).Decode(out)
+			}
+			return nil
+		}
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("POST %s: %d %s", path, resp.StatusCode, body)
+}
+
+// signIn creates the reader's account unless it exists, then logs in
+func (a *apiClient) signIn(r *reader, password string) (string, error) {
+	if err := a.postForm("/users", "", url.Values{
+		"email": {r.Email}, "handle": {r.Handle}, "password": {password},
+	}, nil, http.StatusCreated, http.StatusConflict); err != nil {
+		return "", err
+	}
+	var login struct {
+		AccessToken string `json:"access_token"`
+		User        struct {
+			UUID string `json:"uuid"`
+		} `json:"user"`
+	}
+	if err := a.postForm("/login", "", url.Values{"email": {r.Email}, "password": {password}},
+		&login, http.StatusOK); err != nil {
+		return "", err
+	}
+	r.UUID = login.User.UUID
+	return login.AccessToken, nil
+}
+
+// writeAPI replays each reader's interactions through POST /interactions,
+// workers readers at a time. The server timestamps them, so they all land
+// now rather than spread over 90 days.
+func writeAPI(baseURL string, workers int, readers []reader, password string, interactions []demo.Interaction, bookUUIDs []string) error {
+	client := &apiClient{baseURL: baseURL, http: &http.Client{Timeout: 10 * time.Second}}
+	byReader := make([][]demo.Interaction, len(readers))
+	for _, in := range interactions {
+		byReader[in.Reader] = append(byReader[in.Reader], in)
+	}
+
+	jobs := make(chan int)
+	errs := make(chan error, len(readers))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	posted, finished := 0, 0
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				token, err := client.signIn(&readers[r], password)
+				if err != nil {
+					errs <- fmt.Errorf("%s: %w", readers[r].Handle, err)
+					continue
+				}
+				for _, in := range byReader[r] {
+					form := url.Values{
+						"user_id": {readers[r].UUID},
+						"book_id": {bookUUIDs[in.Book]},
+						"action":  {in.Action},
+					}
+					if in.Rating > 0 {
+						form.Set("rating", strconv.Itoa(in.Rating))
+					}
+					if err = client.postForm("/interactions", token, form, nil, http.StatusCreated); err != nil {
+						break
+					}
+				}
+				if err != nil {
+					errs <- fmt.Errorf("%s: %w", readers[r].Handle, err)
+					continue
+				}
+				mu.Lock()
+				posted += len(byReader[r])
+				if finished++; finished%10 == 0 {
+					log.Printf("📤 %d readers done, %d interactions posted", finished, posted)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for r := range readers {
+		jobs <- r
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	failed := 0
+	var first error
+	for err := range errs {
+		if first == nil {
+			first = err
+		}
+		failed++
+	}
+	if first != nil {
+		return fmt.Errorf("%d of %d readers failed, first: %w", failed, len(readers), first)
+	}
+	return nil
+}
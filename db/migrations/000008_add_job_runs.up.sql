@@ -0,0 +1,15 @@
+-- Progress of background jobs (ingestion, similarity build, ...).
+-- Jobs run as separate processes, so the server reads progress from here.
+CREATE TABLE IF NOT EXISTS job_runs (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  job VARCHAR(64) NOT NULL,
+  status ENUM('running', 'succeeded', 'failed') NOT NULL DEFAULT 'running',
+  processed INT NOT NULL DEFAULT 0,
+  total INT NOT NULL DEFAULT 0,
+  message VARCHAR(512) NULL,
+  started_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  finished_at DATETIME NULL,
+  INDEX idx_job_runs_job_started (job, started_at),
+  INDEX idx_job_runs_updated_at (updated_at)
+);
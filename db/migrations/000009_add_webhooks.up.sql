@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  url VARCHAR(2048) NOT NULL,
+  secret VARCHAR(255) NOT NULL,
+  -- comma-separated event types, e.g. "user.created,interaction.created" (FIND_IN_SET friendly)
+  events VARCHAR(255) NOT NULL,
+  active TINYINT(1) NOT NULL DEFAULT 1,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  webhook_id BIGINT NOT NULL,
+  event_type VARCHAR(64) NOT NULL,
+  payload JSON NOT NULL,
+  status ENUM('pending', 'succeeded', 'failed') NOT NULL DEFAULT 'pending',
+  attempts INT NOT NULL DEFAULT 0,
+  last_status_code INT NULL,
+  last_error VARCHAR(512) NULL,
+  next_attempt_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  delivered_at DATETIME NULL,
+  FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE,
+  INDEX idx_webhook_deliveries_due (status, next_attempt_at),
+  INDEX idx_webhook_deliveries_webhook (webhook_id, id)
+);
@@ -0,0 +1,2 @@
+DROP INDEX idx_books_created_at ON books;
+ALTER TABLE books DROP COLUMN created_at;
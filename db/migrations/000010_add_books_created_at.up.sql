@@ -0,0 +1,6 @@
+-- When a book entered the catalogue (feeds, freshness reporting).
+-- Existing rows get the migration time.
+ALTER TABLE books
+  ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX idx_books_created_at ON books(created_at);
@@ -0,0 +1,9 @@
+DROP INDEX uq_books_slug ON books;
+DROP INDEX uq_books_uuid ON books;
+ALTER TABLE books DROP COLUMN slug, DROP COLUMN uuid;
+
+DROP INDEX uq_interactions_uuid ON interactions;
+ALTER TABLE interactions DROP COLUMN uuid;
+
+DROP INDEX uq_users_uuid ON users;
+ALTER TABLE users DROP COLUMN uuid;
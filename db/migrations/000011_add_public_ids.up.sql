@@ -0,0 +1,25 @@
+-- Public identifiers exposed by the API; integer ids stay internal.
+-- New rows get a UUID from the column default; existing rows are backfilled.
+ALTER TABLE users
+  ADD COLUMN uuid CHAR(36) NOT NULL DEFAULT (UUID());
+UPDATE users SET uuid = UUID();
+CREATE UNIQUE INDEX uq_users_uuid ON users(uuid);
+
+ALTER TABLE interactions
+  ADD COLUMN uuid CHAR(36) NOT NULL DEFAULT (UUID());
+UPDATE interactions SET uuid = UUID();
+CREATE UNIQUE INDEX uq_interactions_uuid ON interactions(uuid);
+
+-- Book slugs are "<title words>-<first 8 chars of uuid>" and never change
+-- after creation, so shared links survive title edits and re-imports.
+ALTER TABLE books
+  ADD COLUMN uuid CHAR(36) NOT NULL DEFAULT (UUID()),
+  ADD COLUMN slug VARCHAR(255) NULL;
+UPDATE books SET uuid = UUID();
+UPDATE books
+  SET slug = CONCAT(
+    COALESCE(NULLIF(TRIM(BOTH '-' FROM LEFT(REGEXP_REPLACE(LOWER(title), '[^a-z0-9]+', '-'), 80)), ''), 'book'),
+    '-', LEFT(uuid, 8));
+ALTER TABLE books MODIFY slug VARCHAR(255) NOT NULL;
+CREATE UNIQUE INDEX uq_books_uuid ON books(uuid);
+CREATE UNIQUE INDEX uq_books_slug ON books(slug);
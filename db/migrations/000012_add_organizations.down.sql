@@ -0,0 +1,13 @@
+ALTER TABLE books DROP FOREIGN KEY fk_books_organization;
+ALTER TABLE books DROP COLUMN organization_id;
+
+ALTER TABLE interactions DROP FOREIGN KEY fk_interactions_organization;
+DROP INDEX idx_interactions_org_action_book ON interactions;
+ALTER TABLE interactions DROP COLUMN organization_id;
+
+ALTER TABLE users DROP FOREIGN KEY fk_users_organization;
+DROP INDEX uq_users_org_email ON users;
+ALTER TABLE users DROP COLUMN organization_id;
+CREATE UNIQUE INDEX email ON users(email);
+
+DROP TABLE organizations;
@@ -0,0 +1,32 @@
+-- Organizations (tenants). Everything that existed before multi-tenancy
+-- belongs to the default organization (id 1).
+CREATE TABLE organizations (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  uuid CHAR(36) NOT NULL DEFAULT (UUID()),
+  slug VARCHAR(64) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_organizations_uuid (uuid),
+  UNIQUE KEY uq_organizations_slug (slug)
+);
+
+INSERT INTO organizations (id, slug, name) VALUES (1, 'default', 'Default');
+
+-- Users belong to exactly one organization; emails are unique per tenant.
+ALTER TABLE users
+  ADD COLUMN organization_id BIGINT NOT NULL DEFAULT 1,
+  ADD CONSTRAINT fk_users_organization FOREIGN KEY (organization_id) REFERENCES organizations(id);
+ALTER TABLE users DROP INDEX email;
+CREATE UNIQUE INDEX uq_users_org_email ON users(organization_id, email);
+
+-- Interactions carry the tenant too, so popularity and co-like queries can
+-- filter without joining users.
+ALTER TABLE interactions
+  ADD COLUMN organization_id BIGINT NOT NULL DEFAULT 1,
+  ADD CONSTRAINT fk_interactions_organization FOREIGN KEY (organization_id) REFERENCES organizations(id);
+CREATE INDEX idx_interactions_org_action_book ON interactions(organization_id, action, book_id);
+
+-- NULL = shared catalogue visible to every tenant; otherwise a private title.
+ALTER TABLE books
+  ADD COLUMN organization_id BIGINT NULL,
+  ADD CONSTRAINT fk_books_organization FOREIGN KEY (organization_id) REFERENCES organizations(id);
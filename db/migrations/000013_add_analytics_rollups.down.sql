@@ -0,0 +1,7 @@
+DROP INDEX idx_interactions_created_at ON interactions;
+DROP INDEX idx_users_created_at ON users;
+
+DROP TABLE analytics_daily_genres;
+DROP TABLE analytics_daily_active_users;
+DROP TABLE analytics_daily_interactions;
+DROP TABLE analytics_daily_signups;
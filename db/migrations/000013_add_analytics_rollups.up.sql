@@ -0,0 +1,37 @@
+-- Daily rollups behind GET /admin/analytics, written by cmd/jobs/analytics.
+-- The endpoint only reads these, so dashboards never scan interactions.
+CREATE TABLE analytics_daily_signups (
+  organization_id BIGINT NOT NULL,
+  day DATE NOT NULL,
+  signups INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (organization_id, day)
+);
+
+CREATE TABLE analytics_daily_interactions (
+  organization_id BIGINT NOT NULL,
+  day DATE NOT NULL,
+  action ENUM('view', 'like', 'rating') NOT NULL,
+  interactions INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (organization_id, day, action)
+);
+
+-- dau = distinct users active on day; wau = distinct users in the 7 days ending on day
+CREATE TABLE analytics_daily_active_users (
+  organization_id BIGINT NOT NULL,
+  day DATE NOT NULL,
+  dau INT NOT NULL DEFAULT 0,
+  wau INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (organization_id, day)
+);
+
+-- interactions per subject of the book interacted with (books.subjects entries)
+CREATE TABLE analytics_daily_genres (
+  organization_id BIGINT NOT NULL,
+  day DATE NOT NULL,
+  genre VARCHAR(255) NOT NULL,
+  interactions INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (organization_id, day, genre)
+);
+
+CREATE INDEX idx_users_created_at ON users(created_at);
+CREATE INDEX idx_interactions_created_at ON interactions(created_at);
@@ -0,0 +1,10 @@
+CREATE TABLE analytics_daily_interactions (
+  organization_id BIGINT NOT NULL,
+  day DATE NOT NULL,
+  action ENUM('view', 'like', 'rating') NOT NULL,
+  interactions INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (organization_id, day, action)
+);
+
+DROP TABLE book_daily_stats;
+DROP TABLE daily_stats;
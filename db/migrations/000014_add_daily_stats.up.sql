@@ -0,0 +1,28 @@
+-- Daily interaction rollups written by cmd/jobs/analytics. daily_stats
+-- supersedes analytics_daily_interactions (counts per action become columns).
+CREATE TABLE daily_stats (
+  organization_id BIGINT NOT NULL,
+  day DATE NOT NULL,
+  views INT NOT NULL DEFAULT 0,
+  likes INT NOT NULL DEFAULT 0,
+  ratings INT NOT NULL DEFAULT 0,
+  unique_users INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (organization_id, day)
+);
+
+-- Per-book engagement; trending and the genre rollup read from here.
+CREATE TABLE book_daily_stats (
+  organization_id BIGINT NOT NULL,
+  day DATE NOT NULL,
+  book_id BIGINT NOT NULL,
+  views INT NOT NULL DEFAULT 0,
+  likes INT NOT NULL DEFAULT 0,
+  ratings INT NOT NULL DEFAULT 0,
+  rating_sum INT NOT NULL DEFAULT 0,
+  unique_users INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (organization_id, day, book_id),
+  INDEX idx_book_daily_stats_book_day (book_id, day),
+  CONSTRAINT fk_book_daily_stats_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+
+DROP TABLE analytics_daily_interactions;
@@ -0,0 +1,12 @@
+-- Social graph: follower_id follows followee_id. Both users belong to the
+-- same organization (enforced by the API, which resolves users per tenant).
+CREATE TABLE follows (
+  follower_id BIGINT NOT NULL,
+  followee_id BIGINT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (follower_id, followee_id),
+  INDEX idx_follows_followee (followee_id, created_at),
+  CONSTRAINT fk_follows_follower FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_follows_followee FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT chk_follows_not_self CHECK (follower_id <> followee_id)
+);
@@ -0,0 +1,2 @@
+DROP INDEX idx_interactions_user_created ON interactions;
+ALTER TABLE interactions DROP COLUMN visibility;
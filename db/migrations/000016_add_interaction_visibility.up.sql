@@ -0,0 +1,7 @@
+-- Whether an interaction may appear in followers' activity feeds (GET /feed).
+-- Views never do; 'private' hides a like or rating as well.
+ALTER TABLE interactions
+  ADD COLUMN visibility ENUM('public', 'private') NOT NULL DEFAULT 'public' AFTER rating;
+
+-- GET /feed walks each followee's interactions newest first
+CREATE INDEX idx_interactions_user_created ON interactions(user_id, created_at, id);
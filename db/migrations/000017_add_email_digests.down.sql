@@ -0,0 +1,7 @@
+DROP TABLE digest_send_books;
+DROP TABLE digest_sends;
+
+ALTER TABLE users
+  DROP INDEX uq_users_digest_unsubscribe_token,
+  DROP COLUMN digest_unsubscribe_token,
+  DROP COLUMN email_digest;
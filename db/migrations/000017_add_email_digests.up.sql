@@ -0,0 +1,29 @@
+-- Weekly recommendation digest (cmd/jobs/digest). Users opt in; every email
+-- carries the user's unsubscribe token, which is reset on each opt-in.
+ALTER TABLE users
+  ADD COLUMN email_digest BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN digest_unsubscribe_token VARCHAR(64) NULL,
+  ADD UNIQUE KEY uq_users_digest_unsubscribe_token (digest_unsubscribe_token);
+
+-- One row per attempted digest; failed sends are retried on the next run.
+CREATE TABLE digest_sends (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  status ENUM('sent', 'failed') NOT NULL,
+  provider VARCHAR(32) NOT NULL,
+  error TEXT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_digest_sends_user_created (user_id, created_at),
+  CONSTRAINT fk_digest_sends_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Books recommended in a sent digest, so later digests only carry fresh ones.
+CREATE TABLE digest_send_books (
+  send_id BIGINT NOT NULL,
+  book_id BIGINT NOT NULL,
+  position TINYINT NOT NULL,
+  PRIMARY KEY (send_id, book_id),
+  INDEX idx_digest_send_books_book (book_id),
+  CONSTRAINT fk_digest_send_books_send FOREIGN KEY (send_id) REFERENCES digest_sends(id) ON DELETE CASCADE,
+  CONSTRAINT fk_digest_send_books_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
@@ -0,0 +1,27 @@
+-- User-owned reading lists (distinct from shelves): an ordered set of books.
+-- Positions are 1-based and kept contiguous by the API.
+CREATE TABLE lists (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  uuid CHAR(36) NOT NULL DEFAULT (UUID()),
+  organization_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_lists_uuid (uuid),
+  INDEX idx_lists_user_updated (user_id, updated_at),
+  CONSTRAINT fk_lists_organization FOREIGN KEY (organization_id) REFERENCES organizations(id),
+  CONSTRAINT fk_lists_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE list_items (
+  list_id BIGINT NOT NULL,
+  book_id BIGINT NOT NULL,
+  position INT NOT NULL,
+  added_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (list_id, book_id),
+  INDEX idx_list_items_position (list_id, position),
+  INDEX idx_list_items_book (book_id),
+  CONSTRAINT fk_list_items_list FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE,
+  CONSTRAINT fk_list_items_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
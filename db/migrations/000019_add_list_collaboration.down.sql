@@ -0,0 +1,2 @@
+DROP TABLE list_events;
+DROP TABLE list_members;
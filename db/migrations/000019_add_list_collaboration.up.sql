@@ -0,0 +1,33 @@
+-- Co-editors of reading lists. The owner stays on lists.user_id; members are
+-- invited by handle ('invited') and join by accepting ('active').
+--   editor  – add, move and remove books
+--   manager – also rename the list and manage members
+CREATE TABLE list_members (
+  list_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  permission ENUM('editor', 'manager') NOT NULL DEFAULT 'editor',
+  status ENUM('invited', 'active') NOT NULL DEFAULT 'invited',
+  invited_by BIGINT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  accepted_at TIMESTAMP NULL,
+  PRIMARY KEY (list_id, user_id),
+  INDEX idx_list_members_user (user_id, status),
+  CONSTRAINT fk_list_members_list FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE,
+  CONSTRAINT fk_list_members_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_list_members_inviter FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Edit history: one row per change, whoever made it.
+CREATE TABLE list_events (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  list_id BIGINT NOT NULL,
+  user_id BIGINT NULL,
+  action VARCHAR(32) NOT NULL,
+  book_id BIGINT NULL,
+  detail JSON NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_list_events_list (list_id, id),
+  CONSTRAINT fk_list_events_list FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE,
+  CONSTRAINT fk_list_events_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+  CONSTRAINT fk_list_events_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE SET NULL
+);
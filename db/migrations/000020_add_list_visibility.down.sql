@@ -0,0 +1,4 @@
+ALTER TABLE lists
+  DROP INDEX uq_lists_share_token,
+  DROP COLUMN share_token,
+  DROP COLUMN visibility;
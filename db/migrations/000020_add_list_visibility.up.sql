@@ -0,0 +1,9 @@
+-- Who can read a list:
+--   public   – anyone in the organization; shown on the owner's profile
+--   unlisted – only through its share link (and co-editors)
+--   private  – only the owner and co-editors; share links stop working
+-- Existing lists stay public, as they were readable by everyone before.
+ALTER TABLE lists
+  ADD COLUMN visibility ENUM('private', 'unlisted', 'public') NOT NULL DEFAULT 'public' AFTER name,
+  ADD COLUMN share_token VARCHAR(64) NULL AFTER visibility,
+  ADD UNIQUE KEY uq_lists_share_token (share_token);
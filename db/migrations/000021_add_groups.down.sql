@@ -0,0 +1,6 @@
+DROP TABLE discussion_posts;
+DROP TABLE discussion_threads;
+DROP TABLE group_meetings;
+DROP TABLE group_picks;
+DROP TABLE group_members;
+DROP TABLE reading_groups;
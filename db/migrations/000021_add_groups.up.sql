@@ -0,0 +1,91 @@
+-- Book clubs. GROUPS is a reserved word in MySQL 8, hence reading_groups.
+-- Anyone in the organization can join. The creator owns the group
+-- (owner_id) and is also its first admin member; admins run the club
+-- (picks, schedule, membership).
+CREATE TABLE reading_groups (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  uuid CHAR(36) NOT NULL DEFAULT (UUID()),
+  organization_id BIGINT NOT NULL,
+  owner_id BIGINT NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  description TEXT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_reading_groups_uuid (uuid),
+  INDEX idx_reading_groups_org (organization_id, created_at),
+  CONSTRAINT fk_reading_groups_organization FOREIGN KEY (organization_id) REFERENCES organizations(id),
+  CONSTRAINT fk_reading_groups_owner FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE group_members (
+  group_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  role ENUM('member', 'admin') NOT NULL DEFAULT 'member',
+  joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (group_id, user_id),
+  INDEX idx_group_members_user (user_id),
+  CONSTRAINT fk_group_members_group FOREIGN KEY (group_id) REFERENCES reading_groups(id) ON DELETE CASCADE,
+  CONSTRAINT fk_group_members_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Books the group reads. At most one is 'current' (kept so by the API);
+-- choosing a new pick moves the old one to 'past'.
+CREATE TABLE group_picks (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  group_id BIGINT NOT NULL,
+  book_id BIGINT NOT NULL,
+  status ENUM('current', 'past') NOT NULL DEFAULT 'current',
+  picked_by BIGINT NULL,
+  starts_on DATE NULL,
+  ends_on DATE NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_group_picks_group (group_id, status, id),
+  CONSTRAINT fk_group_picks_group FOREIGN KEY (group_id) REFERENCES reading_groups(id) ON DELETE CASCADE,
+  CONSTRAINT fk_group_picks_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+  CONSTRAINT fk_group_picks_user FOREIGN KEY (picked_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- The club's schedule: meetings, optionally about a pick.
+CREATE TABLE group_meetings (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  group_id BIGINT NOT NULL,
+  pick_id BIGINT NULL,
+  title VARCHAR(200) NOT NULL,
+  starts_at DATETIME NOT NULL,
+  location VARCHAR(255) NULL,
+  created_by BIGINT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_group_meetings_group (group_id, starts_at),
+  CONSTRAINT fk_group_meetings_group FOREIGN KEY (group_id) REFERENCES reading_groups(id) ON DELETE CASCADE,
+  CONSTRAINT fk_group_meetings_pick FOREIGN KEY (pick_id) REFERENCES group_picks(id) ON DELETE SET NULL,
+  CONSTRAINT fk_group_meetings_user FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Discussion threads, scoped to a group for now. A thread's first post is
+-- its opening message.
+CREATE TABLE discussion_threads (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  uuid CHAR(36) NOT NULL DEFAULT (UUID()),
+  organization_id BIGINT NOT NULL,
+  group_id BIGINT NOT NULL,
+  user_id BIGINT NULL,
+  title VARCHAR(200) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  last_post_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_discussion_threads_uuid (uuid),
+  INDEX idx_discussion_threads_group (group_id, last_post_at),
+  CONSTRAINT fk_discussion_threads_organization FOREIGN KEY (organization_id) REFERENCES organizations(id),
+  CONSTRAINT fk_discussion_threads_group FOREIGN KEY (group_id) REFERENCES reading_groups(id) ON DELETE CASCADE,
+  CONSTRAINT fk_discussion_threads_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE discussion_posts (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  thread_id BIGINT NOT NULL,
+  user_id BIGINT NULL,
+  body TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_discussion_posts_thread (thread_id, id),
+  CONSTRAINT fk_discussion_posts_thread FOREIGN KEY (thread_id) REFERENCES discussion_threads(id) ON DELETE CASCADE,
+  CONSTRAINT fk_discussion_posts_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
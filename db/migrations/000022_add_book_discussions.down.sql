@@ -0,0 +1,17 @@
+ALTER TABLE discussion_posts
+  DROP FOREIGN KEY fk_discussion_posts_deleted_by,
+  DROP COLUMN deleted_by,
+  DROP COLUMN deleted_at,
+  DROP COLUMN spoiler;
+
+DELETE FROM discussion_threads WHERE group_id IS NULL;
+
+ALTER TABLE discussion_threads
+  DROP FOREIGN KEY fk_discussion_threads_deleted_by,
+  DROP FOREIGN KEY fk_discussion_threads_book,
+  DROP INDEX idx_discussion_threads_book,
+  DROP COLUMN deleted_by,
+  DROP COLUMN deleted_at,
+  DROP COLUMN spoiler,
+  DROP COLUMN book_id,
+  MODIFY group_id BIGINT NOT NULL;
@@ -0,0 +1,20 @@
+-- Discussion threads about a book, separate from reviews. A thread belongs
+-- to either a group or a book (set by the API; MySQL won't CHECK columns
+-- that have cascading foreign keys).
+-- spoiler flags threads and posts that discuss plot points. Moderators
+-- soft-delete: rows stay so reply order and counts hold, bodies are hidden.
+ALTER TABLE discussion_threads
+  MODIFY group_id BIGINT NULL,
+  ADD COLUMN book_id BIGINT NULL AFTER group_id,
+  ADD COLUMN spoiler BOOLEAN NOT NULL DEFAULT FALSE AFTER title,
+  ADD COLUMN deleted_at TIMESTAMP NULL,
+  ADD COLUMN deleted_by BIGINT NULL,
+  ADD INDEX idx_discussion_threads_book (book_id, last_post_at),
+  ADD CONSTRAINT fk_discussion_threads_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+  ADD CONSTRAINT fk_discussion_threads_deleted_by FOREIGN KEY (deleted_by) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE discussion_posts
+  ADD COLUMN spoiler BOOLEAN NOT NULL DEFAULT FALSE AFTER body,
+  ADD COLUMN deleted_at TIMESTAMP NULL,
+  ADD COLUMN deleted_by BIGINT NULL,
+  ADD CONSTRAINT fk_discussion_posts_deleted_by FOREIGN KEY (deleted_by) REFERENCES users(id) ON DELETE SET NULL;
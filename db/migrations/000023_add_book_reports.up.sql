@@ -0,0 +1,28 @@
+-- Reader reports of bad catalogue data, worked through by admins.
+-- duplicate_of points at the book a 'duplicate' report thinks this one
+-- copies. Resolving closes the report; the fix itself goes through the
+-- catalogue edit endpoints.
+CREATE TABLE book_reports (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  uuid CHAR(36) NOT NULL DEFAULT (UUID()),
+  organization_id BIGINT NOT NULL,
+  book_id BIGINT NOT NULL,
+  user_id BIGINT NULL,
+  reason ENUM('wrong_author', 'wrong_cover', 'duplicate', 'spam') NOT NULL,
+  details TEXT NULL,
+  duplicate_of BIGINT NULL,
+  status ENUM('open', 'resolved', 'dismissed') NOT NULL DEFAULT 'open',
+  resolution VARCHAR(32) NULL,
+  resolution_note TEXT NULL,
+  resolved_by BIGINT NULL,
+  resolved_at TIMESTAMP NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_book_reports_uuid (uuid),
+  INDEX idx_book_reports_queue (organization_id, status, created_at),
+  INDEX idx_book_reports_book (book_id, status),
+  CONSTRAINT fk_book_reports_organization FOREIGN KEY (organization_id) REFERENCES organizations(id),
+  CONSTRAINT fk_book_reports_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+  CONSTRAINT fk_book_reports_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+  CONSTRAINT fk_book_reports_duplicate FOREIGN KEY (duplicate_of) REFERENCES books(id) ON DELETE SET NULL,
+  CONSTRAINT fk_book_reports_resolver FOREIGN KEY (resolved_by) REFERENCES users(id) ON DELETE SET NULL
+);
@@ -0,0 +1,3 @@
+ALTER TABLE discussion_posts DROP COLUMN hidden_at;
+ALTER TABLE discussion_threads DROP COLUMN hidden_at;
+DROP TABLE content_reports;
@@ -0,0 +1,24 @@
+-- Reader reports of abusive discussion content (threads and posts). Once
+-- enough open reports pile up on one target it's hidden (hidden_at) until a
+-- moderator removes it or restores it. A reader reports a target once.
+CREATE TABLE content_reports (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  organization_id BIGINT NOT NULL,
+  target_type ENUM('thread', 'post') NOT NULL,
+  target_id BIGINT NOT NULL,
+  user_id BIGINT NULL,
+  reason ENUM('spam', 'harassment', 'hate', 'spoiler', 'off_topic', 'other') NOT NULL,
+  details TEXT NULL,
+  status ENUM('open', 'upheld', 'dismissed') NOT NULL DEFAULT 'open',
+  resolved_by BIGINT NULL,
+  resolved_at TIMESTAMP NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_content_reports_reporter (target_type, target_id, user_id),
+  INDEX idx_content_reports_queue (organization_id, status, created_at),
+  CONSTRAINT fk_content_reports_organization FOREIGN KEY (organization_id) REFERENCES organizations(id),
+  CONSTRAINT fk_content_reports_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+  CONSTRAINT fk_content_reports_resolver FOREIGN KEY (resolved_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+ALTER TABLE discussion_threads ADD COLUMN hidden_at TIMESTAMP NULL;
+ALTER TABLE discussion_posts ADD COLUMN hidden_at TIMESTAMP NULL;
@@ -0,0 +1,2 @@
+DROP INDEX idx_interactions_org_created ON interactions;
+ALTER TABLE users DROP COLUMN leaderboard_opt_out;
@@ -0,0 +1,6 @@
+-- Readers who'd rather not appear on GET /leaderboard
+ALTER TABLE users
+  ADD COLUMN leaderboard_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- the leaderboard scans a tenant's interactions by time
+CREATE INDEX idx_interactions_org_created ON interactions(organization_id, created_at);
@@ -0,0 +1,15 @@
+-- Frozen copies of a user's recommendations, readable by anyone with the
+-- token (GET /recommendations/shared/{token}). items is the list as it was
+-- when shared; later likes don't change it.
+CREATE TABLE recommendation_snapshots (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  organization_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  token VARCHAR(64) NOT NULL,
+  items JSON NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_recommendation_snapshots_token (token),
+  INDEX idx_recommendation_snapshots_user (user_id),
+  CONSTRAINT fk_recommendation_snapshots_organization FOREIGN KEY (organization_id) REFERENCES organizations(id),
+  CONSTRAINT fk_recommendation_snapshots_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
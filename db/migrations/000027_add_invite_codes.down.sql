@@ -0,0 +1,7 @@
+ALTER TABLE users
+  DROP FOREIGN KEY fk_users_invite_code,
+  DROP FOREIGN KEY fk_users_invited_by,
+  DROP COLUMN invite_code_id,
+  DROP COLUMN invited_by;
+
+DROP TABLE invite_codes;
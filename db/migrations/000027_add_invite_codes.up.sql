@@ -0,0 +1,22 @@
+-- Invite codes handed out by users; redeeming one at signup records who
+-- brought the new account in (users.invited_by). With SIGNUP_INVITE_ONLY=true
+-- signing up requires a code with uses left.
+CREATE TABLE invite_codes (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  organization_id BIGINT NOT NULL,
+  code VARCHAR(32) NOT NULL,
+  inviter_id BIGINT NOT NULL,
+  max_uses INT NOT NULL DEFAULT 1,
+  uses INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_invite_codes_code (organization_id, code),
+  INDEX idx_invite_codes_inviter (inviter_id),
+  CONSTRAINT fk_invite_codes_organization FOREIGN KEY (organization_id) REFERENCES organizations(id),
+  CONSTRAINT fk_invite_codes_inviter FOREIGN KEY (inviter_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+ALTER TABLE users
+  ADD COLUMN invited_by BIGINT NULL,
+  ADD COLUMN invite_code_id BIGINT NULL,
+  ADD CONSTRAINT fk_users_invited_by FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL,
+  ADD CONSTRAINT fk_users_invite_code FOREIGN KEY (invite_code_id) REFERENCES invite_codes(id) ON DELETE SET NULL;
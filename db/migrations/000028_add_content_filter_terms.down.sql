@@ -0,0 +1 @@
+DROP TABLE content_filter_terms;
@@ -0,0 +1,15 @@
+-- Per-organization denylist for user-written text (handles, list and club
+-- names, discussion titles and posts), managed by admins. word terms match
+-- whole words; substring terms match anywhere. The server also applies the
+-- deployment-wide CONTENT_FILTER_TERMS.
+CREATE TABLE content_filter_terms (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  organization_id BIGINT NOT NULL,
+  term VARCHAR(100) NOT NULL,
+  match_mode ENUM('word', 'substring') NOT NULL DEFAULT 'word',
+  created_by BIGINT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_content_filter_terms_term (organization_id, term),
+  CONSTRAINT fk_content_filter_terms_organization FOREIGN KEY (organization_id) REFERENCES organizations(id),
+  CONSTRAINT fk_content_filter_terms_created_by FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
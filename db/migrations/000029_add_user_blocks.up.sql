@@ -0,0 +1,12 @@
+-- blocker_id has blocked blocked_id: neither can follow the other, and the
+-- blocker no longer sees the blocked user's discussion threads or posts.
+CREATE TABLE user_blocks (
+  blocker_id BIGINT NOT NULL,
+  blocked_id BIGINT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (blocker_id, blocked_id),
+  INDEX idx_user_blocks_blocked (blocked_id),
+  CONSTRAINT fk_user_blocks_blocker FOREIGN KEY (blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_user_blocks_blocked FOREIGN KEY (blocked_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT chk_user_blocks_not_self CHECK (blocker_id <> blocked_id)
+);
@@ -0,0 +1,15 @@
+-- Clicks on purchase/borrow links, recorded by GET /out/{book_id}/{vendor}
+-- before redirecting. user_id is set when the click carried a token.
+CREATE TABLE outbound_clicks (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  organization_id BIGINT NOT NULL,
+  book_id BIGINT NOT NULL,
+  vendor VARCHAR(50) NOT NULL,
+  user_id BIGINT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_outbound_clicks_org_created (organization_id, created_at),
+  INDEX idx_outbound_clicks_book (book_id),
+  CONSTRAINT fk_outbound_clicks_organization FOREIGN KEY (organization_id) REFERENCES organizations(id),
+  CONSTRAINT fk_outbound_clicks_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+  CONSTRAINT fk_outbound_clicks_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
@@ -0,0 +1,8 @@
+-- Formats a book is available in, from edition data (ingest) or admin edits.
+-- An empty set means unknown; format filters leave those books out.
+ALTER TABLE books
+  ADD COLUMN formats SET('print', 'ebook', 'audiobook') NOT NULL DEFAULT '';
+
+-- Open Library catalogues printed editions, so ingested books have print
+-- editions; the next ingest run fills in ebooks and audiobooks.
+UPDATE books SET formats = 'print' WHERE open_library_key IS NOT NULL;
@@ -0,0 +1,4 @@
+-- Typical page count across editions (Open Library's median), for reading
+-- time estimates and length filters. NULL when unknown.
+ALTER TABLE books
+  ADD COLUMN page_count INT NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE users
+  DROP COLUMN max_audience_rating,
+  DROP COLUMN avoid_content_warnings;
+ALTER TABLE books
+  DROP COLUMN content_warnings_curated,
+  DROP COLUMN audience_rating,
+  DROP COLUMN content_warnings;
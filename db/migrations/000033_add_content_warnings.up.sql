@@ -0,0 +1,12 @@
+-- Content warnings and audience rating per book. Ingest derives both from
+-- subjects unless an admin has curated them (content_warnings_curated).
+-- Keep the values in step with internal/contentwarnings.
+ALTER TABLE books
+  ADD COLUMN content_warnings SET('violence', 'sexual_content', 'sexual_violence', 'abuse', 'self_harm', 'substance_abuse', 'war', 'horror') NOT NULL DEFAULT '',
+  ADD COLUMN audience_rating ENUM('children', 'teen', 'adult') NULL,
+  ADD COLUMN content_warnings_curated BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- What readers want kept out of their recommendations
+ALTER TABLE users
+  ADD COLUMN avoid_content_warnings SET('violence', 'sexual_content', 'sexual_violence', 'abuse', 'self_harm', 'substance_abuse', 'war', 'horror') NOT NULL DEFAULT '',
+  ADD COLUMN max_audience_rating ENUM('children', 'teen', 'adult') NULL;
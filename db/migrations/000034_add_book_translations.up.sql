@@ -0,0 +1,11 @@
+-- Translated book metadata, served to readers whose Accept-Language (or the
+-- deployment's DEFAULT_LANGUAGE) asks for it. language is a BCP 47 tag.
+CREATE TABLE book_translations (
+  book_id BIGINT NOT NULL,
+  language VARCHAR(35) NOT NULL,
+  title VARCHAR(512) NOT NULL,
+  description TEXT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (book_id, language),
+  CONSTRAINT fk_book_translations_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
@@ -0,0 +1,9 @@
+-- ISBN-13s of editions we've seen, mapped onto the (work-level) book. Filled
+-- by GET /lookup/isbn as barcodes are scanned.
+CREATE TABLE book_isbns (
+  isbn13 CHAR(13) NOT NULL PRIMARY KEY,
+  book_id BIGINT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_book_isbns_book (book_id),
+  CONSTRAINT fk_book_isbns_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
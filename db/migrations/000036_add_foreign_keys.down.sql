@@ -0,0 +1,12 @@
+ALTER TABLE analytics_daily_genres DROP FOREIGN KEY fk_analytics_daily_genres_organization;
+ALTER TABLE analytics_daily_active_users DROP FOREIGN KEY fk_analytics_daily_active_users_organization;
+ALTER TABLE analytics_daily_signups DROP FOREIGN KEY fk_analytics_daily_signups_organization;
+ALTER TABLE book_daily_stats DROP FOREIGN KEY fk_book_daily_stats_organization;
+ALTER TABLE daily_stats DROP FOREIGN KEY fk_daily_stats_organization;
+
+ALTER TABLE interactions
+  DROP FOREIGN KEY fk_interactions_user,
+  DROP FOREIGN KEY fk_interactions_book;
+ALTER TABLE interactions
+  ADD CONSTRAINT interactions_ibfk_1 FOREIGN KEY (user_id) REFERENCES users(id),
+  ADD CONSTRAINT interactions_ibfk_2 FOREIGN KEY (book_id) REFERENCES books(id);
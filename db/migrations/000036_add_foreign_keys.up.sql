@@ -0,0 +1,23 @@
+-- Constraints the schema was missing. Run cmd/jobs/integrity -repair first:
+-- adding a foreign key fails while orphaned rows exist.
+
+-- interactions kept the unnamed foreign keys from 000002, which block
+-- deleting a user or book; name them and cascade like the newer tables.
+ALTER TABLE interactions
+  DROP FOREIGN KEY interactions_ibfk_1,
+  DROP FOREIGN KEY interactions_ibfk_2;
+ALTER TABLE interactions
+  ADD CONSTRAINT fk_interactions_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  ADD CONSTRAINT fk_interactions_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE;
+
+-- Rollups were keyed by organization without referencing it.
+ALTER TABLE daily_stats
+  ADD CONSTRAINT fk_daily_stats_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE book_daily_stats
+  ADD CONSTRAINT fk_book_daily_stats_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE analytics_daily_signups
+  ADD CONSTRAINT fk_analytics_daily_signups_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE analytics_daily_active_users
+  ADD CONSTRAINT fk_analytics_daily_active_users_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE analytics_daily_genres
+  ADD CONSTRAINT fk_analytics_daily_genres_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
@@ -0,0 +1,24 @@
+-- Running totals per tenant and book, updated in the same transaction as
+-- the interaction they count (internal/counters). Popular books and
+-- avg_rating read these instead of aggregating interactions.
+CREATE TABLE book_counters (
+  organization_id BIGINT NOT NULL,
+  book_id BIGINT NOT NULL,
+  likes INT NOT NULL DEFAULT 0,
+  ratings INT NOT NULL DEFAULT 0,
+  rating_sum INT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (organization_id, book_id),
+  INDEX idx_book_counters_likes (organization_id, likes),
+  CONSTRAINT fk_book_counters_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+  CONSTRAINT fk_book_counters_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+
+INSERT INTO book_counters (organization_id, book_id, likes, ratings, rating_sum)
+SELECT organization_id, book_id,
+       SUM(action = 'like'),
+       SUM(action = 'rating' AND rating IS NOT NULL),
+       COALESCE(SUM(CASE WHEN action = 'rating' THEN rating END), 0)
+FROM interactions
+WHERE action IN ('like', 'rating')
+GROUP BY organization_id, book_id;
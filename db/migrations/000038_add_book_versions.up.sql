@@ -0,0 +1,4 @@
+-- Bumped by every catalogue edit. GET /books/{id} serves it as the ETag and
+-- edits must present it (If-Match, or version in a batch item), so two
+-- admins editing the same book can't silently overwrite each other.
+ALTER TABLE books ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,6 @@
+DROP TABLE book_merges;
+
+ALTER TABLE books
+  DROP FOREIGN KEY fk_books_merged_into,
+  DROP COLUMN merged_at,
+  DROP COLUMN merged_into;
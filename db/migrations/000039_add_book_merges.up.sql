@@ -0,0 +1,29 @@
+-- A merged book stays in place (old links, reports and history still point
+-- at it) but drops out of listings; merged_into is the surviving record.
+ALTER TABLE books
+  ADD COLUMN merged_into BIGINT NULL,
+  ADD COLUMN merged_at TIMESTAMP NULL,
+  ADD CONSTRAINT fk_books_merged_into FOREIGN KEY (merged_into) REFERENCES books(id) ON DELETE SET NULL;
+
+-- One row per POST /admin/books/{id}/merge. moved lists exactly what was
+-- re-pointed (interaction ids, lists, dropped duplicate list items, ISBNs,
+-- translations) so POST /admin/book-merges/{id}/undo can put it back.
+CREATE TABLE book_merges (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  uuid CHAR(36) NOT NULL DEFAULT (UUID()),
+  organization_id BIGINT NOT NULL,
+  source_id BIGINT NOT NULL,
+  target_id BIGINT NOT NULL,
+  merged_by BIGINT NULL,
+  moved JSON NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  undone_by BIGINT NULL,
+  undone_at TIMESTAMP NULL,
+  UNIQUE KEY uq_book_merges_uuid (uuid),
+  INDEX idx_book_merges_source (source_id),
+  CONSTRAINT fk_book_merges_organization FOREIGN KEY (organization_id) REFERENCES organizations(id),
+  CONSTRAINT fk_book_merges_source FOREIGN KEY (source_id) REFERENCES books(id) ON DELETE CASCADE,
+  CONSTRAINT fk_book_merges_target FOREIGN KEY (target_id) REFERENCES books(id) ON DELETE CASCADE,
+  CONSTRAINT fk_book_merges_merged_by FOREIGN KEY (merged_by) REFERENCES users(id) ON DELETE SET NULL,
+  CONSTRAINT fk_book_merges_undone_by FOREIGN KEY (undone_by) REFERENCES users(id) ON DELETE SET NULL
+);
@@ -0,0 +1,4 @@
+ALTER TABLE users
+  DROP FOREIGN KEY fk_users_merged_into,
+  DROP COLUMN merged_into,
+  DROP COLUMN disabled_at;
@@ -0,0 +1,7 @@
+-- A merged account stays in place (its history, reports and posts still
+-- point at it) but can no longer sign in; merged_into is the surviving
+-- account.
+ALTER TABLE users
+  ADD COLUMN disabled_at TIMESTAMP NULL,
+  ADD COLUMN merged_into BIGINT NULL,
+  ADD CONSTRAINT fk_users_merged_into FOREIGN KEY (merged_into) REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,16 @@
+-- Old references and what they point at now: a merged book's slug, UUID
+-- and ID, a book's previous slugs, a merged user's UUID and ID. GET on an
+-- old reference answers with a redirect to the target's current one.
+-- target_id is a books or users id depending on resource, so it has no
+-- foreign key (the integrity job checks it).
+CREATE TABLE redirects (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  organization_id BIGINT NULL,
+  resource ENUM('book', 'user') NOT NULL,
+  old_ref VARCHAR(255) NOT NULL,
+  target_id BIGINT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_redirects_ref (resource, old_ref),
+  INDEX idx_redirects_target (resource, target_id),
+  CONSTRAINT fk_redirects_organization FOREIGN KEY (organization_id) REFERENCES organizations(id)
+);
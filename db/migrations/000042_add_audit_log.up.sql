@@ -0,0 +1,29 @@
+-- One row per admin mutation, written in the mutation's transaction.
+-- before_snapshot / after_snapshot are JSON snapshots of the target row
+-- (NULL before a create and after a delete). actor_email is copied so
+-- entries survive the actor being deleted; actor_id has no foreign key for
+-- the same reason.
+CREATE TABLE audit_log (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  uuid CHAR(36) NOT NULL DEFAULT (UUID()),
+  organization_id BIGINT NOT NULL,
+  actor_id BIGINT NULL,
+  actor_email VARCHAR(255) NULL,
+  action VARCHAR(64) NOT NULL,
+  target_type VARCHAR(32) NOT NULL,
+  target_id BIGINT NOT NULL,
+  before_snapshot JSON NULL,
+  after_snapshot JSON NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_audit_log_uuid (uuid),
+  INDEX idx_audit_log_org_created (organization_id, created_at),
+  INDEX idx_audit_log_target (target_type, target_id),
+  INDEX idx_audit_log_actor (actor_id, created_at),
+  CONSTRAINT fk_audit_log_organization FOREIGN KEY (organization_id) REFERENCES organizations(id)
+);
+
+-- append-only: entries can't be changed or removed through SQL
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+  FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+  FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
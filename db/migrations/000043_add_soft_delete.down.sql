@@ -0,0 +1,20 @@
+ALTER TABLE interactions
+  DROP FOREIGN KEY fk_interactions_deleted_by,
+  DROP INDEX idx_interactions_deleted_at,
+  DROP COLUMN deleted_by,
+  DROP COLUMN deleted_at;
+ALTER TABLE lists
+  DROP FOREIGN KEY fk_lists_deleted_by,
+  DROP INDEX idx_lists_deleted_at,
+  DROP COLUMN deleted_by,
+  DROP COLUMN deleted_at;
+ALTER TABLE books
+  DROP FOREIGN KEY fk_books_deleted_by,
+  DROP INDEX idx_books_deleted_at,
+  DROP COLUMN deleted_by,
+  DROP COLUMN deleted_at;
+ALTER TABLE users
+  DROP FOREIGN KEY fk_users_deleted_by,
+  DROP INDEX idx_users_deleted_at,
+  DROP COLUMN deleted_by,
+  DROP COLUMN deleted_at;
@@ -0,0 +1,23 @@
+-- Deleting a user, book, list or interaction marks it instead of removing
+-- it: reads leave marked rows out, admins can restore them, and
+-- cmd/jobs/purge removes them for good after the retention window.
+ALTER TABLE users
+  ADD COLUMN deleted_at TIMESTAMP NULL,
+  ADD COLUMN deleted_by BIGINT NULL,
+  ADD INDEX idx_users_deleted_at (deleted_at),
+  ADD CONSTRAINT fk_users_deleted_by FOREIGN KEY (deleted_by) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE books
+  ADD COLUMN deleted_at TIMESTAMP NULL,
+  ADD COLUMN deleted_by BIGINT NULL,
+  ADD INDEX idx_books_deleted_at (deleted_at),
+  ADD CONSTRAINT fk_books_deleted_by FOREIGN KEY (deleted_by) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE lists
+  ADD COLUMN deleted_at TIMESTAMP NULL,
+  ADD COLUMN deleted_by BIGINT NULL,
+  ADD INDEX idx_lists_deleted_at (deleted_at),
+  ADD CONSTRAINT fk_lists_deleted_by FOREIGN KEY (deleted_by) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE interactions
+  ADD COLUMN deleted_at TIMESTAMP NULL,
+  ADD COLUMN deleted_by BIGINT NULL,
+  ADD INDEX idx_interactions_deleted_at (deleted_at),
+  ADD CONSTRAINT fk_interactions_deleted_by FOREIGN KEY (deleted_by) REFERENCES users(id) ON DELETE SET NULL;
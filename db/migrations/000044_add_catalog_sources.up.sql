@@ -0,0 +1,9 @@
+-- When each catalogue source (an ingest job category, ...) last delivered,
+-- so GET /admin/catalog/status can warn when the pipeline stops.
+CREATE TABLE IF NOT EXISTS catalog_sources (
+  source VARCHAR(128) NOT NULL PRIMARY KEY,
+  last_attempt_at DATETIME NOT NULL,
+  last_success_at DATETIME NULL,
+  last_error VARCHAR(512) NULL,
+  books_last_run INT NOT NULL DEFAULT 0
+);
@@ -0,0 +1,2 @@
+DROP INDEX idx_interactions_book_action_user ON interactions;
+DROP INDEX idx_interactions_user_action_book ON interactions;
@@ -0,0 +1,4 @@
+-- Recommendations (internal/recommend) look up a reader's likes, then
+-- everyone else who liked those books, then those readers' likes
+CREATE INDEX idx_interactions_user_action_book ON interactions(user_id, action, book_id);
+CREATE INDEX idx_interactions_book_action_user ON interactions(book_id, action, user_id);
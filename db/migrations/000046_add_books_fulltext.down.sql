@@ -0,0 +1,2 @@
+DROP INDEX ft_books_search ON books;
+ALTER TABLE books DROP COLUMN subjects_text;
@@ -0,0 +1,4 @@
+-- Full-text search (GET /books/search) over title, author and subjects.
+-- FULLTEXT can't index a JSON column, so the subjects are mirrored as text.
+ALTER TABLE books ADD COLUMN subjects_text TEXT GENERATED ALWAYS AS (CAST(subjects AS CHAR)) STORED;
+CREATE FULLTEXT INDEX ft_books_search ON books (title, author, subjects_text);
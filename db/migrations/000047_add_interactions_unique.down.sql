@@ -0,0 +1 @@
+ALTER TABLE interactions DROP INDEX uq_interactions_user_book_action;
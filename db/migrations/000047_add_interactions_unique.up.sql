@@ -0,0 +1,5 @@
+-- One row per user, book and action: POST /interactions updates the reader's
+-- existing row instead of adding another. Run cmd/jobs/dedupe first: adding
+-- the key fails while duplicates remain.
+ALTER TABLE interactions
+  ADD UNIQUE KEY uq_interactions_user_book_action (user_id, book_id, action);
@@ -0,0 +1,3 @@
+DELETE FROM interactions WHERE action = 'dislike';
+ALTER TABLE interactions
+  MODIFY COLUMN action ENUM('view', 'like', 'rating') NOT NULL;
@@ -0,0 +1,4 @@
+-- 'dislike' tells the recommender a book (and ones like it) missed the mark.
+-- It isn't counted in book_counters.
+ALTER TABLE interactions
+  MODIFY COLUMN action ENUM('view', 'like', 'rating', 'dislike') NOT NULL;
@@ -0,0 +1,3 @@
+-- Open Library's cover image ID for the work (its search API's cover_i);
+-- book responses link to the image on covers.openlibrary.org.
+ALTER TABLE books ADD COLUMN cover_id BIGINT NULL;
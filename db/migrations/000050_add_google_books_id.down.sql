@@ -0,0 +1,2 @@
+DROP INDEX uq_books_google_books_id ON books;
+ALTER TABLE books DROP COLUMN google_books_id;
@@ -0,0 +1,5 @@
+-- The Google Books volume a book was ingested from or matched to
+-- (bookrec ingest --source=googlebooks). Unique so re-ingesting a volume
+-- finds its book again.
+ALTER TABLE books ADD COLUMN google_books_id VARCHAR(32) NULL;
+CREATE UNIQUE INDEX uq_books_google_books_id ON books(google_books_id);
@@ -0,0 +1,5 @@
+UPDATE job_runs SET status = 'failed', message = 'never run' WHERE status = 'queued';
+ALTER TABLE job_runs
+  DROP INDEX idx_job_runs_job_status,
+  DROP COLUMN params,
+  MODIFY COLUMN status ENUM('running', 'succeeded', 'failed') NOT NULL DEFAULT 'running';
@@ -0,0 +1,6 @@
+-- Runs queued through the API (POST /admin/ingest) wait as 'queued' until
+-- a worker claims them; params is what the worker runs them with.
+ALTER TABLE job_runs
+  MODIFY COLUMN status ENUM('queued', 'running', 'succeeded', 'failed') NOT NULL DEFAULT 'running',
+  ADD COLUMN params JSON NULL,
+  ADD INDEX idx_job_runs_job_status (job, status);
@@ -0,0 +1,14 @@
+-- What each ingest run fetched, beside its job_runs row. Scheduled runs are
+-- recorded when queued; scheduled_for is unique so replicas queue a night's
+-- refresh only once. books and failed_categories stay NULL until it ends.
+CREATE TABLE IF NOT EXISTS ingest_runs (
+  job_run_id BIGINT NOT NULL PRIMARY KEY,
+  source VARCHAR(32) NOT NULL,
+  scheduled_for DATE NULL,
+  categories INT NOT NULL DEFAULT 0,
+  failed_categories INT NULL,
+  books INT NULL,
+  finished_at DATETIME NULL,
+  UNIQUE KEY uq_ingest_runs_scheduled_for (scheduled_for),
+  FOREIGN KEY (job_run_id) REFERENCES job_runs(id) ON DELETE CASCADE
+);
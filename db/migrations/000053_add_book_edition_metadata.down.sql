@@ -0,0 +1,3 @@
+ALTER TABLE books
+  DROP COLUMN publisher,
+  DROP COLUMN language;
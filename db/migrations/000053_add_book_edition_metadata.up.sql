@@ -0,0 +1,5 @@
+-- Edition metadata from the ingestion sources: the language (BCP 47 tag) and
+-- publisher of the first edition listed. NULL when the source didn't say.
+ALTER TABLE books
+  ADD COLUMN language VARCHAR(35) NULL,
+  ADD COLUMN publisher VARCHAR(255) NULL;
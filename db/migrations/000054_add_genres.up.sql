@@ -0,0 +1,45 @@
+-- The curated genre taxonomy (internal/genres, whose All list these rows
+-- must match) and the genres each book's subjects map it to. Books already
+-- in the catalogue get theirs from bookrec genres.
+CREATE TABLE IF NOT EXISTS genres (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  slug VARCHAR(64) NOT NULL,
+  name VARCHAR(128) NOT NULL,
+  UNIQUE KEY uq_genres_slug (slug)
+);
+
+CREATE TABLE IF NOT EXISTS book_genres (
+  book_id BIGINT NOT NULL,
+  genre_id INT NOT NULL,
+  PRIMARY KEY (book_id, genre_id),
+  INDEX idx_book_genres_genre (genre_id, book_id),
+  CONSTRAINT fk_book_genres_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+  CONSTRAINT fk_book_genres_genre FOREIGN KEY (genre_id) REFERENCES genres(id) ON DELETE CASCADE
+);
+
+INSERT INTO genres (slug, name) VALUES
+  ('art', 'Art'),
+  ('biography', 'Biography & Memoir'),
+  ('business', 'Business & Economics'),
+  ('childrens', 'Children''s'),
+  ('classics', 'Classics'),
+  ('comics', 'Comics & Graphic Novels'),
+  ('cooking', 'Cooking'),
+  ('fantasy', 'Fantasy'),
+  ('historical-fiction', 'Historical Fiction'),
+  ('history', 'History'),
+  ('horror', 'Horror'),
+  ('humor', 'Humor'),
+  ('mystery', 'Mystery'),
+  ('philosophy', 'Philosophy'),
+  ('poetry', 'Poetry'),
+  ('psychology', 'Psychology'),
+  ('religion', 'Religion & Spirituality'),
+  ('romance', 'Romance'),
+  ('science', 'Science'),
+  ('science-fiction', 'Science Fiction'),
+  ('self-help', 'Self-Help'),
+  ('technology', 'Technology'),
+  ('thriller', 'Thriller'),
+  ('travel', 'Travel'),
+  ('young-adult', 'Young Adult');
@@ -0,0 +1,6 @@
+ALTER TABLE books
+  DROP FOREIGN KEY fk_books_author,
+  DROP INDEX idx_books_author_id,
+  DROP COLUMN author_id;
+
+DROP TABLE IF EXISTS authors;
@@ -0,0 +1,21 @@
+-- Authors as records of their own, so a reader can follow one across
+-- books. books.author stays as the display name; author_id links it to the
+-- author of that name (internal/authors keeps the two in step).
+CREATE TABLE IF NOT EXISTS authors (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  name VARCHAR(512) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_authors_name (name)
+);
+
+ALTER TABLE books
+  ADD COLUMN author_id BIGINT NULL,
+  ADD INDEX idx_books_author_id (author_id),
+  ADD CONSTRAINT fk_books_author FOREIGN KEY (author_id) REFERENCES authors(id) ON DELETE SET NULL;
+
+INSERT INTO authors (name)
+SELECT DISTINCT TRIM(author) FROM books WHERE author IS NOT NULL AND TRIM(author) <> '';
+
+UPDATE books
+SET author_id = (SELECT a.id FROM authors a WHERE a.name = TRIM(books.author))
+WHERE author IS NOT NULL AND TRIM(author) <> '';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS shelf_books;
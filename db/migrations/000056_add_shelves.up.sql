@@ -0,0 +1,14 @@
+-- Reading shelves: where each book stands for a reader. A book is on at
+-- most one of a reader's shelves; moving it replaces the row.
+CREATE TABLE IF NOT EXISTS shelf_books (
+  user_id BIGINT NOT NULL,
+  book_id BIGINT NOT NULL,
+  shelf ENUM('want-to-read', 'reading', 'read') NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, book_id),
+  INDEX idx_shelf_books_shelf (user_id, shelf, updated_at),
+  INDEX idx_shelf_books_book_id (book_id),
+  CONSTRAINT fk_shelf_books_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_shelf_books_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
@@ -0,0 +1,29 @@
+-- Written reviews: one per reader and book, in the reader's organization.
+-- The star rating stays an interaction; a review shows the reviewer's.
+-- helpful_votes counts review_votes so listings can sort by it.
+CREATE TABLE IF NOT EXISTS reviews (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  uuid CHAR(36) NOT NULL DEFAULT (UUID()),
+  organization_id BIGINT NOT NULL,
+  book_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  body TEXT NOT NULL,
+  spoiler BOOLEAN NOT NULL DEFAULT FALSE,
+  helpful_votes INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_reviews_uuid (uuid),
+  UNIQUE KEY uq_reviews_user_book (user_id, book_id),
+  INDEX idx_reviews_book (book_id, organization_id, helpful_votes, created_at),
+  CONSTRAINT fk_reviews_organization FOREIGN KEY (organization_id) REFERENCES organizations(id),
+  CONSTRAINT fk_reviews_book FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+  CONSTRAINT fk_reviews_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS review_votes (
+  review_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (review_id, user_id),
+  CONSTRAINT fk_review_votes_review FOREIGN KEY (review_id) REFERENCES reviews(id) ON DELETE CASCADE,
+  CONSTRAINT fk_review_votes_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
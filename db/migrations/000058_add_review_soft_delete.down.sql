@@ -0,0 +1,5 @@
+ALTER TABLE reviews
+  DROP FOREIGN KEY fk_reviews_deleted_by,
+  DROP INDEX idx_reviews_deleted_at,
+  DROP COLUMN deleted_by,
+  DROP COLUMN deleted_at;
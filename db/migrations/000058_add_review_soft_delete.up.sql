@@ -0,0 +1,7 @@
+-- Reviews are soft-deleted along with their author, like lists and
+-- interactions, and come back when the account is restored.
+ALTER TABLE reviews
+  ADD COLUMN deleted_at TIMESTAMP NULL,
+  ADD COLUMN deleted_by BIGINT NULL,
+  ADD INDEX idx_reviews_deleted_at (deleted_at),
+  ADD CONSTRAINT fk_reviews_deleted_by FOREIGN KEY (deleted_by) REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,4 @@
+UPDATE list_events e
+JOIN users u ON u.uuid = JSON_UNQUOTE(JSON_EXTRACT(e.detail, '$.user_uuid'))
+SET e.detail = JSON_SET(JSON_REMOVE(e.detail, '$.user_uuid'), '$.user_id', u.id)
+WHERE JSON_CONTAINS_PATH(e.detail, 'one', '$.user_uuid');
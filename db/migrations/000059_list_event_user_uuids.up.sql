@@ -0,0 +1,6 @@
+-- Member events in a list's history named the member by integer id, which
+-- the API no longer exposes; name them by uuid like the rest of the payload.
+UPDATE list_events e
+JOIN users u ON u.id = JSON_EXTRACT(e.detail, '$.user_id')
+SET e.detail = JSON_SET(JSON_REMOVE(e.detail, '$.user_id'), '$.user_uuid', u.uuid)
+WHERE JSON_CONTAINS_PATH(e.detail, 'one', '$.user_id');
@@ -0,0 +1,54 @@
+// Package migrations embeds the schema migrations in this directory so a
+// binary can apply them without the source tree. Applied versions are kept in
+// schema_migrations, the same table the migrate CLI uses, so databases
+// migrated either way stay interchangeable.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// New returns a migrator for db, which must allow multiple statements per
+// Exec. Closing the migrator closes db.
+func New(db *sql.DB) (*migrate.Migrate, error) {
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, err
+	}
+	driver, err := mysql.WithInstance(db, &mysql.Config{})
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewWithInstance("iofs", source, "mysql", driver)
+}
+
+// Latest is the newest migration version built into the binary, the one
+// a fully migrated database is at
+func Latest() (uint, error) {
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = source.Close() }()
+	version, err := source.First()
+	for err == nil {
+		var next uint
+		if next, err = source.Next(version); err == nil {
+			version = next
+		}
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	return version, nil
+}
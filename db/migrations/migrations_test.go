@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLatest(t *testing.T) {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	var want uint
+	for _, name := range names {
+		n, err := strconv.ParseUint(strings.SplitN(name, "_", 2)[0], 10, 64)
+		if err != nil {
+			t.Fatalf("unnumbered migration %s", name)
+		}
+		if uint(n) > want {
+			want = uint(n)
+		}
+	}
+
+	got, err := Latest()
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if got != want || want == 0 {
+		t.Fatalf("expected latest version %d, got %d", want, got)
+	}
+}
@@ -0,0 +1,271 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/YeswanthC7/bookrec/graph/model"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+	"github.com/vikstrous/dataloadgen"
+)
+
+// maxInteractionsPerUser bounds the batched interactions query; the field's
+// limit argument slices within this window.
+const maxInteractionsPerUser = 50
+
+type loadersKey struct{}
+
+// Loaders batches lookups issued while resolving a single GraphQL request.
+// Every lookup is scoped to the request's tenant and leaves out soft-deleted
+// rows; those and rows from other organizations load as missing. Private
+// interactions only load for their owner and admins.
+type Loaders struct {
+	BookByID             *dataloadgen.Loader[int, *model.Book]
+	UserByID             *dataloadgen.Loader[int, *model.User]
+	LikesByBookID        *dataloadgen.Loader[int, int]
+	AvgRatingByBookID    *dataloadgen.Loader[int, *float64]
+	InteractionsByUserID *dataloadgen.Loader[int, []*model.Interaction]
+}
+
+// NewLoaders creates a fresh set of loaders; they cache per request, so never share them.
+func NewLoaders(db *sql.DB) *Loaders {
+	wait := dataloadgen.WithWait(2 * time.Millisecond)
+	return &Loaders{
+		BookByID:             dataloadgen.NewLoader(booksByID(db), wait),
+		UserByID:             dataloadgen.NewLoader(usersByID(db), wait),
+		LikesByBookID:        dataloadgen.NewLoader(likesByBookID(db), wait),
+		AvgRatingByBookID:    dataloadgen.NewLoader(avgRatingByBookID(db), wait),
+		InteractionsByUserID: dataloadgen.NewLoader(interactionsByUserID(db), wait),
+	}
+}
+
+// Middleware attaches per-request loaders to the request context.
+func Middleware(db *sql.DB, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), loadersKey{}, NewLoaders(db))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// For returns the loaders attached by Middleware.
+func For(ctx context.Context) (*Loaders, error) {
+	l, ok := ctx.Value(loadersKey{}).(*Loaders)
+	if !ok {
+		return nil, fmt.Errorf("graphql loaders missing from context")
+	}
+	return l, nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func intArgs(keys []int) []interface{} {
+	args := make([]interface{}, len(keys))
+	for i, k := range keys {
+		args[i] = k
+	}
+	return args
+}
+
+// fillErrors reports the same error for every key in a failed batch.
+func fillErrors(n int, err error) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+func booksByID(db *sql.DB) func(ctx context.Context, keys []int) ([]*model.Book, []error) {
+	return func(ctx context.Context, keys []int) ([]*model.Book, []error) {
+		rows, err := db.QueryContext(ctx, `
+			SELECT id, uuid, slug, title, COALESCE(author, ''), COALESCE(published_year, 0)
+			FROM books
+			WHERE id IN (`+placeholders(len(keys))+`) AND `+tenant.BooksVisibleSQL(""),
+			append(intArgs(keys), tenant.ID(ctx))...)
+		if err != nil {
+			return nil, fillErrors(len(keys), err)
+		}
+		defer func() { _ = rows.Close() }()
+
+		byID := map[int]*model.Book{}
+		for rows.Next() {
+			b := &model.Book{}
+			if err := rows.Scan(&b.ID, &b.UUID, &b.Slug, &b.Title, &b.Author, &b.Year); err != nil {
+				return nil, fillErrors(len(keys), err)
+			}
+			byID[b.ID] = b
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fillErrors(len(keys), err)
+		}
+
+		out := make([]*model.Book, len(keys))
+		for i, k := range keys {
+			out[i] = byID[k]
+		}
+		return out, nil
+	}
+}
+
+func usersByID(db *sql.DB) func(ctx context.Context, keys []int) ([]*model.User, []error) {
+	return func(ctx context.Context, keys []int) ([]*model.User, []error) {
+		rows, err := db.QueryContext(ctx, `
+			SELECT id, uuid, email, handle, created_at
+			FROM users
+			WHERE id IN (`+placeholders(len(keys))+`) AND organization_id = ? AND deleted_at IS NULL`,
+			append(intArgs(keys), tenant.ID(ctx))...)
+		if err != nil {
+			return nil, fillErrors(len(keys), err)
+		}
+		defer func() { _ = rows.Close() }()
+
+		byID := map[int]*model.User{}
+		for rows.Next() {
+			u := &model.User{}
+			if err := rows.Scan(&u.ID, &u.UUID, &u.Email, &u.Handle, &u.CreatedAt); err != nil {
+				return nil, fillErrors(len(keys), err)
+			}
+			byID[u.ID] = u
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fillErrors(len(keys), err)
+		}
+
+		out := make([]*model.User, len(keys))
+		for i, k := range keys {
+			out[i] = byID[k]
+		}
+		return out, nil
+	}
+}
+
+func likesByBookID(db *sql.DB) func(ctx context.Context, keys []int) ([]int, []error) {
+	return func(ctx context.Context, keys []int) ([]int, []error) {
+		rows, err := db.QueryContext(ctx, `
+			SELECT book_id, likes
+			FROM book_counters
+			WHERE organization_id = ? AND book_id IN (`+placeholders(len(keys))+`)`, append([]interface{}{tenant.ID(ctx)}, intArgs(keys)...)...)
+		if err != nil {
+			return nil, fillErrors(len(keys), err)
+		}
+		defer func() { _ = rows.Close() }()
+
+		byID := map[int]int{}
+		for rows.Next() {
+			var id, likes int
+			if err := rows.Scan(&id, &likes); err != nil {
+				return nil, fillErrors(len(keys), err)
+			}
+			byID[id] = likes
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fillErrors(len(keys), err)
+		}
+
+		out := make([]int, len(keys))
+		for i, k := range keys {
+			out[i] = byID[k]
+		}
+		return out, nil
+	}
+}
+
+func avgRatingByBookID(db *sql.DB) func(ctx context.Context, keys []int) ([]*float64, []error) {
+	return func(ctx context.Context, keys []int) ([]*float64, []error) {
+		rows, err := db.QueryContext(ctx, `
+			SELECT book_id, rating_sum / ratings
+			FROM book_counters
+			WHERE organization_id = ? AND ratings > 0
+			  AND book_id IN (`+placeholders(len(keys))+`)`, append([]interface{}{tenant.ID(ctx)}, intArgs(keys)...)...)
+		if err != nil {
+			return nil, fillErrors(len(keys), err)
+		}
+		defer func() { _ = rows.Close() }()
+
+		byID := map[int]*float64{}
+		for rows.Next() {
+			var id int
+			var avg float64
+			if err := rows.Scan(&id, &avg); err != nil {
+				return nil, fillErrors(len(keys), err)
+			}
+			byID[id] = &avg
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fillErrors(len(keys), err)
+		}
+
+		out := make([]*float64, len(keys))
+		for i, k := range keys {
+			out[i] = byID[k]
+		}
+		return out, nil
+	}
+}
+
+func interactionsByUserID(db *sql.DB) func(ctx context.Context, keys []int) ([][]*model.Interaction, []error) {
+	return func(ctx context.Context, keys []int) ([][]*model.Interaction, []error) {
+		args := append(intArgs(keys), tenant.ID(ctx), tenant.ID(ctx))
+		visible := ""
+		if v := viewerFor(ctx); !v.isAdmin() {
+			visible = " AND (i.visibility = 'public' OR i.user_id = ?)"
+			args = append(args, v.UserID)
+		}
+		args = append(args, maxInteractionsPerUser)
+		rows, err := db.QueryContext(ctx, `
+			SELECT id, uuid, user_id, book_id, action, rating, created_at
+			FROM (
+				SELECT i.*, ROW_NUMBER() OVER (PARTITION BY i.user_id ORDER BY i.created_at DESC, i.id DESC) AS rn
+				FROM interactions i
+				JOIN books b ON b.id = i.book_id
+				WHERE i.user_id IN (`+placeholders(len(keys))+`) AND `+tenant.BooksVisibleSQL("b")+`
+				  AND i.organization_id = ? AND i.deleted_at IS NULL`+visible+`
+			) ranked
+			WHERE rn <= ?
+			ORDER BY user_id, rn`, args...)
+		if err != nil {
+			return nil, fillErrors(len(keys), err)
+		}
+		defer func() { _ = rows.Close() }()
+
+		byUser := map[int][]*model.Interaction{}
+		for rows.Next() {
+			it, err := scanInteraction(rows)
+			if err != nil {
+				return nil, fillErrors(len(keys), err)
+			}
+			byUser[it.UserID] = append(byUser[it.UserID], it)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fillErrors(len(keys), err)
+		}
+
+		out := make([][]*model.Interaction, len(keys))
+		for i, k := range keys {
+			out[i] = byUser[k]
+			if out[i] == nil {
+				out[i] = []*model.Interaction{}
+			}
+		}
+		return out, nil
+	}
+}
+
+func scanInteraction(rows *sql.Rows) (*model.Interaction, error) {
+	it := &model.Interaction{}
+	var rating sql.NullInt64
+	if err := rows.Scan(&it.ID, &it.UUID, &it.UserID, &it.BookID, &it.Action, &rating, &it.CreatedAt); err != nil {
+		return nil, err
+	}
+	if rating.Valid {
+		v := int(rating.Int64)
+		it.Rating = &v
+	}
+	return it, nil
+}
@@ -0,0 +1,37 @@
+package model
+
+// Book is the GraphQL view of a catalogue entry
+type Book struct {
+	ID     int    `json:"-"`
+	UUID   string `json:"uuid"`
+	Slug   string `json:"slug"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Year   int    `json:"year"`
+}
+
+// User is the GraphQL view of an account (password and role are never exposed)
+type User struct {
+	ID        int    `json:"-"`
+	UUID      string `json:"uuid"`
+	Email     string `json:"email"`
+	Handle    string `json:"handle"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// Interaction keeps foreign keys so user/book are resolved via dataloaders
+type Interaction struct {
+	ID        int    `json:"-"`
+	UUID      string `json:"uuid"`
+	UserID    int    `json:"-"`
+	BookID    int    `json:"-"`
+	Action    string `json:"action"`
+	Rating    *int   `json:"rating"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// Recommendation pairs a co-like score with the recommended book
+type Recommendation struct {
+	BookID int `json:"-"`
+	Score  int `json:"score"`
+}
@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/YeswanthC7/bookrec/graph/model"
+	"github.com/YeswanthC7/bookrec/internal/store"
+)
+
+// Resolver is the root GraphQL resolver. It holds the shared DB handle;
+// per-request batching lives in the Loaders attached to the request context.
+type Resolver struct {
+	DB *sql.DB
+}
+
+// resolveUser maps a userId argument to the user's internal id, by UUID like
+// the REST routes
+func (r *Resolver) resolveUser(ctx context.Context, ref string) (int, error) {
+	id, err := store.New(r.DB).ResolveUser(ctx, ref)
+	if errors.Is(err, store.ErrNotFound) {
+		return 0, fmt.Errorf("user %s not found", ref)
+	}
+	return id, err
+}
+
+func (r *Resolver) loadBook(ctx context.Context, id int) (*model.Book, error) {
+	l, err := For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b, err := l.BookByID.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, errors.New("book not found")
+	}
+	return b, nil
+}
+
+// limitInteractions applies the field's limit within the batched window
+func limitInteractions(items []*model.Interaction, limit *int) []*model.Interaction {
+	if limit != nil && *limit >= 0 && *limit < len(items) {
+		return items[:*limit]
+	}
+	return items
+}
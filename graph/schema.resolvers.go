@@ -0,0 +1,248 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.78
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/YeswanthC7/bookrec/graph/model"
+	"github.com/YeswanthC7/bookrec/internal/recommend"
+	"github.com/YeswanthC7/bookrec/internal/store"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// Likes is the resolver for the likes field.
+func (r *bookResolver) Likes(ctx context.Context, obj *model.Book) (int, error) {
+	l, err := For(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return l.LikesByBookID.Load(ctx, obj.ID)
+}
+
+// AvgRating is the resolver for the avgRating field.
+func (r *bookResolver) AvgRating(ctx context.Context, obj *model.Book) (*float64, error) {
+	l, err := For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return l.AvgRatingByBookID.Load(ctx, obj.ID)
+}
+
+// User is the resolver for the user field.
+func (r *interactionResolver) User(ctx context.Context, obj *model.Interaction) (*model.User, error) {
+	l, err := For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	u, err := l.UserByID.Load(ctx, obj.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, errors.New("user not found")
+	}
+	return u, nil
+}
+
+// Book is the resolver for the book field.
+func (r *interactionResolver) Book(ctx context.Context, obj *model.Interaction) (*model.Book, error) {
+	return r.loadBook(ctx, obj.BookID)
+}
+
+// Books is the resolver for the books field.
+func (r *queryResolver) Books(ctx context.Context, page *int, limit *int) ([]*model.Book, error) {
+	p, lim := 1, 20
+	if page != nil && *page > 0 {
+		p = *page
+	}
+	if limit != nil && *limit > 0 && *limit <= 100 {
+		lim = *limit
+	}
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, uuid, slug, title, COALESCE(author, ''), COALESCE(published_year, 0)
+		FROM books
+		WHERE `+tenant.BooksVisibleSQL("")+`
+		ORDER BY id
+		LIMIT ? OFFSET ?`, tenant.ID(ctx), lim, (p-1)*lim)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	l, _ := For(ctx)
+	books := []*model.Book{}
+	for rows.Next() {
+		b := &model.Book{}
+		if err := rows.Scan(&b.ID, &b.UUID, &b.Slug, &b.Title, &b.Author, &b.Year); err != nil {
+			return nil, err
+		}
+		if l != nil {
+			l.BookByID.Prime(b.ID, b)
+		}
+		books = append(books, b)
+	}
+	return books, rows.Err()
+}
+
+// Book is the resolver for the book field.
+func (r *queryResolver) Book(ctx context.Context, id string) (*model.Book, error) {
+	bookID, err := store.New(r.DB).ResolveBook(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	l, err := For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return l.BookByID.Load(ctx, bookID)
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context) ([]*model.User, error) {
+	if !viewerFor(ctx).isAdmin() {
+		return nil, errAdminOnly
+	}
+	rows, err := r.DB.QueryContext(ctx,
+		"SELECT id, uuid, email, handle, created_at FROM users WHERE organization_id = ? AND deleted_at IS NULL ORDER BY id", tenant.ID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	users := []*model.User{}
+	for rows.Next() {
+		u := &model.User{}
+		if err := rows.Scan(&u.ID, &u.UUID, &u.Email, &u.Handle, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error) {
+	userID, err := store.New(r.DB).ResolveUser(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	l, err := For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return l.UserByID.Load(ctx, userID)
+}
+
+// Interactions is the resolver for the interactions field.
+func (r *queryResolver) Interactions(ctx context.Context, userID string, limit *int) ([]*model.Interaction, error) {
+	uid, err := r.resolveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !viewerFor(ctx).owns(uid) {
+		return nil, fmt.Errorf("forbidden: cannot read another user's interactions")
+	}
+	l, err := For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items, err := l.InteractionsByUserID.Load(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return limitInteractions(items, limit), nil
+}
+
+// Recommendations is the resolver for the recommendations field.
+func (r *queryResolver) Recommendations(ctx context.Context, userID string) ([]*model.Recommendation, error) {
+	uid, err := r.resolveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !viewerFor(ctx).owns(uid) {
+		return nil, fmt.Errorf("forbidden: cannot read another user's recommendations")
+	}
+	// Same co-like and rating scoring as GET /recommendations/:user_id; the users join
+	// keeps a deleted user or one of another organization from getting anything,
+	// and the books join leaves out deleted titles and other organizations' ones
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT s.book_id, s.score
+		FROM (`+recommend.ScoresSQL+`) s
+		JOIN users u ON u.id = ? AND u.organization_id = ? AND u.deleted_at IS NULL
+		JOIN books b ON b.id = s.book_id AND `+tenant.BooksVisibleSQL("b")+`
+		ORDER BY s.score DESC, s.strongest DESC, s.book_id
+		LIMIT 10`, append(recommend.Args(uid), uid, tenant.ID(ctx), tenant.ID(ctx))...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	recs := []*model.Recommendation{}
+	for rows.Next() {
+		rec := &model.Recommendation{}
+		if err := rows.Scan(&rec.BookID, &rec.Score); err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, rows.Err()
+}
+
+// Book is the resolver for the book field.
+func (r *recommendationResolver) Book(ctx context.Context, obj *model.Recommendation) (*model.Book, error) {
+	return r.loadBook(ctx, obj.BookID)
+}
+
+// Email is the resolver for the email field.
+func (r *userResolver) Email(ctx context.Context, obj *model.User) (*string, error) {
+	if !viewerFor(ctx).owns(obj.ID) {
+		return nil, nil
+	}
+	return &obj.Email, nil
+}
+
+// Interactions is the resolver for the interactions field.
+func (r *userResolver) Interactions(ctx context.Context, obj *model.User, limit *int) ([]*model.Interaction, error) {
+	l, err := For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items, err := l.InteractionsByUserID.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	return limitInteractions(items, limit), nil
+}
+
+// Book returns BookResolver implementation.
+func (r *Resolver) Book() BookResolver { return &bookResolver{r} }
+
+// Interaction returns InteractionResolver implementation.
+func (r *Resolver) Interaction() InteractionResolver { return &interactionResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Recommendation returns RecommendationResolver implementation.
+func (r *Resolver) Recommendation() RecommendationResolver { return &recommendationResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type bookResolver struct{ *Resolver }
+type interactionResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type recommendationResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
@@ -0,0 +1,38 @@
+package graph
+
+import (
+	"context"
+	"errors"
+)
+
+// Viewer is the caller a GraphQL request runs as. The HTTP layer fills it in
+// from the same optional auth as REST; UserID is 0 for anonymous requests.
+type Viewer struct {
+	UserID int
+	Role   string
+}
+
+type viewerKey struct{}
+
+// WithViewer returns ctx carrying v
+func WithViewer(ctx context.Context, v Viewer) context.Context {
+	return context.WithValue(ctx, viewerKey{}, v)
+}
+
+// viewerFor returns the viewer attached by WithViewer (anonymous when unset)
+func viewerFor(ctx context.Context) Viewer {
+	v, _ := ctx.Value(viewerKey{}).(Viewer)
+	return v
+}
+
+func (v Viewer) isAdmin() bool {
+	return v.Role == "admin"
+}
+
+// owns reports whether v may read userID's private data: their own, or
+// anyone's for an admin
+func (v Viewer) owns(userID int) bool {
+	return v.isAdmin() || (v.UserID != 0 && v.UserID == userID)
+}
+
+var errAdminOnly = errors.New("forbidden: admins only")
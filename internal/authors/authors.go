@@ -0,0 +1,36 @@
+// Package authors keeps books linked to author records. An author is
+// identified by name, exactly as books.author spells it; the link is set
+// whenever a book's author is written, by ingest and by admins alike.
+package authors
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// Execer is satisfied by *sql.DB and *sql.Tx
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Link points the book at the author named name, adding the author the
+// first time the name is seen. A blank name unlinks the book.
+func Link(ctx context.Context, db Execer, bookID int64, name string) error {
+	var authorID interface{}
+	if name = strings.TrimSpace(name); name != "" {
+		res, err := db.ExecContext(ctx, `
+			INSERT INTO authors (name) VALUES (?)
+			ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)`, name)
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		authorID = id
+	}
+	_, err := db.ExecContext(ctx, "UPDATE books SET author_id = ? WHERE id = ?", authorID, bookID)
+	return err
+}
@@ -0,0 +1,37 @@
+package authors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestLink(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectExec("INSERT INTO authors \\(name\\) VALUES \\(\\?\\)\\s+ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID\\(id\\)").
+		WithArgs("Ursula K. Le Guin").
+		WillReturnResult(sqlmock.NewResult(4, 0))
+	mock.ExpectExec("UPDATE books SET author_id = \\? WHERE id = \\?").
+		WithArgs(int64(4), int64(10)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// a book with no author is unlinked
+	mock.ExpectExec("UPDATE books SET author_id = \\? WHERE id = \\?").
+		WithArgs(nil, int64(11)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := Link(context.Background(), db, 10, " Ursula K. Le Guin "); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	if err := Link(context.Background(), db, 11, ""); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
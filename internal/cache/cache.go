@@ -0,0 +1,86 @@
+// Package cache is an optional cache, shared by every server process, for
+// reads that are expensive to compute: recommendations and popular books.
+// It is backed by Redis (7 or newer) when REDIS_URL is set.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache stores values under a key and a field. A key groups related
+// values: they expire together and are invalidated together, e.g. every
+// variant of one user's recommendations. Redis being down never fails a
+// request: errors are logged, reads miss and writes are dropped, so callers
+// fall back to the database.
+type Cache interface {
+	Get(ctx context.Context, key, field string) ([]byte, bool)
+	// Set stores value. The key expires ttl after its first field was set;
+	// later fields don't extend it, so nothing outlives its ttl.
+	Set(ctx context.Context, key, field string, value []byte, ttl time.Duration)
+	// Delete drops every field of each key
+	Delete(ctx context.Context, keys ...string)
+}
+
+// keyPrefix namespaces bookrec's keys in a shared Redis
+const keyPrefix = "bookrec:"
+
+// FromEnv builds the cache configured by REDIS_URL
+// (redis://[:password@]host:port/db, or rediss:// for TLS). Without it,
+// FromEnv returns a nil Cache and nothing is cached across processes.
+func FromEnv() (Cache, error) {
+	raw := strings.TrimSpace(os.Getenv("REDIS_URL"))
+	if raw == "" {
+		return nil, nil
+	}
+	opts, err := redis.ParseURL(raw)
+	if err != nil {
+		return nil, fmt.Errorf("REDIS_URL: %w", err)
+	}
+	return &Redis{Client: redis.NewClient(opts)}, nil
+}
+
+// Redis keeps each key as a hash of its fields
+type Redis struct {
+	Client *redis.Client
+}
+
+// Get implements Cache
+func (r *Redis) Get(ctx context.Context, key, field string) ([]byte, bool) {
+	value, err := r.Client.HGet(ctx, keyPrefix+key, field).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("⚠️  Cache read of %s failed (reading from the database): %v", key, err)
+		}
+		return nil, false
+	}
+	return value, true
+}
+
+// Set implements Cache
+func (r *Redis) Set(ctx context.Context, key, field string, value []byte, ttl time.Duration) {
+	pipe := r.Client.TxPipeline()
+	pipe.HSet(ctx, keyPrefix+key, field, value)
+	pipe.ExpireNX(ctx, keyPrefix+key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("⚠️  Cache write of %s failed: %v", key, err)
+	}
+}
+
+// Delete implements Cache
+func (r *Redis) Delete(ctx context.Context, keys ...string) {
+	prefixed := make([]string, len(keys))
+	for i, k := range keys {
+		prefixed[i] = keyPrefix + k
+	}
+	if err := r.Client.Del(ctx, prefixed...).Err(); err != nil {
+		log.Printf("⚠️  Cache invalidation of %v failed (entries expire on their own): %v", keys, err)
+	}
+}
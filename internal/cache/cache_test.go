@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("REDIS_URL", "")
+	if c, err := FromEnv(); c != nil || err != nil {
+		t.Fatalf("expected no cache without REDIS_URL, got %v, %v", c, err)
+	}
+
+	t.Setenv("REDIS_URL", "redis://:secret@cache.internal:6380/2")
+	c, err := FromEnv()
+	if err != nil {
+		t.Fatalf("FromEnv: %v", err)
+	}
+	opts := c.(*Redis).Client.Options()
+	if opts.Addr != "cache.internal:6380" || opts.Password != "secret" || opts.DB != 2 {
+		t.Fatalf("unexpected options: addr %s db %d", opts.Addr, opts.DB)
+	}
+
+	t.Setenv("REDIS_URL", "http://cache.internal")
+	if _, err := FromEnv(); err == nil {
+		t.Fatal("expected an error for a non-redis URL")
+	}
+}
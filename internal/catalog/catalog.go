@@ -0,0 +1,49 @@
+// Package catalog records when each catalogue source last delivered books,
+// so the server can tell operators when ingestion has stopped
+// (GET /admin/catalog/status).
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// Execer is satisfied by *sql.DB and *sql.Tx
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// maxErrorLength fits catalog_sources.last_error
+const maxErrorLength = 512
+
+// RecordIngest notes an attempt to ingest from source: a success that
+// added or refreshed books, or the error it failed with. Like job progress,
+// it's best-effort: a failure to record is logged, not returned.
+func RecordIngest(ctx context.Context, db Execer, source string, books int, cause error) {
+	var err error
+	if cause == nil {
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO catalog_sources (source, last_attempt_at, last_success_at, last_error, books_last_run)
+			VALUES (?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, NULL, ?)
+			ON DUPLICATE KEY UPDATE
+				last_attempt_at = VALUES(last_attempt_at),
+				last_success_at = VALUES(last_success_at),
+				last_error = NULL,
+				books_last_run = VALUES(books_last_run)`, source, books)
+	} else {
+		message := cause.Error()
+		if len(message) > maxErrorLength {
+			message = message[:maxErrorLength]
+		}
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO catalog_sources (source, last_attempt_at, last_error)
+			VALUES (?, CURRENT_TIMESTAMP, ?)
+			ON DUPLICATE KEY UPDATE
+				last_attempt_at = VALUES(last_attempt_at),
+				last_error = VALUES(last_error)`, source, message)
+	}
+	if err != nil {
+		log.Printf("⚠️  Could not record %s ingest (catalog status won't show it): %v", source, err)
+	}
+}
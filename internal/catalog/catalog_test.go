@@ -0,0 +1,33 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRecordIngest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectExec("INSERT INTO catalog_sources .+last_error = NULL").
+		WithArgs("open_library:fantasy", 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// the success time is left alone when an attempt fails
+	mock.ExpectExec("INSERT INTO catalog_sources \\(source, last_attempt_at, last_error\\)").
+		WithArgs("open_library:fantasy", strings.Repeat("x", maxErrorLength)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	ctx := context.Background()
+	RecordIngest(ctx, db, "open_library:fantasy", 10, nil)
+	RecordIngest(ctx, db, "open_library:fantasy", 0, errors.New(strings.Repeat("x", 600)))
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+// Package config is the environment and database setup every bookrec
+// subcommand shares: configs/.env, the DB_* variables and the connection.
+package config
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/joho/godotenv"
+
+	"github.com/YeswanthC7/bookrec/internal/metrics"
+	"github.com/YeswanthC7/bookrec/internal/tracing"
+)
+
+// DefaultEnvFile is where the environment is read from unless told otherwise
+const DefaultEnvFile = "configs/.env"
+
+// Load reads path into the environment. Variables already set win, and a
+// missing file isn't an error: the system's variables are used instead.
+func Load(path string) {
+	if err := godotenv.Load(path); err != nil {
+		log.Printf("⚠️  No %s file found; using system vars", path)
+	}
+}
+
+// DefaultPort is the local MySQL's port (docker run -p 3307:3306)
+const DefaultPort = "3307"
+
+// DSN is the MySQL DSN from DB_USER, DB_PASS, DB_HOST, DB_PORT (default
+// 3307), DB_NAME and DB_TLS
+func DSN() string {
+	port := strings.TrimSpace(os.Getenv("DB_PORT"))
+	if port == "" {
+		port = DefaultPort
+	}
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true&tls=%s",
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASS"),
+		net.JoinHostPort(os.Getenv("DB_HOST"), port),
+		os.Getenv("DB_NAME"),
+		os.Getenv("DB_TLS"),
+	)
+}
+
+// Pool is how many connections each process keeps to MySQL. database/sql
+// would otherwise open as many as there are concurrent queries and keep
+// only two idle, so a burst of requests opens (and closes) connections
+// until MySQL refuses more.
+type Pool struct {
+	// MaxOpen caps connections in use plus idle (DB_MAX_OPEN_CONNS, default 25)
+	MaxOpen int
+	// MaxIdle is how many are kept open between queries (DB_MAX_IDLE_CONNS,
+	// default 10)
+	MaxIdle int
+	// MaxLifetime retires connections, so none outlives MySQL's
+	// wait_timeout or a proxy's idle timeout (DB_CONN_MAX_LIFETIME_SECONDS,
+	// default 300)
+	MaxLifetime time.Duration
+}
+
+// PoolFromEnv reads the pool settings; missing or invalid values get the
+// defaults
+func PoolFromEnv() Pool {
+	pool := Pool{MaxOpen: 25, MaxIdle: 10, MaxLifetime: 5 * time.Minute}
+	if n, err := strconv.Atoi(os.Getenv("DB_MAX_OPEN_CONNS")); err == nil && n > 0 {
+		pool.MaxOpen = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("DB_MAX_IDLE_CONNS")); err == nil && n >= 0 {
+		pool.MaxIdle = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("DB_CONN_MAX_LIFETIME_SECONDS")); err == nil && n > 0 {
+		pool.MaxLifetime = time.Duration(n) * time.Second
+	}
+	return pool
+}
+
+// Apply sets the pool on db
+func (p Pool) Apply(db *sql.DB) {
+	db.SetMaxOpenConns(p.MaxOpen)
+	db.SetMaxIdleConns(p.MaxIdle)
+	db.SetConnMaxLifetime(p.MaxLifetime)
+}
+
+// OpenDB connects to the configured database and checks it's reachable.
+// multiStatements lets one Exec run a whole script, as migrations need.
+// The pool is sized from the environment (PoolFromEnv). Queries are timed
+// for GET /metrics and, within a traced request, traced.
+func OpenDB(multiStatements bool) (*sql.DB, error) {
+	cfg, err := mysql.ParseDSN(DSN())
+	if err != nil {
+		return nil, err
+	}
+	cfg.MultiStatements = multiStatements
+
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db := tracing.OpenDB(metrics.InstrumentConnector(connector))
+	PoolFromEnv().Apply(db)
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("DB unreachable: %w", err)
+	}
+	return db, nil
+}
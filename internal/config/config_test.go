@@ -0,0 +1,46 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDSN(t *testing.T) {
+	t.Setenv("DB_USER", "bookrec")
+	t.Setenv("DB_PASS", "secret")
+	t.Setenv("DB_HOST", "db.internal")
+	t.Setenv("DB_NAME", "bookrec")
+	t.Setenv("DB_TLS", "true")
+
+	t.Setenv("DB_PORT", "")
+	if got, want := DSN(), "bookrec:secret@tcp(db.internal:3307)/bookrec?parseTime=true&tls=true"; got != want {
+		t.Fatalf("expected the default port, got %s", got)
+	}
+	t.Setenv("DB_PORT", "3306")
+	if got, want := DSN(), "bookrec:secret@tcp(db.internal:3306)/bookrec?parseTime=true&tls=true"; got != want {
+		t.Fatalf("expected DB_PORT, got %s", got)
+	}
+}
+
+func TestPoolFromEnv(t *testing.T) {
+	t.Setenv("DB_MAX_OPEN_CONNS", "")
+	t.Setenv("DB_MAX_IDLE_CONNS", "")
+	t.Setenv("DB_CONN_MAX_LIFETIME_SECONDS", "")
+	if got := PoolFromEnv(); got != (Pool{MaxOpen: 25, MaxIdle: 10, MaxLifetime: 5 * time.Minute}) {
+		t.Fatalf("unexpected defaults: %+v", got)
+	}
+
+	t.Setenv("DB_MAX_OPEN_CONNS", "50")
+	t.Setenv("DB_MAX_IDLE_CONNS", "0")
+	t.Setenv("DB_CONN_MAX_LIFETIME_SECONDS", "60")
+	if got := PoolFromEnv(); got != (Pool{MaxOpen: 50, MaxIdle: 0, MaxLifetime: time.Minute}) {
+		t.Fatalf("unexpected pool: %+v", got)
+	}
+
+	t.Setenv("DB_MAX_OPEN_CONNS", "-1")
+	t.Setenv("DB_MAX_IDLE_CONNS", "lots")
+	t.Setenv("DB_CONN_MAX_LIFETIME_SECONDS", "0")
+	if got := PoolFromEnv(); got != (Pool{MaxOpen: 25, MaxIdle: 10, MaxLifetime: 5 * time.Minute}) {
+		t.Fatalf("expected invalid values to fall back to the defaults, got %+v", got)
+	}
+}
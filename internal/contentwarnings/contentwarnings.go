@@ -0,0 +1,138 @@
+// Package contentwarnings defines the content warning and audience rating
+// vocabulary stored on books (and in readers' preferences), and derives
+// both from Open Library subjects. Keep Warnings and Audiences in step with
+// the SET/ENUM columns in the migrations.
+package contentwarnings
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Warnings are the values of books.content_warnings, in display order
+var Warnings = []string{
+	"violence",
+	"sexual_content",
+	"sexual_violence",
+	"abuse",
+	"self_harm",
+	"substance_abuse",
+	"war",
+	"horror",
+}
+
+// Audiences are the values of books.audience_rating, youngest first
+var Audiences = []string{"children", "teen", "adult"}
+
+// subjectKeywords maps subject phrases (lower case) onto warnings
+var subjectKeywords = map[string][]string{
+	"violence":        {"violence", "murder", "serial killer", "torture", "massacre"},
+	"sexual_content":  {"erotica", "erotic", "sexual"},
+	"sexual_violence": {"rape", "sexual abuse", "sexual assault"},
+	"abuse":           {"child abuse", "domestic abuse", "domestic violence", "family violence", "abused"},
+	"self_harm":       {"suicide", "self-harm", "self-mutilation", "eating disorder"},
+	"substance_abuse": {"substance abuse", "drug abuse", "drug use", "alcoholism", "addiction"},
+	"war":             {"war", "genocide", "holocaust"},
+	"horror":          {"horror"},
+}
+
+// Parse normalises a list of warnings into Warnings order, rejecting
+// unknown ones
+func Parse(raw []string) ([]string, error) {
+	seen := map[string]bool{}
+	for _, w := range raw {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w == "" {
+			continue
+		}
+		if _, ok := subjectKeywords[w]; !ok {
+			return nil, fmt.Errorf("unknown content warning: %s (want one of %s)", w, strings.Join(Warnings, ", "))
+		}
+		seen[w] = true
+	}
+	warnings := []string{}
+	for _, w := range Warnings {
+		if seen[w] {
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings, nil
+}
+
+// Split turns a content_warnings column value ("violence,war") into a list
+func Split(raw string) []string {
+	warnings := []string{}
+	for _, w := range strings.Split(raw, ",") {
+		if w != "" {
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings
+}
+
+// FromSubjects lists the warnings suggested by a book's subjects. Keywords
+// match whole words, so "war" doesn't flag "software" and "Warsaw".
+func FromSubjects(subjects []string) []string {
+	found := []string{}
+	for _, w := range Warnings {
+		for _, keyword := range subjectKeywords[w] {
+			if anySubjectHas(subjects, keyword) {
+				found = append(found, w)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// ValidAudience reports whether a is one of Audiences
+func ValidAudience(a string) bool {
+	return audienceRank(a) >= 0
+}
+
+// AudienceFromSubjects guesses a book's audience from its subjects; empty
+// when they don't say
+func AudienceFromSubjects(subjects []string) string {
+	switch {
+	case anySubjectHas(subjects, "erotica"):
+		return "adult"
+	case anySubjectHas(subjects, "young adult"):
+		return "teen"
+	case anySubjectHas(subjects, "juvenile"), anySubjectHas(subjects, "children's"):
+		return "children"
+	}
+	return ""
+}
+
+// Within lists the audiences up to and including max (all of them when max
+// is empty)
+func Within(max string) []string {
+	if max == "" {
+		return Audiences
+	}
+	return Audiences[:audienceRank(max)+1]
+}
+
+func audienceRank(a string) int {
+	for i, known := range Audiences {
+		if known == a {
+			return i
+		}
+	}
+	return -1
+}
+
+// anySubjectHas reports whether a subject contains phrase as whole words
+func anySubjectHas(subjects []string, phrase string) bool {
+	for _, s := range subjects {
+		s = " " + strings.Join(strings.FieldsFunc(strings.ToLower(s), isSeparator), " ") + " "
+		if strings.Contains(s, " "+phrase+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func isSeparator(r rune) bool {
+	return r == ' ' || r == ',' || r == ';' || r == ':' || r == '(' || r == ')' || r == '.' || r == '/'
+}
@@ -0,0 +1,41 @@
+package contentwarnings
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFromSubjects(t *testing.T) {
+	got := FromSubjects([]string{"World War, 1939-1945 -- Fiction", "Drug abuse", "Software engineering", "Murder"})
+	if strings.Join(got, ",") != "violence,substance_abuse,war" {
+		t.Fatalf("unexpected warnings: %v", got)
+	}
+	if got := FromSubjects([]string{"Warsaw (Poland)", "Hobbits"}); len(got) != 0 {
+		t.Fatalf("expected no warnings, got %v", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	got, err := Parse([]string{" War", "violence", "", "war"})
+	if err != nil || strings.Join(got, ",") != "violence,war" {
+		t.Fatalf("unexpected warnings: %v, %v", got, err)
+	}
+	if _, err := Parse([]string{"spiders"}); err == nil {
+		t.Fatalf("expected an error for an unknown warning")
+	}
+}
+
+func TestAudience(t *testing.T) {
+	if a := AudienceFromSubjects([]string{"Young adult fiction", "Dragons"}); a != "teen" {
+		t.Fatalf("expected teen, got %q", a)
+	}
+	if a := AudienceFromSubjects([]string{"Juvenile fiction"}); a != "children" {
+		t.Fatalf("expected children, got %q", a)
+	}
+	if got := Within("teen"); strings.Join(got, ",") != "children,teen" {
+		t.Fatalf("unexpected audiences: %v", got)
+	}
+	if ValidAudience("toddler") {
+		t.Fatalf("toddler isn't an audience")
+	}
+}
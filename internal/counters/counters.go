@@ -0,0 +1,92 @@
+// Package counters maintains book_counters, the per-tenant like and rating
+// totals for each book. Bump runs in the transaction that writes the
+// interaction, so the totals move with the raw events; Rebuild recomputes
+// them after jobs delete interactions in bulk.
+package counters
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Execer is satisfied by *sql.DB and *sql.Tx
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// bumpSQL adds one interaction's worth to a book's counters. The upsert is
+// a single row-locking statement, so concurrent writers never lose updates.
+const bumpSQL = `
+	INSERT INTO book_counters (organization_id, book_id, likes, ratings, rating_sum)
+	VALUES (?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		likes = likes + VALUES(likes),
+		ratings = ratings + VALUES(ratings),
+		rating_sum = rating_sum + VALUES(rating_sum)`
+
+// countSQL recomputes counters from interactions; rebuildSQL and recountSQL
+// finish it for every book or for one
+const countSQL = `
+	INSERT INTO book_counters (organization_id, book_id, likes, ratings, rating_sum)
+	SELECT organization_id, book_id,
+	       SUM(action = 'like'),
+	       SUM(action = 'rating' AND rating IS NOT NULL),
+	       COALESCE(SUM(CASE WHEN action = 'rating' THEN rating END), 0)
+	FROM interactions
+	WHERE action IN ('like', 'rating') AND deleted_at IS NULL`
+
+const (
+	rebuildSQL = countSQL + `
+	GROUP BY organization_id, book_id`
+	recountSQL = countSQL + ` AND book_id = ?
+	GROUP BY organization_id, book_id`
+)
+
+// Bump counts a new interaction. Views aren't counted, and neither is a
+// rating without a score.
+func Bump(ctx context.Context, db Execer, orgID, bookID int, action string, rating sql.NullInt64) error {
+	var likes, ratings, sum int64
+	switch {
+	case action == "like":
+		likes = 1
+	case action == "rating" && rating.Valid:
+		ratings, sum = 1, rating.Int64
+	default:
+		return nil
+	}
+	_, err := db.ExecContext(ctx, bumpSQL, orgID, bookID, likes, ratings, sum)
+	return err
+}
+
+// Rebuild replaces all counters with totals recomputed from interactions,
+// in one transaction so readers never see them empty
+func Rebuild(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM book_counters"); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, rebuildSQL); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Recount recomputes the counters of the given books, e.g. after their
+// interactions were moved to another book. Run it in the transaction that
+// moved them.
+func Recount(ctx context.Context, db Execer, bookIDs ...int) error {
+	for _, id := range bookIDs {
+		if _, err := db.ExecContext(ctx, "DELETE FROM book_counters WHERE book_id = ?", id); err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, recountSQL, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package counters
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestBump(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectExec("INSERT INTO book_counters .+ON DUPLICATE KEY UPDATE").
+		WithArgs(1, 7, 1, 0, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_counters .+ON DUPLICATE KEY UPDATE").
+		WithArgs(1, 7, 0, 1, 4).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	ctx := context.Background()
+	if err := Bump(ctx, db, 1, 7, "like", sql.NullInt64{}); err != nil {
+		t.Fatalf("like: %v", err)
+	}
+	if err := Bump(ctx, db, 1, 7, "rating", sql.NullInt64{Int64: 4, Valid: true}); err != nil {
+		t.Fatalf("rating: %v", err)
+	}
+	// neither touches the table
+	if err := Bump(ctx, db, 1, 7, "view", sql.NullInt64{}); err != nil {
+		t.Fatalf("view: %v", err)
+	}
+	if err := Bump(ctx, db, 1, 7, "rating", sql.NullInt64{}); err != nil {
+		t.Fatalf("unscored rating: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestRebuild(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM book_counters").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("INSERT INTO book_counters .+SELECT .+FROM interactions").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	if err := Rebuild(context.Background(), db); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestRecount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	for _, id := range []int{3, 9} {
+		mock.ExpectExec("DELETE FROM book_counters WHERE book_id = \\?").
+			WithArgs(id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO book_counters .+FROM interactions\\s+WHERE action IN \\('like', 'rating'\\) AND deleted_at IS NULL AND book_id = \\?\\s+GROUP BY").
+			WithArgs(id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	if err := Recount(context.Background(), db, 3, 9); err != nil {
+		t.Fatalf("recount: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+// Package dberr translates MySQL driver errors into domain errors, so
+// callers can test errors.Is(err, dberr.ErrDuplicate) instead of matching
+// server messages.
+package dberr
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Domain errors
+var (
+	// ErrDuplicate is a unique-constraint violation
+	ErrDuplicate = errors.New("duplicate value")
+	// ErrMissingReference is an insert or update pointing at a row that
+	// doesn't exist (foreign key)
+	ErrMissingReference = errors.New("referenced row does not exist")
+	// ErrReferenced is a delete or update of a row other rows still point at
+	ErrReferenced = errors.New("row is still referenced")
+)
+
+// MySQL server error numbers
+const (
+	numDuplicateEntry  uint16 = 1062
+	numRowIsReferenced uint16 = 1451
+	numNoReferencedRow uint16 = 1452
+)
+
+// Error is a translated MySQL error. It matches both its Kind and the
+// original driver error with errors.Is / errors.As.
+type Error struct {
+	Kind   error
+	Number uint16
+	// Constraint is the key or foreign key named by the server, when given
+	Constraint string
+	Err        error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+// Unwrap exposes the domain error and the driver error
+func (e *Error) Unwrap() []error { return []error{e.Kind, e.Err} }
+
+// Translate wraps MySQL errors with a known domain meaning in *Error and
+// returns everything else unchanged (nil stays nil)
+func Translate(err error) error {
+	var myErr *mysql.MySQLError
+	if err == nil || !errors.As(err, &myErr) {
+		return err
+	}
+	var translated *Error
+	if errors.As(err, &translated) {
+		return err
+	}
+
+	switch myErr.Number {
+	case numDuplicateEntry:
+		return &Error{Kind: ErrDuplicate, Number: myErr.Number, Constraint: duplicateKey(myErr.Message), Err: err}
+	case numNoReferencedRow:
+		return &Error{Kind: ErrMissingReference, Number: myErr.Number, Constraint: foreignKey(myErr.Message), Err: err}
+	case numRowIsReferenced:
+		return &Error{Kind: ErrReferenced, Number: myErr.Number, Constraint: foreignKey(myErr.Message), Err: err}
+	}
+	return err
+}
+
+// Is reports whether err translates to kind
+func Is(err, kind error) bool {
+	return errors.Is(Translate(err), kind)
+}
+
+// Constraint is the key or foreign key a translated error names, or ""
+func Constraint(err error) string {
+	var e *Error
+	if errors.As(Translate(err), &e) {
+		return e.Constraint
+	}
+	return ""
+}
+
+// duplicateKey pulls the key out of "Duplicate entry 'x' for key 'users.uq_handle'"
+func duplicateKey(msg string) string {
+	i := strings.LastIndex(msg, " for key '")
+	if i < 0 {
+		return ""
+	}
+	key := strings.TrimSuffix(msg[i+len(" for key '"):], "'")
+	if dot := strings.LastIndex(key, "."); dot >= 0 {
+		key = key[dot+1:]
+	}
+	return key
+}
+
+// foreignKey pulls the name out of "... CONSTRAINT `fk_x` FOREIGN KEY ..."
+func foreignKey(msg string) string {
+	_, rest, ok := strings.Cut(msg, "CONSTRAINT `")
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(rest, "`")
+	return name
+}
@@ -0,0 +1,34 @@
+package dberr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestTranslate(t *testing.T) {
+	dup := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'ada' for key 'users.uq_users_handle'"}
+	err := Translate(fmt.Errorf("insert user: %w", dup))
+	if !errors.Is(err, ErrDuplicate) || Constraint(err) != "uq_users_handle" {
+		t.Fatalf("expected a duplicate on uq_users_handle, got %v (%q)", err, Constraint(err))
+	}
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) || myErr.Number != 1062 {
+		t.Fatalf("expected the driver error to stay reachable")
+	}
+
+	fk := &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`bookrec`.`interactions`, CONSTRAINT `fk_interactions_book` FOREIGN KEY (`book_id`) REFERENCES `books` (`id`) ON DELETE CASCADE)"}
+	if !Is(fk, ErrMissingReference) || Constraint(fk) != "fk_interactions_book" {
+		t.Fatalf("expected a missing reference on fk_interactions_book, got %q", Constraint(fk))
+	}
+	if !Is(&mysql.MySQLError{Number: 1451}, ErrReferenced) {
+		t.Fatalf("expected 1451 to be ErrReferenced")
+	}
+
+	other := errors.New("connection refused")
+	if Translate(other) != other || Translate(nil) != nil || Is(other, ErrDuplicate) {
+		t.Fatalf("expected non-MySQL errors to pass through")
+	}
+}
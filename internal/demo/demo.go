@@ -0,0 +1,228 @@
+// Package demo generates a plausible demo dataset: a catalogue spread over
+// genres, readers who each favour a couple of them, and interactions that
+// follow those tastes, with a few books in every genre far more popular
+// than the rest. The same seed always gives the same data (bookrec seed).
+// Simulate does the same for configurable taste clusters over any
+// catalogue (cmd/simulate).
+package demo
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Genres are the catalogue's genres; each book has one as its first subject
+var Genres = []string{
+	"science fiction", "fantasy", "mystery", "romance", "history",
+	"biography", "self help", "data science", "horror", "poetry",
+}
+
+// genreWords give each genre's titles their flavour
+var genreWords = map[string][2][]string{
+	"science fiction": {{"Distant", "Silent", "Last", "Quantum", "Hollow"}, {"Star", "Colony", "Signal", "Orbit", "Machine"}},
+	"fantasy":         {{"Broken", "Iron", "Hidden", "Winter", "Ember"}, {"Crown", "Dragon", "Sword", "Kingdom", "Oath"}},
+	"mystery":         {{"Quiet", "Crooked", "Missing", "Seventh", "Locked"}, {"Room", "Witness", "Alibi", "Letter", "House"}},
+	"romance":         {{"Summer", "Second", "Sweet", "Stolen", "Endless"}, {"Promise", "Kiss", "Heart", "Season", "Affair"}},
+	"history":         {{"Forgotten", "Great", "Lost", "Bitter", "Golden"}, {"Empire", "War", "Century", "Revolution", "Republic"}},
+	"biography":       {{"Unlikely", "Private", "Restless", "Early", "Public"}, {"Life", "Years", "Journey", "Portrait", "Memoir"}},
+	"self help":       {{"Daily", "Simple", "Deep", "Small", "Better"}, {"Habits", "Focus", "Mind", "Steps", "Courage"}},
+	"data science":    {{"Practical", "Applied", "Modern", "Hands-On", "Essential"}, {"Statistics", "Models", "Learning", "Data", "Inference"}},
+	"horror":          {{"Pale", "Rotten", "Whispering", "Drowned", "Endless"}, {"Night", "Cellar", "Woods", "Hunger", "Dark"}},
+	"poetry":          {{"Small", "Blue", "Salt", "Open", "Late"}, {"Hours", "Songs", "Rivers", "Elegies", "Weather"}},
+}
+
+var (
+	firstNames = []string{"Ada", "Ben", "Chloe", "Dev", "Elena", "Farid", "Grace", "Hugo", "Iris", "Jonah",
+		"Kiran", "Lena", "Mateo", "Nadia", "Omar", "Priya", "Quinn", "Rosa", "Sami", "Tess"}
+	lastNames = []string{"Abbott", "Baptiste", "Castillo", "Dunmore", "Eriksen", "Fontaine", "Gupta", "Hale",
+		"Ibarra", "Jansen", "Kowalski", "Lindqvist", "Moreau", "Nakamura", "Okafor", "Petrov"}
+)
+
+// Book is a generated catalogue entry. Key is unique and stable for a seed,
+// so re-seeding updates books instead of duplicating them.
+type Book struct {
+	Key      string
+	Title    string
+	Author   string
+	Genre    string
+	Subjects []string
+	Year     int
+	Pages    int
+}
+
+// Reader is a generated user and the genres they favour
+type Reader struct {
+	Email  string
+	Handle string
+	Genres []string
+}
+
+// Interaction is one reader's action on one book, by index into the
+// readers and books it was generated from. Rating is 0 unless Action is
+// "rating"; Age is how long before now it happened.
+type Interaction struct {
+	Reader int
+	Book   int
+	Action string
+	Rating int
+	Age    time.Duration
+}
+
+// Books generates n books, spread evenly over Genres
+func Books(rng *rand.Rand, n int) []Book {
+	books := make([]Book, n)
+	for i := range books {
+		genre := Genres[i%len(Genres)]
+		words := genreWords[genre]
+		title := fmt.Sprintf("The %s %s", pick(rng, words[0]), pick(rng, words[1]))
+		if rng.Intn(3) == 0 {
+			title += fmt.Sprintf(": Book %d", 2+rng.Intn(4))
+		}
+		books[i] = Book{
+			Key:      fmt.Sprintf("demo:%d", i+1),
+			Title:    title,
+			Author:   pick(rng, firstNames) + " " + pick(rng, lastNames),
+			Genre:    genre,
+			Subjects: []string{genre, pick(rng, Genres)},
+			Year:     1950 + rng.Intn(76),
+			Pages:    120 + rng.Intn(600),
+		}
+	}
+	return books
+}
+
+// Readers generates n readers who each favour one or two genres
+func Readers(rng *rand.Rand, n int) []Reader {
+	readers := make([]Reader, n)
+	for i := range readers {
+		handle := fmt.Sprintf("reader%02d", i+1)
+		favourites := []string{pick(rng, Genres)}
+		if rng.Intn(2) == 0 {
+			if second := pick(rng, Genres); second != favourites[0] {
+				favourites = append(favourites, second)
+			}
+		}
+		readers[i] = Reader{
+			Email:  handle + "@demo.bookrec.test",
+			Handle: handle,
+			Genres: favourites,
+		}
+	}
+	return readers
+}
+
+// Interactions generates each reader's history over the last 90 days: 10 to
+// 30 likes, mostly in their favourite genres, a view for every like, a
+// rating for about half of them (higher inside their genres) and a few
+// stray views. Within a genre, earlier books are picked far more often, so
+// every genre has its bestsellers.
+func Interactions(rng *rand.Rand, readers []Reader, books []Book) []Interaction {
+	byGenre := booksByGenre(books)
+	out := []Interaction{}
+	for r, reader := range readers {
+		lists := make([][]int, len(reader.Genres))
+		for i, g := range reader.Genres {
+			lists[i] = byGenre[g]
+		}
+		out = append(out, history(rng, r, interleave(lists), len(books), 0.2, 10+rng.Intn(21))...)
+	}
+	return out
+}
+
+// history generates one reader's interactions with likes books: each is
+// drawn from taste (skewed to its first entries) or, with probability
+// noise, from anywhere in the catalogue of total books. Every like comes
+// with a view, about half with a rating (4 or 5 inside taste, 2 to 4
+// outside), and 5 to 15 other books are only viewed.
+func history(rng *rand.Rand, reader int, taste []int, total int, noise float64, likes int) []Interaction {
+	if total == 0 {
+		return nil
+	}
+	inTaste := map[int]bool{}
+	for _, b := range taste {
+		inTaste[b] = true
+	}
+	liked := map[int]bool{}
+	likedInTaste := 0
+	for len(liked) < likes && len(liked) < total {
+		// once taste is used up, the rest comes from anywhere
+		b := rng.Intn(total)
+		if likedInTaste < len(taste) && rng.Float64() >= noise {
+			b = taste[skewed(rng, len(taste))]
+		}
+		if liked[b] {
+			continue
+		}
+		liked[b] = true
+		if inTaste[b] {
+			likedInTaste++
+		}
+	}
+
+	out := []Interaction{}
+	for b := 0; b < total; b++ {
+		if !liked[b] {
+			continue
+		}
+		age := time.Duration(rng.Int63n(int64(90 * 24 * time.Hour)))
+		out = append(out,
+			Interaction{Reader: reader, Book: b, Action: "view", Age: age + time.Hour},
+			Interaction{Reader: reader, Book: b, Action: "like", Age: age})
+		if rng.Intn(2) == 0 {
+			rating := 2 + rng.Intn(3)
+			if inTaste[b] {
+				rating = 4 + rng.Intn(2)
+			}
+			out = append(out, Interaction{Reader: reader, Book: b, Action: "rating", Rating: rating, Age: age})
+		}
+	}
+
+	for n := 5 + rng.Intn(11); n > 0; n-- {
+		b := rng.Intn(total)
+		if liked[b] {
+			continue
+		}
+		liked[b] = true // one view per book
+		out = append(out, Interaction{Reader: reader, Book: b, Action: "view",
+			Age: time.Duration(rng.Int63n(int64(90 * 24 * time.Hour)))})
+	}
+	return out
+}
+
+// booksByGenre indexes books by genre, keeping catalogue order
+func booksByGenre(books []Book) map[string][]int {
+	byGenre := map[string][]int{}
+	for i, b := range books {
+		byGenre[b.Genre] = append(byGenre[b.Genre], i)
+	}
+	return byGenre
+}
+
+// interleave merges lists round-robin, so each list's first entries stay
+// near the front
+func interleave(lists [][]int) []int {
+	out := []int{}
+	for i := 0; ; i++ {
+		added := false
+		for _, l := range lists {
+			if i < len(l) {
+				out = append(out, l[i])
+				added = true
+			}
+		}
+		if !added {
+			return out
+		}
+	}
+}
+
+// skewed picks an index below n, favouring small ones
+func skewed(rng *rand.Rand, n int) int {
+	return int(float64(n) * math.Pow(rng.Float64(), 3))
+}
+
+func pick(rng *rand.Rand, from []string) string {
+	return from[rng.Intn(len(from))]
+}
@@ -0,0 +1,95 @@
+package demo
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSameSeedSameData(t *testing.T) {
+	gen := func() ([]Book, []Reader, []Interaction) {
+		rng := rand.New(rand.NewSource(7))
+		books := Books(rng, 100)
+		readers := Readers(rng, 10)
+		return books, readers, Interactions(rng, readers, books)
+	}
+	b1, r1, i1 := gen()
+	b2, r2, i2 := gen()
+	if !reflect.DeepEqual(b1, b2) || !reflect.DeepEqual(r1, r2) || !reflect.DeepEqual(i1, i2) {
+		t.Fatal("expected the same seed to generate the same data")
+	}
+}
+
+func TestInteractionsFollowTaste(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	books := Books(rng, 500)
+	readers := Readers(rng, 20)
+	seen := map[[2]int]map[string]bool{}
+	inGenre, likes := 0, 0
+	for _, in := range Interactions(rng, readers, books) {
+		key := [2]int{in.Reader, in.Book}
+		if seen[key] == nil {
+			seen[key] = map[string]bool{}
+		}
+		if seen[key][in.Action] {
+			t.Fatalf("reader %d has two %s interactions with book %d", in.Reader, in.Action, in.Book)
+		}
+		seen[key][in.Action] = true
+		if (in.Action == "rating") != (in.Rating > 0) {
+			t.Fatalf("unexpected rating %d on a %s", in.Rating, in.Action)
+		}
+		if in.Action == "like" {
+			likes++
+			if contains(readers[in.Reader].Genres, books[in.Book].Genre) {
+				inGenre++
+			}
+		}
+	}
+	if likes < 10*len(readers) {
+		t.Fatalf("expected at least 10 likes per reader, got %d", likes)
+	}
+	if float64(inGenre)/float64(likes) < 0.7 {
+		t.Fatalf("expected most likes in the readers' genres, got %d of %d", inGenre, likes)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	books := Books(rng, 300)
+	taste := Taste{Readers: 30, Clusters: 4, MinLikes: 10, MaxLikes: 20}
+	interactions, membership, clusters := Simulate(rng, taste, books)
+	if len(clusters) != 4 || len(membership) != 30 {
+		t.Fatalf("expected 4 clusters and 30 readers, got %d and %d", len(clusters), len(membership))
+	}
+	likes := map[int]int{}
+	for _, in := range interactions {
+		if in.Action != "like" {
+			continue
+		}
+		likes[in.Reader]++
+		// without noise every like is in the reader's cluster
+		if !contains(clusters[membership[in.Reader]], books[in.Book].Genre) {
+			t.Fatalf("reader %d liked a %s book outside cluster %v", in.Reader, books[in.Book].Genre, clusters[membership[in.Reader]])
+		}
+	}
+	for r := 0; r < taste.Readers; r++ {
+		if likes[r] < taste.MinLikes || likes[r] > taste.MaxLikes {
+			t.Fatalf("reader %d has %d likes", r, likes[r])
+		}
+	}
+
+	// more clusters than genres
+	_, _, clusters = Simulate(rng, Taste{Readers: 1, Clusters: 50, Noise: 1, MinLikes: 1}, books)
+	if len(clusters) != len(Genres) {
+		t.Fatalf("expected %d clusters, got %d", len(Genres), len(clusters))
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package demo
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Taste configures Simulate
+type Taste struct {
+	// Readers is the number of simulated readers
+	Readers int
+	// Clusters splits the catalogue's genres into this many tastes; every
+	// reader has one. It is capped at the number of genres.
+	Clusters int
+	// Noise is the share of likes picked from anywhere in the catalogue
+	// rather than the reader's cluster, 0 to 1
+	Noise float64
+	// MinLikes and MaxLikes bound each reader's likes
+	MinLikes, MaxLikes int
+}
+
+// Simulate generates interactions of t.Readers readers with books (only
+// Genre is used): each reader belongs to a taste cluster of genres and
+// likes and rates mostly within it. It also returns each reader's cluster,
+// and the genres of every cluster.
+func Simulate(rng *rand.Rand, t Taste, books []Book) ([]Interaction, []int, [][]string) {
+	byGenre := booksByGenre(books)
+	genres := make([]string, 0, len(byGenre))
+	for g := range byGenre {
+		genres = append(genres, g)
+	}
+	sort.Strings(genres)
+
+	clusters := t.Clusters
+	if clusters > len(genres) {
+		clusters = len(genres)
+	}
+	if clusters < 1 {
+		clusters = 1
+	}
+	clusterGenres := make([][]string, clusters)
+	for i, g := range genres {
+		clusterGenres[i%clusters] = append(clusterGenres[i%clusters], g)
+	}
+	tastes := make([][]int, clusters)
+	for c, gs := range clusterGenres {
+		lists := make([][]int, len(gs))
+		for i, g := range gs {
+			lists[i] = byGenre[g]
+		}
+		tastes[c] = interleave(lists)
+	}
+
+	out := []Interaction{}
+	membership := make([]int, t.Readers)
+	for r := range membership {
+		membership[r] = rng.Intn(clusters)
+		likes := t.MinLikes
+		if t.MaxLikes > t.MinLikes {
+			likes += rng.Intn(t.MaxLikes - t.MinLikes + 1)
+		}
+		out = append(out, history(rng, r, tastes[membership[r]], len(books), t.Noise, likes)...)
+	}
+	return out, membership, clusterGenres
+}
@@ -0,0 +1,180 @@
+// Package genres maps books' raw subjects onto a short, curated genre
+// taxonomy, so readers can filter by genre without wading through Open
+// Library's thousands of subject headings. Keep All in step with the genres
+// seeded by the migrations.
+package genres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Genre is one entry of the taxonomy
+type Genre struct {
+	Slug string
+	Name string
+	// keywords are subject phrases (lower case, whole words) that put a
+	// book in the genre
+	keywords []string
+	// excludes are phrases that keep a subject out of the genre even when
+	// it has a keyword ("science fiction" isn't science)
+	excludes []string
+}
+
+// All is the taxonomy, in display order
+var All = []Genre{
+	{Slug: "art", Name: "Art", keywords: []string{"art", "painting", "photography", "architecture"}},
+	{Slug: "biography", Name: "Biography & Memoir", keywords: []string{"biography", "autobiography", "memoir", "memoirs"}},
+	{Slug: "business", Name: "Business & Economics", keywords: []string{"business", "economics", "management", "entrepreneurship", "finance", "marketing"}},
+	{Slug: "childrens", Name: "Children's", keywords: []string{"juvenile fiction", "juvenile literature", "children's", "picture books"}},
+	{Slug: "classics", Name: "Classics", keywords: []string{"classics", "classic literature"}},
+	{Slug: "comics", Name: "Comics & Graphic Novels", keywords: []string{"comics", "graphic novels", "manga"}},
+	{Slug: "cooking", Name: "Cooking", keywords: []string{"cooking", "cookbooks", "recipes"}},
+	{Slug: "fantasy", Name: "Fantasy", keywords: []string{"fantasy", "magic", "dragons", "wizards", "elves"}},
+	{Slug: "historical-fiction", Name: "Historical Fiction", keywords: []string{"historical fiction"}},
+	{Slug: "history", Name: "History", keywords: []string{"history"}, excludes: []string{"historical fiction"}},
+	{Slug: "horror", Name: "Horror", keywords: []string{"horror", "ghost stories", "vampires", "zombies"}},
+	{Slug: "humor", Name: "Humor", keywords: []string{"humor", "humour", "comedy"}},
+	{Slug: "mystery", Name: "Mystery", keywords: []string{"mystery", "mysteries", "detective", "detectives", "private investigators"}},
+	{Slug: "philosophy", Name: "Philosophy", keywords: []string{"philosophy", "ethics", "stoicism"}},
+	{Slug: "poetry", Name: "Poetry", keywords: []string{"poetry", "poems"}},
+	{Slug: "psychology", Name: "Psychology", keywords: []string{"psychology"}},
+	{Slug: "religion", Name: "Religion & Spirituality", keywords: []string{"religion", "christianity", "buddhism", "islam", "judaism", "spirituality", "bible"}},
+	{Slug: "romance", Name: "Romance", keywords: []string{"romance", "love stories"}},
+	{Slug: "science", Name: "Science", keywords: []string{"science", "physics", "chemistry", "biology", "astronomy", "mathematics"},
+		excludes: []string{"science fiction", "computer science", "data science", "political science", "social science"}},
+	{Slug: "science-fiction", Name: "Science Fiction", keywords: []string{"science fiction", "sci-fi", "space opera", "time travel", "dystopias", "cyberpunk"}},
+	{Slug: "self-help", Name: "Self-Help", keywords: []string{"self-help", "self help", "personal development", "self-actualization", "habits"}},
+	{Slug: "technology", Name: "Technology", keywords: []string{"technology", "computers", "computer science", "data science", "programming", "software", "machine learning", "artificial intelligence"}},
+	{Slug: "thriller", Name: "Thriller", keywords: []string{"thriller", "thrillers", "suspense", "espionage", "spy stories"}},
+	{Slug: "travel", Name: "Travel", keywords: []string{"travel", "voyages and travels"}},
+	{Slug: "young-adult", Name: "Young Adult", keywords: []string{"young adult"}},
+}
+
+// Valid reports whether slug names a genre of the taxonomy
+func Valid(slug string) bool {
+	for _, g := range All {
+		if g.Slug == slug {
+			return true
+		}
+	}
+	return false
+}
+
+// FromSubjects lists the slugs of the genres a book's subjects put it in,
+// in All order
+func FromSubjects(subjects []string) []string {
+	normalized := make([]string, 0, len(subjects))
+	for _, s := range subjects {
+		normalized = append(normalized, " "+strings.Join(strings.FieldsFunc(strings.ToLower(s), isSeparator), " ")+" ")
+	}
+	found := []string{}
+	for _, g := range All {
+		if g.matches(normalized) {
+			found = append(found, g.Slug)
+		}
+	}
+	return found
+}
+
+// matches reports whether any normalized subject has one of the genre's
+// keywords and none of its excludes
+func (g Genre) matches(subjects []string) bool {
+	for _, s := range subjects {
+		if hasAny(s, g.keywords) && !hasAny(s, g.excludes) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAny(subject string, phrases []string) bool {
+	for _, p := range phrases {
+		if strings.Contains(subject, " "+p+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func isSeparator(r rune) bool {
+	return r == ' ' || r == ',' || r == ';' || r == ':' || r == '(' || r == ')' || r == '.' || r == '/'
+}
+
+// Execer is satisfied by *sql.DB and *sql.Tx
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Assign replaces a book's genres in book_genres with the ones its
+// subjects put it in
+func Assign(ctx context.Context, db Execer, bookID int64, subjects []string) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM book_genres WHERE book_id = ?", bookID); err != nil {
+		return err
+	}
+	slugs := FromSubjects(subjects)
+	if len(slugs) == 0 {
+		return nil
+	}
+	args := make([]interface{}, 0, len(slugs)+1)
+	args = append(args, bookID)
+	for _, slug := range slugs {
+		args = append(args, slug)
+	}
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO book_genres (book_id, genre_id)
+		SELECT ?, id FROM genres WHERE slug IN (?`+strings.Repeat(", ?", len(slugs)-1)+`)`, args...)
+	return err
+}
+
+// backfillBatch is the number of books Backfill reads at a time
+const backfillBatch = 500
+
+// Backfill reassigns every book's genres from its subjects, for books added
+// before the taxonomy or after it changes, and returns how many books it
+// went through
+func Backfill(ctx context.Context, db *sql.DB) (int, error) {
+	type book struct {
+		id       int64
+		subjects []string
+	}
+	done := 0
+	var after int64
+	for {
+		rows, err := db.QueryContext(ctx,
+			"SELECT id, subjects FROM books WHERE id > ? ORDER BY id LIMIT ?", after, backfillBatch)
+		if err != nil {
+			return done, err
+		}
+		var batch []book
+		for rows.Next() {
+			var b book
+			var raw []byte
+			if err := rows.Scan(&b.id, &raw); err != nil {
+				_ = rows.Close()
+				return done, err
+			}
+			// unreadable subjects leave the book without genres
+			_ = json.Unmarshal(raw, &b.subjects)
+			batch = append(batch, b)
+		}
+		_ = rows.Close()
+		if err := rows.Err(); err != nil {
+			return done, err
+		}
+
+		for _, b := range batch {
+			if err := Assign(ctx, db, b.id, b.subjects); err != nil {
+				return done, fmt.Errorf("book %d: %w", b.id, err)
+			}
+			done++
+			after = b.id
+		}
+		if len(batch) < backfillBatch {
+			return done, nil
+		}
+	}
+}
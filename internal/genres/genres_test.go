@@ -0,0 +1,103 @@
+package genres
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestFromSubjects(t *testing.T) {
+	got := FromSubjects([]string{"Fantasy fiction", "Dragons", "Science fiction", "Young adult fiction", "Middle Earth (Imaginary place)"})
+	if strings.Join(got, ",") != "fantasy,science-fiction,young-adult" {
+		t.Fatalf("unexpected genres: %v", got)
+	}
+	got = FromSubjects([]string{"Computer science", "Historical fiction", "Physics", "Self-help techniques"})
+	if strings.Join(got, ",") != "historical-fiction,science,self-help,technology" {
+		t.Fatalf("unexpected genres: %v", got)
+	}
+	if got := FromSubjects([]string{"Artists", "Magical realism"}); len(got) != 0 {
+		t.Fatalf("expected no genres, got %v", got)
+	}
+}
+
+func TestValid(t *testing.T) {
+	if !Valid("science-fiction") || Valid("Science Fiction") || Valid("") {
+		t.Fatalf("unexpected Valid results")
+	}
+}
+
+func TestAssign(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectExec("DELETE FROM book_genres WHERE book_id = \\?").
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_genres \\(book_id, genre_id\\)\\s+SELECT \\?, id FROM genres WHERE slug IN \\(\\?, \\?\\)").
+		WithArgs(int64(7), "horror", "poetry").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	// subjects that map to no genre only clear the old ones
+	mock.ExpectExec("DELETE FROM book_genres WHERE book_id = \\?").
+		WithArgs(int64(8)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := Assign(context.Background(), db, 7, []string{"Poetry", "Ghost stories"}); err != nil {
+		t.Fatalf("Assign: %v", err)
+	}
+	if err := Assign(context.Background(), db, 8, []string{"Hobbits"}); err != nil {
+		t.Fatalf("Assign: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestAllSeeded(t *testing.T) {
+	seed, err := os.ReadFile("../../db/migrations/000054_add_genres.up.sql")
+	if err != nil {
+		t.Fatalf("read migration: %v", err)
+	}
+	for _, g := range All {
+		row := "('" + g.Slug + "', '" + strings.ReplaceAll(g.Name, "'", "''") + "')"
+		if !strings.Contains(string(seed), row) {
+			t.Fatalf("genre %s isn't seeded as %s", g.Slug, row)
+		}
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT id, subjects FROM books WHERE id > \\? ORDER BY id LIMIT \\?").
+		WithArgs(int64(0), backfillBatch).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subjects"}).
+			AddRow(3, `["Horror tales"]`).
+			AddRow(5, nil))
+	mock.ExpectExec("DELETE FROM book_genres WHERE book_id = \\?").
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO book_genres").
+		WithArgs(int64(3), "horror").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM book_genres WHERE book_id = \\?").
+		WithArgs(int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	n, err := Backfill(context.Background(), db)
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 books, got %d, %v", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+// Package googlebooks fetches volumes from the Google Books API and saves
+// them into the books catalogue, next to what Open Library ingestion
+// brings in. A volume is an edition, so before adding one it's matched to a
+// book we already have by ISBN or Open Library work key.
+package googlebooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/YeswanthC7/bookrec/internal/isbn"
+)
+
+// DefaultBaseURL is the public Google Books API
+const DefaultBaseURL = "https://www.googleapis.com/books/v1"
+
+// MaxResults is the most volumes the API returns per request
+const MaxResults = 40
+
+// Volume is one volume from the volumes API
+type Volume struct {
+	ID   string `json:"id"`
+	Info struct {
+		Title         string   `json:"title"`
+		Authors       []string `json:"authors"`
+		PublishedDate string   `json:"publishedDate"`
+		Categories    []string `json:"categories"`
+		PageCount     int      `json:"pageCount"`
+		// Language is a BCP 47 tag ("en", "zh-CN")
+		Language  string `json:"language"`
+		Publisher string `json:"publisher"`
+		// MaturityRating is NOT_MATURE or MATURE
+		MaturityRating      string `json:"maturityRating"`
+		IndustryIdentifiers []struct {
+			// Type is ISBN_10, ISBN_13 or OTHER
+			Type       string `json:"type"`
+			Identifier string `json:"identifier"`
+		} `json:"industryIdentifiers"`
+	} `json:"volumeInfo"`
+	SaleInfo struct {
+		IsEbook bool `json:"isEbook"`
+	} `json:"saleInfo"`
+	AccessInfo struct {
+		Epub struct {
+			IsAvailable bool `json:"isAvailable"`
+		} `json:"epub"`
+		PDF struct {
+			IsAvailable bool `json:"isAvailable"`
+		} `json:"pdf"`
+	} `json:"accessInfo"`
+}
+
+// Language is the volume's language in canonical form, or "" when it's
+// missing or not a language tag
+func (v Volume) Language() string {
+	tag, err := language.Parse(strings.TrimSpace(v.Info.Language))
+	if err != nil || tag == language.Und {
+		return ""
+	}
+	return tag.String()
+}
+
+// Author is the first listed author, or ""
+func (v Volume) Author() string {
+	if len(v.Info.Authors) > 0 {
+		return v.Info.Authors[0]
+	}
+	return ""
+}
+
+// Year is the year of publishedDate ("2005", "2005-11" or "2005-11-15"),
+// or 0 when it's missing
+func (v Volume) Year() int {
+	if len(v.Info.PublishedDate) < 4 {
+		return 0
+	}
+	year, _ := strconv.Atoi(v.Info.PublishedDate[:4])
+	return year
+}
+
+// ISBN13s lists the volume's valid ISBNs as ISBN-13, ISBN-10s converted
+func (v Volume) ISBN13s() []string {
+	var isbns []string
+	seen := map[string]bool{}
+	for _, id := range v.Info.IndustryIdentifiers {
+		if id.Type != "ISBN_13" && id.Type != "ISBN_10" {
+			continue
+		}
+		isbn13, err := isbn.Normalize(id.Identifier)
+		if err != nil || seen[isbn13] {
+			continue
+		}
+		seen[isbn13] = true
+		isbns = append(isbns, isbn13)
+	}
+	return isbns
+}
+
+// Subjects splits the categories ("Fiction / Science Fiction / General")
+// into subjects as Open Library lists them, leaving out "General"
+func (v Volume) Subjects() []string {
+	subjects := []string{}
+	seen := map[string]bool{}
+	for _, category := range v.Info.Categories {
+		for _, s := range strings.Split(category, "/") {
+			s = strings.TrimSpace(s)
+			if s == "" || strings.EqualFold(s, "general") || seen[strings.ToLower(s)] {
+				continue
+			}
+			seen[strings.ToLower(s)] = true
+			subjects = append(subjects, s)
+		}
+	}
+	return subjects
+}
+
+// BookFormats maps the volume onto books.formats. Google Books lists
+// printed books, so print is assumed; ebook is added when Google sells or
+// serves an electronic edition.
+func (v Volume) BookFormats() string {
+	if v.SaleInfo.IsEbook || v.AccessInfo.Epub.IsAvailable || v.AccessInfo.PDF.IsAvailable {
+		return "print,ebook"
+	}
+	return "print"
+}
+
+// Client calls the volumes API
+type Client struct {
+	// APIKey is optional; without one Google allows fewer requests a day
+	APIKey string
+	// BaseURL defaults to DefaultBaseURL (tests point it elsewhere)
+	BaseURL string
+	// HTTP defaults to a client with a 10s timeout
+	HTTP *http.Client
+}
+
+// Search runs a volumes query (e.g. `subject:fantasy`) and returns up to
+// limit books, at most MaxResults
+func (c *Client) Search(ctx context.Context, query string, limit int) ([]Volume, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	client := c.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if limit < 1 || limit > MaxResults {
+		limit = MaxResults
+	}
+
+	q := url.Values{"q": {query}, "printType": {"books"}, "maxResults": {strconv.Itoa(limit)}}
+	if c.APIKey != "" {
+		q.Set("key", c.APIKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(base, "/")+"/volumes?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("google books search: %s", resp.Status)
+	}
+
+	var result struct {
+		Items []Volume `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("google books search: %w", err)
+	}
+	return result.Items, nil
+}
@@ -0,0 +1,161 @@
+package googlebooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const duneVolume = `{"items":[{"id":"B1hSG45JCX4C","volumeInfo":{"title":"Dune","authors":["Frank Herbert"],
+	"publishedDate":"1965-08","language":"en","publisher":"Ace","categories":["Fiction / Science Fiction / General"],"pageCount":412,
+	"industryIdentifiers":[{"type":"ISBN_10","identifier":"0441013597"},{"type":"ISBN_13","identifier":"9780441013593"},
+	{"type":"OTHER","identifier":"UOM:39015051262590"}]},"saleInfo":{"isEbook":true}}]}`
+
+func search(t *testing.T) Volume {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/volumes" || q.Get("q") != "subject:science fiction" || q.Get("maxResults") != "10" || q.Get("key") != "k" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		_, _ = w.Write([]byte(duneVolume))
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, APIKey: "k"}
+	volumes, err := c.Search(context.Background(), "subject:science fiction", 10)
+	if err != nil || len(volumes) != 1 {
+		t.Fatalf("expected one volume, got %v, %v", volumes, err)
+	}
+	return volumes[0]
+}
+
+func TestSearch(t *testing.T) {
+	v := search(t)
+	if v.ID != "B1hSG45JCX4C" || v.Author() != "Frank Herbert" || v.Year() != 1965 || v.BookFormats() != "print,ebook" ||
+		v.Language() != "en" {
+		t.Fatalf("unexpected volume: %+v", v)
+	}
+	// the ISBN-10 is the same edition as the ISBN-13
+	if got := v.ISBN13s(); !reflect.DeepEqual(got, []string{"9780441013593"}) {
+		t.Fatalf("unexpected ISBNs %v", got)
+	}
+	if got := v.Subjects(); !reflect.DeepEqual(got, []string{"Fiction", "Science Fiction"}) {
+		t.Fatalf("unexpected subjects %v", got)
+	}
+}
+
+func TestUpsert_New(t *testing.T) {
+	v := search(t)
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT COALESCE\\(merged_into, id\\) FROM books WHERE google_books_id = \\?").
+		WithArgs("B1hSG45JCX4C").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("FROM book_isbns bi\\s+JOIN books b ON b.id = bi.book_id\\s+WHERE bi.isbn13 IN \\(\\?\\) AND b.organization_id IS NULL").
+		WithArgs("9780441013593").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT COALESCE\\(merged_into, id\\) FROM books WHERE open_library_key = \\?").
+		WithArgs("/works/OL893415W").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("INSERT INTO books \\(uuid, slug, google_books_id, title, author, subjects, published_year, formats, page_count, content_warnings, audience_rating, language, publisher\\)").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "B1hSG45JCX4C", "Dune", "Frank Herbert",
+			`["Fiction","Science Fiction"]`, 1965, "print,ebook", 412, "", nil, "en", "Ace").
+		WillReturnResult(sqlmock.NewResult(77, 1))
+	mock.ExpectExec("DELETE FROM book_genres WHERE book_id = \\?").
+		WithArgs(int64(77)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO book_genres").
+		WithArgs(int64(77), "science-fiction").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO authors").
+		WithArgs("Frank Herbert").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec("UPDATE books SET author_id = \\? WHERE id = \\?").
+		WithArgs(int64(5), int64(77)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT IGNORE INTO book_isbns \\(isbn13, book_id\\) VALUES \\(\\?, \\?\\)").
+		WithArgs("9780441013593", int64(77)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	workKey := func(ctx context.Context, isbn13 string) (string, error) {
+		if isbn13 != "9780441013593" {
+			t.Errorf("unexpected work key lookup of %s", isbn13)
+		}
+		return "/works/OL893415W", nil
+	}
+	id, created, err := Upsert(context.Background(), db, v, workKey)
+	if err != nil || id != 77 || !created {
+		t.Fatalf("expected the new book 77, got %d, %v, %v", id, created, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestUpsert_MatchedByISBN(t *testing.T) {
+	v := search(t)
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("FROM books WHERE google_books_id = \\?").
+		WithArgs("B1hSG45JCX4C").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("FROM book_isbns bi").
+		WithArgs("9780441013593").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+	// Open Library's book only gains what it lacks
+	mock.ExpectExec("UPDATE books SET\\s+google_books_id = COALESCE\\(google_books_id, \\?\\),\\s+page_count = COALESCE\\(page_count, \\?\\)").
+		WithArgs("B1hSG45JCX4C", 412, 1965, "en", "Ace", int64(12)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT IGNORE INTO book_isbns").
+		WithArgs("9780441013593", int64(12)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	workKey := func(context.Context, string) (string, error) {
+		t.Error("an ISBN match needs no work key lookup")
+		return "", nil
+	}
+	id, created, err := Upsert(context.Background(), db, v, workKey)
+	if err != nil || id != 12 || created {
+		t.Fatalf("expected the existing book 12, got %d, %v, %v", id, created, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestUpsert_WorkKeyLookupFails(t *testing.T) {
+	v := search(t)
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("FROM books WHERE google_books_id = \\?").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("FROM book_isbns bi").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// without knowing whether Open Library has the work, the volume is
+	// left for the next run rather than risk a duplicate
+	lookupErr := errors.New("open library search: 503 Service Unavailable")
+	workKey := func(context.Context, string) (string, error) { return "", lookupErr }
+	if _, _, err := Upsert(context.Background(), db, v, workKey); !errors.Is(err, lookupErr) {
+		t.Fatalf("expected the lookup error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package googlebooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/YeswanthC7/bookrec/internal/authors"
+	"github.com/YeswanthC7/bookrec/internal/contentwarnings"
+	"github.com/YeswanthC7/bookrec/internal/genres"
+	"github.com/YeswanthC7/bookrec/internal/ids"
+)
+
+// Querier is satisfied by *sql.DB and *sql.Tx
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// maxPublisherLength fits books.publisher
+const maxPublisherLength = 255
+
+// WorkKeyFunc finds the Open Library work key ("/works/OL82563W") an
+// ISBN-13 belongs to, or "" when Open Library doesn't know it
+type WorkKeyFunc func(ctx context.Context, isbn13 string) (string, error)
+
+// Upsert saves the volume into the shared catalogue. A volume seen before,
+// or whose ISBNs or Open Library work (looked up with workKey, which may be
+// nil) we already have, belongs to that book: it only fills in the page
+// count, year, language and publisher the book lacks, and Open Library's
+// data is never overwritten. Otherwise the volume is added as a new book,
+// linked to its author and the genres its categories map to. Either way its
+// ISBNs are recorded in book_isbns. created is true when the book is new.
+func Upsert(ctx context.Context, db Querier, v Volume, workKey WorkKeyFunc) (id int64, created bool, err error) {
+	volumeID, title := strings.TrimSpace(v.ID), strings.TrimSpace(v.Info.Title)
+	if volumeID == "" || title == "" {
+		// the volume ID keeps re-ingesting it idempotent
+		return 0, false, errors.New("google books volume needs an id and a title")
+	}
+	isbns := v.ISBN13s()
+	var pages, year interface{}
+	if v.Info.PageCount > 0 {
+		pages = v.Info.PageCount
+	}
+	if y := v.Year(); y > 0 {
+		year = y
+	}
+	var lang, publisher interface{}
+	if l := v.Language(); l != "" {
+		lang = l
+	}
+	if p := []rune(strings.TrimSpace(v.Info.Publisher)); len(p) > 0 {
+		publisher = string(p[:min(len(p), maxPublisherLength)])
+	}
+
+	id, err = match(ctx, db, volumeID, isbns, workKey)
+	if err != nil {
+		return 0, false, err
+	}
+	if id != 0 {
+		_, err = db.ExecContext(ctx, `
+			UPDATE books SET
+				google_books_id = COALESCE(google_books_id, ?),
+				page_count = COALESCE(page_count, ?),
+				published_year = COALESCE(NULLIF(published_year, 0), ?),
+				language = COALESCE(language, ?),
+				publisher = COALESCE(publisher, ?)
+			WHERE id = ?`, volumeID, pages, year, lang, publisher, id)
+	} else {
+		subjects := v.Subjects()
+		subjectsJSON, _ := json.Marshal(subjects)
+		audience := contentwarnings.AudienceFromSubjects(subjects)
+		if audience == "" && v.Info.MaturityRating == "MATURE" {
+			audience = "adult"
+		}
+		var audienceValue interface{}
+		if audience != "" {
+			audienceValue = audience
+		}
+
+		publicID := ids.New()
+		var res sql.Result
+		res, err = db.ExecContext(ctx, `
+			INSERT INTO books (uuid, slug, google_books_id, title, author, subjects, published_year, formats, page_count, content_warnings, audience_rating, language, publisher)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			publicID,
+			ids.BookSlug(title, publicID),
+			volumeID,
+			title,
+			v.Author(),
+			string(subjectsJSON),
+			year,
+			v.BookFormats(),
+			pages,
+			strings.Join(contentwarnings.FromSubjects(subjects), ","),
+			audienceValue,
+			lang,
+			publisher,
+		)
+		if err == nil {
+			id, err = res.LastInsertId()
+			created = true
+		}
+		if err == nil {
+			err = genres.Assign(ctx, db, id, subjects)
+		}
+		if err == nil {
+			err = authors.Link(ctx, db, id, v.Author())
+		}
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	if len(isbns) > 0 {
+		rows := make([]string, 0, len(isbns))
+		args := make([]interface{}, 0, 2*len(isbns))
+		for _, isbn13 := range isbns {
+			rows = append(rows, "(?, ?)")
+			args = append(args, isbn13, id)
+		}
+		// an ISBN already mapped to another book keeps its mapping
+		if _, err := db.ExecContext(ctx,
+			"INSERT IGNORE INTO book_isbns (isbn13, book_id) VALUES "+strings.Join(rows, ", "), args...); err != nil {
+			return 0, false, err
+		}
+	}
+	return id, created, nil
+}
+
+// match finds the shared catalogue book a volume belongs to: the one it was
+// ingested as, one with any of its ISBNs, or the Open Library work of its
+// first ISBN. A merged book leads to the book it was merged into. 0 means
+// the volume is new.
+func match(ctx context.Context, db Querier, volumeID string, isbns []string, workKey WorkKeyFunc) (int64, error) {
+	var id int64
+	err := db.QueryRowContext(ctx,
+		"SELECT COALESCE(merged_into, id) FROM books WHERE google_books_id = ?", volumeID).Scan(&id)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+	if len(isbns) == 0 {
+		return 0, nil
+	}
+
+	args := make([]interface{}, 0, len(isbns))
+	for _, isbn13 := range isbns {
+		args = append(args, isbn13)
+	}
+	err = db.QueryRowContext(ctx, `
+		SELECT COALESCE(b.merged_into, b.id)
+		FROM book_isbns bi
+		JOIN books b ON b.id = bi.book_id
+		WHERE bi.isbn13 IN (?`+strings.Repeat(", ?", len(isbns)-1)+`) AND b.organization_id IS NULL
+		ORDER BY bi.created_at
+		LIMIT 1`, args...).Scan(&id)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+	if workKey == nil {
+		return 0, nil
+	}
+
+	key, err := workKey(ctx, isbns[0])
+	if err != nil || key == "" {
+		return 0, err
+	}
+	err = db.QueryRowContext(ctx,
+		"SELECT COALESCE(merged_into, id) FROM books WHERE open_library_key = ?", key).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
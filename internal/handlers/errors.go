@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+)
+
+// ErrorResponse is the body of every error response. code is stable and
+// meant for programs; message is for people and may change.
+type ErrorResponse struct {
+	Code    string      `json:"code" example:"not_found"`
+	Message string      `json:"message" example:"book not found"`
+	Details interface{} `json:"details,omitempty"`
+} // @name ErrorResponse
+
+// AppError is an error a handler answers with. Status and Message are sent
+// to the client; Err, the cause, is only logged.
+type AppError struct {
+	Status  int
+	Code    string
+	Message string
+	Details interface{}
+	Err     error
+}
+
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error { return e.Err }
+
+// WithDetails attaches machine-readable context, e.g. the current version
+// on a conflict
+func (e *AppError) WithDetails(details interface{}) *AppError {
+	e.Details = details
+	return e
+}
+
+// NewAppError answers with status and message; the code is derived from the
+// status ("not_found", "conflict", ...)
+func NewAppError(status int, message string) *AppError {
+	return &AppError{Status: status, Code: StatusCode(status), Message: message}
+}
+
+// BadRequest, Unauthorized, Forbidden, NotFound and Conflict answer with
+// their status and message
+func BadRequest(message string) *AppError   { return NewAppError(http.StatusBadRequest, message) }
+func Unauthorized(message string) *AppError { return NewAppError(http.StatusUnauthorized, message) }
+func Forbidden(message string) *AppError    { return NewAppError(http.StatusForbidden, message) }
+func NotFound(message string) *AppError     { return NewAppError(http.StatusNotFound, message) }
+func Conflict(message string) *AppError     { return NewAppError(http.StatusConflict, message) }
+
+// StatusCode is the error code for an HTTP status: "not_found", ...
+func StatusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// AsAppError is what the client is told about err. Constraint violations
+// that reach a handler unhandled become 409/422s; anything else unexpected
+// is a 500 that says nothing about the cause (SQL text, hosts, ...).
+func AsAppError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var e *AppError
+	switch {
+	case dberr.Is(err, dberr.ErrDuplicate):
+		e = &AppError{Status: http.StatusConflict, Code: "duplicate", Message: "a record with this value already exists"}
+	case dberr.Is(err, dberr.ErrMissingReference):
+		e = &AppError{Status: http.StatusUnprocessableEntity, Code: "missing_reference", Message: "a referenced record does not exist"}
+	case dberr.Is(err, dberr.ErrReferenced):
+		e = &AppError{Status: http.StatusConflict, Code: "still_referenced", Message: "the record is still referenced by other records"}
+	default:
+		return &AppError{Status: http.StatusInternalServerError, Code: StatusCode(http.StatusInternalServerError), Message: "internal server error", Err: err}
+	}
+	if constraint := dberr.Constraint(err); constraint != "" {
+		e.Details = gin.H{"constraint": constraint}
+	}
+	e.Err = err
+	return e
+}
+
+// ErrorBody is the envelope AbortWithError answers err with
+func ErrorBody(err error) ErrorResponse {
+	e := AsAppError(err)
+	return ErrorResponse{Code: e.Code, Message: e.Message, Details: e.Details}
+}
+
+// AbortWithError answers the request with err in the error envelope and
+// records it on the context, where the request logger prints the cause
+func AbortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.AbortWithStatusJSON(AsAppError(err).Status, ErrorBody(err))
+}
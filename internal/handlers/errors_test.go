@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestAsAppError(t *testing.T) {
+	cases := []struct {
+		err        error
+		status     int
+		code       string
+		constraint string
+	}{
+		{NotFound("book not found"), 404, "not_found", ""},
+		{fmt.Errorf("load: %w", Conflict("taken")), 409, "conflict", ""},
+		{NewAppError(428, "If-Match is required"), 428, "precondition_required", ""},
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'ada' for key 'users.uq_users_handle'"}, 409, "duplicate", "uq_users_handle"},
+		{&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`bookrec`.`interactions`, CONSTRAINT `fk_interactions_book` FOREIGN KEY (`book_id`) REFERENCES `books` (`id`))"}, 422, "missing_reference", "fk_interactions_book"},
+		{&mysql.MySQLError{Number: 1451}, 409, "still_referenced", ""},
+		{errors.New("dial tcp 10.0.0.5:3306: connection refused"), 500, "internal_server_error", ""},
+	}
+	for _, tc := range cases {
+		e := AsAppError(tc.err)
+		if e.Status != tc.status || e.Code != tc.code {
+			t.Fatalf("%v: expected %d %s, got %d %s", tc.err, tc.status, tc.code, e.Status, e.Code)
+		}
+		details, _ := e.Details.(gin.H)
+		if tc.constraint != "" && details["constraint"] != tc.constraint {
+			t.Fatalf("%v: expected constraint %s in details, got %v", tc.err, tc.constraint, e.Details)
+		}
+	}
+}
+
+func TestAbortWithErrorHidesCause(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/fail", func(c *gin.Context) {
+		AbortWithError(c, errors.New("Error 1054: Unknown column 'x' in 'field list'"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if w.Code != 500 || body.Code != "internal_server_error" || strings.Contains(w.Body.String(), "column") {
+		t.Fatalf("expected a generic 500, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,101 @@
+// Package handlers holds HTTP handlers that read through a store.Store
+// rather than the server's database, so they're tested against a fake
+// store, and the error envelope every handler answers with. The routes are
+// registered by internal/server, behind its middleware.
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/store"
+)
+
+// Handlers are the handlers over Store
+type Handlers struct {
+	Store store.Store
+}
+
+// resolveParam answers 404/500 itself and reports whether the handler should continue
+func resolveParam(c *gin.Context, resolve func(context.Context, string) (int, error), raw, what string) (int, bool) {
+	id, err := resolve(c.Request.Context(), raw)
+	if errors.Is(err, store.ErrNotFound) {
+		AbortWithError(c, NotFound(what+" not found"))
+		return 0, false
+	}
+	if err != nil {
+		AbortWithError(c, err)
+		return 0, false
+	}
+	return id, true
+}
+
+// GetUser godoc
+// @Summary Get a user
+// @Description Anyone gets the user's uuid and handle; the email, role and created_at are only shown to the user themselves and admins.
+// @Description A merged account's UUID or ID answers 301 to the account it was merged into.
+// @Tags Users
+// @Produce json
+// @Param Authorization header string false "Bearer token"
+// @Param id path string true "User UUID (or ID)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id} [get]
+func (h *Handlers) GetUser(c *gin.Context) {
+	id, ok := resolveParam(c, h.Store.ResolveUser, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+
+	user, err := h.Store.User(c.Request.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		AbortWithError(c, NotFound("user not found"))
+		return
+	}
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	if c.GetInt("auth_user_id") != user.ID && c.GetString("auth_role") != "admin" {
+		c.JSON(200, user.Public())
+		return
+	}
+	c.JSON(200, user)
+}
+
+// GetInteraction godoc
+// @Summary Get an interaction (owner or admin)
+// @Tags Interactions
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Interaction UUID (or ID)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /interactions/{id} [get]
+func (h *Handlers) GetInteraction(c *gin.Context) {
+	id, ok := resolveParam(c, h.Store.ResolveInteraction, c.Param("id"), "interaction")
+	if !ok {
+		return
+	}
+
+	interaction, err := h.Store.Interaction(c.Request.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		AbortWithError(c, NotFound("interaction not found"))
+		return
+	}
+	if err != nil {
+		AbortWithError(c, err)
+		return
+	}
+
+	// Other users' interactions are reported as missing rather than forbidden
+	if c.GetInt("auth_user_id") != interaction.UserID && c.GetString("auth_role") != "admin" {
+		AbortWithError(c, NotFound("interaction not found"))
+		return
+	}
+	c.JSON(200, interaction)
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/models"
+	"github.com/YeswanthC7/bookrec/internal/store"
+)
+
+// fakeStore resolves and loads from maps keyed by the client's reference
+// and the internal id
+type fakeStore struct {
+	refs         map[string]int
+	users        map[int]models.User
+	interactions map[int]models.Interaction
+}
+
+func (f *fakeStore) resolve(raw string) (int, error) {
+	if id, ok := f.refs[raw]; ok {
+		return id, nil
+	}
+	return 0, store.ErrNotFound
+}
+
+func (f *fakeStore) ResolveUser(_ context.Context, raw string) (int, error) { return f.resolve(raw) }
+func (f *fakeStore) ResolveBook(_ context.Context, raw string) (int, error) { return f.resolve(raw) }
+func (f *fakeStore) ResolveInteraction(_ context.Context, raw string) (int, error) {
+	return f.resolve(raw)
+}
+
+func (f *fakeStore) User(_ context.Context, id int) (models.User, error) {
+	if u, ok := f.users[id]; ok {
+		return u, nil
+	}
+	return models.User{}, store.ErrNotFound
+}
+
+func (f *fakeStore) Interaction(_ context.Context, id int) (models.Interaction, error) {
+	if in, ok := f.interactions[id]; ok {
+		return in, nil
+	}
+	return models.Interaction{}, store.ErrNotFound
+}
+
+func (f *fakeStore) BookStats(context.Context, int) (models.BookStats, error) {
+	return models.BookStats{}, nil
+}
+
+func as(id int, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("auth_user_id", id)
+		c.Set("auth_role", role)
+		c.Next()
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	h := &Handlers{Store: &fakeStore{
+		refs:  map[string]int{"u-2": 2},
+		users: map[int]models.User{2: {ID: 2, UUID: "u-2", Email: "ada@example.com", Handle: "ada", Role: "user"}},
+	}}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users/:id", h.GetUser)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/u-2", nil))
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if w.Code != http.StatusOK || body["uuid"] != "u-2" || body["handle"] != "ada" {
+		t.Fatalf("expected ada, got %d: %s", w.Code, w.Body.String())
+	}
+	// anonymous callers get the public projection
+	if _, ok := body["email"]; ok {
+		t.Fatalf("expected no email for an anonymous caller, got %s", w.Body.String())
+	}
+	if _, ok := body["role"]; ok {
+		t.Fatalf("expected no role for an anonymous caller, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/6f1c2b9e-0c1d-4a52-9d7e-1c0f5b2a9e11", nil))
+	var errBody ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &errBody); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if w.Code != http.StatusNotFound || errBody.Message != "user not found" {
+		t.Fatalf("expected an unknown uuid answered 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetUser_EmailOnlyForSelfOrAdmin(t *testing.T) {
+	h := &Handlers{Store: &fakeStore{
+		refs:  map[string]int{"u-2": 2},
+		users: map[int]models.User{2: {ID: 2, UUID: "u-2", Email: "ada@example.com", Handle: "ada", Role: "user"}},
+	}}
+	gin.SetMode(gin.TestMode)
+	email := func(userID int, role string) interface{} {
+		r := gin.New()
+		r.GET("/users/:id", as(userID, role), h.GetUser)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/u-2", nil))
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid json: %v", err)
+		}
+		return body["email"]
+	}
+
+	if got := email(2, "user"); got != "ada@example.com" {
+		t.Fatalf("expected the user to see their email, got %v", got)
+	}
+	if got := email(1, "admin"); got != "ada@example.com" {
+		t.Fatalf("expected an admin to see the email, got %v", got)
+	}
+	if got := email(3, "user"); got != nil {
+		t.Fatalf("expected another user not to see the email, got %v", got)
+	}
+}
+
+func TestGetInteraction_OwnerOrAdmin(t *testing.T) {
+	rating := int64(4)
+	h := &Handlers{Store: &fakeStore{
+		refs:         map[string]int{"i-5": 5},
+		interactions: map[int]models.Interaction{5: {ID: 5, UUID: "i-5", UserID: 2, Action: "rating", Rating: &rating}},
+	}}
+	gin.SetMode(gin.TestMode)
+	get := func(userID int, role string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET("/interactions/:id", as(userID, role), h.GetInteraction)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/interactions/i-5", nil))
+		return w
+	}
+
+	if w := get(2, "user"); w.Code != http.StatusOK {
+		t.Fatalf("expected the owner answered 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := get(1, "admin"); w.Code != http.StatusOK {
+		t.Fatalf("expected an admin answered 200, got %d: %s", w.Code, w.Body.String())
+	}
+	// someone else's interaction doesn't exist as far as they can tell
+	if w := get(3, "user"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected another user answered 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
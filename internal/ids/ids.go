@@ -0,0 +1,83 @@
+// Package ids generates and recognises the public identifiers exposed by
+// the API (UUIDs for every resource, slugs for books). Integer primary keys
+// stay internal to the database.
+package ids
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// maxSlugWords caps the title part of a slug (matches the migration backfill)
+const maxSlugWords = 80
+
+// maxSlugLen is the books.slug column size
+const maxSlugLen = 255
+
+// New returns a random (v4) UUID string
+func New() string {
+	return uuid.NewString()
+}
+
+// IsUUID reports whether s is a canonical 36-character UUID
+func IsUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	_, err := uuid.Parse(s)
+	return err == nil
+}
+
+// ValidSlug reports whether s can be a book slug: lowercase letters, digits
+// and single dashes between them. All-digit strings (IDs) and UUIDs are
+// refused so references stay unambiguous.
+func ValidSlug(s string) bool {
+	if s == "" || len(s) > maxSlugLen || IsUUID(s) || s[0] == '-' || s[len(s)-1] == '-' || strings.Contains(s, "--") {
+		return false
+	}
+	digits := true
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			digits = false
+		case r >= '0' && r <= '9', r == '-':
+		default:
+			return false
+		}
+	}
+	return !digits || strings.Contains(s, "-")
+}
+
+// BookSlug builds "<title-words>-<first 8 chars of id>". The id suffix keeps
+// slugs unique without a lookup; titles that slugify to nothing become "book".
+func BookSlug(title, id string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	words := b.String()
+	if len(words) > maxSlugWords {
+		words = words[:maxSlugWords]
+	}
+	words = strings.Trim(words, "-")
+	if words == "" {
+		words = "book"
+	}
+
+	suffix := id
+	if len(suffix) > 8 {
+		suffix = suffix[:8]
+	}
+	return words + "-" + suffix
+}
@@ -0,0 +1,42 @@
+package ids
+
+import "testing"
+
+func TestBookSlug(t *testing.T) {
+	id := "1b4e28ba-2fa1-11d2-883f-0016d3cca427"
+	cases := map[string]string{
+		"The Hobbit":                "the-hobbit-1b4e28ba",
+		"  Dune: Messiah (1969)!  ": "dune-messiah-1969-1b4e28ba",
+		"Ça & ‰":                    "a-1b4e28ba",
+		"???":                       "book-1b4e28ba",
+	}
+	for title, want := range cases {
+		if got := BookSlug(title, id); got != want {
+			t.Errorf("BookSlug(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
+
+func TestIsUUID(t *testing.T) {
+	if !IsUUID(New()) {
+		t.Fatalf("expected generated id to be a UUID")
+	}
+	for _, s := range []string{"", "42", "the-hobbit-1b4e28ba", "1b4e28ba2fa111d2883f0016d3cca427"} {
+		if IsUUID(s) {
+			t.Errorf("IsUUID(%q) = true", s)
+		}
+	}
+}
+
+func TestValidSlug(t *testing.T) {
+	for _, s := range []string{"the-hobbit", "dune-1965", "1984-novel", "catch-22"} {
+		if !ValidSlug(s) {
+			t.Errorf("ValidSlug(%q) = false", s)
+		}
+	}
+	for _, s := range []string{"", "42", "The-Hobbit", "-hobbit", "hobbit-", "the--hobbit", "the hobbit", "1b4e28ba-2fa1-11d2-883f-0016d3cca427"} {
+		if ValidSlug(s) {
+			t.Errorf("ValidSlug(%q) = true", s)
+		}
+	}
+}
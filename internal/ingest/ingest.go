@@ -0,0 +1,243 @@
+// Package ingest fetches categories of books from a catalogue (Open Library
+// or Google Books) into the shared catalogue. bookrec ingest runs it from a
+// shell; POST /admin/ingest queues a run for the workers.
+package ingest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YeswanthC7/bookrec/internal/catalog"
+	"github.com/YeswanthC7/bookrec/internal/googlebooks"
+	"github.com/YeswanthC7/bookrec/internal/jobrun"
+	"github.com/YeswanthC7/bookrec/internal/openlibrary"
+)
+
+// Job names ingestion runs in job_runs
+const Job = "ingest"
+
+// DefaultSource is the catalogue fetched when none is asked for
+const DefaultSource = "openlibrary"
+
+// DefaultCategories are fetched when none are asked for
+var DefaultCategories = []string{
+	"science fiction",
+	"data science",
+	"fantasy",
+	"self help",
+}
+
+// openLibrary is the search client for ingesting and for matching Google
+// Books volumes to Open Library works. Nobody is waiting on a run, so it
+// rides out throttling: up to 1+2+4+8s before giving up on a search.
+var openLibrary = &openlibrary.Client{Retries: 4, Backoff: time.Second}
+
+// source is a catalogue Run can fetch from
+type source struct {
+	// label prefixes the category in catalog_sources ("open_library:fantasy")
+	label string
+	// fetch saves one category's books and returns how many it added or
+	// refreshed; an error means the category couldn't be fetched at all
+	fetch func(ctx context.Context, db *sql.DB, category string) (int, error)
+}
+
+// sources are the Params.Source values
+var sources = map[string]source{
+	"openlibrary": {label: "open_library", fetch: fetchOpenLibrary},
+	"googlebooks": {label: "google_books", fetch: fetchGoogleBooks},
+}
+
+// ValidSource reports whether Run can fetch from name
+func ValidSource(name string) bool {
+	_, ok := sources[name]
+	return ok
+}
+
+// Params are what a run fetches; they're stored with queued runs
+type Params struct {
+	Source     string   `json:"source"`
+	Categories []string `json:"categories"`
+}
+
+// DefaultConcurrency is how many categories a run fetches at once unless
+// INGEST_CONCURRENCY says otherwise
+const DefaultConcurrency = 3
+
+// Concurrency is how many categories a run fetches at once
+// (INGEST_CONCURRENCY, 1 to 10, default 3). Each waits out its own
+// throttling by Open Library, so this bounds the load a run puts on it.
+func Concurrency() int {
+	if n, err := strconv.Atoi(os.Getenv("INGEST_CONCURRENCY")); err == nil && n >= 1 && n <= 10 {
+		return n
+	}
+	return DefaultConcurrency
+}
+
+// Run fetches p's categories from its source, Concurrency at a time,
+// recording every category's outcome in catalog_sources, the progress on
+// run, which it finishes, and the run's totals in ingest_runs. It returns
+// how many books were added or refreshed. A category that fails is skipped;
+// Run itself fails for an unknown source or when ctx is cancelled, which
+// stops the fetches under way and leaves the remaining categories unfetched.
+func Run(ctx context.Context, db *sql.DB, p Params, run *jobrun.Run) (int, error) {
+	src, ok := sources[p.Source]
+	if !ok {
+		err := fmt.Errorf("unknown source %q", p.Source)
+		run.Finish("failed", err.Error())
+		return 0, err
+	}
+
+	var mu sync.Mutex
+	total, failed, done := 0, 0, 0
+	categories := make(chan string)
+	var workers sync.WaitGroup
+	for range min(Concurrency(), len(p.Categories)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for cat := range categories {
+				if ctx.Err() != nil {
+					continue
+				}
+				log.Printf("📥 Fetching: %s from %s\n", cat, p.Source)
+				label := src.label + ":" + cat
+				count, err := src.fetch(ctx, db, cat)
+				if ctx.Err() != nil {
+					// cut short, not the source's fault
+					continue
+				}
+				if err != nil {
+					log.Printf("⚠️  Search failed for %s: %v", cat, err)
+					catalog.RecordIngest(ctx, db, label, 0, err)
+				} else {
+					catalog.RecordIngest(ctx, db, label, count, nil)
+					log.Printf("✅ Done category: %s (%d books added/updated)", cat, count)
+				}
+
+				mu.Lock()
+				done++
+				message := fmt.Sprintf("%s: %d books", cat, count)
+				if err != nil {
+					failed++
+					message = cat + ": failed"
+				} else {
+					total += count
+				}
+				run.Progress(done, message)
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, cat := range p.Categories {
+		select {
+		case categories <- cat:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(categories)
+	workers.Wait()
+
+	if err := ctx.Err(); err != nil {
+		run.Finish("failed", fmt.Sprintf("stopped after %d of %d categories (%d books added/updated)", done, len(p.Categories), total))
+		recordStats(context.WithoutCancel(ctx), db, run, p, failed, total)
+		return total, err
+	}
+	run.Finish("succeeded", fmt.Sprintf("%d books added/updated", total))
+	recordStats(ctx, db, run, p, failed, total)
+	return total, nil
+}
+
+// recordStats writes a finished run's totals to ingest_runs, filling in the
+// row a scheduled run was queued with. Like job progress it's best-effort.
+func recordStats(ctx context.Context, db *sql.DB, run *jobrun.Run, p Params, failed, books int) {
+	if run.ID == 0 {
+		return
+	}
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO ingest_runs (job_run_id, source, categories, failed_categories, books, finished_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON DUPLICATE KEY UPDATE
+			failed_categories = VALUES(failed_categories),
+			books = VALUES(books),
+			finished_at = VALUES(finished_at)`,
+		run.ID, p.Source, len(p.Categories), failed, books); err != nil {
+		log.Printf("⚠️  Could not record ingest run %d: %v", run.ID, err)
+	}
+}
+
+// EventData is the data of the ingest.completed webhook event
+func EventData(run *jobrun.Run, p Params, books int) map[string]interface{} {
+	return map[string]interface{}{
+		"job_run_id": run.ID,
+		"source":     p.Source,
+		"categories": p.Categories,
+		"books":      books,
+	}
+}
+
+// fetchOpenLibrary upserts a category's Open Library search results
+func fetchOpenLibrary(ctx context.Context, db *sql.DB, category string) (int, error) {
+	docs, err := openLibrary.Search(ctx, url.Values{"q": {category}, "limit": {"10"}})
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, d := range docs {
+		if ctx.Err() != nil {
+			return count, ctx.Err()
+		}
+		if strings.TrimSpace(d.Title) == "" || strings.TrimSpace(d.Key) == "" {
+			continue
+		}
+		if _, _, err := openlibrary.Upsert(ctx, db, d); err != nil {
+			log.Printf("❌ Insert failed for '%s': %v", d.Title, err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// fetchGoogleBooks upserts a category's Google Books volumes, matching
+// them to catalogue books by ISBN and, through Open Library, by work.
+// GOOGLE_BOOKS_API_KEY raises Google's daily quota.
+func fetchGoogleBooks(ctx context.Context, db *sql.DB, category string) (int, error) {
+	gb := &googlebooks.Client{APIKey: os.Getenv("GOOGLE_BOOKS_API_KEY")}
+	volumes, err := gb.Search(ctx, "subject:"+category, 10)
+	if err != nil {
+		return 0, err
+	}
+	workKey := func(ctx context.Context, isbn13 string) (string, error) {
+		d, err := openLibrary.ByISBN(ctx, isbn13)
+		if errors.Is(err, openlibrary.ErrNotFound) {
+			return "", nil
+		}
+		return d.Key, err
+	}
+	count := 0
+	for _, v := range volumes {
+		if ctx.Err() != nil {
+			return count, ctx.Err()
+		}
+		if strings.TrimSpace(v.Info.Title) == "" || strings.TrimSpace(v.ID) == "" {
+			continue
+		}
+		if _, _, err := googlebooks.Upsert(ctx, db, v, workKey); err != nil {
+			log.Printf("❌ Insert failed for '%s': %v", v.Info.Title, err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,112 @@
+package ingest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/YeswanthC7/bookrec/internal/jobrun"
+)
+
+func TestRun_RecordsStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	t.Setenv("INGEST_CONCURRENCY", "1")
+
+	sources["fake"] = source{label: "fake", fetch: func(_ context.Context, _ *sql.DB, category string) (int, error) {
+		if category == "poetry" {
+			return 0, errors.New("503 Service Unavailable")
+		}
+		return 4, nil
+	}}
+	defer delete(sources, "fake")
+
+	mock.ExpectExec("INSERT INTO job_runs").WithArgs("ingest", 2).WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("INSERT INTO catalog_sources").WithArgs("fake:poetry", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE job_runs SET processed").WithArgs(1, "poetry: failed", int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO catalog_sources").WithArgs("fake:fantasy", 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE job_runs SET processed").WithArgs(2, "fantasy: 4 books", int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE job_runs SET status").WithArgs("succeeded", "4 books added/updated", int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO ingest_runs \\(job_run_id, source, categories, failed_categories, books, finished_at\\)").
+		WithArgs(int64(3), "fake", 2, 1, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	p := Params{Source: "fake", Categories: []string{"poetry", "fantasy"}}
+	total, err := Run(context.Background(), db, p, jobrun.Start(db, Job, len(p.Categories)))
+	if err != nil || total != 4 {
+		t.Fatalf("expected 4 books, got %d, %v", total, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestRun_BoundedConcurrency(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	t.Setenv("INGEST_CONCURRENCY", "2")
+
+	var inFlight, most atomic.Int32
+	sources["fake"] = source{label: "fake", fetch: func(context.Context, *sql.DB, string) (int, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for m := most.Load(); n > m && !most.CompareAndSwap(m, n); m = most.Load() {
+		}
+		time.Sleep(10 * time.Millisecond)
+		return 1, nil
+	}}
+	defer delete(sources, "fake")
+
+	mock.MatchExpectationsInOrder(false)
+	p := Params{Source: "fake", Categories: []string{"a", "b", "c", "d", "e"}}
+	for _, cat := range p.Categories {
+		mock.ExpectExec("INSERT INTO catalog_sources").WithArgs("fake:"+cat, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	// a run that wasn't recorded has nothing to report progress to
+	total, err := Run(context.Background(), db, p, &jobrun.Run{})
+	if err != nil || total != 5 || most.Load() != 2 {
+		t.Fatalf("expected 5 books, 2 at a time, got %d, %v, %d at most", total, err, most.Load())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestRun_Cancelled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	t.Setenv("INGEST_CONCURRENCY", "1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var fetched []string
+	sources["fake"] = source{label: "fake", fetch: func(ctx context.Context, _ *sql.DB, category string) (int, error) {
+		fetched = append(fetched, category)
+		cancel()
+		return 0, ctx.Err()
+	}}
+	defer delete(sources, "fake")
+
+	// the interrupted category isn't blamed on the source
+	p := Params{Source: "fake", Categories: []string{"a", "b", "c"}}
+	if _, err := Run(ctx, db, p, &jobrun.Run{}); !errors.Is(err, context.Canceled) || len(fetched) != 1 {
+		t.Fatalf("expected the run to stop after one category, got %v, fetched %v", err, fetched)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package ingest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+	"github.com/YeswanthC7/bookrec/internal/jobrun"
+)
+
+// Schedule is the nightly refresh the workers queue on their own
+type Schedule struct {
+	// Enabled turns it on (INGEST_SCHEDULE_ENABLED=true)
+	Enabled bool
+	// At is the time of day, in UTC, from midnight (INGEST_SCHEDULE_AT,
+	// HH:MM, default 03:00)
+	At time.Duration
+	// Jitter is the most a run is delayed past At, so deployments don't all
+	// hit the catalogues on the minute (INGEST_SCHEDULE_JITTER_MINUTES,
+	// default 30)
+	Jitter time.Duration
+	// Params are what's fetched (INGEST_SCHEDULE_SOURCE and the
+	// comma-separated INGEST_SCHEDULE_CATEGORIES, defaulting to the ingest
+	// job's own)
+	Params Params
+}
+
+// ScheduleFromEnv reads the schedule. Unlike most settings, a malformed
+// time or an unknown source is an error rather than a silent default: the
+// refresh would otherwise never happen as configured.
+func ScheduleFromEnv() (Schedule, error) {
+	s := Schedule{
+		At:     3 * time.Hour,
+		Jitter: 30 * time.Minute,
+		Params: Params{Source: DefaultSource, Categories: DefaultCategories},
+	}
+	s.Enabled, _ = strconv.ParseBool(os.Getenv("INGEST_SCHEDULE_ENABLED"))
+	if !s.Enabled {
+		return s, nil
+	}
+
+	if v := strings.TrimSpace(os.Getenv("INGEST_SCHEDULE_AT")); v != "" {
+		at, err := time.Parse("15:04", v)
+		if err != nil {
+			return s, fmt.Errorf("INGEST_SCHEDULE_AT %q: want HH:MM", v)
+		}
+		s.At = time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute
+	}
+	if n, err := strconv.Atoi(os.Getenv("INGEST_SCHEDULE_JITTER_MINUTES")); err == nil && n >= 0 {
+		s.Jitter = time.Duration(n) * time.Minute
+	}
+	if v := strings.TrimSpace(os.Getenv("INGEST_SCHEDULE_SOURCE")); v != "" {
+		if !ValidSource(v) {
+			return s, fmt.Errorf("INGEST_SCHEDULE_SOURCE %q: want openlibrary or googlebooks", v)
+		}
+		s.Params.Source = v
+	}
+	var categories []string
+	for _, cat := range strings.Split(os.Getenv("INGEST_SCHEDULE_CATEGORIES"), ",") {
+		if cat = strings.TrimSpace(cat); cat != "" {
+			categories = append(categories, cat)
+		}
+	}
+	if len(categories) > 0 {
+		s.Params.Categories = categories
+	}
+	return s, nil
+}
+
+// Next is the first scheduled time after t (before jitter)
+func (s Schedule) Next(t time.Time) time.Time {
+	t = t.UTC()
+	next := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Add(s.At)
+	if !next.After(t) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// EnqueueScheduled queues the refresh scheduled at slot and records it in
+// ingest_runs. It returns false, and queues nothing, when another worker
+// already queued that day's refresh.
+func EnqueueScheduled(ctx context.Context, db *sql.DB, p Params, slot time.Time) (int64, bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	id, err := jobrun.Enqueue(ctx, tx, Job, len(p.Categories), p)
+	if err != nil {
+		return 0, false, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO ingest_runs (job_run_id, source, scheduled_for, categories)
+		VALUES (?, ?, ?, ?)`, id, p.Source, slot.UTC().Format(time.DateOnly), len(p.Categories)); err != nil {
+		if dberr.Is(err, dberr.ErrDuplicate) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
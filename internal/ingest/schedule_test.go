@@ -0,0 +1,87 @@
+package ingest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestScheduleFromEnv(t *testing.T) {
+	t.Setenv("INGEST_SCHEDULE_ENABLED", "true")
+	t.Setenv("INGEST_SCHEDULE_AT", "22:45")
+	t.Setenv("INGEST_SCHEDULE_JITTER_MINUTES", "0")
+	t.Setenv("INGEST_SCHEDULE_SOURCE", "googlebooks")
+	t.Setenv("INGEST_SCHEDULE_CATEGORIES", "poetry, ,history")
+	s, err := ScheduleFromEnv()
+	if err != nil {
+		t.Fatalf("ScheduleFromEnv: %v", err)
+	}
+	want := Schedule{Enabled: true, At: 22*time.Hour + 45*time.Minute,
+		Params: Params{Source: "googlebooks", Categories: []string{"poetry", "history"}}}
+	if !reflect.DeepEqual(s, want) {
+		t.Fatalf("expected %+v, got %+v", want, s)
+	}
+
+	for name, value := range map[string]string{"INGEST_SCHEDULE_AT": "3am", "INGEST_SCHEDULE_SOURCE": "amazon"} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := ScheduleFromEnv(); err == nil {
+				t.Fatalf("expected %s=%s to be refused", name, value)
+			}
+		})
+	}
+}
+
+func TestScheduleFromEnv_Disabled(t *testing.T) {
+	// settings don't matter while the schedule is off
+	t.Setenv("INGEST_SCHEDULE_ENABLED", "")
+	t.Setenv("INGEST_SCHEDULE_SOURCE", "amazon")
+	if s, err := ScheduleFromEnv(); err != nil || s.Enabled {
+		t.Fatalf("expected a disabled schedule, got %+v, %v", s, err)
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	s := Schedule{At: 3 * time.Hour}
+	for _, tc := range []struct{ now, want time.Time }{
+		{time.Date(2024, 5, 1, 1, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC), time.Date(2024, 5, 2, 3, 0, 0, 0, time.UTC)},
+		// 23:00 in New York is already 03:00 the next day in UTC
+		{time.Date(2024, 4, 30, 23, 30, 0, 0, time.FixedZone("EDT", -4*3600)), time.Date(2024, 5, 2, 3, 0, 0, 0, time.UTC)},
+	} {
+		if got := s.Next(tc.now); !got.Equal(tc.want) {
+			t.Errorf("Next(%s): expected %s, got %s", tc.now, tc.want, got)
+		}
+	}
+}
+
+func TestEnqueueScheduled_AlreadyQueued(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	p := Params{Source: "openlibrary", Categories: []string{"fantasy"}}
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO job_runs").
+		WithArgs("ingest", 1, `{"source":"openlibrary","categories":["fantasy"]}`).
+		WillReturnResult(sqlmock.NewResult(8, 1))
+	// another worker queued tonight's run first
+	mock.ExpectExec("INSERT INTO ingest_runs \\(job_run_id, source, scheduled_for, categories\\)").
+		WithArgs(int64(8), "openlibrary", "2024-05-01", 1).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '2024-05-01' for key 'ingest_runs.uq_ingest_runs_scheduled_for'"})
+	mock.ExpectRollback()
+
+	slot := time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC)
+	if id, queued, err := EnqueueScheduled(context.Background(), db, p, slot); err != nil || queued || id != 0 {
+		t.Fatalf("expected nothing queued, got %d, %v, %v", id, queued, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
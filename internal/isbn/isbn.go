@@ -0,0 +1,103 @@
+// Package isbn normalises ISBN-10 and ISBN-13 input (as typed, printed or
+// scanned from a barcode) to validated ISBN-13.
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+// Normalisation errors
+var (
+	ErrLength   = errors.New("an ISBN has 10 or 13 digits")
+	ErrChar     = errors.New("an ISBN may only contain digits, hyphens, spaces and a final X")
+	ErrChecksum = errors.New("ISBN check digit does not match")
+	ErrPrefix   = errors.New("an ISBN-13 starts with 978 or 979")
+)
+
+// Normalize strips hyphens and spaces (and an "ISBN" label), validates the
+// check digit and returns the ISBN-13. ISBN-10s are converted.
+func Normalize(raw string) (string, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "ISBN-13"), "ISBN-10")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "ISBN"), ":")
+
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '-' || r == ' ':
+		case r == 'X' && i == len(s)-1:
+			b.WriteRune(r)
+		default:
+			return "", ErrChar
+		}
+	}
+	digits := b.String()
+
+	switch len(digits) {
+	case 10:
+		if !valid10(digits) {
+			return "", ErrChecksum
+		}
+		isbn13 := "978" + digits[:9]
+		return isbn13 + string(checkDigit13(isbn13)), nil
+	case 13:
+		if strings.HasSuffix(digits, "X") {
+			return "", ErrChar
+		}
+		if !strings.HasPrefix(digits, "978") && !strings.HasPrefix(digits, "979") {
+			return "", ErrPrefix
+		}
+		if checkDigit13(digits[:12]) != digits[12] {
+			return "", ErrChecksum
+		}
+		return digits, nil
+	}
+	return "", ErrLength
+}
+
+// To10 converts an ISBN-13 to ISBN-10; ok is false for 979 ISBNs, which
+// have no ISBN-10
+func To10(isbn13 string) (string, bool) {
+	if len(isbn13) != 13 || !strings.HasPrefix(isbn13, "978") {
+		return "", false
+	}
+	body := isbn13[3:12]
+	sum := 0
+	for i, r := range body {
+		sum += (10 - i) * int(r-'0')
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return body + "X", true
+	}
+	return body + string(rune('0'+check)), true
+}
+
+// valid10 checks an ISBN-10's weighted mod-11 sum (X is 10, last place only)
+func valid10(digits string) bool {
+	sum := 0
+	for i, r := range digits {
+		v := int(r - '0')
+		if r == 'X' {
+			v = 10
+		}
+		sum += (10 - i) * v
+	}
+	return sum%11 == 0
+}
+
+// checkDigit13 is the check digit for the first 12 digits of an ISBN-13
+func checkDigit13(first12 string) byte {
+	sum := 0
+	for i, r := range first12[:12] {
+		w := 1
+		if i%2 == 1 {
+			w = 3
+		}
+		sum += w * int(r-'0')
+	}
+	return byte('0' + (10-sum%10)%10)
+}
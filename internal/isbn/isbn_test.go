@@ -0,0 +1,42 @@
+package isbn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for raw, want := range map[string]string{
+		"978-0-261-10221-7":  "9780261102217",
+		"ISBN 0-261-10221-4": "9780261102217",
+		"080442957X":         "9780804429573",
+		" 979 10 90636 07 1": "9791090636071",
+	} {
+		got, err := Normalize(raw)
+		if err != nil || got != want {
+			t.Fatalf("Normalize(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+
+	for raw, want := range map[string]error{
+		"978-0-261-10221-8": ErrChecksum,
+		"0-261-10221-5":     ErrChecksum,
+		"12345":             ErrLength,
+		"97802611022X7":     ErrChar,
+		"0261X10221":        ErrChar,
+		"1234567890128":     ErrPrefix,
+	} {
+		if _, err := Normalize(raw); !errors.Is(err, want) {
+			t.Fatalf("Normalize(%q) error = %v; want %v", raw, err, want)
+		}
+	}
+}
+
+func TestTo10(t *testing.T) {
+	if got, ok := To10("9780804429573"); !ok || got != "080442957X" {
+		t.Fatalf("unexpected ISBN-10: %q, %v", got, ok)
+	}
+	if _, ok := To10("9791090636071"); ok {
+		t.Fatalf("979 ISBNs have no ISBN-10")
+	}
+}
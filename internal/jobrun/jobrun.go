@@ -0,0 +1,103 @@
+// Package jobrun records background job progress in job_runs so the server
+// can stream it to the admin UI (GET /admin/jobs/stream). Runs can also be
+// queued (Enqueue) for a worker to claim (Claim) and run.
+package jobrun
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+)
+
+// Run tracks one execution of a job.
+// Progress is best-effort: if the table is missing the job still runs.
+type Run struct {
+	db *sql.DB
+	ID int64
+}
+
+// Start inserts a running job_runs row for job with total steps
+func Start(db *sql.DB, job string, total int) *Run {
+	res, err := db.Exec(`INSERT INTO job_runs (job, total) VALUES (?, ?)`, job, total)
+	if err != nil {
+		log.Printf("⚠️  Could not record job run (progress won't be streamed): %v", err)
+		return &Run{}
+	}
+	id, _ := res.LastInsertId()
+	return &Run{db: db, ID: id}
+}
+
+// Execer is satisfied by *sql.DB and *sql.Tx
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Enqueue inserts a queued run of job with total steps, which the worker
+// that claims it runs with params (stored as JSON). Unlike Start, failing to
+// record it is an error: nothing would run.
+func Enqueue(ctx context.Context, db Execer, job string, total int, params interface{}) (int64, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return 0, err
+	}
+	res, err := db.ExecContext(ctx, `INSERT INTO job_runs (job, status, total, params) VALUES (?, 'queued', ?, ?)`,
+		job, total, string(raw))
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// Claim marks the oldest queued run of job running and returns it with its
+// params, or a nil Run when none is queued. Workers claiming at the same
+// time never get the same run.
+func Claim(ctx context.Context, db *sql.DB, job string) (*Run, []byte, error) {
+	var id int64
+	var params []byte
+	err := db.QueryRowContext(ctx, `
+		SELECT id, params FROM job_runs
+		WHERE job = ? AND status = 'queued'
+		ORDER BY id
+		LIMIT 1`, job).Scan(&id, &params)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := db.ExecContext(ctx, `
+		UPDATE job_runs SET status = 'running', started_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'queued'`, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		// another worker got there first
+		return nil, nil, err
+	}
+	return &Run{db: db, ID: id}, params, nil
+}
+
+// Progress reports how many steps are done
+func (r *Run) Progress(processed int, message string) {
+	if r.db == nil {
+		return
+	}
+	if _, err := r.db.Exec(`UPDATE job_runs SET processed = ?, message = ? WHERE id = ?`,
+		processed, message, r.ID); err != nil {
+		log.Printf("⚠️  Could not update job run: %v", err)
+	}
+}
+
+// Finish marks the run succeeded or failed
+func (r *Run) Finish(status string, message string) {
+	if r.db == nil {
+		return
+	}
+	if _, err := r.db.Exec(`UPDATE job_runs SET status = ?, message = ?, finished_at = NOW() WHERE id = ?`,
+		status, message, r.ID); err != nil {
+		log.Printf("⚠️  Could not finish job run: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package jobrun
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestClaim(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT id, params FROM job_runs\\s+WHERE job = \\? AND status = 'queued'").
+		WithArgs("ingest").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "params"}).AddRow(7, `{"source":"openlibrary"}`))
+	mock.ExpectExec("UPDATE job_runs SET status = 'running', started_at = CURRENT_TIMESTAMP\\s+WHERE id = \\? AND status = 'queued'").
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// another worker claims the next one first
+	mock.ExpectQuery("SELECT id, params FROM job_runs").
+		WithArgs("ingest").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "params"}).AddRow(8, `{}`))
+	mock.ExpectExec("UPDATE job_runs SET status = 'running'").
+		WithArgs(int64(8)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id, params FROM job_runs").
+		WithArgs("ingest").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "params"}))
+
+	ctx := context.Background()
+	run, params, err := Claim(ctx, db, "ingest")
+	if err != nil || run == nil || run.ID != 7 || string(params) != `{"source":"openlibrary"}` {
+		t.Fatalf("expected run 7 claimed, got %+v %s %v", run, params, err)
+	}
+	for i := 0; i < 2; i++ {
+		if run, _, err := Claim(ctx, db, "ingest"); err != nil || run != nil {
+			t.Fatalf("expected nothing claimed, got %+v %v", run, err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
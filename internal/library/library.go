@@ -0,0 +1,70 @@
+// Package library looks up which libraries in a region hold a book, through
+// a pluggable provider chosen with LIBRARY_PROVIDER.
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Book is what providers search their catalogues by
+type Book struct {
+	Title  string
+	Author string
+}
+
+// Holding is one library that owns a copy
+type Holding struct {
+	Name    string `json:"name"`
+	Code    string `json:"code,omitempty"` // provider's library identifier (OCLC symbol, ...)
+	Country string `json:"country,omitempty"`
+	Region  string `json:"region,omitempty"`
+	// Available is whether a copy can be borrowed now; nil when the provider
+	// only reports ownership
+	Available *bool  `json:"available"`
+	URL       string `json:"url,omitempty"`
+}
+
+// Provider searches library holdings. Name identifies it in responses.
+type Provider interface {
+	Name() string
+	Holdings(ctx context.Context, book Book, region string) ([]Holding, error)
+}
+
+// ErrInvalidRegion means a region isn't an ISO 3166 country or subdivision code
+var ErrInvalidRegion = errors.New("region must be a country code such as US or a subdivision such as US-CA")
+
+var regionPattern = regexp.MustCompile(`^[A-Z]{2}(-[A-Z0-9]{1,3})?$`)
+
+// NormalizeRegion uppercases and checks an ISO 3166-1 alpha-2 country
+// ("GB") or ISO 3166-2 subdivision ("US-CA") code
+func NormalizeRegion(raw string) (string, error) {
+	region := strings.ToUpper(strings.TrimSpace(raw))
+	if !regionPattern.MatchString(region) {
+		return "", ErrInvalidRegion
+	}
+	return region, nil
+}
+
+// FromEnv builds the provider selected by LIBRARY_PROVIDER:
+//
+//	worldcat – WORLDCAT_CLIENT_ID, WORLDCAT_CLIENT_SECRET (WorldCat Search API v2)
+//	none     – no lookups (the default); FromEnv returns a nil Provider
+func FromEnv() (Provider, error) {
+	switch provider := strings.ToLower(strings.TrimSpace(os.Getenv("LIBRARY_PROVIDER"))); provider {
+	case "", "none":
+		return nil, nil
+	case "worldcat":
+		id, secret := os.Getenv("WORLDCAT_CLIENT_ID"), os.Getenv("WORLDCAT_CLIENT_SECRET")
+		if id == "" || secret == "" {
+			return nil, fmt.Errorf("WORLDCAT_CLIENT_ID and WORLDCAT_CLIENT_SECRET are required for worldcat")
+		}
+		return &WorldCatProvider{ClientID: id, ClientSecret: secret}, nil
+	default:
+		return nil, fmt.Errorf("unknown LIBRARY_PROVIDER %q (want worldcat or none)", provider)
+	}
+}
@@ -0,0 +1,89 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("LIBRARY_PROVIDER", "")
+	if p, err := FromEnv(); p != nil || err != nil {
+		t.Fatalf("expected no provider by default, got %v, %v", p, err)
+	}
+
+	t.Setenv("LIBRARY_PROVIDER", "worldcat")
+	t.Setenv("WORLDCAT_CLIENT_ID", "id")
+	t.Setenv("WORLDCAT_CLIENT_SECRET", "")
+	if _, err := FromEnv(); err == nil {
+		t.Fatalf("expected an error without WORLDCAT_CLIENT_SECRET")
+	}
+
+	t.Setenv("LIBRARY_PROVIDER", "bookmobile")
+	if _, err := FromEnv(); err == nil {
+		t.Fatalf("expected an error for an unknown provider")
+	}
+}
+
+func TestNormalizeRegion(t *testing.T) {
+	for raw, want := range map[string]string{" us-ca ": "US-CA", "gb": "GB", "USA": "", "US-": "", "": ""} {
+		got, err := NormalizeRegion(raw)
+		if got != want || (err == nil) != (want != "") {
+			t.Fatalf("%q: expected %q, got %q (%v)", raw, want, got, err)
+		}
+	}
+}
+
+func TestWorldCatProvider(t *testing.T) {
+	tokens := 0
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if id, secret, _ := r.BasicAuth(); id != "id" || secret != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			tokens++
+			_, _ = fmt.Fprint(w, `{"access_token":"tok","expires_in":1200}`)
+		case "/holdings":
+			if r.Header.Get("Authorization") != "Bearer tok" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			query = r.URL.Query()
+			_, _ = fmt.Fprint(w, `{"briefRecords":[
+				{"institutionHolding":{"briefHoldings":[
+					{"oclcSymbol":"SFP","institutionName":"San Francisco Public Library","country":"US","state":"US-CA"},
+					{"oclcSymbol":"LPU","institutionName":"Los Angeles Public Library","country":"US","state":"US-CA"}]}},
+				{"institutionHolding":{"briefHoldings":[
+					{"oclcSymbol":"SFP","institutionName":"San Francisco Public Library","country":"US","state":"US-CA"}]}}]}`)
+		}
+	}))
+	defer srv.Close()
+
+	p := &WorldCatProvider{ClientID: "id", ClientSecret: "secret", TokenURL: srv.URL + "/token", HoldingsURL: srv.URL + "/holdings"}
+	for i := 0; i < 2; i++ {
+		holdings, err := p.Holdings(context.Background(), Book{Title: "Dune", Author: "Frank Herbert"}, "US-CA")
+		if err != nil {
+			t.Fatalf("holdings: %v", err)
+		}
+		if len(holdings) != 2 || holdings[0].Code != "SFP" || holdings[0].Available != nil {
+			t.Fatalf("unexpected holdings: %+v", holdings)
+		}
+	}
+	if tokens != 1 {
+		t.Fatalf("expected the token to be reused, fetched %d", tokens)
+	}
+	if query.Get("heldInState") != "US-CA" || query.Get("q") != `ti:"Dune" AND au:"Frank Herbert"` {
+		t.Fatalf("unexpected query: %v", query)
+	}
+
+	p = &WorldCatProvider{ClientID: "id", ClientSecret: "wrong", TokenURL: srv.URL + "/token", HoldingsURL: srv.URL + "/holdings"}
+	if _, err := p.Holdings(context.Background(), Book{Title: "Dune"}, "US"); err == nil {
+		t.Fatalf("expected an error when the token is refused")
+	}
+}
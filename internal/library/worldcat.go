@@ -0,0 +1,168 @@
+package library
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WorldCat endpoints
+const (
+	worldCatTokenURL    = "https://oauth.oclc.org/token"
+	worldCatHoldingsURL = "https://americas.discovery.api.oclc.org/worldcat/search/v2/bibs-holdings"
+)
+
+// worldCatMaxHoldings caps the libraries returned per lookup
+const worldCatMaxHoldings = 50
+
+// WorldCatProvider reports which member libraries own a book, using the
+// WorldCat Search API v2 with a client-credentials token. WorldCat knows
+// ownership, not loan status, so Available is always nil.
+type WorldCatProvider struct {
+	ClientID     string
+	ClientSecret string
+	// TokenURL and HoldingsURL override the endpoints (tests)
+	TokenURL    string
+	HoldingsURL string
+	// Client defaults to a client with a 10s timeout
+	Client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// Name implements Provider
+func (p *WorldCatProvider) Name() string { return "worldcat" }
+
+func (p *WorldCatProvider) client() *http.Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+// accessToken returns a cached token, fetching a new one a minute before expiry
+func (p *WorldCatProvider) accessToken(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Now().Before(p.expires) {
+		return p.token, nil
+	}
+
+	tokenURL := p.TokenURL
+	if tokenURL == "" {
+		tokenURL = worldCatTokenURL
+	}
+	form := url.Values{"grant_type": {"client_credentials"}, "scope": {"wcapi"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(p.ClientID, p.ClientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("worldcat token: %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	p.token = body.AccessToken
+	p.expires = time.Now().Add(time.Duration(body.ExpiresIn)*time.Second - time.Minute)
+	return p.token, nil
+}
+
+type worldCatHoldingsResponse struct {
+	BriefRecords []struct {
+		InstitutionHolding struct {
+			BriefHoldings []struct {
+				OCLCSymbol      string `json:"oclcSymbol"`
+				InstitutionName string `json:"institutionName"`
+				Country         string `json:"country"`
+				State           string `json:"state"`
+			} `json:"briefHoldings"`
+		} `json:"institutionHolding"`
+	} `json:"briefRecords"`
+}
+
+// Holdings implements Provider. Editions are merged: a library holding any
+// matching record is listed once.
+func (p *WorldCatProvider) Holdings(ctx context.Context, book Book, region string) ([]Holding, error) {
+	token, err := p.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := fmt.Sprintf("ti:%q", book.Title)
+	if book.Author != "" {
+		q += fmt.Sprintf(" AND au:%q", book.Author)
+	}
+	params := url.Values{"q": {q}, "limit": {"10"}}
+	if strings.Contains(region, "-") {
+		params.Set("heldInState", region)
+	} else {
+		params.Set("heldInCountry", region)
+	}
+
+	holdingsURL := p.HoldingsURL
+	if holdingsURL == "" {
+		holdingsURL = worldCatHoldingsURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, holdingsURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("worldcat: %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+
+	var body worldCatHoldingsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	holdings := []Holding{}
+	seen := map[string]bool{}
+	for _, record := range body.BriefRecords {
+		for _, h := range record.InstitutionHolding.BriefHoldings {
+			if seen[h.OCLCSymbol] || len(holdings) == worldCatMaxHoldings {
+				continue
+			}
+			seen[h.OCLCSymbol] = true
+			holdings = append(holdings, Holding{
+				Name:    h.InstitutionName,
+				Code:    h.OCLCSymbol,
+				Country: h.Country,
+				Region:  h.State,
+			})
+		}
+	}
+	return holdings, nil
+}
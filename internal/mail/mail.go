@@ -0,0 +1,83 @@
+// Package mail sends transactional email (the weekly digest) through a
+// pluggable provider chosen with MAIL_PROVIDER.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Message is a multipart (text + HTML) email to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	// Headers are extra headers such as List-Unsubscribe
+	Headers map[string]string
+}
+
+// Sender delivers messages. Name identifies the provider in send history.
+type Sender interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv builds the sender selected by MAIL_PROVIDER:
+//
+//	smtp     – SMTP_HOST, SMTP_PORT (default 587), SMTP_USER, SMTP_PASS
+//	sendgrid – SENDGRID_API_KEY
+//	log      – print messages instead of sending them (the default)
+//
+// MAIL_FROM is the sender address for every provider.
+func FromEnv() (Sender, error) {
+	from := os.Getenv("MAIL_FROM")
+	provider := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_PROVIDER")))
+	if provider != "" && provider != "log" && from == "" {
+		return nil, fmt.Errorf("MAIL_FROM is required for %s", provider)
+	}
+
+	switch provider {
+	case "", "log":
+		return LogSender{}, nil
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required for smtp")
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return &SMTPSender{
+			Addr:     host + ":" + port,
+			Host:     host,
+			Username: os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASS"),
+			From:     from,
+		}, nil
+	case "sendgrid":
+		key := os.Getenv("SENDGRID_API_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("SENDGRID_API_KEY is required for sendgrid")
+		}
+		return &SendGridSender{APIKey: key, From: from}, nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_PROVIDER %q (want smtp, sendgrid or log)", provider)
+	}
+}
+
+// LogSender prints messages; handy for local runs and dry runs
+type LogSender struct{}
+
+// Name implements Sender
+func (LogSender) Name() string { return "log" }
+
+// Send implements Sender
+func (LogSender) Send(_ context.Context, msg Message) error {
+	log.Printf("✉️  To: %s | Subject: %s\n%s", msg.To, msg.Subject, msg.Text)
+	return nil
+}
@@ -0,0 +1,87 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("MAIL_PROVIDER", "")
+	s, err := FromEnv()
+	if err != nil || s.Name() != "log" {
+		t.Fatalf("expected the log sender by default, got %v, %v", s, err)
+	}
+
+	t.Setenv("MAIL_PROVIDER", "sendgrid")
+	t.Setenv("MAIL_FROM", "digest@example.com")
+	t.Setenv("SENDGRID_API_KEY", "")
+	if _, err := FromEnv(); err == nil {
+		t.Fatalf("expected an error without SENDGRID_API_KEY")
+	}
+
+	t.Setenv("MAIL_PROVIDER", "pigeon")
+	if _, err := FromEnv(); err == nil {
+		t.Fatalf("expected an error for an unknown provider")
+	}
+}
+
+func TestSendGridSender(t *testing.T) {
+	var got sendGridRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	s := &SendGridSender{APIKey: "key", From: "digest@example.com", URL: srv.URL}
+	err := s.Send(context.Background(), Message{
+		To:      "reader@example.com",
+		Subject: "Your weekly picks",
+		Text:    "plain",
+		HTML:    "<p>html</p>",
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com/u>"},
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if got.Personalizations[0].To[0].Email != "reader@example.com" || len(got.Content) != 2 ||
+		got.Content[0].Type != "text/plain" || got.Headers["List-Unsubscribe"] == "" {
+		t.Fatalf("unexpected request: %+v", got)
+	}
+
+	s.APIKey = "wrong"
+	if err := s.Send(context.Background(), Message{To: "reader@example.com"}); err == nil {
+		t.Fatalf("expected an error for a non-2xx response")
+	}
+}
+
+func TestBuildMIME(t *testing.T) {
+	raw, err := buildMIME("digest@example.com", Message{
+		To:      "reader@example.com",
+		Subject: "Your weekly picks",
+		Text:    "plain",
+		HTML:    "<p>html</p>",
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com/u>"},
+	})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	for _, want := range []string{
+		"To: reader@example.com\r\n",
+		"List-Unsubscribe: <https://example.com/u>\r\n",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Type: text/html; charset=utf-8",
+	} {
+		if !strings.Contains(string(raw), want) {
+			t.Fatalf("missing %q in:\n%s", want, raw)
+		}
+	}
+}
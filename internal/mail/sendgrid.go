@@ -0,0 +1,96 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// sendGridURL is the SendGrid v3 mail send endpoint
+const sendGridURL = "https://api.sendgrid.com/v3/mail/send"
+
+// SendGridSender sends through the SendGrid v3 HTTP API
+type SendGridSender struct {
+	APIKey string
+	From   string
+	// URL overrides the API endpoint (tests); empty means sendGridURL
+	URL string
+	// Client defaults to a client with a 10s timeout
+	Client *http.Client
+}
+
+// Name implements Sender
+func (s *SendGridSender) Name() string { return "sendgrid" }
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	Headers          map[string]string         `json:"headers,omitempty"`
+}
+
+// Send implements Sender
+func (s *SendGridSender) Send(ctx context.Context, msg Message) error {
+	req := sendGridRequest{
+		Personalizations: []sendGridPersonalization{{To: []sendGridAddress{{Email: msg.To}}}},
+		From:             sendGridAddress{Email: s.From},
+		Subject:          msg.Subject,
+		Headers:          msg.Headers,
+	}
+	// SendGrid requires text/plain before text/html
+	if msg.Text != "" {
+		req.Content = append(req.Content, sendGridContent{Type: "text/plain", Value: msg.Text})
+	}
+	if msg.HTML != "" {
+		req.Content = append(req.Content, sendGridContent{Type: "text/html", Value: msg.HTML})
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	url := s.URL
+	if url == "" {
+		url = sendGridURL
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+s.APIKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sendgrid: %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	return nil
+}
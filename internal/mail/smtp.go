@@ -0,0 +1,96 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/smtp"
+	"sort"
+	"time"
+)
+
+// SMTPSender sends through an SMTP relay with PLAIN auth (STARTTLS is used
+// automatically when the server offers it)
+type SMTPSender struct {
+	Addr     string // host:port
+	Host     string
+	Username string
+	Password string
+	From     string
+}
+
+// Name implements Sender
+func (s *SMTPSender) Name() string { return "smtp" }
+
+// Send implements Sender. net/smtp has no context support, so ctx is only
+// checked before dialing.
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	body, err := buildMIME(s.From, msg)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	return smtp.SendMail(s.Addr, auth, s.From, []string{msg.To}, body)
+}
+
+// buildMIME renders msg as a multipart/alternative message
+func buildMIME(from string, msg Message) ([]byte, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	boundary := "bookrec-" + hex.EncodeToString(b)
+
+	var buf bytes.Buffer
+	headers := map[string]string{
+		"From":         from,
+		"To":           msg.To,
+		"Subject":      mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date":         time.Now().UTC().Format(time.RFC1123Z),
+		"MIME-Version": "1.0",
+		"Content-Type": fmt.Sprintf("multipart/alternative; boundary=%q", boundary),
+	}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%s: %s\r\n", k, headers[k])
+	}
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		if part.body == "" {
+			continue
+		}
+		fmt.Fprintf(&buf, "--%s\r\nContent-Type: %s; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n",
+			boundary, part.contentType)
+		qp := quotedprintable.NewWriter(&buf)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// InstrumentConnector wraps a database/sql connector so every query and
+// statement run through its connections is timed in DBQueryDuration. The
+// wrapped connections keep the optional driver interfaces database/sql
+// relies on (contexts, session resets, argument checks) by passing them
+// through to the driver's.
+func InstrumentConnector(c driver.Connector) driver.Connector {
+	return connector{c}
+}
+
+type connector struct {
+	driver.Connector
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	inner, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{inner}, nil
+}
+
+// observe times a query that finished at the driver. driver.ErrSkip means
+// database/sql will prepare the statement and run it again, where it's
+// timed; it isn't a query of its own.
+func observe(query string, start time.Time, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+	DBQueryDuration.WithLabelValues(operation(query)).Observe(time.Since(start).Seconds())
+}
+
+type conn struct {
+	driver.Conn
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	observe(query, start, err)
+	return rows, err
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := e.ExecContext(ctx, query, args)
+	observe(query, start, err)
+	return res, err
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var s driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = p.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: s, query: query}, nil
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return nil, errors.New("metrics: driver connection has no BeginTx")
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type stmt struct {
+	driver.Stmt
+	query string
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		return nil, errors.New("metrics: driver statement has no QueryContext")
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, args)
+	observe(s.query, start, err)
+	return rows, err
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		return nil, errors.New("metrics: driver statement has no ExecContext")
+	}
+	start := time.Now()
+	res, err := e.ExecContext(ctx, args)
+	observe(s.query, start, err)
+	return res, err
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
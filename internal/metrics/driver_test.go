@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// dsnConnector opens connections from a registered driver by DSN, as
+// sql.Open would
+type dsnConnector struct {
+	dsn string
+	drv driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.drv.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.drv }
+
+func samples(t *testing.T, op string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := DBQueryDuration.WithLabelValues(op).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatalf("read histogram: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestInstrumentConnector(t *testing.T) {
+	mockDB, mock, err := sqlmock.NewWithDSN("metrics-test")
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db := sql.OpenDB(InstrumentConnector(dsnConnector{dsn: "metrics-test", drv: mockDB.Driver()}))
+	defer func() { _ = db.Close() }()
+
+	selects, inserts, updates := samples(t, "select"), samples(t, "insert"), samples(t, "update")
+
+	mock.ExpectQuery("SELECT title FROM books WHERE id = \\?").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"title"}).AddRow("Dune"))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO interactions").WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectPrepare("UPDATE books SET title = \\?").
+		ExpectExec().WithArgs("Dune").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var title string
+	if err := db.QueryRow("SELECT title FROM books WHERE id = ?", 1).Scan(&title); err != nil || title != "Dune" {
+		t.Fatalf("query: %q %v", title, err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec("INSERT INTO interactions (user_id, book_id) VALUES (?, ?)", 2, 1); err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	stmt, err := db.Prepare("UPDATE books SET title = ?")
+	if err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	if _, err := stmt.Exec("Dune"); err != nil {
+		t.Fatalf("stmt exec: %v", err)
+	}
+	_ = stmt.Close()
+
+	if got := samples(t, "select") - selects; got != 1 {
+		t.Fatalf("expected 1 select timed, got %d", got)
+	}
+	if got := samples(t, "insert") - inserts; got != 1 {
+		t.Fatalf("expected 1 insert timed, got %d", got)
+	}
+	if got := samples(t, "update") - updates; got != 1 {
+		t.Fatalf("expected the prepared update timed once, got %d", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestOperation(t *testing.T) {
+	cases := map[string]string{
+		"\n\t\tSELECT 1":                        "select",
+		"WITH liked AS (SELECT 1) SELECT *":     "select",
+		"insert into books values (?)":          "insert",
+		"DELETE FROM interactions WHERE id = ?": "delete",
+		"SET time_zone = '+00:00'":              "other",
+		"":                                      "other",
+	}
+	for query, want := range cases {
+		if got := operation(query); got != want {
+			t.Fatalf("operation(%q) = %s, want %s", query, got, want)
+		}
+	}
+}
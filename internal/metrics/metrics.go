@@ -0,0 +1,68 @@
+// Package metrics holds the Prometheus metrics the server exposes on
+// GET /metrics. They live in the default registry, next to the Go runtime
+// and process metrics client_golang registers there.
+package metrics
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes every bookrec metric
+const Namespace = "bookrec"
+
+var (
+	// HTTPRequestDuration is request latency by method, route pattern (e.g.
+	// /books/:id, so ids don't multiply series) and status
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time to answer HTTP requests, by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// DBQueryDuration is how long queries and statements take to return, by
+	// operation (select, insert, update, delete or other). Reading the rows
+	// of a query isn't included.
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Time for the database to answer a query or statement, by operation.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation"})
+
+	// RecommendationDuration is the time to compute a user's
+	// recommendations, by mode (collaborative, content, authors or hybrid).
+	// Cached answers aren't computed, so they aren't counted.
+	RecommendationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "recommendation_duration_seconds",
+		Help:      "Time to compute a user's recommendations, by mode.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"mode"})
+)
+
+// Handler serves the default registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// operation is the label a query is counted under: its first keyword, with
+// CTEs counted as selects
+func operation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "other"
+	}
+	switch op := strings.ToLower(fields[0]); op {
+	case "select", "insert", "update", "delete":
+		return op
+	case "with":
+		return "select"
+	}
+	return "other"
+}
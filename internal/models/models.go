@@ -0,0 +1,50 @@
+// Package models holds the records internal/store reads and the API
+// answers with, in their JSON shape. Integer ids are loaded for the
+// server's own use but never serialized; clients see UUIDs.
+package models
+
+// User is an account as GET /users/{id} shows it
+type User struct {
+	ID        int    `json:"-"`
+	UUID      string `json:"uuid"`
+	Email     string `json:"email"`
+	Handle    string `json:"handle"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+}
+
+// PublicUser is what anyone other than the user and admins sees of an account
+type PublicUser struct {
+	UUID   string `json:"uuid"`
+	Handle string `json:"handle"`
+}
+
+// Public returns u without the email and role
+func (u User) Public() PublicUser {
+	return PublicUser{UUID: u.UUID, Handle: u.Handle}
+}
+
+// Interaction is one reader's like, view, rating or dislike of a book
+type Interaction struct {
+	ID       int    `json:"-"`
+	UUID     string `json:"uuid"`
+	UserID   int    `json:"-"`
+	UserUUID string `json:"user_uuid"`
+	BookID   int    `json:"-"`
+	BookUUID string `json:"book_uuid"`
+	Action   string `json:"action"`
+	// Rating is 1-5 for action=rating, nil otherwise
+	Rating     *int64 `json:"rating"`
+	Visibility string `json:"visibility"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// BookStats are a book's totals in one organization
+type BookStats struct {
+	Likes   int `json:"likes"`
+	Ratings int `json:"ratings"`
+	// AvgRating is nil until someone rates the book
+	AvgRating *float64 `json:"avg_rating"`
+	Views     int      `json:"views"`
+	Reviews   int      `json:"reviews"`
+}
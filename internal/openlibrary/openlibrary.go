@@ -0,0 +1,250 @@
+// Package openlibrary fetches work documents from the Open Library search
+// API and saves them into the books catalogue. The ingest job and on-demand
+// lookups (ISBN scans) share it so both write books the same way.
+package openlibrary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/YeswanthC7/bookrec/internal/isbn"
+)
+
+// DefaultBaseURL is the public Open Library API
+const DefaultBaseURL = "https://openlibrary.org"
+
+// CoversBaseURL serves cover images by cover ID
+const CoversBaseURL = "https://covers.openlibrary.org"
+
+// ErrNotFound is returned when Open Library has no matching work
+var ErrNotFound = errors.New("not found on Open Library")
+
+// searchFields asks Open Library for the fields Doc decodes (format,
+// number_of_pages_median, cover_i and the edition fields aren't returned by
+// default)
+const searchFields = "key,title,author_name,subject,first_publish_year,ebook_access,format,number_of_pages_median,cover_i,isbn,language,publisher"
+
+// maxISBNs caps the edition ISBNs kept per work; popular works list
+// hundreds
+const maxISBNs = 50
+
+// Doc is one work document from the search API
+type Doc struct {
+	Key      string   `json:"key"`
+	Title    string   `json:"title"`
+	Authors  []string `json:"author_name"`
+	Subjects []string `json:"subject"`
+	Year     int      `json:"first_publish_year"`
+	// EbookAccess is no_ebook, printdisabled, borrowable or public
+	EbookAccess string `json:"ebook_access"`
+	// Formats lists edition formats ("Paperback", "Audio CD", ...)
+	Formats []string `json:"format"`
+	// Pages is the median page count across editions (0 when unknown)
+	Pages int `json:"number_of_pages_median"`
+	// CoverID identifies the work's cover image (0 when it has none)
+	CoverID int64 `json:"cover_i"`
+	// ISBNs of the work's editions, ISBN-10 and ISBN-13 mixed
+	ISBNs []string `json:"isbn"`
+	// Languages are the editions' MARC codes ("eng", "fre", ...)
+	Languages []string `json:"language"`
+	// Publishers of the editions
+	Publishers []string `json:"publisher"`
+}
+
+// Author is the first listed author, or ""
+func (d Doc) Author() string {
+	if len(d.Authors) > 0 {
+		return d.Authors[0]
+	}
+	return ""
+}
+
+// ISBN13s are the editions' valid ISBNs as ISBN-13s, without duplicates,
+// at most maxISBNs of them
+func (d Doc) ISBN13s() []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, raw := range d.ISBNs {
+		isbn13, err := isbn.Normalize(raw)
+		if err != nil || seen[isbn13] {
+			continue
+		}
+		seen[isbn13] = true
+		out = append(out, isbn13)
+		if len(out) == maxISBNs {
+			break
+		}
+	}
+	return out
+}
+
+// Language is the first listed language as a BCP 47 tag ("en" for "eng"),
+// or "" when none is known
+func (d Doc) Language() string {
+	for _, code := range d.Languages {
+		tag, err := language.Parse(code)
+		if err == nil && tag != language.Und && tag.String() != "mul" {
+			return tag.String()
+		}
+	}
+	return ""
+}
+
+// Publisher is the first listed publisher, or ""
+func (d Doc) Publisher() string {
+	for _, p := range d.Publishers {
+		if p = strings.TrimSpace(p); p != "" {
+			return p
+		}
+	}
+	return ""
+}
+
+// CoverURL links to the cover image with the given ID in size S, M or L
+func CoverURL(coverID int64, size string) string {
+	return fmt.Sprintf("%s/b/id/%d-%s.jpg", CoversBaseURL, coverID, size)
+}
+
+// BookFormats maps the document's edition data onto books.formats. Open
+// Library catalogues printed editions, so print is assumed unless every
+// listed edition is electronic or audio.
+func (d Doc) BookFormats() string {
+	var print, ebook, audio bool
+	for _, f := range d.Formats {
+		f = strings.ToLower(f)
+		switch {
+		case strings.Contains(f, "audio"):
+			audio = true
+		case strings.Contains(f, "ebook"), strings.Contains(f, "electronic"), strings.Contains(f, "kindle"):
+			ebook = true
+		default:
+			print = true
+		}
+	}
+	if len(d.Formats) == 0 {
+		print = true
+	}
+	if d.EbookAccess == "borrowable" || d.EbookAccess == "public" {
+		ebook = true
+	}
+
+	formats := []string{}
+	if print {
+		formats = append(formats, "print")
+	}
+	if ebook {
+		formats = append(formats, "ebook")
+	}
+	if audio {
+		formats = append(formats, "audiobook")
+	}
+	return strings.Join(formats, ",")
+}
+
+// maxBackoff caps the wait before a retry, whatever Retry-After asks
+const maxBackoff = 30 * time.Second
+
+// Client calls the search API
+type Client struct {
+	// BaseURL defaults to DefaultBaseURL (tests point it elsewhere)
+	BaseURL string
+	// HTTP defaults to a client with a 10s timeout
+	HTTP *http.Client
+	// Retries is how many times a search throttled (429) or failed by Open
+	// Library (5xx) is retried. The default, none, suits lookups a user is
+	// waiting on.
+	Retries int
+	// Backoff is the wait before the first retry, doubled before each
+	// next one unless Retry-After says otherwise (default 1s)
+	Backoff time.Duration
+}
+
+// Search runs a search.json query (q, isbn, limit, ...) and returns the
+// matching works
+func (c *Client) Search(ctx context.Context, params url.Values) ([]Doc, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	client := c.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	q := url.Values{}
+	for k, v := range params {
+		q[k] = v
+	}
+	q.Set("fields", searchFields)
+	resp, err := c.get(ctx, client, strings.TrimRight(base, "/")+"/search.json?"+q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("open library search: %s", resp.Status)
+	}
+
+	var result struct {
+		Docs []Doc `json:"docs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("open library search: %w", err)
+	}
+	return result.Docs, nil
+}
+
+// get requests target, retrying throttled and failed responses as the
+// client allows. It gives up early, with ctx's error, when ctx is done.
+func (c *Client) get(ctx context.Context, client *http.Client, target string) (*http.Response, error) {
+	wait := c.Backoff
+	if wait <= 0 {
+		wait = time.Second
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil || attempt >= c.Retries ||
+			(resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500) {
+			return resp, err
+		}
+
+		delay := wait
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			delay = time.Duration(secs) * time.Second
+		}
+		_ = resp.Body.Close()
+		timer := time.NewTimer(min(delay, maxBackoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		wait *= 2
+	}
+}
+
+// ByISBN finds the work an ISBN-13 belongs to
+func (c *Client) ByISBN(ctx context.Context, isbn13 string) (Doc, error) {
+	docs, err := c.Search(ctx, url.Values{"isbn": {isbn13}, "limit": {"1"}})
+	if err != nil {
+		return Doc{}, err
+	}
+	if len(docs) == 0 || strings.TrimSpace(docs[0].Key) == "" || strings.TrimSpace(docs[0].Title) == "" {
+		return Doc{}, ErrNotFound
+	}
+	return docs[0], nil
+}
@@ -0,0 +1,151 @@
+package openlibrary
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestByISBN(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search.json" || r.URL.Query().Get("fields") == "" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		if r.URL.Query().Get("isbn") == "9780261102217" {
+			_, _ = w.Write([]byte(`{"docs":[{"key":"/works/OL262758W","title":"The Hobbit","author_name":["J.R.R. Tolkien"],"format":["Paperback","Audio CD"],"number_of_pages_median":310,"cover_i":6979861}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"docs":[]}`))
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL}
+	doc, err := c.ByISBN(context.Background(), "9780261102217")
+	if err != nil {
+		t.Fatalf("ByISBN: %v", err)
+	}
+	if doc.Key != "/works/OL262758W" || doc.Author() != "J.R.R. Tolkien" || doc.Pages != 310 || doc.BookFormats() != "print,audiobook" ||
+		doc.CoverID != 6979861 {
+		t.Fatalf("unexpected doc: %+v", doc)
+	}
+	if _, err := c.ByISBN(context.Background(), "9791090636071"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSearch_Retries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			_, _ = w.Write([]byte(`{"docs":[{"key":"/works/OL262758W","title":"The Hobbit"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, Retries: 2, Backoff: time.Millisecond}
+	docs, err := c.Search(context.Background(), url.Values{"q": {"hobbit"}})
+	if err != nil || len(docs) != 1 || calls.Load() != 3 {
+		t.Fatalf("expected the third try to succeed, got %v, %v after %d calls", docs, err, calls.Load())
+	}
+
+	// out of retries, the last failure is the error; a 404 isn't retried
+	calls.Store(0)
+	c.Retries = 1
+	if _, err := c.Search(context.Background(), url.Values{"q": {"hobbit"}}); err == nil || calls.Load() != 2 {
+		t.Fatalf("expected an error after 2 calls, got %v after %d", err, calls.Load())
+	}
+	notFound := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer notFound.Close()
+	calls.Store(0)
+	c.BaseURL = notFound.URL
+	if _, err := c.Search(context.Background(), url.Values{"q": {"hobbit"}}); err == nil || calls.Load() != 1 {
+		t.Fatalf("expected one call, got %d (%v)", calls.Load(), err)
+	}
+}
+
+func TestSearch_RetryStopsWithContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c := &Client{BaseURL: srv.URL, Retries: 5}
+	if _, err := c.Search(ctx, url.Values{"q": {"hobbit"}}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline, got %v", err)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectExec("INSERT INTO books .* ON DUPLICATE KEY UPDATE\\s+id = LAST_INSERT_ID\\(id\\)").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "/works/OL262758W", "The Hobbit", "J.R.R. Tolkien",
+			`["Fantasy"]`, 1937, "print", 310, "", nil, int64(6979861), "en", "Allen & Unwin").
+		WillReturnResult(sqlmock.NewResult(42, 2))
+	// the ISBN-10 is the same edition as the first ISBN-13; the typo is dropped
+	mock.ExpectExec("INSERT IGNORE INTO book_isbns \\(isbn13, book_id\\) VALUES \\(\\?, \\?\\), \\(\\?, \\?\\)$").
+		WithArgs("9780261102217", int64(42), "9780547928227", int64(42)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM book_genres WHERE book_id = \\?").
+		WithArgs(int64(42)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO book_genres").
+		WithArgs(int64(42), "fantasy").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO authors").
+		WithArgs("J.R.R. Tolkien").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("UPDATE books SET author_id = \\? WHERE id = \\?").
+		WithArgs(int64(3), int64(42)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	id, created, err := Upsert(context.Background(), db, Doc{
+		Key: "/works/OL262758W", Title: "The Hobbit", Authors: []string{"J.R.R. Tolkien"},
+		Subjects: []string{"Fantasy"}, Year: 1937, Pages: 310, CoverID: 6979861,
+		ISBNs:     []string{"9780261102217", "0261102214", "9780547928227", "9780547928228"},
+		Languages: []string{"eng", "fre"}, Publishers: []string{" Allen & Unwin", "Houghton Mifflin"},
+	})
+	if err != nil || id != 42 || created {
+		t.Fatalf("expected the existing book 42, got %d, %v, %v", id, created, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestDocLanguage(t *testing.T) {
+	for codes, want := range map[string]string{"eng": "en", "ger,eng": "de", "mul,xxx": "", "": ""} {
+		if got := (Doc{Languages: strings.Split(codes, ",")}).Language(); got != want {
+			t.Errorf("%q: expected %q, got %q", codes, want, got)
+		}
+	}
+}
+
+func TestCoverURL(t *testing.T) {
+	if got := CoverURL(6979861, "M"); got != "https://covers.openlibrary.org/b/id/6979861-M.jpg" {
+		t.Fatalf("unexpected cover URL %s", got)
+	}
+}
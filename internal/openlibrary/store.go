@@ -0,0 +1,120 @@
+package openlibrary
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/YeswanthC7/bookrec/internal/authors"
+	"github.com/YeswanthC7/bookrec/internal/contentwarnings"
+	"github.com/YeswanthC7/bookrec/internal/genres"
+	"github.com/YeswanthC7/bookrec/internal/ids"
+)
+
+// Execer is satisfied by *sql.DB and *sql.Tx
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// maxPublisherLength fits books.publisher
+const maxPublisherLength = 255
+
+// Upsert inserts the work as a catalogue book, or refreshes the book with
+// the same Open Library key. uuid and slug are only set on first insert so
+// public links stay stable, and admin-curated content warnings are left
+// alone. The editions' ISBNs are recorded in book_isbns, the subjects'
+// genres in book_genres and the author in authors. created is true when the
+// book is new.
+func Upsert(ctx context.Context, db Execer, d Doc) (id int64, created bool, err error) {
+	key, title := strings.TrimSpace(d.Key), strings.TrimSpace(d.Title)
+	if key == "" || title == "" {
+		// Key is needed for idempotent upsert on UNIQUE(open_library_key)
+		return 0, false, errors.New("open library doc needs a key and a title")
+	}
+
+	subjectsJSON, _ := json.Marshal(d.Subjects)
+	var pages interface{}
+	if d.Pages > 0 {
+		pages = d.Pages
+	}
+	var cover interface{}
+	if d.CoverID > 0 {
+		cover = d.CoverID
+	}
+	var audience interface{}
+	if a := contentwarnings.AudienceFromSubjects(d.Subjects); a != "" {
+		audience = a
+	}
+	var lang, publisher interface{}
+	if l := d.Language(); l != "" {
+		lang = l
+	}
+	if p := []rune(d.Publisher()); len(p) > 0 {
+		publisher = string(p[:min(len(p), maxPublisherLength)])
+	}
+
+	publicID := ids.New()
+	res, err := db.ExecContext(ctx, `
+		INSERT INTO books (uuid, slug, open_library_key, title, author, subjects, published_year, formats, page_count, content_warnings, audience_rating, cover_id, language, publisher)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			id = LAST_INSERT_ID(id),
+			title = VALUES(title),
+			author = VALUES(author),
+			subjects = VALUES(subjects),
+			published_year = VALUES(published_year),
+			formats = VALUES(formats),
+			page_count = COALESCE(VALUES(page_count), page_count),
+			cover_id = COALESCE(VALUES(cover_id), cover_id),
+			language = COALESCE(VALUES(language), language),
+			publisher = COALESCE(VALUES(publisher), publisher),
+			content_warnings = IF(content_warnings_curated, content_warnings, VALUES(content_warnings)),
+			audience_rating = IF(content_warnings_curated, audience_rating, VALUES(audience_rating))`,
+		publicID,
+		ids.BookSlug(title, publicID),
+		key,
+		title,
+		d.Author(),
+		string(subjectsJSON),
+		d.Year,
+		d.BookFormats(),
+		pages,
+		strings.Join(contentwarnings.FromSubjects(d.Subjects), ","),
+		audience,
+		cover,
+		lang,
+		publisher,
+	)
+	if err != nil {
+		return 0, false, err
+	}
+	id, err = res.LastInsertId()
+	if err != nil {
+		return 0, false, err
+	}
+	// MySQL reports 1 affected row for an insert, 2 for an update
+	n, _ := res.RowsAffected()
+
+	if isbns := d.ISBN13s(); len(isbns) > 0 {
+		rows := make([]string, 0, len(isbns))
+		args := make([]interface{}, 0, 2*len(isbns))
+		for _, isbn13 := range isbns {
+			rows = append(rows, "(?, ?)")
+			args = append(args, isbn13, id)
+		}
+		// an ISBN already mapped to another book keeps its mapping
+		if _, err := db.ExecContext(ctx,
+			"INSERT IGNORE INTO book_isbns (isbn13, book_id) VALUES "+strings.Join(rows, ", "), args...); err != nil {
+			return 0, false, err
+		}
+	}
+	if err := genres.Assign(ctx, db, id, d.Subjects); err != nil {
+		return 0, false, err
+	}
+	if err := authors.Link(ctx, db, id, d.Author()); err != nil {
+		return 0, false, err
+	}
+	return id, n == 1, nil
+}
@@ -0,0 +1,110 @@
+// Package ratelimit keeps one client from hammering the server. Each key (a
+// client IP, a user) has a token bucket: it holds up to Burst requests and
+// refills at PerMinute. Buckets live in the process, or in Redis so every
+// server process shares them.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limit is a bucket's size and refill rate. A zero PerMinute or Burst
+// means no limit.
+type Limit struct {
+	PerMinute float64
+	Burst     int
+}
+
+// Off reports whether l limits nothing
+func (l Limit) Off() bool {
+	return l.PerMinute <= 0 || l.Burst <= 0
+}
+
+// LimitFromEnv reads <prefix>_PER_MINUTE and <prefix>_BURST, e.g.
+// RATE_LIMIT_IP_PER_MINUTE. Missing or invalid values get def's; 0 turns
+// the limit off.
+func LimitFromEnv(prefix string, def Limit) Limit {
+	l := def
+	if f, err := strconv.ParseFloat(os.Getenv(prefix+"_PER_MINUTE"), 64); err == nil && f >= 0 {
+		l.PerMinute = f
+	}
+	if n, err := strconv.Atoi(os.Getenv(prefix + "_BURST")); err == nil && n >= 0 {
+		l.Burst = n
+	}
+	return l
+}
+
+// Limiter takes a token from key's bucket. When the bucket is empty the
+// request is refused and retryAfter says when the next token comes.
+type Limiter interface {
+	Allow(ctx context.Context, key string, l Limit) (ok bool, retryAfter time.Duration)
+}
+
+// take refills a bucket holding tokens for the elapsed time since it was
+// last used and takes one. It returns what's left and, when there was no
+// token, how long until one comes.
+func take(l Limit, tokens float64, elapsed time.Duration) (left float64, retryAfter time.Duration) {
+	perSecond := l.PerMinute / 60
+	tokens = math.Min(float64(l.Burst), tokens+math.Max(0, elapsed.Seconds())*perSecond)
+	if tokens >= 1 {
+		return tokens - 1, 0
+	}
+	return tokens, time.Duration((1 - tokens) / perSecond * float64(time.Second))
+}
+
+// Memory keeps buckets in the process: each server process limits on its
+// own
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+	full   time.Time // when it holds Burst again if left alone
+}
+
+// sweepEvery is how often full buckets are dropped from a Memory
+const sweepEvery = time.Minute
+
+// NewMemory returns an empty in-process Limiter
+func NewMemory() *Memory {
+	return &Memory{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// Allow implements Limiter
+func (m *Memory) Allow(_ context.Context, key string, l Limit) (bool, time.Duration) {
+	if l.Off() {
+		return true, 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if now.Sub(m.lastSweep) >= sweepEvery {
+		for k, b := range m.buckets {
+			if !now.Before(b.full) {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), at: now}
+		m.buckets[key] = b
+	}
+	left, retryAfter := take(l, b.tokens, now.Sub(b.at))
+	b.tokens, b.at = left, now
+	b.full = now.Add(time.Duration((float64(l.Burst) - left) / l.PerMinute * float64(time.Minute)))
+	return retryAfter == 0, retryAfter
+}
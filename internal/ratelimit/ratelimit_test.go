@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemory_Allow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	l := Limit{PerMinute: 60, Burst: 2}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := m.Allow(ctx, "ip:1", l); !ok {
+			t.Fatalf("expected request %d within the burst", i+1)
+		}
+	}
+	if ok, retry := m.Allow(ctx, "ip:1", l); ok || retry != time.Second {
+		t.Fatalf("expected a refusal for a second, got %v %v", ok, retry)
+	}
+	// other keys have their own bucket
+	if ok, _ := m.Allow(ctx, "ip:2", l); !ok {
+		t.Fatal("expected another client's request through")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if ok, retry := m.Allow(ctx, "ip:1", l); ok || retry != 500*time.Millisecond {
+		t.Fatalf("expected half a token, got %v %v", ok, retry)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := m.Allow(ctx, "ip:1", l); !ok {
+		t.Fatal("expected a token after a second")
+	}
+
+	// a bucket left alone fills up to the burst only
+	now = now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if ok, _ := m.Allow(ctx, "ip:1", l); !ok {
+			t.Fatalf("expected request %d within the refilled burst", i+1)
+		}
+	}
+	if ok, _ := m.Allow(ctx, "ip:1", l); ok {
+		t.Fatal("expected the burst to cap the refill")
+	}
+}
+
+func TestMemory_ForgetsFullBuckets(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	l := Limit{PerMinute: 60, Burst: 10}
+	ctx := context.Background()
+
+	m.Allow(ctx, "a", l)
+	now = now.Add(2 * time.Minute)
+	m.Allow(ctx, "b", l)
+	if _, ok := m.buckets["a"]; ok || len(m.buckets) != 1 {
+		t.Fatalf("expected only b's bucket kept, got %v", m.buckets)
+	}
+}
+
+func TestLimit_Off(t *testing.T) {
+	m := NewMemory()
+	for i := 0; i < 100; i++ {
+		if ok, _ := m.Allow(context.Background(), "ip:1", Limit{PerMinute: 0, Burst: 5}); !ok {
+			t.Fatal("expected no limit with a zero rate")
+		}
+	}
+	if len(m.buckets) != 0 {
+		t.Fatal("expected no bucket kept for a limit that's off")
+	}
+}
+
+func TestLimitFromEnv(t *testing.T) {
+	def := Limit{PerMinute: 600, Burst: 60}
+	t.Setenv("RATE_LIMIT_TEST_PER_MINUTE", "")
+	t.Setenv("RATE_LIMIT_TEST_BURST", "nope")
+	if got := LimitFromEnv("RATE_LIMIT_TEST", def); got != def {
+		t.Fatalf("expected the default, got %+v", got)
+	}
+	t.Setenv("RATE_LIMIT_TEST_PER_MINUTE", "30")
+	t.Setenv("RATE_LIMIT_TEST_BURST", "5")
+	if got := LimitFromEnv("RATE_LIMIT_TEST", def); got != (Limit{PerMinute: 30, Burst: 5}) {
+		t.Fatalf("unexpected limit %+v", got)
+	}
+	t.Setenv("RATE_LIMIT_TEST_PER_MINUTE", "0")
+	if got := LimitFromEnv("RATE_LIMIT_TEST", def); !got.Off() {
+		t.Fatalf("expected 0 to turn it off, got %+v", got)
+	}
+}
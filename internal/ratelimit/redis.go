@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces the buckets in a shared Redis
+const keyPrefix = "bookrec:ratelimit:"
+
+// takeScript is take in Redis: it refills and takes from the bucket in
+// KEYS[1] (a hash of tokens and at, Redis' own clock so processes agree)
+// in one step, and lets the hash expire once it would be full anyway.
+// ARGV is the rate per second and the burst; it returns whether a token
+// was taken and what's left.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(b[1]) or burst
+local at = tonumber(b[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - at) * rate)
+local taken = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	taken = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1)
+return {taken, tostring(tokens)}
+`)
+
+// Redis keeps buckets in Redis, shared by every server process. Redis
+// being down never refuses a request: the error is logged and the request
+// let through.
+type Redis struct {
+	Client *redis.Client
+}
+
+// Allow implements Limiter
+func (r *Redis) Allow(ctx context.Context, key string, l Limit) (bool, time.Duration) {
+	if l.Off() {
+		return true, 0
+	}
+	perSecond := l.PerMinute / 60
+	res, err := takeScript.Run(ctx, r.Client, []string{keyPrefix + key},
+		strconv.FormatFloat(perSecond, 'f', -1, 64), l.Burst).Slice()
+	if err != nil {
+		log.Printf("⚠️  Rate limit check of %s failed (letting it through): %v", key, err)
+		return true, 0
+	}
+	if taken, _ := res[0].(int64); taken == 1 {
+		return true, 0
+	}
+	raw, _ := res[1].(string)
+	left, _ := strconv.ParseFloat(raw, 64)
+	return false, time.Duration(math.Max(0, 1-left) / perSecond * float64(time.Second))
+}
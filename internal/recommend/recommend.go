@@ -0,0 +1,68 @@
+// Package recommend holds the "readers who enjoyed the same books" scoring
+// shared by GET /recommendations, the GraphQL recommendations field and the
+// digest job (cmd/jobs/digest).
+package recommend
+
+// Neighbors is how many of the most similar readers a user's
+// recommendations are drawn from
+const Neighbors = 50
+
+// ScoresSQL scores books for a user in two stages. It first picks the
+// Neighbors readers in the user's organization whose taste agrees most with
+// the user's, where enjoying a book is liking it or rating it 4 or 5: each
+// book both enjoyed counts +1 and each book the reader enjoyed but the user
+// disliked counts -1, and readers who disagree as much as they agree aren't
+// neighbours. Then each of those readers votes on every book the user
+// doesn't already know (KnownBooksSQL): +1 for enjoying it, -1 for
+// disliking it or rating it 1 or 2 (a 3 is neutral). A neighbour votes once
+// per book either way, however many likes they share, and books the
+// neighbours like no more than they dislike are left out. strongest is the
+// best overlap among the readers who enjoyed the book, for breaking ties.
+//
+// Bind the user ID, the neighbour limit and the user ID twice more. Select from
+// it as a derived table with columns book_id, score and strongest.
+const ScoresSQL = `
+	SELECT k.book_id,
+		COUNT(DISTINCT CASE WHEN k.action = 'like' OR (k.action = 'rating' AND k.rating >= 4) THEN k.user_id END)
+			- COUNT(DISTINCT CASE WHEN k.action = 'dislike' OR (k.action = 'rating' AND k.rating <= 2) THEN k.user_id END) AS score,
+		MAX(CASE WHEN k.action = 'like' OR (k.action = 'rating' AND k.rating >= 4) THEN n.overlap END) AS strongest
+	FROM (
+		SELECT j.user_id, j.organization_id,
+			COUNT(DISTINCT CASE WHEN i.action <> 'dislike' THEN j.book_id END)
+				- COUNT(DISTINCT CASE WHEN i.action = 'dislike' THEN j.book_id END) AS overlap
+		FROM interactions i
+		JOIN interactions j
+			ON j.book_id = i.book_id
+			AND j.organization_id = i.organization_id
+			AND (j.action = 'like' OR (j.action = 'rating' AND j.rating >= 4))
+			AND j.user_id <> i.user_id
+			AND j.deleted_at IS NULL
+		WHERE i.user_id = ?
+			AND (i.action IN ('like', 'dislike') OR (i.action = 'rating' AND i.rating >= 4))
+			AND i.deleted_at IS NULL
+		GROUP BY j.user_id, j.organization_id
+		HAVING overlap > 0
+		ORDER BY overlap DESC, j.user_id
+		LIMIT ?
+	) n
+	JOIN interactions k
+		ON k.user_id = n.user_id
+		AND k.organization_id = n.organization_id
+		AND k.action IN ('like', 'rating', 'dislike')
+		AND k.deleted_at IS NULL
+	WHERE k.book_id NOT IN (` + KnownBooksSQL + `)
+	GROUP BY k.book_id
+	HAVING score > 0`
+
+// Args binds ScoresSQL for userID
+func Args(userID int) []interface{} {
+	return []interface{}{userID, Neighbors, userID, userID}
+}
+
+// KnownBooksSQL lists the books a user already knows, which are never
+// recommended to them: any they've interacted with (disliked ones included)
+// or put on one of their shelves. Bind the user ID twice.
+const KnownBooksSQL = `
+		SELECT book_id FROM interactions WHERE user_id = ? AND deleted_at IS NULL
+		UNION
+		SELECT book_id FROM shelf_books WHERE user_id = ?`
@@ -0,0 +1,12 @@
+package recommend
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestArgsMatchPlaceholders(t *testing.T) {
+	if got, want := len(Args(7)), strings.Count(ScoresSQL, "?"); got != want {
+		t.Fatalf("Args binds %d values, ScoresSQL has %d placeholders", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// activityVerbs maps the interaction actions shown in GET /feed to the verb
+// rendered for them. Views are deliberately absent: they are never public.
+var activityVerbs = map[string]string{
+	"like":   "liked",
+	"rating": "rated",
+}
+
+// encodeKeysetCursor packs the keyset position (created_at, id) of the last
+// item on a page, for GET /feed and GET /users/{id}/history
+func encodeKeysetCursor(createdAt time.Time, id int) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + strconv.Itoa(id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeKeysetCursor(cursor string) (time.Time, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf("malformed cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return createdAt, n, nil
+}
+
+// ActivityFeedHandler godoc
+// @Summary Recent activity from users you follow (newest first)
+// @Description Likes and ratings by followed users, skipping interactions marked private and books outside the organization's catalogue. Pass next_cursor back as cursor for the next page; it is null on the last page.
+// @Tags Social
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Param cursor query string false "Opaque cursor from a previous page"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /feed [get]
+func ActivityFeedHandler(c *gin.Context) {
+	userID := c.GetInt("auth_user_id")
+	if userID <= 0 {
+		abortWithError(c, unauthorized("unauthorized"))
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	orgID := tenant.ID(c.Request.Context())
+	query := `
+		SELECT i.id, i.uuid, i.action, i.rating, i.created_at,
+		       u.uuid, u.handle,
+		       b.uuid, b.slug, b.title, b.author
+		FROM follows f
+		JOIN interactions i ON i.user_id = f.followee_id
+		JOIN users u ON u.id = i.user_id
+		JOIN books b ON b.id = i.book_id
+		WHERE f.follower_id = ?
+		  AND i.organization_id = ?
+		  AND i.action IN ('like', 'rating')
+		  AND i.visibility = 'public'
+		  AND i.deleted_at IS NULL AND u.deleted_at IS NULL
+		  AND ` + tenant.BooksVisibleSQL("b")
+	args := []interface{}{userID, orgID, orgID}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		before, beforeID, err := decodeKeysetCursor(cursor)
+		if err != nil {
+			abortWithError(c, badRequest("invalid cursor"))
+			return
+		}
+		query += " AND (i.created_at < ? OR (i.created_at = ? AND i.id < ?))"
+		args = append(args, before, before, beforeID)
+	}
+	// one extra row tells us whether there is another page
+	query += " ORDER BY i.created_at DESC, i.id DESC LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := db.QueryContext(c.Request.Context(), query, args...)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	items := []gin.H{}
+	var lastCreated time.Time
+	var lastID int
+	hasMore := false
+	for rows.Next() {
+		if len(items) == limit {
+			hasMore = true
+			break
+		}
+		var id int
+		var publicID, action, actorUUID, handle, bookUUID, slug, title string
+		var author sql.NullString
+		var rating sql.NullInt64
+		var createdAt time.Time
+		if err := rows.Scan(&id, &publicID, &action, &rating, &createdAt,
+			&actorUUID, &handle,
+			&bookUUID, &slug, &title, &author); err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		var ratingValue interface{}
+		if rating.Valid {
+			ratingValue = rating.Int64
+		}
+		items = append(items, gin.H{
+			"id":         publicID,
+			"verb":       activityVerbs[action],
+			"rating":     ratingValue,
+			"created_at": createdAt,
+			"actor":      gin.H{"uuid": actorUUID, "handle": handle},
+			"book":       gin.H{"uuid": bookUUID, "slug": slug, "title": title, "author": author.String},
+		})
+		lastCreated, lastID = createdAt, id
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	var next interface{}
+	if hasMore {
+		next = encodeKeysetCursor(lastCreated, lastID)
+	}
+	c.JSON(200, gin.H{
+		"limit":       limit,
+		"data":        items,
+		"next_cursor": next,
+	})
+}
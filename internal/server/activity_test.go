@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestActivityFeedHandler_KeysetPagination(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	newer := time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC)
+	older := newer.Add(-time.Hour)
+	cols := []string{"id", "uuid", "action", "rating", "created_at",
+		"actor_uuid", "handle",
+		"book_uuid", "slug", "title", "author"}
+
+	mock.ExpectQuery("FROM follows f\\s+JOIN interactions i ON i.user_id = f.followee_id").
+		WithArgs(1, 1, 1, 2).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow(9, "i-9", "rating", 5, newer, "u-2", "bob", "b-3", "dune-b-3", "Dune", "Frank Herbert").
+			AddRow(8, "i-8", "like", nil, older, "u-2", "bob", "b-4", "emma-b-4", "Emma", "Jane Austen"))
+	mock.ExpectQuery("AND \\(i.created_at < \\? OR \\(i.created_at = \\? AND i.id < \\?\\)\\)").
+		WithArgs(1, 1, 1, newer, newer, 9, 2).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow(8, "i-8", "like", nil, older, "u-2", "bob", "b-4", "emma-b-4", "Emma", "Jane Austen"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/feed", asUser(1), ActivityFeedHandler)
+
+	type page struct {
+		Data       []map[string]any `json:"data"`
+		NextCursor *string          `json:"next_cursor"`
+	}
+	get := func(path string) page {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		var p page
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatalf("invalid json: %v", err)
+		}
+		return p
+	}
+
+	first := get("/feed?limit=1")
+	if len(first.Data) != 1 || first.Data[0]["verb"] != "rated" || first.NextCursor == nil {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
+
+	second := get("/feed?limit=1&cursor=" + *first.NextCursor)
+	if len(second.Data) != 1 || second.Data[0]["verb"] != "liked" || second.NextCursor != nil {
+		t.Fatalf("unexpected last page: %+v", second)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestActivityFeedHandler_InvalidCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/feed", asUser(1), ActivityFeedHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/feed?cursor=not-a-cursor", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// Analytics range limits
+const (
+	analyticsDefaultDays = 30
+	analyticsMaxDays     = 366
+	analyticsTopGenres   = 10
+)
+
+// AnalyticsDay is one point of the analytics time series
+type AnalyticsDay struct {
+	Date         string         `json:"date" example:"2026-10-01"`
+	Signups      int            `json:"signups"`
+	Interactions map[string]int `json:"interactions"`
+	DAU          int            `json:"dau"`
+	WAU          int            `json:"wau"`
+}
+
+// GenreCount is a genre with its interaction count over the range
+type GenreCount struct {
+	Genre        string `json:"genre"`
+	Interactions int    `json:"interactions"`
+}
+
+// AnalyticsResponse is the body of GET /admin/analytics
+type AnalyticsResponse struct {
+	From      string         `json:"from"`
+	To        string         `json:"to"`
+	Series    []AnalyticsDay `json:"series"`
+	TopGenres []GenreCount   `json:"top_genres"`
+}
+
+// parseAnalyticsRange reads from/to (YYYY-MM-DD, inclusive); the default is
+// the last analyticsDefaultDays days ending today (UTC)
+func parseAnalyticsRange(c *gin.Context) (time.Time, time.Time, error) {
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if v := strings.TrimSpace(c.Query("to")); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to must be YYYY-MM-DD")
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -(analyticsDefaultDays - 1))
+	if v := strings.TrimSpace(c.Query("from")); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from must be YYYY-MM-DD")
+		}
+		from = t
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+	}
+	if to.Sub(from) >= analyticsMaxDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("range is limited to %d days", analyticsMaxDays)
+	}
+	return from, to, nil
+}
+
+// AnalyticsHandler godoc
+// @Summary Daily analytics (signups, interactions by type, DAU/WAU, top genres)
+// @Description Reads the daily_stats and analytics_daily_* rollups written by the analytics job (cmd/jobs/analytics); days it hasn't rolled up yet report zeros.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param from query string false "First day, YYYY-MM-DD (default 29 days before to)"
+// @Param to query string false "Last day, YYYY-MM-DD (default today, UTC)"
+// @Success 200 {object} AnalyticsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/analytics [get]
+func AnalyticsHandler(c *gin.Context) {
+	from, to, err := parseAnalyticsRange(c)
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+
+	resp, err := loadAnalytics(c.Request.Context(), from, to)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, resp)
+}
+
+func loadAnalytics(ctx context.Context, from, to time.Time) (*AnalyticsResponse, error) {
+	orgID := tenant.ID(ctx)
+	fromDay, toDay := from.Format(time.DateOnly), to.Format(time.DateOnly)
+
+	// one zero-filled point per day so charts don't need to fill gaps
+	series := []AnalyticsDay{}
+	index := map[string]*AnalyticsDay{}
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		series = append(series, AnalyticsDay{
+			Date:         d.Format(time.DateOnly),
+			Interactions: map[string]int{"view": 0, "like": 0, "rating": 0},
+		})
+	}
+	for i := range series {
+		index[series[i].Date] = &series[i]
+	}
+
+	signups, err := db.QueryContext(ctx, `
+		SELECT day, signups FROM analytics_daily_signups
+		WHERE organization_id = ? AND day BETWEEN ? AND ?`, orgID, fromDay, toDay)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = signups.Close() }()
+	for signups.Next() {
+		var day time.Time
+		var n int
+		if err := signups.Scan(&day, &n); err != nil {
+			return nil, err
+		}
+		if d, ok := index[day.Format(time.DateOnly)]; ok {
+			d.Signups = n
+		}
+	}
+	if err := signups.Err(); err != nil {
+		return nil, err
+	}
+
+	interactions, err := db.QueryContext(ctx, `
+		SELECT day, views, likes, ratings FROM daily_stats
+		WHERE organization_id = ? AND day BETWEEN ? AND ?`, orgID, fromDay, toDay)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = interactions.Close() }()
+	for interactions.Next() {
+		var day time.Time
+		var views, likes, ratings int
+		if err := interactions.Scan(&day, &views, &likes, &ratings); err != nil {
+			return nil, err
+		}
+		if d, ok := index[day.Format(time.DateOnly)]; ok {
+			d.Interactions = map[string]int{"view": views, "like": likes, "rating": ratings}
+		}
+	}
+	if err := interactions.Err(); err != nil {
+		return nil, err
+	}
+
+	active, err := db.QueryContext(ctx, `
+		SELECT day, dau, wau FROM analytics_daily_active_users
+		WHERE organization_id = ? AND day BETWEEN ? AND ?`, orgID, fromDay, toDay)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = active.Close() }()
+	for active.Next() {
+		var day time.Time
+		var dau, wau int
+		if err := active.Scan(&day, &dau, &wau); err != nil {
+			return nil, err
+		}
+		if d, ok := index[day.Format(time.DateOnly)]; ok {
+			d.DAU, d.WAU = dau, wau
+		}
+	}
+	if err := active.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT genre, SUM(interactions) AS total
+		FROM analytics_daily_genres
+		WHERE organization_id = ? AND day BETWEEN ? AND ?
+		GROUP BY genre
+		ORDER BY total DESC, genre
+		LIMIT ?`, orgID, fromDay, toDay, analyticsTopGenres)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	genres := []GenreCount{}
+	for rows.Next() {
+		var g GenreCount
+		if err := rows.Scan(&g.Genre, &g.Interactions); err != nil {
+			return nil, err
+		}
+		genres = append(genres, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &AnalyticsResponse{From: fromDay, To: toDay, Series: series, TopGenres: genres}, nil
+}
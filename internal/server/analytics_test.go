@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAnalyticsHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	day := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+
+	mock.ExpectQuery("SELECT day, signups FROM analytics_daily_signups").
+		WithArgs(1, "2026-10-01", "2026-10-03").
+		WillReturnRows(sqlmock.NewRows([]string{"day", "signups"}).AddRow(day("2026-10-02"), 4))
+	mock.ExpectQuery("SELECT day, views, likes, ratings FROM daily_stats").
+		WithArgs(1, "2026-10-01", "2026-10-03").
+		WillReturnRows(sqlmock.NewRows([]string{"day", "views", "likes", "ratings"}).
+			AddRow(day("2026-10-01"), 0, 7, 0).
+			AddRow(day("2026-10-03"), 2, 0, 0))
+	mock.ExpectQuery("SELECT day, dau, wau FROM analytics_daily_active_users").
+		WithArgs(1, "2026-10-01", "2026-10-03").
+		WillReturnRows(sqlmock.NewRows([]string{"day", "dau", "wau"}).AddRow(day("2026-10-03"), 3, 9))
+	mock.ExpectQuery("SELECT genre, SUM\\(interactions\\) AS total\\s+FROM analytics_daily_genres").
+		WithArgs(1, "2026-10-01", "2026-10-03", analyticsTopGenres).
+		WillReturnRows(sqlmock.NewRows([]string{"genre", "total"}).AddRow("Fantasy", 5))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin/analytics", AnalyticsHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/analytics?from=2026-10-01&to=2026-10-03", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp AnalyticsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(resp.Series) != 3 {
+		t.Fatalf("expected a zero-filled point per day, got %+v", resp.Series)
+	}
+	first, second, third := resp.Series[0], resp.Series[1], resp.Series[2]
+	if first.Interactions["like"] != 7 || first.Interactions["view"] != 0 || first.Signups != 0 {
+		t.Fatalf("unexpected first day: %+v", first)
+	}
+	if second.Signups != 4 || second.DAU != 0 {
+		t.Fatalf("unexpected second day: %+v", second)
+	}
+	if third.DAU != 3 || third.WAU != 9 || third.Interactions["view"] != 2 {
+		t.Fatalf("unexpected third day: %+v", third)
+	}
+	if len(resp.TopGenres) != 1 || resp.TopGenres[0].Genre != "Fantasy" || resp.TopGenres[0].Interactions != 5 {
+		t.Fatalf("unexpected genres: %+v", resp.TopGenres)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestAnalyticsHandler_InvalidRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin/analytics", AnalyticsHandler)
+
+	for _, q := range []string{
+		"?from=yesterday",
+		"?from=2026-10-05&to=2026-10-01",
+		"?from=2024-01-01&to=2026-01-01",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/analytics"+q, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
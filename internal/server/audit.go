@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// Audited admin actions
+const (
+	AuditBookCreate         = "book.create"
+	AuditBookUpdate         = "book.update"
+	AuditBookMerge          = "book.merge"
+	AuditBookMergeUndo      = "book.merge_undo"
+	AuditUserMerge          = "user.merge"
+	AuditUserUpdate         = "user.update"
+	AuditTranslationPut     = "book_translation.put"
+	AuditTranslationDelete  = "book_translation.delete"
+	AuditReportResolve      = "book_report.resolve"
+	AuditContentRemove      = "content.remove"
+	AuditContentRestore     = "content.restore"
+	AuditThreadDelete       = "thread.delete"
+	AuditPostDelete         = "post.delete"
+	AuditFilterTermAdd      = "content_filter.add"
+	AuditFilterTermRemove   = "content_filter.remove"
+	AuditBookDelete         = "book.delete"
+	AuditUserDelete         = "user.delete"
+	AuditBookRestore        = "book.restore"
+	AuditUserRestore        = "user.restore"
+	AuditListRestore        = "list.restore"
+	AuditInteractionRestore = "interaction.restore"
+)
+
+// auditLogMaxLimit caps GET /admin/audit-log pages
+const auditLogMaxLimit = 100
+
+// auditSnapshots select a target row as one JSON object, keyed by
+// audit_log.target_type. Each takes the row's id; book_translation takes the
+// book id and language (its target_id is the book).
+var auditSnapshots = map[string]string{
+	"book": `SELECT JSON_OBJECT('id', id, 'slug', slug, 'title', title, 'author', author,
+		'published_year', published_year, 'subjects', subjects, 'formats', formats, 'page_count', page_count,
+		'content_warnings', content_warnings, 'audience_rating', audience_rating, 'version', version,
+		'merged_into', merged_into, 'deleted_at', deleted_at, 'deleted_by', deleted_by)
+		FROM books WHERE id = ?`,
+	"user": `SELECT JSON_OBJECT('id', id, 'uuid', uuid, 'email', email, 'handle', handle, 'role', role,
+		'disabled_at', disabled_at, 'merged_into', merged_into, 'deleted_at', deleted_at, 'deleted_by', deleted_by)
+		FROM users WHERE id = ?`,
+	"list": `SELECT JSON_OBJECT('id', id, 'uuid', uuid, 'user_id', user_id, 'name', name, 'visibility', visibility,
+		'deleted_at', deleted_at, 'deleted_by', deleted_by)
+		FROM lists WHERE id = ?`,
+	"interaction": `SELECT JSON_OBJECT('id', id, 'uuid', uuid, 'user_id', user_id, 'book_id', book_id, 'action', action,
+		'rating', rating, 'visibility', visibility, 'deleted_at', deleted_at, 'deleted_by', deleted_by)
+		FROM interactions WHERE id = ?`,
+	"book_translation": `SELECT JSON_OBJECT('book_id', book_id, 'language', language, 'title', title,
+		'description', description)
+		FROM book_translations WHERE book_id = ? AND language = ?`,
+	"book_report": `SELECT JSON_OBJECT('id', id, 'book_id', book_id, 'reason', reason, 'status', status,
+		'resolution', resolution, 'resolution_note', resolution_note)
+		FROM book_reports WHERE id = ?`,
+	"thread": `SELECT JSON_OBJECT('id', id, 'title', title, 'user_id', user_id, 'hidden_at', hidden_at,
+		'deleted_at', deleted_at, 'deleted_by', deleted_by)
+		FROM discussion_threads WHERE id = ?`,
+	"post": `SELECT JSON_OBJECT('id', id, 'thread_id', thread_id, 'user_id', user_id, 'body', body,
+		'hidden_at', hidden_at, 'deleted_at', deleted_at, 'deleted_by', deleted_by)
+		FROM discussion_posts WHERE id = ?`,
+	"content_filter_term": `SELECT JSON_OBJECT('id', id, 'term', term, 'match_mode', match_mode)
+		FROM content_filter_terms WHERE id = ?`,
+}
+
+// auditSnapshot reads a target row as JSON inside tx. It returns nil (NULL)
+// when the row doesn't exist, as before a create or after a delete.
+func auditSnapshot(ctx context.Context, tx *sql.Tx, targetType string, key ...interface{}) (interface{}, error) {
+	var snapshot sql.NullString
+	err := tx.QueryRowContext(ctx, auditSnapshots[targetType], key...).Scan(&snapshot)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !snapshot.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.String, nil
+}
+
+// recordAudit appends the caller's action on a target to audit_log, with
+// before (from auditSnapshot ahead of the change) and the row as it now
+// stands. key selects the row when it isn't just targetID. Run it in the
+// transaction that makes the change, so either both land or neither.
+func recordAudit(c *gin.Context, tx *sql.Tx, action, targetType string, targetID int, before interface{}, key ...interface{}) error {
+	ctx := c.Request.Context()
+	if len(key) == 0 {
+		key = []interface{}{targetID}
+	}
+	after, err := auditSnapshot(ctx, tx, targetType, key...)
+	if err != nil {
+		return err
+	}
+	var actorID, actorEmail interface{}
+	if id := c.GetInt("auth_user_id"); id != 0 {
+		actorID = id
+	}
+	if email := c.GetString("auth_email"); email != "" {
+		actorEmail = email
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO audit_log (organization_id, actor_id, actor_email, action, target_type, target_id, before_snapshot, after_snapshot)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		tenant.ID(ctx), actorID, actorEmail, action, targetType, targetID, before, after)
+	return err
+}
+
+// ListAuditLogHandler godoc
+// @Summary Browse the audit log of admin actions (newest first)
+// @Description Every admin mutation (book edits, deletes and translations, merges and their undos, restores of deleted rows, report and content moderation, blocked-term changes) is recorded with who made it and JSON snapshots of the target before and after (null before a create and after a delete). Entries can't be changed or removed.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param action query string false "Only this action, e.g. book.update"
+// @Param target_type query string false "Only this target type: book, user, list, interaction, book_translation, book_report, thread, post or content_filter_term"
+// @Param target_id query int false "Only this target (with target_type)"
+// @Param actor query string false "Only actions by this admin (UUID)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(50)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/audit-log [get]
+func ListAuditLogHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	where := " WHERE a.organization_id = ?"
+	args := []interface{}{tenant.ID(ctx)}
+	if action := c.Query("action"); action != "" {
+		where += " AND a.action = ?"
+		args = append(args, action)
+	}
+	if targetType := c.Query("target_type"); targetType != "" {
+		if _, ok := auditSnapshots[targetType]; !ok {
+			abortWithError(c, badRequest("target_type must be book, user, list, interaction, book_translation, book_report, thread, post or content_filter_term"))
+			return
+		}
+		where += " AND a.target_type = ?"
+		args = append(args, targetType)
+	}
+	if raw := c.Query("target_id"); raw != "" {
+		if c.Query("target_type") == "" {
+			abortWithError(c, badRequest("target_id needs target_type"))
+			return
+		}
+		targetID, err := strconv.Atoi(raw)
+		if err != nil || targetID < 1 {
+			abortWithError(c, badRequest("target_id must be a positive integer"))
+			return
+		}
+		where += " AND a.target_id = ?"
+		args = append(args, targetID)
+	}
+	if raw := c.Query("actor"); raw != "" {
+		actorID, ok := resolveParam(c, resolveUserRef, raw, "actor")
+		if !ok {
+			return
+		}
+		where += " AND a.actor_id = ?"
+		args = append(args, actorID)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > auditLogMaxLimit {
+		limit = 50
+	}
+	offset := (page - 1) * limit
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log a"+where, args...).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT a.uuid, a.action, a.target_type, a.target_id, a.before_snapshot, a.after_snapshot, a.created_at,
+		       a.actor_id, u.uuid, a.actor_email
+		FROM audit_log a
+		LEFT JOIN users u ON u.id = a.actor_id`+where+`
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	entries := []gin.H{}
+	for rows.Next() {
+		var targetID int
+		var publicID, action, targetType, createdAt string
+		var before, after, actorUUID, actorEmail sql.NullString
+		var actorID sql.NullInt64
+		if err := rows.Scan(&publicID, &action, &targetType, &targetID, &before, &after, &createdAt,
+			&actorID, &actorUUID, &actorEmail); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		// the email outlives a deleted actor's account
+		var actor interface{}
+		if actorID.Valid {
+			actor = gin.H{"uuid": nullableString(actorUUID), "email": nullableString(actorEmail)}
+		}
+		entries = append(entries, gin.H{
+			"uuid":       publicID,
+			"action":     action,
+			"actor":      actor,
+			"target":     gin.H{"type": targetType, "id": targetID},
+			"before":     rawJSON(before),
+			"after":      rawJSON(after),
+			"created_at": createdAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  entries,
+	})
+}
+
+// rawJSON embeds a stored JSON document as-is (null when NULL)
+func rawJSON(s sql.NullString) interface{} {
+	if !s.Valid {
+		return nil
+	}
+	return json.RawMessage(s.String)
+}
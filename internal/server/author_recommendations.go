@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/recommend"
+)
+
+// loadAuthorRecommendations returns userID's top 10 books by the authors
+// whose books they enjoyed (liked, or rated 4 or 5): more by authors you
+// like. An author scores +1 for each of their books the user enjoyed and -1
+// for each they disliked or rated 1 or 2; authors scoring 0 or less are left
+// out, and each of the author's other books gets the author's score, ties
+// going to the books most liked in the organization. The catalogue is the
+// user's organization's; filters and content preferences apply as in
+// loadRecommendations.
+func loadAuthorRecommendations(ctx context.Context, q querier, userID int, filters bookFilters) ([]gin.H, error) {
+	prefs, err := loadContentPreferences(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	filters.avoidWarnings, filters.maxAudience = prefs.AvoidWarnings, prefs.MaxAudienceRating
+	filterSQL, filterArgs := filters.sql("b")
+	query := `
+        WITH favourite AS (
+            SELECT r.author_id,
+                   COUNT(DISTINCT CASE WHEN i.action = 'like' OR (i.action = 'rating' AND i.rating >= 4) THEN i.book_id END)
+                       - COUNT(DISTINCT CASE WHEN i.action = 'dislike' OR (i.action = 'rating' AND i.rating <= 2) THEN i.book_id END) AS score
+            FROM interactions i
+            JOIN books r ON r.id = i.book_id
+            WHERE i.user_id = ? AND i.deleted_at IS NULL AND r.author_id IS NOT NULL
+            GROUP BY r.author_id
+            HAVING score > 0
+        ),
+        reader AS (
+            SELECT organization_id FROM users WHERE id = ?
+        )
+        SELECT b.id, b.uuid, b.slug, b.title, b.author, b.page_count, f.score, b.cover_id
+        FROM favourite f
+        JOIN books b ON b.author_id = f.author_id
+        CROSS JOIN reader
+        LEFT JOIN book_counters bc ON bc.book_id = b.id AND bc.organization_id = reader.organization_id
+        WHERE (b.organization_id IS NULL OR b.organization_id = reader.organization_id)
+          AND b.deleted_at IS NULL AND b.merged_into IS NULL
+          AND b.id NOT IN (` + recommend.KnownBooksSQL + `)` + filterSQL + `
+        ORDER BY f.score DESC, COALESCE(bc.likes, 0) DESC, b.id
+        LIMIT 10;
+    `
+	rows, err := q.QueryContext(ctx, query, append([]interface{}{userID, userID, userID, userID}, filterArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	recs := []gin.H{}
+	for rows.Next() {
+		var id, score int
+		var publicID, slug, title string
+		var author sql.NullString
+		var pages, cover sql.NullInt64
+		if err := rows.Scan(&id, &publicID, &slug, &title, &author, &pages, &score, &cover); err != nil {
+			return nil, err
+		}
+		recs = append(recs, gin.H{
+			"book_id":       id,
+			"book_uuid":     publicID,
+			"slug":          slug,
+			"title":         title,
+			"author":        author.String,
+			"page_count":    nullableInt(pages),
+			"reading_hours": readingHours(pages),
+			"cover_url":     coverURL(cover),
+			"score":         score,
+		})
+	}
+	return recs, rows.Err()
+}
+
+// authorReasons picks, for each book, the most recently enjoyed book of the
+// user's by the same author
+func authorReasons(ctx context.Context, q querier, userID int, ids []interface{}) (map[int]gin.H, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT c.id, a.id, a.name, b.uuid, b.slug, b.title
+		FROM books c
+		JOIN authors a ON a.id = c.author_id
+		JOIN books b ON b.author_id = c.author_id
+		JOIN interactions i ON i.book_id = b.id
+		WHERE c.id IN (`+placeholders(len(ids))+`)
+			AND i.user_id = ?
+			AND (i.action = 'like' OR (i.action = 'rating' AND i.rating >= 4))
+			AND i.deleted_at IS NULL
+		ORDER BY c.id, i.created_at DESC, b.id`, append(append([]interface{}{}, ids...), userID)...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	reasons := map[int]gin.H{}
+	for rows.Next() {
+		var bookID int
+		var authorID int64
+		var name, publicID, slug, title string
+		if err := rows.Scan(&bookID, &authorID, &name, &publicID, &slug, &title); err != nil {
+			return nil, err
+		}
+		if reasons[bookID] != nil {
+			continue
+		}
+		reasons[bookID] = gin.H{
+			"type":   "author",
+			"book":   gin.H{"uuid": publicID, "slug": slug, "title": title},
+			"author": gin.H{"id": authorID, "name": name},
+			"text":   "More by " + name + ", because you liked " + title,
+		}
+	}
+	return reasons, rows.Err()
+}
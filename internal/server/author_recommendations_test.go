@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecommendationsHandler_AuthorsMode(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT avoid_content_warnings, max_audience_rating FROM users WHERE id = \\?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"avoid_content_warnings", "max_audience_rating"}).AddRow("", nil))
+	mock.ExpectQuery("WITH favourite AS .+GROUP BY r.author_id\\s+HAVING score > 0.+JOIN books b ON b.author_id = f.author_id.+ORDER BY f.score DESC, COALESCE\\(bc.likes, 0\\) DESC, b.id").
+		WithArgs(2, 2, 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "page_count", "score", "cover_id"}).
+			AddRow(12, "b-12", "the-tombs-of-atuan-b12", "The Tombs of Atuan", "Ursula K. Le Guin", 180, 2, nil).
+			AddRow(15, "b-15", "emma-b15", "Emma", "Jane Austen", nil, 1, nil))
+	// Emma's author has no enjoyed book left to explain it
+	mock.ExpectQuery("FROM books c\\s+JOIN authors a ON a.id = c.author_id\\s+JOIN books b ON b.author_id = c.author_id").
+		WithArgs(12, 15, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"c_id", "a_id", "name", "uuid", "slug", "title"}).
+			AddRow(12, 7, "Ursula K. Le Guin", "b-9", "earthsea-b9", "A Wizard of Earthsea").
+			AddRow(12, 7, "Ursula K. Le Guin", "b-10", "dispossessed-b10", "The Dispossessed"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/recommendations/:user_id", asUser(2), RecommendationsHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recommendations/2?mode=authors", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if strings.Index(body, "Atuan") > strings.Index(body, "Emma") {
+		t.Fatalf("expected The Tombs of Atuan first, got %s", body)
+	}
+	if !strings.Contains(body, `"text":"More by Ursula K. Le Guin, because you liked A Wizard of Earthsea"`) ||
+		!strings.Contains(body, `"reason":null`) {
+		t.Fatalf("expected Atuan explained by Earthsea and Emma unexplained, got %s", body)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
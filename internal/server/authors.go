@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// Popular authors config
+const (
+	popularAuthorsDefaultLimit = 10
+	popularAuthorsMaxLimit     = 50
+)
+
+// GetAuthorHandler godoc
+// @Summary Get an author
+// @Description The author's name, how many of the organization's books are theirs (book_count) and how popular those books are in the organization: likes, ratings and avg_rating (null when unrated), summed over the books. A book's author_id (GET /books/{id}) or ?include=author links here. 404 when the organization has none of the author's books.
+// @Tags Authors
+// @Produce json
+// @Param id path int true "Author ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /authors/{id} [get]
+func GetAuthorHandler(c *gin.Context) {
+	author, ok := authorParam(c)
+	if !ok {
+		return
+	}
+	c.JSON(200, author)
+}
+
+// ListAuthorBooksHandler godoc
+// @Summary An author's books, most liked first
+// @Description The organization's books by the author, ranked by their likes in the organization, then newest first.
+// @Tags Authors
+// @Produce json
+// @Param id path int true "Author ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /authors/{id}/books [get]
+func ListAuthorBooksHandler(c *gin.Context) {
+	author, ok := authorParam(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	orgID := tenant.ID(ctx)
+	rows, err := db.QueryContext(ctx, `
+		SELECT b.id, b.uuid, b.slug, b.title, b.published_year, b.formats, b.page_count, b.cover_id, COALESCE(bc.likes, 0)
+		FROM books b
+		LEFT JOIN book_counters bc ON bc.book_id = b.id AND bc.organization_id = ?
+		WHERE b.author_id = ? AND `+tenant.BooksVisibleSQL("b")+` AND b.merged_into IS NULL
+		ORDER BY COALESCE(bc.likes, 0) DESC, b.published_year DESC, b.id
+		LIMIT ? OFFSET ?`, orgID, author["id"], orgID, limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	books := []map[string]interface{}{}
+	for rows.Next() {
+		var id, likes int
+		var publicID, slug, title, formats string
+		var year, pages, cover sql.NullInt64
+		if err := rows.Scan(&id, &publicID, &slug, &title, &year, &formats, &pages, &cover, &likes); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		books = append(books, gin.H{
+			"id":            id,
+			"uuid":          publicID,
+			"slug":          slug,
+			"title":         title,
+			"author":        author["name"],
+			"year":          year.Int64,
+			"formats":       splitFormats(formats),
+			"page_count":    nullableInt(pages),
+			"reading_hours": readingHours(pages),
+			"cover_url":     coverURL(cover),
+			"likes":         likes,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := localizeBooks(c, books); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	hideInternalIDs(books, "id")
+
+	c.JSON(200, gin.H{
+		"author": gin.H{"id": author["id"], "name": author["name"]},
+		"page":   page,
+		"limit":  limit,
+		"total":  author["book_count"],
+		"data":   books,
+	})
+}
+
+// PopularAuthorsHandler godoc
+// @Summary Most liked authors in the organization
+// @Description Authors ranked by the likes their books have in the organization, with the same stats as GET /authors/{id}. Authors with no likes are left out.
+// @Tags Authors
+// @Produce json
+// @Param limit query int false "Number of authors (1-50)" default(10)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /authors/popular [get]
+func PopularAuthorsHandler(c *gin.Context) {
+	limit := popularAuthorsDefaultLimit
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > popularAuthorsMaxLimit {
+			abortWithError(c, badRequest("limit must be between 1 and "+strconv.Itoa(popularAuthorsMaxLimit)))
+			return
+		}
+		limit = n
+	}
+
+	ctx := c.Request.Context()
+	orgID := tenant.ID(ctx)
+	rows, err := db.QueryContext(ctx, authorStatsSQL+`
+		GROUP BY a.id, a.name
+		HAVING SUM(bc.likes) > 0
+		ORDER BY SUM(bc.likes) DESC, a.id
+		LIMIT ?`, orgID, orgID, limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	authors := []gin.H{}
+	for rows.Next() {
+		author, err := scanAuthor(rows)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		authors = append(authors, author)
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"data": authors})
+}
+
+// authorStatsSQL selects authors with their visible books and those books'
+// counters; bind the organization ID twice, then add a WHERE or GROUP BY
+var authorStatsSQL = `
+		SELECT a.id, a.name, COUNT(b.id), COALESCE(SUM(bc.likes), 0), COALESCE(SUM(bc.ratings), 0),
+			SUM(bc.rating_sum) / NULLIF(SUM(bc.ratings), 0)
+		FROM authors a
+		JOIN books b ON b.author_id = a.id AND b.merged_into IS NULL
+		LEFT JOIN book_counters bc ON bc.book_id = b.id AND bc.organization_id = ?
+		WHERE ` + tenant.BooksVisibleSQL("b")
+
+// scanAuthor reads a row of authorStatsSQL
+func scanAuthor(row interface{ Scan(...interface{}) error }) (gin.H, error) {
+	var id int64
+	var name string
+	var books, likes, ratings int
+	var avg sql.NullFloat64
+	if err := row.Scan(&id, &name, &books, &likes, &ratings, &avg); err != nil {
+		return nil, err
+	}
+	author := gin.H{"id": id, "name": name, "book_count": books, "likes": likes, "ratings": ratings, "avg_rating": nil}
+	if avg.Valid {
+		author["avg_rating"] = avg.Float64
+	}
+	return author, nil
+}
+
+// loadAuthor is the author with the stats GET /authors/{id} shows, or
+// sql.ErrNoRows when the tenant on ctx has none of their books
+func loadAuthor(ctx context.Context, id int64) (gin.H, error) {
+	orgID := tenant.ID(ctx)
+	return scanAuthor(db.QueryRowContext(ctx, authorStatsSQL+` AND a.id = ?
+		GROUP BY a.id, a.name`, orgID, orgID, id))
+}
+
+// authorParam loads the author in the path, answering 404/500 itself; it
+// reports whether the handler should continue
+func authorParam(c *gin.Context) (gin.H, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		abortWithError(c, notFound("author not found"))
+		return nil, false
+	}
+	author, err := loadAuthor(c.Request.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("author not found"))
+		return nil, false
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return nil, false
+	}
+	return author, true
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+var authorColumns = []string{"id", "name", "books", "likes", "ratings", "avg_rating"}
+
+func authorsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorMiddleware())
+	r.GET("/authors/popular", PopularAuthorsHandler)
+	r.GET("/authors/:id", GetAuthorHandler)
+	r.GET("/authors/:id/books", ListAuthorBooksHandler)
+	return r
+}
+
+func TestGetAuthorHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("FROM authors a\\s+JOIN books b ON b.author_id = a.id AND b.merged_into IS NULL\\s+LEFT JOIN book_counters bc .* AND a.id = \\?\\s+GROUP BY a.id, a.name").
+		WithArgs(1, 1, int64(7)).
+		WillReturnRows(sqlmock.NewRows(authorColumns).AddRow(7, "Ursula K. Le Guin", 3, 40, 12, 4.25))
+	// another organization's author, and one with no books left
+	mock.ExpectQuery("FROM authors a").
+		WithArgs(1, 1, int64(8)).
+		WillReturnRows(sqlmock.NewRows(authorColumns))
+
+	r := authorsRouter()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/authors/7", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var author map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &author); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if author["name"] != "Ursula K. Le Guin" || author["book_count"] != float64(3) || author["likes"] != float64(40) || author["avg_rating"] != 4.25 {
+		t.Fatalf("unexpected author %v", author)
+	}
+
+	for _, path := range []string{"/authors/8", "/authors/le-guin"} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404, got %d", path, w.Code)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestListAuthorBooksHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("FROM authors a").
+		WithArgs(1, 1, int64(7)).
+		WillReturnRows(sqlmock.NewRows(authorColumns).AddRow(7, "Ursula K. Le Guin", 3, 40, 12, 4.25))
+	mock.ExpectQuery("FROM books b\\s+LEFT JOIN book_counters bc ON bc.book_id = b.id AND bc.organization_id = \\?\\s+WHERE b.author_id = \\? AND .+ORDER BY COALESCE\\(bc.likes, 0\\) DESC, b.published_year DESC, b.id\\s+LIMIT \\? OFFSET \\?").
+		WithArgs(1, int64(7), 1, 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "published_year", "formats", "page_count", "cover_id", "likes"}).
+			AddRow(10, "b-10", "dispossessed-b10", "The Dispossessed", 1974, "print", 387, nil, 3))
+
+	r := authorsRouter()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/authors/7/books?page=2&limit=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Author struct {
+			Name string `json:"name"`
+		} `json:"author"`
+		Total int `json:"total"`
+		Data  []struct {
+			Title  string `json:"title"`
+			Author string `json:"author"`
+			Likes  int    `json:"likes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if body.Total != 3 || body.Author.Name != "Ursula K. Le Guin" || len(body.Data) != 1 ||
+		body.Data[0].Author != "Ursula K. Le Guin" || body.Data[0].Likes != 3 {
+		t.Fatalf("unexpected books: %s", w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestPopularAuthorsHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("FROM authors a.+GROUP BY a.id, a.name\\s+HAVING SUM\\(bc.likes\\) > 0\\s+ORDER BY SUM\\(bc.likes\\) DESC, a.id\\s+LIMIT \\?").
+		WithArgs(1, 1, 5).
+		WillReturnRows(sqlmock.NewRows(authorColumns).
+			AddRow(7, "Ursula K. Le Guin", 3, 40, 12, 4.25).
+			AddRow(2, "Frank Herbert", 1, 9, 0, nil))
+
+	r := authorsRouter()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/authors/popular?limit=5", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if len(body.Data) != 2 || body.Data[0]["likes"] != float64(40) || body.Data[1]["avg_rating"] != nil {
+		t.Fatalf("unexpected authors: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/authors/popular?limit=500", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a limit out of range, got %d", w.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// maxFormMemory is how much of a multipart form is kept in memory; the
+// rest spills to temporary files, as with c.PostForm
+const maxFormMemory = 32 << 20
+
+func init() {
+	// name fields in validation errors by the form (or JSON) key a client
+	// sent, not the Go field name
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			for _, tag := range []string{"form", "json"} {
+				name := strings.Split(f.Tag.Get(tag), ",")[0]
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return f.Name
+		})
+	}
+}
+
+// normalizer is implemented by request structs that clean up what was
+// sent (trimming whitespace, ...) before it's validated
+type normalizer interface {
+	normalize()
+}
+
+// bindRequest fills req, a pointer to a struct with form, json and binding
+// tags, from the request body and validates it. application/json bodies are
+// decoded as JSON and anything else as an urlencoded or multipart form, with
+// the same field names either way. On failure it answers 400 with one
+// ValidationDetail per invalid field and returns false.
+func bindRequest(c *gin.Context, req interface{}) bool {
+	decode := decodeForm
+	if c.ContentType() == binding.MIMEJSON {
+		decode = decodeJSON
+	}
+	if err := decode(c, req); err != nil {
+		abortWithError(c, err)
+		return false
+	}
+	if n, ok := req.(normalizer); ok {
+		n.normalize()
+	}
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		abortWithError(c, badRequest("request is invalid").WithDetails(bindingDetails(err)))
+		return false
+	}
+	return true
+}
+
+func decodeForm(c *gin.Context, req interface{}) error {
+	if err := c.Request.ParseMultipartForm(maxFormMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return badRequest("request body is not a valid form")
+	}
+	if err := binding.MapFormWithTag(req, c.Request.PostForm, "form"); err != nil {
+		// conversion errors don't name the field; the OpenAPI validator has
+		// usually rejected the value by name already
+		reason := err.Error()
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) {
+			reason = strconv.Quote(numErr.Num) + " is not a number"
+		}
+		return badRequest("request is invalid").WithDetails([]ValidationDetail{{In: "body", Reason: reason}})
+	}
+	return nil
+}
+
+// decodeJSON decodes the body into req; an empty body is an empty object,
+// so missing fields are reported as such
+func decodeJSON(c *gin.Context, req interface{}) error {
+	err := json.NewDecoder(c.Request.Body).Decode(req)
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil, errors.Is(err, io.EOF):
+		return nil
+	case errors.As(err, &typeErr):
+		return badRequest("request is invalid").WithDetails([]ValidationDetail{
+			{In: "body", Name: typeErr.Field, Reason: "must be " + jsonTypeName(typeErr.Type)}})
+	default:
+		return badRequest("request body is not valid JSON")
+	}
+}
+
+func jsonTypeName(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "a boolean"
+	}
+	return "a " + t.Kind().String()
+}
+
+// bindingDetails describes each field that failed validation
+func bindingDetails(err error) []ValidationDetail {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return []ValidationDetail{{In: "body", Reason: err.Error()}}
+	}
+	details := make([]ValidationDetail, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		details = append(details, ValidationDetail{In: "body", Name: fe.Field(), Reason: validationReason(fe)})
+	}
+	return details
+}
+
+func validationReason(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be an email address"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "min":
+		if fe.Kind() == reflect.String && fe.Param() == "1" {
+			return "must not be empty"
+		}
+		if fe.Kind() == reflect.String {
+			return "must be at least " + fe.Param() + " characters"
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return "must be at most " + fe.Param() + " characters"
+		}
+		return "must be at most " + fe.Param()
+	}
+	return "failed the " + fe.Tag() + " check"
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateUserHandler_FieldErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/users", CreateUserHandler)
+
+	w := postForm(r, "/users", url.Values{"email": {"not-an-email"}, "handle": {"   "}, "password": {"pw"}})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	raw, _ := json.Marshal(body.Details)
+	var details []ValidationDetail
+	_ = json.Unmarshal(raw, &details)
+	// the handle is trimmed before it's validated, so blanks are missing
+	want := []ValidationDetail{
+		{In: "body", Name: "email", Reason: "must be an email address"},
+		{In: "body", Name: "handle", Reason: "is required"},
+	}
+	if body.Code != "bad_request" || !reflect.DeepEqual(details, want) {
+		t.Fatalf("unexpected error %s", w.Body.String())
+	}
+}
+
+func TestBindRequest_Multipart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	var got loginRequest
+	r.POST("/login", func(c *gin.Context) {
+		if bindRequest(c, &got) {
+			c.Status(http.StatusNoContent)
+		}
+	})
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	_ = mw.WriteField("email", " a@example.com ")
+	_ = mw.WriteField("password", " pw ")
+	_ = mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/login", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	// passwords are taken as sent
+	if got.Email != "a@example.com" || got.Password != " pw " {
+		t.Fatalf("unexpected binding %+v", got)
+	}
+}
+
+func TestBindRequest_JSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/interactions", func(c *gin.Context) {
+		var req createInteractionRequest
+		if bindRequest(c, &req) {
+			c.JSON(http.StatusOK, req)
+		}
+	})
+
+	cases := []struct {
+		name    string
+		body    string
+		status  int
+		message string
+		details []ValidationDetail
+	}{
+		{"valid", `{"user_id": "1", "book_id": "the-hobbit", "action": "rating", "rating": 5}`, http.StatusOK, "", nil},
+		{"wrong type", `{"user_id": "1", "book_id": "2", "action": "rating", "rating": "5"}`, http.StatusBadRequest,
+			"request is invalid", []ValidationDetail{{In: "body", Name: "rating", Reason: "must be an integer"}}},
+		{"out of range", `{"user_id": "1", "book_id": "2", "action": "rating", "rating": 9}`, http.StatusBadRequest,
+			"request is invalid", []ValidationDetail{{In: "body", Name: "rating", Reason: "must be at most 5"}}},
+		{"malformed", `{"user_id": `, http.StatusBadRequest, "request body is not valid JSON", nil},
+		{"empty", ``, http.StatusBadRequest, "request is invalid", []ValidationDetail{
+			{In: "body", Name: "user_id", Reason: "is required"},
+			{In: "body", Name: "book_id", Reason: "is required"},
+			{In: "body", Name: "action", Reason: "is required"},
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/interactions", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json; charset=utf-8")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.status {
+				t.Fatalf("expected %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+			if tc.status == http.StatusOK {
+				// the default visibility applies to JSON bodies too
+				if !strings.Contains(w.Body.String(), `"visibility":"public"`) {
+					t.Fatalf("unexpected binding %s", w.Body.String())
+				}
+				return
+			}
+			var body struct {
+				Message string             `json:"message"`
+				Details []ValidationDetail `json:"details"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.Message != tc.message || !reflect.DeepEqual(body.Details, tc.details) {
+				t.Fatalf("unexpected error %s", w.Body.String())
+			}
+		})
+	}
+}
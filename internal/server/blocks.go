@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+)
+
+// notBlockedSQL keeps rows whose author (column col) the viewer hasn't
+// blocked; it takes the viewer's ID as its argument
+func notBlockedSQL(col string) string {
+	return "NOT EXISTS (SELECT 1 FROM user_blocks ub WHERE ub.blocker_id = ? AND ub.blocked_id = " + col + ")"
+}
+
+// blockedUsers returns the IDs viewerID has blocked; anonymous viewers (0)
+// have none and cost no query
+func blockedUsers(ctx context.Context, viewerID int) (map[int]bool, error) {
+	if viewerID <= 0 {
+		return nil, nil
+	}
+	rows, err := db.QueryContext(ctx, "SELECT blocked_id FROM user_blocks WHERE blocker_id = ?", viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	blocked := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		blocked[id] = true
+	}
+	return blocked, rows.Err()
+}
+
+// eitherBlocked reports whether a has blocked b or b has blocked a
+func eitherBlocked(ctx context.Context, a, b int) (bool, error) {
+	return rowExists(ctx,
+		"SELECT 1 FROM user_blocks WHERE (blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)",
+		a, b, b, a)
+}
+
+// BlockUserHandler godoc
+// @Summary Block a user
+// @Description Removes follows in both directions and stops either user following the other. The blocked user's discussion threads disappear from your thread lists and their posts are withheld (blocked true, body null). Idempotent: blocking someone already blocked returns 200 instead of 201.
+// @Tags Social
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID to block"
+// @Success 201 {object} map[string]interface{}
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/block [post]
+func BlockUserHandler(c *gin.Context) {
+	blockedID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+	blockerID := c.GetInt("auth_user_id")
+	if blockerID <= 0 {
+		abortWithError(c, unauthorized("unauthorized"))
+		return
+	}
+	if blockerID == blockedID {
+		abortWithError(c, badRequest("cannot block yourself"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT IGNORE INTO user_blocks (blocker_id, blocked_id) VALUES (?, ?)", blockerID, blockedID)
+	if err != nil {
+		if dberr.Is(err, dberr.ErrMissingReference) {
+			abortWithError(c, notFound("user not found"))
+			return
+		}
+		abortWithError(c, err)
+		return
+	}
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM follows WHERE (follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)",
+		blockerID, blockedID, blockedID, blockerID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	refs, err := publicRefs(ctx, tx, "users", blockerID, blockedID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	status := 200
+	if n, _ := res.RowsAffected(); n > 0 {
+		status = 201
+	}
+	c.JSON(status, gin.H{
+		"blocker_uuid": refs[0],
+		"blocked_uuid": refs[1],
+		"blocked":      true,
+	})
+}
+
+// UnblockUserHandler godoc
+// @Summary Unblock a user
+// @Description Follows removed by the block aren't restored. Idempotent: unblocking someone you haven't blocked also returns 204.
+// @Tags Social
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID to unblock"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/block [delete]
+func UnblockUserHandler(c *gin.Context) {
+	blockedID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+	blockerID := c.GetInt("auth_user_id")
+	if blockerID <= 0 {
+		abortWithError(c, unauthorized("unauthorized"))
+		return
+	}
+
+	if _, err := db.ExecContext(c.Request.Context(),
+		"DELETE FROM user_blocks WHERE blocker_id = ? AND blocked_id = ?", blockerID, blockedID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// ListBlocksHandler godoc
+// @Summary Users you have blocked (newest first)
+// @Tags Social
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID; must be the caller"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/blocks [get]
+func ListBlocksHandler(c *gin.Context) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+	if c.GetInt("auth_user_id") != userID {
+		abortWithError(c, forbidden("cannot view another user's blocks"))
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	var total int
+	if err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM user_blocks WHERE blocker_id = ?", userID).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT u.uuid, u.handle, ub.created_at
+		FROM user_blocks ub
+		JOIN users u ON u.id = ub.blocked_id
+		WHERE ub.blocker_id = ?
+		ORDER BY ub.created_at DESC, u.id DESC
+		LIMIT ? OFFSET ?`, userID, limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	users := []gin.H{}
+	for rows.Next() {
+		var publicID, handle, blockedAt string
+		if err := rows.Scan(&publicID, &handle, &blockedAt); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		users = append(users, gin.H{
+			"uuid":       publicID,
+			"handle":     handle,
+			"blocked_at": blockedAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  users,
+	})
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBlockUserHandler_RemovesFollows(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT IGNORE INTO user_blocks \\(blocker_id, blocked_id\\) VALUES \\(\\?, \\?\\)").
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM follows WHERE \\(follower_id = \\? AND followee_id = \\?\\) OR \\(follower_id = \\? AND followee_id = \\?\\)").
+		WithArgs(1, 2, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	expectPublicRef(mock, "users", 1, "u-1")
+	expectPublicRef(mock, "users", 2, "u-2")
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/users/:id/block", asUser(1), BlockUserHandler)
+
+	for _, tc := range []struct {
+		path string
+		want int
+	}{
+		{"/users/2/block", http.StatusCreated},
+		{"/users/1/block", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.path, nil))
+		if w.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d: %s", tc.path, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestGetBookThreadHandler_WithholdsBlockedAuthors(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectBookThread(mock, 9, 8)
+	mock.ExpectQuery("FROM discussion_threads t\\s+JOIN books b ON b.id = t.book_id\\s+WHERE t.id = \\?").
+		WithArgs(8).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "book_uuid", "title", "spoiler", "created_at", "count"}).
+			AddRow("t-8", "b-9", "The ending", false, "2026-10-01 12:00:00", 2))
+	mock.ExpectQuery("SELECT blocked_id FROM user_blocks WHERE blocker_id = \\?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"blocked_id"}).AddRow(3))
+	mock.ExpectQuery("FROM discussion_posts p\\s+LEFT JOIN users u ON u.id = p.user_id\\s+WHERE p.thread_id = \\?").
+		WithArgs(8, 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "body", "spoiler", "deleted", "hidden", "created_at", "user_id", "user_uuid", "handle"}).
+			AddRow(1, "What did everyone think?", false, false, false, "2026-10-01 12:00:00", 2, "u-2", "bob").
+			AddRow(2, "you're all wrong", false, false, false, "2026-10-01 13:00:00", 3, "u-3", "troll"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/:id/threads/:thread_id", asUser(1), GetBookThreadHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/9/threads/8", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if len(body.Data) != 2 || body.Data[0]["body"] != "What did everyone think?" || body.Data[0]["blocked"] != false {
+		t.Fatalf("unexpected posts: %v", body.Data)
+	}
+	if body.Data[1]["body"] != nil || body.Data[1]["blocked"] != true {
+		t.Fatalf("blocked author's post not withheld: %v", body.Data[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
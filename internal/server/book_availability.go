@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/library"
+)
+
+// Availability cache config
+const (
+	availabilityTTL        = 6 * time.Hour
+	availabilityMaxEntries = 10000
+)
+
+// libraryProvider answers GET /books/{id}/availability; nil when
+// LIBRARY_PROVIDER isn't set
+var libraryProvider library.Provider
+
+type availabilityKey struct {
+	bookID int
+	region string
+}
+
+type availabilityEntry struct {
+	holdings  []library.Holding
+	checkedAt time.Time
+}
+
+// availabilityCache keeps provider answers per book and region for
+// availabilityTTL; holdings change slowly and provider APIs are rate limited
+type availabilityCache struct {
+	mu      sync.Mutex
+	entries map[availabilityKey]availabilityEntry
+}
+
+var availability = &availabilityCache{entries: map[availabilityKey]availabilityEntry{}}
+
+// Get returns the holdings of bookID in region, asking the provider on a miss.
+// cached reports whether the answer came from the cache.
+func (a *availabilityCache) Get(ctx context.Context, p library.Provider, bookID int, book library.Book, region string) (availabilityEntry, bool, error) {
+	key := availabilityKey{bookID: bookID, region: region}
+	now := time.Now()
+	a.mu.Lock()
+	entry, ok := a.entries[key]
+	a.mu.Unlock()
+	if ok && now.Before(entry.checkedAt.Add(availabilityTTL)) {
+		return entry, true, nil
+	}
+
+	holdings, err := p.Holdings(ctx, book, region)
+	if err != nil {
+		return availabilityEntry{}, false, err
+	}
+	entry = availabilityEntry{holdings: holdings, checkedAt: now}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.entries) >= availabilityMaxEntries {
+		for k, e := range a.entries {
+			if !now.Before(e.checkedAt.Add(availabilityTTL)) {
+				delete(a.entries, k)
+			}
+		}
+		if len(a.entries) >= availabilityMaxEntries {
+			a.entries = map[availabilityKey]availabilityEntry{}
+		}
+	}
+	a.entries[key] = entry
+	return entry, false, nil
+}
+
+// BookAvailabilityHandler godoc
+// @Summary Libraries in a region that carry a book
+// @Description Asks the configured library provider (LIBRARY_PROVIDER) and caches the answer per book and region for 6 hours. available is whether a copy can be borrowed now, or null when the provider only knows ownership (WorldCat).
+// @Tags Books
+// @Produce json
+// @Param id path string true "Book UUID or slug"
+// @Param location query string true "ISO 3166 country (US) or subdivision (US-CA) code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /books/{id}/availability [get]
+func BookAvailabilityHandler(c *gin.Context) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	region, err := library.NormalizeRegion(c.Query("location"))
+	if err != nil {
+		abortWithError(c, badRequest("location: "+err.Error()))
+		return
+	}
+	if libraryProvider == nil {
+		abortWithError(c, newAppError(503, "library availability isn't configured"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	var publicID, title string
+	var author sql.NullString
+	err = db.QueryRowContext(ctx, "SELECT uuid, title, author FROM books WHERE id = ?", bookID).Scan(&publicID, &title, &author)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("book not found"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	entry, cached, err := availability.Get(ctx, libraryProvider, bookID,
+		library.Book{Title: title, Author: author.String}, region)
+	if err != nil {
+		log.Printf("⚠️ %s availability lookup failed: %v", libraryProvider.Name(), err)
+		abortWithError(c, newAppError(502, "library provider unavailable"))
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"book_uuid":  publicID,
+		"location":   region,
+		"provider":   libraryProvider.Name(),
+		"carried":    len(entry.holdings) > 0,
+		"libraries":  entry.holdings,
+		"checked_at": entry.checkedAt,
+		"cached":     cached,
+	})
+}
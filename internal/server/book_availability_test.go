@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/library"
+)
+
+// fakeLibraries holds every book everywhere and counts lookups
+type fakeLibraries struct{ calls int }
+
+func (f *fakeLibraries) Name() string { return "fake" }
+
+func (f *fakeLibraries) Holdings(_ context.Context, book library.Book, region string) ([]library.Holding, error) {
+	f.calls++
+	return []library.Holding{{Name: book.Title + " Library", Region: region}}, nil
+}
+
+func TestBookAvailabilityHandler_CachesPerRegion(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	fake := &fakeLibraries{}
+	libraryProvider = fake
+	availability = &availabilityCache{entries: map[availabilityKey]availabilityEntry{}}
+	defer func() { libraryProvider = nil }()
+
+	for range 2 {
+		mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		mock.ExpectQuery("SELECT uuid, title, author FROM books WHERE id = \\?").
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"uuid", "title", "author"}).AddRow("b-3", "Dune", "Frank Herbert"))
+	}
+	// bad location
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/:id/availability", BookAvailabilityHandler)
+
+	for i, target := range []string{"/books/3/availability?location=us-ca", "/books/3/availability?location=US-CA"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("bad json: %v", err)
+		}
+		if body["carried"] != true || body["location"] != "US-CA" || body["cached"] != (i == 1) {
+			t.Fatalf("unexpected body: %v", body)
+		}
+	}
+	if fake.calls != 1 {
+		t.Fatalf("expected one provider lookup, got %d", fake.calls)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/3/availability?location=California", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad location, got %d", w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
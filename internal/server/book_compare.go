@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/softdelete"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// compareMaxBooks caps how many books GET /books/compare takes at once
+const compareMaxBooks = 4
+
+// CompareBooksHandler godoc
+// @Summary Compare books side by side
+// @Description Metadata, genres, average rating, review count and the rating distribution of each book, plus audience overlap for every pair. Readers are people who liked or rated a book; a_readers_who_read_b_pct is the share of A's readers who also read B (null when A has none).
+// @Tags Books
+// @Produce json
+// @Param ids query string true "2 to 4 comma-separated book UUIDs or slugs"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /books/compare [get]
+func CompareBooksHandler(c *gin.Context) {
+	refs := []string{}
+	for _, ref := range strings.Split(c.Query("ids"), ",") {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) < 2 || len(refs) > compareMaxBooks {
+		abortWithError(c, badRequest(fmt.Sprintf("ids must list 2 to %d books", compareMaxBooks)))
+		return
+	}
+	bookIDs := make([]int, 0, len(refs))
+	seen := map[int]bool{}
+	for _, ref := range refs {
+		id, ok := resolveParam(c, resolveBookRef, ref, "book")
+		if !ok {
+			return
+		}
+		if seen[id] {
+			abortWithError(c, badRequest("ids lists the same book twice"))
+			return
+		}
+		seen[id] = true
+		bookIDs = append(bookIDs, id)
+	}
+
+	ctx := c.Request.Context()
+	books, err := loadComparedBooks(ctx, bookIDs)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	overlap, err := loadAudienceOverlap(ctx, bookIDs, books)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	hideInternalIDs(books, "id")
+	c.JSON(200, gin.H{
+		"books":   books,
+		"overlap": overlap,
+	})
+}
+
+// loadComparedBooks returns the books in bookIDs order with genres,
+// avg_rating, rating_count, review_count, rating_distribution and readers
+// filled in
+func loadComparedBooks(ctx context.Context, bookIDs []int) ([]map[string]interface{}, error) {
+	ids := make([]interface{}, len(bookIDs))
+	for i, id := range bookIDs {
+		ids[i] = id
+	}
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT id, uuid, slug, title, author, published_year FROM books WHERE id IN ("+placeholders(len(ids))+")", ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	byID := map[int]map[string]interface{}{}
+	for rows.Next() {
+		var id int
+		var publicID, slug, title string
+		var author sql.NullString
+		var year sql.NullInt64
+		if err := rows.Scan(&id, &publicID, &slug, &title, &author, &year); err != nil {
+			return nil, err
+		}
+		byID[id] = map[string]interface{}{
+			"id":                  id,
+			"uuid":                publicID,
+			"slug":                slug,
+			"title":               title,
+			"author":              author.String,
+			"year":                year.Int64,
+			"rating_distribution": map[string]int{"1": 0, "2": 0, "3": 0, "4": 0, "5": 0},
+			"readers":             0,
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	books := make([]map[string]interface{}, 0, len(bookIDs))
+	for _, id := range bookIDs {
+		if b, ok := byID[id]; ok {
+			books = append(books, b)
+		}
+	}
+
+	if err := applyIncludes(ctx, books, map[string]bool{includeGenres: true, includeAvgRating: true}); err != nil {
+		return nil, err
+	}
+
+	args := append([]interface{}{tenant.ID(ctx)}, ids...)
+	ratings, err := db.QueryContext(ctx, `
+		SELECT book_id, rating, COUNT(*)
+		FROM interactions
+		WHERE organization_id = ? AND `+softdelete.LiveSQL("")+` AND action = 'rating' AND rating IS NOT NULL
+		  AND book_id IN (`+placeholders(len(ids))+`)
+		GROUP BY book_id, rating`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = ratings.Close() }()
+	for ratings.Next() {
+		var id, rating, n int
+		if err := ratings.Scan(&id, &rating, &n); err != nil {
+			return nil, err
+		}
+		if b, ok := byID[id]; ok {
+			b["rating_distribution"].(map[string]int)[strconv.Itoa(rating)] = n
+		}
+	}
+	if err := ratings.Err(); err != nil {
+		return nil, err
+	}
+
+	readers, err := db.QueryContext(ctx, `
+		SELECT book_id, COUNT(DISTINCT user_id)
+		FROM interactions
+		WHERE organization_id = ? AND `+softdelete.LiveSQL("")+` AND action <> 'view' AND book_id IN (`+placeholders(len(ids))+`)
+		GROUP BY book_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = readers.Close() }()
+	for readers.Next() {
+		var id, n int
+		if err := readers.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		if b, ok := byID[id]; ok {
+			b["readers"] = n
+		}
+	}
+	return books, readers.Err()
+}
+
+// loadAudienceOverlap counts shared readers for every pair of books, in the
+// order the books were asked for
+func loadAudienceOverlap(ctx context.Context, bookIDs []int, books []map[string]interface{}) ([]gin.H, error) {
+	ids := make([]interface{}, len(bookIDs))
+	for i, id := range bookIDs {
+		ids[i] = id
+	}
+	orgID := tenant.ID(ctx)
+	args := append(append([]interface{}{orgID, orgID}, ids...), ids...)
+	rows, err := db.QueryContext(ctx, `
+		SELECT a.book_id, b.book_id, COUNT(DISTINCT a.user_id)
+		FROM interactions a
+		JOIN interactions b ON b.user_id = a.user_id AND b.book_id > a.book_id
+		WHERE a.organization_id = ? AND b.organization_id = ?
+		  AND `+softdelete.LiveSQL("a")+` AND `+softdelete.LiveSQL("b")+`
+		  AND a.action <> 'view' AND b.action <> 'view'
+		  AND a.book_id IN (`+placeholders(len(ids))+`)
+		  AND b.book_id IN (`+placeholders(len(ids))+`)
+		GROUP BY a.book_id, b.book_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	type pair struct{ a, b int }
+	shared := map[pair]int{}
+	for rows.Next() {
+		var a, b, n int
+		if err := rows.Scan(&a, &b, &n); err != nil {
+			return nil, err
+		}
+		shared[pair{a, b}] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	readers := map[int]int{}
+	uuids := map[int]interface{}{}
+	for _, b := range books {
+		readers[b["id"].(int)] = b["readers"].(int)
+		uuids[b["id"].(int)] = b["uuid"]
+	}
+	// share of from's readers who also read the other book
+	pct := func(n, from int) interface{} {
+		if readers[from] == 0 {
+			return nil
+		}
+		return math.Round(float64(n)*1000/float64(readers[from])) / 10
+	}
+
+	overlap := []gin.H{}
+	for i, a := range bookIDs {
+		for _, b := range bookIDs[i+1:] {
+			n := shared[pair{min(a, b), max(a, b)}]
+			overlap = append(overlap, gin.H{
+				"a":                        uuids[a],
+				"b":                        uuids[b],
+				"shared_readers":           n,
+				"a_readers_who_read_b_pct": pct(n, a),
+				"b_readers_who_read_a_pct": pct(n, b),
+			})
+		}
+	}
+	return overlap, nil
+}
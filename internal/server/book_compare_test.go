@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCompareBooksHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	for _, id := range []int{2, 1} {
+		mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+			WithArgs(id, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}
+	mock.ExpectQuery("SELECT id, uuid, slug, title, author, published_year FROM books WHERE id IN \\(\\?, \\?\\)").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "published_year"}).
+			AddRow(1, "b-1", "dune", "Dune", "Frank Herbert", 1965).
+			AddRow(2, "b-2", "hyperion", "Hyperion", "Dan Simmons", 1989))
+	mock.ExpectQuery("SELECT bg.book_id, g.slug\\s+FROM book_genres bg\\s+JOIN genres g ON g.id = bg.genre_id\\s+WHERE bg.book_id IN").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "slug"}).AddRow(1, "science-fiction"))
+	mock.ExpectQuery("SELECT book_id, rating_sum / ratings, ratings\\s+FROM book_counters").
+		WithArgs(1, 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "avg", "count"}).AddRow(1, 4.5, 2))
+	mock.ExpectQuery("SELECT book_id, COUNT\\(\\*\\)\\s+FROM reviews").
+		WithArgs(1, 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "count"}))
+	// deleted ratings and readers don't count
+	mock.ExpectQuery("SELECT book_id, rating, COUNT\\(\\*\\)\\s+FROM interactions\\s+WHERE organization_id = \\? AND deleted_at IS NULL AND action = 'rating'").
+		WithArgs(1, 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "rating", "count"}).AddRow(1, 4, 1).AddRow(1, 5, 1))
+	mock.ExpectQuery("SELECT book_id, COUNT\\(DISTINCT user_id\\)\\s+FROM interactions\\s+WHERE organization_id = \\? AND deleted_at IS NULL AND action <> 'view'").
+		WithArgs(1, 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "readers"}).AddRow(1, 4).AddRow(2, 2))
+	mock.ExpectQuery("FROM interactions a\\s+JOIN interactions b ON b.user_id = a.user_id AND b.book_id > a.book_id\\s+WHERE a.organization_id = \\? AND b.organization_id = \\?\\s+AND a.deleted_at IS NULL AND b.deleted_at IS NULL").
+		WithArgs(1, 1, 2, 1, 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"a", "b", "shared"}).AddRow(1, 2, 1))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/compare", CompareBooksHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/compare?ids=2,1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Books   []map[string]interface{} `json:"books"`
+		Overlap []map[string]interface{} `json:"overlap"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if len(body.Books) != 2 || body.Books[0]["title"] != "Hyperion" {
+		t.Fatalf("books not in the order asked for: %s", w.Body.String())
+	}
+	if _, ok := body.Books[0]["id"]; ok {
+		t.Fatalf("expected no integer ids, got %s", w.Body.String())
+	}
+	dist := body.Books[1]["rating_distribution"].(map[string]interface{})
+	if dist["5"] != float64(1) || dist["1"] != float64(0) {
+		t.Fatalf("unexpected rating distribution: %v", dist)
+	}
+	if len(body.Overlap) != 1 {
+		t.Fatalf("expected one pair, got %v", body.Overlap)
+	}
+	pair := body.Overlap[0]
+	if pair["a"] != "b-2" || pair["a_readers_who_read_b_pct"] != float64(50) || pair["b_readers_who_read_a_pct"] != float64(25) {
+		t.Fatalf("unexpected overlap: %v", pair)
+	}
+
+	for _, target := range []string{"/books/compare?ids=1", "/books/compare?ids=1,2,3,4,5"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", target, w.Code)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/authors"
+	"github.com/YeswanthC7/bookrec/internal/contentwarnings"
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+	"github.com/YeswanthC7/bookrec/internal/genres"
+	"github.com/YeswanthC7/bookrec/internal/ids"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// NewBook is the body of POST /admin/books; only title is required
+type NewBook struct {
+	Title         *string   `json:"title" example:"The Hobbit"`
+	Author        *string   `json:"author,omitempty" example:"J.R.R. Tolkien"`
+	PublishedYear *int      `json:"published_year,omitempty" example:"1937"`
+	Subjects      *[]string `json:"subjects,omitempty"`
+	Formats       *[]string `json:"formats,omitempty" example:"print,ebook"`
+	PageCount     *int      `json:"page_count,omitempty" example:"310"`
+	// ContentWarnings and AudienceRating, when either is sent, are curated;
+	// otherwise they're derived from subjects as ingest does
+	ContentWarnings *[]string `json:"content_warnings,omitempty" example:"violence"`
+	AudienceRating  *string   `json:"audience_rating,omitempty" example:"teen"`
+	// Slug defaults to one made from the title
+	Slug *string `json:"slug,omitempty" example:"the-hobbit"`
+}
+
+// CreateBookHandler godoc
+// @Summary Add a book
+// @Description Adds a book by hand, validated like PATCH /admin/books/{id}. The default organization adds to the shared catalogue, any other to its own titles. Content warnings and audience are derived from subjects unless given. 409 if the slug is taken.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param body body NewBook true "The book"
+// @Success 201 {object} map[string]interface{}
+// @Header 201 {string} Location "/books/{slug}"
+// @Header 201 {string} ETag "The version, \"1\""
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/books [post]
+func CreateBookHandler(c *gin.Context) {
+	var req NewBook
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, badRequest("invalid JSON body"))
+		return
+	}
+	if req.Title == nil {
+		abortWithError(c, badRequest("title is required"))
+		return
+	}
+	sets, args, err := BookPatch{
+		Title: req.Title, Author: req.Author, PublishedYear: req.PublishedYear, Subjects: req.Subjects,
+		Formats: req.Formats, PageCount: req.PageCount, ContentWarnings: req.ContentWarnings,
+		AudienceRating: req.AudienceRating, Slug: req.Slug,
+	}.assignments()
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+
+	publicID := ids.New()
+	if req.Slug == nil {
+		sets = append(sets, "slug = ?")
+		args = append(args, ids.BookSlug(strings.TrimSpace(*req.Title), publicID))
+	}
+	if req.ContentWarnings == nil && req.AudienceRating == nil && req.Subjects != nil {
+		var audience interface{}
+		if a := contentwarnings.AudienceFromSubjects(*req.Subjects); a != "" {
+			audience = a
+		}
+		sets = append(sets, "content_warnings = ?", "audience_rating = ?")
+		args = append(args, strings.Join(contentwarnings.FromSubjects(*req.Subjects), ","), audience)
+	}
+	ctx := c.Request.Context()
+	var orgID interface{}
+	if id := tenant.ID(ctx); id != tenant.DefaultID {
+		orgID = id
+	}
+	sets = append(sets, "uuid = ?", "organization_id = ?")
+	args = append(args, publicID, orgID)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO books SET "+strings.Join(sets, ", "), args...)
+	if dberr.Is(err, dberr.ErrDuplicate) {
+		abortWithError(c, conflict("slug is already taken"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	id64, err := res.LastInsertId()
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	id := int(id64)
+
+	var slug, title string
+	err = tx.QueryRowContext(ctx, "SELECT slug, title FROM books WHERE id = ?", id).Scan(&slug, &title)
+	if err == nil && req.Subjects != nil {
+		err = genres.Assign(ctx, tx, id64, *req.Subjects)
+	}
+	if err == nil && req.Author != nil {
+		err = authors.Link(ctx, tx, id64, *req.Author)
+	}
+	if err == nil {
+		err = recordAudit(c, tx, AuditBookCreate, "book", id, nil)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Header("Location", "/books/"+slug)
+	c.Header("ETag", bookETag(1))
+	c.JSON(201, gin.H{"uuid": publicID, "slug": slug, "title": title, "version": 1})
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+)
+
+func postBook(body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin/books", CreateBookHandler)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/books", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateBookHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// the default organization adds to the shared catalogue, and warnings
+	// come from the subjects when none are given
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO books SET title = \\?, author = \\?, subjects = \\?, slug = \\?, content_warnings = \\?, audience_rating = \\?, uuid = \\?, organization_id = \\?").
+		WithArgs("The Hobbit", "J.R.R. Tolkien", `["Fantasy","War","Young adult"]`, sqlmock.AnyArg(), "war", "teen", sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectQuery("SELECT slug, title FROM books WHERE id = \\?").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"slug", "title"}).AddRow("the-hobbit-3f6c1a9e", "The Hobbit"))
+	mock.ExpectExec("DELETE FROM book_genres WHERE book_id = \\?").
+		WithArgs(9).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO book_genres").
+		WithArgs(9, "fantasy", "young-adult").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO authors").
+		WithArgs("J.R.R. Tolkien").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("UPDATE books SET author_id = \\? WHERE id = \\?").
+		WithArgs(int64(2), 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, AuditBookCreate, "book", 9)
+	mock.ExpectCommit()
+
+	w := postBook(`{"title": " The Hobbit ", "author": "J.R.R. Tolkien", "subjects": ["Fantasy", "War", "Young adult"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Location") != "/books/the-hobbit-3f6c1a9e" || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("unexpected headers %v", w.Header())
+	}
+	var body map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	if body["id"] != nil || body["slug"] != "the-hobbit-3f6c1a9e" || body["version"] != float64(1) {
+		t.Fatalf("unexpected body %s", w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestCreateBookHandler_Invalid(t *testing.T) {
+	cases := []struct {
+		name, body, message string
+	}{
+		{"no title", `{"author": "Anon"}`, "title is required"},
+		{"blank title", `{"title": "  "}`, "title cannot be empty"},
+		{"bad year", `{"title": "Dune", "published_year": 0}`, "published_year out of range"},
+		{"bad slug", `{"title": "Dune", "slug": "Dune!"}`, "slug must be lowercase letters, digits and single dashes, and not only digits"},
+		{"not json", `{"title": `, "invalid JSON body"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := postBook(tc.body)
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tc.message) {
+				t.Fatalf("expected 400 %q, got %d: %s", tc.message, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateBookHandler_SlugTaken(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO books SET title = \\?, slug = \\?, uuid = \\?, organization_id = \\?").
+		WithArgs("Dune", "dune", sqlmock.AnyArg(), nil).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'dune' for key 'books.uq_books_slug'"})
+	mock.ExpectRollback()
+
+	w := postBook(`{"title": "Dune", "slug": "dune"}`)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "slug is already taken") {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
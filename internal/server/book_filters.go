@@ -0,0 +1,156 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/contentwarnings"
+	"github.com/YeswanthC7/bookrec/internal/genres"
+	"github.com/YeswanthC7/bookrec/internal/openlibrary"
+)
+
+// wordsPerPage converts page counts to words for reading time estimates
+const wordsPerPage = 275
+
+// readingWPM is the reading speed behind reading_hours (READING_WPM, read
+// in Run by loadReadingWPM)
+var readingWPM = 250
+
+// loadReadingWPM reads READING_WPM, keeping the default unless it's a
+// positive whole number
+func loadReadingWPM() {
+	if n, err := strconv.Atoi(os.Getenv("READING_WPM")); err == nil && n > 0 {
+		readingWPM = n
+	}
+}
+
+// readingHours estimates hours to read pages at readingWPM, to a tenth of an
+// hour; nil when the page count is unknown
+func readingHours(pages sql.NullInt64) interface{} {
+	if !pages.Valid || pages.Int64 <= 0 {
+		return nil
+	}
+	return math.Round(float64(pages.Int64)*wordsPerPage/float64(readingWPM)/60*10) / 10
+}
+
+// nullableInt is v or nil
+func nullableInt(v sql.NullInt64) interface{} {
+	if !v.Valid {
+		return nil
+	}
+	return v.Int64
+}
+
+// coverURL links to a book's medium-size cover on Open Library, or nil when
+// the book has no cover ID
+func coverURL(coverID sql.NullInt64) interface{} {
+	if !coverID.Valid {
+		return nil
+	}
+	return openlibrary.CoverURL(coverID.Int64, "M")
+}
+
+// bookFilters narrows book listings and recommendations: formats keeps books
+// available in any of them, minPages/maxPages (0 = unbounded) their length,
+// genre those its subjects put in that genre (a slug from GET /genres).
+// Length filters leave out books with no known page count.
+// avoidWarnings and maxAudience come from a reader's content preferences;
+// books with no audience rating pass maxAudience.
+type bookFilters struct {
+	formats       []string
+	minPages      int
+	maxPages      int
+	genre         string
+	avoidWarnings []string
+	maxAudience   string
+}
+
+// parseBookFilters reads ?format=, ?min_pages=, ?max_pages= and ?genre=
+func parseBookFilters(c *gin.Context) (bookFilters, error) {
+	formats, err := parseFormats(c.Query("format"))
+	if err != nil {
+		return bookFilters{}, err
+	}
+	f := bookFilters{formats: formats}
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"min_pages", &f.minPages}, {"max_pages", &f.maxPages}} {
+		raw := strings.TrimSpace(c.Query(p.name))
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return bookFilters{}, fmt.Errorf("%s must be a positive integer", p.name)
+		}
+		*p.dst = n
+	}
+	if f.maxPages > 0 && f.minPages > f.maxPages {
+		return bookFilters{}, fmt.Errorf("min_pages cannot be greater than max_pages")
+	}
+	if f.genre, err = parseGenre(c); err != nil {
+		return bookFilters{}, err
+	}
+	return f, nil
+}
+
+// parseGenre reads ?genre=, a slug from GET /genres ("" when absent)
+func parseGenre(c *gin.Context) (string, error) {
+	genre := strings.ToLower(strings.TrimSpace(c.Query("genre")))
+	if genre != "" && !genres.Valid(genre) {
+		return "", fmt.Errorf("unknown genre %q; GET /genres lists them", genre)
+	}
+	return genre, nil
+}
+
+// genreFilterSQL is " AND ..." keeping the books whose id column bookID
+// (qualified, or genres.id would shadow it) is filed under the genre slug
+func genreFilterSQL(bookID, genre string) (string, []interface{}) {
+	return " AND EXISTS (SELECT 1 FROM book_genres bg JOIN genres g ON g.id = bg.genre_id WHERE bg.book_id = " + bookID + " AND g.slug = ?)", []interface{}{genre}
+}
+
+// sql is " AND ..." for the filters on books aliased alias (empty for no
+// alias); empty when nothing is filtered
+func (f bookFilters) sql(alias string) (string, []interface{}) {
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+	clause, args := formatsFilterSQL(prefix+"formats", f.formats)
+	if f.minPages > 0 {
+		clause += " AND " + prefix + "page_count >= ?"
+		args = append(args, f.minPages)
+	}
+	if f.maxPages > 0 {
+		clause += " AND " + prefix + "page_count <= ?"
+		args = append(args, f.maxPages)
+	}
+	if f.genre != "" {
+		bookID := "books.id"
+		if alias != "" {
+			bookID = alias + ".id"
+		}
+		genreSQL, genreArgs := genreFilterSQL(bookID, f.genre)
+		clause += genreSQL
+		args = append(args, genreArgs...)
+	}
+	for _, w := range f.avoidWarnings {
+		clause += " AND FIND_IN_SET(?, " + prefix + "content_warnings) = 0"
+		args = append(args, w)
+	}
+	if f.maxAudience != "" {
+		allowed := contentwarnings.Within(f.maxAudience)
+		clause += " AND (" + prefix + "audience_rating IS NULL OR " + prefix + "audience_rating IN (" + placeholders(len(allowed)) + "))"
+		for _, a := range allowed {
+			args = append(args, a)
+		}
+	}
+	return clause, args
+}
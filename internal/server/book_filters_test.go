@@ -0,0 +1,71 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadingHours(t *testing.T) {
+	// 300 pages * 275 words at 250 wpm = 330 minutes
+	if h := readingHours(sql.NullInt64{Int64: 300, Valid: true}); h != 5.5 {
+		t.Fatalf("expected 5.5 hours, got %v", h)
+	}
+	if h := readingHours(sql.NullInt64{}); h != nil {
+		t.Fatalf("expected nil for an unknown page count, got %v", h)
+	}
+}
+
+func TestSearchBooksHandler_FiltersByLength(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("FROM books b\\s+WHERE .* AND b.page_count <= \\?").
+		WithArgs(searchPopularityWeight, 1, 1, 299, 21, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "published_year", "formats", "page_count", "content_warnings", "audience_rating", "sort_key", "cover_id", "language", "publisher"}).
+			AddRow(4, "b-4", "siddhartha-b4", "Siddhartha", "Hermann Hesse", 1922, "print", 152, "", nil, 0, nil, nil, nil))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/search", SearchBooksHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/search?max_pages=299", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data []struct {
+			PageCount    int     `json:"page_count"`
+			ReadingHours float64 `json:"reading_hours"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if len(body.Data) != 1 || body.Data[0].PageCount != 152 || body.Data[0].ReadingHours != 2.8 {
+		t.Fatalf("unexpected books: %s", w.Body.String())
+	}
+
+	for _, query := range []string{"max_pages=lots", "min_pages=0", "min_pages=500&max_pages=300"} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/search?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", query, w.Code)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bookFormats are the values of books.formats, in display order
+var bookFormats = []string{"print", "ebook", "audiobook"}
+
+// parseFormats turns "?format=ebook,audiobook" into a list, rejecting unknown
+// formats; empty means no filter
+func parseFormats(raw string) ([]string, error) {
+	formats := []string{}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" || seen[part] {
+			continue
+		}
+		known := false
+		for _, f := range bookFormats {
+			known = known || f == part
+		}
+		if !known {
+			return nil, fmt.Errorf("unsupported format: %s (want print, ebook or audiobook)", part)
+		}
+		seen[part] = true
+		formats = append(formats, part)
+	}
+	return formats, nil
+}
+
+// formatsFilterSQL is " AND (...)" keeping books available in any of formats
+// (col is the formats column, e.g. "b.formats"); empty when there's no filter
+func formatsFilterSQL(col string, formats []string) (string, []interface{}) {
+	if len(formats) == 0 {
+		return "", nil
+	}
+	conds := make([]string, len(formats))
+	args := make([]interface{}, len(formats))
+	for i, f := range formats {
+		conds[i] = "FIND_IN_SET(?, " + col + ") > 0"
+		args[i] = f
+	}
+	return " AND (" + strings.Join(conds, " OR ") + ")", args
+}
+
+// splitFormats turns a formats column value ("print,ebook") into a list
+func splitFormats(raw string) []string {
+	formats := []string{}
+	for _, f := range strings.Split(raw, ",") {
+		if f != "" {
+			formats = append(formats, f)
+		}
+	}
+	return formats
+}
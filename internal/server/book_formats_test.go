@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseFormats(t *testing.T) {
+	formats, err := parseFormats(" Audiobook,ebook,,audiobook")
+	if err != nil || len(formats) != 2 || formats[0] != "audiobook" || formats[1] != "ebook" {
+		t.Fatalf("unexpected formats: %v, %v", formats, err)
+	}
+	if _, err := parseFormats("vinyl"); err == nil {
+		t.Fatalf("expected an error for an unknown format")
+	}
+}
+
+func TestListBooksHandler_FiltersByFormat(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("FROM books\\s+WHERE .* AND \\(FIND_IN_SET\\(\\?, formats\\) > 0 OR FIND_IN_SET\\(\\?, formats\\) > 0\\)").
+		WithArgs(1, "audiobook", "ebook", 21, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "published_year", "formats", "page_count", "content_warnings", "audience_rating", "cover_id", "language", "publisher"}).
+			AddRow(1, "b-1", "dune-b1", "Dune", "Frank Herbert", 1965, "print,audiobook", 412, "", nil, nil, nil, nil))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books", ListBooksHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books?format=audiobook,ebook", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data []struct {
+			Formats []string `json:"formats"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if len(body.Data) != 1 || len(body.Data[0].Formats) != 2 || body.Data[0].Formats[1] != "audiobook" {
+		t.Fatalf("unexpected books: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books?format=scroll", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown format, got %d", w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
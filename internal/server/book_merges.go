@@ -0,0 +1,600 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/counters"
+	"github.com/YeswanthC7/bookrec/internal/ids"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// mergeMoves is what a merge re-pointed, stored in book_merges.moved so the
+// merge can be undone
+type mergeMoves struct {
+	Interactions []int64 `json:"interactions"`
+	Reviews      []int64 `json:"reviews"`
+	// Lists held the source but not the target; their item now points at
+	// the target
+	Lists []int64 `json:"lists"`
+	// DroppedItems were the source's entries in lists that already held the
+	// target
+	DroppedItems []droppedListItem `json:"dropped_list_items"`
+	ISBNs        []string          `json:"isbns"`
+	// Translations are the languages the target didn't have yet
+	Translations []string `json:"translations"`
+	// Shelves are the readers who shelved the source but not the target;
+	// their row now points at the target
+	Shelves []int64 `json:"shelves"`
+	// DroppedShelves were the source's rows for readers who had also
+	// shelved the target
+	DroppedShelves []droppedShelfBook `json:"dropped_shelves"`
+}
+
+type droppedListItem struct {
+	ListID   int64     `json:"list_id"`
+	Position int       `json:"position"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+type droppedShelfBook struct {
+	UserID    int64     `json:"user_id"`
+	Shelf     string    `json:"shelf"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func resolveBookMergeRef(ctx context.Context, raw string) (int, error) {
+	return resolveRef(ctx, "book_merges", "", orgScope, raw)
+}
+
+// lockedIDs and lockedKeys run a single-column query inside tx
+func lockedIDs(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	found := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found = append(found, id)
+	}
+	return found, rows.Err()
+}
+
+func lockedKeys(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// idArgs and keyArgs append values to the leading query args
+func idArgs(values []int64, leading ...interface{}) []interface{} {
+	args := append([]interface{}{}, leading...)
+	for _, v := range values {
+		args = append(args, v)
+	}
+	return args
+}
+
+func keyArgs(keys []string, leading ...interface{}) []interface{} {
+	args := append([]interface{}{}, leading...)
+	for _, k := range keys {
+		args = append(args, k)
+	}
+	return args
+}
+
+// moveBookRows re-points sourceID's interactions (ratings included),
+// reviews, list items, shelved copies, ISBNs and missing translations to
+// targetID and reports what moved
+func moveBookRows(ctx context.Context, tx *sql.Tx, sourceID, targetID int) (mergeMoves, error) {
+	moves := mergeMoves{DroppedItems: []droppedListItem{}, DroppedShelves: []droppedShelfBook{}}
+	var err error
+
+	// a reader has one row per book and action: where they already have the
+	// target's, theirs for the source stays behind with it
+	moves.Interactions, err = lockedIDs(ctx, tx, `
+		SELECT s.id FROM interactions s
+		WHERE s.book_id = ? AND NOT EXISTS (
+			SELECT 1 FROM interactions t WHERE t.book_id = ? AND t.user_id = s.user_id AND t.action = s.action)
+		ORDER BY s.id
+		FOR UPDATE`, sourceID, targetID)
+	if err != nil {
+		return moves, err
+	}
+	if len(moves.Interactions) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE interactions SET book_id = ? WHERE book_id = ? AND id IN ("+placeholders(len(moves.Interactions))+")",
+			idArgs(moves.Interactions, targetID, sourceID)...); err != nil {
+			return moves, err
+		}
+	}
+
+	// likewise one review per reader and book
+	moves.Reviews, err = lockedIDs(ctx, tx, `
+		SELECT s.id FROM reviews s
+		WHERE s.book_id = ? AND NOT EXISTS (
+			SELECT 1 FROM reviews t WHERE t.book_id = ? AND t.user_id = s.user_id)
+		ORDER BY s.id
+		FOR UPDATE`, sourceID, targetID)
+	if err != nil {
+		return moves, err
+	}
+	if len(moves.Reviews) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE reviews SET book_id = ? WHERE book_id = ? AND id IN ("+placeholders(len(moves.Reviews))+")",
+			idArgs(moves.Reviews, targetID, sourceID)...); err != nil {
+			return moves, err
+		}
+	}
+
+	// a list can't hold the same book twice: where it already has the target,
+	// the source's entry goes and the books after it close the gap
+	rows, err := tx.QueryContext(ctx, `
+		SELECT s.list_id, s.position, s.added_at, t.list_id IS NOT NULL
+		FROM list_items s
+		LEFT JOIN list_items t ON t.list_id = s.list_id AND t.book_id = ?
+		WHERE s.book_id = ?
+		ORDER BY s.list_id
+		FOR UPDATE`, targetID, sourceID)
+	if err != nil {
+		return moves, err
+	}
+	moves.Lists = []int64{}
+	for rows.Next() {
+		var item droppedListItem
+		var hasTarget bool
+		if err := rows.Scan(&item.ListID, &item.Position, &item.AddedAt, &hasTarget); err != nil {
+			_ = rows.Close()
+			return moves, err
+		}
+		if hasTarget {
+			moves.DroppedItems = append(moves.DroppedItems, item)
+		} else {
+			moves.Lists = append(moves.Lists, item.ListID)
+		}
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return moves, err
+	}
+	if len(moves.Lists) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE list_items SET book_id = ? WHERE book_id = ? AND list_id IN ("+placeholders(len(moves.Lists))+")",
+			idArgs(moves.Lists, targetID, sourceID)...); err != nil {
+			return moves, err
+		}
+	}
+	for _, item := range moves.DroppedItems {
+		if _, err := tx.ExecContext(ctx,
+			"DELETE FROM list_items WHERE list_id = ? AND book_id = ?", item.ListID, sourceID); err != nil {
+			return moves, err
+		}
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE list_items SET position = position - 1 WHERE list_id = ? AND position > ?", item.ListID, item.Position); err != nil {
+			return moves, err
+		}
+	}
+
+	// a reader shelves a book once: where they already shelved the target,
+	// that shelf stands and the source's row goes. updated_at is kept, so
+	// shelves stay in the order the reader filled them.
+	rows, err = tx.QueryContext(ctx, `
+		SELECT s.user_id, s.shelf, s.created_at, s.updated_at, t.user_id IS NOT NULL
+		FROM shelf_books s
+		LEFT JOIN shelf_books t ON t.user_id = s.user_id AND t.book_id = ?
+		WHERE s.book_id = ?
+		ORDER BY s.user_id
+		FOR UPDATE`, targetID, sourceID)
+	if err != nil {
+		return moves, err
+	}
+	moves.Shelves = []int64{}
+	for rows.Next() {
+		var shelved droppedShelfBook
+		var hasTarget bool
+		if err := rows.Scan(&shelved.UserID, &shelved.Shelf, &shelved.CreatedAt, &shelved.UpdatedAt, &hasTarget); err != nil {
+			_ = rows.Close()
+			return moves, err
+		}
+		if hasTarget {
+			moves.DroppedShelves = append(moves.DroppedShelves, shelved)
+		} else {
+			moves.Shelves = append(moves.Shelves, shelved.UserID)
+		}
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return moves, err
+	}
+	if len(moves.Shelves) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE shelf_books SET book_id = ?, updated_at = updated_at WHERE book_id = ? AND user_id IN ("+placeholders(len(moves.Shelves))+")",
+			idArgs(moves.Shelves, targetID, sourceID)...); err != nil {
+			return moves, err
+		}
+	}
+	if len(moves.DroppedShelves) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			"DELETE FROM shelf_books WHERE book_id = ? AND user_id IN ("+placeholders(len(moves.DroppedShelves))+")",
+			idArgs(droppedShelfUsers(moves.DroppedShelves), sourceID)...); err != nil {
+			return moves, err
+		}
+	}
+
+	moves.ISBNs, err = lockedKeys(ctx, tx,
+		"SELECT isbn13 FROM book_isbns WHERE book_id = ? ORDER BY isbn13 FOR UPDATE", sourceID)
+	if err != nil {
+		return moves, err
+	}
+	if len(moves.ISBNs) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE book_isbns SET book_id = ? WHERE book_id = ?", targetID, sourceID); err != nil {
+			return moves, err
+		}
+	}
+
+	moves.Translations, err = lockedKeys(ctx, tx, `
+		SELECT s.language FROM book_translations s
+		WHERE s.book_id = ? AND NOT EXISTS (
+			SELECT 1 FROM book_translations t WHERE t.book_id = ? AND t.language = s.language)
+		ORDER BY s.language
+		FOR UPDATE`, sourceID, targetID)
+	if err != nil {
+		return moves, err
+	}
+	if len(moves.Translations) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE book_translations SET book_id = ? WHERE book_id = ? AND language IN ("+placeholders(len(moves.Translations))+")",
+			keyArgs(moves.Translations, targetID, sourceID)...); err != nil {
+			return moves, err
+		}
+	}
+	return moves, nil
+}
+
+// droppedShelfUsers lists the readers whose shelf rows were dropped
+func droppedShelfUsers(dropped []droppedShelfBook) []int64 {
+	users := make([]int64, 0, len(dropped))
+	for _, d := range dropped {
+		users = append(users, d.UserID)
+	}
+	return users
+}
+
+// restoreBookRows puts back what moveBookRows moved. Rows that have moved
+// on since (deleted, or a list that now holds the source again) are skipped.
+func restoreBookRows(ctx context.Context, tx *sql.Tx, sourceID, targetID int, moves mergeMoves) error {
+	if len(moves.Interactions) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE IGNORE interactions SET book_id = ? WHERE book_id = ? AND id IN ("+placeholders(len(moves.Interactions))+")",
+			idArgs(moves.Interactions, sourceID, targetID)...); err != nil {
+			return err
+		}
+	}
+	if len(moves.Reviews) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE IGNORE reviews SET book_id = ? WHERE book_id = ? AND id IN ("+placeholders(len(moves.Reviews))+")",
+			idArgs(moves.Reviews, sourceID, targetID)...); err != nil {
+			return err
+		}
+	}
+	if len(moves.Lists) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE IGNORE list_items SET book_id = ? WHERE book_id = ? AND list_id IN ("+placeholders(len(moves.Lists))+")",
+			idArgs(moves.Lists, sourceID, targetID)...); err != nil {
+			return err
+		}
+	}
+	for _, item := range moves.DroppedItems {
+		res, err := tx.ExecContext(ctx, `
+			INSERT IGNORE INTO list_items (list_id, book_id, position, added_at)
+			SELECT id, ?, ?, ? FROM lists WHERE id = ?`,
+			sourceID, item.Position, item.AddedAt, item.ListID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		// make room: everything from the old position on, except the
+		// restored item, moves down one
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE list_items SET position = position + 1 WHERE list_id = ? AND position >= ? AND book_id <> ?",
+			item.ListID, item.Position, sourceID); err != nil {
+			return err
+		}
+	}
+	if len(moves.Shelves) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE IGNORE shelf_books SET book_id = ?, updated_at = updated_at WHERE book_id = ? AND user_id IN ("+placeholders(len(moves.Shelves))+")",
+			idArgs(moves.Shelves, sourceID, targetID)...); err != nil {
+			return err
+		}
+	}
+	for _, shelved := range moves.DroppedShelves {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT IGNORE INTO shelf_books (user_id, book_id, shelf, created_at, updated_at)
+			SELECT id, ?, ?, ?, ? FROM users WHERE id = ?`,
+			sourceID, shelved.Shelf, shelved.CreatedAt, shelved.UpdatedAt, shelved.UserID); err != nil {
+			return err
+		}
+	}
+	if len(moves.ISBNs) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE book_isbns SET book_id = ? WHERE book_id = ? AND isbn13 IN ("+placeholders(len(moves.ISBNs))+")",
+			keyArgs(moves.ISBNs, sourceID, targetID)...); err != nil {
+			return err
+		}
+	}
+	if len(moves.Translations) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE IGNORE book_translations SET book_id = ? WHERE book_id = ? AND language IN ("+placeholders(len(moves.Translations))+")",
+			keyArgs(moves.Translations, sourceID, targetID)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MergeBookHandler godoc
+// @Summary Merge a duplicate book into another
+// @Description In one transaction, moves the duplicate's interactions (ratings included), reviews, list items, shelved copies, ISBNs and the translations the target lacks to the target, recounts both books, closes open duplicate reports on the duplicate (resolution merged) and marks it merged. GET /books/{id} on the duplicate's slug or UUID then redirects (301) to the target. A reader's review of the duplicate stays behind when they also reviewed the target. Where a list already holds the target, the duplicate's entry is dropped, and so is a reader's shelf row for the duplicate when they also shelved the target. The merge is recorded and can be undone with POST /admin/book-merges/{id}/undo.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Duplicate book slug or UUID"
+// @Param into query string true "Surviving book slug or UUID"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/books/{id}/merge [post]
+func MergeBookHandler(c *gin.Context) {
+	sourceID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	if c.Query("into") == "" {
+		abortWithError(c, badRequest("into is required"))
+		return
+	}
+	targetID, ok := resolveParam(c, resolveBookRef, c.Query("into"), "target book")
+	if !ok {
+		return
+	}
+	if sourceID == targetID {
+		abortWithError(c, badRequest("cannot merge a book into itself"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// lock both books; only one the tenant may edit can be merged away
+	var sourceUUID, targetUUID string
+	var sourceMerged, targetMerged sql.NullInt64
+	err = tx.QueryRowContext(ctx,
+		"SELECT uuid, merged_into FROM books WHERE id = ? AND "+editableBooksSQL(ctx)+" FOR UPDATE",
+		sourceID, tenant.ID(ctx)).Scan(&sourceUUID, &sourceMerged)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("book not found"))
+		return
+	}
+	if err == nil {
+		err = tx.QueryRowContext(ctx, "SELECT uuid, merged_into FROM books WHERE id = ? FOR UPDATE", targetID).Scan(&targetUUID, &targetMerged)
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if sourceMerged.Valid || targetMerged.Valid {
+		merged, message := sourceMerged, "book is already merged"
+		if !sourceMerged.Valid {
+			merged, message = targetMerged, "target book was itself merged; merge into its survivor instead"
+		}
+		survivor, err := publicRef(ctx, tx, "books", int(merged.Int64))
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		abortWithError(c, conflict(message).WithDetails(gin.H{"merged_into": survivor}))
+		return
+	}
+
+	before, err := auditSnapshot(ctx, tx, "book", sourceID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	moves, err := moveBookRows(ctx, tx, sourceID, targetID)
+	if err == nil {
+		err = counters.Recount(ctx, tx, sourceID, targetID)
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	adminID := c.GetInt("auth_user_id")
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE books SET merged_into = ?, merged_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = ?`, targetID, sourceID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	// old links to the duplicate now lead to the survivor
+	if err := redirectAway(ctx, tx, "book", sourceID, targetID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	closed, err := tx.ExecContext(ctx, `
+		UPDATE book_reports
+		SET status = 'resolved', resolution = 'merged', resolved_by = ?, resolved_at = CURRENT_TIMESTAMP
+		WHERE book_id = ? AND reason = 'duplicate' AND status = 'open' AND organization_id = ?`,
+		adminID, sourceID, tenant.ID(ctx))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	movedJSON, _ := json.Marshal(moves)
+	publicID := ids.New()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO book_merges (uuid, organization_id, source_id, target_id, merged_by, moved)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		publicID, tenant.ID(ctx), sourceID, targetID, adminID, string(movedJSON))
+	if err == nil {
+		err = recordAudit(c, tx, AuditBookMerge, "book", sourceID, before)
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	reportsClosed, _ := closed.RowsAffected()
+	c.JSON(201, gin.H{
+		"uuid":        publicID,
+		"source_uuid": sourceUUID,
+		"target_uuid": targetUUID,
+		"moved": gin.H{
+			"interactions":       len(moves.Interactions),
+			"reviews":            len(moves.Reviews),
+			"list_items":         len(moves.Lists),
+			"dropped_list_items": len(moves.DroppedItems),
+			"shelves":            len(moves.Shelves),
+			"dropped_shelves":    len(moves.DroppedShelves),
+			"isbns":              len(moves.ISBNs),
+			"translations":       len(moves.Translations),
+		},
+		"reports_closed": reportsClosed,
+		"links": gin.H{
+			"target": "/books/" + c.Query("into"),
+			"undo":   "/admin/book-merges/" + publicID + "/undo",
+		},
+	})
+}
+
+// UndoBookMergeHandler godoc
+// @Summary Undo a book merge
+// @Description Moves back what the merge moved, un-marks the duplicate and drops its redirects. Rows removed since the merge stay removed; reports the merge closed stay closed.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Merge UUID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/book-merges/{id}/undo [post]
+func UndoBookMergeHandler(c *gin.Context) {
+	mergeID, ok := resolveParam(c, resolveBookMergeRef, c.Param("id"), "merge")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var sourceID, targetID int
+	var publicID, sourceUUID, targetUUID, movedJSON string
+	var undoneAt sql.NullTime
+	var mergedInto sql.NullInt64
+	if err := tx.QueryRowContext(ctx, `
+		SELECT m.uuid, m.source_id, b.uuid, m.target_id, t.uuid, m.moved, m.undone_at, b.merged_into
+		FROM book_merges m
+		JOIN books b ON b.id = m.source_id
+		JOIN books t ON t.id = m.target_id
+		WHERE m.id = ?
+		FOR UPDATE`, mergeID).
+		Scan(&publicID, &sourceID, &sourceUUID, &targetID, &targetUUID, &movedJSON, &undoneAt, &mergedInto); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if undoneAt.Valid {
+		abortWithError(c, conflict("merge was already undone"))
+		return
+	}
+	if !mergedInto.Valid || int(mergedInto.Int64) != targetID {
+		abortWithError(c, conflict("book is no longer merged into the target"))
+		return
+	}
+
+	var moves mergeMoves
+	if err := json.Unmarshal([]byte(movedJSON), &moves); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	before, err := auditSnapshot(ctx, tx, "book", sourceID)
+	if err == nil {
+		err = restoreBookRows(ctx, tx, sourceID, targetID, moves)
+	}
+	if err == nil {
+		err = counters.Recount(ctx, tx, sourceID, targetID)
+	}
+	if err == nil {
+		_, err = tx.ExecContext(ctx,
+			"UPDATE books SET merged_into = NULL, merged_at = NULL, version = version + 1 WHERE id = ?", sourceID)
+	}
+	if err == nil {
+		err = unredirect(ctx, tx, "book", sourceID, targetID)
+	}
+	if err == nil {
+		_, err = tx.ExecContext(ctx,
+			"UPDATE book_merges SET undone_by = ?, undone_at = CURRENT_TIMESTAMP WHERE id = ?",
+			c.GetInt("auth_user_id"), mergeID)
+	}
+	if err == nil {
+		err = recordAudit(c, tx, AuditBookMergeUndo, "book", sourceID, before)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"uuid":        publicID,
+		"source_uuid": sourceUUID,
+		"target_uuid": targetUUID,
+		"undone":      true,
+	})
+}
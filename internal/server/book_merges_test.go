@@ -0,0 +1,235 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMergeBookHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	added := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, id := range []int{5, 9} {
+		mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+			WithArgs(id, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT uuid, merged_into FROM books WHERE id = \\? AND \\(\\(organization_id IS NULL OR organization_id = \\?\\) AND deleted_at IS NULL\\) FOR UPDATE").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "merged_into"}).AddRow("b-5", nil))
+	mock.ExpectQuery("SELECT uuid, merged_into FROM books WHERE id = \\? FOR UPDATE").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "merged_into"}).AddRow("b-9", nil))
+	expectAuditSnapshot(mock, "book", `{"id": 5, "merged_into": null}`)
+	mock.ExpectQuery("SELECT s.id FROM interactions s\\s+WHERE s.book_id = \\? AND NOT EXISTS").
+		WithArgs(5, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11).AddRow(12))
+	mock.ExpectExec("UPDATE interactions SET book_id = \\? WHERE book_id = \\? AND id IN \\(\\?, \\?\\)").
+		WithArgs(9, 5, int64(11), int64(12)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("SELECT s.id FROM reviews s\\s+WHERE s.book_id = \\? AND NOT EXISTS").
+		WithArgs(5, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(21))
+	mock.ExpectExec("UPDATE reviews SET book_id = \\? WHERE book_id = \\? AND id IN \\(\\?\\)").
+		WithArgs(9, 5, int64(21)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// list 3 only has the duplicate; list 4 has both
+	mock.ExpectQuery("SELECT s.list_id, s.position, s.added_at, t.list_id IS NOT NULL\\s+FROM list_items s").
+		WithArgs(9, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"list_id", "position", "added_at", "has_target"}).
+			AddRow(3, 2, added, false).
+			AddRow(4, 1, added, true))
+	mock.ExpectExec("UPDATE list_items SET book_id = \\? WHERE book_id = \\? AND list_id IN \\(\\?\\)").
+		WithArgs(9, 5, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM list_items WHERE list_id = \\? AND book_id = \\?").
+		WithArgs(4, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE list_items SET position = position - 1 WHERE list_id = \\? AND position > \\?").
+		WithArgs(4, 1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	// reader 6 only shelved the duplicate; reader 7 shelved both
+	mock.ExpectQuery("SELECT s.user_id, s.shelf, s.created_at, s.updated_at, t.user_id IS NOT NULL\\s+FROM shelf_books s").
+		WithArgs(9, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "shelf", "created_at", "updated_at", "has_target"}).
+			AddRow(6, "reading", added, added, false).
+			AddRow(7, "read", added, added, true))
+	mock.ExpectExec("UPDATE shelf_books SET book_id = \\?, updated_at = updated_at WHERE book_id = \\? AND user_id IN \\(\\?\\)").
+		WithArgs(9, 5, int64(6)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM shelf_books WHERE book_id = \\? AND user_id IN \\(\\?\\)").
+		WithArgs(5, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT isbn13 FROM book_isbns WHERE book_id = \\?").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"isbn13"}))
+	mock.ExpectQuery("SELECT s.language FROM book_translations s").
+		WithArgs(5, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"language"}).AddRow("fr"))
+	mock.ExpectExec("UPDATE book_translations SET book_id = \\? WHERE book_id = \\? AND language IN \\(\\?\\)").
+		WithArgs(9, 5, "fr").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, id := range []int{5, 9} {
+		mock.ExpectExec("DELETE FROM book_counters WHERE book_id = \\?").WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO book_counters").WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec("UPDATE books SET merged_into = \\?, merged_at = CURRENT_TIMESTAMP, version = version \\+ 1\\s+WHERE id = \\?").
+		WithArgs(9, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO redirects \\(organization_id, resource, old_ref, target_id\\)").
+		WithArgs("book", 9, 5, "book", 9, 5, "book", 9, 5).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("UPDATE book_reports\\s+SET status = 'resolved', resolution = 'merged'").
+		WithArgs(1, 5, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_merges \\(uuid, organization_id, source_id, target_id, merged_by, moved\\)").
+		WithArgs(sqlmock.AnyArg(), 1, 5, 9, 1,
+			`{"interactions":[11,12],"reviews":[21],"lists":[3],"dropped_list_items":[{"list_id":4,"position":1,"added_at":"2026-03-01T12:00:00Z"}],"isbns":[],"translations":["fr"],"shelves":[6],"dropped_shelves":[{"user_id":7,"shelf":"read","created_at":"2026-03-01T12:00:00Z","updated_at":"2026-03-01T12:00:00Z"}]}`).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	expectAudit(mock, AuditBookMerge, "book", 5)
+	mock.ExpectCommit()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin/books/:id/merge", asUser(1), MergeBookHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/books/5/merge?into=9", nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Moved         map[string]int `json:"moved"`
+		ReportsClosed int            `json:"reports_closed"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if body.Moved["interactions"] != 2 || body.Moved["reviews"] != 1 || body.Moved["list_items"] != 1 || body.Moved["dropped_list_items"] != 1 ||
+		body.Moved["translations"] != 1 || body.Moved["shelves"] != 1 || body.Moved["dropped_shelves"] != 1 || body.ReportsClosed != 1 {
+		t.Fatalf("unexpected summary: %+v", body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestMergeBookHandler_AlreadyMerged(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	for _, id := range []int{5, 9} {
+		mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+			WithArgs(id, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT uuid, merged_into FROM books WHERE id = \\? AND").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "merged_into"}).AddRow("b-5", 7))
+	mock.ExpectQuery("SELECT uuid, merged_into FROM books WHERE id = \\? FOR UPDATE").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "merged_into"}).AddRow("b-9", nil))
+	mock.ExpectQuery("SELECT uuid FROM books WHERE id = \\?").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid"}).AddRow("b-7"))
+	mock.ExpectRollback()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin/books/:id/merge", asUser(1), MergeBookHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/books/5/merge?into=9", nil))
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"merged_into":"b-7"`) {
+		t.Fatalf("expected 409 naming the survivor, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestUndoBookMergeHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM book_merges WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT m.uuid, m.source_id, b.uuid, m.target_id, t.uuid, m.moved, m.undone_at, b.merged_into\\s+FROM book_merges m").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "source_id", "source_uuid", "target_id", "target_uuid", "moved", "undone_at", "merged_into"}).
+			AddRow("m-2", 5, "b-5", 9, "b-9", `{"interactions":[11,12],"reviews":[21],"lists":[],"dropped_list_items":[{"list_id":4,"position":1,"added_at":"2026-03-01T12:00:00Z"}],"isbns":[],"translations":[],"shelves":[6],"dropped_shelves":[{"user_id":7,"shelf":"read","created_at":"2026-03-01T12:00:00Z","updated_at":"2026-03-01T12:00:00Z"}]}`, nil, 9))
+	expectAuditSnapshot(mock, "book", `{"id": 5, "merged_into": 9}`)
+	mock.ExpectExec("UPDATE IGNORE interactions SET book_id = \\? WHERE book_id = \\? AND id IN \\(\\?, \\?\\)").
+		WithArgs(5, 9, int64(11), int64(12)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE IGNORE reviews SET book_id = \\? WHERE book_id = \\? AND id IN \\(\\?\\)").
+		WithArgs(5, 9, int64(21)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT IGNORE INTO list_items \\(list_id, book_id, position, added_at\\)\\s+SELECT id, \\?, \\?, \\? FROM lists WHERE id = \\?").
+		WithArgs(5, 1, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE list_items SET position = position \\+ 1 WHERE list_id = \\? AND position >= \\? AND book_id <> \\?").
+		WithArgs(int64(4), 1, 5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE IGNORE shelf_books SET book_id = \\?, updated_at = updated_at WHERE book_id = \\? AND user_id IN \\(\\?\\)").
+		WithArgs(5, 9, int64(6)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT IGNORE INTO shelf_books \\(user_id, book_id, shelf, created_at, updated_at\\)\\s+SELECT id, \\?, \\?, \\?, \\? FROM users WHERE id = \\?").
+		WithArgs(5, "read", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, id := range []int{5, 9} {
+		mock.ExpectExec("DELETE FROM book_counters WHERE book_id = \\?").WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO book_counters").WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec("UPDATE books SET merged_into = NULL, merged_at = NULL").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE r FROM redirects r").
+		WithArgs(5, "book", 9).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("UPDATE book_merges SET undone_by = \\?, undone_at = CURRENT_TIMESTAMP WHERE id = \\?").
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, AuditBookMergeUndo, "book", 5)
+	mock.ExpectCommit()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin/book-merges/:id/undo", asUser(1), UndoBookMergeHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/book-merges/2/undo", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,355 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/ids"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// reportDetailsMaxLen bounds the free-text part of a report
+const reportDetailsMaxLen = 2000
+
+// bookReportReasons are what readers can flag a book for
+var bookReportReasons = map[string]bool{
+	"wrong_author": true,
+	"wrong_cover":  true,
+	"duplicate":    true,
+	"spam":         true,
+}
+
+// reportResolutions maps how an admin closed a report to its status
+var reportResolutions = map[string]string{
+	"edited":    "resolved", // metadata fixed via the edit endpoints
+	"merged":    "resolved", // duplicate folded into another record
+	"removed":   "resolved", // spam taken out of the catalogue
+	"dismissed": "dismissed",
+}
+
+func resolveReportRef(ctx context.Context, raw string) (int, error) {
+	return resolveRef(ctx, "book_reports", "", orgScope, raw)
+}
+
+// reportLinks points admins at the endpoints that act on a report
+func reportLinks(reportUUID, bookUUID string, duplicateUUID sql.NullString) gin.H {
+	links := gin.H{
+		"book":    "/books/" + bookUUID,
+		"edit":    "/admin/books/batch",
+		"resolve": "/admin/reports/" + reportUUID + "/resolve",
+	}
+	if duplicateUUID.Valid {
+		links["duplicate_of"] = "/books/" + duplicateUUID.String
+		links["merge"] = "/admin/books/" + bookUUID + "/merge?into=" + duplicateUUID.String
+	}
+	return links
+}
+
+// ReportBookHandler godoc
+// @Summary Report incorrect book metadata
+// @Description Feeds the admin review queue (GET /admin/reports). One open report per reader, book and reason.
+// @Tags Books
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Book UUID or slug"
+// @Param reason formData string true "wrong_author, wrong_cover, duplicate or spam"
+// @Param details formData string false "What's wrong (max 2000 characters)"
+// @Param duplicate_of formData string false "For duplicates: the book this one copies (UUID or slug)"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /books/{id}/report [post]
+func ReportBookHandler(c *gin.Context) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	reason := strings.TrimSpace(c.PostForm("reason"))
+	if !bookReportReasons[reason] {
+		abortWithError(c, badRequest("reason must be wrong_author, wrong_cover, duplicate or spam"))
+		return
+	}
+	details := strings.TrimSpace(c.PostForm("details"))
+	if len([]rune(details)) > reportDetailsMaxLen {
+		abortWithError(c, badRequest(fmt.Sprintf("details must be at most %d characters", reportDetailsMaxLen)))
+		return
+	}
+	var duplicateOf interface{}
+	if ref := c.PostForm("duplicate_of"); ref != "" {
+		if reason != "duplicate" {
+			abortWithError(c, badRequest("duplicate_of only applies to duplicate reports"))
+			return
+		}
+		otherID, ok := resolveParam(c, resolveBookRef, ref, "duplicate_of book")
+		if !ok {
+			return
+		}
+		if otherID == bookID {
+			abortWithError(c, badRequest("a book can't duplicate itself"))
+			return
+		}
+		duplicateOf = otherID
+	}
+
+	ctx := c.Request.Context()
+	userID := c.GetInt("auth_user_id")
+	dup, err := rowExists(ctx,
+		"SELECT 1 FROM book_reports WHERE book_id = ? AND user_id = ? AND reason = ? AND status = 'open'",
+		bookID, userID, reason)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if dup {
+		abortWithError(c, conflict("you already reported this book for "+reason))
+		return
+	}
+
+	// the response names both books by uuid, whatever the client sent
+	var bookUUID string
+	var duplicateUUID sql.NullString
+	if err := db.QueryRowContext(ctx,
+		"SELECT b.uuid, d.uuid FROM books b LEFT JOIN books d ON d.id = ? WHERE b.id = ?", duplicateOf, bookID).
+		Scan(&bookUUID, &duplicateUUID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	var detailsValue interface{}
+	if details != "" {
+		detailsValue = details
+	}
+	publicID := ids.New()
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO book_reports (uuid, organization_id, book_id, user_id, reason, details, duplicate_of)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		publicID, tenant.ID(ctx), bookID, userID, reason, detailsValue, duplicateOf); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(201, gin.H{
+		"uuid":         publicID,
+		"book_uuid":    bookUUID,
+		"reason":       reason,
+		"details":      detailsValue,
+		"duplicate_of": nullableString(duplicateUUID),
+		"status":       "open",
+	})
+}
+
+// ListBookReportsHandler godoc
+// @Summary Review queue of book metadata reports (oldest first)
+// @Description Each report links to the book, the edit endpoint and its resolve action; open_for_book counts open reports on the same book.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param status query string false "open (default), resolved, dismissed or all"
+// @Param reason query string false "Only this reason"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/reports [get]
+func ListBookReportsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	where := " WHERE r.organization_id = ?"
+	args := []interface{}{tenant.ID(ctx)}
+	switch status := c.DefaultQuery("status", "open"); status {
+	case "all":
+	case "open", "resolved", "dismissed":
+		where += " AND r.status = ?"
+		args = append(args, status)
+	default:
+		abortWithError(c, badRequest("status must be open, resolved, dismissed or all"))
+		return
+	}
+	if reason := c.Query("reason"); reason != "" {
+		if !bookReportReasons[reason] {
+			abortWithError(c, badRequest("reason must be wrong_author, wrong_cover, duplicate or spam"))
+			return
+		}
+		where += " AND r.reason = ?"
+		args = append(args, reason)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM book_reports r"+where, args...).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT r.uuid, r.reason, r.details, r.status, r.resolution, r.resolution_note, r.created_at, r.resolved_at,
+		       b.uuid, b.slug, b.title, b.author,
+		       d.uuid,
+		       u.id, u.uuid, u.handle,
+		       (SELECT COUNT(*) FROM book_reports o
+		        WHERE o.book_id = r.book_id AND o.organization_id = r.organization_id AND o.status = 'open')
+		FROM book_reports r
+		JOIN books b ON b.id = r.book_id
+		LEFT JOIN books d ON d.id = r.duplicate_of
+		LEFT JOIN users u ON u.id = r.user_id`+where+`
+		ORDER BY r.created_at, r.id
+		LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	reports := []gin.H{}
+	for rows.Next() {
+		var openForBook int
+		var publicID, reason, status, createdAt, bookUUID, slug, title string
+		var details, resolution, note, resolvedAt, author, duplicateUUID sql.NullString
+		var reporterID sql.NullInt64
+		var reporterUUID, handle sql.NullString
+		if err := rows.Scan(&publicID, &reason, &details, &status, &resolution, &note, &createdAt, &resolvedAt,
+			&bookUUID, &slug, &title, &author,
+			&duplicateUUID,
+			&reporterID, &reporterUUID, &handle,
+			&openForBook); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		reports = append(reports, gin.H{
+			"uuid":            publicID,
+			"reason":          reason,
+			"details":         nullableString(details),
+			"status":          status,
+			"resolution":      nullableString(resolution),
+			"resolution_note": nullableString(note),
+			"created_at":      createdAt,
+			"resolved_at":     nullableString(resolvedAt),
+			"book":            gin.H{"uuid": bookUUID, "slug": slug, "title": title, "author": author.String},
+			"reporter":        userRef(reporterID, reporterUUID, handle),
+			"open_for_book":   openForBook,
+			"links":           reportLinks(publicID, bookUUID, duplicateUUID),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  reports,
+	})
+}
+
+// ResolveBookReportHandler godoc
+// @Summary Close a book metadata report
+// @Description Record what was done after fixing the book through the edit endpoint (edited), folding it into another record (merged), or taking it down (removed); or dismiss the report. With all_open=true, every open report on the same book for the same reason is closed too.
+// @Tags Admin
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Report UUID"
+// @Param resolution formData string true "edited, merged, removed or dismissed"
+// @Param note formData string false "Note for other admins"
+// @Param all_open formData bool false "Also close the other open reports like this one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/reports/{id}/resolve [post]
+func ResolveBookReportHandler(c *gin.Context) {
+	reportID, ok := resolveParam(c, resolveReportRef, c.Param("id"), "report")
+	if !ok {
+		return
+	}
+	resolution := strings.TrimSpace(c.PostForm("resolution"))
+	status, ok := reportResolutions[resolution]
+	if !ok {
+		abortWithError(c, badRequest("resolution must be edited, merged, removed or dismissed"))
+		return
+	}
+	var note interface{}
+	if n := strings.TrimSpace(c.PostForm("note")); n != "" {
+		note = n
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var bookID int
+	var publicID, reason, current string
+	if err := tx.QueryRowContext(ctx,
+		"SELECT uuid, book_id, reason, status FROM book_reports WHERE id = ? FOR UPDATE", reportID).
+		Scan(&publicID, &bookID, &reason, &current); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if current != "open" {
+		abortWithError(c, conflict("report is already "+current))
+		return
+	}
+	before, err := auditSnapshot(ctx, tx, "book_report", reportID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	query := `
+		UPDATE book_reports
+		SET status = ?, resolution = ?, resolution_note = ?, resolved_by = ?, resolved_at = CURRENT_TIMESTAMP
+		WHERE `
+	args := []interface{}{status, resolution, note, c.GetInt("auth_user_id")}
+	if c.PostForm("all_open") == "true" {
+		query += "book_id = ? AND reason = ? AND organization_id = ? AND status = 'open'"
+		args = append(args, bookID, reason, tenant.ID(ctx))
+	} else {
+		query += "id = ?"
+		args = append(args, reportID)
+	}
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err == nil {
+		err = recordAudit(c, tx, AuditReportResolve, "book_report", reportID, before)
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	closed, _ := res.RowsAffected()
+	c.JSON(200, gin.H{
+		"uuid":       publicID,
+		"status":     status,
+		"resolution": resolution,
+		"closed":     closed,
+	})
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestReportBookHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectBook := func(id int) {
+		mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+			WithArgs(id, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}
+
+	// a duplicate of book 4
+	expectBook(9)
+	expectBook(4)
+	mock.ExpectQuery("SELECT 1 FROM book_reports WHERE book_id = \\? AND user_id = \\? AND reason = \\? AND status = 'open'").
+		WithArgs(9, 2, "duplicate").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}))
+	mock.ExpectQuery("SELECT b.uuid, d.uuid FROM books b LEFT JOIN books d ON d.id = \\? WHERE b.id = \\?").
+		WithArgs(4, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "uuid"}).AddRow("b-9", "b-4"))
+	mock.ExpectExec("INSERT INTO book_reports \\(uuid, organization_id, book_id, user_id, reason, details, duplicate_of\\)").
+		WithArgs(sqlmock.AnyArg(), 1, 9, 2, "duplicate", "Same edition", 4).
+		WillReturnResult(sqlmock.NewResult(11, 1))
+	// reported twice
+	expectBook(9)
+	mock.ExpectQuery("SELECT 1 FROM book_reports WHERE book_id = \\? AND user_id = \\? AND reason = \\? AND status = 'open'").
+		WithArgs(9, 2, "spam").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	// unknown reason
+	expectBook(9)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/books/:id/report", asUser(2), ReportBookHandler)
+
+	for _, tc := range []struct {
+		form url.Values
+		want int
+	}{
+		{url.Values{"reason": {"duplicate"}, "details": {" Same edition "}, "duplicate_of": {"4"}}, http.StatusCreated},
+		{url.Values{"reason": {"spam"}}, http.StatusConflict},
+		{url.Values{"reason": {"boring"}}, http.StatusBadRequest},
+	} {
+		w := postForm(r, "/books/9/report", tc.form)
+		if w.Code != tc.want {
+			t.Fatalf("%v: expected %d, got %d: %s", tc.form, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestResolveBookReportHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectReport := func(id int, status string) {
+		mock.ExpectQuery("SELECT 1 FROM book_reports WHERE id = \\?").
+			WithArgs(id, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT uuid, book_id, reason, status FROM book_reports WHERE id = \\? FOR UPDATE").
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"uuid", "book_id", "reason", "status"}).AddRow("r-1", 9, "spam", status))
+	}
+
+	// closes every open spam report on the book
+	expectReport(11, "open")
+	expectAuditSnapshot(mock, "book_report", `{"id": 11, "status": "open"}`)
+	mock.ExpectExec("UPDATE book_reports\\s+SET status = \\?, resolution = \\?, resolution_note = \\?, resolved_by = \\?, resolved_at = CURRENT_TIMESTAMP\\s+WHERE book_id = \\? AND reason = \\? AND organization_id = \\? AND status = 'open'").
+		WithArgs("resolved", "removed", nil, 1, 9, "spam", 1).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	expectAudit(mock, AuditReportResolve, "book_report", 11)
+	mock.ExpectCommit()
+	// already handled
+	expectReport(12, "dismissed")
+	mock.ExpectRollback()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin/reports/:id/resolve", asUser(1), ResolveBookReportHandler)
+
+	w := postForm(r, "/admin/reports/11/resolve", url.Values{"resolution": {"removed"}, "all_open": {"true"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w = postForm(r, "/admin/reports/12/resolve", url.Values{"resolution": {"edited"}})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a closed report, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
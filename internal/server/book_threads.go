@@ -0,0 +1,513 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// parseSpoiler reads an optional boolean spoiler flag
+func parseSpoiler(raw string) (bool, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return false, nil
+	}
+	spoiler, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("spoiler must be true or false")
+	}
+	return spoiler, nil
+}
+
+// hideSpoilers reports whether the client asked for spoilers=hide
+func hideSpoilers(c *gin.Context) bool {
+	return c.Query("spoilers") == "hide"
+}
+
+// bookThread resolves :thread_id and checks it's a live thread about bookID
+func bookThread(c *gin.Context, bookID int) (int, bool) {
+	threadID, ok := resolveParam(c, resolveThreadRef, c.Param("thread_id"), "thread")
+	if !ok {
+		return 0, false
+	}
+	found, err := rowExists(c.Request.Context(),
+		"SELECT 1 FROM discussion_threads WHERE id = ? AND book_id = ? AND "+liveThreadSQL, threadID, bookID)
+	if err != nil {
+		abortWithError(c, err)
+		return 0, false
+	}
+	if !found {
+		abortWithError(c, notFound("thread not found"))
+		return 0, false
+	}
+	return threadID, true
+}
+
+// ListBookThreadsHandler godoc
+// @Summary Discussion threads about a book, most recently active first
+// @Description Separate from reviews. Deleted threads are left out, as are threads by users the caller blocked; spoilers=hide also leaves out threads flagged as spoilers.
+// @Tags Discussions
+// @Produce json
+// @Param Authorization header string false "Bearer token (to leave out blocked users)"
+// @Param id path string true "Book UUID or slug"
+// @Param spoilers query string false "hide to skip spoiler threads"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /books/{id}/threads [get]
+func ListBookThreadsHandler(c *gin.Context) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	where := " WHERE t.book_id = ? AND t.organization_id = ? AND t.deleted_at IS NULL AND t.hidden_at IS NULL"
+	args := []interface{}{bookID, tenant.ID(ctx)}
+	if hideSpoilers(c) {
+		where += " AND t.spoiler = FALSE"
+	}
+	if viewerID := c.GetInt("auth_user_id"); viewerID > 0 {
+		where += " AND " + notBlockedSQL("t.user_id")
+		args = append(args, viewerID)
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM discussion_threads t"+where, args...).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT t.uuid, t.title, t.spoiler, t.created_at, t.last_post_at,
+		       (SELECT COUNT(*) FROM discussion_posts p WHERE p.thread_id = t.id AND p.deleted_at IS NULL AND p.hidden_at IS NULL),
+		       u.id, u.uuid, u.handle
+		FROM discussion_threads t
+		LEFT JOIN users u ON u.id = t.user_id`+where+`
+		ORDER BY t.last_post_at DESC, t.id DESC
+		LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	threads := []gin.H{}
+	for rows.Next() {
+		var postCount int
+		var spoiler bool
+		var publicID, title, createdAt, lastPostAt string
+		var authorID sql.NullInt64
+		var authorUUID, handle sql.NullString
+		if err := rows.Scan(&publicID, &title, &spoiler, &createdAt, &lastPostAt, &postCount,
+			&authorID, &authorUUID, &handle); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		threads = append(threads, gin.H{
+			"uuid":         publicID,
+			"title":        title,
+			"spoiler":      spoiler,
+			"author":       userRef(authorID, authorUUID, handle),
+			"post_count":   postCount,
+			"created_at":   createdAt,
+			"last_post_at": lastPostAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  threads,
+	})
+}
+
+// CreateBookThreadHandler godoc
+// @Summary Start a discussion thread about a book
+// @Tags Discussions
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Book UUID or slug"
+// @Param title formData string true "Title (max 200 characters)"
+// @Param body formData string true "Opening post (max 10000 characters)"
+// @Param spoiler formData bool false "Discusses plot points"
+// @Success 201 {object} map[string]interface{}
+// @Header 201 {string} Location "/books/{id}/threads/{uuid}"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /books/{id}/threads [post]
+func CreateBookThreadHandler(c *gin.Context) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	title, err := parseThreadTitle(c.PostForm("title"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	body, err := parsePostBody(c.PostForm("body"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	if !screenText(c, "title", title) || !screenText(c, "body", body) {
+		return
+	}
+	spoiler, err := parseSpoiler(c.PostForm("spoiler"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := c.GetInt("auth_user_id")
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO discussion_threads (organization_id, book_id, user_id, title, spoiler) VALUES (?, ?, ?, ?, ?)",
+		tenant.ID(ctx), bookID, userID, title, spoiler)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	id, _ := res.LastInsertId()
+	threadID := int(id)
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO discussion_posts (thread_id, user_id, body, spoiler) VALUES (?, ?, ?, ?)",
+		threadID, userID, body, spoiler); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	var publicID, bookUUID string
+	if err := tx.QueryRowContext(ctx,
+		"SELECT t.uuid, b.uuid FROM discussion_threads t JOIN books b ON b.id = t.book_id WHERE t.id = ?", threadID).
+		Scan(&publicID, &bookUUID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/books/%s/threads/%s", c.Param("id"), publicID))
+	c.JSON(201, gin.H{
+		"uuid":       publicID,
+		"book_uuid":  bookUUID,
+		"title":      title,
+		"spoiler":    spoiler,
+		"post_count": 1,
+	})
+}
+
+// GetBookThreadHandler godoc
+// @Summary A book discussion thread with its replies, oldest first
+// @Description Deleted replies keep their place with deleted=true and no body; replies hidden after reports (hidden=true) lose their body until a moderator decides, and replies by users the caller blocked (blocked=true) lose theirs. With spoilers=hide, bodies of spoiler replies are withheld too.
+// @Tags Discussions
+// @Produce json
+// @Param Authorization header string false "Bearer token (to withhold posts by blocked users)"
+// @Param id path string true "Book UUID or slug"
+// @Param thread_id path string true "Thread UUID"
+// @Param spoilers query string false "hide to withhold spoiler bodies"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /books/{id}/threads/{thread_id} [get]
+func GetBookThreadHandler(c *gin.Context) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	threadID, ok := bookThread(c, bookID)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	var publicID, bookUUID, title, createdAt string
+	var spoiler bool
+	var total int
+	if err := db.QueryRowContext(ctx, `
+		SELECT t.uuid, b.uuid, t.title, t.spoiler, t.created_at, (SELECT COUNT(*) FROM discussion_posts p WHERE p.thread_id = t.id)
+		FROM discussion_threads t
+		JOIN books b ON b.id = t.book_id
+		WHERE t.id = ?`, threadID).Scan(&publicID, &bookUUID, &title, &spoiler, &createdAt, &total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	blocked, err := blockedUsers(ctx, c.GetInt("auth_user_id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT p.id, p.body, p.spoiler, p.deleted_at IS NOT NULL, p.hidden_at IS NOT NULL, p.created_at, u.id, u.uuid, u.handle
+		FROM discussion_posts p
+		LEFT JOIN users u ON u.id = p.user_id
+		WHERE p.thread_id = ?
+		ORDER BY p.id
+		LIMIT ? OFFSET ?`, threadID, limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	hide := hideSpoilers(c)
+	posts := []gin.H{}
+	for rows.Next() {
+		var id int
+		var body, postedAt string
+		var postSpoiler, deleted, hidden bool
+		var authorID sql.NullInt64
+		var authorUUID, handle sql.NullString
+		if err := rows.Scan(&id, &body, &postSpoiler, &deleted, &hidden, &postedAt, &authorID, &authorUUID, &handle); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		post := gin.H{
+			"id":         id,
+			"body":       body,
+			"spoiler":    postSpoiler,
+			"author":     userRef(authorID, authorUUID, handle),
+			"created_at": postedAt,
+		}
+		withholdPost(post, deleted, hidden, authorID.Valid && blocked[int(authorID.Int64)])
+		if postSpoiler && hide {
+			post["body"] = nil
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"uuid":       publicID,
+		"book_uuid":  bookUUID,
+		"title":      title,
+		"spoiler":    spoiler,
+		"created_at": createdAt,
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+		"data":       posts,
+	})
+}
+
+// CreateBookPostHandler godoc
+// @Summary Reply in a book discussion thread
+// @Tags Discussions
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Book UUID or slug"
+// @Param thread_id path string true "Thread UUID"
+// @Param body formData string true "Reply (max 10000 characters)"
+// @Param spoiler formData bool false "Discusses plot points"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /books/{id}/threads/{thread_id}/posts [post]
+func CreateBookPostHandler(c *gin.Context) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	threadID, ok := bookThread(c, bookID)
+	if !ok {
+		return
+	}
+	body, err := parsePostBody(c.PostForm("body"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	if !screenText(c, "body", body) {
+		return
+	}
+	spoiler, err := parseSpoiler(c.PostForm("spoiler"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := c.GetInt("auth_user_id")
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO discussion_posts (thread_id, user_id, body, spoiler) VALUES (?, ?, ?, ?)",
+		threadID, userID, body, spoiler)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE discussion_threads SET last_post_at = CURRENT_TIMESTAMP WHERE id = ?", threadID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	threadUUID, err := publicRef(ctx, db, "discussion_threads", threadID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	id, _ := res.LastInsertId()
+	c.JSON(201, gin.H{"id": id, "thread_uuid": threadUUID, "body": body, "spoiler": spoiler})
+}
+
+// softDeleteAudited marks a thread or post deleted by the caller and
+// records it in the audit log. Deleting it again changes nothing.
+func softDeleteAudited(c *gin.Context, targetType, table, action string, id int) error {
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	before, err := auditSnapshot(ctx, tx, targetType, id)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx,
+		"UPDATE "+table+" SET deleted_at = CURRENT_TIMESTAMP, deleted_by = ? WHERE id = ? AND deleted_at IS NULL",
+		c.GetInt("auth_user_id"), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	if err := recordAudit(c, tx, action, targetType, id, before); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteBookThreadHandler godoc
+// @Summary Soft-delete a book discussion thread (moderators)
+// @Description Moderators are organization admins. The thread disappears from listings; its rows are kept.
+// @Tags Discussions
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Book UUID or slug"
+// @Param thread_id path string true "Thread UUID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /books/{id}/threads/{thread_id} [delete]
+func DeleteBookThreadHandler(c *gin.Context) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	threadID, ok := bookThread(c, bookID)
+	if !ok {
+		return
+	}
+	if err := softDeleteAudited(c, "thread", "discussion_threads", AuditThreadDelete, threadID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// DeleteBookPostHandler godoc
+// @Summary Soft-delete a reply in a book discussion thread (moderators)
+// @Description The reply keeps its place in the thread with deleted=true and no body.
+// @Tags Discussions
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Book UUID or slug"
+// @Param thread_id path string true "Thread UUID"
+// @Param post_id path int true "Post ID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /books/{id}/threads/{thread_id}/posts/{post_id} [delete]
+func DeleteBookPostHandler(c *gin.Context) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	threadID, ok := bookThread(c, bookID)
+	if !ok {
+		return
+	}
+	postID, err := strconv.Atoi(c.Param("post_id"))
+	if err != nil {
+		abortWithError(c, notFound("post not found"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	found, err := rowExists(ctx, "SELECT 1 FROM discussion_posts WHERE id = ? AND thread_id = ?", postID, threadID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if !found {
+		abortWithError(c, notFound("post not found"))
+		return
+	}
+	if err := softDeleteAudited(c, "post", "discussion_posts", AuditPostDelete, postID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(204)
+}
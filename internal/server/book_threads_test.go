@@ -0,0 +1,165 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func expectBookThread(mock sqlmock.Sqlmock, bookID, threadID int) {
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(bookID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT 1 FROM discussion_threads WHERE id = \\? AND organization_id = \\?").
+		WithArgs(threadID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT 1 FROM discussion_threads WHERE id = \\? AND book_id = \\? AND deleted_at IS NULL AND hidden_at IS NULL").
+		WithArgs(threadID, bookID).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+}
+
+func TestListBookThreadsHandler_HidesSpoilers(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM discussion_threads t WHERE t.book_id = \\? AND t.organization_id = \\? AND t.deleted_at IS NULL AND t.hidden_at IS NULL AND t.spoiler = FALSE").
+		WithArgs(9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("FROM discussion_threads t\\s+LEFT JOIN users u ON u.id = t.user_id WHERE .* AND t.spoiler = FALSE\\s+ORDER BY t.last_post_at DESC").
+		WithArgs(9, 1, 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "title", "spoiler", "created_at", "last_post_at", "post_count", "user_id", "user_uuid", "handle"}).
+			AddRow("t-8", "Favourite characters?", false, "2026-10-01 12:00:00", "2026-10-02 12:00:00", 4, 2, "u-2", "bob"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/:id/threads", ListBookThreadsHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/9/threads?spoilers=hide", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestGetBookThreadHandler_WithholdsDeletedHiddenAndSpoilers(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectBookThread(mock, 9, 8)
+	mock.ExpectQuery("FROM discussion_threads t\\s+JOIN books b ON b.id = t.book_id\\s+WHERE t.id = \\?").
+		WithArgs(8).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "book_uuid", "title", "spoiler", "created_at", "count"}).
+			AddRow("t-8", "b-9", "The ending", false, "2026-10-01 12:00:00", 4))
+	mock.ExpectQuery("FROM discussion_posts p\\s+LEFT JOIN users u ON u.id = p.user_id\\s+WHERE p.thread_id = \\?").
+		WithArgs(8, 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "body", "spoiler", "deleted", "hidden", "created_at", "user_id", "user_uuid", "handle"}).
+			AddRow(1, "What did everyone think?", false, false, false, "2026-10-01 12:00:00", 2, "u-2", "bob").
+			AddRow(2, "rude remark", false, true, false, "2026-10-01 13:00:00", 3, "u-3", "troll").
+			AddRow(3, "Paul dies", true, false, false, "2026-10-01 14:00:00", 4, "u-4", "amy").
+			AddRow(4, "buy cheap pills", false, false, true, "2026-10-01 15:00:00", 5, "u-5", "spammer"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/:id/threads/:thread_id", GetBookThreadHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/9/threads/8?spoilers=hide", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if len(body.Data) != 4 {
+		t.Fatalf("expected 4 posts, got %d", len(body.Data))
+	}
+	if body.Data[0]["body"] != "What did everyone think?" {
+		t.Fatalf("visible post lost its body: %v", body.Data[0])
+	}
+	if body.Data[1]["body"] != nil || body.Data[1]["author"] != nil || body.Data[1]["deleted"] != true {
+		t.Fatalf("deleted post leaked: %v", body.Data[1])
+	}
+	if body.Data[2]["body"] != nil || body.Data[2]["spoiler"] != true {
+		t.Fatalf("spoiler body not withheld: %v", body.Data[2])
+	}
+	if body.Data[3]["body"] != nil || body.Data[3]["hidden"] != true || body.Data[3]["author"] == nil {
+		t.Fatalf("hidden post not withheld: %v", body.Data[3])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestDeleteBookPostHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectBookThread(mock, 9, 8)
+	mock.ExpectQuery("SELECT 1 FROM discussion_posts WHERE id = \\? AND thread_id = \\?").
+		WithArgs(2, 8).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	expectAuditSnapshot(mock, "post", `{"id": 2, "deleted_at": null}`)
+	mock.ExpectExec("UPDATE discussion_posts SET deleted_at = CURRENT_TIMESTAMP, deleted_by = \\? WHERE id = \\?").
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, AuditPostDelete, "post", 2)
+	mock.ExpectCommit()
+	// a post from another thread
+	expectBookThread(mock, 9, 8)
+	mock.ExpectQuery("SELECT 1 FROM discussion_posts WHERE id = \\? AND thread_id = \\?").
+		WithArgs(50, 8).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.DELETE("/books/:id/threads/:thread_id/posts/:post_id", asUser(1), DeleteBookPostHandler)
+
+	for _, tc := range []struct {
+		path string
+		want int
+	}{
+		{"/books/9/threads/8/posts/2", http.StatusNoContent},
+		{"/books/9/threads/8/posts/50", http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, tc.path, nil))
+		if w.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d: %s", tc.path, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
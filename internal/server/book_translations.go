@@ -0,0 +1,313 @@
+package server
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// defaultLanguage is tried after the caller's Accept-Language, so a
+// deployment can serve its own language to clients that don't send one
+// (DEFAULT_LANGUAGE, e.g. "de", read in Run; empty keeps the catalogue's
+// metadata)
+var defaultLanguage string
+
+// canonicalLanguage is raw as a canonical BCP 47 tag, or "" when invalid
+func canonicalLanguage(raw string) string {
+	tag, err := language.Parse(strings.TrimSpace(raw))
+	if err != nil || tag == language.Und {
+		return ""
+	}
+	return tag.String()
+}
+
+// requestLanguages lists the languages to look for translations in, best
+// first: Accept-Language by q-value, each followed by its base language
+// ("pt-BR" then "pt"), then defaultLanguage
+func requestLanguages(c *gin.Context) []string {
+	tags, _, _ := language.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	langs := []string{}
+	seen := map[string]bool{}
+	add := func(lang string) {
+		if lang != "" && lang != "und" && !seen[lang] {
+			seen[lang] = true
+			langs = append(langs, lang)
+		}
+	}
+	for _, tag := range tags {
+		base, _ := tag.Base()
+		if base.String() == "mul" { // "*"
+			continue
+		}
+		add(tag.String())
+		add(base.String())
+	}
+	add(defaultLanguage)
+	return langs
+}
+
+// localizeBooks swaps in each book's best translation for the request:
+// title becomes the translated one (the catalogue's is kept in
+// original_title), and description and language are set (a catalogue
+// language is kept in original_language). Books without a
+// matching translation are left as they are. Runs no query when the
+// request has no language preference.
+func localizeBooks(c *gin.Context, books []map[string]interface{}) error {
+	c.Header("Vary", "Accept-Language")
+	langs := requestLanguages(c)
+	if len(books) == 0 || len(langs) == 0 {
+		return nil
+	}
+
+	ids := make([]interface{}, 0, len(books))
+	for _, b := range books {
+		ids = append(ids, b["id"])
+	}
+	args := append([]interface{}{}, ids...)
+	for _, l := range langs {
+		args = append(args, l)
+	}
+	rows, err := db.QueryContext(c.Request.Context(), `
+		SELECT book_id, language, title, description
+		FROM book_translations
+		WHERE book_id IN (`+placeholders(len(ids))+`) AND language IN (`+placeholders(len(langs))+`)`, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	rank := map[string]int{}
+	for i, l := range langs {
+		rank[l] = i
+	}
+	type translation struct {
+		language    string
+		title       string
+		description sql.NullString
+	}
+	best := map[int]translation{}
+	for rows.Next() {
+		var bookID int
+		var t translation
+		if err := rows.Scan(&bookID, &t.language, &t.title, &t.description); err != nil {
+			return err
+		}
+		if cur, ok := best[bookID]; !ok || rank[t.language] < rank[cur.language] {
+			best[bookID] = t
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, b := range books {
+		id, _ := b["id"].(int)
+		t, ok := best[id]
+		if !ok {
+			continue
+		}
+		b["original_title"] = b["title"]
+		if lang, ok := b["language"]; ok {
+			b["original_language"] = lang
+		}
+		b["title"] = t.title
+		b["description"] = nullableString(t.description)
+		b["language"] = t.language
+	}
+	// a single translated book tells the client which language it got
+	if len(books) == 1 {
+		id, _ := books[0]["id"].(int)
+		if t, ok := best[id]; ok {
+			c.Header("Content-Language", t.language)
+		}
+	}
+	return nil
+}
+
+// BookTranslation is the body of PUT /admin/books/{id}/translations/{language}
+type BookTranslation struct {
+	Title       string `json:"title" example:"Der Hobbit"`
+	Description string `json:"description" example:"Bilbo Beutlin lebt ein ruhiges Leben ..."`
+}
+
+// ListBookTranslationsHandler godoc
+// @Summary List a book's translations
+// @Tags Books
+// @Produce json
+// @Param id path string true "Book slug or UUID"
+// @Success 200 {array} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /books/{id}/translations [get]
+func ListBookTranslationsHandler(c *gin.Context) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	rows, err := db.QueryContext(c.Request.Context(), `
+		SELECT language, title, description, updated_at
+		FROM book_translations
+		WHERE book_id = ?
+		ORDER BY language`, bookID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	translations := []gin.H{}
+	for rows.Next() {
+		var lang, title string
+		var description sql.NullString
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&lang, &title, &description, &updatedAt); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		translations = append(translations, gin.H{
+			"language":    lang,
+			"title":       title,
+			"description": nullableString(description),
+			"updated_at":  updatedAt.Time,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, translations)
+}
+
+// PutBookTranslationHandler godoc
+// @Summary Add or replace a book translation
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Book slug or UUID"
+// @Param language path string true "BCP 47 language tag, e.g. de or pt-BR"
+// @Param body body BookTranslation true "Translated metadata"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/books/{id}/translations/{language} [put]
+func PutBookTranslationHandler(c *gin.Context) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	lang := canonicalLanguage(c.Param("language"))
+	if lang == "" {
+		abortWithError(c, badRequest("language must be a BCP 47 tag, e.g. de or pt-BR"))
+		return
+	}
+	var body BookTranslation
+	if err := c.ShouldBindJSON(&body); err != nil {
+		abortWithError(c, badRequest("invalid JSON body"))
+		return
+	}
+	title := strings.TrimSpace(body.Title)
+	if title == "" {
+		abortWithError(c, badRequest("title is required"))
+		return
+	}
+	if len(title) > 512 {
+		abortWithError(c, badRequest("title is too long (max 512)"))
+		return
+	}
+	var description interface{}
+	if d := strings.TrimSpace(body.Description); d != "" {
+		description = d
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	before, err := auditSnapshot(ctx, tx, "book_translation", bookID, lang)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO book_translations (book_id, language, title, description)
+			VALUES (?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE title = VALUES(title), description = VALUES(description)`,
+			bookID, lang, title, description)
+	}
+	if err == nil {
+		err = recordAudit(c, tx, AuditTranslationPut, "book_translation", bookID, before, bookID, lang)
+	}
+	var bookUUID string
+	if err == nil {
+		bookUUID, err = publicRef(ctx, tx, "books", bookID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"book_uuid":   bookUUID,
+		"language":    lang,
+		"title":       title,
+		"description": description,
+	})
+}
+
+// DeleteBookTranslationHandler godoc
+// @Summary Remove a book translation
+// @Tags Admin
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Book slug or UUID"
+// @Param language path string true "BCP 47 language tag"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/books/{id}/translations/{language} [delete]
+func DeleteBookTranslationHandler(c *gin.Context) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	lang := canonicalLanguage(c.Param("language"))
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	before, err := auditSnapshot(ctx, tx, "book_translation", bookID, lang)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM book_translations WHERE book_id = ? AND language = ?", bookID, lang)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		abortWithError(c, notFound("translation not found"))
+		return
+	}
+	err = recordAudit(c, tx, AuditTranslationDelete, "book_translation", bookID, before, bookID, lang)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(204)
+}
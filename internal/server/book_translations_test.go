@@ -0,0 +1,123 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestLanguages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("Accept-Language", "fr;q=0.5, pt-br, *;q=0.1")
+	if got := strings.Join(requestLanguages(c), ","); got != "pt-BR,pt,fr" {
+		t.Fatalf("unexpected languages: %s", got)
+	}
+}
+
+func TestListBooksHandler_Localized(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT id, uuid, slug, title, author, published_year, formats, page_count, content_warnings, audience_rating, cover_id, language, publisher\\s+FROM books").
+		WithArgs(1, 21, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "published_year", "formats", "page_count", "content_warnings", "audience_rating", "cover_id", "language", "publisher"}).
+			AddRow(1, "b-1", "the-hobbit-b1", "The Hobbit", "J.R.R. Tolkien", 1937, "print", 310, "", nil, nil, nil, nil).
+			AddRow(2, "b-2", "dune-b2", "Dune", "Frank Herbert", 1965, "print", 412, "", nil, nil, nil, nil))
+	mock.ExpectQuery("FROM book_translations\\s+WHERE book_id IN \\(\\?, \\?\\) AND language IN \\(\\?, \\?\\)").
+		WithArgs(1, 2, "de-AT", "de").
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "language", "title", "description"}).
+			AddRow(1, "de", "Der kleine Hobbit", nil).
+			AddRow(1, "de-AT", "Der Hobbit", "Bilbo Beutlin ..."))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books", ListBooksHandler)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/books", nil)
+	req.Header.Set("Accept-Language", "de-AT")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	hobbit, dune := body.Data[0], body.Data[1]
+	if hobbit["title"] != "Der Hobbit" || hobbit["original_title"] != "The Hobbit" || hobbit["language"] != "de-AT" {
+		t.Fatalf("expected the de-AT translation, got %v", hobbit)
+	}
+	if dune["title"] != "Dune" || dune["language"] != nil {
+		t.Fatalf("expected an untranslated book to be left alone, got %v", dune)
+	}
+	if w.Header().Get("Vary") != "Accept-Language" {
+		t.Fatalf("expected Vary: Accept-Language, got %q", w.Header().Get("Vary"))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestPutBookTranslationHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	expectAuditSnapshot(mock, "book_translation", "")
+	mock.ExpectExec("INSERT INTO book_translations \\(book_id, language, title, description\\)").
+		WithArgs(1, "pt-BR", "O Hobbit", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, AuditTranslationPut, "book_translation", 1)
+	expectPublicRef(mock, "books", 1, "b-1")
+	mock.ExpectCommit()
+	// bad tag
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PUT("/admin/books/:id/translations/:language", PutBookTranslationHandler)
+
+	put := func(target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := put("/admin/books/1/translations/pt-br", `{"title":" O Hobbit "}`); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"language":"pt-BR"`) {
+		t.Fatalf("expected 200 for pt-BR, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := put("/admin/books/1/translations/not_a_tag!", `{"title":"x"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad language tag, got %d", w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
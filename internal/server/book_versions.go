@@ -0,0 +1,156 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/authors"
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+	"github.com/YeswanthC7/bookrec/internal/genres"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// bookETag is the strong ETag for a book version, e.g. "3"
+func bookETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch reads the version from an If-Match header, accepting the
+// quoted ETag, a weak W/"3" or a bare 3. ok is false for anything else.
+func parseIfMatch(header string) (version int, ok bool) {
+	tag := strings.TrimPrefix(strings.TrimSpace(header), "W/")
+	tag = strings.TrimSuffix(strings.TrimPrefix(tag, `"`), `"`)
+	version, err := strconv.Atoi(tag)
+	return version, err == nil && version > 0
+}
+
+// PatchBookHandler godoc
+// @Summary Update a book
+// @Description Partial update with the same fields as a PATCH /admin/books/batch item (id is taken from the path). If-Match must carry the version from GET /books/{id} (its ETag); if someone else edited the book since, nothing is changed and the response is 409 with the current version. A new slug keeps the old one redirecting to the book; 409 if another book has it.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param If-Match header string false "Book version (ETag), e.g. \"3\"; required (428 without)"
+// @Param id path string true "Book slug or UUID"
+// @Param body body BookPatch true "Fields to change"
+// @Success 200 {object} map[string]interface{}
+// @Header 200 {string} ETag "The new version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Router /admin/books/{id} [patch]
+func PatchBookHandler(c *gin.Context) {
+	id, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		abortWithError(c, newAppError(428, "If-Match with the book's version is required"))
+		return
+	}
+	version, ok := parseIfMatch(ifMatch)
+	if !ok {
+		abortWithError(c, badRequest("If-Match must be a book version, e.g. \"3\""))
+		return
+	}
+
+	var patch BookPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		abortWithError(c, badRequest("invalid JSON body"))
+		return
+	}
+	patch.ID = c.Param("id")
+	set, args, err := patch.validate()
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// a rename redirects from the slug it replaces
+	var oldSlug string
+	if patch.Slug != nil {
+		err := tx.QueryRowContext(ctx, "SELECT slug FROM books WHERE id = ? FOR UPDATE", id).Scan(&oldSlug)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+
+	before, err := auditSnapshot(ctx, tx, "book", id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	args = append(args, id, version, tenant.ID(ctx))
+	res, err := tx.ExecContext(ctx,
+		"UPDATE books SET "+set+" WHERE id = ? AND version = ? AND "+editableBooksSQL(ctx), args...)
+	if dberr.Is(err, dberr.ErrDuplicate) {
+		abortWithError(c, conflict("slug is already taken"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	// every update bumps version, so no affected row means a stale version
+	// or a book this tenant can't edit
+	if n, _ := res.RowsAffected(); n == 0 {
+		var current int
+		err := tx.QueryRowContext(ctx,
+			"SELECT version FROM books WHERE id = ? AND "+editableBooksSQL(ctx), id, tenant.ID(ctx)).Scan(&current)
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithError(c, notFound("book not found"))
+			return
+		}
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.Header("ETag", bookETag(current))
+		abortWithError(c, conflict("book was modified by someone else; reload it and retry").WithDetails(gin.H{"version": current}))
+		return
+	}
+
+	if patch.Slug != nil {
+		err = recordSlugChange(ctx, tx, id, oldSlug, *patch.Slug)
+	}
+	if err == nil && patch.Subjects != nil {
+		err = genres.Assign(ctx, tx, int64(id), *patch.Subjects)
+	}
+	if err == nil && patch.Author != nil {
+		err = authors.Link(ctx, tx, int64(id), *patch.Author)
+	}
+	if err == nil {
+		err = recordAudit(c, tx, AuditBookUpdate, "book", id, before)
+	}
+	var publicID string
+	if err == nil {
+		publicID, err = publicRef(ctx, tx, "books", id)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Header("ETag", bookETag(version+1))
+	c.JSON(200, gin.H{"uuid": publicID, "version": version + 1})
+}
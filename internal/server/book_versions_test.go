@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func patchBook(t *testing.T, ifMatch, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PATCH("/admin/books/:id", PatchBookHandler)
+
+	req := httptest.NewRequest(http.MethodPatch, "/admin/books/5", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestPatchBookHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	expectAuditSnapshot(mock, "book", `{"id": 5, "title": "Dun"}`)
+	mock.ExpectExec("UPDATE books SET title = \\?, version = version \\+ 1 WHERE id = \\? AND version = \\? AND \\(\\(organization_id IS NULL OR organization_id = \\?\\) AND deleted_at IS NULL\\)").
+		WithArgs("Dune", 5, 3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, AuditBookUpdate, "book", 5)
+	expectPublicRef(mock, "books", 5, "b-5")
+	mock.ExpectCommit()
+
+	w := patchBook(t, `"3"`, `{"title":"Dune"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("ETag") != `"4"` {
+		t.Fatalf("expected the bumped version as ETag, got %q", w.Header().Get("ETag"))
+	}
+	if !strings.Contains(w.Body.String(), `"uuid":"b-5"`) || strings.Contains(w.Body.String(), `"id"`) {
+		t.Fatalf("expected the book's uuid and no integer id, got %s", w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestPatchBookHandler_StaleVersion(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	expectAuditSnapshot(mock, "book", `{"id": 5}`)
+	// another admin saved version 4 in the meantime
+	mock.ExpectExec("UPDATE books SET author = \\?, version = version \\+ 1 WHERE id = \\? AND version = \\?").
+		WithArgs("Frank Herbert", 5, 3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version FROM books WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+	mock.ExpectRollback()
+
+	w := patchBook(t, `W/"3"`, `{"author":"Frank Herbert"}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Code    string         `json:"code"`
+		Details map[string]any `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if body.Code != "conflict" || body.Details["version"] != float64(4) {
+		t.Fatalf("expected the current version, got %v", body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestPatchBookHandler_RequiresIfMatch(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	if w := patchBook(t, "", `{"title":"Dune"}`); w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("expected 428, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
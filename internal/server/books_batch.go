@@ -0,0 +1,351 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/authors"
+	"github.com/YeswanthC7/bookrec/internal/contentwarnings"
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+	"github.com/YeswanthC7/bookrec/internal/genres"
+	"github.com/YeswanthC7/bookrec/internal/ids"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// maxBatchUpdates caps the number of items in one PATCH /admin/books/batch request
+const maxBatchUpdates = 500
+
+// Per-item batch statuses
+const (
+	batchStatusUpdated  = "updated"
+	batchStatusNotFound = "not_found"
+	batchStatusInvalid  = "invalid"
+	batchStatusConflict = "conflict"
+)
+
+// BookPatch is a partial update; omitted fields are left unchanged
+type BookPatch struct {
+	// ID is the book's UUID or slug
+	ID            string    `json:"id" example:"the-hobbit-1b4e28ba"`
+	Title         *string   `json:"title,omitempty"`
+	Author        *string   `json:"author,omitempty"`
+	PublishedYear *int      `json:"published_year,omitempty" example:"1999"`
+	Subjects      *[]string `json:"subjects,omitempty"`
+	Formats       *[]string `json:"formats,omitempty" example:"print,ebook"`
+	PageCount     *int      `json:"page_count,omitempty" example:"412"`
+	// ContentWarnings and AudienceRating ("" clears it) mark the book's
+	// warnings as curated, so ingest stops deriving them from subjects
+	ContentWarnings *[]string `json:"content_warnings,omitempty" example:"violence,war"`
+	AudienceRating  *string   `json:"audience_rating,omitempty" example:"teen"`
+	// Slug renames the book's URL; the old slug redirects to the new one
+	Slug *string `json:"slug,omitempty" example:"the-hobbit"`
+	// Version, when set, must match the book's current version (see GET
+	// /books/{id}); a stale one reports the item as a conflict
+	Version *int `json:"version,omitempty" example:"3"`
+}
+
+// BookBatchRequest is the body of PATCH /admin/books/batch
+type BookBatchRequest struct {
+	Updates []BookPatch `json:"updates"`
+}
+
+// BookBatchResult reports what happened to one item, in request order
+type BookBatchResult struct {
+	Index  int    `json:"index"`
+	ID     string `json:"id"`
+	Status string `json:"status" example:"updated"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BookBatchResponse summarises a batch
+type BookBatchResponse struct {
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Results []BookBatchResult `json:"results"`
+}
+
+// editableBooksSQL limits batch edits to the tenant's own titles; the default
+// organization also curates the shared catalogue. Bind tenant.ID(ctx).
+func editableBooksSQL(ctx context.Context) string {
+	if tenant.ID(ctx) == tenant.DefaultID {
+		return tenant.BooksVisibleSQL("")
+	}
+	return "organization_id = ?"
+}
+
+// validate checks a patch in isolation and returns the SET clause and args
+func (p BookPatch) validate() (string, []interface{}, error) {
+	if strings.TrimSpace(p.ID) == "" {
+		return "", nil, fmt.Errorf("id is required")
+	}
+	sets, args, err := p.assignments()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(sets) == 0 {
+		return "", nil, fmt.Errorf("no fields to update")
+	}
+	sets = append(sets, "version = version + 1")
+	return strings.Join(sets, ", "), args, nil
+}
+
+// assignments checks the fields that are set and returns a "column = ?" for
+// each, with their args
+func (p BookPatch) assignments() ([]string, []interface{}, error) {
+	sets := []string{}
+	args := []interface{}{}
+	if p.Title != nil {
+		title := strings.TrimSpace(*p.Title)
+		if title == "" {
+			return nil, nil, fmt.Errorf("title cannot be empty")
+		}
+		if len(title) > 512 {
+			return nil, nil, fmt.Errorf("title is too long (max 512)")
+		}
+		sets = append(sets, "title = ?")
+		args = append(args, title)
+	}
+	if p.Author != nil {
+		author := strings.TrimSpace(*p.Author)
+		if len(author) > 512 {
+			return nil, nil, fmt.Errorf("author is too long (max 512)")
+		}
+		sets = append(sets, "author = ?")
+		args = append(args, author)
+	}
+	if p.PublishedYear != nil {
+		if *p.PublishedYear < 1 || *p.PublishedYear > time.Now().Year()+1 {
+			return nil, nil, fmt.Errorf("published_year out of range")
+		}
+		sets = append(sets, "published_year = ?")
+		args = append(args, *p.PublishedYear)
+	}
+	if p.Subjects != nil {
+		subjects := []string{}
+		for _, s := range *p.Subjects {
+			if s = strings.TrimSpace(s); s != "" {
+				subjects = append(subjects, s)
+			}
+		}
+		raw, err := json.Marshal(subjects)
+		if err != nil {
+			return nil, nil, err
+		}
+		sets = append(sets, "subjects = ?")
+		args = append(args, string(raw))
+	}
+	if p.Formats != nil {
+		formats, err := parseFormats(strings.Join(*p.Formats, ","))
+		if err != nil {
+			return nil, nil, err
+		}
+		sets = append(sets, "formats = ?")
+		args = append(args, strings.Join(formats, ","))
+	}
+	if p.PageCount != nil {
+		if *p.PageCount < 1 {
+			return nil, nil, fmt.Errorf("page_count must be positive")
+		}
+		sets = append(sets, "page_count = ?")
+		args = append(args, *p.PageCount)
+	}
+	if p.ContentWarnings != nil {
+		warnings, err := contentwarnings.Parse(*p.ContentWarnings)
+		if err != nil {
+			return nil, nil, err
+		}
+		sets = append(sets, "content_warnings = ?")
+		args = append(args, strings.Join(warnings, ","))
+	}
+	if p.AudienceRating != nil {
+		var audience interface{}
+		if a := strings.ToLower(strings.TrimSpace(*p.AudienceRating)); a != "" {
+			if !contentwarnings.ValidAudience(a) {
+				return nil, nil, fmt.Errorf("audience_rating must be one of %s", strings.Join(contentwarnings.Audiences, ", "))
+			}
+			audience = a
+		}
+		sets = append(sets, "audience_rating = ?")
+		args = append(args, audience)
+	}
+	if p.Slug != nil {
+		if !ids.ValidSlug(*p.Slug) {
+			return nil, nil, fmt.Errorf("slug must be lowercase letters, digits and single dashes, and not only digits")
+		}
+		sets = append(sets, "slug = ?")
+		args = append(args, *p.Slug)
+	}
+	if p.ContentWarnings != nil || p.AudienceRating != nil {
+		sets = append(sets, "content_warnings_curated = TRUE")
+	}
+	return sets, args, nil
+}
+
+// BatchUpdateBooksHandler godoc
+// @Summary Bulk-update books
+// @Description Applies up to 500 partial updates in one transaction. Invalid or unknown items, and items whose version is stale (status conflict), are reported per item and skipped; the rest are committed together.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param body body BookBatchRequest true "Partial updates"
+// @Success 200 {object} BookBatchResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/books/batch [patch]
+func BatchUpdateBooksHandler(c *gin.Context) {
+	var req BookBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, badRequest("invalid JSON body"))
+		return
+	}
+	if len(req.Updates) == 0 {
+		abortWithError(c, badRequest("updates cannot be empty"))
+		return
+	}
+	if len(req.Updates) > maxBatchUpdates {
+		abortWithError(c, badRequest(fmt.Sprintf("at most %d updates per batch", maxBatchUpdates)))
+		return
+	}
+
+	type pending struct {
+		index int
+		id    int
+		set   string
+		args  []interface{}
+	}
+
+	ctx := c.Request.Context()
+	results := make([]BookBatchResult, len(req.Updates))
+	queued := []pending{}
+	seen := map[int]bool{}
+	found := []interface{}{}
+	for i, p := range req.Updates {
+		results[i] = BookBatchResult{Index: i, ID: p.ID}
+		set, args, err := p.validate()
+		if err != nil {
+			results[i].Status = batchStatusInvalid
+			results[i].Error = err.Error()
+			continue
+		}
+		// books the tenant may not edit count as missing
+		id, err := resolveRef(ctx, "books", "slug", editableBooksSQL(ctx), strings.TrimSpace(p.ID))
+		if errors.Is(err, errRefNotFound) {
+			results[i].Status = batchStatusNotFound
+			results[i].Error = "book not found"
+			continue
+		}
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if seen[id] {
+			results[i].Status = batchStatusInvalid
+			results[i].Error = "duplicate book in batch"
+			continue
+		}
+		seen[id] = true
+		found = append(found, id)
+		queued = append(queued, pending{index: i, id: id, set: set, args: args})
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Lock the rows we're about to touch and learn which ids exist, their
+	// versions and slugs. Books the tenant may not edit count as missing.
+	versions := map[int]int{}
+	slugs := map[int]string{}
+	if len(found) > 0 {
+		rows, err := tx.QueryContext(ctx,
+			"SELECT id, version, slug FROM books WHERE id IN ("+placeholders(len(found))+") AND "+editableBooksSQL(ctx)+" FOR UPDATE",
+			append(found, tenant.ID(ctx))...)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		for rows.Next() {
+			var id, version int
+			var slug string
+			if err := rows.Scan(&id, &version, &slug); err != nil {
+				_ = rows.Close()
+				abortWithError(c, err)
+				return
+			}
+			versions[id] = version
+			slugs[id] = slug
+		}
+		_ = rows.Close()
+		if err := rows.Err(); err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+
+	updated := 0
+	for _, q := range queued {
+		id := q.id
+		current, ok := versions[id]
+		if !ok {
+			results[q.index].Status = batchStatusNotFound
+			results[q.index].Error = "book not found"
+			continue
+		}
+		if want := req.Updates[q.index].Version; want != nil && *want != current {
+			results[q.index].Status = batchStatusConflict
+			results[q.index].Error = fmt.Sprintf("book was modified (now version %d)", current)
+			continue
+		}
+		before, err := auditSnapshot(ctx, tx, "book", id)
+		if err != nil {
+			abortWithError(c, fmt.Errorf("update %d (id %d): %w", q.index, id, err))
+			return
+		}
+		args := append(q.args, id)
+		_, err = tx.ExecContext(ctx, "UPDATE books SET "+q.set+" WHERE id = ?", args...)
+		if dberr.Is(err, dberr.ErrDuplicate) {
+			// only the failed statement is rolled back; the batch goes on
+			results[q.index].Status = batchStatusInvalid
+			results[q.index].Error = "slug is already taken"
+			continue
+		}
+		if err == nil && req.Updates[q.index].Slug != nil {
+			err = recordSlugChange(ctx, tx, id, slugs[id], *req.Updates[q.index].Slug)
+		}
+		if err == nil && req.Updates[q.index].Subjects != nil {
+			err = genres.Assign(ctx, tx, int64(id), *req.Updates[q.index].Subjects)
+		}
+		if err == nil && req.Updates[q.index].Author != nil {
+			err = authors.Link(ctx, tx, int64(id), *req.Updates[q.index].Author)
+		}
+		if err == nil {
+			err = recordAudit(c, tx, AuditBookUpdate, "book", id, before)
+		}
+		if err != nil {
+			abortWithError(c, fmt.Errorf("update %d (id %d): %w", q.index, id, err))
+			return
+		}
+		results[q.index].Status = batchStatusUpdated
+		updated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, BookBatchResponse{
+		Updated: updated,
+		Failed:  len(results) - updated,
+		Results: results,
+	})
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBatchUpdateBooksHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectResolve := func(ref string, id int) {
+		rows := sqlmock.NewRows([]string{"id"})
+		if id > 0 {
+			rows.AddRow(id)
+		}
+		mock.ExpectQuery("SELECT id FROM books WHERE slug = \\? AND \\(\\(organization_id IS NULL OR organization_id = \\?\\) AND deleted_at IS NULL\\)").
+			WithArgs(ref, 1).
+			WillReturnRows(rows)
+	}
+	expectResolve("dune-1b4e28ba", 1)
+	expectResolve("ghost-9f8e7d6c", 2)
+	expectResolve("missing", 0)
+	expectResolve("dune-1b4e28ba", 1)
+	expectResolve("emma-0c1d2e3f", 4)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, version, slug FROM books WHERE id IN \\(\\?, \\?, \\?\\) AND \\(\\(organization_id IS NULL OR organization_id = \\?\\) AND deleted_at IS NULL\\) FOR UPDATE").
+		WithArgs(1, 2, 4, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "slug"}).AddRow(1, 3, "dune-1b4e28ba").AddRow(4, 2, "emma-0c1d2e3f"))
+	expectAuditSnapshot(mock, "book", `{"id": 1, "published_year": 1965}`)
+	mock.ExpectExec("UPDATE books SET published_year = \\?, subjects = \\?, version = version \\+ 1 WHERE id = \\?").
+		WithArgs(1999, `["Fantasy"]`, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM book_genres WHERE book_id = \\?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO book_genres").
+		WithArgs(1, "fantasy").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, AuditBookUpdate, "book", 1)
+	mock.ExpectCommit()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PATCH("/admin/books/batch", BatchUpdateBooksHandler)
+
+	body := `{"updates":[
+		{"id":"dune-1b4e28ba","published_year":1999,"subjects":[" Fantasy ",""]},
+		{"id":"ghost-9f8e7d6c","title":"Ghost"},
+		{"id":"missing","title":"Nowhere"},
+		{"id":"no-fields"},
+		{"id":"dune-1b4e28ba","title":"Dup"},
+		{"id":"emma-0c1d2e3f","title":"Stale","version":1}
+	]}`
+	req := httptest.NewRequest(http.MethodPatch, "/admin/books/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp BookBatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	want := []string{batchStatusUpdated, batchStatusNotFound, batchStatusNotFound, batchStatusInvalid, batchStatusInvalid, batchStatusConflict}
+	if resp.Updated != 1 || resp.Failed != 5 || len(resp.Results) != len(want) {
+		t.Fatalf("unexpected summary: %+v", resp)
+	}
+	for i, status := range want {
+		if resp.Results[i].Index != i || resp.Results[i].Status != status {
+			t.Fatalf("result %d: expected %s, got %+v", i, status, resp.Results[i])
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestBatchUpdateBooksHandler_TooMany(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PATCH("/admin/books/batch", BatchUpdateBooksHandler)
+
+	items := make([]string, maxBatchUpdates+1)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"id":"book-%d","author":"x"}`, i+1)
+	}
+	body := `{"updates":[` + strings.Join(items, ",") + `]}`
+	req := httptest.NewRequest(http.MethodPatch, "/admin/books/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
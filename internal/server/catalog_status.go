@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// catalogStaleAfter is how long a source can go without a successful ingest
+// before it's reported stale (CATALOG_STALE_HOURS, read in Run by
+// loadCatalogStaleAfter)
+var catalogStaleAfter = 48 * time.Hour
+
+// loadCatalogStaleAfter reads CATALOG_STALE_HOURS, keeping the default
+// unless it's a positive whole number
+func loadCatalogStaleAfter() {
+	if n, err := strconv.Atoi(os.Getenv("CATALOG_STALE_HOURS")); err == nil && n > 0 {
+		catalogStaleAfter = time.Duration(n) * time.Hour
+	}
+}
+
+// stuckIngestAfter is how long a running ingest can go without reporting
+// progress before it's reported stuck
+const stuckIngestAfter = time.Hour
+
+// CatalogSource is one source's last ingest
+type CatalogSource struct {
+	Source        string     `json:"source" example:"open_library:fantasy"`
+	LastAttemptAt time.Time  `json:"last_attempt_at"`
+	LastSuccessAt *time.Time `json:"last_success_at" extensions:"x-nullable"`
+	LastError     *string    `json:"last_error" extensions:"x-nullable"`
+	BooksLastRun  int        `json:"books_last_run"`
+	Stale         bool       `json:"stale"`
+}
+
+// CatalogStatus is the body of GET /admin/catalog/status
+type CatalogStatus struct {
+	Sources             []CatalogSource `json:"sources"`
+	Books               int             `json:"books"`
+	BooksAddedLast7Days int             `json:"books_added_last_7_days"`
+	LastBookAddedAt     *time.Time      `json:"last_book_added_at" extensions:"x-nullable"`
+	StaleAfterHours     int             `json:"stale_after_hours"`
+	Warnings            []string        `json:"warnings"`
+}
+
+// CatalogStatusHandler godoc
+// @Summary Catalogue freshness: last ingest per source, recent additions, staleness warnings
+// @Description Sources are recorded by the ingest job (bookrec ingest), one per Open Library category. A source is stale when it hasn't succeeded within CATALOG_STALE_HOURS (default 48). warnings also flag sources whose last attempt failed or brought no books, an ingest run that has stopped reporting progress, and a catalogue with no new books in 7 days. Platform admins only.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} CatalogStatus
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/catalog/status [get]
+func CatalogStatusHandler(c *gin.Context) {
+	status, err := loadCatalogStatus(c.Request.Context(), time.Now())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, status)
+}
+
+func loadCatalogStatus(ctx context.Context, now time.Time) (*CatalogStatus, error) {
+	status := &CatalogStatus{
+		Sources:         []CatalogSource{},
+		StaleAfterHours: int(catalogStaleAfter / time.Hour),
+		Warnings:        []string{},
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT source, last_attempt_at, last_success_at, last_error, books_last_run
+		FROM catalog_sources
+		ORDER BY source`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var s CatalogSource
+		var success sql.NullTime
+		var lastError sql.NullString
+		if err := rows.Scan(&s.Source, &s.LastAttemptAt, &success, &lastError, &s.BooksLastRun); err != nil {
+			return nil, err
+		}
+		s.Stale = !success.Valid || now.Sub(success.Time) > catalogStaleAfter
+		switch {
+		case !success.Valid:
+			status.Warnings = append(status.Warnings, s.Source+" has never ingested successfully")
+		case s.Stale:
+			status.Warnings = append(status.Warnings, fmt.Sprintf("%s last ingested successfully %s ago",
+				s.Source, now.Sub(success.Time).Round(time.Hour)))
+		}
+		if success.Valid {
+			s.LastSuccessAt = &success.Time
+		}
+		if lastError.Valid {
+			s.LastError = &lastError.String
+			status.Warnings = append(status.Warnings, s.Source+" failed on its last attempt: "+lastError.String)
+		} else if s.BooksLastRun == 0 {
+			status.Warnings = append(status.Warnings, s.Source+" brought no books on its last run")
+		}
+		status.Sources = append(status.Sources, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(status.Sources) == 0 {
+		status.Warnings = append(status.Warnings, "no ingest has been recorded; run bookrec ingest")
+	}
+
+	var lastAdded sql.NullTime
+	if err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(created_at >= ?), 0), MAX(created_at)
+		FROM books
+		WHERE deleted_at IS NULL`, now.AddDate(0, 0, -7)).
+		Scan(&status.Books, &status.BooksAddedLast7Days, &lastAdded); err != nil {
+		return nil, err
+	}
+	if lastAdded.Valid {
+		status.LastBookAddedAt = &lastAdded.Time
+	}
+	if status.BooksAddedLast7Days == 0 {
+		status.Warnings = append(status.Warnings, "no books were added in the last 7 days")
+	}
+
+	var runID int
+	var progressAt time.Time
+	err = db.QueryRowContext(ctx, `
+		SELECT id, updated_at FROM job_runs
+		WHERE job = 'ingest' AND status = 'running' AND updated_at < ?
+		ORDER BY id DESC
+		LIMIT 1`, now.Add(-stuckIngestAfter)).Scan(&runID, &progressAt)
+	switch {
+	case err == nil:
+		status.Warnings = append(status.Warnings, fmt.Sprintf("ingest run %d is still running but hasn't reported progress for %s",
+			runID, now.Sub(progressAt).Round(time.Minute)))
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+	return status, nil
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestLoadCatalogStatus(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	hoursAgo := func(h int) time.Time { return now.Add(-time.Duration(h) * time.Hour) }
+	mock.ExpectQuery("SELECT source, last_attempt_at, last_success_at, last_error, books_last_run\\s+FROM catalog_sources").
+		WillReturnRows(sqlmock.NewRows([]string{"source", "last_attempt_at", "last_success_at", "last_error", "books_last_run"}).
+			AddRow("open_library:data science", hoursAgo(2), hoursAgo(2), nil, 0).
+			AddRow("open_library:fantasy", hoursAgo(2), hoursAgo(2), nil, 10).
+			AddRow("open_library:self help", hoursAgo(2), hoursAgo(72), "search: 503", 10).
+			AddRow("open_library:poetry", hoursAgo(2), nil, "search: 503", 0))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\), COALESCE\\(SUM\\(created_at >= \\?\\), 0\\), MAX\\(created_at\\)\\s+FROM books").
+		WithArgs(now.AddDate(0, 0, -7)).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "added", "last"}).AddRow(120, 0, hoursAgo(24*9)))
+	mock.ExpectQuery("SELECT id, updated_at FROM job_runs\\s+WHERE job = 'ingest' AND status = 'running' AND updated_at < \\?").
+		WithArgs(now.Add(-stuckIngestAfter)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "updated_at"}).AddRow(31, now.Add(-90*time.Minute)))
+
+	status, err := loadCatalogStatus(context.Background(), now)
+	if err != nil {
+		t.Fatalf("loadCatalogStatus: %v", err)
+	}
+	if status.Books != 120 || status.BooksAddedLast7Days != 0 || status.StaleAfterHours != 48 {
+		t.Fatalf("unexpected counts: %+v", status)
+	}
+	stale := []bool{}
+	for _, s := range status.Sources {
+		stale = append(stale, s.Stale)
+	}
+	if !reflect.DeepEqual(stale, []bool{false, false, true, true}) {
+		t.Fatalf("expected self help and poetry stale, got %v", stale)
+	}
+	if status.Sources[3].LastSuccessAt != nil || *status.Sources[2].LastError != "search: 503" {
+		t.Fatalf("unexpected sources: %+v", status.Sources)
+	}
+	want := []string{
+		"open_library:data science brought no books on its last run",
+		"open_library:self help last ingested successfully 72h0m0s ago",
+		"open_library:self help failed on its last attempt: search: 503",
+		"open_library:poetry has never ingested successfully",
+		"open_library:poetry failed on its last attempt: search: 503",
+		"no books were added in the last 7 days",
+		"ingest run 31 is still running but hasn't reported progress for 1h30m0s",
+	}
+	if !reflect.DeepEqual(status.Warnings, want) {
+		t.Fatalf("expected warnings\n%q\ngot\n%q", want, status.Warnings)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestLoadCatalogStatusNothingIngested(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM catalog_sources").
+		WillReturnRows(sqlmock.NewRows([]string{"source", "last_attempt_at", "last_success_at", "last_error", "books_last_run"}))
+	mock.ExpectQuery("FROM books").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "added", "last"}).AddRow(0, 0, nil))
+	mock.ExpectQuery("FROM job_runs").
+		WillReturnError(sql.ErrNoRows)
+
+	status, err := loadCatalogStatus(context.Background(), now)
+	if err != nil {
+		t.Fatalf("loadCatalogStatus: %v", err)
+	}
+	want := []string{"no ingest has been recorded; run bookrec ingest", "no books were added in the last 7 days"}
+	if len(status.Sources) != 0 || status.LastBookAddedAt != nil || !reflect.DeepEqual(status.Warnings, want) {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
@@ -0,0 +1,329 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// Content filter config
+const (
+	contentFilterInterval = time.Minute
+	filterTermMaxLen      = 100
+)
+
+// filterTerm is one denylisted word or phrase
+type filterTerm struct {
+	term  string
+	mode  string   // word or substring
+	words []string // term split like the text it's matched against
+}
+
+func newFilterTerm(term, mode string) filterTerm {
+	term = strings.ToLower(strings.TrimSpace(term))
+	return filterTerm{term: term, mode: mode, words: filterWords(term)}
+}
+
+// filterWords lowercases text and splits it on anything but letters and digits,
+// so "Foo_bar!" and "foo bar" both become [foo bar]
+func filterWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matches reports whether t occurs in text (words are text's filterWords)
+func (t filterTerm) matches(lower string, words []string) bool {
+	if t.mode == "substring" {
+		return strings.Contains(lower, t.term)
+	}
+	if len(t.words) == 0 {
+		return false
+	}
+	for i := 0; i+len(t.words) <= len(words); i++ {
+		match := true
+		for j, w := range t.words {
+			if words[i+j] != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// contentFilterSet holds the denylist in memory so validating text never
+// waits on the database: the deployment-wide terms from CONTENT_FILTER_TERMS
+// plus each organization's, reloaded every contentFilterInterval and right
+// after an admin changes them.
+type contentFilterSet struct {
+	mu     sync.RWMutex
+	global []filterTerm
+	byOrg  map[int][]filterTerm
+}
+
+// contentFilter starts without deployment-wide terms; Run replaces it with
+// one built from CONTENT_FILTER_TERMS
+var contentFilter = newContentFilterSet("")
+
+// newContentFilterSet takes the comma-separated deployment-wide word terms
+func newContentFilterSet(global string) *contentFilterSet {
+	f := &contentFilterSet{byOrg: map[int][]filterTerm{}}
+	for _, term := range strings.Split(global, ",") {
+		if t := newFilterTerm(term, "word"); t.term != "" {
+			f.global = append(f.global, t)
+		}
+	}
+	return f
+}
+
+// Match returns the first denylisted term found in text
+func (f *contentFilterSet) Match(orgID int, text string) (string, bool) {
+	lower := strings.ToLower(text)
+	words := filterWords(text)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, terms := range [][]filterTerm{f.global, f.byOrg[orgID]} {
+		for _, t := range terms {
+			if t.matches(lower, words) {
+				return t.term, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Run reloads the organization terms every contentFilterInterval until ctx is cancelled
+func (f *contentFilterSet) Run(ctx context.Context) {
+	ticker := time.NewTicker(contentFilterInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := f.refresh(ctx); err != nil {
+			log.Printf("⚠️ content filter refresh failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *contentFilterSet) refresh(ctx context.Context) error {
+	rows, err := db.QueryContext(ctx, "SELECT organization_id, term, match_mode FROM content_filter_terms")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	byOrg := map[int][]filterTerm{}
+	for rows.Next() {
+		var orgID int
+		var term, mode string
+		if err := rows.Scan(&orgID, &term, &mode); err != nil {
+			return err
+		}
+		byOrg[orgID] = append(byOrg[orgID], newFilterTerm(term, mode))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.byOrg = byOrg
+	f.mu.Unlock()
+	return nil
+}
+
+// screenText rejects text containing a denylisted term with a 400 naming the
+// field and the term; it reports whether the handler may go on
+func screenText(c *gin.Context, field, text string) bool {
+	term, found := contentFilter.Match(tenant.ID(c.Request.Context()), text)
+	if !found {
+		return true
+	}
+	abortWithError(c, &AppError{
+		Status:  400,
+		Code:    "blocked_term",
+		Message: fmt.Sprintf("%s contains a blocked word (%q)", field, term),
+		Details: gin.H{"field": field},
+	})
+	return false
+}
+
+// ListFilterTermsHandler godoc
+// @Summary The organization's blocked words
+// @Description Terms added through this API; the deployment-wide CONTENT_FILTER_TERMS aren't listed.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/content-filter [get]
+func ListFilterTermsHandler(c *gin.Context) {
+	rows, err := db.QueryContext(c.Request.Context(), `
+		SELECT id, term, match_mode, created_at
+		FROM content_filter_terms
+		WHERE organization_id = ?
+		ORDER BY term`, tenant.ID(c.Request.Context()))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	terms := []gin.H{}
+	for rows.Next() {
+		var id int
+		var term, mode, createdAt string
+		if err := rows.Scan(&id, &term, &mode, &createdAt); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		terms = append(terms, gin.H{"id": id, "term": term, "match": mode, "created_at": createdAt})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"data": terms})
+}
+
+// AddFilterTermHandler godoc
+// @Summary Block a word or phrase in user-written text
+// @Description Applies to handles, list names, book club names and descriptions, and discussion titles and posts. Matching ignores case; word terms match whole words (or a run of whole words for phrases), substring terms match anywhere. Existing text isn't rechecked.
+// @Tags Admin
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param term formData string true "Word or phrase (max 100 characters)"
+// @Param match formData string false "word (default) or substring"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/content-filter [post]
+func AddFilterTermHandler(c *gin.Context) {
+	mode := c.DefaultPostForm("match", "word")
+	if mode != "word" && mode != "substring" {
+		abortWithError(c, badRequest("match must be word or substring"))
+		return
+	}
+	t := newFilterTerm(c.PostForm("term"), mode)
+	if t.term == "" {
+		abortWithError(c, badRequest("term is required"))
+		return
+	}
+	if len([]rune(t.term)) > filterTermMaxLen {
+		abortWithError(c, badRequest(fmt.Sprintf("term must be at most %d characters", filterTermMaxLen)))
+		return
+	}
+	if mode == "word" && len(t.words) == 0 {
+		abortWithError(c, badRequest("word terms need at least one letter or digit; use match=substring for symbols"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO content_filter_terms (organization_id, term, match_mode, created_by) VALUES (?, ?, ?, ?)",
+		tenant.ID(ctx), t.term, mode, c.GetInt("auth_user_id"))
+	if dberr.Is(err, dberr.ErrDuplicate) {
+		abortWithError(c, conflict("term is already blocked"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	id, _ := res.LastInsertId()
+	err = recordAudit(c, tx, AuditFilterTermAdd, "content_filter_term", int(id), nil)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := contentFilter.refresh(ctx); err != nil {
+		log.Printf("⚠️ content filter refresh failed: %v", err)
+	}
+
+	c.JSON(201, gin.H{"id": id, "term": t.term, "match": mode})
+}
+
+// RemoveFilterTermHandler godoc
+// @Summary Unblock a word or phrase
+// @Tags Admin
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Term ID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/content-filter/{id} [delete]
+func RemoveFilterTermHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithError(c, notFound("term not found"))
+		return
+	}
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	before, err := auditSnapshot(ctx, tx, "content_filter_term", id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	res, err := tx.ExecContext(ctx,
+		"DELETE FROM content_filter_terms WHERE id = ? AND organization_id = ?", id, tenant.ID(ctx))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		abortWithError(c, notFound("term not found"))
+		return
+	}
+	err = recordAudit(c, tx, AuditFilterTermRemove, "content_filter_term", id, before)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := contentFilter.refresh(ctx); err != nil {
+		log.Printf("⚠️ content filter refresh failed: %v", err)
+	}
+	c.Status(204)
+}
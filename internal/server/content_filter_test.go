@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestContentFilterSet_Match(t *testing.T) {
+	f := newContentFilterSet("darn, heck it")
+	f.byOrg[1] = []filterTerm{newFilterTerm("spam.link", "substring")}
+
+	for _, tc := range []struct {
+		org  int
+		text string
+		want string
+	}{
+		{1, "Well DARN!", "darn"},
+		{1, "darned socks", ""},
+		{1, "oh heck_it all", "heck it"},
+		{1, "heck, then it", ""},
+		{1, "visit myspam.link.example", "spam.link"},
+		{2, "visit myspam.link.example", ""},
+	} {
+		term, found := f.Match(tc.org, tc.text)
+		if term != tc.want || found != (tc.want != "") {
+			t.Fatalf("org %d %q: expected %q, got %q (%v)", tc.org, tc.text, tc.want, term, found)
+		}
+	}
+}
+
+func TestAddFilterTermHandler_BlocksSignupHandle(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	saved := contentFilter
+	contentFilter = newContentFilterSet("")
+	defer func() { contentFilter = saved }()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO content_filter_terms \\(organization_id, term, match_mode, created_by\\)").
+		WithArgs(1, "troll", "substring", 9).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	expectAudit(mock, AuditFilterTermAdd, "content_filter_term", 3)
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT organization_id, term, match_mode FROM content_filter_terms").
+		WillReturnRows(sqlmock.NewRows([]string{"organization_id", "term", "match_mode"}).AddRow(1, "troll", "substring"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin/content-filter", asUser(9), AddFilterTermHandler)
+	r.POST("/users", CreateUserHandler)
+
+	if w := postForm(r, "/admin/content-filter", url.Values{"term": {" Troll "}, "match": {"substring"}}); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := postForm(r, "/admin/content-filter", url.Values{"term": {"x"}, "match": {"regex"}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown match mode, got %d", w.Code)
+	}
+
+	w := postForm(r, "/users", url.Values{"email": {"a@example.com"}, "handle": {"TheTroller"}, "password": {"pw"}})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a blocked handle, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Code    string                 `json:"code"`
+		Details map[string]interface{} `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if body.Code != "blocked_term" || body.Details["field"] != "handle" {
+		t.Fatalf("unexpected error body: %v", body)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/contentwarnings"
+)
+
+// ContentPreferences is what a reader wants kept out of their
+// recommendations. An empty MaxAudienceRating means no limit.
+type ContentPreferences struct {
+	AvoidWarnings     []string `json:"avoid_content_warnings" example:"violence,self_harm"`
+	MaxAudienceRating string   `json:"max_audience_rating" example:"teen"`
+}
+
+// loadContentPreferences reads userID's preferences (empty for unknown users)
+func loadContentPreferences(ctx context.Context, q querier, userID int) (ContentPreferences, error) {
+	var avoid string
+	var audience sql.NullString
+	err := q.QueryRowContext(ctx,
+		"SELECT avoid_content_warnings, max_audience_rating FROM users WHERE id = ?", userID).Scan(&avoid, &audience)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ContentPreferences{AvoidWarnings: []string{}}, nil
+	}
+	if err != nil {
+		return ContentPreferences{}, err
+	}
+	return ContentPreferences{AvoidWarnings: contentwarnings.Split(avoid), MaxAudienceRating: audience.String}, nil
+}
+
+// contentPreferencesOwner resolves :id and checks it is the caller
+func contentPreferencesOwner(c *gin.Context) (int, bool) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return 0, false
+	}
+	if c.GetInt("auth_user_id") != userID {
+		abortWithError(c, forbidden("cannot access another user's content preferences"))
+		return 0, false
+	}
+	return userID, true
+}
+
+// GetContentPreferencesHandler godoc
+// @Summary Get your content preferences
+// @Description Content warnings to keep out of your recommendations, and the highest audience rating to recommend.
+// @Tags Users
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID; must be the caller"
+// @Success 200 {object} ContentPreferences
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/content-preferences [get]
+func GetContentPreferencesHandler(c *gin.Context) {
+	userID, ok := contentPreferencesOwner(c)
+	if !ok {
+		return
+	}
+	prefs, err := loadContentPreferences(c.Request.Context(), db, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, prefs)
+}
+
+// UpdateContentPreferencesHandler godoc
+// @Summary Set your content preferences
+// @Description Replaces both settings. Recommendations then skip books with any of avoid_content_warnings, and books rated above max_audience_rating (children, teen, adult; empty for no limit). Books with no audience rating are still recommended.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID; must be the caller"
+// @Param body body ContentPreferences true "Preferences"
+// @Success 200 {object} ContentPreferences
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/content-preferences [put]
+func UpdateContentPreferencesHandler(c *gin.Context) {
+	userID, ok := contentPreferencesOwner(c)
+	if !ok {
+		return
+	}
+	var body ContentPreferences
+	if err := c.ShouldBindJSON(&body); err != nil {
+		abortWithError(c, badRequest("invalid JSON body"))
+		return
+	}
+	warnings, err := contentwarnings.Parse(body.AvoidWarnings)
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	var audience interface{}
+	maxAudience := strings.ToLower(strings.TrimSpace(body.MaxAudienceRating))
+	if maxAudience != "" {
+		if !contentwarnings.ValidAudience(maxAudience) {
+			abortWithError(c, badRequest("max_audience_rating must be one of "+strings.Join(contentwarnings.Audiences, ", ")))
+			return
+		}
+		audience = maxAudience
+	}
+
+	if _, err := db.ExecContext(c.Request.Context(),
+		"UPDATE users SET avoid_content_warnings = ?, max_audience_rating = ? WHERE id = ?",
+		strings.Join(warnings, ","), audience, userID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	forgetRecommendations(c.Request.Context(), userID)
+	c.JSON(200, ContentPreferences{AvoidWarnings: warnings, MaxAudienceRating: maxAudience})
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/recommend"
+)
+
+func TestUpdateContentPreferencesHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectExec("UPDATE users SET avoid_content_warnings = \\?, max_audience_rating = \\? WHERE id = \\?").
+		WithArgs("violence,self_harm", "teen", 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// unknown warning
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	// someone else's preferences
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PUT("/users/:id/content-preferences", asUser(2), UpdateContentPreferencesHandler)
+
+	put := func(target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := put("/users/2/content-preferences", `{"avoid_content_warnings":["self_harm","Violence"],"max_audience_rating":"teen"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"avoid_content_warnings":["violence","self_harm"]`) {
+		t.Fatalf("expected 200 with normalised warnings, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := put("/users/2/content-preferences", `{"avoid_content_warnings":["spiders"]}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown warning, got %d", w.Code)
+	}
+	if w := put("/users/3/content-preferences", `{}`); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another user, got %d", w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestRecommendationsHandler_AppliesContentPreferences(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT avoid_content_warnings, max_audience_rating FROM users WHERE id = \\?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"avoid_content_warnings", "max_audience_rating"}).AddRow("violence", "teen"))
+	mock.ExpectQuery("AND FIND_IN_SET\\(\\?, b.content_warnings\\) = 0 AND \\(b.audience_rating IS NULL OR b.audience_rating IN \\(\\?, \\?\\)\\)").
+		WithArgs(2, recommend.Neighbors, 2, 2, "violence", "children", "teen").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "page_count", "score", "cover_id"}).
+			AddRow(8, "b-8", "matilda-b8", "Matilda", "Roald Dahl", 240, 2, nil))
+	expectCoLiked(mock, coLikedRows(), 8)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/recommendations/:user_id", asUser(2), RecommendationsHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recommendations/2", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Matilda") {
+		t.Fatalf("expected 200 with Matilda, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
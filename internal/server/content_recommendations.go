@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/YeswanthC7/bookrec/internal/metrics"
+	"github.com/YeswanthC7/bookrec/internal/recommend"
+	"github.com/YeswanthC7/bookrec/internal/tracing"
+)
+
+// Recommendation modes for GET /recommendations/{user_id}?mode=
+const (
+	recommendCollaborative = "collaborative"
+	recommendContent       = "content"
+	recommendAuthors       = "authors"
+	recommendHybrid        = "hybrid"
+)
+
+// parseRecommendMode reads ?mode= (collaborative by default)
+func parseRecommendMode(raw string) (string, error) {
+	switch raw {
+	case "", recommendCollaborative:
+		return recommendCollaborative, nil
+	case recommendContent:
+		return recommendContent, nil
+	case recommendAuthors:
+		return recommendAuthors, nil
+	case recommendHybrid:
+		return recommendHybrid, nil
+	}
+	return "", fmt.Errorf("mode must be collaborative, content, authors or hybrid")
+}
+
+// recommendFor runs the recommender mode picks; weights only matter to
+// the hybrid one
+func recommendFor(ctx context.Context, q querier, userID int, mode string, filters bookFilters, weights hybridWeights) ([]gin.H, error) {
+	defer prometheus.NewTimer(metrics.RecommendationDuration.WithLabelValues(mode)).ObserveDuration()
+	ctx, span := otel.Tracer(tracing.ServiceName).Start(ctx, "recommend",
+		trace.WithAttributes(attribute.String("recommend.mode", mode), attribute.Int("user.id", userID)))
+	defer span.End()
+	switch mode {
+	case recommendContent:
+		return loadContentRecommendations(ctx, q, userID, filters)
+	case recommendAuthors:
+		return loadAuthorRecommendations(ctx, q, userID, filters)
+	case recommendHybrid:
+		return loadHybridRecommendations(ctx, q, userID, filters, weights)
+	}
+	return loadRecommendations(ctx, q, userID, filters)
+}
+
+// loadContentRecommendations returns userID's top 10 books by subject
+// similarity to the books they liked, so readers with only a like or two
+// (and no neighbours yet) still get something. A rating of 4 or more counts
+// as a like and one of 2 or less as a dislike, as in the collaborative mode;
+// a book both liked and disliked counts as liked. Each candidate scores the
+// sum of its Jaccard similarities (shared subjects over all subjects of the
+// pair, compared case-insensitively, so "Fantasy" and "fantasy" on one book
+// count once) to every liked book, minus those to every disliked one; books
+// scoring 0 or less are left out. The catalogue is the user's
+// organization's; filters and content preferences apply as in
+// loadRecommendations.
+func loadContentRecommendations(ctx context.Context, q querier, userID int, filters bookFilters) ([]gin.H, error) {
+	prefs, err := loadContentPreferences(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	filters.avoidWarnings, filters.maxAudience = prefs.AvoidWarnings, prefs.MaxAudienceRating
+	filterSQL, filterArgs := filters.sql("b")
+	query := `
+        WITH liked AS (
+            SELECT book_id, MAX(CASE WHEN action = 'dislike' OR (action = 'rating' AND rating <= 2) THEN -1 ELSE 1 END) AS weight
+            FROM interactions
+            WHERE user_id = ? AND deleted_at IS NULL
+              AND (action IN ('like', 'dislike') OR (action = 'rating' AND (rating >= 4 OR rating <= 2)))
+            GROUP BY book_id
+        ),
+        liked_subjects AS (
+            SELECT DISTINCT l.book_id, l.weight, LOWER(s.subject) AS subject
+            FROM liked l
+            JOIN books b ON b.id = l.book_id
+            JOIN JSON_TABLE(b.subjects, '$[*]' COLUMNS (subject VARCHAR(255) PATH '$')) s
+        ),
+        candidate_subjects AS (
+            SELECT DISTINCT c.id AS book_id, LOWER(cs.subject) AS subject
+            FROM books c
+            JOIN JSON_TABLE(c.subjects, '$[*]' COLUMNS (subject VARCHAR(255) PATH '$')) cs
+            WHERE (c.organization_id IS NULL OR c.organization_id = (SELECT organization_id FROM users WHERE id = ?))
+              AND c.deleted_at IS NULL AND c.merged_into IS NULL
+              AND c.id NOT IN (` + recommend.KnownBooksSQL + `)
+        ),
+        liked_sizes AS (
+            SELECT book_id, COUNT(*) AS size FROM liked_subjects GROUP BY book_id
+        ),
+        candidate_sizes AS (
+            SELECT book_id, COUNT(*) AS size FROM candidate_subjects GROUP BY book_id
+        ),
+        candidates AS (
+            SELECT cs.book_id, ls.book_id AS liked_id, MAX(ls.weight) AS weight, COUNT(*) AS overlap
+            FROM candidate_subjects cs
+            JOIN liked_subjects ls ON ls.subject = cs.subject
+            GROUP BY cs.book_id, ls.book_id
+        )
+        SELECT b.id, b.uuid, b.slug, b.title, b.author, b.page_count, s.score, b.cover_id
+        FROM (
+            SELECT c.book_id, SUM(c.weight * c.overlap / (cz.size + lz.size - c.overlap)) AS score
+            FROM candidates c
+            JOIN candidate_sizes cz ON cz.book_id = c.book_id
+            JOIN liked_sizes lz ON lz.book_id = c.liked_id
+            GROUP BY c.book_id
+            HAVING score > 0
+        ) s
+        JOIN books b ON b.id = s.book_id
+        WHERE 1=1` + filterSQL + `
+        ORDER BY s.score DESC, b.id
+        LIMIT 10;
+    `
+	rows, err := q.QueryContext(ctx, query, append([]interface{}{userID, userID, userID, userID}, filterArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	recs := []gin.H{}
+	for rows.Next() {
+		var id int
+		var score float64
+		var publicID, slug, title string
+		var author sql.NullString
+		var pages, cover sql.NullInt64
+		if err := rows.Scan(&id, &publicID, &slug, &title, &author, &pages, &score, &cover); err != nil {
+			return nil, err
+		}
+		recs = append(recs, gin.H{
+			"book_id":       id,
+			"book_uuid":     publicID,
+			"slug":          slug,
+			"title":         title,
+			"author":        author.String,
+			"page_count":    nullableInt(pages),
+			"reading_hours": readingHours(pages),
+			"cover_url":     coverURL(cover),
+			"score":         math.Round(score*1000) / 1000,
+		})
+	}
+	return recs, rows.Err()
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecommendationsHandler_ContentMode(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT avoid_content_warnings, max_audience_rating FROM users WHERE id = \\?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"avoid_content_warnings", "max_audience_rating"}).AddRow("", nil))
+	mock.ExpectQuery("WITH liked AS .+SELECT DISTINCT c.id AS book_id, LOWER\\(cs.subject\\).+SUM\\(c.weight \\* c.overlap / \\(cz.size \\+ lz.size - c.overlap\\)\\) AS score.+HAVING score > 0").
+		WithArgs(2, 2, 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "page_count", "score", "cover_id"}).
+			AddRow(8, "b-8", "the-hobbit-b8", "The Hobbit", "J.R.R. Tolkien", 310, 0.66666, nil).
+			AddRow(9, "b-9", "earthsea-b9", "A Wizard of Earthsea", "Ursula K. Le Guin", nil, 0.25, nil))
+	// the Hobbit shares two subjects with The Lord of the Rings and one with
+	// Dune, so the first is its reason
+	mock.ExpectQuery("SELECT b.uuid, b.slug, b.title, b.subjects\\s+FROM interactions i").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "slug", "title", "subjects"}).
+			AddRow("b-3", "dune-b3", "Dune", `["Adventure", "Science fiction"]`).
+			AddRow("b-4", "lotr-b4", "The Lord of the Rings", `["Fantasy", "adventure", "Epic"]`))
+	mock.ExpectQuery("SELECT id, subjects FROM books WHERE id IN \\(\\?, \\?\\)").
+		WithArgs(8, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subjects"}).
+			AddRow(8, `["fantasy", "Adventure", "Dragons"]`).
+			AddRow(9, nil))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/recommendations/:user_id", asUser(2), RecommendationsHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recommendations/2?mode=content", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"score":0.667`) || strings.Index(body, "The Hobbit") > strings.Index(body, "Earthsea") {
+		t.Fatalf("expected The Hobbit first with a rounded score, got %s", body)
+	}
+	if !strings.Contains(body, `"text":"Because you liked The Lord of the Rings (adventure, fantasy)"`) ||
+		!strings.Contains(body, `"reason":null`) {
+		t.Fatalf("expected The Hobbit explained by The Lord of the Rings and Earthsea unexplained, got %s", body)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestLoadContentRecommendations_WeighsRatings(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// a rating of 4+ pulls towards a book's subjects and one of 2 or less
+	// pushes away, like a like and a dislike
+	mock.ExpectQuery("SELECT avoid_content_warnings, max_audience_rating FROM users WHERE id = \\?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"avoid_content_warnings", "max_audience_rating"}).AddRow("", nil))
+	mock.ExpectQuery("WITH liked AS \\(\\s+SELECT book_id, MAX\\(CASE WHEN action = 'dislike' OR \\(action = 'rating' AND rating <= 2\\) THEN -1 ELSE 1 END\\) AS weight\\s+FROM interactions\\s+"+
+		"WHERE user_id = \\? AND deleted_at IS NULL\\s+AND \\(action IN \\('like', 'dislike'\\) OR \\(action = 'rating' AND \\(rating >= 4 OR rating <= 2\\)\\)\\)\\s+GROUP BY book_id").
+		WithArgs(2, 2, 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "page_count", "score", "cover_id"}).
+			AddRow(8, "b-8", "the-hobbit-b8", "The Hobbit", "J.R.R. Tolkien", 310, 0.5, nil))
+
+	recs, err := loadContentRecommendations(context.Background(), db, 2, bookFilters{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recs) != 1 || recs[0]["book_uuid"] != "b-8" {
+		t.Fatalf("unexpected recommendations: %v", recs)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestRecommendationsHandler_UnknownMode(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/recommendations/:user_id", asUser(2), RecommendationsHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recommendations/2?mode=random", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,458 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// contentReportHideThreshold is how many open reports hide a thread or post
+// until a moderator looks at it
+const contentReportHideThreshold = 3
+
+// contentExcerptLen bounds post bodies shown in the moderation queue
+const contentExcerptLen = 200
+
+// contentReportReasons are what readers can flag discussion content for
+var contentReportReasons = map[string]bool{
+	"spam":       true,
+	"harassment": true,
+	"hate":       true,
+	"spoiler":    true,
+	"off_topic":  true,
+	"other":      true,
+}
+
+// contentTargetTables maps a report target_type to the table holding it
+var contentTargetTables = map[string]string{
+	"thread": "discussion_threads",
+	"post":   "discussion_posts",
+}
+
+// contentActions maps a moderator decision to the status of the reports it closes
+var contentActions = map[string]string{
+	"remove":  "upheld",    // soft-delete the content
+	"restore": "dismissed", // unhide it
+}
+
+// ReportThreadHandler godoc
+// @Summary Report an abusive discussion thread
+// @Description Once 3 readers have open reports on a thread it's hidden until a moderator resolves it (GET /admin/content-reports). A reader reports a thread once; club threads can only be reported by members.
+// @Tags Discussions
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Thread UUID"
+// @Param reason formData string true "spam, harassment, hate, spoiler, off_topic or other"
+// @Param details formData string false "What's wrong (max 2000 characters)"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /threads/{id}/report [post]
+func ReportThreadHandler(c *gin.Context) {
+	threadID, ok := resolveParam(c, resolveThreadRef, c.Param("id"), "thread")
+	if !ok {
+		return
+	}
+	var publicID string
+	var groupID sql.NullInt64
+	err := db.QueryRowContext(c.Request.Context(),
+		"SELECT uuid, group_id FROM discussion_threads WHERE id = ? AND deleted_at IS NULL", threadID).Scan(&publicID, &groupID)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("thread not found"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	reportContent(c, "thread", threadID, publicID, groupID)
+}
+
+// ReportPostHandler godoc
+// @Summary Report an abusive post in a discussion thread
+// @Description Once 3 readers have open reports on a post its body is hidden until a moderator resolves it. A reader reports a post once; posts in club threads can only be reported by members.
+// @Tags Discussions
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Post ID"
+// @Param reason formData string true "spam, harassment, hate, spoiler, off_topic or other"
+// @Param details formData string false "What's wrong (max 2000 characters)"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /posts/{id}/report [post]
+func ReportPostHandler(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithError(c, notFound("post not found"))
+		return
+	}
+	ctx := c.Request.Context()
+	var groupID sql.NullInt64
+	err = db.QueryRowContext(ctx, `
+		SELECT t.group_id
+		FROM discussion_posts p
+		JOIN discussion_threads t ON t.id = p.thread_id
+		WHERE p.id = ? AND t.organization_id = ? AND p.deleted_at IS NULL AND t.deleted_at IS NULL`,
+		postID, tenant.ID(ctx)).Scan(&groupID)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("post not found"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	reportContent(c, "post", postID, "", groupID)
+}
+
+// reportContent files the caller's report on a thread or post and hides the
+// target once it reaches contentReportHideThreshold open reports
+func reportContent(c *gin.Context, targetType string, targetID int, threadUUID string, groupID sql.NullInt64) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("auth_user_id")
+	if groupID.Valid {
+		// club discussions are private; outsiders get the same 404 as a missing target
+		role, err := groupRoleOf(ctx, int(groupID.Int64), userID)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if role < groupRoleMember {
+			abortWithError(c, notFound(targetType+" not found"))
+			return
+		}
+	}
+
+	reason := strings.TrimSpace(c.PostForm("reason"))
+	if !contentReportReasons[reason] {
+		abortWithError(c, badRequest("reason must be spam, harassment, hate, spoiler, off_topic or other"))
+		return
+	}
+	details := strings.TrimSpace(c.PostForm("details"))
+	if len([]rune(details)) > reportDetailsMaxLen {
+		abortWithError(c, badRequest(fmt.Sprintf("details must be at most %d characters", reportDetailsMaxLen)))
+		return
+	}
+	var detailsValue interface{}
+	if details != "" {
+		detailsValue = details
+	}
+
+	res, err := db.ExecContext(ctx, `
+		INSERT INTO content_reports (organization_id, target_type, target_id, user_id, reason, details)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		tenant.ID(ctx), targetType, targetID, userID, reason, detailsValue)
+	if dberr.Is(err, dberr.ErrDuplicate) {
+		abortWithError(c, conflict("you already reported this "+targetType))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	id, _ := res.LastInsertId()
+
+	var open int
+	if err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM content_reports WHERE target_type = ? AND target_id = ? AND status = 'open'",
+		targetType, targetID).Scan(&open); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	hidden := open >= contentReportHideThreshold
+	if hidden {
+		if _, err := db.ExecContext(ctx,
+			"UPDATE "+contentTargetTables[targetType]+" SET hidden_at = CURRENT_TIMESTAMP WHERE id = ? AND hidden_at IS NULL",
+			targetID); err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+
+	report := gin.H{
+		"id":          id,
+		"target_type": targetType,
+		"reason":      reason,
+		"details":     detailsValue,
+		"status":      "open",
+		"hidden":      hidden,
+	}
+	for k, v := range contentTarget(targetType, targetID, threadUUID) {
+		report[k] = v
+	}
+	c.JSON(201, report)
+}
+
+// contentTarget names a reported thread by its uuid and a post, which has
+// none, by its id
+func contentTarget(targetType string, targetID int, threadUUID string) gin.H {
+	if targetType == "thread" {
+		return gin.H{"target_uuid": threadUUID}
+	}
+	return gin.H{"target_id": targetID}
+}
+
+// contentLinks points moderators at the reported content and its resolve action
+func contentLinks(targetType string, targetID int, threadUUID string, bookUUID, groupUUID sql.NullString) gin.H {
+	target := strconv.Itoa(targetID)
+	if targetType == "thread" {
+		target = threadUUID
+	}
+	links := gin.H{
+		"resolve": fmt.Sprintf("/admin/content-reports/%s/%s/resolve", targetType, target),
+	}
+	switch {
+	case bookUUID.Valid:
+		links["thread"] = fmt.Sprintf("/books/%s/threads/%s", bookUUID.String, threadUUID)
+	case groupUUID.Valid:
+		links["thread"] = fmt.Sprintf("/groups/%s/threads/%s", groupUUID.String, threadUUID)
+	}
+	return links
+}
+
+// ListContentReportsHandler godoc
+// @Summary Moderation queue of reported discussion content
+// @Description One entry per reported thread or post with open reports, most reported first. hidden is true once the target crossed the auto-hide threshold.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param target_type query string false "thread or post"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/content-reports [get]
+func ListContentReportsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	where := " WHERE r.organization_id = ? AND r.status = 'open'"
+	args := []interface{}{tenant.ID(ctx)}
+	if targetType := c.Query("target_type"); targetType != "" {
+		if _, ok := contentTargetTables[targetType]; !ok {
+			abortWithError(c, badRequest("target_type must be thread or post"))
+			return
+		}
+		where += " AND r.target_type = ?"
+		args = append(args, targetType)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	var total int
+	if err := db.QueryRowContext(ctx,
+		"SELECT COUNT(DISTINCT r.target_type, r.target_id) FROM content_reports r"+where, args...).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT q.target_type, q.target_id, q.reports, q.reasons, q.first_reported_at,
+		       COALESCE(t.title, pt.title), p.body,
+		       COALESCE(t.hidden_at, p.hidden_at) IS NOT NULL,
+		       COALESCE(t.uuid, pt.uuid), b.uuid, g.uuid,
+		       u.id, u.uuid, u.handle
+		FROM (
+			SELECT r.target_type, r.target_id, COUNT(*) AS reports,
+			       GROUP_CONCAT(DISTINCT r.reason ORDER BY r.reason) AS reasons,
+			       MIN(r.created_at) AS first_reported_at
+			FROM content_reports r`+where+`
+			GROUP BY r.target_type, r.target_id
+		) q
+		LEFT JOIN discussion_threads t ON q.target_type = 'thread' AND t.id = q.target_id
+		LEFT JOIN discussion_posts p ON q.target_type = 'post' AND p.id = q.target_id
+		LEFT JOIN discussion_threads pt ON pt.id = p.thread_id
+		LEFT JOIN books b ON b.id = COALESCE(t.book_id, pt.book_id)
+		LEFT JOIN reading_groups g ON g.id = COALESCE(t.group_id, pt.group_id)
+		LEFT JOIN users u ON u.id = COALESCE(t.user_id, p.user_id)
+		ORDER BY q.reports DESC, q.first_reported_at
+		LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	items := []gin.H{}
+	for rows.Next() {
+		var targetType, reasons, firstReportedAt string
+		var targetID, reports int
+		var hidden bool
+		var title, body, threadUUID, bookUUID, groupUUID sql.NullString
+		var authorID sql.NullInt64
+		var authorUUID, handle sql.NullString
+		if err := rows.Scan(&targetType, &targetID, &reports, &reasons, &firstReportedAt,
+			&title, &body, &hidden,
+			&threadUUID, &bookUUID, &groupUUID,
+			&authorID, &authorUUID, &handle); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		excerpt := title.String
+		if targetType == "post" {
+			excerpt = body.String
+			if r := []rune(excerpt); len(r) > contentExcerptLen {
+				excerpt = string(r[:contentExcerptLen]) + "…"
+			}
+		}
+		item := gin.H{
+			"target_type":       targetType,
+			"open_reports":      reports,
+			"reasons":           strings.Split(reasons, ","),
+			"hidden":            hidden,
+			"excerpt":           excerpt,
+			"thread_title":      nullableString(title),
+			"author":            userRef(authorID, authorUUID, handle),
+			"first_reported_at": firstReportedAt,
+			"links":             contentLinks(targetType, targetID, threadUUID.String, bookUUID, groupUUID),
+		}
+		for k, v := range contentTarget(targetType, targetID, threadUUID.String) {
+			item[k] = v
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  items,
+	})
+}
+
+// ResolveContentReportsHandler godoc
+// @Summary Decide on reported discussion content
+// @Description remove soft-deletes the thread or post and upholds its open reports; restore unhides it and dismisses them.
+// @Tags Admin
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param target_type path string true "thread or post"
+// @Param target_id path string true "Thread UUID or post ID"
+// @Param action formData string true "remove or restore"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/content-reports/{target_type}/{target_id}/resolve [post]
+func ResolveContentReportsHandler(c *gin.Context) {
+	targetType := c.Param("target_type")
+	table, ok := contentTargetTables[targetType]
+	if !ok {
+		abortWithError(c, notFound("target_type must be thread or post"))
+		return
+	}
+	var targetID int
+	if targetType == "thread" {
+		if targetID, ok = resolveParam(c, resolveThreadRef, c.Param("target_id"), "thread"); !ok {
+			return
+		}
+	} else {
+		var err error
+		if targetID, err = strconv.Atoi(c.Param("target_id")); err != nil {
+			abortWithError(c, notFound(targetType+" not found"))
+			return
+		}
+	}
+	action := strings.TrimSpace(c.PostForm("action"))
+	status, ok := contentActions[action]
+	if !ok {
+		abortWithError(c, badRequest("action must be remove or restore"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	moderatorID := c.GetInt("auth_user_id")
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE content_reports
+		SET status = ?, resolved_by = ?, resolved_at = CURRENT_TIMESTAMP
+		WHERE organization_id = ? AND target_type = ? AND target_id = ? AND status = 'open'`,
+		status, moderatorID, tenant.ID(ctx), targetType, targetID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	closed, _ := res.RowsAffected()
+	if closed == 0 {
+		abortWithError(c, notFound("no open reports for this "+targetType))
+		return
+	}
+
+	before, err := auditSnapshot(ctx, tx, targetType, targetID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	auditAction := AuditContentRestore
+	if action == "remove" {
+		auditAction = AuditContentRemove
+		_, err = tx.ExecContext(ctx,
+			"UPDATE "+table+" SET deleted_at = CURRENT_TIMESTAMP, deleted_by = ? WHERE id = ? AND deleted_at IS NULL",
+			moderatorID, targetID)
+	} else {
+		_, err = tx.ExecContext(ctx, "UPDATE "+table+" SET hidden_at = NULL WHERE id = ?", targetID)
+	}
+	if err == nil {
+		err = recordAudit(c, tx, auditAction, targetType, targetID, before)
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	var threadUUID string
+	if targetType == "thread" {
+		if threadUUID, err = publicRef(ctx, db, "discussion_threads", targetID); err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+	resolved := gin.H{
+		"target_type": targetType,
+		"action":      action,
+		"status":      status,
+		"closed":      closed,
+	}
+	for k, v := range contentTarget(targetType, targetID, threadUUID) {
+		resolved[k] = v
+	}
+	c.JSON(200, resolved)
+}
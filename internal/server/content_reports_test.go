@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestReportPostHandler_HidesAtThreshold(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectPost := func(id int) {
+		mock.ExpectQuery("FROM discussion_posts p\\s+JOIN discussion_threads t ON t.id = p.thread_id\\s+WHERE p.id = \\? AND t.organization_id = \\?").
+			WithArgs(id, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"group_id"}).AddRow(nil))
+	}
+
+	// third open report hides the post
+	expectPost(5)
+	mock.ExpectExec("INSERT INTO content_reports \\(organization_id, target_type, target_id, user_id, reason, details\\)").
+		WithArgs(1, "post", 5, 2, "harassment", nil).
+		WillReturnResult(sqlmock.NewResult(31, 1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM content_reports WHERE target_type = \\? AND target_id = \\? AND status = 'open'").
+		WithArgs("post", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectExec("UPDATE discussion_posts SET hidden_at = CURRENT_TIMESTAMP WHERE id = \\? AND hidden_at IS NULL").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// reported twice by the same reader
+	expectPost(5)
+	mock.ExpectExec("INSERT INTO content_reports").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	// unknown reason
+	expectPost(5)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/posts/:id/report", asUser(2), ReportPostHandler)
+
+	w := postForm(r, "/posts/5/report", url.Values{"reason": {"harassment"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if body["hidden"] != true || body["target_id"] != float64(5) {
+		t.Fatalf("expected the post to be hidden: %v", body)
+	}
+	if w := postForm(r, "/posts/5/report", url.Values{"reason": {"spam"}}); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a repeat report, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := postForm(r, "/posts/5/report", url.Values{"reason": {"boring"}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown reason, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestReportThreadHandler_ClubMembersOnly(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM discussion_threads WHERE id = \\? AND organization_id = \\?").
+		WithArgs(8, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT uuid, group_id FROM discussion_threads WHERE id = \\? AND deleted_at IS NULL").
+		WithArgs(8).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "group_id"}).AddRow("t-8", 3))
+	mock.ExpectQuery("SELECT g.owner_id, COALESCE\\(m.role, ''\\)").
+		WithArgs(2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"owner_id", "role"}).AddRow(7, ""))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/threads/:id/report", asUser(2), ReportThreadHandler)
+
+	w := postForm(r, "/threads/8/report", url.Values{"reason": {"spam"}})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a non-member, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestResolveContentReportsHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// remove upholds the reports and soft-deletes the post
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE content_reports\\s+SET status = \\?, resolved_by = \\?, resolved_at = CURRENT_TIMESTAMP\\s+WHERE organization_id = \\? AND target_type = \\? AND target_id = \\? AND status = 'open'").
+		WithArgs("upheld", 1, 1, "post", 5).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	expectAuditSnapshot(mock, "post", `{"id": 5, "deleted_at": null}`)
+	mock.ExpectExec("UPDATE discussion_posts SET deleted_at = CURRENT_TIMESTAMP, deleted_by = \\? WHERE id = \\? AND deleted_at IS NULL").
+		WithArgs(1, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, AuditContentRemove, "post", 5)
+	mock.ExpectCommit()
+	// nothing open on the thread
+	mock.ExpectQuery("SELECT 1 FROM discussion_threads WHERE id = \\? AND organization_id = \\?").
+		WithArgs(8, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE content_reports").
+		WithArgs("dismissed", 1, 1, "thread", 8).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin/content-reports/:target_type/:target_id/resolve", asUser(1), ResolveContentReportsHandler)
+
+	w := postForm(r, "/admin/content-reports/post/5/resolve", url.Values{"action": {"remove"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w = postForm(r, "/admin/content-reports/thread/8/resolve", url.Values{"action": {"restore"}})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without open reports, got %d: %s", w.Code, w.Body.String())
+	}
+	w = postForm(r, "/admin/content-reports/review/8/resolve", url.Values{"action": {"restore"}})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown target type, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORS settings, read from the environment in Run (see loadCORSSettings)
+var (
+	// allowedOrigins are the browser origins allowed for CORS and websocket
+	// upgrades: exact origins, "*" for any, or one "*" standing for a
+	// subdomain, e.g. "https://*.example.com"
+	allowedOrigins = []string{"http://localhost:5173"}
+	allowedMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	allowedHeaders = []string{"Origin", "Content-Type", "Accept", "Accept-Language", "Authorization", "If-Match", tenantHeader}
+	// exposedHeaders are the response headers browser code may read
+	exposedHeaders = []string{"Content-Length", "Content-Disposition", "Content-Language", "ETag", "Location", "Retry-After"}
+	// corsMaxAge is how long browsers may cache a preflight answer
+	corsMaxAge = 10 * time.Minute
+)
+
+// loadCORSSettings reads CORS_ALLOWED_ORIGINS, CORS_ALLOWED_METHODS and
+// CORS_ALLOWED_HEADERS (comma-separated, each replacing the default list)
+// and CORS_MAX_AGE_SECONDS
+func loadCORSSettings() {
+	for name, list := range map[string]*[]string{
+		"CORS_ALLOWED_ORIGINS": &allowedOrigins,
+		"CORS_ALLOWED_METHODS": &allowedMethods,
+		"CORS_ALLOWED_HEADERS": &allowedHeaders,
+	} {
+		if values := splitList(os.Getenv(name)); len(values) > 0 {
+			*list = values
+		}
+	}
+	if n, err := strconv.Atoi(os.Getenv("CORS_MAX_AGE_SECONDS")); err == nil && n >= 0 {
+		corsMaxAge = time.Duration(n) * time.Second
+	}
+}
+
+// splitList splits a comma-separated setting, dropping blanks
+func splitList(raw string) []string {
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// originAllowed reports whether a browser at origin may call the API
+func originAllowed(origin string) bool {
+	for _, o := range allowedOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+		if prefix, suffix, ok := strings.Cut(o, "*"); ok && len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// corsMiddleware answers preflights and adds the CORS headers. The allowed
+// origin is echoed back rather than "*", so browsers accept it along with
+// credentials; other origins get 403.
+func corsMiddleware() gin.HandlerFunc {
+	return cors.New(cors.Config{
+		AllowOriginFunc:  originAllowed,
+		AllowMethods:     allowedMethods,
+		AllowHeaders:     allowedHeaders,
+		ExposeHeaders:    exposedHeaders,
+		AllowCredentials: true,
+		MaxAge:           corsMaxAge,
+	})
+}
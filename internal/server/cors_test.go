@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withCORSSettings restores the CORS settings after the test
+func withCORSSettings(t *testing.T) {
+	t.Helper()
+	origins, methods, headers, maxAge := allowedOrigins, allowedMethods, allowedHeaders, corsMaxAge
+	t.Cleanup(func() {
+		allowedOrigins, allowedMethods, allowedHeaders, corsMaxAge = origins, methods, headers, maxAge
+	})
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	withCORSSettings(t)
+	allowedOrigins = []string{"https://app.example.com", "https://*.example.org"}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(corsMiddleware())
+	r.POST("/interactions", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	r.GET("/books/:id", func(c *gin.Context) {
+		c.Header("ETag", `"1"`)
+		c.Status(http.StatusOK)
+	})
+
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/interactions", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := preflight("https://app.example.com")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Fatalf("expected the preflight allowed, got %d %v", w.Code, w.Header())
+	}
+	if !strings.Contains(w.Header().Get("Access-Control-Allow-Methods"), "POST") ||
+		!strings.Contains(w.Header().Get("Access-Control-Allow-Headers"), "Authorization") ||
+		w.Header().Get("Access-Control-Max-Age") != "600" {
+		t.Fatalf("unexpected preflight headers %v", w.Header())
+	}
+	if w := preflight("https://shop.example.org"); w.Code != http.StatusNoContent {
+		t.Fatalf("expected a subdomain allowed, got %d", w.Code)
+	}
+	for _, origin := range []string{"https://evil.example", "https://example.org"} {
+		if w := preflight(origin); w.Code != http.StatusForbidden {
+			t.Fatalf("expected %s refused, got %d", origin, w.Code)
+		}
+	}
+
+	// browser code can read the headers it needs
+	req := httptest.NewRequest(http.MethodGet, "/books/1", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("Access-Control-Expose-Headers"), "Etag") {
+		t.Fatalf("expected ETag exposed, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestLoadCORSSettings(t *testing.T) {
+	withCORSSettings(t)
+	t.Setenv("CORS_ALLOWED_ORIGINS", " https://a.example.com, ,https://b.example.com ")
+	t.Setenv("CORS_ALLOWED_METHODS", "")
+	t.Setenv("CORS_ALLOWED_HEADERS", "Authorization,Content-Type")
+	t.Setenv("CORS_MAX_AGE_SECONDS", "60")
+	methods := allowedMethods
+	loadCORSSettings()
+
+	if !reflect.DeepEqual(allowedOrigins, []string{"https://a.example.com", "https://b.example.com"}) ||
+		!reflect.DeepEqual(allowedHeaders, []string{"Authorization", "Content-Type"}) ||
+		!reflect.DeepEqual(allowedMethods, methods) || corsMaxAge != time.Minute {
+		t.Fatalf("unexpected settings %v %v %v %v", allowedOrigins, allowedMethods, allowedHeaders, corsMaxAge)
+	}
+}
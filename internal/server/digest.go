@@ -0,0 +1,112 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// digestOwner resolves :id and checks it is the caller
+func digestOwner(c *gin.Context) (int, bool) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return 0, false
+	}
+	if c.GetInt("auth_user_id") != userID {
+		abortWithError(c, forbidden("cannot change another user's digest"))
+		return 0, false
+	}
+	return userID, true
+}
+
+// SubscribeDigestHandler godoc
+// @Summary Opt in to the weekly recommendation email
+// @Description Issues a fresh unsubscribe token, invalidating links in earlier emails.
+// @Tags Users
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID; must be the caller"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/digest [post]
+func SubscribeDigestHandler(c *gin.Context) {
+	userID, ok := digestOwner(c)
+	if !ok {
+		return
+	}
+	token, err := newURLToken()
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if _, err := db.ExecContext(c.Request.Context(),
+		"UPDATE users SET email_digest = TRUE, digest_unsubscribe_token = ? WHERE id = ?", token, userID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"email_digest": true})
+}
+
+// UnsubscribeDigestHandler godoc
+// @Summary Opt out of the weekly recommendation email
+// @Tags Users
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID; must be the caller"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/digest [delete]
+func UnsubscribeDigestHandler(c *gin.Context) {
+	userID, ok := digestOwner(c)
+	if !ok {
+		return
+	}
+	if _, err := db.ExecContext(c.Request.Context(),
+		"UPDATE users SET email_digest = FALSE WHERE id = ?", userID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"email_digest": false})
+}
+
+// DigestTokenUnsubscribeHandler godoc
+// @Summary Unsubscribe from the digest with the link in the email
+// @Description No login needed. Also accepts POST for RFC 8058 one-click unsubscribe; repeating it is harmless.
+// @Tags Users
+// @Produce json
+// @Param token query string true "Unsubscribe token from the email"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /digest/unsubscribe [get]
+// @Router /digest/unsubscribe [post]
+func DigestTokenUnsubscribeHandler(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		abortWithError(c, badRequest("token is required"))
+		return
+	}
+	// tokens are unique across organizations, so no tenant filter here
+	var userID int
+	err := db.QueryRowContext(c.Request.Context(),
+		"SELECT id FROM users WHERE digest_unsubscribe_token = ?", token).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithError(c, notFound("unknown or expired unsubscribe link"))
+			return
+		}
+		abortWithError(c, err)
+		return
+	}
+	if _, err := db.ExecContext(c.Request.Context(),
+		"UPDATE users SET email_digest = FALSE WHERE id = ?", userID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"unsubscribed": true})
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSubscribeDigestHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectExec("UPDATE users SET email_digest = TRUE, digest_unsubscribe_token = \\? WHERE id = \\?").
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/users/:id/digest", asUser(1), SubscribeDigestHandler)
+
+	for _, tc := range []struct {
+		path string
+		want int
+	}{
+		{"/users/1/digest", http.StatusOK},
+		{"/users/2/digest", http.StatusForbidden},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.path, nil))
+		if w.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d: %s", tc.path, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestDigestTokenUnsubscribeHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT id FROM users WHERE digest_unsubscribe_token = \\?").
+		WithArgs("good").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectExec("UPDATE users SET email_digest = FALSE WHERE id = \\?").
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id FROM users WHERE digest_unsubscribe_token = \\?").
+		WithArgs("stale").
+		WillReturnError(sql.ErrNoRows)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/digest/unsubscribe", DigestTokenUnsubscribeHandler)
+
+	for _, tc := range []struct {
+		query string
+		want  int
+	}{
+		{"?token=good", http.StatusOK},
+		{"?token=stale", http.StatusNotFound},
+		{"", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/digest/unsubscribe"+tc.query, nil))
+		if w.Code != tc.want {
+			t.Fatalf("%q: expected %d, got %d: %s", tc.query, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
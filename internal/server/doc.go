@@ -0,0 +1,13 @@
+// Package server is the HTTP API that `bookrec serve` runs: routing and
+// middleware (server.go), then one file per feature with its handlers, its
+// SQL and, alongside, its sqlmock tests. Users, books and interactions are
+// resolved and loaded through records, the store.Store Run hands the
+// package, and the handlers that need nothing else live in
+// internal/handlers, which also defines the error envelope. Feature queries
+// read and write through the package-level *sql.DB; code that must also run
+// inside a transaction (the recommender under `bookrec evaluate`) takes a
+// querier instead. Scoping and soft deletes come from internal/tenant and
+// internal/softdelete, and anything a job or the GraphQL layer shares lives
+// in its own internal package. The integration suite (build tag
+// integration) runs every route against MySQL.
+package server
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/handlers"
+)
+
+// ErrorResponse and AppError are the error envelope, defined in
+// internal/handlers so handlers outside this package answer the same way
+type ErrorResponse = handlers.ErrorResponse // @name ErrorResponse
+
+type AppError = handlers.AppError
+
+func newAppError(status int, message string) *AppError { return handlers.NewAppError(status, message) }
+
+func badRequest(message string) *AppError   { return handlers.BadRequest(message) }
+func unauthorized(message string) *AppError { return handlers.Unauthorized(message) }
+func forbidden(message string) *AppError    { return handlers.Forbidden(message) }
+func notFound(message string) *AppError     { return handlers.NotFound(message) }
+func conflict(message string) *AppError     { return handlers.Conflict(message) }
+
+func errorBody(err error) ErrorResponse        { return handlers.ErrorBody(err) }
+func abortWithError(c *gin.Context, err error) { handlers.AbortWithError(c, err) }
+
+// ErrorMiddleware answers requests whose handlers recorded an error with
+// c.Error but wrote nothing
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		c.AbortWithStatusJSON(handlers.AsAppError(err).Status, errorBody(err))
+	}
+}
+
+// recoverWithError answers a panicking request with a 500 envelope
+func recoverWithError(c *gin.Context, recovered interface{}) {
+	abortWithError(c, fmt.Errorf("panic: %v", recovered))
+}
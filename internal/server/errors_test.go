@@ -0,0 +1,41 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrorMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.CustomRecovery(recoverWithError), ErrorMiddleware())
+	r.GET("/recorded", func(c *gin.Context) { _ = c.Error(notFound("book not found")) })
+	r.GET("/written", func(c *gin.Context) {
+		_ = c.Error(errors.New("logged only"))
+		c.JSON(200, gin.H{"ok": true})
+	})
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	get := func(path string) (*httptest.ResponseRecorder, ErrorResponse) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var body ErrorResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return w, body
+	}
+	if w, body := get("/recorded"); w.Code != 404 || body.Code != "not_found" || body.Message != "book not found" {
+		t.Fatalf("expected the recorded 404, got %d %s", w.Code, w.Body.String())
+	}
+	if w, _ := get("/written"); w.Code != 200 {
+		t.Fatalf("expected the handler's own response, got %d %s", w.Code, w.Body.String())
+	}
+	if w, body := get("/panic"); w.Code != 500 || body.Code != "internal_server_error" || strings.Contains(w.Body.String(), "boom") {
+		t.Fatalf("expected a generic 500 for a panic, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+)
+
+// EvaluationOptions picks the readers an evaluation runs on
+type EvaluationOptions struct {
+	Readers  int    // how many readers to sample
+	MinLikes int    // readers need at least this many likes, one of which is held out
+	Seed     int64  // the same seed samples the same readers
+	Mode     string // recommender to evaluate: collaborative (default) or content
+}
+
+// EvaluationReport is how well recommendations predict a reader's next like
+type EvaluationReport struct {
+	Readers  int     `json:"readers"`
+	Hits     int     `json:"hits"`
+	HitRate  float64 `json:"hit_rate"`
+	MRR      float64 `json:"mrr"`
+	Empty    int     `json:"empty"`
+	Coverage float64 `json:"coverage"`
+}
+
+// Evaluate measures the recommender offline by leaving one out. Each sampled
+// reader's most recent like is hidden and their recommendations are computed
+// exactly as GET /recommendations does in opts.Mode (hybrid with the
+// HYBRID_WEIGHT_* defaults); a hit is the hidden book coming back in the top
+// 10. MRR also rewards ranking it higher, Empty counts readers who got no
+// recommendations, and Coverage is the share of the catalogue that was
+// recommended to anyone. The holdout is a soft delete in a transaction that's
+// always rolled back, so nothing is changed.
+func Evaluate(ctx context.Context, database *sql.DB, opts EvaluationOptions) (EvaluationReport, error) {
+	report := EvaluationReport{}
+	mode, err := parseRecommendMode(opts.Mode)
+	if err != nil {
+		return report, err
+	}
+	loadHybridWeights()
+	rows, err := database.QueryContext(ctx, `
+		SELECT user_id
+		FROM interactions
+		WHERE action = 'like' AND deleted_at IS NULL
+		GROUP BY user_id
+		HAVING COUNT(*) >= ?
+		ORDER BY RAND(?)
+		LIMIT ?`, opts.MinLikes, opts.Seed, opts.Readers)
+	if err != nil {
+		return report, err
+	}
+	readers := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return report, err
+		}
+		readers = append(readers, id)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	recommended := map[int]bool{}
+	reciprocalRanks := 0.0
+	for _, userID := range readers {
+		rank, recs, err := holdOutRank(ctx, database, userID, mode)
+		if err != nil {
+			return report, err
+		}
+		report.Readers++
+		if recs == nil {
+			report.Empty++
+		}
+		for _, bookID := range recs {
+			recommended[bookID] = true
+		}
+		if rank > 0 {
+			report.Hits++
+			reciprocalRanks += 1 / float64(rank)
+		}
+	}
+
+	var books int
+	if err := database.QueryRowContext(ctx, "SELECT COUNT(*) FROM books WHERE deleted_at IS NULL").Scan(&books); err != nil {
+		return report, err
+	}
+	if report.Readers > 0 {
+		report.HitRate = float64(report.Hits) / float64(report.Readers)
+		report.MRR = reciprocalRanks / float64(report.Readers)
+	}
+	if books > 0 {
+		report.Coverage = float64(len(recommended)) / float64(books)
+	}
+	return report, nil
+}
+
+// holdOutRank hides userID's most recent like and returns where that book
+// ranks in their recommendations (0 when it's missing) and the book ids
+// recommended
+func holdOutRank(ctx context.Context, database *sql.DB, userID int, mode string) (int, []int, error) {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var heldOut int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT book_id FROM interactions
+		WHERE user_id = ? AND action = 'like' AND deleted_at IS NULL
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`, userID).Scan(&heldOut); err != nil {
+		return 0, nil, err
+	}
+	// every interaction with the book, and its shelf, or the recommender
+	// skips it as known
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE interactions SET deleted_at = CURRENT_TIMESTAMP WHERE user_id = ? AND book_id = ? AND deleted_at IS NULL",
+		userID, heldOut); err != nil {
+		return 0, nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM shelf_books WHERE user_id = ? AND book_id = ?", userID, heldOut); err != nil {
+		return 0, nil, err
+	}
+
+	recs, err := recommendFor(ctx, tx, userID, mode, bookFilters{}, defaultHybridWeights)
+	if err != nil {
+		return 0, nil, err
+	}
+	rank := 0
+	var bookIDs []int
+	for i, rec := range recs {
+		bookID, _ := rec["book_id"].(int)
+		bookIDs = append(bookIDs, bookID)
+		if bookID == heldOut && rank == 0 {
+			rank = i + 1
+		}
+	}
+	return rank, bookIDs, nil
+}
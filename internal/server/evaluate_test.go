@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/YeswanthC7/bookrec/internal/recommend"
+)
+
+func TestEvaluate(t *testing.T) {
+	database, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	recCols := []string{"id", "uuid", "slug", "title", "author", "page_count", "score", "cover_id"}
+	prefCols := []string{"avoid_content_warnings", "max_audience_rating"}
+	mock.ExpectQuery("SELECT user_id\\s+FROM interactions[\\s\\S]+HAVING COUNT\\(\\*\\) >= \\?\\s+ORDER BY RAND\\(\\?\\)").
+		WithArgs(3, int64(7), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1).AddRow(2))
+
+	// reader 1's held-out book comes back second
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT book_id FROM interactions\\s+WHERE user_id = \\? AND action = 'like'").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id"}).AddRow(40))
+	mock.ExpectExec("UPDATE interactions SET deleted_at = CURRENT_TIMESTAMP WHERE user_id = \\? AND book_id = \\?").
+		WithArgs(1, 40).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM shelf_books WHERE user_id = \\? AND book_id = \\?").
+		WithArgs(1, 40).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT avoid_content_warnings, max_audience_rating FROM users").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(prefCols).AddRow("", nil))
+	mock.ExpectQuery("FROM interactions i\\s+JOIN interactions j").
+		WithArgs(1, recommend.Neighbors, 1, 1).
+		WillReturnRows(sqlmock.NewRows(recCols).
+			AddRow(41, "b-41", "dune", "Dune", "Frank Herbert", 412, 5, nil).
+			AddRow(40, "b-40", "emma", "Emma", "Jane Austen", 474, 3, nil))
+	mock.ExpectRollback()
+
+	// reader 2 gets nothing
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT book_id FROM interactions").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id"}).AddRow(41))
+	mock.ExpectExec("UPDATE interactions SET deleted_at").
+		WithArgs(2, 41).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM shelf_books").
+		WithArgs(2, 41).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT avoid_content_warnings, max_audience_rating FROM users").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(prefCols).AddRow("", nil))
+	mock.ExpectQuery("FROM interactions i\\s+JOIN interactions j").
+		WithArgs(2, recommend.Neighbors, 2, 2).
+		WillReturnRows(sqlmock.NewRows(recCols))
+	mock.ExpectRollback()
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM books WHERE deleted_at IS NULL").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+
+	report, err := Evaluate(context.Background(), database, EvaluationOptions{Readers: 2, MinLikes: 3, Seed: 7})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	want := EvaluationReport{Readers: 2, Hits: 1, HitRate: 0.5, MRR: 0.25, Empty: 1, Coverage: 0.2}
+	if report != want {
+		t.Fatalf("expected %+v, got %+v", want, report)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
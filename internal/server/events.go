@@ -0,0 +1,81 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types published on the in-process bus
+const (
+	EventInteractionCreated = "interaction.created"
+	EventTrendingEntered    = "trending.entered"
+)
+
+// Event is a domain event fanned out to live subscribers (websockets, SSE, ...)
+type Event struct {
+	Type string                 `json:"type"`
+	Data map[string]interface{} `json:"data"`
+	At   time.Time              `json:"at"`
+}
+
+// eventBus is a small in-process pub/sub. Publishing never blocks: a
+// subscriber that can't keep up misses events rather than stalling writers.
+type eventBus struct {
+	mu   sync.RWMutex
+	subs map[chan Event]struct{}
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subs: map[chan Event]struct{}{}}
+}
+
+// events is the process-wide bus handlers publish to
+var events = newEventBus()
+
+// Subscribe returns a buffered channel of events and a func to unsubscribe.
+func (b *eventBus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// internalEventKeys are the integer ids events carry for in-process
+// subscribers; publicEventData drops them before an event leaves the server
+var internalEventKeys = []string{"user_id", "book_id", "organization_id"}
+
+// publicEventData is data as websocket listeners and webhooks see it
+func publicEventData(data map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		out[k] = v
+	}
+	for _, k := range internalEventKeys {
+		delete(out, k)
+	}
+	return out
+}
+
+// Publish delivers ev to every subscriber with room in its buffer.
+func (b *eventBus) Publish(eventType string, data map[string]interface{}) {
+	ev := Event{Type: eventType, Data: data, At: time.Now().UTC()}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
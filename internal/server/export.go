@@ -0,0 +1,274 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// exportFlushEvery controls how often buffered export rows are flushed to the client
+const exportFlushEvery = 500
+
+// exportWriter writes rows as CSV or JSON Lines straight to the response,
+// flushing periodically so large exports never sit in memory.
+type exportWriter struct {
+	c       *gin.Context
+	format  string
+	columns []string
+	csv     *csv.Writer
+	json    *json.Encoder
+	rows    int
+}
+
+func parseExportFormat(c *gin.Context) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", "csv")))
+	if format != "csv" && format != "jsonl" {
+		return "", fmt.Errorf("format must be csv or jsonl")
+	}
+	return format, nil
+}
+
+func newExportWriter(c *gin.Context, format, name string, columns []string) *exportWriter {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102T150405Z"), format)
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	w := &exportWriter{c: c, format: format, columns: columns}
+	if format == "csv" {
+		w.csv = csv.NewWriter(c.Writer)
+		_ = w.csv.Write(columns)
+	} else {
+		w.json = json.NewEncoder(c.Writer)
+	}
+	return w
+}
+
+// Write emits one record; values must line up with columns
+func (w *exportWriter) Write(values []interface{}) error {
+	var err error
+	if w.format == "csv" {
+		record := make([]string, len(values))
+		for i, v := range values {
+			record[i] = csvValue(v)
+		}
+		err = w.csv.Write(record)
+	} else {
+		obj := make(map[string]interface{}, len(values))
+		for i, v := range values {
+			obj[w.columns[i]] = v
+		}
+		err = w.json.Encode(obj)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.rows++
+	if w.rows%exportFlushEvery == 0 {
+		w.Flush()
+	}
+	return nil
+}
+
+func (w *exportWriter) Flush() {
+	if w.csv != nil {
+		w.csv.Flush()
+	}
+	w.c.Writer.Flush()
+}
+
+func csvValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case time.Time:
+		return t.UTC().Format(time.RFC3339)
+	case []string:
+		return strings.Join(t, "|")
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// parseExportTime accepts RFC3339 or YYYY-MM-DD. Date-only upper bounds
+// cover the whole day.
+func parseExportTime(raw string, endOfDay bool) (time.Time, bool, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, false, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date %q (use YYYY-MM-DD or RFC3339)", raw)
+	}
+	if endOfDay {
+		t = t.Add(24 * time.Hour)
+	}
+	return t, true, nil
+}
+
+// ExportInteractionsHandler godoc
+// @Summary Export interactions (CSV or JSON Lines, streamed)
+// @Tags Admin
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param Authorization header string true "Bearer token"
+// @Param format query string false "csv (default) | jsonl"
+// @Param from query string false "Created at or after (YYYY-MM-DD or RFC3339)"
+// @Param to query string false "Created before (RFC3339) or on (YYYY-MM-DD)"
+// @Success 200 {string} string "export file"
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/export/interactions [get]
+func ExportInteractionsHandler(c *gin.Context) {
+	format, err := parseExportFormat(c)
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	from, hasFrom, err := parseExportTime(c.Query("from"), false)
+	if err != nil {
+		abortWithError(c, badRequest("from: "+err.Error()))
+		return
+	}
+	to, hasTo, err := parseExportTime(c.Query("to"), true)
+	if err != nil {
+		abortWithError(c, badRequest("to: "+err.Error()))
+		return
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(`
+		SELECT id, uuid, user_id, book_id, action, rating, created_at
+		FROM interactions
+		WHERE organization_id = ? AND deleted_at IS NULL`)
+	args := []interface{}{tenant.ID(c.Request.Context())}
+	if hasFrom {
+		sb.WriteString(" AND created_at >= ?")
+		args = append(args, from)
+	}
+	if hasTo {
+		sb.WriteString(" AND created_at < ?")
+		args = append(args, to)
+	}
+	sb.WriteString(" ORDER BY id")
+
+	rows, err := db.QueryContext(c.Request.Context(), sb.String(), args...)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	w := newExportWriter(c, format, "interactions",
+		[]string{"id", "uuid", "user_id", "book_id", "action", "rating", "created_at"})
+	defer w.Flush()
+
+	for rows.Next() {
+		var id, userID, bookID int
+		var publicID, action string
+		var rating sql.NullInt64
+		var createdAt time.Time
+		if err := rows.Scan(&id, &publicID, &userID, &bookID, &action, &rating, &createdAt); err != nil {
+			// headers are already sent; the truncated file is the signal
+			log.Printf("⚠️ interactions export aborted: %v", err)
+			return
+		}
+
+		var ratingValue interface{}
+		if rating.Valid {
+			ratingValue = rating.Int64
+		}
+		if err := w.Write([]interface{}{id, publicID, userID, bookID, action, ratingValue, createdAt.UTC()}); err != nil {
+			log.Printf("⚠️ interactions export aborted: %v", err)
+			return
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("⚠️ interactions export aborted: %v", err)
+	}
+}
+
+// ExportBooksHandler godoc
+// @Summary Export the catalogue visible to the organization (CSV or JSON Lines, streamed)
+// @Description Subjects are flattened to a "|"-separated string in CSV and kept as an array in JSONL.
+// @Tags Admin
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param Authorization header string true "Bearer token"
+// @Param format query string false "csv (default) | jsonl"
+// @Success 200 {string} string "export file"
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/export/books [get]
+func ExportBooksHandler(c *gin.Context) {
+	format, err := parseExportFormat(c)
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+
+	rows, err := db.QueryContext(c.Request.Context(), `
+		SELECT id, uuid, slug, open_library_key, title, author, published_year, subjects, popularity_score, created_at
+		FROM books
+		WHERE `+tenant.BooksVisibleSQL("")+`
+		ORDER BY id`, tenant.ID(c.Request.Context()))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	w := newExportWriter(c, format, "books",
+		[]string{"id", "uuid", "slug", "open_library_key", "title", "author", "published_year", "subjects", "popularity_score", "created_at"})
+	defer w.Flush()
+
+	for rows.Next() {
+		var id int
+		var publicID, slug, title string
+		var olKey, author, subjectsJSON sql.NullString
+		var year sql.NullInt64
+		var popularity sql.NullFloat64
+		var createdAt time.Time
+		if err := rows.Scan(&id, &publicID, &slug, &olKey, &title, &author, &year, &subjectsJSON, &popularity, &createdAt); err != nil {
+			log.Printf("⚠️ books export aborted: %v", err)
+			return
+		}
+
+		subjects := []string{}
+		if subjectsJSON.Valid && subjectsJSON.String != "" {
+			_ = json.Unmarshal([]byte(subjectsJSON.String), &subjects)
+		}
+		var yearValue interface{}
+		if year.Valid {
+			yearValue = year.Int64
+		}
+
+		if err := w.Write([]interface{}{
+			id, publicID, slug, olKey.String, title, author.String, yearValue, subjects, popularity.Float64, createdAt.UTC(),
+		}); err != nil {
+			log.Printf("⚠️ books export aborted: %v", err)
+			return
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("⚠️ books export aborted: %v", err)
+	}
+}
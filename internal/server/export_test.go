@@ -0,0 +1,146 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestExportInteractionsHandler_CSV(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC) // date-only "to" covers the whole day
+	at := time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM interactions\\s+WHERE organization_id = \\? AND deleted_at IS NULL AND created_at >= \\? AND created_at < \\? ORDER BY id").
+		WithArgs(1, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "user_id", "book_id", "action", "rating", "created_at"}).
+			AddRow(1, "u-1", 2, 3, "like", nil, at).
+			AddRow(2, "u-2", 2, 4, "rating", 5, at))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin/export/interactions", ExportInteractionsHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/export/interactions?from=2024-01-01&to=2024-01-31", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+	want := "id,uuid,user_id,book_id,action,rating,created_at\n" +
+		"1,u-1,2,3,like,,2024-01-15T09:30:00Z\n" +
+		"2,u-2,2,4,rating,5,2024-01-15T09:30:00Z\n"
+	if w.Body.String() != want {
+		t.Fatalf("unexpected csv:\n%s", w.Body.String())
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), "interactions-") {
+		t.Fatalf("missing attachment filename: %q", w.Header().Get("Content-Disposition"))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestExportInteractionsHandler_JSONL(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("FROM interactions\\s+WHERE organization_id = \\? AND deleted_at IS NULL ORDER BY id").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "user_id", "book_id", "action", "rating", "created_at"}).
+			AddRow(1, "u-1", 2, 3, "view", nil, time.Now()))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin/export/interactions", ExportInteractionsHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/export/interactions?format=jsonl", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	scanner := bufio.NewScanner(w.Body)
+	lines := 0
+	for scanner.Scan() {
+		var row map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatalf("invalid json line %q: %v", scanner.Text(), err)
+		}
+		if row["action"] != "view" || row["rating"] != nil {
+			t.Fatalf("unexpected row: %v", row)
+		}
+		lines++
+	}
+	if lines != 1 {
+		t.Fatalf("expected 1 line, got %d", lines)
+	}
+}
+
+func TestExportInteractionsHandler_BadInput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin/export/interactions", ExportInteractionsHandler)
+
+	for _, q := range []string{"format=xlsx", "from=yesterday"} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/export/interactions?"+q, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
+
+func TestExportBooksHandler_FlattensSubjects(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	at := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT id, uuid, slug, open_library_key, title, author, published_year, subjects, popularity_score, created_at\\s+FROM books").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "open_library_key", "title", "author", "published_year", "subjects", "popularity_score", "created_at"}).
+			AddRow(1, "b-1", "dune-b1", "/works/OL1W", "Dune", "Frank Herbert", 1965, `["Science fiction","Deserts"]`, 0.0, at).
+			AddRow(2, "b-2", "notes-vol-1-b2", nil, "Notes, Vol. 1", nil, nil, nil, nil, at))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin/export/books", ExportBooksHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/export/books", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	want := "id,uuid,slug,open_library_key,title,author,published_year,subjects,popularity_score,created_at\n" +
+		"1,b-1,dune-b1,/works/OL1W,Dune,Frank Herbert,1965,Science fiction|Deserts,0,2024-03-01T00:00:00Z\n" +
+		"2,b-2,notes-vol-1-b2,,\"Notes, Vol. 1\",,,,0,2024-03-01T00:00:00Z\n"
+	if w.Body.String() != want {
+		t.Fatalf("unexpected csv:\n%s", w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,294 @@
+package server
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// feedSize is the number of items per feed
+const feedSize = 50
+
+// feedItem is the format-neutral entry rendered as RSS or Atom
+type feedItem struct {
+	UUID      string
+	Title     string
+	Author    string
+	Year      int
+	Link      string
+	Published time.Time
+	Summary   string
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	NS      string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string     `xml:"title"`
+	ID      string     `xml:"id"`
+	Updated string     `xml:"updated"`
+	Links   []atomLink `xml:"link"`
+	Author  atomAuthor `xml:"author"`
+	Summary string     `xml:"summary"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// NewBooksFeedHandler godoc
+// @Summary Feed of newly added books (RSS 2.0 or Atom)
+// @Tags Feeds
+// @Produce xml
+// @Param genre query string false "Only books in this genre (a slug from GET /genres)"
+// @Param format query string false "rss (default) | atom"
+// @Success 200 {string} string "feed XML"
+// @Failure 400 {object} ErrorResponse
+// @Router /feeds/new.xml [get]
+func NewBooksFeedHandler(c *gin.Context) {
+	genre, err := parseGenre(c)
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	items, err := loadNewBookItems(c.Request.Context(), genre)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	title := "BookRec – New books"
+	if genre != "" {
+		title += " in " + genre
+	}
+	writeFeed(c, title, "Books most recently added to the BookRec catalogue", items)
+}
+
+// TrendingBooksFeedHandler godoc
+// @Summary Feed of trending books (most liked yesterday and today; RSS 2.0 or Atom)
+// @Tags Feeds
+// @Produce xml
+// @Param genre query string false "Only books in this genre (a slug from GET /genres)"
+// @Param format query string false "rss (default) | atom"
+// @Success 200 {string} string "feed XML"
+// @Failure 400 {object} ErrorResponse
+// @Router /feeds/trending.xml [get]
+func TrendingBooksFeedHandler(c *gin.Context) {
+	genre, err := parseGenre(c)
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	books, err := loadTrendingBooks(c.Request.Context(), genre, feedSize)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	items := make([]feedItem, 0, len(books))
+	for rank, b := range books {
+		items = append(items, feedItem{
+			UUID:      b.UUID,
+			Title:     fmt.Sprintf("#%d %s", rank+1, b.Title),
+			Author:    b.Author,
+			Link:      bookLink("", b.Slug),
+			Published: now,
+			Summary:   fmt.Sprintf("%s by %s – %d likes since yesterday", b.Title, b.Author, b.Likes),
+		})
+	}
+
+	title := "BookRec – Trending books"
+	if genre != "" {
+		title += " in " + genre
+	}
+	writeFeed(c, title, "Most liked BookRec books since yesterday", items)
+}
+
+func loadNewBookItems(ctx context.Context, genre string) ([]feedItem, error) {
+	sb := strings.Builder{}
+	sb.WriteString(`
+		SELECT b.uuid, b.slug, b.title, COALESCE(b.author, ''), COALESCE(b.published_year, 0),
+		       COALESCE(b.open_library_key, ''), b.created_at
+		FROM books b
+		WHERE ` + tenant.BooksVisibleSQL("b"))
+	args := []interface{}{tenant.ID(ctx)}
+	if genre != "" {
+		genreSQL, genreArgs := genreFilterSQL("b.id", genre)
+		sb.WriteString(genreSQL)
+		args = append(args, genreArgs...)
+	}
+	sb.WriteString(" ORDER BY b.created_at DESC, b.id DESC LIMIT ?")
+	args = append(args, feedSize)
+
+	rows, err := db.QueryContext(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	items := []feedItem{}
+	for rows.Next() {
+		var it feedItem
+		var slug, olKey string
+		if err := rows.Scan(&it.UUID, &slug, &it.Title, &it.Author, &it.Year, &olKey, &it.Published); err != nil {
+			return nil, err
+		}
+		it.Link = bookLink(olKey, slug)
+		it.Summary = it.Title
+		if it.Author != "" {
+			it.Summary += " by " + it.Author
+		}
+		if it.Year > 0 {
+			it.Summary += fmt.Sprintf(" (%d)", it.Year)
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// requestBaseURL reconstructs scheme://host for absolute feed links
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// bookLink prefers the Open Library work page, falling back to our own
+// slug URL (relative; writeFeed makes it absolute)
+func bookLink(olKey, slug string) string {
+	if olKey != "" {
+		return "https://openlibrary.org" + olKey
+	}
+	return "/books/" + url.PathEscape(slug)
+}
+
+func writeFeed(c *gin.Context, title, description string, items []feedItem) {
+	format := strings.ToLower(c.DefaultQuery("format", "rss"))
+	if format != "rss" && format != "atom" {
+		abortWithError(c, badRequest("format must be rss or atom"))
+		return
+	}
+
+	for i := range items {
+		if strings.HasPrefix(items[i].Link, "/") {
+			items[i].Link = requestBaseURL(c) + items[i].Link
+		}
+	}
+
+	self := requestBaseURL(c) + c.Request.URL.RequestURI()
+	updated := time.Now().UTC()
+	if len(items) > 0 {
+		updated = items[0].Published.UTC()
+	}
+
+	var doc interface{}
+	contentType := "application/rss+xml; charset=utf-8"
+	if format == "atom" {
+		contentType = "application/atom+xml; charset=utf-8"
+		feed := atomFeed{
+			NS:      "http://www.w3.org/2005/Atom",
+			Title:   title,
+			ID:      self,
+			Updated: updated.Format(time.RFC3339),
+			Links:   []atomLink{{Href: self, Rel: "self", Type: "application/atom+xml"}},
+		}
+		for _, it := range items {
+			feed.Entries = append(feed.Entries, atomEntry{
+				Title:   it.Title,
+				ID:      "urn:uuid:" + it.UUID,
+				Updated: it.Published.UTC().Format(time.RFC3339),
+				Links:   []atomLink{{Href: it.Link, Rel: "alternate"}},
+				Author:  atomAuthor{Name: it.Author},
+				Summary: it.Summary,
+			})
+		}
+		doc = feed
+	} else {
+		feed := rssFeed{
+			Version: "2.0",
+			AtomNS:  "http://www.w3.org/2005/Atom",
+			Channel: rssChannel{
+				Title:         title,
+				Link:          requestBaseURL(c),
+				Description:   description,
+				LastBuildDate: updated.Format(time.RFC1123Z),
+				AtomLink:      atomLink{Href: self, Rel: "self", Type: "application/rss+xml"},
+			},
+		}
+		for _, it := range items {
+			feed.Channel.Items = append(feed.Channel.Items, rssItem{
+				Title:       it.Title,
+				Link:        it.Link,
+				Description: it.Summary,
+				GUID:        rssGUID{IsPermaLink: "false", Value: "urn:uuid:" + it.UUID},
+				PubDate:     it.Published.UTC().Format(time.RFC1123Z),
+			})
+		}
+		doc = feed
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		abortWithError(c, newAppError(500, "failed to render feed"))
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, contentType, append([]byte(xml.Header), out...))
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewBooksFeedHandler_RSS(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	added := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM books b\\s+WHERE \\(\\(b.organization_id IS NULL OR b.organization_id = \\?\\) AND b.deleted_at IS NULL\\) AND EXISTS \\(SELECT 1 FROM book_genres bg JOIN genres g ON g.id = bg.genre_id WHERE bg.book_id = b.id AND g.slug = \\?\\)").
+		WithArgs(1, "fantasy", feedSize).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "slug", "title", "author", "published_year", "open_library_key", "created_at"}).
+			AddRow("b-3", "the-hobbit-b3", "The Hobbit", "J.R.R. Tolkien", 1937, "/works/OL262758W", added).
+			AddRow("b-2", "untitled-co-b2", "Untitled & Co", "", 0, "", added))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/feeds/new.xml", NewBooksFeedHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/feeds/new.xml?genre=Fantasy", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/rss+xml") {
+		t.Fatalf("unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+
+	var feed rssFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("invalid xml: %v", err)
+	}
+	items := feed.Channel.Items
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	if items[0].Link != "https://openlibrary.org/works/OL262758W" || items[0].Description != "The Hobbit by J.R.R. Tolkien (1937)" {
+		t.Fatalf("unexpected first item: %+v", items[0])
+	}
+	if items[1].Link != "http://example.com/books/untitled-co-b2" || items[1].GUID.Value != "urn:uuid:b-2" {
+		t.Fatalf("expected slug fallback link and uuid guid, got %+v", items[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestTrendingBooksFeedHandler_Atom(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	mock.ExpectQuery("FROM book_daily_stats\\s+WHERE organization_id = \\? AND day = \\?.+UNION ALL.+FROM interactions").
+		WithArgs(1, yesterday, 1, sqlmock.AnyArg(), feedSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "likes"}).
+			AddRow(5, "b-5", "dune-b5", "Dune", "Frank Herbert", 12))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/feeds/trending.xml", TrendingBooksFeedHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/feeds/trending.xml?format=atom", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+
+	var feed atomFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("invalid xml: %v", err)
+	}
+	if len(feed.Entries) != 1 || feed.Entries[0].Title != "#1 Dune" || feed.Entries[0].Author.Name != "Frank Herbert" {
+		t.Fatalf("unexpected entries: %+v", feed.Entries)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+)
+
+// FollowUserHandler godoc
+// @Summary Follow a user
+// @Description Idempotent: following someone you already follow returns 200 instead of 201. 403 if either user has blocked the other.
+// @Tags Social
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID to follow"
+// @Success 201 {object} map[string]interface{}
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/follow [post]
+func FollowUserHandler(c *gin.Context) {
+	followeeID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+	followerID := c.GetInt("auth_user_id")
+	if followerID <= 0 {
+		abortWithError(c, unauthorized("unauthorized"))
+		return
+	}
+	if followerID == followeeID {
+		abortWithError(c, badRequest("cannot follow yourself"))
+		return
+	}
+	blocked, err := eitherBlocked(c.Request.Context(), followerID, followeeID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if blocked {
+		abortWithError(c, forbidden("cannot follow a user you have blocked or who has blocked you"))
+		return
+	}
+
+	res, err := db.ExecContext(c.Request.Context(),
+		"INSERT IGNORE INTO follows (follower_id, followee_id) VALUES (?, ?)", followerID, followeeID)
+	if err != nil {
+		if dberr.Is(err, dberr.ErrMissingReference) {
+			abortWithError(c, notFound("user not found"))
+			return
+		}
+		abortWithError(c, err)
+		return
+	}
+
+	refs, err := publicRefs(c.Request.Context(), db, "users", followerID, followeeID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	status := 200
+	if n, _ := res.RowsAffected(); n > 0 {
+		status = 201
+	}
+	c.JSON(status, gin.H{
+		"follower_uuid": refs[0],
+		"followee_uuid": refs[1],
+		"following":     true,
+	})
+}
+
+// UnfollowUserHandler godoc
+// @Summary Unfollow a user
+// @Description Idempotent: unfollowing someone you don't follow also returns 204.
+// @Tags Social
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID to unfollow"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/follow [delete]
+func UnfollowUserHandler(c *gin.Context) {
+	followeeID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+	followerID := c.GetInt("auth_user_id")
+	if followerID <= 0 {
+		abortWithError(c, unauthorized("unauthorized"))
+		return
+	}
+
+	if _, err := db.ExecContext(c.Request.Context(),
+		"DELETE FROM follows WHERE follower_id = ? AND followee_id = ?", followerID, followeeID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// ListFollowersHandler godoc
+// @Summary Users who follow a user (newest first)
+// @Tags Social
+// @Produce json
+// @Param id path string true "User UUID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/followers [get]
+func ListFollowersHandler(c *gin.Context) {
+	listFollows(c, "followee_id", "follower_id")
+}
+
+// ListFollowingHandler godoc
+// @Summary Users a user follows (newest first)
+// @Tags Social
+// @Produce json
+// @Param id path string true "User UUID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/following [get]
+func ListFollowingHandler(c *gin.Context) {
+	listFollows(c, "follower_id", "followee_id")
+}
+
+// listFollows pages through follows rows where matchColumn is the path user,
+// returning the users in listColumn. Emails are never exposed here.
+func listFollows(c *gin.Context, matchColumn, listColumn string) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	var total int
+	if err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM follows WHERE "+matchColumn+" = ?", userID).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT u.uuid, u.handle, f.created_at
+		FROM follows f
+		JOIN users u ON u.id = f.`+listColumn+`
+		WHERE f.`+matchColumn+` = ?
+		ORDER BY f.created_at DESC, u.id DESC
+		LIMIT ? OFFSET ?`, userID, limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	users := []map[string]interface{}{}
+	for rows.Next() {
+		var publicID, handle, followedAt string
+		if err := rows.Scan(&publicID, &handle, &followedAt); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		users = append(users, gin.H{
+			"uuid":        publicID,
+			"handle":      handle,
+			"followed_at": followedAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  users,
+	})
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+// asUser stands in for AuthMiddleware in handler tests
+func asUser(id int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("auth_user_id", id)
+		c.Set("auth_role", "user")
+		c.Next()
+	}
+}
+
+func TestFollowUserHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectNotBlocked := func(other int, blocked bool) {
+		rows := sqlmock.NewRows([]string{"1"})
+		if blocked {
+			rows.AddRow(1)
+		}
+		mock.ExpectQuery("SELECT 1 FROM user_blocks WHERE \\(blocker_id = \\? AND blocked_id = \\?\\) OR").
+			WithArgs(1, other, other, 1).
+			WillReturnRows(rows)
+	}
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	expectNotBlocked(2, false)
+	mock.ExpectExec("INSERT IGNORE INTO follows \\(follower_id, followee_id\\) VALUES \\(\\?, \\?\\)").
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectPublicRef(mock, "users", 1, "u-1")
+	expectPublicRef(mock, "users", 2, "u-2")
+	// second follow is a no-op
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	expectNotBlocked(2, false)
+	mock.ExpectExec("INSERT IGNORE INTO follows").
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectPublicRef(mock, "users", 1, "u-1")
+	expectPublicRef(mock, "users", 2, "u-2")
+	// a block either way stops the follow
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	expectNotBlocked(3, true)
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/users/:id/follow", asUser(1), FollowUserHandler)
+
+	for _, tc := range []struct {
+		path string
+		want int
+	}{
+		{"/users/2/follow", http.StatusCreated},
+		{"/users/2/follow", http.StatusOK},
+		{"/users/3/follow", http.StatusForbidden},
+		{"/users/1/follow", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.path, nil))
+		if w.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d: %s", tc.path, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestListFollowersHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM follows WHERE followee_id = \\?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("JOIN users u ON u.id = f.follower_id\\s+WHERE f.followee_id = \\?").
+		WithArgs(2, 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "handle", "created_at"}).
+			AddRow("u-1", "alice", "2026-10-01 12:00:00"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users/:id/followers", ListFollowersHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/2/followers", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var body struct {
+		Total int              `json:"total"`
+		Data  []map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if body.Total != 1 || len(body.Data) != 1 || body.Data[0]["handle"] != "alice" {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+	if _, leaked := body.Data[0]["email"]; leaked {
+		t.Fatalf("follower listings must not expose emails")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
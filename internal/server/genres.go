@@ -0,0 +1,46 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// ListGenresHandler godoc
+// @Summary List the genres books can be filtered by
+// @Description The curated genres books' subjects are mapped into, by name, each with how many of the organization's books it has. Pass a slug as ?genre= to GET /books, /books/search or /recommendations/{user_id}.
+// @Tags Books
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /genres [get]
+func ListGenresHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	rows, err := db.QueryContext(ctx, `
+		SELECT g.slug, g.name, COUNT(b.id)
+		FROM genres g
+		LEFT JOIN book_genres bg ON bg.genre_id = g.id
+		LEFT JOIN books b ON b.id = bg.book_id AND `+tenant.BooksVisibleSQL("b")+` AND b.merged_into IS NULL
+		GROUP BY g.id, g.slug, g.name
+		ORDER BY g.name`, tenant.ID(ctx))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	genres := []gin.H{}
+	for rows.Next() {
+		var slug, name string
+		var books int
+		if err := rows.Scan(&slug, &name, &books); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		genres = append(genres, gin.H{"slug": slug, "name": name, "books": books})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"data": genres})
+}
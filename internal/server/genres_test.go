@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestListGenresHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("FROM genres g\\s+LEFT JOIN book_genres bg ON bg.genre_id = g.id\\s+LEFT JOIN books b ON b.id = bg.book_id AND .* AND b.merged_into IS NULL\\s+GROUP BY g.id, g.slug, g.name\\s+ORDER BY g.name").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"slug", "name", "count"}).
+			AddRow("art", "Art", 0).
+			AddRow("fantasy", "Fantasy", 12))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/genres", ListGenresHandler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/genres", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data []struct {
+			Slug  string `json:"slug"`
+			Name  string `json:"name"`
+			Books int    `json:"books"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if len(body.Data) != 2 || body.Data[1].Slug != "fantasy" || body.Data[1].Books != 12 || body.Data[0].Books != 0 {
+		t.Fatalf("unexpected genres: %s", w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestListBooksHandler_FiltersByGenre(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("FROM books\\s+WHERE .* AND EXISTS \\(SELECT 1 FROM book_genres bg JOIN genres g ON g.id = bg.genre_id WHERE bg.book_id = books.id AND g.slug = \\?\\)").
+		WithArgs(1, "science-fiction", 21, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "published_year", "formats", "page_count", "content_warnings", "audience_rating", "cover_id", "language", "publisher"}).
+			AddRow(1, "b-1", "dune-b1", "Dune", "Frank Herbert", 1965, "print", 412, "", nil, nil, nil, nil))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books", ListBooksHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books?genre=Science-Fiction", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books?genre=space-westerns", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown genre, got %d", w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/graph"
+)
+
+// graphqlComplexityLimit rejects deeply nested queries before they hit the DB
+const graphqlComplexityLimit = 500
+
+// GraphQLHandler serves /graphql (GET + POST). Loaders are created per request
+// so nested fields (book.likes, interaction.user, ...) are batched. Mount it
+// behind OptionalAuthMiddleware: the resolvers check the caller it sets.
+func GraphQLHandler() gin.HandlerFunc {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{DB: db},
+	}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.FixedComplexityLimit(graphqlComplexityLimit))
+
+	h := graph.Middleware(db, srv)
+	return func(c *gin.Context) {
+		ctx := graph.WithViewer(c.Request.Context(), graph.Viewer{
+			UserID: c.GetInt("auth_user_id"),
+			Role:   c.GetString("auth_role"),
+		})
+		h.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+	}
+}
+
+// GraphQLPlaygroundHandler serves an in-browser GraphQL IDE
+func GraphQLPlaygroundHandler() gin.HandlerFunc {
+	return gin.WrapH(playground.Handler("BookRec GraphQL", "/graphql"))
+}
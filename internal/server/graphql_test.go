@@ -0,0 +1,190 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGraphQLHandler_BatchesNestedFields(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT id, uuid, slug, title, COALESCE\\(author, ''\\), COALESCE\\(published_year, 0\\)\\s+FROM books\\s+WHERE \\(\\(organization_id IS NULL OR organization_id = \\?\\) AND deleted_at IS NULL\\)\\s+ORDER BY id").
+		WithArgs(1, 2, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "published_year"}).
+			AddRow(1, "b-1", "book-a-b1", "Book A", "Author A", 2001).
+			AddRow(2, "b-2", "book-b-b2", "Book B", "Author B", 2002))
+	// one batched query for both books' likes, not one per book
+	mock.ExpectQuery("SELECT book_id, likes\\s+FROM book_counters\\s+WHERE organization_id = \\? AND book_id IN \\(\\?, \\?\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "count"}).AddRow(1, 3))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/graphql", GraphQLHandler())
+
+	body := `{"query":"{ books(limit: 2) { uuid title likes } }"}`
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data struct {
+			Books []struct {
+				UUID  string `json:"uuid"`
+				Title string `json:"title"`
+				Likes int    `json:"likes"`
+			} `json:"books"`
+		} `json:"data"`
+		Errors []any `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(resp.Errors) > 0 {
+		t.Fatalf("unexpected graphql errors: %v", resp.Errors)
+	}
+	if len(resp.Data.Books) != 2 || resp.Data.Books[0].UUID != "b-1" || resp.Data.Books[0].Likes != 3 || resp.Data.Books[1].Likes != 0 {
+		t.Fatalf("unexpected books: %+v", resp.Data.Books)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+// graphQLUserUUID is user 2's UUID in the GraphQL tests
+const graphQLUserUUID = "7c9e6679-7425-40de-944c-e07fc1f90ae7"
+
+// expectGraphQLUser answers the resolver's lookup of graphQLUserUUID
+func expectGraphQLUser(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT id FROM users WHERE uuid = \\? AND organization_id = \\?").
+		WithArgs(graphQLUserUUID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+}
+
+func TestGraphQLHandler_OwnerOrAdminOnly(t *testing.T) {
+	cases := []struct {
+		name    string
+		auth    gin.HandlerFunc
+		query   string
+		resolve bool
+	}{
+		{"anonymous users", func(c *gin.Context) { c.Next() }, "{ users { handle } }", false},
+		{"user lists users", asUser(1), "{ users { handle } }", false},
+		{"another user's interactions", asUser(1), `{ interactions(userId: "` + graphQLUserUUID + `") { action } }`, true},
+		{"another user's recommendations", asUser(1), `{ recommendations(userId: "` + graphQLUserUUID + `") { score } }`, true},
+		{"anonymous recommendations", func(c *gin.Context) { c.Next() }, `{ recommendations(userId: "` + graphQLUserUUID + `") { score } }`, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mock sqlmock.Sqlmock
+			var err error
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock new: %v", err)
+			}
+			defer func() { _ = db.Close() }()
+			if tc.resolve {
+				expectGraphQLUser(mock)
+			}
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.POST("/graphql", tc.auth, GraphQLHandler())
+
+			body, _ := json.Marshal(map[string]string{"query": tc.query})
+			req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			var resp struct {
+				Errors []struct {
+					Message string `json:"message"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid json: %v", err)
+			}
+			if len(resp.Errors) != 1 || !strings.HasPrefix(resp.Errors[0].Message, "forbidden") {
+				t.Fatalf("expected a forbidden error, got body=%s", w.Body.String())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("unmet sql expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestGraphQLHandler_HidesAnotherUsersPrivateData(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectGraphQLUser(mock)
+	mock.ExpectQuery("SELECT id, uuid, email, handle, created_at\\s+FROM users\\s+WHERE id IN \\(\\?\\) AND organization_id = \\? AND deleted_at IS NULL").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "email", "handle", "created_at"}).
+			AddRow(2, "u-2", "two@example.com", "two", "2024-01-01T00:00:00Z"))
+	// user 1 only sees user 2's public interactions on live books
+	mock.ExpectQuery("JOIN books b ON b.id = i.book_id\\s+WHERE i.user_id IN \\(\\?\\) AND \\(\\(b.organization_id IS NULL OR b.organization_id = \\?\\) AND b.deleted_at IS NULL\\)\\s+AND i.organization_id = \\? AND i.deleted_at IS NULL AND \\(i.visibility = 'public' OR i.user_id = \\?\\)").
+		WithArgs(2, 1, 1, 1, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "user_id", "book_id", "action", "rating", "created_at"}).
+			AddRow(7, "i-7", 2, 3, "like", nil, "2024-01-02T00:00:00Z"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/graphql", asUser(1), GraphQLHandler())
+
+	body := `{"query":"{ user(id: \"` + graphQLUserUUID + `\") { handle email interactions { action } } }"}`
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp struct {
+		Data struct {
+			User struct {
+				Handle       string  `json:"handle"`
+				Email        *string `json:"email"`
+				Interactions []struct {
+					Action string `json:"action"`
+				} `json:"interactions"`
+			} `json:"user"`
+		} `json:"data"`
+		Errors []any `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(resp.Errors) > 0 {
+		t.Fatalf("unexpected graphql errors: %v", resp.Errors)
+	}
+	if resp.Data.User.Handle != "two" || resp.Data.User.Email != nil || len(resp.Data.User.Interactions) != 1 {
+		t.Fatalf("unexpected user: %s", w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
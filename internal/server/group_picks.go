@@ -0,0 +1,538 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// groupMeetingTitleMaxLen bounds schedule entry titles
+const groupMeetingTitleMaxLen = 200
+
+// parsePickDate reads an optional YYYY-MM-DD form value
+func parsePickDate(raw, field string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a date (YYYY-MM-DD)", field)
+	}
+	return t.Format("2006-01-02"), nil
+}
+
+// loadCurrentPick returns the group's current pick, or nil
+func loadCurrentPick(ctx context.Context, groupID int) (gin.H, error) {
+	var id int
+	var bookUUID, slug, title, createdAt string
+	var author sql.NullString
+	var startsOn, endsOn sql.NullTime
+	err := db.QueryRowContext(ctx, `
+		SELECT p.id, b.uuid, b.slug, b.title, b.author, p.starts_on, p.ends_on, p.created_at
+		FROM group_picks p
+		JOIN books b ON b.id = p.book_id
+		WHERE p.group_id = ? AND p.status = 'current'
+		ORDER BY p.id DESC
+		LIMIT 1`, groupID).
+		Scan(&id, &bookUUID, &slug, &title, &author, &startsOn, &endsOn, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"id":         id,
+		"book_uuid":  bookUUID,
+		"slug":       slug,
+		"title":      title,
+		"author":     author.String,
+		"starts_on":  nullableDate(startsOn),
+		"ends_on":    nullableDate(endsOn),
+		"status":     "current",
+		"created_at": createdAt,
+	}, nil
+}
+
+func nullableString(s sql.NullString) interface{} {
+	if s.Valid {
+		return s.String
+	}
+	return nil
+}
+
+// nullableDate formats a DATE column as YYYY-MM-DD, or nil
+func nullableDate(t sql.NullTime) interface{} {
+	if t.Valid {
+		return t.Time.Format("2006-01-02")
+	}
+	return nil
+}
+
+// groupRecommendations suggests books for the whole group: books liked by
+// readers who share likes with the members, ranked by how many members they
+// connect to and then by overlap. Books any member has already interacted
+// with, and past picks, are left out.
+func groupRecommendations(ctx context.Context, groupID, limit int) ([]gin.H, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT b.id, b.uuid, b.slug, b.title, b.author,
+		       COUNT(DISTINCT i.user_id) AS members_matched,
+		       COUNT(*) AS score
+		FROM group_members gm
+		JOIN interactions i
+		    ON i.user_id = gm.user_id
+		    AND i.action = 'like'
+		    AND i.deleted_at IS NULL
+		JOIN interactions j
+		    ON j.book_id = i.book_id
+		    AND j.action = 'like'
+		    AND j.organization_id = i.organization_id
+		    AND j.deleted_at IS NULL
+		    AND j.user_id NOT IN (SELECT user_id FROM group_members WHERE group_id = ?)
+		JOIN interactions k
+		    ON k.user_id = j.user_id
+		    AND k.action = 'like'
+		    AND k.deleted_at IS NULL
+		JOIN books b
+		    ON b.id = k.book_id
+		WHERE gm.group_id = ?
+		AND `+tenant.BooksVisibleSQL("b")+`
+		AND k.book_id NOT IN (
+		    SELECT x.book_id FROM interactions x
+		    JOIN group_members xm ON xm.user_id = x.user_id
+		    WHERE xm.group_id = ? AND x.deleted_at IS NULL
+		)
+		AND k.book_id NOT IN (SELECT book_id FROM group_picks WHERE group_id = ?)
+		GROUP BY b.id, b.uuid, b.slug, b.title, b.author
+		ORDER BY members_matched DESC, score DESC, b.id
+		LIMIT ?`, groupID, groupID, tenant.ID(ctx), groupID, groupID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	recs := []gin.H{}
+	for rows.Next() {
+		var id, membersMatched, score int
+		var publicID, slug, title string
+		var author sql.NullString
+		if err := rows.Scan(&id, &publicID, &slug, &title, &author, &membersMatched, &score); err != nil {
+			return nil, err
+		}
+		recs = append(recs, gin.H{
+			"book_id":         id,
+			"book_uuid":       publicID,
+			"slug":            slug,
+			"title":           title,
+			"author":          author.String,
+			"members_matched": membersMatched,
+			"score":           score,
+		})
+	}
+	return recs, rows.Err()
+}
+
+// GroupRecommendationsHandler godoc
+// @Summary Recommended next books for a book club (members only)
+// @Description Collaborative filtering over all members' likes. members_matched is how many members' likes led to the book. Pass a book_uuid from here as book_id to POST /groups/{id}/picks, or use recommended=true there to take the top one.
+// @Tags Groups
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Group UUID"
+// @Param limit query int false "Limit (max 50)" default(10)
+// @Success 200 {array} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /groups/{id}/recommendations [get]
+func GroupRecommendationsHandler(c *gin.Context) {
+	groupID, ok := authorizeGroup(c, groupRoleMember)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	recs, err := groupRecommendations(c.Request.Context(), groupID, limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	hideInternalIDs(recs, "book_id")
+	c.JSON(200, recs)
+}
+
+// ListGroupPicksHandler godoc
+// @Summary A book club's picks, current first, then past ones newest first
+// @Tags Groups
+// @Produce json
+// @Param id path string true "Group UUID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /groups/{id}/picks [get]
+func ListGroupPicksHandler(c *gin.Context) {
+	groupID, ok := resolveParam(c, resolveGroupRef, c.Param("id"), "group")
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM group_picks WHERE group_id = ?", groupID).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT p.id, p.status, b.uuid, b.slug, b.title, b.author, p.starts_on, p.ends_on, p.created_at
+		FROM group_picks p
+		JOIN books b ON b.id = p.book_id
+		WHERE p.group_id = ?
+		ORDER BY p.status = 'current' DESC, p.id DESC
+		LIMIT ? OFFSET ?`, groupID, limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	picks := []gin.H{}
+	for rows.Next() {
+		var id int
+		var status, bookUUID, slug, title, createdAt string
+		var author sql.NullString
+		var startsOn, endsOn sql.NullTime
+		if err := rows.Scan(&id, &status, &bookUUID, &slug, &title, &author, &startsOn, &endsOn, &createdAt); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		picks = append(picks, gin.H{
+			"id":         id,
+			"book_uuid":  bookUUID,
+			"slug":       slug,
+			"title":      title,
+			"author":     author.String,
+			"starts_on":  nullableDate(startsOn),
+			"ends_on":    nullableDate(endsOn),
+			"status":     status,
+			"created_at": createdAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  picks,
+	})
+}
+
+// CreateGroupPickHandler godoc
+// @Summary Set a book club's current pick (admins)
+// @Description The previous current pick becomes past. Give book_id, or recommended=true to take the top of GET /groups/{id}/recommendations.
+// @Tags Groups
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Group UUID"
+// @Param book_id formData string false "Book UUID or slug"
+// @Param recommended formData bool false "Pick the top group recommendation instead"
+// @Param starts_on formData string false "Reading starts (YYYY-MM-DD)"
+// @Param ends_on formData string false "Reading ends (YYYY-MM-DD)"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /groups/{id}/picks [post]
+func CreateGroupPickHandler(c *gin.Context) {
+	groupID, ok := authorizeGroup(c, groupRoleAdmin)
+	if !ok {
+		return
+	}
+	startsOn, err := parsePickDate(c.PostForm("starts_on"), "starts_on")
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	endsOn, err := parsePickDate(c.PostForm("ends_on"), "ends_on")
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	if startsOn != nil && endsOn != nil && endsOn.(string) < startsOn.(string) {
+		abortWithError(c, badRequest("ends_on must not be before starts_on"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	var bookID int
+	switch bookRef := c.PostForm("book_id"); {
+	case bookRef != "":
+		if bookID, ok = resolveParam(c, resolveBookRef, bookRef, "book"); !ok {
+			return
+		}
+	case c.PostForm("recommended") == "true":
+		recs, err := groupRecommendations(ctx, groupID, 1)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if len(recs) == 0 {
+			abortWithError(c, conflict("no recommendations for this group yet; pass book_id"))
+			return
+		}
+		bookID = recs[0]["book_id"].(int)
+	default:
+		abortWithError(c, badRequest("book_id or recommended=true is required"))
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// lock the group so two admins picking at once can't both end up current
+	var locked int
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM reading_groups WHERE id = ? FOR UPDATE", groupID).Scan(&locked); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE group_picks SET status = 'past' WHERE group_id = ? AND status = 'current'", groupID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO group_picks (group_id, book_id, picked_by, starts_on, ends_on) VALUES (?, ?, ?, ?, ?)",
+		groupID, bookID, c.GetInt("auth_user_id"), startsOn, endsOn); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	pick, err := loadCurrentPick(ctx, groupID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(201, pick)
+}
+
+// ListGroupMeetingsHandler godoc
+// @Summary A book club's schedule (soonest first)
+// @Description Upcoming meetings only, unless past=true.
+// @Tags Groups
+// @Produce json
+// @Param id path string true "Group UUID"
+// @Param past query bool false "Include meetings that already started"
+// @Success 200 {array} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /groups/{id}/schedule [get]
+func ListGroupMeetingsHandler(c *gin.Context) {
+	groupID, ok := resolveParam(c, resolveGroupRef, c.Param("id"), "group")
+	if !ok {
+		return
+	}
+
+	query := `
+		SELECT m.id, m.title, m.starts_at, m.location, m.pick_id, b.uuid, b.title
+		FROM group_meetings m
+		LEFT JOIN group_picks p ON p.id = m.pick_id
+		LEFT JOIN books b ON b.id = p.book_id
+		WHERE m.group_id = ?`
+	args := []interface{}{groupID}
+	if c.Query("past") != "true" {
+		query += " AND m.starts_at >= ?"
+		args = append(args, time.Now().UTC())
+	}
+	query += " ORDER BY m.starts_at, m.id LIMIT 100"
+
+	rows, err := db.QueryContext(c.Request.Context(), query, args...)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	meetings := []gin.H{}
+	for rows.Next() {
+		var id int
+		var title string
+		var startsAt time.Time
+		var location, bookUUID, bookTitle sql.NullString
+		var pickID sql.NullInt64
+		if err := rows.Scan(&id, &title, &startsAt, &location, &pickID, &bookUUID, &bookTitle); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		meeting := gin.H{
+			"id":        id,
+			"title":     title,
+			"starts_at": startsAt.UTC().Format(time.RFC3339),
+			"location":  nullableString(location),
+			"pick":      nil,
+		}
+		if pickID.Valid {
+			meeting["pick"] = gin.H{"id": pickID.Int64, "book_uuid": bookUUID.String, "title": bookTitle.String}
+		}
+		meetings = append(meetings, meeting)
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, meetings)
+}
+
+// CreateGroupMeetingHandler godoc
+// @Summary Add a meeting to a book club's schedule (admins)
+// @Description The meeting is about the current pick unless pick_id says otherwise.
+// @Tags Groups
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Group UUID"
+// @Param title formData string true "Title (max 200 characters)"
+// @Param starts_at formData string true "Start time (RFC3339)"
+// @Param location formData string false "Where (address or video link)"
+// @Param pick_id formData int false "Pick the meeting is about"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /groups/{id}/schedule [post]
+func CreateGroupMeetingHandler(c *gin.Context) {
+	groupID, ok := authorizeGroup(c, groupRoleAdmin)
+	if !ok {
+		return
+	}
+	title := strings.TrimSpace(c.PostForm("title"))
+	if title == "" || len([]rune(title)) > groupMeetingTitleMaxLen {
+		abortWithError(c, badRequest(fmt.Sprintf("title is required (max %d characters)", groupMeetingTitleMaxLen)))
+		return
+	}
+	startsAt, err := time.Parse(time.RFC3339, strings.TrimSpace(c.PostForm("starts_at")))
+	if err != nil {
+		abortWithError(c, badRequest("starts_at must be an RFC3339 time"))
+		return
+	}
+	var location interface{}
+	if l := strings.TrimSpace(c.PostForm("location")); l != "" {
+		location = l
+	}
+
+	ctx := c.Request.Context()
+	var pickID interface{}
+	if raw := c.PostForm("pick_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		found := false
+		if err == nil {
+			if found, err = rowExists(ctx, "SELECT 1 FROM group_picks WHERE id = ? AND group_id = ?", id, groupID); err != nil {
+				abortWithError(c, err)
+				return
+			}
+		}
+		if !found {
+			abortWithError(c, notFound("pick not found"))
+			return
+		}
+		pickID = id
+	} else {
+		current, err := loadCurrentPick(ctx, groupID)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if current != nil {
+			pickID = current["id"]
+		}
+	}
+
+	res, err := db.ExecContext(ctx,
+		"INSERT INTO group_meetings (group_id, pick_id, title, starts_at, location, created_by) VALUES (?, ?, ?, ?, ?, ?)",
+		groupID, pickID, title, startsAt.UTC(), location, c.GetInt("auth_user_id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	id, _ := res.LastInsertId()
+	c.JSON(201, gin.H{
+		"id":        id,
+		"title":     title,
+		"starts_at": startsAt.UTC().Format(time.RFC3339),
+		"location":  location,
+		"pick_id":   pickID,
+	})
+}
+
+// DeleteGroupMeetingHandler godoc
+// @Summary Remove a meeting from a book club's schedule (admins)
+// @Tags Groups
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Group UUID"
+// @Param meeting_id path int true "Meeting ID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /groups/{id}/schedule/{meeting_id} [delete]
+func DeleteGroupMeetingHandler(c *gin.Context) {
+	groupID, ok := authorizeGroup(c, groupRoleAdmin)
+	if !ok {
+		return
+	}
+	meetingID, err := strconv.Atoi(c.Param("meeting_id"))
+	if err != nil {
+		abortWithError(c, notFound("meeting not found"))
+		return
+	}
+	res, err := db.ExecContext(c.Request.Context(),
+		"DELETE FROM group_meetings WHERE id = ? AND group_id = ?", meetingID, groupID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		abortWithError(c, notFound("meeting not found"))
+		return
+	}
+	c.Status(204)
+}
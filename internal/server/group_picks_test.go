@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateGroupPickHandler_Recommended(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	recColumns := []string{"id", "uuid", "slug", "title", "author", "members_matched", "score"}
+
+	expectGroupResolve(mock, 3)
+	expectGroupRole(mock, 3, 2, 1, "admin")
+	mock.ExpectQuery("FROM group_members gm\\s+JOIN interactions i").
+		WithArgs(3, 3, 1, 3, 3, 1).
+		WillReturnRows(sqlmock.NewRows(recColumns).AddRow(9, "b-9", "dune-b-9", "Dune", "Frank Herbert", 3, 7))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM reading_groups WHERE id = \\? FOR UPDATE").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectExec("UPDATE group_picks SET status = 'past' WHERE group_id = \\? AND status = 'current'").
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO group_picks \\(group_id, book_id, picked_by, starts_on, ends_on\\)").
+		WithArgs(3, 9, 2, "2026-11-01", nil).
+		WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("FROM group_picks p\\s+JOIN books b ON b.id = p.book_id\\s+WHERE p.group_id = \\? AND p.status = 'current'").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "starts_on", "ends_on", "created_at"}).
+			AddRow(12, "b-9", "dune-b-9", "Dune", "Frank Herbert", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), nil, "2026-10-14 12:00:00"))
+	// nothing to recommend
+	expectGroupResolve(mock, 3)
+	expectGroupRole(mock, 3, 2, 1, "admin")
+	mock.ExpectQuery("FROM group_members gm\\s+JOIN interactions i").
+		WithArgs(3, 3, 1, 3, 3, 1).
+		WillReturnRows(sqlmock.NewRows(recColumns))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/groups/:id/picks", asUser(2), CreateGroupPickHandler)
+
+	w := postForm(r, "/groups/3/picks", url.Values{"recommended": {"true"}, "starts_on": {"2026-11-01"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	w = postForm(r, "/groups/3/picks", url.Values{"recommended": {"true"}})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 without recommendations, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestCreateGroupMeetingHandler_RequiresAdmin(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectGroupResolve(mock, 3)
+	expectGroupRole(mock, 3, 2, 1, "member")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/groups/:id/schedule", asUser(2), CreateGroupMeetingHandler)
+
+	w := postForm(r, "/groups/3/schedule", url.Values{"title": {"Part one"}, "starts_at": {"2026-11-08T18:00:00Z"}})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,420 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// Discussion limits
+const (
+	threadTitleMaxLen = 200
+	postBodyMaxLen    = 10000
+)
+
+func resolveThreadRef(ctx context.Context, raw string) (int, error) {
+	return resolveRef(ctx, "discussion_threads", "", orgScope, raw)
+}
+
+func parseThreadTitle(raw string) (string, error) {
+	title := strings.TrimSpace(raw)
+	if title == "" {
+		return "", fmt.Errorf("title is required")
+	}
+	if len([]rune(title)) > threadTitleMaxLen {
+		return "", fmt.Errorf("title must be at most %d characters", threadTitleMaxLen)
+	}
+	return title, nil
+}
+
+func parsePostBody(raw string) (string, error) {
+	body := strings.TrimSpace(raw)
+	if body == "" {
+		return "", fmt.Errorf("body is required")
+	}
+	if len([]rune(body)) > postBodyMaxLen {
+		return "", fmt.Errorf("body must be at most %d characters", postBodyMaxLen)
+	}
+	return body, nil
+}
+
+// liveThreadSQL leaves out threads removed by moderators or hidden pending review
+const liveThreadSQL = "deleted_at IS NULL AND hidden_at IS NULL"
+
+// groupThread resolves :thread_id and checks it's a live thread in groupID
+func groupThread(c *gin.Context, groupID int) (int, bool) {
+	threadID, ok := resolveParam(c, resolveThreadRef, c.Param("thread_id"), "thread")
+	if !ok {
+		return 0, false
+	}
+	found, err := rowExists(c.Request.Context(),
+		"SELECT 1 FROM discussion_threads WHERE id = ? AND group_id = ? AND "+liveThreadSQL, threadID, groupID)
+	if err != nil {
+		abortWithError(c, err)
+		return 0, false
+	}
+	if !found {
+		abortWithError(c, notFound("thread not found"))
+		return 0, false
+	}
+	return threadID, true
+}
+
+// withholdPost blanks what readers shouldn't see of a post: deleted posts
+// lose their body and author, hidden ones (reported, awaiting a moderator)
+// and ones by users the reader blocked their body
+func withholdPost(post gin.H, deleted, hidden, blocked bool) {
+	post["deleted"], post["hidden"], post["blocked"] = deleted, hidden && !deleted, blocked && !deleted
+	switch {
+	case deleted:
+		post["body"], post["author"] = nil, nil
+	case hidden, blocked:
+		post["body"] = nil
+	}
+}
+
+// userRef is the author/actor shape for rows whose user may have been deleted
+func userRef(id sql.NullInt64, publicID, handle sql.NullString) interface{} {
+	if !id.Valid {
+		return nil
+	}
+	return gin.H{"uuid": publicID.String, "handle": handle.String}
+}
+
+// ListGroupThreadsHandler godoc
+// @Summary Discussion threads of a book club, most recently active first (members only)
+// @Description Threads started by users the caller blocked are left out.
+// @Tags Groups
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Group UUID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /groups/{id}/threads [get]
+func ListGroupThreadsHandler(c *gin.Context) {
+	groupID, ok := authorizeGroup(c, groupRoleMember)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	where := " WHERE t.group_id = ? AND t.deleted_at IS NULL AND t.hidden_at IS NULL AND " + notBlockedSQL("t.user_id")
+	args := []interface{}{groupID, c.GetInt("auth_user_id")}
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM discussion_threads t"+where, args...).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT t.uuid, t.title, t.created_at, t.last_post_at,
+		       (SELECT COUNT(*) FROM discussion_posts p WHERE p.thread_id = t.id AND p.deleted_at IS NULL AND p.hidden_at IS NULL),
+		       u.id, u.uuid, u.handle
+		FROM discussion_threads t
+		LEFT JOIN users u ON u.id = t.user_id`+where+`
+		ORDER BY t.last_post_at DESC, t.id DESC
+		LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	threads := []gin.H{}
+	for rows.Next() {
+		var postCount int
+		var publicID, title, createdAt, lastPostAt string
+		var authorID sql.NullInt64
+		var authorUUID, handle sql.NullString
+		if err := rows.Scan(&publicID, &title, &createdAt, &lastPostAt, &postCount,
+			&authorID, &authorUUID, &handle); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		threads = append(threads, gin.H{
+			"uuid":         publicID,
+			"title":        title,
+			"author":       userRef(authorID, authorUUID, handle),
+			"post_count":   postCount,
+			"created_at":   createdAt,
+			"last_post_at": lastPostAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  threads,
+	})
+}
+
+// CreateGroupThreadHandler godoc
+// @Summary Start a discussion thread in a book club (members only)
+// @Tags Groups
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Group UUID"
+// @Param title formData string true "Title (max 200 characters)"
+// @Param body formData string true "Opening post (max 10000 characters)"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /groups/{id}/threads [post]
+func CreateGroupThreadHandler(c *gin.Context) {
+	groupID, ok := authorizeGroup(c, groupRoleMember)
+	if !ok {
+		return
+	}
+	title, err := parseThreadTitle(c.PostForm("title"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	body, err := parsePostBody(c.PostForm("body"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	if !screenText(c, "title", title) || !screenText(c, "body", body) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := c.GetInt("auth_user_id")
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO discussion_threads (organization_id, group_id, user_id, title) VALUES (?, ?, ?, ?)",
+		tenant.ID(ctx), groupID, userID, title)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	id, _ := res.LastInsertId()
+	threadID := int(id)
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO discussion_posts (thread_id, user_id, body) VALUES (?, ?, ?)", threadID, userID, body); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	var publicID, groupUUID string
+	if err := tx.QueryRowContext(ctx,
+		"SELECT t.uuid, g.uuid FROM discussion_threads t JOIN reading_groups g ON g.id = t.group_id WHERE t.id = ?", threadID).
+		Scan(&publicID, &groupUUID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/groups/%s/threads/%s", c.Param("id"), publicID))
+	c.JSON(201, gin.H{
+		"uuid":       publicID,
+		"group_uuid": groupUUID,
+		"title":      title,
+		"post_count": 1,
+	})
+}
+
+// GetGroupThreadHandler godoc
+// @Summary A discussion thread with its posts, oldest first (members only)
+// @Description Posts by users the caller blocked keep their place with blocked=true and no body.
+// @Tags Groups
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Group UUID"
+// @Param thread_id path string true "Thread UUID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /groups/{id}/threads/{thread_id} [get]
+func GetGroupThreadHandler(c *gin.Context) {
+	groupID, ok := authorizeGroup(c, groupRoleMember)
+	if !ok {
+		return
+	}
+	threadID, ok := groupThread(c, groupID)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	var publicID, title, createdAt string
+	var total int
+	if err := db.QueryRowContext(ctx, `
+		SELECT uuid, title, created_at, (SELECT COUNT(*) FROM discussion_posts p WHERE p.thread_id = t.id)
+		FROM discussion_threads t
+		WHERE id = ?`, threadID).Scan(&publicID, &title, &createdAt, &total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	blocked, err := blockedUsers(ctx, c.GetInt("auth_user_id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT p.id, p.body, p.deleted_at IS NOT NULL, p.hidden_at IS NOT NULL, p.created_at, u.id, u.uuid, u.handle
+		FROM discussion_posts p
+		LEFT JOIN users u ON u.id = p.user_id
+		WHERE p.thread_id = ?
+		ORDER BY p.id
+		LIMIT ? OFFSET ?`, threadID, limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	posts := []gin.H{}
+	for rows.Next() {
+		var id int
+		var body, postedAt string
+		var deleted, hidden bool
+		var authorID sql.NullInt64
+		var authorUUID, handle sql.NullString
+		if err := rows.Scan(&id, &body, &deleted, &hidden, &postedAt, &authorID, &authorUUID, &handle); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		post := gin.H{
+			"id":         id,
+			"body":       body,
+			"author":     userRef(authorID, authorUUID, handle),
+			"created_at": postedAt,
+		}
+		withholdPost(post, deleted, hidden, authorID.Valid && blocked[int(authorID.Int64)])
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"uuid":       publicID,
+		"title":      title,
+		"created_at": createdAt,
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+		"data":       posts,
+	})
+}
+
+// CreateGroupPostHandler godoc
+// @Summary Reply in a book club's discussion thread (members only)
+// @Tags Groups
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Group UUID"
+// @Param thread_id path string true "Thread UUID"
+// @Param body formData string true "Post (max 10000 characters)"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /groups/{id}/threads/{thread_id}/posts [post]
+func CreateGroupPostHandler(c *gin.Context) {
+	groupID, ok := authorizeGroup(c, groupRoleMember)
+	if !ok {
+		return
+	}
+	threadID, ok := groupThread(c, groupID)
+	if !ok {
+		return
+	}
+	body, err := parsePostBody(c.PostForm("body"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	if !screenText(c, "body", body) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := c.GetInt("auth_user_id")
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO discussion_posts (thread_id, user_id, body) VALUES (?, ?, ?)", threadID, userID, body)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE discussion_threads SET last_post_at = CURRENT_TIMESTAMP WHERE id = ?", threadID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	threadUUID, err := publicRef(ctx, db, "discussion_threads", threadID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	id, _ := res.LastInsertId()
+	c.JSON(201, gin.H{"id": id, "thread_uuid": threadUUID, "body": body})
+}
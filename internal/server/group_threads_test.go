@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateGroupPostHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectThread := func(threadID, groupID int, found bool) {
+		mock.ExpectQuery("SELECT 1 FROM discussion_threads WHERE id = \\? AND organization_id = \\?").
+			WithArgs(threadID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		rows := sqlmock.NewRows([]string{"1"})
+		if found {
+			rows.AddRow(1)
+		}
+		mock.ExpectQuery("SELECT 1 FROM discussion_threads WHERE id = \\? AND group_id = \\?").
+			WithArgs(threadID, groupID).
+			WillReturnRows(rows)
+	}
+
+	// a member replies
+	expectGroupResolve(mock, 3)
+	expectGroupRole(mock, 3, 2, 1, "member")
+	expectThread(8, 3, true)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO discussion_posts \\(thread_id, user_id, body\\)").
+		WithArgs(8, 2, "Loved the ending").
+		WillReturnResult(sqlmock.NewResult(40, 1))
+	mock.ExpectExec("UPDATE discussion_threads SET last_post_at = CURRENT_TIMESTAMP WHERE id = \\?").
+		WithArgs(8).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectPublicRef(mock, "discussion_threads", 8, "t-8")
+	// a thread from another group
+	expectGroupResolve(mock, 3)
+	expectGroupRole(mock, 3, 2, 1, "member")
+	expectThread(9, 3, false)
+	// not a member
+	expectGroupResolve(mock, 4)
+	expectGroupRole(mock, 4, 2, 1, "")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/groups/:id/threads/:thread_id/posts", asUser(2), CreateGroupPostHandler)
+
+	for _, tc := range []struct {
+		path string
+		want int
+	}{
+		{"/groups/3/threads/8/posts", http.StatusCreated},
+		{"/groups/3/threads/9/posts", http.StatusNotFound},
+		{"/groups/4/threads/8/posts", http.StatusForbidden},
+	} {
+		w := postForm(r, tc.path, url.Values{"body": {" Loved the ending "}})
+		if w.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d: %s", tc.path, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,625 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// Book club limits
+const (
+	groupNameMaxLen        = 100
+	groupDescriptionMaxLen = 2000
+)
+
+func resolveGroupRef(ctx context.Context, raw string) (int, error) {
+	return resolveRef(ctx, "reading_groups", "", orgScope, raw)
+}
+
+func parseGroupName(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+	if len([]rune(name)) > groupNameMaxLen {
+		return "", fmt.Errorf("name must be at most %d characters", groupNameMaxLen)
+	}
+	return name, nil
+}
+
+func parseGroupDescription(raw string) (string, error) {
+	description := strings.TrimSpace(raw)
+	if len([]rune(description)) > groupDescriptionMaxLen {
+		return "", fmt.Errorf("description must be at most %d characters", groupDescriptionMaxLen)
+	}
+	return description, nil
+}
+
+// groupRole is what a user may do in a group; each role includes the ones below it
+type groupRole int
+
+const (
+	groupRoleNone groupRole = iota
+	groupRoleMember
+	groupRoleAdmin
+	groupRoleOwner
+)
+
+func (r groupRole) String() string {
+	switch r {
+	case groupRoleMember:
+		return "member"
+	case groupRoleAdmin:
+		return "admin"
+	case groupRoleOwner:
+		return "owner"
+	}
+	return ""
+}
+
+// groupRoleOf returns userID's role in groupID: the owner, a member's role,
+// or groupRoleNone
+func groupRoleOf(ctx context.Context, groupID, userID int) (groupRole, error) {
+	var ownerID int
+	var role string
+	if err := db.QueryRowContext(ctx, `
+		SELECT g.owner_id, COALESCE(m.role, '')
+		FROM reading_groups g
+		LEFT JOIN group_members m ON m.group_id = g.id AND m.user_id = ?
+		WHERE g.id = ?`, userID, groupID).Scan(&ownerID, &role); err != nil {
+		return groupRoleNone, err
+	}
+	switch {
+	case ownerID == userID:
+		return groupRoleOwner, nil
+	case role == "admin":
+		return groupRoleAdmin, nil
+	case role == "member":
+		return groupRoleMember, nil
+	}
+	return groupRoleNone, nil
+}
+
+// authorizeGroup resolves :id and checks the caller has at least need in it
+func authorizeGroup(c *gin.Context, need groupRole) (int, bool) {
+	groupID, ok := resolveParam(c, resolveGroupRef, c.Param("id"), "group")
+	if !ok {
+		return 0, false
+	}
+	role, err := groupRoleOf(c.Request.Context(), groupID, c.GetInt("auth_user_id"))
+	if err != nil {
+		abortWithError(c, err)
+		return 0, false
+	}
+	if role < need {
+		var msg string
+		switch need {
+		case groupRoleMember:
+			msg = "join the group first"
+		case groupRoleOwner:
+			msg = "only the group's owner can do this"
+		default:
+			msg = "requires the " + need.String() + " role in the group"
+		}
+		abortWithError(c, forbidden(msg))
+		return 0, false
+	}
+	return groupID, true
+}
+
+// loadGroup returns a group with its member count and current pick
+func loadGroup(ctx context.Context, id int) (gin.H, error) {
+	var memberCount int
+	var publicID, ownerUUID, name, createdAt, updatedAt string
+	var description sql.NullString
+	if err := db.QueryRowContext(ctx, `
+		SELECT g.uuid, u.uuid, g.name, g.description, g.created_at, g.updated_at,
+		       (SELECT COUNT(*) FROM group_members m WHERE m.group_id = g.id)
+		FROM reading_groups g
+		JOIN users u ON u.id = g.owner_id
+		WHERE g.id = ?`, id).
+		Scan(&publicID, &ownerUUID, &name, &description, &createdAt, &updatedAt, &memberCount); err != nil {
+		return nil, err
+	}
+	current, err := loadCurrentPick(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"uuid":         publicID,
+		"owner_uuid":   ownerUUID,
+		"name":         name,
+		"description":  description.String,
+		"member_count": memberCount,
+		"current_pick": current,
+		"created_at":   createdAt,
+		"updated_at":   updatedAt,
+	}, nil
+}
+
+// CreateGroupHandler godoc
+// @Summary Create a book club
+// @Description The caller becomes the group's owner and first admin.
+// @Tags Groups
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param name formData string true "Group name (max 100 characters)"
+// @Param description formData string false "What the club reads (max 2000 characters)"
+// @Success 201 {object} map[string]interface{}
+// @Header 201 {string} Location "/groups/{uuid}"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /groups [post]
+func CreateGroupHandler(c *gin.Context) {
+	name, err := parseGroupName(c.PostForm("name"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	description, err := parseGroupDescription(c.PostForm("description"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	if !screenText(c, "name", name) || !screenText(c, "description", description) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := c.GetInt("auth_user_id")
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO reading_groups (organization_id, owner_id, name, description) VALUES (?, ?, ?, ?)",
+		tenant.ID(ctx), userID, name, description)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	id, _ := res.LastInsertId()
+	groupID := int(id)
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO group_members (group_id, user_id, role) VALUES (?, ?, 'admin')", groupID, userID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	group, err := loadGroup(ctx, groupID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Header("Location", fmt.Sprintf("/groups/%v", group["uuid"]))
+	c.JSON(201, group)
+}
+
+// ListGroupsHandler godoc
+// @Summary List the organization's book clubs (newest first)
+// @Tags Groups
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Router /groups [get]
+func ListGroupsHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	orgID := tenant.ID(ctx)
+	var total int
+	if err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM reading_groups WHERE organization_id = ?", orgID).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT g.uuid, g.name, g.description,
+		       (SELECT COUNT(*) FROM group_members m WHERE m.group_id = g.id)
+		FROM reading_groups g
+		WHERE g.organization_id = ?
+		ORDER BY g.created_at DESC, g.id DESC
+		LIMIT ? OFFSET ?`, orgID, limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	groups := []gin.H{}
+	for rows.Next() {
+		var memberCount int
+		var publicID, name string
+		var description sql.NullString
+		if err := rows.Scan(&publicID, &name, &description, &memberCount); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		groups = append(groups, gin.H{
+			"uuid":         publicID,
+			"name":         name,
+			"description":  description.String,
+			"member_count": memberCount,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  groups,
+	})
+}
+
+// GetGroupHandler godoc
+// @Summary Get a book club with its current pick
+// @Tags Groups
+// @Produce json
+// @Param id path string true "Group UUID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /groups/{id} [get]
+func GetGroupHandler(c *gin.Context) {
+	groupID, ok := resolveParam(c, resolveGroupRef, c.Param("id"), "group")
+	if !ok {
+		return
+	}
+	group, err := loadGroup(c.Request.Context(), groupID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, group)
+}
+
+// UpdateGroupHandler godoc
+// @Summary Rename a book club or change its description (admins)
+// @Tags Groups
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Group UUID"
+// @Param name formData string false "New name (max 100 characters)"
+// @Param description formData string false "New description (max 2000 characters)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /groups/{id} [patch]
+func UpdateGroupHandler(c *gin.Context) {
+	groupID, ok := authorizeGroup(c, groupRoleAdmin)
+	if !ok {
+		return
+	}
+
+	sets := []string{}
+	args := []interface{}{}
+	if raw, ok := c.GetPostForm("name"); ok {
+		name, err := parseGroupName(raw)
+		if err != nil {
+			abortWithError(c, badRequest(err.Error()))
+			return
+		}
+		if !screenText(c, "name", name) {
+			return
+		}
+		sets = append(sets, "name = ?")
+		args = append(args, name)
+	}
+	if raw, ok := c.GetPostForm("description"); ok {
+		description, err := parseGroupDescription(raw)
+		if err != nil {
+			abortWithError(c, badRequest(err.Error()))
+			return
+		}
+		if !screenText(c, "description", description) {
+			return
+		}
+		sets = append(sets, "description = ?")
+		args = append(args, description)
+	}
+	if len(sets) == 0 {
+		abortWithError(c, badRequest("name or description is required"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	args = append(args, groupID)
+	if _, err := db.ExecContext(ctx,
+		"UPDATE reading_groups SET "+strings.Join(sets, ", ")+" WHERE id = ?", args...); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	group, err := loadGroup(ctx, groupID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, group)
+}
+
+// DeleteGroupHandler godoc
+// @Summary Delete a book club with its picks, schedule and threads (owner only)
+// @Tags Groups
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Group UUID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /groups/{id} [delete]
+func DeleteGroupHandler(c *gin.Context) {
+	groupID, ok := authorizeGroup(c, groupRoleOwner)
+	if !ok {
+		return
+	}
+	if _, err := db.ExecContext(c.Request.Context(), "DELETE FROM reading_groups WHERE id = ?", groupID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// ListGroupMembersHandler godoc
+// @Summary Members of a book club (admins first, then by join date)
+// @Tags Groups
+// @Produce json
+// @Param id path string true "Group UUID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /groups/{id}/members [get]
+func ListGroupMembersHandler(c *gin.Context) {
+	groupID, ok := resolveParam(c, resolveGroupRef, c.Param("id"), "group")
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM group_members WHERE group_id = ?", groupID).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT u.uuid, u.handle, IF(g.owner_id = m.user_id, 'owner', m.role), m.joined_at
+		FROM group_members m
+		JOIN users u ON u.id = m.user_id
+		JOIN reading_groups g ON g.id = m.group_id
+		WHERE m.group_id = ?
+		ORDER BY m.role = 'admin' DESC, m.joined_at, m.user_id
+		LIMIT ? OFFSET ?`, groupID, limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	members := []gin.H{}
+	for rows.Next() {
+		var userUUID, handle, role, joinedAt string
+		if err := rows.Scan(&userUUID, &handle, &role, &joinedAt); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		members = append(members, gin.H{
+			"user_uuid": userUUID,
+			"handle":    handle,
+			"role":      role,
+			"joined_at": joinedAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  members,
+	})
+}
+
+// JoinGroupHandler godoc
+// @Summary Join a book club
+// @Tags Groups
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Group UUID"
+// @Success 201 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /groups/{id}/members [post]
+func JoinGroupHandler(c *gin.Context) {
+	groupID, ok := resolveParam(c, resolveGroupRef, c.Param("id"), "group")
+	if !ok {
+		return
+	}
+	userID := c.GetInt("auth_user_id")
+	if _, err := db.ExecContext(c.Request.Context(),
+		"INSERT INTO group_members (group_id, user_id) VALUES (?, ?)", groupID, userID); err != nil {
+		if dberr.Is(err, dberr.ErrDuplicate) {
+			abortWithError(c, conflict("already a member"))
+			return
+		}
+		abortWithError(c, err)
+		return
+	}
+	refs, err := groupMemberRefs(c.Request.Context(), groupID, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(201, gin.H{"group_uuid": refs[0], "user_uuid": refs[1], "role": "member"})
+}
+
+// UpdateGroupMemberHandler godoc
+// @Summary Promote a member to admin or demote an admin (owner only)
+// @Tags Groups
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Group UUID"
+// @Param user_id path string true "Member UUID"
+// @Param role formData string true "member or admin"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /groups/{id}/members/{user_id} [patch]
+func UpdateGroupMemberHandler(c *gin.Context) {
+	groupID, ok := authorizeGroup(c, groupRoleOwner)
+	if !ok {
+		return
+	}
+	role := strings.TrimSpace(c.PostForm("role"))
+	if role != "member" && role != "admin" {
+		abortWithError(c, badRequest("role must be member or admin"))
+		return
+	}
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("user_id"), "user")
+	if !ok {
+		return
+	}
+	if userID == c.GetInt("auth_user_id") {
+		abortWithError(c, badRequest("the owner's role can't be changed"))
+		return
+	}
+
+	res, err := db.ExecContext(c.Request.Context(),
+		"UPDATE group_members SET role = ? WHERE group_id = ? AND user_id = ?", role, groupID, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		found, err := rowExists(c.Request.Context(),
+			"SELECT 1 FROM group_members WHERE group_id = ? AND user_id = ?", groupID, userID)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if !found {
+			abortWithError(c, notFound("member not found"))
+			return
+		}
+	}
+	refs, err := groupMemberRefs(c.Request.Context(), groupID, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"group_uuid": refs[0], "user_uuid": refs[1], "role": role})
+}
+
+// groupMemberRefs returns the uuids of a group and one of its members
+func groupMemberRefs(ctx context.Context, groupID, userID int) ([2]string, error) {
+	var refs [2]string
+	err := db.QueryRowContext(ctx,
+		"SELECT g.uuid, u.uuid FROM reading_groups g, users u WHERE g.id = ? AND u.id = ?", groupID, userID).
+		Scan(&refs[0], &refs[1])
+	return refs, err
+}
+
+// RemoveGroupMemberHandler godoc
+// @Summary Leave a book club, or remove a member (admins; only the owner removes admins)
+// @Description The owner can't leave; delete the group instead.
+// @Tags Groups
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Group UUID"
+// @Param user_id path string true "Member UUID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /groups/{id}/members/{user_id} [delete]
+func RemoveGroupMemberHandler(c *gin.Context) {
+	groupID, ok := resolveParam(c, resolveGroupRef, c.Param("id"), "group")
+	if !ok {
+		return
+	}
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("user_id"), "user")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	target, err := groupRoleOf(ctx, groupID, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if target == groupRoleNone {
+		abortWithError(c, notFound("member not found"))
+		return
+	}
+	if target == groupRoleOwner {
+		abortWithError(c, badRequest("the owner can't leave the group; delete it instead"))
+		return
+	}
+	if callerID := c.GetInt("auth_user_id"); callerID != userID {
+		caller, err := groupRoleOf(ctx, groupID, callerID)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if caller < groupRoleAdmin || caller <= target {
+			abortWithError(c, forbidden("you can't remove this member"))
+			return
+		}
+	}
+
+	if _, err := db.ExecContext(ctx,
+		"DELETE FROM group_members WHERE group_id = ? AND user_id = ?", groupID, userID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(204)
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func expectGroupRole(mock sqlmock.Sqlmock, groupID, userID, ownerID int, role string) {
+	mock.ExpectQuery("SELECT g.owner_id, COALESCE\\(m.role, ''\\)\\s+FROM reading_groups g").
+		WithArgs(userID, groupID).
+		WillReturnRows(sqlmock.NewRows([]string{"owner_id", "role"}).AddRow(ownerID, role))
+}
+
+func expectGroupResolve(mock sqlmock.Sqlmock, groupID int) {
+	mock.ExpectQuery("SELECT 1 FROM reading_groups WHERE id = \\?").
+		WithArgs(groupID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+}
+
+func TestCreateGroupHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO reading_groups \\(organization_id, owner_id, name, description\\)").
+		WithArgs(1, 1, "Sci-fi Club", "").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("INSERT INTO group_members \\(group_id, user_id, role\\) VALUES \\(\\?, \\?, 'admin'\\)").
+		WithArgs(3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("FROM reading_groups g\\s+JOIN users u ON u.id = g.owner_id\\s+WHERE g.id = \\?").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "owner_uuid", "name", "description", "created_at", "updated_at", "member_count"}).
+			AddRow("g-3", "u-1", "Sci-fi Club", "", "2026-10-01 12:00:00", "2026-10-01 12:00:00", 1))
+	mock.ExpectQuery("FROM group_picks p\\s+JOIN books b ON b.id = p.book_id\\s+WHERE p.group_id = \\? AND p.status = 'current'").
+		WithArgs(3).
+		WillReturnError(sql.ErrNoRows)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/groups", asUser(1), CreateGroupHandler)
+
+	w := postForm(r, "/groups", url.Values{"name": {" Sci-fi Club "}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if loc := w.Header().Get("Location"); loc != "/groups/g-3" {
+		t.Fatalf("unexpected Location %q", loc)
+	}
+	w = postForm(r, "/groups", url.Values{"name": {"  "}})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a name, got %d", w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestRemoveGroupMemberHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectUser := func(id int) {
+		mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+			WithArgs(id, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}
+
+	// an admin can't remove another admin
+	expectGroupResolve(mock, 3)
+	expectUser(4)
+	expectGroupRole(mock, 3, 4, 1, "admin")
+	expectGroupRole(mock, 3, 2, 1, "admin")
+	// an admin removes a member
+	expectGroupResolve(mock, 3)
+	expectUser(5)
+	expectGroupRole(mock, 3, 5, 1, "member")
+	expectGroupRole(mock, 3, 2, 1, "admin")
+	mock.ExpectExec("DELETE FROM group_members WHERE group_id = \\? AND user_id = \\?").
+		WithArgs(3, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// the owner can't leave
+	expectGroupResolve(mock, 3)
+	expectUser(1)
+	expectGroupRole(mock, 3, 1, 1, "admin")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.DELETE("/as/2/groups/:id/members/:user_id", asUser(2), RemoveGroupMemberHandler)
+	r.DELETE("/as/1/groups/:id/members/:user_id", asUser(1), RemoveGroupMemberHandler)
+
+	for _, tc := range []struct {
+		path string
+		want int
+	}{
+		{"/as/2/groups/3/members/4", http.StatusForbidden},
+		{"/as/2/groups/3/members/5", http.StatusNoContent},
+		{"/as/1/groups/3/members/1", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, tc.path, nil))
+		if w.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d: %s", tc.path, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/recommend"
+)
+
+// hybridWeights weighs the signals mode=hybrid blends. Each signal's scores
+// are scaled to 0-1 (by its top score) before weighing, so the weights
+// don't have to add up to anything.
+type hybridWeights struct {
+	Collaborative float64
+	Content       float64
+	Popularity    float64
+}
+
+// defaultHybridWeights are the weights requests don't override, read in Run
+// and Evaluate by loadHybridWeights
+var defaultHybridWeights = hybridWeights{Collaborative: 0.6, Content: 0.3, Popularity: 0.1}
+
+// loadHybridWeights reads HYBRID_WEIGHT_COLLABORATIVE, HYBRID_WEIGHT_CONTENT
+// and HYBRID_WEIGHT_POPULARITY, each keeping its default unless it's a
+// number, 0 or more
+func loadHybridWeights() {
+	for name, w := range map[string]*float64{
+		"HYBRID_WEIGHT_COLLABORATIVE": &defaultHybridWeights.Collaborative,
+		"HYBRID_WEIGHT_CONTENT":       &defaultHybridWeights.Content,
+		"HYBRID_WEIGHT_POPULARITY":    &defaultHybridWeights.Popularity,
+	} {
+		if v, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil && v >= 0 && !math.IsInf(v, 0) {
+			*w = v
+		}
+	}
+}
+
+// parseHybridWeights reads ?w_collaborative=, ?w_content= and
+// ?w_popularity=, each defaulting to defaultHybridWeights
+func parseHybridWeights(c *gin.Context) (hybridWeights, error) {
+	w := defaultHybridWeights
+	for _, p := range []struct {
+		name string
+		dst  *float64
+	}{
+		{"w_collaborative", &w.Collaborative},
+		{"w_content", &w.Content},
+		{"w_popularity", &w.Popularity},
+	} {
+		raw := strings.TrimSpace(c.Query(p.name))
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return w, errors.New(p.name + " must be a number, 0 or more")
+		}
+		*p.dst = v
+	}
+	if w.Collaborative == 0 && w.Content == 0 && w.Popularity == 0 {
+		return w, errors.New("at least one of w_collaborative, w_content and w_popularity must be above 0")
+	}
+	return w, nil
+}
+
+// loadHybridRecommendations blends userID's collaborative and content
+// recommendations with the organization's most liked books they don't know
+// yet, so a reader with no likes still gets the popular ones. Each signal's
+// scores are divided by its top score and multiplied by its weight, and a
+// book's score is the sum over the signals that picked it, rounded to 3
+// places. Signals weighted 0 aren't computed.
+func loadHybridRecommendations(ctx context.Context, q querier, userID int, filters bookFilters, w hybridWeights) ([]gin.H, error) {
+	signals := []struct {
+		weight float64
+		load   func(context.Context, querier, int, bookFilters) ([]gin.H, error)
+	}{
+		{w.Collaborative, loadRecommendations},
+		{w.Content, loadContentRecommendations},
+		{w.Popularity, loadPopularRecommendations},
+	}
+
+	books := map[int]gin.H{}
+	scores := map[int]float64{}
+	for _, s := range signals {
+		if s.weight == 0 {
+			continue
+		}
+		recs, err := s.load(ctx, q, userID, filters)
+		if err != nil {
+			return nil, err
+		}
+		// each signal comes sorted, best first
+		if len(recs) == 0 {
+			continue
+		}
+		top := recScore(recs[0])
+		if top <= 0 {
+			continue
+		}
+		for _, rec := range recs {
+			id, _ := rec["book_id"].(int)
+			if _, ok := books[id]; !ok {
+				books[id] = rec
+			}
+			scores[id] += s.weight * recScore(rec) / top
+		}
+	}
+
+	ids := make([]int, 0, len(books))
+	for id := range books {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > 10 {
+		ids = ids[:10]
+	}
+	recs := make([]gin.H, 0, len(ids))
+	for _, id := range ids {
+		rec := books[id]
+		rec["score"] = math.Round(scores[id]*1000) / 1000
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+// recScore reads a recommendation's score, an int or a float64 depending on
+// the mode
+func recScore(rec gin.H) float64 {
+	switch v := rec["score"].(type) {
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+// loadPopularRecommendations returns the 10 books most liked in userID's
+// organization that they haven't interacted with or shelved, scored by
+// their likes. Filters and content preferences apply as in
+// loadRecommendations.
+func loadPopularRecommendations(ctx context.Context, q querier, userID int, filters bookFilters) ([]gin.H, error) {
+	prefs, err := loadContentPreferences(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	filters.avoidWarnings, filters.maxAudience = prefs.AvoidWarnings, prefs.MaxAudienceRating
+	filterSQL, filterArgs := filters.sql("b")
+	query := `
+        SELECT b.id, b.uuid, b.slug, b.title, b.author, b.page_count, bc.likes, b.cover_id
+        FROM book_counters bc
+        JOIN books b ON b.id = bc.book_id
+        WHERE bc.organization_id = (SELECT organization_id FROM users WHERE id = ?) AND bc.likes > 0
+          AND (b.organization_id IS NULL OR b.organization_id = bc.organization_id)
+          AND b.deleted_at IS NULL AND b.merged_into IS NULL
+          AND b.id NOT IN (` + recommend.KnownBooksSQL + `)` + filterSQL + `
+        ORDER BY bc.likes DESC, b.id
+        LIMIT 10;
+    `
+	rows, err := q.QueryContext(ctx, query, append([]interface{}{userID, userID, userID}, filterArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	recs := []gin.H{}
+	for rows.Next() {
+		var id, likes int
+		var publicID, slug, title string
+		var author sql.NullString
+		var pages, cover sql.NullInt64
+		if err := rows.Scan(&id, &publicID, &slug, &title, &author, &pages, &likes, &cover); err != nil {
+			return nil, err
+		}
+		recs = append(recs, gin.H{
+			"book_id":       id,
+			"book_uuid":     publicID,
+			"slug":          slug,
+			"title":         title,
+			"author":        author.String,
+			"page_count":    nullableInt(pages),
+			"reading_hours": readingHours(pages),
+			"cover_url":     coverURL(cover),
+			"score":         likes,
+		})
+	}
+	return recs, rows.Err()
+}
+
+// hybridReasons explains each book the way the signal that best ties it to
+// the user would: a co-liked book first, then shared subjects, and
+// otherwise its popularity
+func hybridReasons(ctx context.Context, q querier, userID int, ids []interface{}) (map[int]gin.H, error) {
+	reasons, err := coLikedReasons(ctx, q, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	var rest []interface{}
+	for _, id := range ids {
+		if n, _ := id.(int); reasons[n] == nil {
+			rest = append(rest, id)
+		}
+	}
+	if len(rest) > 0 {
+		bySubject, err := subjectReasons(ctx, q, userID, rest)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range rest {
+			n, _ := id.(int)
+			if r := bySubject[n]; r != nil {
+				reasons[n] = r
+			} else {
+				reasons[n] = gin.H{"type": "popular", "text": "Popular with readers in your organization"}
+			}
+		}
+	}
+	return reasons, nil
+}
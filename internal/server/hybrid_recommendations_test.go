@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/recommend"
+)
+
+func TestParseHybridWeights(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	parse := func(query string) (hybridWeights, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/recommendations/2?"+query, nil)
+		return parseHybridWeights(c)
+	}
+
+	w, err := parse("")
+	if err != nil || w != defaultHybridWeights {
+		t.Fatalf("expected the defaults, got %+v, %v", w, err)
+	}
+	w, err = parse("w_content=2&w_popularity=0")
+	if err != nil || w.Collaborative != defaultHybridWeights.Collaborative || w.Content != 2 || w.Popularity != 0 {
+		t.Fatalf("expected content weighted 2 and popularity 0, got %+v, %v", w, err)
+	}
+	for _, query := range []string{"w_content=-1", "w_popularity=lots", "w_collaborative=NaN", "w_collaborative=0&w_content=0&w_popularity=0"} {
+		if _, err := parse(query); err == nil {
+			t.Fatalf("expected %q rejected", query)
+		}
+	}
+}
+
+func TestRecommendationsHandler_HybridMode(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	recColumns := []string{"id", "uuid", "slug", "title", "author", "page_count", "score", "cover_id"}
+	noPrefs := func() {
+		mock.ExpectQuery("SELECT avoid_content_warnings, max_audience_rating FROM users WHERE id = \\?").
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"avoid_content_warnings", "max_audience_rating"}).AddRow("", nil))
+	}
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	// a reader with a single like: no neighbours, one subject match
+	noPrefs()
+	mock.ExpectQuery("ORDER BY s.score DESC, s.strongest DESC, b.id").
+		WithArgs(2, recommend.Neighbors, 2, 2).
+		WillReturnRows(sqlmock.NewRows(recColumns))
+	noPrefs()
+	mock.ExpectQuery("WITH liked AS").
+		WithArgs(2, 2, 2, 2).
+		WillReturnRows(sqlmock.NewRows(recColumns).
+			AddRow(9, "b-9", "earthsea-b9", "A Wizard of Earthsea", "Ursula K. Le Guin", nil, 0.5, nil))
+	noPrefs()
+	mock.ExpectQuery("FROM book_counters bc[\\s\\S]+ORDER BY bc.likes DESC, b.id").
+		WithArgs(2, 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "page_count", "likes", "cover_id"}).
+			AddRow(8, "b-8", "the-hobbit-b8", "The Hobbit", "J.R.R. Tolkien", 310, 40, nil).
+			AddRow(9, "b-9", "earthsea-b9", "A Wizard of Earthsea", "Ursula K. Le Guin", nil, 10, nil))
+	// reasons: nothing co-liked, Earthsea by subject, The Hobbit by popularity
+	mock.ExpectQuery("SELECT r.book_id, b.uuid, b.slug, b.title, COUNT\\(DISTINCT r.user_id\\) AS readers").
+		WithArgs(2, 9, 8).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "uuid", "slug", "title", "readers"}))
+	mock.ExpectQuery("SELECT b.uuid, b.slug, b.title, b.subjects\\s+FROM interactions i").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "slug", "title", "subjects"}).
+			AddRow("b-4", "tombs-b4", "The Tombs of Atuan", `["Fantasy", "Wizards"]`))
+	mock.ExpectQuery("SELECT id, subjects FROM books WHERE id IN \\(\\?, \\?\\)").
+		WithArgs(9, 8).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subjects"}).
+			AddRow(9, `["fantasy", "wizards"]`).
+			AddRow(8, `["Dragons"]`))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/recommendations/:user_id", asUser(2), RecommendationsHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recommendations/2?mode=hybrid&w_content=0.5&w_popularity=0.5", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var recs []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &recs); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	// Earthsea: 0.5 * 0.5/0.5 + 0.5 * 10/40; The Hobbit: 0.5 * 40/40
+	if len(recs) != 2 || recs[0]["slug"] != "earthsea-b9" || recs[0]["score"] != 0.625 ||
+		recs[1]["slug"] != "the-hobbit-b8" || recs[1]["score"] != 0.5 {
+		t.Fatalf("expected Earthsea then The Hobbit with blended scores, got %s", w.Body.String())
+	}
+	if reason, _ := recs[0]["reason"].(map[string]interface{}); reason == nil || reason["type"] != "subjects" {
+		t.Fatalf("expected Earthsea explained by subjects, got %v", recs[0]["reason"])
+	}
+	if reason, _ := recs[1]["reason"].(map[string]interface{}); reason == nil || reason["type"] != "popular" {
+		t.Fatalf("expected The Hobbit explained by its popularity, got %v", recs[1]["reason"])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/store"
+)
+
+// errRefNotFound means an id/uuid/slug reference matched nothing
+var errRefNotFound = store.ErrNotFound
+
+// records is the store users, books and interactions are looked up
+// through. Run points it at the database.
+var records store.Store
+
+// resolveRef maps a client-supplied reference to the internal integer id
+// (see store.ResolveRef) for the tables records doesn't cover
+func resolveRef(ctx context.Context, table, slugColumn, scope, raw string) (int, error) {
+	return store.ResolveRef(ctx, db, table, slugColumn, scope, raw)
+}
+
+// hideInternalIDs drops the integer ids result rows carry for the server's
+// own follow-up queries (includes, reasons, ...) once those are done, so
+// clients only ever see UUIDs and slugs
+func hideInternalIDs[M ~map[string]interface{}](rows []M, keys ...string) {
+	for _, row := range rows {
+		for _, k := range keys {
+			delete(row, k)
+		}
+	}
+}
+
+// publicRef returns the uuid of table's row id, for payloads that refer to
+// a row the handler only has the integer id of
+func publicRef(ctx context.Context, q querier, table string, id int) (string, error) {
+	var publicID string
+	err := q.QueryRowContext(ctx, "SELECT uuid FROM "+table+" WHERE id = ?", id).Scan(&publicID)
+	return publicID, err
+}
+
+// publicRefs is publicRef for each of ids, in order
+func publicRefs(ctx context.Context, q querier, table string, ids ...int) ([]string, error) {
+	refs := make([]string, len(ids))
+	for i, id := range ids {
+		var err error
+		if refs[i], err = publicRef(ctx, q, table, id); err != nil {
+			return nil, err
+		}
+	}
+	return refs, nil
+}
+
+// newURLToken returns an unguessable 256-bit token for links that work
+// without logging in (digest unsubscribe, list sharing)
+func newURLToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// newInviteCode returns a short code people can type: 10 base32 characters
+// (48 random bits)
+func newInviteCode() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
+
+// orgScope and liveScope are the resolveRef scopes for tables owned by one
+// organization, the latter leaving soft-deleted rows out
+const orgScope = store.OrgScope
+
+var liveScope = store.LiveScope
+
+func resolveUserRef(ctx context.Context, raw string) (int, error) {
+	return records.ResolveUser(ctx, raw)
+}
+
+func resolveBookRef(ctx context.Context, raw string) (int, error) {
+	return records.ResolveBook(ctx, raw)
+}
+
+func resolveInteractionRef(ctx context.Context, raw string) (int, error) {
+	return records.ResolveInteraction(ctx, raw)
+}
+
+// resolveParam answers 404/500 itself and reports whether the handler should continue
+func resolveParam(c *gin.Context, resolve func(context.Context, string) (int, error), raw, what string) (int, bool) {
+	id, err := resolve(c.Request.Context(), raw)
+	if errors.Is(err, errRefNotFound) {
+		abortWithError(c, notFound(what+" not found"))
+		return 0, false
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/store"
+)
+
+// liveDB forwards to whichever db a test has swapped in, so records
+// follows it
+type liveDB struct{}
+
+func (liveDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.QueryContext(ctx, query, args...)
+}
+
+func (liveDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.QueryRowContext(ctx, query, args...)
+}
+
+// The handler tests address records by integer id, which only resolves
+// under the deprecated LEGACY_INTEGER_IDS; TestGetBookHandler_IntegerID and
+// store's tests cover rejecting them by default
+func init() {
+	records = store.New(liveDB{})
+	store.LegacyIntegerIDs = true
+}
+
+// expectPublicRef answers publicRef's lookup of table's row id
+func expectPublicRef(mock sqlmock.Sqlmock, table string, id int, publicID string) {
+	mock.ExpectQuery("SELECT uuid FROM " + table + " WHERE id = \\?").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid"}).AddRow(publicID))
+}
+
+func TestGetBookHandler_IntegerID(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	store.LegacyIntegerIDs = false
+	defer func() { store.LegacyIntegerIDs = true }()
+
+	// "3" is only ever a slug now
+	mock.ExpectQuery("SELECT id FROM books WHERE slug = \\?").
+		WithArgs("3", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/:id", GetBookHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/3", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected an integer id answered 404, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestGetBookHandler_BySlug(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT id FROM books WHERE slug = \\?").
+		WithArgs("the-hobbit-1b4e28ba", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery("SELECT uuid, slug, title, author, author_id, published_year, open_library_key, subjects, formats, page_count, content_warnings, audience_rating, version, cover_id, language, publisher\\s+FROM books WHERE id = \\?").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "slug", "title", "author", "author_id", "published_year", "open_library_key", "subjects", "formats", "page_count", "content_warnings", "audience_rating", "version", "cover_id", "language", "publisher"}).
+			AddRow("1b4e28ba-2fa1-11d2-883f-0016d3cca427", "the-hobbit-1b4e28ba", "The Hobbit", "J.R.R. Tolkien", 2, 1937, "/works/OL262758W", `["Fantasy","Dragons"]`, "print", 310, "", nil, 2, 6979861, "en", "Allen & Unwin"))
+	mock.ExpectQuery("SELECT likes, ratings, rating_sum / NULLIF\\(ratings, 0\\)\\s+FROM book_counters").
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"likes", "ratings", "avg"}).AddRow(12, 4, 4.25))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\)\\s+FROM interactions\\s+WHERE organization_id = \\? AND book_id = \\? AND action = 'view'").
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(30))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM reviews WHERE organization_id = \\? AND book_id = \\? AND deleted_at IS NULL").
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectQuery("SELECT isbn13 FROM book_isbns WHERE book_id = \\? ORDER BY isbn13").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"isbn13"}).AddRow("9780261102217").AddRow("9791090636071"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/:id", GetBookHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/the-hobbit-1b4e28ba", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if body["uuid"] != "1b4e28ba-2fa1-11d2-883f-0016d3cca427" || body["title"] != "The Hobbit" {
+		t.Fatalf("unexpected body: %v", body)
+	}
+	stats, _ := body["stats"].(map[string]any)
+	if body["open_library_key"] != "/works/OL262758W" || len(body["subjects"].([]any)) != 2 ||
+		stats["likes"] != 12.0 || stats["avg_rating"] != 4.25 || stats["views"] != 30.0 || stats["reviews"] != 5.0 {
+		t.Fatalf("unexpected metadata or stats: %v", body)
+	}
+	if body["cover_url"] != "https://covers.openlibrary.org/b/id/6979861-M.jpg" {
+		t.Fatalf("unexpected cover_url %v", body["cover_url"])
+	}
+	// a 979 ISBN has no ISBN-10
+	isbns, _ := body["isbns"].([]any)
+	if body["language"] != "en" || body["publisher"] != "Allen & Unwin" || len(isbns) != 2 ||
+		isbns[0].(map[string]any)["isbn10"] != "0261102214" || isbns[1].(map[string]any)["isbn10"] != nil {
+		t.Fatalf("unexpected edition metadata: %v", body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// Supported ?include= values for book endpoints
+const (
+	includeAuthor    = "author"
+	includeGenres    = "genres"
+	includeAvgRating = "avg_rating"
+	includeLinks     = "links"
+)
+
+// parseIncludes turns "?include=author,genres" into a set, rejecting unknown values.
+func parseIncludes(raw string) (map[string]bool, error) {
+	includes := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		switch part {
+		case includeAuthor, includeGenres, includeAvgRating, includeLinks:
+			includes[part] = true
+		default:
+			return nil, fmt.Errorf("unsupported include: %s", part)
+		}
+	}
+	return includes, nil
+}
+
+// placeholders returns "?, ?, ?" for n arguments (used for IN clauses)
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// applyIncludes expands related data onto a page of book payloads.
+// Each include is loaded with a single batched query for the whole page
+// so clients don't have to issue one follow-up call per book.
+// Counts and ratings are scoped to the tenant on ctx.
+func applyIncludes(ctx context.Context, books []map[string]interface{}, includes map[string]bool) error {
+	if len(books) == 0 || len(includes) == 0 {
+		return nil
+	}
+
+	ids := make([]interface{}, 0, len(books))
+	for _, b := range books {
+		ids = append(ids, b["id"])
+	}
+
+	if includes[includeLinks] {
+		if err := includeBookLinks(ctx, books, ids); err != nil {
+			return err
+		}
+	}
+	if includes[includeAuthor] {
+		if err := includeAuthors(ctx, books); err != nil {
+			return err
+		}
+	}
+	if includes[includeGenres] {
+		if err := includeBookGenres(ctx, books, ids); err != nil {
+			return err
+		}
+	}
+	if includes[includeAvgRating] {
+		if err := includeAvgRatings(ctx, books, ids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// includeAuthors replaces the author string with {id, name, book_count};
+// id is the author's (see GET /authors/{id}), null for a book not linked to
+// one
+func includeAuthors(ctx context.Context, books []map[string]interface{}) error {
+	seen := map[string]bool{}
+	names := []interface{}{}
+	for _, b := range books {
+		name, _ := b["author"].(string)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	counts := map[string]int{}
+	authorIDs := map[string]interface{}{}
+	if len(names) > 0 {
+		args := append(names, tenant.ID(ctx))
+		rows, err := db.QueryContext(ctx, `
+			SELECT author, MAX(author_id), COUNT(*)
+			FROM books
+			WHERE author IN (`+placeholders(len(names))+`) AND `+tenant.BooksVisibleSQL("")+`
+			GROUP BY author`, args...)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rows.Close() }()
+
+		for rows.Next() {
+			var name string
+			var authorID sql.NullInt64
+			var count int
+			if err := rows.Scan(&name, &authorID, &count); err != nil {
+				return err
+			}
+			counts[name] = count
+			authorIDs[name] = nullableInt(authorID)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+
+	for _, b := range books {
+		name, _ := b["author"].(string)
+		b["author"] = map[string]interface{}{
+			"id":         authorIDs[name],
+			"name":       name,
+			"book_count": counts[name],
+		}
+	}
+	return nil
+}
+
+// includeBookGenres adds the slugs of the curated genres each book is filed
+// under in book_genres
+func includeBookGenres(ctx context.Context, books []map[string]interface{}, ids []interface{}) error {
+	rows, err := db.QueryContext(ctx, `
+		SELECT bg.book_id, g.slug
+		FROM book_genres bg
+		JOIN genres g ON g.id = bg.genre_id
+		WHERE bg.book_id IN (`+placeholders(len(ids))+`)
+		ORDER BY bg.book_id, g.slug`, ids...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	genres := map[int][]string{}
+	for rows.Next() {
+		var id int
+		var slug string
+		if err := rows.Scan(&id, &slug); err != nil {
+			return err
+		}
+		genres[id] = append(genres[id], slug)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, b := range books {
+		id, _ := b["id"].(int)
+		list := genres[id]
+		if list == nil {
+			list = []string{}
+		}
+		b["genres"] = list
+	}
+	return nil
+}
+
+// includeAvgRatings adds avg_rating (null when unrated), rating_count and
+// review_count
+func includeAvgRatings(ctx context.Context, books []map[string]interface{}, ids []interface{}) error {
+	args := append([]interface{}{tenant.ID(ctx)}, ids...)
+	rows, err := db.QueryContext(ctx, `
+		SELECT book_id, rating_sum / ratings, ratings
+		FROM book_counters
+		WHERE organization_id = ? AND ratings > 0
+		  AND book_id IN (`+placeholders(len(ids))+`)`, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	type ratingAgg struct {
+		avg   float64
+		count int
+	}
+	aggs := map[int]ratingAgg{}
+	for rows.Next() {
+		var id, count int
+		var avg float64
+		if err := rows.Scan(&id, &avg, &count); err != nil {
+			return err
+		}
+		aggs[id] = ratingAgg{avg: avg, count: count}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	reviews, err := db.QueryContext(ctx, `
+		SELECT book_id, COUNT(*)
+		FROM reviews
+		WHERE organization_id = ? AND book_id IN (`+placeholders(len(ids))+`) AND deleted_at IS NULL
+		GROUP BY book_id`, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = reviews.Close() }()
+	reviewCounts := map[int]int{}
+	for reviews.Next() {
+		var id, count int
+		if err := reviews.Scan(&id, &count); err != nil {
+			return err
+		}
+		reviewCounts[id] = count
+	}
+	if err := reviews.Err(); err != nil {
+		return err
+	}
+
+	for _, b := range books {
+		id, _ := b["id"].(int)
+		if agg, ok := aggs[id]; ok {
+			b["avg_rating"] = agg.avg
+			b["rating_count"] = agg.count
+		} else {
+			b["avg_rating"] = nil
+			b["rating_count"] = 0
+		}
+		b["review_count"] = reviewCounts[id]
+	}
+	return nil
+}
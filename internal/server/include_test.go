@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestParseIncludes(t *testing.T) {
+	includes, err := parseIncludes(" author, AVG_RATING ,,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !includes[includeAuthor] || !includes[includeAvgRating] || includes[includeGenres] {
+		t.Fatalf("unexpected includes: %v", includes)
+	}
+
+	if _, err := parseIncludes("author,reviews"); err == nil {
+		t.Fatalf("expected error for unsupported include")
+	}
+}
+
+func TestListBooksHandler_Include(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT id, uuid, slug, title, author, published_year, formats, page_count, content_warnings, audience_rating, cover_id, language, publisher\\s+FROM books").
+		WithArgs(1, 21, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "published_year", "formats", "page_count", "content_warnings", "audience_rating", "cover_id", "language", "publisher"}).
+			AddRow(1, "b-1", "book-a-b1", "Book A", "Author A", 2001, "print", 320, "", nil, nil, nil, nil).
+			AddRow(2, "b-2", "book-b-b2", "Book B", "Author B", 2002, "print,ebook", nil, "", nil, nil, nil, nil))
+	mock.ExpectQuery("SELECT bg.book_id, g.slug\\s+FROM book_genres bg\\s+JOIN genres g ON g.id = bg.genre_id\\s+WHERE bg.book_id IN").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "slug"}).
+			AddRow(1, "fantasy").
+			AddRow(1, "young-adult"))
+	mock.ExpectQuery("SELECT book_id, rating_sum / ratings, ratings\\s+FROM book_counters").
+		WithArgs(1, 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "avg", "count"}).
+			AddRow(1, 4.5, 2))
+	mock.ExpectQuery("SELECT book_id, COUNT\\(\\*\\)\\s+FROM reviews[\\s\\S]+AND deleted_at IS NULL").
+		WithArgs(1, 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "count"}).
+			AddRow(2, 3))
+
+	r := setupRouter()
+	req := httptest.NewRequest(http.MethodGet, "/books?include=genres,avg_rating", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+
+	var body struct {
+		Data []map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(body.Data) != 2 {
+		t.Fatalf("expected 2 books, got %d", len(body.Data))
+	}
+	if body.Data[0]["avg_rating"] != 4.5 || body.Data[1]["avg_rating"] != nil {
+		t.Fatalf("unexpected avg_rating values: %v", body.Data)
+	}
+	if body.Data[0]["review_count"] != 0.0 || body.Data[1]["review_count"] != 3.0 {
+		t.Fatalf("unexpected review_count values: %v", body.Data)
+	}
+	if genres, ok := body.Data[0]["genres"].([]any); !ok || len(genres) != 2 {
+		t.Fatalf("unexpected genres: %v", body.Data[0]["genres"])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestListBooksHandler_UnsupportedInclude(t *testing.T) {
+	r := setupRouter()
+	req := httptest.NewRequest(http.MethodGet, "/books?include=reviews", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
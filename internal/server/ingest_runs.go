@@ -0,0 +1,277 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/ingest"
+	"github.com/YeswanthC7/bookrec/internal/jobrun"
+)
+
+// ingestPollInterval is how often workers look for queued ingest runs
+var ingestPollInterval = 5 * time.Second
+
+// ingestSchedule is the nightly refresh, read from the environment by Run
+// and RunWorkers
+var ingestSchedule ingest.Schedule
+
+// Limits on what one queued ingest run fetches
+const (
+	maxIngestCategories     = 20
+	maxIngestCategoryLength = 100
+)
+
+// IngestRequest is the body of POST /admin/ingest; both fields are optional
+type IngestRequest struct {
+	// Source defaults to openlibrary
+	Source string `json:"source,omitempty" enums:"openlibrary,googlebooks" example:"googlebooks"`
+	// Categories default to the ingest job's own
+	Categories []string `json:"categories,omitempty" example:"fantasy,poetry"`
+}
+
+// IngestRun is an ingest job run with what it fetches and, once it's over,
+// what it got. Runs started with bookrec ingest have no source or
+// categories.
+type IngestRun struct {
+	JobRun
+	Source     string   `json:"source,omitempty" example:"googlebooks"`
+	Categories []string `json:"categories,omitempty"`
+	// ScheduledFor is the night (UTC) a scheduled run was queued for
+	ScheduledFor string `json:"scheduled_for,omitempty" example:"2024-05-01"`
+	// Books added or refreshed, when the run is over
+	Books *int `json:"books,omitempty" example:"37"`
+	// FailedCategories couldn't be fetched, when the run is over
+	FailedCategories *int `json:"failed_categories,omitempty" example:"0"`
+}
+
+// QueueIngestHandler godoc
+// @Summary Queue a catalogue ingestion run
+// @Description Queues what bookrec ingest does, for the workers to run: bookrec serve (unless started with --workers=false) or bookrec worker, which pick it up within seconds. The run starts out queued; follow it at the Location (GET /admin/ingest/{job_id}) or on GET /admin/jobs/stream. Platform admins only.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param body body IngestRequest false "What to fetch"
+// @Success 202 {object} IngestRun
+// @Header 202 {string} Location "/admin/ingest/{job_id}"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/ingest [post]
+func QueueIngestHandler(c *gin.Context) {
+	var req IngestRequest
+	if err := decodeJSON(c, &req); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	p, err := req.params()
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	id, err := jobrun.Enqueue(ctx, db, ingest.Job, len(p.Categories), p)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	run, err := loadIngestRun(ctx, id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Header("Location", "/admin/ingest/"+strconv.FormatInt(id, 10))
+	c.JSON(http.StatusAccepted, run)
+}
+
+// params checks the request and fills in the defaults
+func (r IngestRequest) params() (ingest.Params, error) {
+	p := ingest.Params{Source: strings.TrimSpace(r.Source), Categories: ingest.DefaultCategories}
+	if p.Source == "" {
+		p.Source = ingest.DefaultSource
+	}
+	if !ingest.ValidSource(p.Source) {
+		return p, errors.New("source must be openlibrary or googlebooks")
+	}
+	if len(r.Categories) > maxIngestCategories {
+		return p, fmt.Errorf("at most %d categories per run", maxIngestCategories)
+	}
+	if len(r.Categories) > 0 {
+		p.Categories = make([]string, 0, len(r.Categories))
+		for _, cat := range r.Categories {
+			cat = strings.TrimSpace(cat)
+			if cat == "" || len(cat) > maxIngestCategoryLength {
+				return p, fmt.Errorf("categories must be 1 to %d characters", maxIngestCategoryLength)
+			}
+			p.Categories = append(p.Categories, cat)
+		}
+	}
+	return p, nil
+}
+
+// GetIngestRunHandler godoc
+// @Summary An ingestion run's status
+// @Description status is queued, running, succeeded or failed; processed counts the categories done out of total. Once a run is over, books and failed_categories total it up; scheduled_for marks the nightly refresh. Runs started with bookrec ingest are found too. Platform admins only.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param job_id path int true "Job run ID"
+// @Success 200 {object} IngestRun
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/ingest/{job_id} [get]
+func GetIngestRunHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("job_id"), 10, 64)
+	if err != nil {
+		abortWithError(c, notFound("ingest run not found"))
+		return
+	}
+	run, err := loadIngestRun(c.Request.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("ingest run not found"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, run)
+}
+
+func loadIngestRun(ctx context.Context, id int64) (*IngestRun, error) {
+	var run IngestRun
+	var message sql.NullString
+	var finishedAt, scheduledFor sql.NullTime
+	var books, failed sql.NullInt64
+	var params []byte
+	if err := db.QueryRowContext(ctx, `
+		SELECT jr.id, jr.job, jr.status, jr.processed, jr.total, jr.message, jr.started_at, jr.updated_at, jr.finished_at, jr.params,
+			ir.scheduled_for, ir.books, ir.failed_categories
+		FROM job_runs jr
+		LEFT JOIN ingest_runs ir ON ir.job_run_id = jr.id
+		WHERE jr.id = ? AND jr.job = ?`, id, ingest.Job).
+		Scan(&run.ID, &run.Job, &run.Status, &run.Processed, &run.Total,
+			&message, &run.StartedAt, &run.UpdatedAt, &finishedAt, &params,
+			&scheduledFor, &books, &failed); err != nil {
+		return nil, err
+	}
+	run.Message = message.String
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	if len(params) > 0 {
+		var p ingest.Params
+		if err := json.Unmarshal(params, &p); err == nil {
+			run.Source, run.Categories = p.Source, p.Categories
+		}
+	}
+	if scheduledFor.Valid {
+		run.ScheduledFor = scheduledFor.Time.Format(time.DateOnly)
+	}
+	if books.Valid {
+		n := int(books.Int64)
+		run.Books = &n
+	}
+	if failed.Valid {
+		n := int(failed.Int64)
+		run.FailedCategories = &n
+	}
+	return &run, nil
+}
+
+// runIngestWorker runs queued ingest runs, one at a time, until ctx is
+// cancelled. A run cut short by shutdown is marked failed.
+func runIngestWorker(ctx context.Context) {
+	ticker := time.NewTicker(ingestPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for ctx.Err() == nil {
+			ran, err := runQueuedIngest(ctx)
+			if err != nil {
+				log.Printf("⚠️ ingest worker: %v", err)
+			}
+			if !ran {
+				break
+			}
+		}
+	}
+}
+
+// runQueuedIngest claims and runs the oldest queued ingest run, and reports
+// whether there was one
+func runQueuedIngest(ctx context.Context) (bool, error) {
+	run, raw, err := jobrun.Claim(ctx, db, ingest.Job)
+	if err != nil || run == nil {
+		return false, err
+	}
+	var p ingest.Params
+	if err := json.Unmarshal(raw, &p); err != nil {
+		run.Finish("failed", "unreadable params: "+err.Error())
+		return true, fmt.Errorf("ingest run %d: %w", run.ID, err)
+	}
+
+	log.Printf("📥 Running queued ingest run %d", run.ID)
+	total, err := ingest.Run(ctx, db, p, run)
+	if err != nil {
+		return true, fmt.Errorf("ingest run %d: %w", run.ID, err)
+	}
+	if err := enqueueWebhookEvent(ctx, "ingest.completed", ingest.EventData(run, p, total)); err != nil {
+		log.Printf("⚠️ webhook enqueue failed for ingest.completed: %v", err)
+	}
+	return true, nil
+}
+
+// runIngestScheduler queues the nightly ingest run, if one is scheduled, at
+// its time plus a random part of its jitter, until ctx is cancelled. Nights
+// missed while no worker was running aren't made up.
+func runIngestScheduler(ctx context.Context) {
+	if !ingestSchedule.Enabled {
+		return
+	}
+	log.Printf("🗓️ Scheduled ingest of %s from %s daily at %s UTC (up to %s later)",
+		strings.Join(ingestSchedule.Params.Categories, ", "), ingestSchedule.Params.Source,
+		time.Time{}.Add(ingestSchedule.At).Format("15:04"), ingestSchedule.Jitter)
+
+	for {
+		slot := ingestSchedule.Next(time.Now())
+		wait := time.Until(slot)
+		if ingestSchedule.Jitter > 0 {
+			wait += rand.N(ingestSchedule.Jitter)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		id, queued, err := ingest.EnqueueScheduled(ctx, db, ingestSchedule.Params, slot)
+		switch {
+		case err != nil:
+			log.Printf("⚠️ ingest scheduler: %v", err)
+		case queued:
+			log.Printf("🗓️ Queued the scheduled ingest run %d for %s", id, slot.Format(time.DateOnly))
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+var ingestRunColumns = []string{"id", "job", "status", "processed", "total", "message", "started_at", "updated_at", "finished_at", "params", "scheduled_for", "books", "failed_categories"}
+
+func TestQueueIngestHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	params := `{"source":"googlebooks","categories":["poetry","fantasy"]}`
+	mock.ExpectExec("INSERT INTO job_runs \\(job, status, total, params\\) VALUES \\(\\?, 'queued', \\?, \\?\\)").
+		WithArgs("ingest", 2, params).
+		WillReturnResult(sqlmock.NewResult(12, 1))
+	now := time.Now()
+	mock.ExpectQuery("LEFT JOIN ingest_runs ir ON ir.job_run_id = jr.id\\s+WHERE jr.id = \\? AND jr.job = \\?").
+		WithArgs(int64(12), "ingest").
+		WillReturnRows(sqlmock.NewRows(ingestRunColumns).AddRow(12, "ingest", "queued", 0, 2, nil, now, now, nil, params, nil, nil, nil))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorMiddleware())
+	r.POST("/admin/ingest", QueueIngestHandler)
+	req := httptest.NewRequest(http.MethodPost, "/admin/ingest",
+		strings.NewReader(`{"source":"googlebooks","categories":[" poetry ","fantasy"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted || w.Header().Get("Location") != "/admin/ingest/12" {
+		t.Fatalf("expected 202 with a Location, got %d %v: %s", w.Code, w.Header(), w.Body.String())
+	}
+	var run IngestRun
+	if err := json.Unmarshal(w.Body.Bytes(), &run); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if run.ID != 12 || run.Status != "queued" || run.Source != "googlebooks" || len(run.Categories) != 2 {
+		t.Fatalf("unexpected run %+v", run)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestQueueIngestHandler_Invalid(t *testing.T) {
+	var err error
+	db, _, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorMiddleware())
+	r.POST("/admin/ingest", QueueIngestHandler)
+	for _, body := range []string{
+		`{"source":"amazon"}`,
+		`{"categories":["fantasy","  "]}`,
+		`{"categories":"fantasy"}`,
+		`{"categories":[` + strings.Repeat(`"fantasy",`, maxIngestCategories) + `"poetry"]}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/ingest", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestGetIngestRunHandler_NotFound(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// another job's run isn't an ingest run
+	mock.ExpectQuery("LEFT JOIN ingest_runs ir ON ir.job_run_id = jr.id\\s+WHERE jr.id = \\? AND jr.job = \\?").
+		WithArgs(int64(5), "ingest").
+		WillReturnRows(sqlmock.NewRows(ingestRunColumns))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorMiddleware())
+	r.GET("/admin/ingest/:job_id", GetIngestRunHandler)
+	for _, path := range []string{"/admin/ingest/5", "/admin/ingest/latest"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404, got %d", path, w.Code)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestRunQueuedIngest_FailsUnknownSource(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// a run queued before a source was retired fails instead of fetching
+	mock.ExpectQuery("SELECT id, params FROM job_runs").
+		WithArgs("ingest").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "params"}).AddRow(9, `{"source":"retired","categories":["fantasy"]}`))
+	mock.ExpectExec("UPDATE job_runs SET status = 'running'").
+		WithArgs(int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE job_runs SET status = \\?, message = \\?, finished_at = NOW\\(\\) WHERE id = \\?").
+		WithArgs("failed", `unknown source "retired"`, int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id, params FROM job_runs").
+		WithArgs("ingest").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "params"}))
+
+	ran, err := runQueuedIngest(context.Background())
+	if !ran || err == nil {
+		t.Fatalf("expected the run to fail, got %v, %v", ran, err)
+	}
+	if ran, err := runQueuedIngest(context.Background()); ran || err != nil {
+		t.Fatalf("expected nothing queued, got %v, %v", ran, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestGetIngestRunHandler_ScheduledAndFinished(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	night := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("LEFT JOIN ingest_runs").
+		WithArgs(int64(30), "ingest").
+		WillReturnRows(sqlmock.NewRows(ingestRunColumns).AddRow(30, "ingest", "succeeded", 4, 4, "37 books added/updated",
+			now, now, now, `{"source":"openlibrary","categories":["a","b","c","d"]}`, night, 37, 1))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorMiddleware())
+	r.GET("/admin/ingest/:job_id", GetIngestRunHandler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/ingest/30", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var run IngestRun
+	if err := json.Unmarshal(w.Body.Bytes(), &run); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if run.ScheduledFor != "2024-05-01" || run.Books == nil || *run.Books != 37 ||
+		run.FailedCategories == nil || *run.FailedCategories != 1 {
+		t.Fatalf("unexpected run %+v", run)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,929 @@
+//go:build integration
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/YeswanthC7/bookrec/internal/catalog"
+	"github.com/YeswanthC7/bookrec/internal/ingest"
+	"github.com/YeswanthC7/bookrec/internal/store"
+)
+
+// the flows share the seeded catalogue; each signs up its own accounts so
+// they don't depend on one another's data
+
+// books reads the first page of the catalogue
+func books(t *testing.T) []map[string]interface{} {
+	t.Helper()
+	var out []map[string]interface{}
+	for _, b := range call(t, "GET", "/books?limit=50", "", nil).expect(t, 200).data(t) {
+		out = append(out, b.(map[string]interface{}))
+	}
+	if len(out) < 10 {
+		t.Fatalf("expected the seeded catalogue, got %d books", len(out))
+	}
+	return out
+}
+
+// walkPages follows next_cursor from target's first page to its last and
+// returns the uuids of the books on the way
+func walkPages(t *testing.T, target string) []interface{} {
+	t.Helper()
+	var ids []interface{}
+	for next := target; ; {
+		page := call(t, "GET", next, "", nil).expect(t, 200).object(t)
+		for _, b := range page["data"].([]interface{}) {
+			ids = append(ids, b.(map[string]interface{})["uuid"])
+		}
+		cursor, ok := page["next_cursor"].(string)
+		if !ok {
+			return ids
+		}
+		next = target + "&cursor=" + url.QueryEscape(cursor)
+	}
+}
+
+func admin(t *testing.T) *account {
+	t.Helper()
+	return signIn(t, "admin@integration.test")
+}
+
+func TestIntegrationAuth(t *testing.T) {
+	useIntegrationDB()
+
+	health := call(t, "GET", "/healthz", "", nil).expect(t, 200).object(t)
+	if health["schema_version"] == nil || health["schema_version"] != health["schema_latest"] || health["schema_dirty"] != false {
+		t.Fatalf("expected the database fully migrated, got %v", health)
+	}
+	call(t, "HEAD", "/healthz", "", nil).expect(t, 200)
+	if scrape := call(t, "GET", "/metrics", "", nil).expect(t, 200); !strings.Contains(string(scrape.body),
+		`bookrec_http_request_duration_seconds_count{method="GET",route="/healthz",status="200"}`) {
+		t.Fatalf("expected /healthz in the request metrics, got %s", scrape.body)
+	}
+	boss := admin(t)
+	stats := call(t, "GET", "/stats", boss.token, nil).expect(t, 200).object(t)
+	if stats["books"].(float64) < 40 || stats["new_this_week"].(map[string]any)["books"].(float64) < 40 {
+		t.Fatalf("expected the seeded books in stats, got %v", stats)
+	}
+
+	alice := signUp(t, "auth_alice")
+	call(t, "POST", "/users", "", url.Values{
+		"email": {alice.email}, "handle": {"again"}, "password": {integrationPassword},
+	}).expect(t, 409)
+	call(t, "POST", "/login", "", url.Values{
+		"email": {alice.email}, "password": {"wrong"},
+	}).expect(t, 401)
+	// JSON bodies work like forms
+	call(t, "POST", "/auth/register", "", map[string]string{
+		"email": "auth_bob@integration.test", "handle": "auth_bob", "password": integrationPassword,
+	}).expect(t, 201)
+	call(t, "POST", "/auth/login", "", map[string]string{
+		"email": "auth_bob@integration.test", "password": integrationPassword,
+	}).expect(t, 200)
+
+	rotated := call(t, "POST", "/refresh", "", url.Values{"refresh_token": {alice.refresh}}).expect(t, 200).object(t)
+	call(t, "POST", "/refresh", "", url.Values{"refresh_token": {alice.refresh}}).expect(t, 401)
+	call(t, "POST", "/logout", "", url.Values{"refresh_token": {str(t, rotated, "refresh_token")}}).expect(t, 200)
+
+	again := signIn(t, alice.email)
+	call(t, "POST", "/logout-all", again.token, nil).expect(t, 200)
+	call(t, "POST", "/refresh", "", url.Values{"refresh_token": {again.refresh}}).expect(t, 401)
+
+	// stats and the user list (with emails) are for admins only
+	call(t, "GET", "/stats", "", nil).expect(t, 401)
+	call(t, "GET", "/stats", again.token, nil).expect(t, 403)
+	call(t, "GET", "/users", again.token, nil).expect(t, 403)
+	users := call(t, "GET", "/users?limit=1", boss.token, nil).expect(t, 200).object(t)
+	if users["total"].(float64) < 2 || len(users["data"].([]interface{})) != 1 {
+		t.Fatalf("expected one of the admin and alice, got %v", users)
+	}
+	rest := call(t, "GET", "/users?limit=100&cursor="+url.QueryEscape(users["next_cursor"].(string)), boss.token, nil).
+		expect(t, 200).data(t)
+	if len(rest) < 1 {
+		t.Fatalf("expected the users after the first, got %v", rest)
+	}
+	user := call(t, "GET", "/users/"+alice.uuid, "", nil).expect(t, 200).object(t)
+	if user["handle"] != "auth_alice" || user["email"] != nil {
+		t.Fatalf("unexpected user %v", user)
+	}
+	if own := call(t, "GET", "/users/"+alice.uuid, again.token, nil).expect(t, 200).object(t); own["email"] != alice.email {
+		t.Fatalf("expected alice to see her email, got %v", own)
+	}
+	call(t, "GET", "/users/"+alice.uuid, "", nil).expect(t, 200)
+	renamed := call(t, "PATCH", "/users/"+alice.uuid, again.token, map[string]string{"handle": "auth_alice_renamed"}).
+		expect(t, 200).object(t)
+	if renamed["handle"] != "auth_alice_renamed" || renamed["email"] != alice.email {
+		t.Fatalf("expected only the handle to change, got %v", renamed)
+	}
+	call(t, "PATCH", "/users/"+alice.uuid, again.token, url.Values{"email": {"auth_bob@integration.test"}}).expect(t, 409)
+	call(t, "PATCH", "/users/"+alice.uuid, "", url.Values{"handle": {"nobody"}}).expect(t, 401)
+
+	call(t, "GET", "/admin/users", "", nil).expect(t, 401)
+	call(t, "GET", "/admin/users", again.token, nil).expect(t, 403)
+	call(t, "GET", "/admin/users", admin(t).token, nil).expect(t, 200)
+}
+
+func TestIntegrationCatalogue(t *testing.T) {
+	useIntegrationDB()
+	boss := admin(t)
+	catalogue := books(t)
+	first, second := catalogue[0], catalogue[1]
+	slug, bookUUID := str(t, first, "slug"), str(t, first, "uuid")
+
+	call(t, "GET", "/books?include=author,genres,avg_rating,links&format=ebook&min_pages=1", "", nil).expect(t, 200)
+	book := call(t, "GET", "/books/"+slug, "", nil).expect(t, 200).object(t)
+	if book["uuid"] != bookUUID || book["stats"] == nil || book["subjects"] == nil {
+		t.Fatalf("expected book %s with subjects and stats, got %v", bookUUID, book)
+	}
+	if _, ok := book["cover_url"]; !ok {
+		t.Fatalf("expected a cover_url, got %v", book)
+	}
+	call(t, "GET", "/books/"+bookUUID, "", nil).expect(t, 200)
+	// bare integer ids only resolve under the deprecated LEGACY_INTEGER_IDS
+	bookID := query(t, "SELECT id FROM books WHERE uuid = ?", bookUUID)
+	legacy := store.LegacyIntegerIDs
+	store.LegacyIntegerIDs = false
+	call(t, "GET", "/books/"+bookID, "", nil).expect(t, 404)
+	store.LegacyIntegerIDs = true
+	call(t, "GET", "/books/"+bookID, "", nil).expect(t, 200)
+	store.LegacyIntegerIDs = legacy
+	call(t, "GET", "/books/no-such-book-00000000", "", nil).expect(t, 404)
+
+	// the title's longest word, so it isn't a full-text stopword or too short to be indexed
+	title, word := str(t, first, "title"), ""
+	for _, w := range strings.Fields(title) {
+		if len(w) > len(word) {
+			word = w
+		}
+	}
+	found := call(t, "GET", "/books/search?q="+url.QueryEscape(word)+"&sort=year", "", nil).expect(t, 200).data(t)
+	if len(found) == 0 {
+		t.Fatalf("expected %q to find books", title)
+	}
+	ranked := call(t, "GET", "/books/search?q="+url.QueryEscape(word), "", nil).expect(t, 200).data(t)
+	if len(ranked) != len(found) {
+		t.Fatalf("expected relevance and newest to find the same %d books, got %d", len(found), len(ranked))
+	}
+	call(t, "GET", "/books/search?author="+url.QueryEscape(str(t, first, "author"))+"&year_from=1900&year_to=2100&sort=popularity", "", nil).expect(t, 200)
+	// small pages by cursor list every book of one big page, in its order
+	for _, target := range []string{"/books?", "/books/search?sort=relevance", "/books/search?sort=year", "/books/search?sort=popularity"} {
+		var whole []interface{}
+		for _, b := range call(t, "GET", target+"&limit=100", "", nil).expect(t, 200).data(t) {
+			whole = append(whole, b.(map[string]interface{})["uuid"])
+		}
+		if paged := walkPages(t, target+"&limit=7"); !reflect.DeepEqual(paged, whole) {
+			t.Fatalf("%s: paging by cursor gave %v, one page %v", target, paged, whole)
+		}
+	}
+	call(t, "GET", "/books/popular?include=genres", "", nil).expect(t, 200)
+	call(t, "GET", "/books/popular?window=7d&action=view&genre=fantasy&limit=5", "", nil).expect(t, 200)
+	call(t, "GET", "/books/popular?genre=fiction", "", nil).expect(t, 400)
+	call(t, "GET", "/books/popular?action=rating", "", nil).expect(t, 200)
+	compared := call(t, "GET", "/books/compare?ids="+bookUUID+","+str(t, second, "slug"), "", nil).expect(t, 200).object(t)
+	if len(compared["books"].([]interface{})) != 2 {
+		t.Fatalf("expected two books compared, got %v", compared)
+	}
+
+	// the book's author, their books and the most liked authors
+	authorID, ok := book["author_id"].(float64)
+	if !ok {
+		t.Fatalf("expected the book linked to its author, got %v", book["author_id"])
+	}
+	authorPath := fmt.Sprintf("/authors/%d", int(authorID))
+	author := call(t, "GET", authorPath, "", nil).expect(t, 200).object(t)
+	if author["name"] != first["author"] || author["book_count"].(float64) < 1 {
+		t.Fatalf("expected %v's author with their books, got %v", first["author"], author)
+	}
+	if byAuthor := call(t, "GET", authorPath+"/books", "", nil).expect(t, 200).data(t); len(byAuthor) == 0 {
+		t.Fatalf("expected the author's books")
+	}
+	call(t, "GET", "/authors/popular?limit=5", "", nil).expect(t, 200)
+	call(t, "GET", "/authors/999999", "", nil).expect(t, 404)
+
+	// translations: admins write them, everyone reads them
+	call(t, "PUT", "/admin/books/"+slug+"/translations/de", boss.token,
+		map[string]string{"title": "Der Titel", "description": "Beschreibung"}).expect(t, 200)
+	translations := call(t, "GET", "/books/"+slug+"/translations", "", nil).expect(t, 200).array(t)
+	if len(translations) != 1 {
+		t.Fatalf("expected one translation, got %v", translations)
+	}
+	localized := call(t, "GET", "/books/"+slug, "", nil, "Accept-Language", "de-DE").expect(t, 200).object(t)
+	if localized["title"] != "Der Titel" {
+		t.Fatalf("expected the German title, got %v", localized["title"])
+	}
+	call(t, "DELETE", "/admin/books/"+slug+"/translations/de", boss.token, nil).expect(t, 204)
+
+	// edits need the current version
+	etag := call(t, "GET", "/books/"+slug, "", nil).expect(t, 200).header.Get("ETag")
+	call(t, "PATCH", "/admin/books/"+slug, boss.token, map[string]interface{}{"page_count": 321}).expect(t, 428)
+	call(t, "PATCH", "/admin/books/"+slug, boss.token, map[string]interface{}{"page_count": 321}, "If-Match", etag).expect(t, 200)
+	call(t, "PATCH", "/admin/books/"+slug, boss.token, map[string]interface{}{"page_count": 322}, "If-Match", etag).expect(t, 409)
+	batch := call(t, "PATCH", "/admin/books/batch", boss.token, map[string]interface{}{
+		"updates": []map[string]interface{}{
+			{"id": str(t, second, "uuid"), "subjects": []string{"fantasy", "adventure"}, "content_warnings": []string{"violence"}},
+			{"id": "no-such-book-00000000", "page_count": 10},
+		},
+	}).expect(t, 200).object(t)
+	if batch["updated"].(float64) != 1 {
+		t.Fatalf("expected one book updated, got %v", batch)
+	}
+	fantasy := call(t, "GET", "/books?genre=fantasy", "", nil).expect(t, 200).object(t)
+	if books, _ := fantasy["data"].([]interface{}); len(books) != 1 || books[0].(map[string]interface{})["uuid"] != second["uuid"] {
+		t.Fatalf("expected the edited book in fantasy, got %v", fantasy)
+	}
+	call(t, "GET", "/books?genre=space-westerns", "", nil).expect(t, 400)
+	genreList := call(t, "GET", "/genres", "", nil).expect(t, 200).object(t)
+	if list, _ := genreList["data"].([]interface{}); len(list) == 0 {
+		t.Fatalf("expected the seeded genres, got %v", genreList)
+	}
+
+	// books added by hand
+	added := call(t, "POST", "/admin/books", boss.token, map[string]interface{}{
+		"title": "Hand Made", "author": "An Admin", "subjects": []string{"war"}, "slug": "hand-made",
+	}).expect(t, 201)
+	if added.header.Get("Location") != "/books/hand-made" {
+		t.Fatalf("expected the new book's Location, got %q", added.header.Get("Location"))
+	}
+	if book := call(t, "GET", "/books/hand-made", "", nil).expect(t, 200).object(t); book["title"] != "Hand Made" {
+		t.Fatalf("expected the added book, got %v", book)
+	}
+	call(t, "POST", "/admin/books", boss.token, map[string]interface{}{"title": "Again", "slug": "hand-made"}).expect(t, 409)
+	call(t, "POST", "/admin/books", boss.token, map[string]interface{}{"author": "Nobody"}).expect(t, 400)
+	call(t, "DELETE", "/admin/books/hand-made", boss.token, nil).expect(t, 204)
+
+	// a subject repeated in another case counts once on each side of the
+	// Jaccard similarity, so books with the same subjects score 1
+	liked := call(t, "POST", "/admin/books", boss.token, map[string]interface{}{
+		"title": "Tidepools", "subjects": []string{"Tide pools", "tide pools", "Marine life"}, "slug": "tidepools",
+	}).expect(t, 201).object(t)
+	call(t, "POST", "/admin/books", boss.token, map[string]interface{}{
+		"title": "Rockpools", "subjects": []string{"marine life", "Tide Pools"}, "slug": "rockpools",
+	}).expect(t, 201)
+	naturalist := signUp(t, "naturalist")
+	call(t, "POST", "/interactions", naturalist.token, map[string]interface{}{
+		"user_id": naturalist.uuid, "book_id": str(t, liked, "uuid"), "action": "like",
+	}).expect(t, 201)
+	similar := call(t, "GET", "/recommendations/"+naturalist.uuid+"?mode=content", naturalist.token, nil).expect(t, 200).array(t)
+	if len(similar) != 1 || similar[0].(map[string]interface{})["slug"] != "rockpools" || similar[0].(map[string]interface{})["score"] != float64(1) {
+		t.Fatalf("expected Rockpools with a similarity of 1, got %v", similar)
+	}
+	call(t, "DELETE", "/admin/books/tidepools", boss.token, nil).expect(t, 204)
+	call(t, "DELETE", "/admin/books/rockpools", boss.token, nil).expect(t, 204)
+
+	call(t, "GET", "/lookup/isbn/978-0-261-10221-7", "", nil).expect(t, 200)
+	call(t, "GET", "/lookup/isbn/not-an-isbn", "", nil).expect(t, 400)
+	scanned := call(t, "GET", "/books/isbn/0261102214", "", nil).expect(t, 200).object(t)
+	if isbns, _ := scanned["isbns"].([]interface{}); len(isbns) == 0 || scanned["version"] == nil {
+		t.Fatalf("expected the full record with its ISBNs, got %v", scanned)
+	}
+	call(t, "GET", "/books/isbn/9791090636071", "", nil).expect(t, 404)
+
+	call(t, "GET", "/books/"+slug+"/availability?location=US", "", nil).expect(t, 503)
+	libraryProvider = &fakeLibraries{}
+	defer func() { libraryProvider = nil }()
+	call(t, "GET", "/books/"+slug+"/availability?location=US-CA", "", nil).expect(t, 200)
+
+	out := call(t, "GET", "/out/"+slug+"/openlibrary", "", nil).expect(t, 302)
+	if out.header.Get("Location") == "" {
+		t.Fatal("expected the outbound redirect to have a Location")
+	}
+	clicks := call(t, "GET", "/admin/outbound-clicks?days=7", boss.token, nil).expect(t, 200).object(t)
+	if clicks["total"] == float64(0) {
+		t.Fatalf("expected the click to be counted, got %v", clicks)
+	}
+
+	rss := call(t, "GET", "/feeds/new.xml", "", nil).expect(t, 200)
+	if !strings.Contains(string(rss.body), "<rss") {
+		t.Fatalf("expected an RSS feed, got %s", rss.body)
+	}
+	call(t, "GET", "/feeds/trending.xml?format=atom", "", nil).expect(t, 200)
+
+	csv := call(t, "GET", "/admin/export/books", boss.token, nil).expect(t, 200)
+	if strings.Count(string(csv.body), "\n") < 40 {
+		t.Fatalf("expected every book exported, got %s", csv.body)
+	}
+	call(t, "GET", "/admin/export/books?format=jsonl", boss.token, nil).expect(t, 200)
+}
+
+func TestIntegrationReadingActivity(t *testing.T) {
+	useIntegrationDB()
+	boss := admin(t)
+	catalogue := books(t)
+	reader, peer := signUp(t, "activity_reader"), signUp(t, "activity_peer")
+
+	// both like the same books, so the peer's other likes get recommended
+	interact := func(a *account, book map[string]interface{}, form url.Values) map[string]interface{} {
+		form.Set("user_id", a.uuid)
+		form.Set("book_id", str(t, book, "uuid"))
+		return call(t, "POST", "/interactions", a.token, form).expect(t, 201).object(t)
+	}
+	for _, b := range catalogue[:3] {
+		interact(reader, b, url.Values{"action": {"like"}})
+		interact(peer, b, url.Values{"action": {"like"}})
+	}
+	for _, b := range catalogue[3:6] {
+		interact(peer, b, url.Values{"action": {"like"}})
+	}
+	// a high rating recommends a book like a like does, a low one holds it back
+	interact(peer, catalogue[8], url.Values{"action": {"rating"}, "rating": {"1"}})
+	interact(peer, catalogue[9], url.Values{"action": {"rating"}, "rating": {"5"}})
+	rated := interact(reader, catalogue[6], url.Values{"action": {"rating"}, "rating": {"4"}, "visibility": {"private"}})
+	viewed := interact(reader, catalogue[7], url.Values{"action": {"view"}})
+
+	call(t, "POST", "/interactions", reader.token, map[string]interface{}{
+		"user_id": peer.uuid, "book_id": str(t, catalogue[0], "uuid"), "action": "like",
+	}).expect(t, 403)
+	call(t, "POST", "/interactions", reader.token, map[string]interface{}{
+		"user_id": reader.uuid, "book_id": str(t, catalogue[0], "uuid"), "action": "rating", "rating": 6,
+	}).expect(t, 400)
+	call(t, "GET", "/interactions/"+str(t, rated, "uuid"), reader.token, nil).expect(t, 200)
+
+	history := call(t, "GET", "/users/"+reader.uuid+"/history", reader.token, nil).expect(t, 200).data(t)
+	if len(history) != 5 {
+		t.Fatalf("expected 5 interactions in the history, got %d", len(history))
+	}
+	page := call(t, "GET", "/users/"+reader.uuid+"/history?limit=3&since=2000-01-01T00:00:00Z", reader.token, nil).expect(t, 200).object(t)
+	rest := call(t, "GET", "/users/"+reader.uuid+"/history?limit=3&cursor="+url.QueryEscape(page["next_cursor"].(string)), reader.token, nil).expect(t, 200).object(t)
+	if len(page["data"].([]interface{})) != 3 || len(rest["data"].([]interface{})) != 2 || rest["next_cursor"] != nil {
+		t.Fatalf("expected the history in pages of 3 and 2, got %v then %v", page, rest)
+	}
+	call(t, "GET", "/users/"+reader.uuid+"/stats", "", nil).expect(t, 200)
+
+	call(t, "GET", "/users/"+reader.uuid+"/history", "", nil).expect(t, 401)
+	call(t, "GET", "/users/"+reader.uuid+"/history", peer.token, nil).expect(t, 403)
+	recs := call(t, "GET", "/recommendations/"+reader.uuid, reader.token, nil).expect(t, 200).array(t)
+	recommended := map[interface{}]bool{}
+	for _, r := range recs {
+		rec := r.(map[string]interface{})
+		recommended[rec["book_uuid"]] = true
+		if reason, _ := rec["reason"].(map[string]interface{}); reason == nil || reason["type"] != "co_liked" {
+			t.Fatalf("expected each recommendation explained by a co-liked book, got %v", rec)
+		}
+	}
+	if !recommended[catalogue[9]["uuid"]] || recommended[catalogue[8]["uuid"]] {
+		t.Fatalf("expected the peer's 5-star book and not their 1-star one, got %v", recs)
+	}
+	call(t, "GET", "/recommendations/"+reader.uuid+"?format=print,ebook&min_pages=1", reader.token, nil).expect(t, 200)
+	call(t, "GET", "/recommendations/"+reader.uuid+"?mode=content", reader.token, nil).expect(t, 200)
+	call(t, "GET", "/recommendations/"+reader.uuid+"?mode=authors&genre=fantasy", reader.token, nil).expect(t, 200)
+	call(t, "GET", "/recommendations/"+reader.uuid+"?mode=hybrid&w_popularity=0.5", reader.token, nil).expect(t, 200)
+	call(t, "GET", "/recommendations/"+reader.uuid+"?mode=hybrid&w_content=-1", reader.token, nil).expect(t, 400)
+	call(t, "GET", "/recommendations/"+reader.uuid+"?mode=random", reader.token, nil).expect(t, 400)
+	// a dislike takes the book out of the recommendations
+	if !recommended[catalogue[5]["uuid"]] {
+		t.Fatalf("expected the peer's like %v recommended, got %v", catalogue[5]["uuid"], recs)
+	}
+	interact(reader, catalogue[5], url.Values{"action": {"dislike"}})
+	for _, r := range call(t, "GET", "/recommendations/"+reader.uuid, reader.token, nil).expect(t, 200).array(t) {
+		if r.(map[string]interface{})["book_uuid"] == catalogue[5]["uuid"] {
+			t.Fatalf("expected the disliked book left out, got %v", r)
+		}
+	}
+	call(t, "DELETE", "/interactions?book_id="+str(t, catalogue[5], "uuid")+"&action=dislike", reader.token, nil).expect(t, 204)
+	// so does putting it on a shelf
+	shelf := "/users/" + reader.uuid + "/shelves/want-to-read"
+	call(t, "PUT", shelf+"/books/"+str(t, catalogue[9], "uuid"), reader.token, nil).expect(t, 200)
+	call(t, "PUT", shelf+"/books/"+str(t, catalogue[9], "uuid"), peer.token, nil).expect(t, 403)
+	call(t, "PUT", "/users/"+reader.uuid+"/shelves/abandoned/books/"+str(t, catalogue[9], "uuid"), reader.token, nil).expect(t, 400)
+	for _, r := range call(t, "GET", "/recommendations/"+reader.uuid+"?mode=collaborative", reader.token, nil).expect(t, 200).array(t) {
+		if r.(map[string]interface{})["book_uuid"] == catalogue[9]["uuid"] {
+			t.Fatalf("expected the shelved book left out, got %v", r)
+		}
+	}
+	if shelved := call(t, "GET", shelf, reader.token, nil).expect(t, 200).data(t); len(shelved) != 1 {
+		t.Fatalf("expected one book on the shelf, got %v", shelved)
+	}
+	if shelves := call(t, "GET", "/users/"+reader.uuid+"/shelves", reader.token, nil).expect(t, 200).data(t); len(shelves) != 3 {
+		t.Fatalf("expected three shelves, got %v", shelves)
+	}
+	call(t, "GET", shelf, peer.token, nil).expect(t, 403)
+	call(t, "DELETE", "/users/"+reader.uuid+"/shelves/read/books/"+str(t, catalogue[9], "uuid"), reader.token, nil).expect(t, 404)
+	call(t, "DELETE", shelf+"/books/"+str(t, catalogue[9], "uuid"), reader.token, nil).expect(t, 204)
+	call(t, "GET", "/recommendations/"+reader.uuid, peer.token, nil).expect(t, 403)
+	share := call(t, "POST", "/recommendations/"+reader.uuid+"/share", reader.token, nil).expect(t, 201).object(t)
+	token := str(t, share, "share_token")
+	call(t, "GET", "/recommendations/shared/"+token, "", nil).expect(t, 200)
+	call(t, "DELETE", "/recommendations/shared/"+token, peer.token, nil).expect(t, 404)
+	call(t, "DELETE", "/recommendations/shared/"+token, reader.token, nil).expect(t, 204)
+	call(t, "GET", "/recommendations/shared/"+token, "", nil).expect(t, 404)
+
+	call(t, "GET", "/leaderboard?window=all_time", "", nil).expect(t, 200)
+	call(t, "DELETE", "/users/"+peer.uuid+"/leaderboard", peer.token, nil).expect(t, 200)
+	board := call(t, "GET", "/leaderboard?window=all_time&limit=100", "", nil).expect(t, 200).object(t)
+	for _, row := range board["data"].([]interface{}) {
+		if user := row.(map[string]interface{})["user"].(map[string]interface{}); user["handle"] == peer.handle {
+			t.Fatalf("expected the opted-out peer off the leaderboard, got %v", row)
+		}
+	}
+	call(t, "POST", "/users/"+peer.uuid+"/leaderboard", peer.token, nil).expect(t, 200)
+
+	call(t, "GET", "/users/"+reader.uuid+"/content-preferences", reader.token, nil).expect(t, 200)
+	call(t, "PUT", "/users/"+reader.uuid+"/content-preferences", reader.token, map[string]interface{}{
+		"avoid_content_warnings": []string{"violence"}, "max_audience_rating": "teen",
+	}).expect(t, 200)
+	call(t, "GET", "/users/"+reader.uuid+"/content-preferences", peer.token, nil).expect(t, 403)
+
+	call(t, "GET", "/admin/analytics", boss.token, nil).expect(t, 200)
+	jsonl := call(t, "GET", "/admin/export/interactions?format=jsonl&from=2000-01-01", boss.token, nil).expect(t, 200)
+	if !strings.Contains(string(jsonl.body), str(t, viewed, "uuid")) {
+		t.Fatalf("expected the view in the export, got %s", jsonl.body)
+	}
+	call(t, "GET", "/admin/export/interactions", boss.token, nil).expect(t, 200)
+
+	// deleting an interaction takes it out of the counters; restoring brings it back
+	call(t, "DELETE", "/interactions/"+str(t, viewed, "uuid"), peer.token, nil).expect(t, 404)
+	call(t, "DELETE", "/interactions/"+str(t, viewed, "uuid"), reader.token, nil).expect(t, 204)
+	call(t, "GET", "/admin/deleted?resource=interaction", boss.token, nil).expect(t, 200)
+	call(t, "POST", "/admin/interactions/"+str(t, viewed, "uuid")+"/restore", boss.token, nil).expect(t, 204)
+	call(t, "POST", "/admin/interactions/"+str(t, viewed, "uuid")+"/restore", boss.token, nil).expect(t, 409)
+
+	// one like per reader and book: liking again answers with the same row,
+	// and unliking then liking brings it back
+	liked := url.Values{"user_id": {reader.uuid}, "book_id": {str(t, catalogue[0], "uuid")}, "action": {"like"}}
+	again := call(t, "POST", "/interactions", reader.token, liked).expect(t, 200).object(t)
+	call(t, "DELETE", "/interactions?book_id="+str(t, catalogue[0], "uuid"), reader.token, nil).expect(t, 204)
+	call(t, "DELETE", "/interactions?book_id="+str(t, catalogue[0], "uuid"), reader.token, nil).expect(t, 404)
+	back := call(t, "POST", "/interactions", reader.token, liked).expect(t, 201).object(t)
+	if back["uuid"] != again["uuid"] {
+		t.Fatalf("expected the like %v back, got %v", again["uuid"], back["uuid"])
+	}
+}
+
+func TestIntegrationSocial(t *testing.T) {
+	useIntegrationDB()
+	boss := admin(t)
+	catalogue := books(t)
+	ann, ben := signUp(t, "social_ann"), signUp(t, "social_ben")
+
+	call(t, "POST", "/users/"+ben.uuid+"/follow", ann.token, nil).expect(t, 201)
+	call(t, "POST", "/users/"+ben.uuid+"/follow", ann.token, nil).expect(t, 200)
+	followers := call(t, "GET", "/users/"+ben.uuid+"/followers", "", nil).expect(t, 200).data(t)
+	if len(followers) != 1 {
+		t.Fatalf("expected ann following ben, got %v", followers)
+	}
+	call(t, "GET", "/users/"+ann.uuid+"/following", "", nil).expect(t, 200)
+
+	call(t, "POST", "/interactions", ben.token, url.Values{
+		"user_id": {ben.uuid}, "book_id": {str(t, catalogue[0], "slug")}, "action": {"like"},
+	}).expect(t, 201)
+	feed := call(t, "GET", "/feed", ann.token, nil).expect(t, 200).data(t)
+	if len(feed) == 0 {
+		t.Fatal("expected ben's like in ann's feed")
+	}
+	call(t, "DELETE", "/users/"+ben.uuid+"/follow", ann.token, nil).expect(t, 204)
+
+	call(t, "POST", "/users/"+ann.uuid+"/block", ben.token, nil).expect(t, 201)
+	call(t, "POST", "/users/"+ben.uuid+"/follow", ann.token, nil).expect(t, 403)
+	blocks := call(t, "GET", "/users/"+ben.uuid+"/blocks", ben.token, nil).expect(t, 200).data(t)
+	if len(blocks) != 1 {
+		t.Fatalf("expected one block, got %v", blocks)
+	}
+	call(t, "DELETE", "/users/"+ann.uuid+"/block", ben.token, nil).expect(t, 204)
+
+	// invites: a code signs a new reader up and credits the inviter
+	invite := call(t, "POST", "/users/"+ann.uuid+"/invites", ann.token, url.Values{"max_uses": {"2"}}).expect(t, 201).object(t)
+	call(t, "POST", "/users", "", url.Values{
+		"email": {"social_cat@integration.test"}, "handle": {"social_cat"},
+		"password": {integrationPassword}, "invite_code": {str(t, invite, "code")},
+	}).expect(t, 201)
+	call(t, "GET", "/users/"+ann.uuid+"/invites", ann.token, nil).expect(t, 200)
+	call(t, "GET", "/admin/referrals", boss.token, nil).expect(t, 200)
+
+	// the weekly digest: subscribe, then unsubscribe by API and by emailed link
+	call(t, "POST", "/users/"+ann.uuid+"/digest", ann.token, nil).expect(t, 200)
+	call(t, "DELETE", "/users/"+ann.uuid+"/digest", ann.token, nil).expect(t, 200)
+	call(t, "POST", "/users/"+ann.uuid+"/digest", ann.token, nil).expect(t, 200)
+	token := query(t, "SELECT digest_unsubscribe_token FROM users WHERE uuid = ?", ann.uuid)
+	call(t, "GET", "/digest/unsubscribe?token="+token, "", nil).expect(t, 200)
+	call(t, "POST", "/digest/unsubscribe?token="+token, "", nil).expect(t, 200)
+	call(t, "GET", "/digest/unsubscribe?token=nope", "", nil).expect(t, 404)
+}
+
+func TestIntegrationLists(t *testing.T) {
+	useIntegrationDB()
+	boss := admin(t)
+	catalogue := books(t)
+	owner, editor, stranger := signUp(t, "lists_owner"), signUp(t, "lists_editor"), signUp(t, "lists_stranger")
+
+	list := call(t, "POST", "/users/"+owner.uuid+"/lists", owner.token, url.Values{
+		"name": {"Summer reading"}, "visibility": {"public"},
+	}).expect(t, 201).object(t)
+	listID := str(t, list, "uuid")
+	call(t, "POST", "/users/"+owner.uuid+"/lists", stranger.token, url.Values{"name": {"Not mine"}}).expect(t, 403)
+
+	for _, b := range catalogue[:3] {
+		call(t, "POST", "/lists/"+listID+"/books", owner.token, url.Values{"book_id": {str(t, b, "slug")}}).expect(t, 201)
+	}
+	call(t, "POST", "/lists/"+listID+"/books", owner.token, url.Values{
+		"book_id": {str(t, catalogue[3], "uuid")}, "position": {"1"},
+	}).expect(t, 201)
+	moved := call(t, "PATCH", "/lists/"+listID+"/books/"+str(t, catalogue[3], "uuid"), owner.token,
+		url.Values{"position": {"4"}}).expect(t, 200).object(t)
+	if entries := moved["books"].([]interface{}); len(entries) != 4 {
+		t.Fatalf("expected 4 books in the list, got %v", moved)
+	}
+	call(t, "DELETE", "/lists/"+listID+"/books/"+str(t, catalogue[0], "slug"), owner.token, nil).expect(t, 204)
+
+	call(t, "PATCH", "/lists/"+listID, owner.token, url.Values{"name": {"Summer reading 2"}}).expect(t, 200)
+	call(t, "PATCH", "/lists/"+listID, stranger.token, url.Values{"name": {"Hijacked"}}).expect(t, 403)
+	got := call(t, "GET", "/lists/"+listID, "", nil).expect(t, 200).object(t)
+	if got["name"] != "Summer reading 2" {
+		t.Fatalf("expected the renamed list, got %v", got)
+	}
+	mine := call(t, "GET", "/users/"+owner.uuid+"/lists", "", nil).expect(t, 200).data(t)
+	if len(mine) != 1 {
+		t.Fatalf("expected one list, got %v", mine)
+	}
+
+	// co-editors: invite, accept, promote, remove; a second invite is declined
+	call(t, "POST", "/lists/"+listID+"/members", owner.token, url.Values{"handle": {editor.handle}}).expect(t, 201)
+	invitations := call(t, "GET", "/users/"+editor.uuid+"/list-invitations", editor.token, nil).expect(t, 200).array(t)
+	if len(invitations) != 1 {
+		t.Fatalf("expected one invitation, got %v", invitations)
+	}
+	call(t, "POST", "/lists/"+listID+"/invitation", editor.token, nil).expect(t, 200)
+	call(t, "POST", "/lists/"+listID+"/books", editor.token, url.Values{"book_id": {str(t, catalogue[4], "slug")}}).expect(t, 201)
+	call(t, "PATCH", "/lists/"+listID+"/members/"+editor.uuid, owner.token, url.Values{"permission": {"manager"}}).expect(t, 200)
+	members := call(t, "GET", "/lists/"+listID+"/members", "", nil).expect(t, 200).array(t)
+	if len(members) != 1 {
+		t.Fatalf("expected one member, got %v", members)
+	}
+	call(t, "DELETE", "/lists/"+listID+"/members/"+editor.uuid, owner.token, nil).expect(t, 204)
+	call(t, "POST", "/lists/"+listID+"/members", owner.token, url.Values{"user_id": {stranger.uuid}}).expect(t, 201)
+	call(t, "DELETE", "/lists/"+listID+"/invitation", stranger.token, nil).expect(t, 204)
+
+	history := call(t, "GET", "/lists/"+listID+"/history", "", nil).expect(t, 200).data(t)
+	if len(history) == 0 {
+		t.Fatal("expected the list's changes in its history")
+	}
+	export := call(t, "GET", "/lists/"+listID+"/export", "", nil).expect(t, 200)
+	if !strings.Contains(string(export.body), str(t, catalogue[4], "title")) {
+		t.Fatalf("expected the list's books in the export, got %s", export.body)
+	}
+
+	// share links reach unlisted lists until revoked
+	call(t, "PATCH", "/lists/"+listID, owner.token, url.Values{"visibility": {"unlisted"}}).expect(t, 200)
+	share := call(t, "POST", "/lists/"+listID+"/share", owner.token, nil).expect(t, 200).object(t)
+	token := str(t, share, "share_token")
+	call(t, "GET", "/lists/shared/"+token, "", nil).expect(t, 200)
+	call(t, "DELETE", "/lists/"+listID+"/share", owner.token, nil).expect(t, 204)
+	call(t, "GET", "/lists/shared/"+token, "", nil).expect(t, 404)
+
+	call(t, "DELETE", "/lists/"+listID, owner.token, nil).expect(t, 204)
+	call(t, "GET", "/lists/"+listID, owner.token, nil).expect(t, 404)
+	call(t, "POST", "/admin/lists/"+listID+"/restore", boss.token, nil).expect(t, 204)
+	call(t, "GET", "/lists/"+listID, owner.token, nil).expect(t, 200)
+}
+
+func TestIntegrationGroups(t *testing.T) {
+	useIntegrationDB()
+	catalogue := books(t)
+	host, member := signUp(t, "groups_host"), signUp(t, "groups_member")
+
+	group := call(t, "POST", "/groups", host.token, url.Values{
+		"name": {"Tuesday Readers"}, "description": {"Fantasy, mostly"},
+	}).expect(t, 201).object(t)
+	groupID := str(t, group, "uuid")
+	call(t, "GET", "/groups", "", nil).expect(t, 200)
+	call(t, "GET", "/groups/"+groupID, "", nil).expect(t, 200)
+	call(t, "PATCH", "/groups/"+groupID, host.token, url.Values{"description": {"Fantasy and sci-fi"}}).expect(t, 200)
+	call(t, "PATCH", "/groups/"+groupID, member.token, url.Values{"name": {"Mine now"}}).expect(t, 403)
+
+	call(t, "POST", "/groups/"+groupID+"/members", member.token, nil).expect(t, 201)
+	members := call(t, "GET", "/groups/"+groupID+"/members", "", nil).expect(t, 200).data(t)
+	if len(members) != 2 {
+		t.Fatalf("expected host and member, got %v", members)
+	}
+	call(t, "PATCH", "/groups/"+groupID+"/members/"+member.uuid, host.token, url.Values{"role": {"admin"}}).expect(t, 200)
+
+	for _, b := range catalogue[:2] {
+		call(t, "POST", "/interactions", member.token, url.Values{
+			"user_id": {member.uuid}, "book_id": {str(t, b, "uuid")}, "action": {"like"},
+		}).expect(t, 201)
+	}
+	call(t, "GET", "/groups/"+groupID+"/recommendations", member.token, nil).expect(t, 200)
+	pick := call(t, "POST", "/groups/"+groupID+"/picks", host.token, url.Values{
+		"book_id": {str(t, catalogue[5], "slug")}, "starts_on": {"2030-01-01"}, "ends_on": {"2030-01-31"},
+	}).expect(t, 201).object(t)
+	call(t, "POST", "/groups/"+groupID+"/picks", host.token, url.Values{"recommended": {"true"}}).expect(t, 201)
+	picks := call(t, "GET", "/groups/"+groupID+"/picks", "", nil).expect(t, 200).data(t)
+	if len(picks) != 2 {
+		t.Fatalf("expected two picks, got %v", picks)
+	}
+
+	meeting := call(t, "POST", "/groups/"+groupID+"/schedule", host.token, url.Values{
+		"title": {"Kickoff"}, "starts_at": {"2030-01-02T18:00:00Z"}, "location": {"Library"}, "pick_id": {str(t, pick, "id")},
+	}).expect(t, 201).object(t)
+	schedule := call(t, "GET", "/groups/"+groupID+"/schedule", "", nil).expect(t, 200).array(t)
+	if len(schedule) != 1 {
+		t.Fatalf("expected the meeting scheduled, got %v", schedule)
+	}
+	call(t, "DELETE", "/groups/"+groupID+"/schedule/"+str(t, meeting, "id"), host.token, nil).expect(t, 204)
+
+	thread := call(t, "POST", "/groups/"+groupID+"/threads", member.token, url.Values{
+		"title": {"First impressions"}, "body": {"Loving it so far"},
+	}).expect(t, 201).object(t)
+	threadID := str(t, thread, "uuid")
+	call(t, "POST", "/groups/"+groupID+"/threads/"+threadID+"/posts", host.token, url.Values{"body": {"Same here"}}).expect(t, 201)
+	call(t, "GET", "/groups/"+groupID+"/threads", member.token, nil).expect(t, 200)
+	posts := call(t, "GET", "/groups/"+groupID+"/threads/"+threadID, host.token, nil).expect(t, 200).data(t)
+	if len(posts) != 2 {
+		t.Fatalf("expected the opening post and a reply, got %v", posts)
+	}
+	outsider := signUp(t, "groups_outsider")
+	call(t, "GET", "/groups/"+groupID+"/threads", outsider.token, nil).expect(t, 403)
+
+	call(t, "DELETE", "/groups/"+groupID+"/members/"+member.uuid, member.token, nil).expect(t, 204)
+	call(t, "DELETE", "/groups/"+groupID, host.token, nil).expect(t, 204)
+	call(t, "GET", "/groups/"+groupID, "", nil).expect(t, 404)
+}
+
+func TestIntegrationDiscussionsAndModeration(t *testing.T) {
+	useIntegrationDB()
+	boss := admin(t)
+	catalogue := books(t)
+	slug := str(t, catalogue[8], "slug")
+	poster, reporter := signUp(t, "talk_poster"), signUp(t, "talk_reporter")
+
+	thread := call(t, "POST", "/books/"+slug+"/threads", poster.token, url.Values{
+		"title": {"That ending"}, "body": {"Did not see it coming"}, "spoiler": {"true"},
+	}).expect(t, 201).object(t)
+	threadID := str(t, thread, "uuid")
+	reply := call(t, "POST", "/books/"+slug+"/threads/"+threadID+"/posts", reporter.token,
+		url.Values{"body": {"Neither did I"}}).expect(t, 201).object(t)
+	listed := call(t, "GET", "/books/"+slug+"/threads", "", nil).expect(t, 200).data(t)
+	if len(listed) != 1 {
+		t.Fatalf("expected one thread, got %v", listed)
+	}
+	call(t, "GET", "/books/"+slug+"/threads?spoilers=hide", "", nil).expect(t, 200)
+	call(t, "GET", "/books/"+slug+"/threads/"+threadID, "", nil).expect(t, 200)
+
+	call(t, "POST", "/threads/"+threadID+"/report", reporter.token, url.Values{"reason": {"spoiler"}}).expect(t, 201)
+	call(t, "POST", "/posts/"+str(t, reply, "id")+"/report", poster.token, url.Values{
+		"reason": {"off_topic"}, "details": {"Not about the book"},
+	}).expect(t, 201)
+	reports := call(t, "GET", "/admin/content-reports", boss.token, nil).expect(t, 200).data(t)
+	if len(reports) != 2 {
+		t.Fatalf("expected two content reports, got %v", reports)
+	}
+	call(t, "POST", "/admin/content-reports/thread/"+str(t, thread, "uuid")+"/resolve", boss.token,
+		url.Values{"action": {"restore"}}).expect(t, 200)
+	call(t, "POST", "/admin/content-reports/post/"+str(t, reply, "id")+"/resolve", boss.token,
+		url.Values{"action": {"remove"}}).expect(t, 200)
+	call(t, "POST", "/admin/content-reports/post/"+str(t, reply, "id")+"/resolve", boss.token,
+		url.Values{"action": {"restore"}}).expect(t, 404)
+
+	second := call(t, "POST", "/books/"+slug+"/threads/"+threadID+"/posts", reporter.token,
+		url.Values{"body": {"On reflection, I did"}}).expect(t, 201).object(t)
+	call(t, "DELETE", "/books/"+slug+"/threads/"+threadID+"/posts/"+str(t, second, "id"), poster.token, nil).expect(t, 403)
+	call(t, "DELETE", "/books/"+slug+"/threads/"+threadID+"/posts/"+str(t, second, "id"), boss.token, nil).expect(t, 204)
+	call(t, "DELETE", "/books/"+slug+"/threads/"+threadID, boss.token, nil).expect(t, 204)
+
+	// reviews, ranked by how many readers found them helpful
+	call(t, "POST", "/books/"+slug+"/reviews", poster.token, url.Values{"body": {"Worth it for the ending"}}).expect(t, 201)
+	call(t, "POST", "/books/"+slug+"/reviews", poster.token, url.Values{"body": {"Again"}}).expect(t, 409)
+	helpful := call(t, "POST", "/books/"+slug+"/reviews", reporter.token, url.Values{
+		"body": {"The ending recasts everything"}, "spoiler": {"true"},
+	}).expect(t, 201).object(t)
+	vote := "/books/" + slug + "/reviews/" + str(t, helpful, "uuid") + "/helpful"
+	call(t, "POST", vote, reporter.token, nil).expect(t, 400)
+	call(t, "POST", vote, poster.token, nil).expect(t, 201)
+	call(t, "POST", vote, poster.token, nil).expect(t, 200)
+	reviews := call(t, "GET", "/books/"+slug+"/reviews", "", nil).expect(t, 200).data(t)
+	if len(reviews) != 2 || reviews[0].(map[string]interface{})["uuid"] != helpful["uuid"] {
+		t.Fatalf("expected the helpful review first, got %v", reviews)
+	}
+	if hidden := call(t, "GET", "/books/"+slug+"/reviews?sort=newest&spoilers=hide", "", nil).expect(t, 200).data(t); len(hidden) != 1 {
+		t.Fatalf("expected the spoiler review left out, got %v", hidden)
+	}
+	call(t, "DELETE", vote, poster.token, nil).expect(t, 200)
+	stats := call(t, "GET", "/books/"+slug, "", nil).expect(t, 200).object(t)["stats"].(map[string]interface{})
+	if stats["reviews"] != float64(2) {
+		t.Fatalf("expected two reviews in the book's stats, got %v", stats)
+	}
+
+	// the content filter screens handles and posts
+	term := call(t, "POST", "/admin/content-filter", boss.token, url.Values{"term": {"forbiddenword"}}).expect(t, 201).object(t)
+	terms := call(t, "GET", "/admin/content-filter", boss.token, nil).expect(t, 200).data(t)
+	if len(terms) == 0 {
+		t.Fatal("expected the filter term listed")
+	}
+	call(t, "DELETE", "/admin/content-filter/"+str(t, term, "id"), boss.token, nil).expect(t, 204)
+
+	// catalogue reports
+	report := call(t, "POST", "/books/"+slug+"/report", reporter.token, url.Values{
+		"reason": {"wrong_author"}, "details": {"Co-written"},
+	}).expect(t, 201).object(t)
+	open := call(t, "GET", "/admin/reports?status=open", boss.token, nil).expect(t, 200).data(t)
+	if len(open) != 1 {
+		t.Fatalf("expected one open report, got %v", open)
+	}
+	call(t, "POST", "/admin/reports/"+str(t, report, "uuid")+"/resolve", boss.token, url.Values{
+		"resolution": {"edited"}, "note": {"Added the co-author"},
+	}).expect(t, 200)
+	call(t, "GET", "/admin/reports?status=resolved", boss.token, nil).expect(t, 200)
+
+	audit := call(t, "GET", "/admin/audit-log?target_type=book_report", boss.token, nil).expect(t, 200).data(t)
+	if len(audit) == 0 {
+		t.Fatal("expected the resolution in the audit log")
+	}
+}
+
+func TestIntegrationMergesAndDeletes(t *testing.T) {
+	useIntegrationDB()
+	boss := admin(t)
+	catalogue := books(t)
+	dupe, keep := catalogue[10], catalogue[11]
+	fan := signUp(t, "merge_fan")
+	call(t, "POST", "/interactions", fan.token, url.Values{
+		"user_id": {fan.uuid}, "book_id": {str(t, dupe, "uuid")}, "action": {"like"},
+	}).expect(t, 201)
+
+	// soft deletes hide rows until an admin restores them
+	victim := catalogue[12]
+	call(t, "DELETE", "/admin/books/"+str(t, victim, "uuid"), boss.token, nil).expect(t, 204)
+	call(t, "GET", "/books/"+str(t, victim, "uuid"), "", nil).expect(t, 404)
+	deleted := call(t, "GET", "/admin/deleted?resource=book", boss.token, nil).expect(t, 200).data(t)
+	if len(deleted) == 0 || deleted[0].(map[string]interface{})["uuid"] != str(t, victim, "uuid") {
+		t.Fatalf("expected the deleted book listed first, got %v", deleted)
+	}
+	call(t, "POST", "/admin/books/"+str(t, victim, "uuid")+"/restore", boss.token, nil).expect(t, 204)
+	call(t, "GET", "/books/"+str(t, victim, "uuid"), "", nil).expect(t, 200)
+
+	leaving := signUp(t, "delete_me")
+	call(t, "DELETE", "/users/"+leaving.uuid, fan.token, nil).expect(t, 403)
+	call(t, "DELETE", "/users/"+leaving.uuid, leaving.token, nil).expect(t, 204)
+	call(t, "GET", "/users/"+leaving.uuid, "", nil).expect(t, 404)
+	call(t, "POST", "/admin/users/"+leaving.uuid+"/restore", boss.token, nil).expect(t, 204)
+	call(t, "GET", "/users/"+leaving.uuid, "", nil).expect(t, 200)
+	log := call(t, "GET", "/admin/audit-log?action=user.restore", boss.token, nil).expect(t, 200).data(t)
+	if len(log) != 1 {
+		t.Fatalf("expected the restore audited, got %v", log)
+	}
+
+	merge := call(t, "POST", "/admin/books/"+str(t, dupe, "uuid")+"/merge?into="+str(t, keep, "uuid"), boss.token, nil).
+		expect(t, 201).object(t)
+	moved := call(t, "GET", "/books/"+str(t, dupe, "uuid"), "", nil).expect(t, 301)
+	if moved.header.Get("Location") != "/books/"+str(t, keep, "slug") {
+		t.Fatalf("expected the merged book to redirect, got %v", moved.header)
+	}
+	call(t, "POST", "/admin/book-merges/"+str(t, merge, "uuid")+"/undo", boss.token, nil).expect(t, 200)
+	call(t, "GET", "/books/"+str(t, dupe, "uuid"), "", nil).expect(t, 200)
+
+	old, current := signUp(t, "merge_old"), signUp(t, "merge_current")
+	call(t, "POST", "/admin/users/"+old.uuid+"/merge?into="+current.uuid, boss.token, nil).expect(t, 200)
+	call(t, "GET", "/users/"+old.uuid, "", nil).expect(t, 301)
+	call(t, "POST", "/login", "", url.Values{"email": {old.email}, "password": {integrationPassword}}).expect(t, 403)
+}
+
+func TestIntegrationTenantsAndWebhooks(t *testing.T) {
+	useIntegrationDB()
+	boss := admin(t)
+
+	call(t, "POST", "/admin/organizations", boss.token, url.Values{"slug": {"acme"}, "name": {"Acme Books"}}).expect(t, 201)
+	call(t, "POST", "/admin/organizations", boss.token, url.Values{"slug": {"acme"}, "name": {"Again"}}).expect(t, 409)
+	orgs := call(t, "GET", "/admin/organizations", boss.token, nil).expect(t, 200).array(t)
+	if len(orgs) != 2 {
+		t.Fatalf("expected default and acme, got %v", orgs)
+	}
+
+	// tenants see the shared catalogue but only their own users
+	call(t, "POST", "/users", "", url.Values{
+		"email": {"reader@acme.test"}, "handle": {"acme_reader"}, "password": {integrationPassword},
+	}, "X-Tenant", "acme").expect(t, 201)
+	if _, err := db.Exec("UPDATE users SET role = 'admin' WHERE email = 'reader@acme.test'"); err != nil {
+		t.Fatalf("promoting acme's reader: %v", err)
+	}
+	acmeAdmin := call(t, "POST", "/login", "", url.Values{
+		"email": {"reader@acme.test"}, "password": {integrationPassword},
+	}, "X-Tenant", "acme").expect(t, 200).object(t)
+	users := call(t, "GET", "/users", str(t, acmeAdmin, "access_token"), nil, "X-Tenant", "acme").expect(t, 200).data(t)
+	if len(users) != 1 {
+		t.Fatalf("expected only acme's reader, got %v", users)
+	}
+	call(t, "GET", "/books", "", nil, "X-Tenant", "acme").expect(t, 200)
+	call(t, "GET", "/users", "", nil, "X-Tenant", "nope").expect(t, 404)
+
+	hook := call(t, "POST", "/admin/webhooks", boss.token, url.Values{
+		"url": {"https://example.com/hooks"}, "events": {"user.created,interaction.created"},
+	}).expect(t, 201).object(t)
+	call(t, "GET", "/admin/webhooks", boss.token, nil).expect(t, 200)
+	signUp(t, "webhook_trigger")
+	deliveries := call(t, "GET", "/admin/webhooks/"+str(t, hook, "id")+"/deliveries", boss.token, nil).expect(t, 200).array(t)
+	if len(deliveries) == 0 {
+		t.Fatal("expected the signup queued for delivery")
+	}
+	call(t, "DELETE", "/admin/webhooks/"+str(t, hook, "id"), boss.token, nil).expect(t, 200)
+}
+
+func TestIntegrationLiveAndMisc(t *testing.T) {
+	useIntegrationDB()
+	boss := admin(t)
+
+	// browser preflights from the frontend's origin, and no other
+	preflight := call(t, "OPTIONS", "/interactions", "", nil, "Origin", "http://localhost:5173",
+		"Access-Control-Request-Method", "POST", "Access-Control-Request-Headers", "authorization").expect(t, 204)
+	if preflight.header.Get("Access-Control-Allow-Origin") != "http://localhost:5173" {
+		t.Fatalf("expected the frontend's origin allowed, got %v", preflight.header)
+	}
+	call(t, "OPTIONS", "/interactions", "", nil, "Origin", "https://elsewhere.example",
+		"Access-Control-Request-Method", "POST").expect(t, 403)
+
+	// server-sent events: the first event arrives straight away
+	firstEvent := func(path, token string) string {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != 200 {
+			t.Fatalf("GET %s: expected 200, got %d", path, resp.StatusCode)
+		}
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		return line
+	}
+	if line := firstEvent("/stats/stream", boss.token); !strings.HasPrefix(line, "event:stats") {
+		t.Fatalf("expected a stats event, got %q", line)
+	}
+	watcher := signUp(t, "jobs_watcher")
+	if _, err := db.Exec("INSERT INTO job_runs (job, status) VALUES ('ingest', 'running')"); err != nil {
+		t.Fatalf("inserting a job run: %v", err)
+	}
+	if line := firstEvent("/admin/jobs/stream", boss.token); !strings.HasPrefix(line, "event:job") {
+		t.Fatalf("expected a job event, got %q", line)
+	}
+
+	catalog.RecordIngest(context.Background(), db, "open_library:fantasy", 10, nil)
+	call(t, "GET", "/admin/catalog/status", watcher.token, nil).expect(t, 403)
+	status := call(t, "GET", "/admin/catalog/status", boss.token, nil).expect(t, 200).object(t)
+	sources, _ := status["sources"].([]interface{})
+	if len(sources) != 1 || status["books_added_last_7_days"] == 0.0 {
+		t.Fatalf("expected the source and the seeded books, got %v", status)
+	}
+
+	call(t, "POST", "/admin/ingest", watcher.token, map[string]interface{}{}).expect(t, 403)
+	call(t, "POST", "/admin/ingest", boss.token, map[string]interface{}{"source": "amazon"}).expect(t, 400)
+	queued := call(t, "POST", "/admin/ingest", boss.token, map[string]interface{}{"source": "openlibrary", "categories": []string{"fantasy"}}).expect(t, 202)
+	location := queued.header.Get("Location")
+	if !strings.HasPrefix(location, "/admin/ingest/") {
+		t.Fatalf("expected a Location for the run, got %q", location)
+	}
+	// no workers run here, so the run waits in the queue
+	if run := call(t, "GET", location, boss.token, nil).expect(t, 200).object(t); run["status"] != "queued" || run["source"] != "openlibrary" {
+		t.Fatalf("expected a queued Open Library run, got %v", run)
+	}
+	call(t, "GET", "/admin/ingest/999999999", boss.token, nil).expect(t, 404)
+	night := time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC)
+	nightly, queuedTonight, err := ingest.EnqueueScheduled(context.Background(), db, ingest.Params{Source: "openlibrary", Categories: []string{"fantasy"}}, night)
+	if err != nil || !queuedTonight {
+		t.Fatalf("queueing the nightly run: %v, %v", queuedTonight, err)
+	}
+	if _, again, err := ingest.EnqueueScheduled(context.Background(), db, ingest.Params{Source: "openlibrary"}, night); err != nil || again {
+		t.Fatalf("expected the night to be queued once, got %v, %v", again, err)
+	}
+	if run := call(t, "GET", fmt.Sprintf("/admin/ingest/%d", nightly), boss.token, nil).expect(t, 200).object(t); run["scheduled_for"] != "2024-05-01" {
+		t.Fatalf("expected the nightly run, got %v", run)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/trending", nil)
+	if err != nil {
+		t.Fatalf("dialing /ws/trending: %v", err)
+	}
+	var snapshot map[string]interface{}
+	if err := conn.ReadJSON(&snapshot); err != nil || snapshot["type"] != "snapshot" {
+		t.Fatalf("expected a snapshot, got %v (%v)", snapshot, err)
+	}
+	_ = conn.Close()
+
+	gql := call(t, "POST", "/graphql", "", map[string]string{"query": "{ books(limit: 2) { title } }"}).expect(t, 200).object(t)
+	if gql["errors"] != nil {
+		t.Fatalf("unexpected GraphQL errors: %v", gql["errors"])
+	}
+	call(t, "GET", "/graphql?query="+url.QueryEscape("{ books(limit: 1) { title } }"), "", nil).expect(t, 200)
+	call(t, "GET", "/graphql/playground", "", nil).expect(t, 200)
+	call(t, "GET", "/swagger/index.html", "", nil).expect(t, 200)
+	call(t, "GET", "/", "", nil).expect(t, 200)
+	call(t, "GET", "/ui/index.html", "", nil).expect(t, 301)
+	call(t, "DELETE", "/healthz", "", nil).expect(t, 405)
+}
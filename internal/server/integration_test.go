@@ -0,0 +1,374 @@
+//go:build integration
+
+package server
+
+// The integration suite runs the real router against a real MySQL: every
+// migration applied, a seeded catalogue, and each route exercised end to end
+// through HTTP. It complements the sqlmock tests, whose regexes keep passing
+// when the SQL itself is wrong.
+//
+//	go test -tags integration ./internal/server
+//
+// By default it starts mysql:8.0 with testcontainers (Docker required). Set
+// INTEGRATION_DSN to an empty database instead, e.g.
+// "root:secret@tcp(localhost:3307)/bookrec_test".
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	tcmysql "github.com/testcontainers/testcontainers-go/modules/mysql"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/YeswanthC7/bookrec/db/migrations"
+	"github.com/YeswanthC7/bookrec/internal/authors"
+	"github.com/YeswanthC7/bookrec/internal/demo"
+	"github.com/YeswanthC7/bookrec/internal/ids"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// integrationPassword is every seeded and signed-up account's password
+const integrationPassword = "integration-pass"
+
+// seededISBN resolves from book_isbns, so the ISBN lookup never calls Open Library
+const seededISBN = "9780261102217"
+
+// server is the router under test, listening on a local port
+var server *httptest.Server
+
+// integrationDB is the suite's database. The package's sqlmock tests swap
+// db out, so each integration test starts with useIntegrationDB.
+var integrationDB *sql.DB
+
+// hitRoutes records "METHOD /full/path" for every route a request matched
+var hitRoutes sync.Map
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	dsn, stop, err := integrationDatabase(ctx)
+	if err != nil {
+		log.Fatalf("❌ integration database: %v", err)
+	}
+	code := runIntegration(m, dsn)
+	stop()
+	os.Exit(code)
+}
+
+// integrationDatabase returns the DSN of an empty database and a function
+// that tears it down
+func integrationDatabase(ctx context.Context) (string, func(), error) {
+	if dsn := os.Getenv("INTEGRATION_DSN"); dsn != "" {
+		return dsn, func() {}, nil
+	}
+	container, err := tcmysql.Run(ctx, "mysql:8.0",
+		tcmysql.WithDatabase("bookrec"),
+		tcmysql.WithUsername("bookrec"),
+		tcmysql.WithPassword("bookrec"),
+	)
+	if err != nil {
+		return "", nil, err
+	}
+	dsn, err := container.ConnectionString(ctx)
+	if err != nil {
+		_ = container.Terminate(ctx)
+		return "", nil, err
+	}
+	return dsn, func() { _ = container.Terminate(ctx) }, nil
+}
+
+func runIntegration(m *testing.M, dsn string) int {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		log.Printf("❌ INTEGRATION_DSN: %v", err)
+		return 1
+	}
+	cfg.ParseTime = true
+	cfg.MultiStatements = true // migrations run a file per Exec
+
+	database, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		log.Printf("❌ DB connection error: %v", err)
+		return 1
+	}
+	defer func() { _ = database.Close() }()
+	integrationDB = database
+	useIntegrationDB()
+
+	if err := migrate(cfg.FormatDSN()); err != nil {
+		log.Printf("❌ %v", err)
+		return 1
+	}
+	if err := seedCatalogue(db); err != nil {
+		log.Printf("❌ seeding: %v", err)
+		return 1
+	}
+
+	jwtSecret = []byte("integration-secret")
+	jwtIssuer = "bookrec"
+	// responses that drift from the spec are logged, not failed
+	_ = os.Setenv("OPENAPI_VALIDATE_RESPONSES", "true")
+	r, err := newRouter(func(c *gin.Context) {
+		if path := c.FullPath(); path != "" {
+			hitRoutes.Store(c.Request.Method+" "+path, true)
+		}
+		c.Next()
+	})
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 1
+	}
+	server = httptest.NewServer(headAsGet(r))
+	defer server.Close()
+	routes = r.Routes()
+
+	return m.Run()
+}
+
+func useIntegrationDB() { db = integrationDB }
+
+// routes are the router's routes, checked off by TestIntegrationCoversEveryRoute
+var routes gin.RoutesInfo
+
+// migrate applies every migration the way bookrec migrate does. It has a
+// connection of its own, since closing the migrator closes it.
+func migrate(dsn string) error {
+	database, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return err
+	}
+	m, err := migrations.New(database)
+	if err != nil {
+		_ = database.Close()
+		return err
+	}
+	defer func() { _, _ = m.Close() }()
+	return m.Up()
+}
+
+// seedCatalogue inserts a shared demo catalogue (the ingest job's job in
+// production) and the default organization's admin
+func seedCatalogue(db *sql.DB) error {
+	rng := rand.New(rand.NewSource(1))
+	for i, b := range demo.Books(rng, 40) {
+		publicID := ids.New()
+		subjects, _ := json.Marshal(b.Subjects)
+		res, err := db.Exec(`
+			INSERT INTO books (uuid, slug, open_library_key, title, author, subjects, published_year, formats, page_count)
+			VALUES (?, ?, ?, ?, ?, ?, ?, 'print,ebook', ?)`,
+			publicID, ids.BookSlug(b.Title, publicID), b.Key, b.Title, b.Author, string(subjects), b.Year, b.Pages)
+		if err != nil {
+			return err
+		}
+		bookID, _ := res.LastInsertId()
+		if err := authors.Link(context.Background(), db, bookID, b.Author); err != nil {
+			return err
+		}
+		if i == 0 {
+			if _, err := db.Exec("INSERT INTO book_isbns (isbn13, book_id) VALUES (?, ?)", seededISBN, bookID); err != nil {
+				return err
+			}
+		}
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(integrationPassword), bcrypt.MinCost)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(
+		"INSERT INTO users (organization_id, email, handle, password_hash, role) VALUES (?, ?, ?, ?, 'admin')",
+		tenant.DefaultID, "admin@integration.test", "admin", string(hashed))
+	return err
+}
+
+// reply is a response, read in full
+type reply struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// call sends a request as token (none when empty). url.Values bodies are
+// posted as forms; anything else non-nil as JSON. Extra headers come in
+// name, value pairs.
+func call(t *testing.T, method, path, token string, body interface{}, headers ...string) *reply {
+	t.Helper()
+	var reader io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case url.Values:
+		reader, contentType = strings.NewReader(b.Encode()), "application/x-www-form-urlencoded"
+	default:
+		raw, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("encoding %s %s body: %v", method, path, err)
+		}
+		reader, contentType = bytes.NewReader(raw), "application/json"
+	}
+
+	req, err := http.NewRequest(method, server.URL+path, reader)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse // redirects are asserted, not followed
+	}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s %s: reading body: %v", method, path, err)
+	}
+	return &reply{status: resp.StatusCode, header: resp.Header, body: raw}
+}
+
+// expect fails the test unless the status is want
+func (r *reply) expect(t *testing.T, want int) *reply {
+	t.Helper()
+	if r.status != want {
+		t.Fatalf("expected %d, got %d body=%s", want, r.status, r.body)
+	}
+	return r
+}
+
+// object decodes a JSON object body
+func (r *reply) object(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var out map[string]interface{}
+	if err := json.Unmarshal(r.body, &out); err != nil {
+		t.Fatalf("expected a JSON object, got %s", r.body)
+	}
+	return out
+}
+
+// array decodes a JSON array body
+func (r *reply) array(t *testing.T) []interface{} {
+	t.Helper()
+	var out []interface{}
+	if err := json.Unmarshal(r.body, &out); err != nil {
+		t.Fatalf("expected a JSON array, got %s", r.body)
+	}
+	return out
+}
+
+// data is the "data" array of a paginated body
+func (r *reply) data(t *testing.T) []interface{} {
+	t.Helper()
+	data, ok := r.object(t)["data"].([]interface{})
+	if !ok {
+		t.Fatalf("expected a data array, got %s", r.body)
+	}
+	return data
+}
+
+// str reads a string field, failing when it's missing
+func str(t *testing.T, obj map[string]interface{}, key string) string {
+	t.Helper()
+	switch v := obj[key].(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	}
+	t.Fatalf("expected %q in %v", key, obj)
+	return ""
+}
+
+// account is a signed-in user
+type account struct {
+	uuid    string
+	handle  string
+	email   string
+	token   string
+	refresh string
+}
+
+// signUp creates an account through the API and signs it in
+func signUp(t *testing.T, handle string) *account {
+	t.Helper()
+	email := handle + "@integration.test"
+	call(t, "POST", "/users", "", url.Values{
+		"email": {email}, "handle": {handle}, "password": {integrationPassword},
+	}).expect(t, 201)
+	a := signIn(t, email)
+	a.handle = handle
+	return a
+}
+
+// signIn logs an existing account in
+func signIn(t *testing.T, email string) *account {
+	t.Helper()
+	login := call(t, "POST", "/login", "", url.Values{
+		"email": {email}, "password": {integrationPassword},
+	}).expect(t, 200).object(t)
+	user, _ := login["user"].(map[string]interface{})
+	return &account{
+		uuid:    str(t, user, "uuid"),
+		email:   email,
+		token:   str(t, login, "access_token"),
+		refresh: str(t, login, "refresh_token"),
+	}
+}
+
+// query reads one value straight from the database, for what the API
+// doesn't expose (tokens that are only ever emailed, ...)
+func query(t *testing.T, q string, args ...interface{}) string {
+	t.Helper()
+	var v string
+	if err := db.QueryRow(q, args...).Scan(&v); err != nil {
+		t.Fatalf("%s: %v", q, err)
+	}
+	return v
+}
+
+// TestIntegrationCoversEveryRoute runs after the other integration tests
+// (integration_flows_test.go sorts first): a route none of them requested is
+// a handler the suite doesn't cover
+func TestIntegrationCoversEveryRoute(t *testing.T) {
+	var missing []string
+	for _, r := range routes {
+		key := r.Method + " " + r.Path
+		if r.Method == http.MethodHead {
+			// headAsGet answers HEAD through the GET route
+			key = http.MethodGet + " " + r.Path
+		}
+		if _, ok := hitRoutes.Load(key); !ok {
+			missing = append(missing, r.Method+" "+r.Path)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Fatalf("%d routes are never requested:\n%s", len(missing), strings.Join(missing, "\n"))
+	}
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func interactionsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/interactions", func(c *gin.Context) { c.Set("auth_user_id", 1) }, CreateInteractionHandler)
+	return r
+}
+
+// expectNoInteraction has user 1 not yet have the action on book 7
+func expectNoInteraction(mock sqlmock.Sqlmock, action string) {
+	mock.ExpectQuery("SELECT id, rating, deleted_at IS NOT NULL FROM interactions\\s+WHERE user_id = \\? AND book_id = \\? AND action = \\?\\s+FOR UPDATE").
+		WithArgs(1, 7, action).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rating", "deleted"}))
+}
+
+// expectTakeBack has user 1's live opposite of the action on book 7 (a
+// like's dislike, ...) deleted; taken is how many rows that touched
+func expectTakeBack(mock sqlmock.Sqlmock, opposite string, taken int64) {
+	mock.ExpectExec("UPDATE interactions SET deleted_at = CURRENT_TIMESTAMP, deleted_by = \\?\\s+WHERE user_id = \\? AND book_id = \\? AND action = \\? AND deleted_at IS NULL").
+		WithArgs(1, 1, 7, opposite).
+		WillReturnResult(sqlmock.NewResult(0, taken))
+}
+
+func TestCreateInteractionHandler_BumpsCounters(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	expectNoInteraction(mock, "rating")
+	mock.ExpectExec("INSERT INTO interactions \\(organization_id, user_id, book_id, action, rating, visibility\\)").
+		WithArgs(1, 1, 7, "rating", int64(4), "public").
+		WillReturnResult(sqlmock.NewResult(30, 1))
+	mock.ExpectExec("INSERT INTO book_counters").
+		WithArgs(1, 7, 0, 1, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT i.uuid, i.user_id, u.uuid, i.book_id, b.uuid").
+		WithArgs(30).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "user_id", "user_uuid", "book_id", "book_uuid", "action", "rating", "visibility", "created_at"}).
+			AddRow("i-30", 1, "u-1", 7, "b-7", "rating", 4, "public", "2026-01-01 00:00:00"))
+	mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	w := postForm(interactionsRouter(), "/interactions",
+		url.Values{"user_id": {"1"}, "book_id": {"7"}, "action": {"rating"}, "rating": {"4"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestCreateInteractionHandler_CounterFailureRollsBack(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	expectNoInteraction(mock, "like")
+	mock.ExpectExec("INSERT INTO interactions \\(organization_id, user_id, book_id, action, rating, visibility\\)").
+		WithArgs(1, 1, 7, "like", nil, "public").
+		WillReturnResult(sqlmock.NewResult(31, 1))
+	expectTakeBack(mock, "dislike", 0)
+	mock.ExpectExec("INSERT INTO book_counters").
+		WithArgs(1, 7, 1, 0, 0).
+		WillReturnError(errors.New("lock wait timeout exceeded"))
+	// the interaction goes too, and no event is emitted
+	mock.ExpectRollback()
+
+	w := postForm(interactionsRouter(), "/interactions",
+		url.Values{"user_id": {"1"}, "book_id": {"7"}, "action": {"like"}})
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestCreateInteractionHandler_Upserts(t *testing.T) {
+	cases := []struct {
+		name     string
+		form     url.Values
+		existing *sqlmock.Rows
+		expect   func(mock sqlmock.Sqlmock)
+		status   int
+	}{
+		{
+			name:     "re-rating recounts the book",
+			form:     url.Values{"action": {"rating"}, "rating": {"2"}},
+			existing: sqlmock.NewRows([]string{"id", "rating", "deleted"}).AddRow(30, 4, false),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE interactions SET rating = \\?, visibility = \\? WHERE id = \\?").
+					WithArgs(int64(2), "public", 30).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM book_counters WHERE book_id = \\?").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO book_counters").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:     "liking again changes nothing",
+			form:     url.Values{"action": {"like"}},
+			existing: sqlmock.NewRows([]string{"id", "rating", "deleted"}).AddRow(30, nil, false),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE interactions SET rating = \\?, visibility = \\? WHERE id = \\?").
+					WithArgs(nil, "public", 30).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				expectTakeBack(mock, "dislike", 0)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:     "a deleted like comes back",
+			form:     url.Values{"action": {"like"}},
+			existing: sqlmock.NewRows([]string{"id", "rating", "deleted"}).AddRow(30, nil, true),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE interactions\\s+SET rating = \\?, visibility = \\?, created_at = CURRENT_TIMESTAMP, deleted_at = NULL, deleted_by = NULL").
+					WithArgs(nil, "public", 30).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectTakeBack(mock, "dislike", 0)
+				mock.ExpectExec("INSERT INTO book_counters").
+					WithArgs(1, 7, 1, 0, 0).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			status: http.StatusCreated,
+		},
+		{
+			name:     "disliking takes back the like",
+			form:     url.Values{"action": {"dislike"}},
+			existing: sqlmock.NewRows([]string{"id", "rating", "deleted"}),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO interactions").
+					WithArgs(1, 1, 7, "dislike", nil, "public").
+					WillReturnResult(sqlmock.NewResult(30, 1))
+				expectTakeBack(mock, "like", 1)
+				mock.ExpectExec("DELETE FROM book_counters WHERE book_id = \\?").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO book_counters").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			status: http.StatusCreated,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mock sqlmock.Sqlmock
+			var err error
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock new: %v", err)
+			}
+			defer func() { _ = db.Close() }()
+
+			action := tc.form.Get("action")
+			mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+				WithArgs(7, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT id, rating, deleted_at IS NOT NULL FROM interactions").
+				WithArgs(1, 7, action).
+				WillReturnRows(tc.existing)
+			tc.expect(mock)
+			mock.ExpectCommit()
+			mock.ExpectQuery("SELECT i.uuid, i.user_id, u.uuid, i.book_id, b.uuid").
+				WithArgs(30).
+				WillReturnRows(sqlmock.NewRows([]string{"uuid", "user_id", "user_uuid", "book_id", "book_uuid", "action", "rating", "visibility", "created_at"}).
+					AddRow("i-30", 1, "u-1", 7, "b-7", action, nil, "public", "2026-01-01 00:00:00"))
+			// only a new or restored interaction is announced
+			if tc.status == http.StatusCreated {
+				mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+
+			form := url.Values{"user_id": {"1"}, "book_id": {"7"}}
+			for k, v := range tc.form {
+				form[k] = v
+			}
+			w := postForm(interactionsRouter(), "/interactions", form)
+			if w.Code != tc.status {
+				t.Fatalf("expected %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+			if created := w.Header().Get("Location") != ""; created != (tc.status == http.StatusCreated) {
+				t.Fatalf("unexpected Location %q", w.Header().Get("Location"))
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("unmet sql expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateInteractionHandler_RejectsInvalidFields(t *testing.T) {
+	cases := []struct {
+		name   string
+		form   url.Values
+		field  string
+		reason string
+	}{
+		{"rating not a number", url.Values{"action": {"rating"}, "rating": {"five"}}, "", `"five" is not a number`},
+		{"rating below range", url.Values{"action": {"rating"}, "rating": {"0"}}, "rating", "must be at least 1"},
+		{"rating above range", url.Values{"action": {"rating"}, "rating": {"6"}}, "rating", "must be at most 5"},
+		{"unknown action", url.Values{"action": {"bookmark"}}, "action", "must be one of like, view, rating, dislike"},
+		{"unknown visibility", url.Values{"action": {"like"}, "visibility": {"friends"}}, "visibility", "must be one of public, private"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mock sqlmock.Sqlmock
+			var err error
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock new: %v", err)
+			}
+			defer func() { _ = db.Close() }()
+
+			tc.form.Set("user_id", "1")
+			tc.form.Set("book_id", "7")
+			w := postForm(interactionsRouter(), "/interactions", tc.form)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+			var body struct {
+				Details []ValidationDetail `json:"details"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			want := []ValidationDetail{{In: "body", Name: tc.field, Reason: tc.reason}}
+			if !reflect.DeepEqual(body.Details, want) {
+				t.Fatalf("details = %+v, want %+v", body.Details, want)
+			}
+			// nothing is looked up for an invalid request
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("unmet sql expectations: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// Invite limits
+const (
+	inviteMaxUses   = 100
+	inviteOpenLimit = 10 // codes with uses left, per user
+)
+
+// signupInviteOnly makes POST /users require an invite code (waitlisted
+// launches); Run reads it from SIGNUP_INVITE_ONLY
+var signupInviteOnly bool
+
+// errInviteInvalid means the code doesn't exist in this organization or is used up
+var errInviteInvalid = errors.New("invalid or used-up invite code")
+
+// redeemInvite consumes one use of code and returns the code's id and its inviter
+func redeemInvite(ctx context.Context, tx *sql.Tx, code string) (int, int, error) {
+	var codeID, inviterID, uses, maxUses int
+	err := tx.QueryRowContext(ctx,
+		"SELECT id, inviter_id, uses, max_uses FROM invite_codes WHERE code = ? AND organization_id = ? FOR UPDATE",
+		strings.ToUpper(code), tenant.ID(ctx)).Scan(&codeID, &inviterID, &uses, &maxUses)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, errInviteInvalid
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	if uses >= maxUses {
+		return 0, 0, errInviteInvalid
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE invite_codes SET uses = uses + 1 WHERE id = ?", codeID); err != nil {
+		return 0, 0, err
+	}
+	return codeID, inviterID, nil
+}
+
+// inviteOwner resolves :id and checks it is the caller
+func inviteOwner(c *gin.Context) (int, bool) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return 0, false
+	}
+	if c.GetInt("auth_user_id") != userID {
+		abortWithError(c, forbidden("cannot manage another user's invites"))
+		return 0, false
+	}
+	return userID, true
+}
+
+// CreateInviteHandler godoc
+// @Summary Generate an invite code
+// @Description New users pass it as invite_code to POST /users, which records who invited them. A user can hold up to 10 codes with uses left.
+// @Tags Users
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID; must be the caller"
+// @Param max_uses formData int false "How many signups the code allows (1-100)" default(1)
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users/{id}/invites [post]
+func CreateInviteHandler(c *gin.Context) {
+	userID, ok := inviteOwner(c)
+	if !ok {
+		return
+	}
+	maxUses := 1
+	if v := strings.TrimSpace(c.PostForm("max_uses")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > inviteMaxUses {
+			abortWithError(c, badRequest("max_uses must be between 1 and 100"))
+			return
+		}
+		maxUses = n
+	}
+
+	ctx := c.Request.Context()
+	var open int
+	if err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM invite_codes WHERE inviter_id = ? AND uses < max_uses", userID).Scan(&open); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if open >= inviteOpenLimit {
+		abortWithError(c, conflict("you already have 10 unused invite codes"))
+		return
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	res, err := db.ExecContext(ctx,
+		"INSERT INTO invite_codes (organization_id, code, inviter_id, max_uses) VALUES (?, ?, ?, ?)",
+		tenant.ID(ctx), code, userID, maxUses)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	id, _ := res.LastInsertId()
+	c.JSON(201, gin.H{
+		"id":       id,
+		"code":     code,
+		"max_uses": maxUses,
+		"uses":     0,
+	})
+}
+
+// ListInvitesHandler godoc
+// @Summary Your invite codes and who joined with them
+// @Tags Users
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID; must be the caller"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/invites [get]
+func ListInvitesHandler(c *gin.Context) {
+	userID, ok := inviteOwner(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, code, max_uses, uses, created_at
+		FROM invite_codes
+		WHERE inviter_id = ?
+		ORDER BY created_at DESC, id DESC`, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	codes := []gin.H{}
+	byID := map[int]gin.H{}
+	for rows.Next() {
+		var id, maxUses, uses int
+		var code, createdAt string
+		if err := rows.Scan(&id, &code, &maxUses, &uses, &createdAt); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		entry := gin.H{
+			"id":          id,
+			"code":        code,
+			"max_uses":    maxUses,
+			"uses":        uses,
+			"created_at":  createdAt,
+			"redeemed_by": []gin.H{},
+		}
+		codes = append(codes, entry)
+		byID[id] = entry
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	joined, err := db.QueryContext(ctx, `
+		SELECT invite_code_id, id, uuid, handle, created_at
+		FROM users
+		WHERE invited_by = ? AND invite_code_id IS NOT NULL
+		ORDER BY created_at, id`, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = joined.Close() }()
+
+	invited := 0
+	for joined.Next() {
+		var codeID, id int
+		var publicID, handle, joinedAt string
+		if err := joined.Scan(&codeID, &id, &publicID, &handle, &joinedAt); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		invited++
+		if entry, ok := byID[codeID]; ok {
+			entry["redeemed_by"] = append(entry["redeemed_by"].([]gin.H),
+				gin.H{"uuid": publicID, "handle": handle, "joined_at": joinedAt})
+		}
+	}
+	if err := joined.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"invited": invited,
+		"data":    codes,
+	})
+}
+
+// ReferralsHandler godoc
+// @Summary Who invited whom (newest signups first)
+// @Description top_inviters ranks the organization's users by how many people signed up with their codes.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/referrals [get]
+func ReferralsHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	orgID := tenant.ID(ctx)
+	var total int
+	if err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM users WHERE organization_id = ? AND invite_code_id IS NOT NULL", orgID).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT u.id, u.uuid, u.handle, u.created_at, ic.code, inv.id, inv.uuid, inv.handle
+		FROM users u
+		JOIN invite_codes ic ON ic.id = u.invite_code_id
+		LEFT JOIN users inv ON inv.id = u.invited_by
+		WHERE u.organization_id = ?
+		ORDER BY u.created_at DESC, u.id DESC
+		LIMIT ? OFFSET ?`, orgID, limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	referrals := []gin.H{}
+	for rows.Next() {
+		var id int
+		var publicID, handle, joinedAt, code string
+		var inviterID sql.NullInt64
+		var inviterUUID, inviterHandle sql.NullString
+		if err := rows.Scan(&id, &publicID, &handle, &joinedAt, &code, &inviterID, &inviterUUID, &inviterHandle); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		referrals = append(referrals, gin.H{
+			"user":       gin.H{"uuid": publicID, "handle": handle},
+			"invited_by": userRef(inviterID, inviterUUID, inviterHandle),
+			"code":       code,
+			"joined_at":  joinedAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	top, err := db.QueryContext(ctx, `
+		SELECT inv.id, inv.uuid, inv.handle, COUNT(*) AS invited
+		FROM users u
+		JOIN users inv ON inv.id = u.invited_by
+		WHERE u.organization_id = ?
+		GROUP BY inv.id, inv.uuid, inv.handle
+		ORDER BY invited DESC, inv.id
+		LIMIT 10`, orgID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = top.Close() }()
+
+	inviters := []gin.H{}
+	for top.Next() {
+		var id, invited int
+		var publicID, handle string
+		if err := top.Scan(&id, &publicID, &handle, &invited); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		inviters = append(inviters, gin.H{
+			"user":    gin.H{"uuid": publicID, "handle": handle},
+			"invited": invited,
+		})
+	}
+	if err := top.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"page":         page,
+		"limit":        limit,
+		"total":        total,
+		"data":         referrals,
+		"top_inviters": inviters,
+	})
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateUserHandler_RedeemsInvite(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	inviteColumns := []string{"id", "inviter_id", "uses", "max_uses"}
+	// a code with a use left
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, inviter_id, uses, max_uses FROM invite_codes WHERE code = \\? AND organization_id = \\? FOR UPDATE").
+		WithArgs("ABCDEFGHJK", 1).
+		WillReturnRows(sqlmock.NewRows(inviteColumns).AddRow(4, 2, 0, 1))
+	mock.ExpectExec("UPDATE invite_codes SET uses = uses \\+ 1 WHERE id = \\?").
+		WithArgs(4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectPublicRef(mock, "users", 2, "u-2")
+	mock.ExpectExec("INSERT INTO users \\(organization_id, email, handle, password_hash, invited_by, invite_code_id\\)").
+		WithArgs(1, "a@example.com", "ann", sqlmock.AnyArg(), 2, 4).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT uuid, email, handle, role, created_at FROM users WHERE id = \\?").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "email", "handle", "role", "created_at"}).
+			AddRow("u-7", "a@example.com", "ann", "user", time.Now()))
+	mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+	// used up
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, inviter_id, uses, max_uses FROM invite_codes").
+		WithArgs("ABCDEFGHJK", 1).
+		WillReturnRows(sqlmock.NewRows(inviteColumns).AddRow(4, 2, 1, 1))
+	mock.ExpectRollback()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/users", CreateUserHandler)
+
+	form := url.Values{"email": {"a@example.com"}, "handle": {"ann"}, "password": {"pw"}, "invite_code": {"abcdefghjk"}}
+	w := postForm(r, "/users", form)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if body["invited_by"] != "u-2" {
+		t.Fatalf("expected invited_by u-2, got %v", body["invited_by"])
+	}
+
+	if w := postForm(r, "/users", form); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a used-up code, got %d: %s", w.Code, w.Body.String())
+	}
+
+	signupInviteOnly = true
+	defer func() { signupInviteOnly = false }()
+	w = postForm(r, "/users", url.Values{"email": {"b@example.com"}, "handle": {"bo"}, "password": {"pw"}})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a code when invite-only, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestCreateInviteHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectUser := func(id int) {
+		mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+			WithArgs(id, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}
+	expectOpen := func(n int) {
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM invite_codes WHERE inviter_id = \\? AND uses < max_uses").
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(n))
+	}
+
+	expectUser(2)
+	expectOpen(0)
+	mock.ExpectExec("INSERT INTO invite_codes \\(organization_id, code, inviter_id, max_uses\\)").
+		WithArgs(1, sqlmock.AnyArg(), 2, 5).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	// too many unused codes
+	expectUser(2)
+	expectOpen(inviteOpenLimit)
+	// out-of-range max_uses
+	expectUser(2)
+	// someone else's codes
+	expectUser(3)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/users/:id/invites", asUser(2), CreateInviteHandler)
+
+	for _, tc := range []struct {
+		path string
+		form url.Values
+		want int
+	}{
+		{"/users/2/invites", url.Values{"max_uses": {"5"}}, http.StatusCreated},
+		{"/users/2/invites", url.Values{}, http.StatusConflict},
+		{"/users/2/invites", url.Values{"max_uses": {"0"}}, http.StatusBadRequest},
+		{"/users/3/invites", url.Values{}, http.StatusForbidden},
+	} {
+		w := postForm(r, tc.path, tc.form)
+		if w.Code != tc.want {
+			t.Fatalf("%s %v: expected %d, got %d: %s", tc.path, tc.form, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/isbn"
+	"github.com/YeswanthC7/bookrec/internal/openlibrary"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// openLibrary is where unknown ISBNs are looked up (tests swap it)
+var openLibrary = &openlibrary.Client{}
+
+// GetBookByISBNHandler godoc
+// @Summary Get a book by ISBN
+// @Description For barcode-scanning clients: the full record of the catalogue book an ISBN-10 or ISBN-13 (hyphens allowed, check digit validated) belongs to, as GET /books/{id} returns it. Only ISBNs the catalogue knows are found; GET /lookup/isbn/{raw} also looks unknown ones up on Open Library.
+// @Tags Books
+// @Produce json
+// @Param isbn path string true "ISBN-10 or ISBN-13, e.g. 978-0-261-10221-7"
+// @Param Accept-Language header string false "Preferred languages for translated metadata"
+// @Success 200 {object} map[string]interface{}
+// @Header 200 {string} ETag "Quoted book version, e.g. \"3\""
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /books/isbn/{isbn} [get]
+func GetBookByISBNHandler(c *gin.Context) {
+	isbn13, err := isbn.Normalize(c.Param("isbn"))
+	if err != nil {
+		abortWithError(c, badRequest("invalid ISBN").WithDetails(gin.H{"reason": err.Error()}))
+		return
+	}
+	ctx := c.Request.Context()
+	var bookID int
+	err = db.QueryRowContext(ctx, `
+		SELECT b.id
+		FROM book_isbns bi
+		JOIN books b ON b.id = bi.book_id
+		WHERE bi.isbn13 = ? AND `+tenant.BooksVisibleSQL("b"), isbn13, tenant.ID(ctx)).Scan(&bookID)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("no book found for this ISBN").WithDetails(gin.H{"isbn13": isbn13}))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	writeBook(c, bookID)
+}
+
+// loadBookISBNs lists the ISBNs known for a book's editions
+func loadBookISBNs(ctx context.Context, bookID int) ([]gin.H, error) {
+	rows, err := db.QueryContext(ctx, "SELECT isbn13 FROM book_isbns WHERE book_id = ? ORDER BY isbn13", bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	isbns := []gin.H{}
+	for rows.Next() {
+		var isbn13 string
+		if err := rows.Scan(&isbn13); err != nil {
+			return nil, err
+		}
+		var isbn10 interface{}
+		if v, ok := isbn.To10(isbn13); ok {
+			isbn10 = v
+		}
+		isbns = append(isbns, gin.H{"isbn13": isbn13, "isbn10": isbn10})
+	}
+	return isbns, rows.Err()
+}
+
+// LookupISBNHandler godoc
+// @Summary Resolve an ISBN to a book
+// @Description Accepts ISBN-10 or ISBN-13 with or without hyphens (as scanned from a barcode) and validates the check digit. Known ISBNs resolve to their book (source catalogue). Unknown ones are looked up on Open Library, and the work is added to the catalogue if needed (source open_library; 201 when the book is new).
+// @Tags Books
+// @Produce json
+// @Param raw path string true "ISBN-10 or ISBN-13, e.g. 978-0-261-10221-7"
+// @Success 200 {object} map[string]interface{}
+// @Success 201 {object} map[string]interface{}
+// @Header 201 {string} Location "/books/{slug}"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Router /lookup/isbn/{raw} [get]
+func LookupISBNHandler(c *gin.Context) {
+	isbn13, err := isbn.Normalize(c.Param("raw"))
+	if err != nil {
+		abortWithError(c, badRequest("invalid ISBN").WithDetails(gin.H{"reason": err.Error()}))
+		return
+	}
+	isbn10, _ := isbn.To10(isbn13)
+
+	ctx := c.Request.Context()
+	source, created := "catalogue", false
+	var bookID int64
+	err = db.QueryRowContext(ctx, `
+		SELECT b.id
+		FROM book_isbns bi
+		JOIN books b ON b.id = bi.book_id
+		WHERE bi.isbn13 = ? AND `+tenant.BooksVisibleSQL("b"), isbn13, tenant.ID(ctx)).Scan(&bookID)
+	if errors.Is(err, sql.ErrNoRows) {
+		doc, lookupErr := openLibrary.ByISBN(ctx, isbn13)
+		if errors.Is(lookupErr, openlibrary.ErrNotFound) {
+			abortWithError(c, notFound("no book found for this ISBN").WithDetails(gin.H{"isbn13": isbn13}))
+			return
+		}
+		if lookupErr != nil {
+			log.Printf("⚠️ open library lookup for %s failed: %v", isbn13, lookupErr)
+			abortWithError(c, newAppError(502, "book lookup is unavailable, try again later"))
+			return
+		}
+		bookID, created, err = openlibrary.Upsert(ctx, db, doc)
+		if err == nil {
+			_, err = db.ExecContext(ctx, "INSERT IGNORE INTO book_isbns (isbn13, book_id) VALUES (?, ?)", isbn13, bookID)
+		}
+		source = "open_library"
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	var publicID, slug, title string
+	var author sql.NullString
+	var year sql.NullInt64
+	if err := db.QueryRowContext(ctx,
+		"SELECT uuid, slug, title, author, published_year FROM books WHERE id = ?", bookID).
+		Scan(&publicID, &slug, &title, &author, &year); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	status := 200
+	if created {
+		status = 201
+		c.Header("Location", "/books/"+slug)
+	}
+	var isbn10Value interface{}
+	if isbn10 != "" {
+		isbn10Value = isbn10
+	}
+	c.JSON(status, gin.H{
+		"isbn13":  isbn13,
+		"isbn10":  isbn10Value,
+		"source":  source,
+		"created": created,
+		"book": gin.H{
+			"uuid":   publicID,
+			"slug":   slug,
+			"title":  title,
+			"author": author.String,
+			"year":   year.Int64,
+		},
+	})
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/openlibrary"
+)
+
+func TestLookupISBNHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("isbn") == "9780261102217" {
+			_, _ = w.Write([]byte(`{"docs":[{"key":"/works/OL262758W","title":"The Hobbit","author_name":["J.R.R. Tolkien"],"first_publish_year":1937}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"docs":[]}`))
+	}))
+	defer srv.Close()
+	saved := openLibrary
+	openLibrary = &openlibrary.Client{BaseURL: srv.URL}
+	defer func() { openLibrary = saved }()
+
+	// known ISBN
+	mock.ExpectQuery("FROM book_isbns bi\\s+JOIN books b").
+		WithArgs("9780261102217", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery("SELECT uuid, slug, title, author, published_year FROM books WHERE id = \\?").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "slug", "title", "author", "published_year"}).
+			AddRow("b-3", "the-hobbit-b3", "The Hobbit", "J.R.R. Tolkien", 1937))
+	// unknown ISBN: fetched from Open Library and created
+	mock.ExpectQuery("FROM book_isbns bi\\s+JOIN books b").
+		WithArgs("9780261102217", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("INSERT INTO books").
+		WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectExec("DELETE FROM book_genres WHERE book_id = \\?").
+		WithArgs(9).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO authors").
+		WithArgs("J.R.R. Tolkien").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("UPDATE books SET author_id = \\? WHERE id = \\?").
+		WithArgs(int64(2), 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT IGNORE INTO book_isbns \\(isbn13, book_id\\)").
+		WithArgs("9780261102217", 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT uuid, slug, title, author, published_year FROM books WHERE id = \\?").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "slug", "title", "author", "published_year"}).
+			AddRow("b-9", "the-hobbit-b9", "The Hobbit", "J.R.R. Tolkien", 1937))
+	// valid but unknown everywhere
+	mock.ExpectQuery("FROM book_isbns bi\\s+JOIN books b").
+		WithArgs("9791090636071", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/lookup/isbn/:raw", LookupISBNHandler)
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	w := get("/lookup/isbn/0-261-10221-4")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"source":"catalogue"`) || !strings.Contains(w.Body.String(), `"isbn10":"0261102214"`) {
+		t.Fatalf("expected the catalogue book, got %d: %s", w.Code, w.Body.String())
+	}
+	w = get("/lookup/isbn/978-0-261-10221-7")
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/books/the-hobbit-b9" {
+		t.Fatalf("expected 201 with a Location, got %d %q: %s", w.Code, w.Header().Get("Location"), w.Body.String())
+	}
+	if w := get("/lookup/isbn/979-10-90636-07-1"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an ISBN nobody knows, got %d", w.Code)
+	}
+	if w := get("/lookup/isbn/978-0-261-10221-8"); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "check digit") {
+		t.Fatalf("expected 400 for a bad check digit, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestGetBookByISBNHandler_NotInCatalogue(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// the ISBN-10 is looked up as its ISBN-13, and Open Library isn't asked
+	mock.ExpectQuery("FROM book_isbns bi\\s+JOIN books b").
+		WithArgs("9780261102217", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorMiddleware())
+	r.GET("/books/isbn/:isbn", GetBookByISBNHandler)
+	for path, want := range map[string]int{"/books/isbn/0-261-10221-4": http.StatusNotFound, "/books/isbn/0261102215": http.StatusBadRequest} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Fatalf("%s: expected %d, got %d: %s", path, want, w.Code, w.Body.String())
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
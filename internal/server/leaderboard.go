@@ -0,0 +1,142 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// leaderboardWindows maps a window name to how far back it looks (0 = no limit)
+var leaderboardWindows = map[string]time.Duration{
+	"weekly":   7 * 24 * time.Hour,
+	"monthly":  30 * 24 * time.Hour,
+	"all_time": 0,
+}
+
+// LeaderboardHandler godoc
+// @Summary Most active readers
+// @Description Ranks readers by books finished (distinct books rated) in a rolling window, then by interactions (likes and ratings). Views, interactions marked private and readers who opted out are left out.
+// @Tags Social
+// @Produce json
+// @Param window query string false "weekly (default), monthly or all_time"
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /leaderboard [get]
+func LeaderboardHandler(c *gin.Context) {
+	window := c.DefaultQuery("window", "weekly")
+	span, ok := leaderboardWindows[window]
+	if !ok {
+		abortWithError(c, badRequest("window must be weekly, monthly or all_time"))
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	ctx := c.Request.Context()
+	where := `
+		WHERE i.organization_id = ? AND i.action IN ('like', 'rating') AND i.visibility = 'public'
+		  AND u.leaderboard_opt_out = FALSE AND i.deleted_at IS NULL AND u.deleted_at IS NULL`
+	args := []interface{}{tenant.ID(ctx)}
+	var since interface{}
+	if span > 0 {
+		from := time.Now().UTC().Add(-span).Truncate(time.Second)
+		where += " AND i.created_at >= ?"
+		args = append(args, from)
+		since = from.Format(time.RFC3339)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT u.id, u.uuid, u.handle,
+		       COUNT(DISTINCT CASE WHEN i.action = 'rating' THEN i.book_id END) AS finished,
+		       COUNT(*) AS interactions
+		FROM interactions i
+		JOIN users u ON u.id = i.user_id`+where+`
+		GROUP BY u.id, u.uuid, u.handle
+		ORDER BY finished DESC, interactions DESC, u.id
+		LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	readers := []gin.H{}
+	for rows.Next() {
+		var id, finished, interactions int
+		var publicID, handle string
+		if err := rows.Scan(&id, &publicID, &handle, &finished, &interactions); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		readers = append(readers, gin.H{
+			"rank":           len(readers) + 1,
+			"user":           gin.H{"uuid": publicID, "handle": handle},
+			"books_finished": finished,
+			"interactions":   interactions,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"window": window,
+		"since":  since,
+		"data":   readers,
+	})
+}
+
+// setLeaderboardOptOut flips the caller's leaderboard_opt_out flag
+func setLeaderboardOptOut(c *gin.Context, optOut bool) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+	if c.GetInt("auth_user_id") != userID {
+		abortWithError(c, forbidden("cannot change another user's leaderboard setting"))
+		return
+	}
+	if _, err := db.ExecContext(c.Request.Context(),
+		"UPDATE users SET leaderboard_opt_out = ? WHERE id = ?", optOut, userID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"leaderboard_opt_out": optOut})
+}
+
+// LeaderboardOptOutHandler godoc
+// @Summary Leave the leaderboard
+// @Tags Users
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID; must be the caller"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/leaderboard [delete]
+func LeaderboardOptOutHandler(c *gin.Context) {
+	setLeaderboardOptOut(c, true)
+}
+
+// LeaderboardOptInHandler godoc
+// @Summary Appear on the leaderboard again (the default)
+// @Tags Users
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID; must be the caller"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/leaderboard [post]
+func LeaderboardOptInHandler(c *gin.Context) {
+	setLeaderboardOptOut(c, false)
+}
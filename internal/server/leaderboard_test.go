@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLeaderboardHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	columns := []string{"id", "uuid", "handle", "finished", "interactions"}
+	// monthly looks back from now
+	mock.ExpectQuery("FROM interactions i\\s+JOIN users u ON u.id = i.user_id\\s+WHERE i.organization_id = \\? AND i.action IN \\('like', 'rating'\\) AND i.visibility = 'public'\\s+AND u.leaderboard_opt_out = FALSE AND i.deleted_at IS NULL AND u.deleted_at IS NULL AND i.created_at >= \\?\\s+GROUP BY").
+		WithArgs(1, sqlmock.AnyArg(), 5).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(2, "u-2", "bob", 4, 9).
+			AddRow(3, "u-3", "amy", 1, 12))
+	// all_time has no cutoff
+	mock.ExpectQuery("AND u.leaderboard_opt_out = FALSE AND i.deleted_at IS NULL AND u.deleted_at IS NULL\\s+GROUP BY").
+		WithArgs(1, 20).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/leaderboard", LeaderboardHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/leaderboard?window=monthly&limit=5", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Since *string                  `json:"since"`
+		Data  []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if body.Since == nil || len(body.Data) != 2 || body.Data[1]["rank"] != float64(2) {
+		t.Fatalf("unexpected leaderboard: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/leaderboard?window=all_time", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/leaderboard?window=daily", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown window, got %d", w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestLeaderboardOptOutHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectExec("UPDATE users SET leaderboard_opt_out = \\? WHERE id = \\?").
+		WithArgs(true, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.DELETE("/users/:id/leaderboard", asUser(1), LeaderboardOptOutHandler)
+
+	for _, tc := range []struct {
+		path string
+		want int
+	}{
+		{"/users/1/leaderboard", http.StatusOK},
+		{"/users/2/leaderboard", http.StatusForbidden},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, tc.path, nil))
+		if w.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d: %s", tc.path, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// shutdownTimeout is how long in-flight requests get to finish once the
+// server is stopping (SHUTDOWN_TIMEOUT_SECONDS, read in Run by
+// loadShutdownTimeout); keep it under the deploy's kill grace period
+var shutdownTimeout = 30 * time.Second
+
+// loadShutdownTimeout reads SHUTDOWN_TIMEOUT_SECONDS, keeping the default
+// unless it's a positive whole number
+func loadShutdownTimeout() {
+	if n, err := strconv.Atoi(os.Getenv("SHUTDOWN_TIMEOUT_SECONDS")); err == nil && n > 0 {
+		shutdownTimeout = time.Duration(n) * time.Second
+	}
+}
+
+// shuttingDown is closed when the server starts shutting down. Event streams
+// and websockets never finish on their own, so they watch it and end.
+var shuttingDown = make(chan struct{})
+
+// shutdownOnce guards closing shuttingDown, which serve does every time it
+// stops
+var shutdownOnce sync.Once
+
+// stopStreams closes shuttingDown; later calls do nothing
+func stopStreams() {
+	shutdownOnce.Do(func() { close(shuttingDown) })
+}
+
+// serve answers requests on ln until ctx is cancelled, then shuts down
+// gracefully: ln closes, streams are told to end and in-flight requests get
+// shutdownTimeout to finish before their connections are cut.
+func serve(ctx context.Context, srv *http.Server, ln net.Listener) error {
+	failed := make(chan error, 1)
+	go func() {
+		if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			failed <- err
+		}
+	}()
+	select {
+	case err := <-failed:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Printf("⏳ Shutting down, giving in-flight requests up to %s", shutdownTimeout)
+	stopStreams()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️  Requests still running after %s were cut off: %v", shutdownTimeout, err)
+		_ = srv.Close()
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// resetShutdown gives the test its own shuttingDown to close
+func resetShutdown(t *testing.T) {
+	prev := shuttingDown
+	shuttingDown, shutdownOnce = make(chan struct{}), sync.Once{}
+	t.Cleanup(func() { shuttingDown, shutdownOnce = prev, sync.Once{} })
+}
+
+func TestServeFinishesInFlightRequests(t *testing.T) {
+	resetShutdown(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	started := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = io.WriteString(w, "done")
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, srv, ln) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{string(body), err}
+	}()
+
+	<-started
+	cancel()
+	if r := <-responses; r.err != nil || r.body != "done" {
+		t.Fatalf("expected the in-flight request to finish, got %q, %v", r.body, r.err)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	select {
+	case <-shuttingDown:
+	default:
+		t.Fatal("expected streams to be told to stop")
+	}
+	if _, err := http.Get("http://" + ln.Addr().String()); err == nil {
+		t.Fatal("expected new connections to be refused after shutdown")
+	}
+}
+
+func TestServeCutsOffRequestsPastTheTimeout(t *testing.T) {
+	resetShutdown(t)
+	prev := shutdownTimeout
+	shutdownTimeout = 50 * time.Millisecond
+	defer func() { shutdownTimeout = prev }()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, srv, ln) }()
+	go func() { _, _ = http.Get("http://" + ln.Addr().String()) }()
+
+	<-started
+	cancel()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("serve: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected shutdown to give up on the stuck request")
+	}
+}
+
+func TestStopStreamsTwice(t *testing.T) {
+	resetShutdown(t)
+	stopStreams()
+	stopStreams()
+	select {
+	case <-shuttingDown:
+	default:
+		t.Fatal("expected streams to be told to stop")
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/softdelete"
+)
+
+// goodreadsColumns is the subset of Goodreads' library export that its
+// importer (and StoryGraph's) reads
+var goodreadsColumns = []string{
+	"Title", "Author", "ISBN", "My Rating", "Date Added", "Bookshelves", "Exclusive Shelf",
+}
+
+// goodreadsShelf turns a list name into a Goodreads shelf name
+// ("Summer Reads 2026" -> "summer-reads-2026")
+func goodreadsShelf(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
+
+// ExportListHandler godoc
+// @Summary Export a reading list as a Goodreads-compatible CSV
+// @Description Importable by Goodreads and StoryGraph. Books land on a shelf named after the list and on "to-read". My Rating is the list owner's latest rating (0 when unrated), whoever downloads it. ISBN is the book's lowest ISBN-13; books with none leave it empty and importers match on title and author.
+// @Tags Lists
+// @Produce text/csv
+// @Param Authorization header string false "Bearer token (for unlisted and private lists)"
+// @Param id path string true "List UUID"
+// @Param format query string false "goodreads (default)"
+// @Success 200 {string} string "export file"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id}/export [get]
+func ExportListHandler(c *gin.Context) {
+	if format := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", "goodreads"))); format != "goodreads" {
+		abortWithError(c, badRequest("format must be goodreads"))
+		return
+	}
+	listID, ok := readableList(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	var publicID, name string
+	var ownerID int
+	if err := db.QueryRowContext(ctx, "SELECT uuid, name, user_id FROM lists WHERE id = ?", listID).Scan(&publicID, &name, &ownerID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT b.title, b.author, (SELECT MIN(bi.isbn13) FROM book_isbns bi WHERE bi.book_id = b.id), li.added_at,
+		       (SELECT i.rating FROM interactions i
+		        WHERE i.user_id = ? AND i.book_id = b.id AND i.action = 'rating' AND `+softdelete.LiveSQL("i")+`
+		        ORDER BY i.created_at DESC, i.id DESC LIMIT 1)
+		FROM list_items li
+		JOIN books b ON b.id = li.book_id
+		WHERE li.list_id = ?
+		ORDER BY li.position`, ownerID, listID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	shelf := goodreadsShelf(name)
+	w := newExportWriter(c, "csv", "list-"+publicID+"-goodreads", goodreadsColumns)
+	defer w.Flush()
+
+	for rows.Next() {
+		var title string
+		var author, isbn sql.NullString
+		var addedAt time.Time
+		var rating sql.NullInt64
+		if err := rows.Scan(&title, &author, &isbn, &addedAt, &rating); err != nil {
+			log.Printf("⚠️ list export aborted: %v", err)
+			return
+		}
+		if err := w.Write([]interface{}{
+			title, author.String, isbn.String, rating.Int64, addedAt.UTC().Format("2006/01/02"), shelf, "to-read",
+		}); err != nil {
+			log.Printf("⚠️ list export aborted: %v", err)
+			return
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("⚠️ list export aborted: %v", err)
+	}
+}
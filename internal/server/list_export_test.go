@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestExportListHandler_Goodreads(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	added := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT 1 FROM lists WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT visibility FROM lists WHERE id = \\?").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"visibility"}).AddRow("public"))
+	// the list is user 3's; exported by user 1 it still carries user 3's ratings
+	mock.ExpectQuery("SELECT uuid, name, user_id FROM lists WHERE id = \\?").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "name", "user_id"}).AddRow("l-5", "Summer  Reads", 3))
+	mock.ExpectQuery("SELECT MIN\\(bi.isbn13\\) FROM book_isbns bi WHERE bi.book_id = b.id[\\s\\S]+i.action = 'rating' AND i.deleted_at IS NULL[\\s\\S]+FROM list_items li\\s+JOIN books b ON b.id = li.book_id\\s+WHERE li.list_id = \\?").
+		WithArgs(3, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "author", "isbn", "added_at", "rating"}).
+			AddRow("Dune", "Frank Herbert", "9780441172719", added, 5).
+			AddRow("The Dispossessed, Anniversary Edition", nil, nil, added, nil))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/lists/:id/export", asUser(1), ExportListHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lists/5/export?format=goodreads", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	want := "Title,Author,ISBN,My Rating,Date Added,Bookshelves,Exclusive Shelf\n" +
+		"Dune,Frank Herbert,9780441172719,5,2026/10/01,summer-reads,to-read\n" +
+		"\"The Dispossessed, Anniversary Edition\",,,0,2026/10/01,summer-reads,to-read\n"
+	if w.Body.String() != want {
+		t.Fatalf("unexpected csv:\n%s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lists/5/export?format=librarything", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown format, got %d", w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,634 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// parseListPermission reads a member permission; empty means editor
+func parseListPermission(raw string) (string, bool) {
+	switch strings.TrimSpace(raw) {
+	case "", "editor":
+		return "editor", true
+	case "manager":
+		return "manager", true
+	}
+	return "", false
+}
+
+// inviteeID finds the user to invite from the user_id (UUID) or
+// handle form field, within the request's organization
+func inviteeID(c *gin.Context) (int, bool) {
+	if ref := c.PostForm("user_id"); ref != "" {
+		return resolveParam(c, resolveUserRef, ref, "user")
+	}
+	handle := strings.TrimSpace(c.PostForm("handle"))
+	if handle == "" {
+		abortWithError(c, badRequest("handle or user_id is required"))
+		return 0, false
+	}
+
+	ctx := c.Request.Context()
+	rows, err := db.QueryContext(ctx,
+		"SELECT id FROM users WHERE handle = ? AND organization_id = ? LIMIT 2", handle, tenant.ID(ctx))
+	if err != nil {
+		abortWithError(c, err)
+		return 0, false
+	}
+	defer func() { _ = rows.Close() }()
+
+	var matches []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			abortWithError(c, err)
+			return 0, false
+		}
+		matches = append(matches, id)
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return 0, false
+	}
+	switch len(matches) {
+	case 0:
+		abortWithError(c, notFound("user not found"))
+		return 0, false
+	case 1:
+		return matches[0], true
+	}
+	// handles aren't unique
+	abortWithError(c, conflict("several users have that handle; invite by user_id instead"))
+	return 0, false
+}
+
+// memberEventDetail is detail for the list history, naming the member
+// whose membership changed
+func memberEventDetail(ctx context.Context, tx *sql.Tx, userID int, detail gin.H) (gin.H, error) {
+	userUUID, err := publicRef(ctx, tx, "users", userID)
+	if err != nil {
+		return nil, err
+	}
+	detail["user_uuid"] = userUUID
+	return detail, nil
+}
+
+// loadListMember returns one membership row (invited or active)
+func loadListMember(ctx context.Context, listID, userID int) (gin.H, error) {
+	var userUUID, handle, permission, status, invitedAt string
+	var acceptedAt sql.NullString
+	if err := db.QueryRowContext(ctx, `
+		SELECT u.uuid, u.handle, m.permission, m.status, m.created_at, m.accepted_at
+		FROM list_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.list_id = ? AND m.user_id = ?`, listID, userID).
+		Scan(&userUUID, &handle, &permission, &status, &invitedAt, &acceptedAt); err != nil {
+		return nil, err
+	}
+	var accepted interface{}
+	if acceptedAt.Valid {
+		accepted = acceptedAt.String
+	}
+	return gin.H{
+		"user_uuid":   userUUID,
+		"handle":      handle,
+		"permission":  permission,
+		"status":      status,
+		"invited_at":  invitedAt,
+		"accepted_at": accepted,
+	}, nil
+}
+
+// InviteListMemberHandler godoc
+// @Summary Invite a co-editor to a reading list (owner or manager)
+// @Description The invitee joins by accepting (POST /lists/{id}/invitation). Handles aren't unique: an ambiguous handle returns 409, so pass user_id instead.
+// @Tags Lists
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "List UUID"
+// @Param handle formData string false "Invitee handle"
+// @Param user_id formData string false "Invitee UUID; wins over handle"
+// @Param permission formData string false "editor (default) or manager"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /lists/{id}/members [post]
+func InviteListMemberHandler(c *gin.Context) {
+	listID, ok := authorizeList(c, listRoleManager)
+	if !ok {
+		return
+	}
+	permission, ok := parseListPermission(c.PostForm("permission"))
+	if !ok {
+		abortWithError(c, badRequest("permission must be editor or manager"))
+		return
+	}
+	userID, ok := inviteeID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	role, err := listRoleOf(ctx, listID, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if role == listRoleOwner {
+		abortWithError(c, badRequest("the owner is already on the list"))
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	actorID := c.GetInt("auth_user_id")
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO list_members (list_id, user_id, permission, invited_by) VALUES (?, ?, ?, ?)",
+		listID, userID, permission, actorID); err != nil {
+		if dberr.Is(err, dberr.ErrDuplicate) {
+			abortWithError(c, conflict("user is already a member or invited"))
+			return
+		}
+		abortWithError(c, err)
+		return
+	}
+	detail, err := memberEventDetail(ctx, tx, userID, gin.H{"permission": permission})
+	if err == nil {
+		err = logListChange(ctx, tx, listID, actorID, "member_invited", 0, detail)
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	member, err := loadListMember(ctx, listID, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(201, member)
+}
+
+// ListListMembersHandler godoc
+// @Summary Co-editors and pending invitations of a reading list
+// @Tags Lists
+// @Produce json
+// @Param Authorization header string false "Bearer token (for unlisted and private lists)"
+// @Param id path string true "List UUID"
+// @Success 200 {array} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id}/members [get]
+func ListListMembersHandler(c *gin.Context) {
+	listID, ok := readableList(c)
+	if !ok {
+		return
+	}
+
+	rows, err := db.QueryContext(c.Request.Context(), `
+		SELECT u.uuid, u.handle, m.permission, m.status, m.created_at, m.accepted_at
+		FROM list_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.list_id = ?
+		ORDER BY m.status, m.created_at, m.user_id`, listID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	members := []gin.H{}
+	for rows.Next() {
+		var userUUID, handle, permission, status, invitedAt string
+		var acceptedAt sql.NullString
+		if err := rows.Scan(&userUUID, &handle, &permission, &status, &invitedAt, &acceptedAt); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		var accepted interface{}
+		if acceptedAt.Valid {
+			accepted = acceptedAt.String
+		}
+		members = append(members, gin.H{
+			"user_uuid":   userUUID,
+			"handle":      handle,
+			"permission":  permission,
+			"status":      status,
+			"invited_at":  invitedAt,
+			"accepted_at": accepted,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, members)
+}
+
+// UpdateListMemberHandler godoc
+// @Summary Change a co-editor's permission (owner or manager)
+// @Tags Lists
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "List UUID"
+// @Param user_id path string true "Member UUID"
+// @Param permission formData string true "editor or manager"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id}/members/{user_id} [patch]
+func UpdateListMemberHandler(c *gin.Context) {
+	listID, ok := authorizeList(c, listRoleManager)
+	if !ok {
+		return
+	}
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("user_id"), "user")
+	if !ok {
+		return
+	}
+	permission, ok := parseListPermission(c.PostForm("permission"))
+	if !ok || c.PostForm("permission") == "" {
+		abortWithError(c, badRequest("permission must be editor or manager"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var current string
+	err = tx.QueryRowContext(ctx,
+		"SELECT permission FROM list_members WHERE list_id = ? AND user_id = ? FOR UPDATE", listID, userID).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("user is not a member of the list"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if current != permission {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE list_members SET permission = ? WHERE list_id = ? AND user_id = ?", permission, listID, userID); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		detail, err := memberEventDetail(ctx, tx, userID, gin.H{"from": current, "to": permission})
+		if err == nil {
+			err = logListChange(ctx, tx, listID, c.GetInt("auth_user_id"), "permission_changed", 0, detail)
+		}
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	member, err := loadListMember(ctx, listID, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, member)
+}
+
+// RemoveListMemberHandler godoc
+// @Summary Remove a co-editor or revoke an invitation
+// @Description Owners and managers can remove anyone; members can remove themselves (leave the list).
+// @Tags Lists
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "List UUID"
+// @Param user_id path string true "Member UUID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id}/members/{user_id} [delete]
+func RemoveListMemberHandler(c *gin.Context) {
+	listID, ok := resolveParam(c, resolveListRef, c.Param("id"), "list")
+	if !ok {
+		return
+	}
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("user_id"), "user")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	actorID := c.GetInt("auth_user_id")
+	if actorID != userID {
+		role, err := listRoleOf(ctx, listID, actorID)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if role < listRoleManager {
+			abortWithError(c, forbidden("requires manager access to the list"))
+			return
+		}
+	}
+
+	action := "member_removed"
+	if actorID == userID {
+		action = "member_left"
+	}
+	removeListMember(c, listID, userID, action)
+}
+
+// AcceptListInvitationHandler godoc
+// @Summary Accept an invitation to co-edit a reading list
+// @Tags Lists
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "List UUID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id}/invitation [post]
+func AcceptListInvitationHandler(c *gin.Context) {
+	listID, ok := resolveParam(c, resolveListRef, c.Param("id"), "list")
+	if !ok {
+		return
+	}
+	userID := c.GetInt("auth_user_id")
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE list_members SET status = 'active', accepted_at = CURRENT_TIMESTAMP
+		WHERE list_id = ? AND user_id = ? AND status = 'invited'`, listID, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		abortWithError(c, notFound("no pending invitation for this list"))
+		return
+	}
+	if err := logListChange(ctx, tx, listID, userID, "member_joined", 0, nil); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	member, err := loadListMember(ctx, listID, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, member)
+}
+
+// DeclineListInvitationHandler godoc
+// @Summary Decline an invitation to co-edit a reading list
+// @Tags Lists
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "List UUID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id}/invitation [delete]
+func DeclineListInvitationHandler(c *gin.Context) {
+	listID, ok := resolveParam(c, resolveListRef, c.Param("id"), "list")
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	userID := c.GetInt("auth_user_id")
+	pending, err := rowExists(ctx,
+		"SELECT 1 FROM list_members WHERE list_id = ? AND user_id = ? AND status = 'invited'", listID, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if !pending {
+		abortWithError(c, notFound("no pending invitation for this list"))
+		return
+	}
+	removeListMember(c, listID, userID, "invitation_declined")
+}
+
+// removeListMember deletes the membership, logs action and answers 204
+// (or 404 when there was nothing to delete)
+func removeListMember(c *gin.Context, listID, userID int, action string) {
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM list_members WHERE list_id = ? AND user_id = ?", listID, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		abortWithError(c, notFound("user is not a member of the list"))
+		return
+	}
+	detail, err := memberEventDetail(ctx, tx, userID, gin.H{})
+	if err == nil {
+		err = logListChange(ctx, tx, listID, c.GetInt("auth_user_id"), action, 0, detail)
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// ListInvitationsHandler godoc
+// @Summary Pending list invitations for the caller
+// @Tags Lists
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID; must be the caller"
+// @Success 200 {array} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/list-invitations [get]
+func ListInvitationsHandler(c *gin.Context) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+	if userID != c.GetInt("auth_user_id") {
+		abortWithError(c, forbidden("cannot read another user's invitations"))
+		return
+	}
+
+	rows, err := db.QueryContext(c.Request.Context(), `
+		SELECT l.uuid, l.name, m.permission, m.created_at, inv.uuid, inv.handle
+		FROM list_members m
+		JOIN lists l ON l.id = m.list_id
+		LEFT JOIN users inv ON inv.id = m.invited_by
+		WHERE m.user_id = ? AND m.status = 'invited'
+		ORDER BY m.created_at DESC`, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	invitations := []gin.H{}
+	for rows.Next() {
+		var listUUID, name, permission, invitedAt string
+		var inviterUUID, inviterHandle sql.NullString
+		if err := rows.Scan(&listUUID, &name, &permission, &invitedAt, &inviterUUID, &inviterHandle); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		var inviter interface{}
+		if inviterUUID.Valid {
+			inviter = gin.H{"uuid": inviterUUID.String, "handle": inviterHandle.String}
+		}
+		invitations = append(invitations, gin.H{
+			"list_uuid":  listUUID,
+			"name":       name,
+			"permission": permission,
+			"invited_at": invitedAt,
+			"invited_by": inviter,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, invitations)
+}
+
+// ListHistoryHandler godoc
+// @Summary Edit history of a reading list (newest first)
+// @Description Actions: created, renamed, book_added, book_moved, book_removed, member_invited, member_joined, member_left, member_removed, invitation_declined, permission_changed, visibility_changed, share_link_created, share_link_revoked.
+// @Tags Lists
+// @Produce json
+// @Param Authorization header string false "Bearer token (for unlisted and private lists)"
+// @Param id path string true "List UUID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id}/history [get]
+func ListHistoryHandler(c *gin.Context) {
+	listID, ok := readableList(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM list_events WHERE list_id = ?", listID).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT e.id, e.action, e.detail, e.created_at,
+		       u.uuid, u.handle,
+		       b.uuid, b.slug, b.title
+		FROM list_events e
+		LEFT JOIN users u ON u.id = e.user_id
+		LEFT JOIN books b ON b.id = e.book_id
+		WHERE e.list_id = ?
+		ORDER BY e.id DESC
+		LIMIT ? OFFSET ?`, listID, limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	events := []gin.H{}
+	for rows.Next() {
+		var id int
+		var action, createdAt string
+		var detail []byte
+		var actorUUID, handle, bookUUID, slug, title sql.NullString
+		if err := rows.Scan(&id, &action, &detail, &createdAt,
+			&actorUUID, &handle,
+			&bookUUID, &slug, &title); err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		event := gin.H{"id": id, "action": action, "created_at": createdAt, "actor": nil, "book": nil, "detail": nil}
+		if actorUUID.Valid {
+			event["actor"] = gin.H{"uuid": actorUUID.String, "handle": handle.String}
+		}
+		if bookUUID.Valid {
+			event["book"] = gin.H{"uuid": bookUUID.String, "slug": slug.String, "title": title.String}
+		}
+		if len(detail) > 0 {
+			event["detail"] = json.RawMessage(detail)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  events,
+	})
+}
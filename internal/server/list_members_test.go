@@ -0,0 +1,111 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestInviteListMemberHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// invite by handle
+	mock.ExpectQuery("SELECT 1 FROM lists WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	expectListRole(mock, 5, 1, 1, "")
+	mock.ExpectQuery("SELECT id FROM users WHERE handle = \\? AND organization_id = \\?").
+		WithArgs("bob", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	expectListRole(mock, 5, 2, 1, "")
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO list_members \\(list_id, user_id, permission, invited_by\\)").
+		WithArgs(5, 2, "manager", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectPublicRef(mock, "users", 2, "u-2")
+	expectListChange(mock, 5, "member_invited")
+	mock.ExpectCommit()
+	mock.ExpectQuery("FROM list_members m\\s+JOIN users u ON u.id = m.user_id\\s+WHERE m.list_id = \\? AND m.user_id = \\?").
+		WithArgs(5, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "handle", "permission", "status", "created_at", "accepted_at"}).
+			AddRow("u-2", "bob", "manager", "invited", "2026-10-01 12:00:00", nil))
+
+	// a handle shared by two users
+	mock.ExpectQuery("SELECT 1 FROM lists WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	expectListRole(mock, 5, 1, 1, "")
+	mock.ExpectQuery("SELECT id FROM users WHERE handle = \\? AND organization_id = \\?").
+		WithArgs("sam", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(4))
+
+	// "owner" isn't a grantable permission
+	mock.ExpectQuery("SELECT 1 FROM lists WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	expectListRole(mock, 5, 1, 1, "")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/lists/:id/members", asUser(1), InviteListMemberHandler)
+
+	w := postForm(r, "/lists/5/members", url.Values{"handle": {"bob"}, "permission": {"manager"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	w = postForm(r, "/lists/5/members", url.Values{"handle": {"sam"}})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for an ambiguous handle, got %d: %s", w.Code, w.Body.String())
+	}
+	w = postForm(r, "/lists/5/members", url.Values{"handle": {"bob"}, "permission": {"owner"}})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown permission, got %d", w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestAcceptListInvitationHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM lists WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE list_members SET status = 'active'").
+		WithArgs(5, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/lists/:id/invitation", asUser(2), AcceptListInvitationHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/lists/5/invitation", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without a pending invitation, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateListShareHandler godoc
+// @Summary Create (or rotate) a read-only share link for a list (owner or manager)
+// @Description Anyone with the link can view the list at GET /lists/shared/{token}, without an account. Calling this again replaces the token, so older links stop working. Private lists can't be shared; making a list private disables its link.
+// @Tags Lists
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "List UUID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id}/share [post]
+func CreateListShareHandler(c *gin.Context) {
+	listID, ok := authorizeList(c, listRoleManager)
+	if !ok {
+		return
+	}
+	token, err := newURLToken()
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var visibility string
+	if err := tx.QueryRowContext(ctx, "SELECT visibility FROM lists WHERE id = ? FOR UPDATE", listID).
+		Scan(&visibility); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if visibility == "private" {
+		abortWithError(c, badRequest("private lists can't be shared; make the list unlisted or public first"))
+		return
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE lists SET share_token = ? WHERE id = ?", token, listID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := logListChange(ctx, tx, listID, c.GetInt("auth_user_id"), "share_link_created", 0, nil); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"share_token": token,
+		"share_url":   "/lists/shared/" + token,
+		"visibility":  visibility,
+	})
+}
+
+// RevokeListShareHandler godoc
+// @Summary Revoke a list's share link (owner or manager)
+// @Tags Lists
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "List UUID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id}/share [delete]
+func RevokeListShareHandler(c *gin.Context) {
+	listID, ok := authorizeList(c, listRoleManager)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE lists SET share_token = NULL WHERE id = ? AND share_token IS NOT NULL", listID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	// revoking twice is fine, but only the first one goes in the history
+	if n, _ := res.RowsAffected(); n > 0 {
+		if err := logListChange(ctx, tx, listID, c.GetInt("auth_user_id"), "share_link_revoked", 0, nil); err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// SharedListHandler godoc
+// @Summary View a shared reading list (no login needed)
+// @Description Read-only. The token is the whole credential, so this works from any organization and without an account.
+// @Tags Lists
+// @Produce json
+// @Param token path string true "Share token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/shared/{token} [get]
+func SharedListHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	var listID int
+	err := db.QueryRowContext(ctx,
+		"SELECT id FROM lists WHERE share_token = ? AND visibility <> 'private' AND deleted_at IS NULL", c.Param("token")).Scan(&listID)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("shared list not found"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	list, err := loadList(ctx, listID, true)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, list)
+}
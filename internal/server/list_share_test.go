@@ -0,0 +1,118 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateListShareHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM lists WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	expectListRole(mock, 5, 1, 1, "")
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT visibility FROM lists WHERE id = \\? FOR UPDATE").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"visibility"}).AddRow("unlisted"))
+	mock.ExpectExec("UPDATE lists SET share_token = \\? WHERE id = \\?").
+		WithArgs(sqlmock.AnyArg(), 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectListChange(mock, 5, "share_link_created")
+	mock.ExpectCommit()
+	// private lists can't be shared
+	mock.ExpectQuery("SELECT 1 FROM lists WHERE id = \\?").
+		WithArgs(6, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	expectListRole(mock, 6, 1, 1, "")
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT visibility FROM lists WHERE id = \\? FOR UPDATE").
+		WithArgs(6).
+		WillReturnRows(sqlmock.NewRows([]string{"visibility"}).AddRow("private"))
+	mock.ExpectRollback()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/lists/:id/share", asUser(1), CreateListShareHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/lists/5/share", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	token, _ := body["share_token"].(string)
+	if len(token) < 40 || body["share_url"] != "/lists/shared/"+token {
+		t.Fatalf("unexpected share link %v", body)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/lists/6/share", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a private list, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestSharedListHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT id FROM lists WHERE share_token = \\? AND visibility <> 'private'").
+		WithArgs("good").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	expectListSummary(mock, 5)
+	mock.ExpectQuery("FROM list_items li\\s+JOIN books b").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"position", "uuid", "slug", "title", "author", "added_at"}).
+			AddRow(1, "b-9", "dune-b-9", "Dune", "Frank Herbert", "2026-10-01 12:00:00"))
+	mock.ExpectQuery("SELECT id FROM lists WHERE share_token = \\?").
+		WithArgs("revoked").
+		WillReturnError(sql.ErrNoRows)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/lists/shared/:token", SharedListHandler)
+
+	for _, tc := range []struct {
+		token string
+		want  int
+	}{
+		{"good", http.StatusOK},
+		{"revoked", http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lists/shared/"+tc.token, nil))
+		if w.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d: %s", tc.token, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,801 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+	"github.com/YeswanthC7/bookrec/internal/softdelete"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// Reading list limits
+const (
+	listNameMaxLen = 100
+	listMaxBooks   = 500
+)
+
+func resolveListRef(ctx context.Context, raw string) (int, error) {
+	return resolveRef(ctx, "lists", "", liveScope, raw)
+}
+
+func parseListName(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+	if len([]rune(name)) > listNameMaxLen {
+		return "", fmt.Errorf("name must be at most %d characters", listNameMaxLen)
+	}
+	return name, nil
+}
+
+// parseListVisibility reads a visibility; empty means def
+func parseListVisibility(raw, def string) (string, error) {
+	switch v := strings.TrimSpace(raw); v {
+	case "":
+		return def, nil
+	case "private", "unlisted", "public":
+		return v, nil
+	}
+	return "", fmt.Errorf("visibility must be private, unlisted or public")
+}
+
+// listRole is what a user may do to a list; each role includes the ones below it
+type listRole int
+
+const (
+	listRoleNone listRole = iota
+	listRoleEditor
+	listRoleManager
+	listRoleOwner
+)
+
+func (r listRole) String() string {
+	switch r {
+	case listRoleEditor:
+		return "editor"
+	case listRoleManager:
+		return "manager"
+	case listRoleOwner:
+		return "owner"
+	}
+	return ""
+}
+
+// listRoleOf returns userID's role on listID: the owner, an active member's
+// permission, or listRoleNone
+func listRoleOf(ctx context.Context, listID, userID int) (listRole, error) {
+	var ownerID int
+	var permission string
+	if err := db.QueryRowContext(ctx, `
+		SELECT l.user_id, COALESCE(m.permission, '')
+		FROM lists l
+		LEFT JOIN list_members m ON m.list_id = l.id AND m.user_id = ? AND m.status = 'active'
+		WHERE l.id = ?`, userID, listID).Scan(&ownerID, &permission); err != nil {
+		return listRoleNone, err
+	}
+	switch {
+	case ownerID == userID:
+		return listRoleOwner, nil
+	case permission == "manager":
+		return listRoleManager, nil
+	case permission == "editor":
+		return listRoleEditor, nil
+	}
+	return listRoleNone, nil
+}
+
+// authorizeList resolves :id and checks the caller has at least need on it
+func authorizeList(c *gin.Context, need listRole) (int, bool) {
+	listID, ok := resolveParam(c, resolveListRef, c.Param("id"), "list")
+	if !ok {
+		return 0, false
+	}
+	role, err := listRoleOf(c.Request.Context(), listID, c.GetInt("auth_user_id"))
+	if err != nil {
+		abortWithError(c, err)
+		return 0, false
+	}
+	if role < need {
+		msg := "only the list's owner can do this"
+		if need < listRoleOwner {
+			msg = "requires " + need.String() + " access to the list"
+		}
+		abortWithError(c, forbidden(msg))
+		return 0, false
+	}
+	return listID, true
+}
+
+// readableList resolves :id for reading. Public lists are open to everyone
+// in the organization; unlisted and private ones only to the owner and
+// co-editors (anyone else gets 404, so they can't probe for them).
+func readableList(c *gin.Context) (int, bool) {
+	listID, ok := resolveParam(c, resolveListRef, c.Param("id"), "list")
+	if !ok {
+		return 0, false
+	}
+	ctx := c.Request.Context()
+	var visibility string
+	if err := db.QueryRowContext(ctx, "SELECT visibility FROM lists WHERE id = ?", listID).Scan(&visibility); err != nil {
+		abortWithError(c, err)
+		return 0, false
+	}
+	if visibility == "public" {
+		return listID, true
+	}
+	role, err := listRoleOf(ctx, listID, c.GetInt("auth_user_id"))
+	if err != nil {
+		abortWithError(c, err)
+		return 0, false
+	}
+	if role == listRoleNone {
+		abortWithError(c, notFound("list not found"))
+		return 0, false
+	}
+	return listID, true
+}
+
+// loadList returns a list's summary; withBooks adds its books in order
+func loadList(ctx context.Context, id int, withBooks bool) (gin.H, error) {
+	var bookCount int
+	var publicID, userUUID, name, visibility, createdAt, updatedAt string
+	if err := db.QueryRowContext(ctx, `
+		SELECT l.uuid, u.uuid, l.name, l.visibility, l.created_at, l.updated_at,
+		       (SELECT COUNT(*) FROM list_items li WHERE li.list_id = l.id)
+		FROM lists l
+		JOIN users u ON u.id = l.user_id
+		WHERE l.id = ?`, id).
+		Scan(&publicID, &userUUID, &name, &visibility, &createdAt, &updatedAt, &bookCount); err != nil {
+		return nil, err
+	}
+	list := gin.H{
+		"uuid":       publicID,
+		"user_uuid":  userUUID,
+		"name":       name,
+		"visibility": visibility,
+		"book_count": bookCount,
+		"created_at": createdAt,
+		"updated_at": updatedAt,
+	}
+	if !withBooks {
+		return list, nil
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT li.position, b.uuid, b.slug, b.title, b.author, li.added_at
+		FROM list_items li
+		JOIN books b ON b.id = li.book_id
+		WHERE li.list_id = ?
+		ORDER BY li.position`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	books := []gin.H{}
+	for rows.Next() {
+		var position int
+		var bookUUID, slug, title, addedAt string
+		var author sql.NullString
+		if err := rows.Scan(&position, &bookUUID, &slug, &title, &author, &addedAt); err != nil {
+			return nil, err
+		}
+		books = append(books, gin.H{
+			"position":  position,
+			"book_uuid": bookUUID,
+			"slug":      slug,
+			"title":     title,
+			"author":    author.String,
+			"added_at":  addedAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	list["books"] = books
+	return list, nil
+}
+
+// CreateListHandler godoc
+// @Summary Create a reading list
+// @Tags Lists
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID; must be the caller"
+// @Param name formData string true "List name (max 100 characters)"
+// @Param visibility formData string false "public (default), unlisted or private"
+// @Success 201 {object} map[string]interface{}
+// @Header 201 {string} Location "/lists/{uuid}"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/lists [post]
+func CreateListHandler(c *gin.Context) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+	if userID != c.GetInt("auth_user_id") {
+		abortWithError(c, forbidden("cannot create a list for another user"))
+		return
+	}
+	name, err := parseListName(c.PostForm("name"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	if !screenText(c, "name", name) {
+		return
+	}
+	visibility, err := parseListVisibility(c.PostForm("visibility"), "public")
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO lists (organization_id, user_id, name, visibility) VALUES (?, ?, ?, ?)",
+		tenant.ID(ctx), userID, name, visibility)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	id, _ := res.LastInsertId()
+	listID := int(id)
+	if err := logListChange(ctx, tx, listID, userID, "created", 0,
+		gin.H{"name": name, "visibility": visibility}); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	list, err := loadList(ctx, listID, true)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Header("Location", fmt.Sprintf("/lists/%s", list["uuid"]))
+	c.JSON(201, list)
+}
+
+// ListUserListsHandler godoc
+// @Summary A user's reading lists (recently updated first)
+// @Description Includes lists the user co-edits; role is owner, manager or editor. Other callers only see public lists.
+// @Tags Lists
+// @Produce json
+// @Param Authorization header string false "Bearer token (to see your own unlisted and private lists)"
+// @Param id path string true "User UUID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/lists [get]
+func ListUserListsHandler(c *gin.Context) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	// owned lists plus the ones the user co-edits
+	from := `
+		FROM lists l
+		LEFT JOIN list_members m ON m.list_id = l.id AND m.user_id = ? AND m.status = 'active'
+		WHERE (l.user_id = ? OR m.user_id IS NOT NULL) AND l.deleted_at IS NULL`
+	if c.GetInt("auth_user_id") != userID {
+		from += " AND l.visibility = 'public'"
+	}
+
+	ctx := c.Request.Context()
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*)"+from, userID, userID).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT l.uuid, l.name, l.visibility, l.updated_at,
+		       (SELECT COUNT(*) FROM list_items li WHERE li.list_id = l.id),
+		       COALESCE(m.permission, 'owner')`+from+`
+		ORDER BY l.updated_at DESC, l.id DESC
+		LIMIT ? OFFSET ?`, userID, userID, limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	lists := []gin.H{}
+	for rows.Next() {
+		var bookCount int
+		var publicID, name, visibility, updatedAt, role string
+		if err := rows.Scan(&publicID, &name, &visibility, &updatedAt, &bookCount, &role); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		lists = append(lists, gin.H{
+			"uuid":       publicID,
+			"name":       name,
+			"visibility": visibility,
+			"book_count": bookCount,
+			"role":       role,
+			"updated_at": updatedAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  lists,
+	})
+}
+
+// GetListHandler godoc
+// @Summary Get a reading list with its books in order
+// @Description Unlisted and private lists are only readable by the owner and co-editors (404 for anyone else); share links use GET /lists/shared/{token}.
+// @Tags Lists
+// @Produce json
+// @Param Authorization header string false "Bearer token (for unlisted and private lists)"
+// @Param id path string true "List UUID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id} [get]
+func GetListHandler(c *gin.Context) {
+	listID, ok := readableList(c)
+	if !ok {
+		return
+	}
+	list, err := loadList(c.Request.Context(), listID, true)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("list not found"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, list)
+}
+
+// UpdateListHandler godoc
+// @Summary Rename a reading list or change its visibility (owner or manager)
+// @Tags Lists
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "List UUID"
+// @Param name formData string false "New name (max 100 characters)"
+// @Param visibility formData string false "public, unlisted or private"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id} [patch]
+func UpdateListHandler(c *gin.Context) {
+	listID, ok := authorizeList(c, listRoleManager)
+	if !ok {
+		return
+	}
+	rawName, rawVisibility := c.PostForm("name"), c.PostForm("visibility")
+	if rawName == "" && rawVisibility == "" {
+		abortWithError(c, badRequest("name or visibility is required"))
+		return
+	}
+	var name string
+	if rawName != "" {
+		n, err := parseListName(rawName)
+		if err != nil {
+			abortWithError(c, badRequest(err.Error()))
+			return
+		}
+		if !screenText(c, "name", n) {
+			return
+		}
+		name = n
+	}
+	visibility, err := parseListVisibility(rawVisibility, "")
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var oldName, oldVisibility string
+	if err := tx.QueryRowContext(ctx, "SELECT name, visibility FROM lists WHERE id = ? FOR UPDATE", listID).
+		Scan(&oldName, &oldVisibility); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	actorID := c.GetInt("auth_user_id")
+	if name != "" && name != oldName {
+		if _, err := tx.ExecContext(ctx, "UPDATE lists SET name = ? WHERE id = ?", name, listID); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if err := logListChange(ctx, tx, listID, actorID, "renamed", 0,
+			gin.H{"from": oldName, "to": name}); err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+	if visibility != "" && visibility != oldVisibility {
+		if _, err := tx.ExecContext(ctx, "UPDATE lists SET visibility = ? WHERE id = ?", visibility, listID); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if err := logListChange(ctx, tx, listID, actorID, "visibility_changed", 0,
+			gin.H{"from": oldVisibility, "to": visibility}); err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	list, err := loadList(ctx, listID, false)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, list)
+}
+
+// DeleteListHandler godoc
+// @Summary Delete a reading list (owner only)
+// @Description The list is soft-deleted: it disappears right away, an admin can restore it until the purge job removes it.
+// @Tags Lists
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "List UUID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id} [delete]
+func DeleteListHandler(c *gin.Context) {
+	listID, ok := authorizeList(c, listRoleOwner)
+	if !ok {
+		return
+	}
+	if _, err := softdelete.Delete(c.Request.Context(), db, "lists", listID, c.GetInt("auth_user_id")); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// AddListBookHandler godoc
+// @Summary Add a book to a reading list (owner or collaborator)
+// @Description Appends the book, or inserts it at position and shifts the books after it down.
+// @Tags Lists
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "List UUID"
+// @Param book_id formData string true "Book UUID or slug"
+// @Param position formData int false "1-based position (default: end of the list)"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /lists/{id}/books [post]
+func AddListBookHandler(c *gin.Context) {
+	listID, ok := authorizeList(c, listRoleEditor)
+	if !ok {
+		return
+	}
+	bookRef := c.PostForm("book_id")
+	if bookRef == "" {
+		abortWithError(c, badRequest("book_id is required"))
+		return
+	}
+	bookID, ok := resolveParam(c, resolveBookRef, bookRef, "book")
+	if !ok {
+		return
+	}
+	position := 0
+	if raw := c.PostForm("position"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			abortWithError(c, badRequest("position must be a positive integer"))
+			return
+		}
+		position = n
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	count, err := lockList(ctx, tx, listID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if count >= listMaxBooks {
+		abortWithError(c, badRequest(fmt.Sprintf("a list holds at most %d books", listMaxBooks)))
+		return
+	}
+	if position == 0 || position > count+1 {
+		position = count + 1
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE list_items SET position = position + 1 WHERE list_id = ? AND position >= ?", listID, position); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO list_items (list_id, book_id, position) VALUES (?, ?, ?)", listID, bookID, position); err != nil {
+		if dberr.Is(err, dberr.ErrDuplicate) {
+			abortWithError(c, conflict("book is already in the list"))
+			return
+		}
+		abortWithError(c, err)
+		return
+	}
+	if err := logListChange(ctx, tx, listID, c.GetInt("auth_user_id"), "book_added", bookID,
+		gin.H{"position": position}); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	list, err := loadList(ctx, listID, true)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(201, list)
+}
+
+// MoveListBookHandler godoc
+// @Summary Move a book within a reading list (owner or collaborator)
+// @Tags Lists
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "List UUID"
+// @Param book_id path string true "Book UUID or slug"
+// @Param position formData int true "New 1-based position (past the end moves it last)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id}/books/{book_id} [patch]
+func MoveListBookHandler(c *gin.Context) {
+	listID, ok := authorizeList(c, listRoleEditor)
+	if !ok {
+		return
+	}
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("book_id"), "book")
+	if !ok {
+		return
+	}
+	position, err := strconv.Atoi(c.PostForm("position"))
+	if err != nil || position < 1 {
+		abortWithError(c, badRequest("position must be a positive integer"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	count, err := lockList(ctx, tx, listID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	var current int
+	err = tx.QueryRowContext(ctx,
+		"SELECT position FROM list_items WHERE list_id = ? AND book_id = ?", listID, bookID).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("book is not in the list"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if position > count {
+		position = count
+	}
+
+	// close the gap at the old position, open one at the new position
+	if position != current {
+		var shift string
+		var lo, hi int
+		if position < current {
+			shift, lo, hi = "position + 1", position, current-1
+		} else {
+			shift, lo, hi = "position - 1", current+1, position
+		}
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE list_items SET position = "+shift+" WHERE list_id = ? AND position BETWEEN ? AND ?",
+			listID, lo, hi); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE list_items SET position = ? WHERE list_id = ? AND book_id = ?", position, listID, bookID); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if err := logListChange(ctx, tx, listID, c.GetInt("auth_user_id"), "book_moved", bookID,
+			gin.H{"from": current, "to": position}); err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	list, err := loadList(ctx, listID, true)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, list)
+}
+
+// RemoveListBookHandler godoc
+// @Summary Remove a book from a reading list (owner or collaborator)
+// @Description Idempotent: removing a book that isn't in the list also returns 204.
+// @Tags Lists
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "List UUID"
+// @Param book_id path string true "Book UUID or slug"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lists/{id}/books/{book_id} [delete]
+func RemoveListBookHandler(c *gin.Context) {
+	listID, ok := authorizeList(c, listRoleEditor)
+	if !ok {
+		return
+	}
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("book_id"), "book")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := lockList(ctx, tx, listID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	var position int
+	err = tx.QueryRowContext(ctx,
+		"SELECT position FROM list_items WHERE list_id = ? AND book_id = ?", listID, bookID).Scan(&position)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.Status(204)
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM list_items WHERE list_id = ? AND book_id = ?", listID, bookID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE list_items SET position = position - 1 WHERE list_id = ? AND position > ?", listID, position); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := logListChange(ctx, tx, listID, c.GetInt("auth_user_id"), "book_removed", bookID,
+		gin.H{"position": position}); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// lockList locks the list row for the rest of tx, so concurrent edits can't
+// interleave positions, and returns how many books it holds
+func lockList(ctx context.Context, tx *sql.Tx, listID int) (int, error) {
+	var count int
+	err := tx.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM list_items WHERE list_id = l.id)
+		FROM lists l WHERE l.id = ? FOR UPDATE`, listID).Scan(&count)
+	return count, err
+}
+
+// logListChange appends to the list's edit history and bumps updated_at.
+// bookID 0 means the change isn't about a book.
+func logListChange(ctx context.Context, tx *sql.Tx, listID, actorID int, action string, bookID int, detail gin.H) error {
+	var book interface{}
+	if bookID > 0 {
+		book = bookID
+	}
+	var detailJSON interface{}
+	if detail != nil {
+		b, err := json.Marshal(detail)
+		if err != nil {
+			return err
+		}
+		detailJSON = string(b)
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO list_events (list_id, user_id, action, book_id, detail) VALUES (?, ?, ?, ?, ?)",
+		listID, actorID, action, book, detailJSON); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "UPDATE lists SET updated_at = CURRENT_TIMESTAMP WHERE id = ?", listID)
+	return err
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+// sendForm is postForm for other methods (PATCH)
+func sendForm(r http.Handler, method, path string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func expectListRole(mock sqlmock.Sqlmock, listID, userID, ownerID int, permission string) {
+	mock.ExpectQuery("SELECT l.user_id, COALESCE\\(m.permission, ''\\)\\s+FROM lists l\\s+LEFT JOIN list_members m").
+		WithArgs(userID, listID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "permission"}).AddRow(ownerID, permission))
+}
+
+func expectListChange(mock sqlmock.Sqlmock, listID int, action string) {
+	mock.ExpectExec("INSERT INTO list_events \\(list_id, user_id, action, book_id, detail\\)").
+		WithArgs(listID, sqlmock.AnyArg(), action, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE lists SET updated_at = CURRENT_TIMESTAMP WHERE id = \\?").
+		WithArgs(listID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectListSummary(mock sqlmock.Sqlmock, listID int) {
+	mock.ExpectQuery("FROM lists l\\s+JOIN users u ON u.id = l.user_id\\s+WHERE l.id = \\?").
+		WithArgs(listID).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "user_uuid", "name", "visibility", "created_at", "updated_at", "book_count"}).
+			AddRow("l-1", "u-1", "Summer", "public", "2026-10-01 12:00:00", "2026-10-01 12:00:00", 0))
+}
+
+func TestCreateListHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO lists \\(organization_id, user_id, name, visibility\\) VALUES \\(\\?, \\?, \\?, \\?\\)").
+		WithArgs(1, 1, "Summer", "public").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	expectListChange(mock, 5, "created")
+	mock.ExpectCommit()
+	expectListSummary(mock, 5)
+	mock.ExpectQuery("FROM list_items li\\s+JOIN books b").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"position", "uuid", "slug", "title", "author", "added_at"}))
+	// someone else's user id
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	// unknown visibility
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/users/:id/lists", asUser(1), CreateListHandler)
+
+	w := postForm(r, "/users/1/lists", url.Values{"name": {"  Summer "}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if loc := w.Header().Get("Location"); loc != "/lists/l-1" {
+		t.Fatalf("unexpected Location %q", loc)
+	}
+
+	w = postForm(r, "/users/2/lists", url.Values{"name": {"Mine now"}})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+	w = postForm(r, "/users/1/lists", url.Values{"name": {"Secret"}, "visibility": {"hidden"}})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown visibility, got %d", w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestAddListBookHandler_InsertsAtPosition(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM lists WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	// a collaborator, not the owner
+	expectListRole(mock, 5, 1, 2, "editor")
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM lists l WHERE l.id = \\? FOR UPDATE").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectExec("UPDATE list_items SET position = position \\+ 1 WHERE list_id = \\? AND position >= \\?").
+		WithArgs(5, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO list_items \\(list_id, book_id, position\\) VALUES \\(\\?, \\?, \\?\\)").
+		WithArgs(5, 9, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectListChange(mock, 5, "book_added")
+	mock.ExpectCommit()
+	expectListSummary(mock, 5)
+	mock.ExpectQuery("FROM list_items li\\s+JOIN books b").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"position", "uuid", "slug", "title", "author", "added_at"}).
+			AddRow(2, "b-9", "dune-b-9", "Dune", "Frank Herbert", "2026-10-01 12:00:00"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/lists/:id/books", asUser(1), AddListBookHandler)
+
+	w := postForm(r, "/lists/5/books", url.Values{"book_id": {"9"}, "position": {"2"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestUpdateListHandler_EditorCannotRename(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM lists WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	expectListRole(mock, 5, 1, 2, "editor")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PATCH("/lists/:id", asUser(1), UpdateListHandler)
+
+	w := sendForm(r, http.MethodPatch, "/lists/5", url.Values{"name": {"Hijacked"}})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestGetListHandler_HidesUnlistedFromStrangers(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// anonymous caller
+	mock.ExpectQuery("SELECT 1 FROM lists WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT visibility FROM lists WHERE id = \\?").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"visibility"}).AddRow("unlisted"))
+	expectListRole(mock, 5, 0, 1, "")
+	// a co-editor
+	mock.ExpectQuery("SELECT 1 FROM lists WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT visibility FROM lists WHERE id = \\?").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"visibility"}).AddRow("unlisted"))
+	expectListRole(mock, 5, 2, 1, "editor")
+	expectListSummary(mock, 5)
+	mock.ExpectQuery("FROM list_items li\\s+JOIN books b").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"position", "uuid", "slug", "title", "author", "added_at"}))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/lists/:id", GetListHandler)
+	r.GET("/as/2/lists/:id", asUser(2), GetListHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lists/5", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a stranger, got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/as/2/lists/5", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a co-editor, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// configureMethodHandling turns wrong-method 404s into 405s with an Allow
+// header and answers plain OPTIONS requests with the allowed methods.
+// CORS preflights are still answered by the cors middleware.
+func configureMethodHandling(r *gin.Engine) {
+	r.HandleMethodNotAllowed = true
+	r.NoMethod(MethodNotAllowedHandler)
+}
+
+// MethodNotAllowedHandler runs when the path exists but not for this method.
+// Gin has already set Allow to the methods registered for the path.
+func MethodNotAllowedHandler(c *gin.Context) {
+	allowed := strings.Split(c.Writer.Header().Get("Allow"), ", ")
+	for _, m := range allowed {
+		if m == http.MethodGet {
+			// served by headAsGet
+			allowed = append(allowed, http.MethodHead)
+			break
+		}
+	}
+	allowed = append(allowed, http.MethodOptions)
+	c.Header("Allow", strings.Join(allowed, ", "))
+
+	if c.Request.Method == http.MethodOptions {
+		c.AbortWithStatus(http.StatusNoContent)
+		return
+	}
+	abortWithError(c, newAppError(http.StatusMethodNotAllowed, "method "+c.Request.Method+" not allowed").WithDetails(gin.H{"allowed": allowed}))
+}
+
+// headAsGet serves HEAD requests through the matching GET route. net/http
+// discards the body for HEAD (the original request), but still reports
+// headers and Content-Length exactly as GET would.
+func headAsGet(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodHead {
+			req = req.Clone(req.Context())
+			req.Method = http.MethodGet
+		}
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupMethodsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	configureMethodHandling(r)
+	r.GET("/healthz", func(c *gin.Context) { c.JSON(200, gin.H{"status": "ok"}) })
+	r.POST("/interactions", func(c *gin.Context) { c.JSON(201, gin.H{}) })
+
+	srv := httptest.NewServer(headAsGet(r))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	srv := setupMethodsServer(t)
+
+	resp, err := http.Post(srv.URL+"/healthz", "application/json", nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Allow"); got != "GET, HEAD, OPTIONS" {
+		t.Fatalf("unexpected Allow header: %q", got)
+	}
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if body["code"] != "method_not_allowed" || body["message"] == nil {
+		t.Fatalf("expected an error envelope, got %v", body)
+	}
+}
+
+func TestOptionsListsAllowedMethods(t *testing.T) {
+	srv := setupMethodsServer(t)
+
+	req, _ := http.NewRequest(http.MethodOptions, srv.URL+"/interactions", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Allow"); got != "POST, OPTIONS" {
+		t.Fatalf("unexpected Allow header: %q", got)
+	}
+}
+
+func TestHeadServedByGetRoute(t *testing.T) {
+	srv := setupMethodsServer(t)
+
+	resp, err := http.Head(srv.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if len(body) != 0 {
+		t.Fatalf("expected empty body, got %q", body)
+	}
+	if resp.ContentLength <= 0 {
+		t.Fatalf("expected Content-Length of the GET body, got %d", resp.ContentLength)
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/YeswanthC7/bookrec/internal/metrics"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(MetricsMiddleware())
+	r.GET("/metrics-test/:id", func(c *gin.Context) { abortWithError(c, notFound("book not found")) })
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	for _, path := range []string{"/metrics-test/1", "/metrics-test/2", "/no-such-route"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`bookrec_http_request_duration_seconds_count{method="GET",route="/metrics-test/:id",status="404"} 2`,
+		`bookrec_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %s in\n%s", want, body)
+		}
+	}
+}
+
+func TestIngestCollector(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	attempt := time.Unix(1760000000, 0)
+	mock.ExpectQuery("SELECT source, last_attempt_at, last_success_at, last_error IS NOT NULL, books_last_run\\s+FROM catalog_sources").
+		WillReturnRows(sqlmock.NewRows([]string{"source", "last_attempt_at", "last_success_at", "failed", "books_last_run"}).
+			AddRow("open_library:fantasy", attempt, attempt, false, 10).
+			AddRow("open_library:poetry", attempt, nil, true, 0))
+
+	want := `
+# HELP bookrec_ingest_books_last_run Books added or refreshed by the source's last successful ingest.
+# TYPE bookrec_ingest_books_last_run gauge
+bookrec_ingest_books_last_run{source="open_library:fantasy"} 10
+bookrec_ingest_books_last_run{source="open_library:poetry"} 0
+# HELP bookrec_ingest_last_attempt_failed 1 if the source's last ingest failed, 0 otherwise.
+# TYPE bookrec_ingest_last_attempt_failed gauge
+bookrec_ingest_last_attempt_failed{source="open_library:fantasy"} 0
+bookrec_ingest_last_attempt_failed{source="open_library:poetry"} 1
+# HELP bookrec_ingest_last_success_timestamp_seconds When the source was last ingested from successfully; absent if it never was.
+# TYPE bookrec_ingest_last_success_timestamp_seconds gauge
+bookrec_ingest_last_success_timestamp_seconds{source="open_library:fantasy"} 1.76e+09
+`
+	if err := testutil.CollectAndCompare(ingestCollector{}, strings.NewReader(want),
+		"bookrec_ingest_books_last_run", "bookrec_ingest_last_attempt_failed", "bookrec_ingest_last_success_timestamp_seconds"); err != nil {
+		t.Fatalf("unexpected metrics: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
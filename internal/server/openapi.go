@@ -0,0 +1,231 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/docs"
+)
+
+// maxValidatedResponseBytes caps how much of a response is buffered for validation
+const maxValidatedResponseBytes = 1 << 20
+
+// ValidationDetail is one schema violation in a 400 response
+type ValidationDetail struct {
+	In         string `json:"in"`
+	Name       string `json:"name,omitempty"`
+	Reason     string `json:"reason"`
+	SchemaPath string `json:"schema_path,omitempty"`
+}
+
+// loadOpenAPIRouter converts the generated Swagger 2.0 spec (docs package)
+// into OpenAPI 3 and builds a route matcher over it.
+func loadOpenAPIRouter() (routers.Router, error) {
+	var doc2 openapi2.T
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &doc2); err != nil {
+		return nil, err
+	}
+	doc3, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, err
+	}
+
+	// Match on path only, whatever host/port we're served from
+	doc3.Servers = nil
+
+	// Authorization is documented as a header param for Swagger UI, but it's
+	// enforced by AuthMiddleware (401), not by schema validation (400).
+	for _, item := range doc3.Paths.Map() {
+		for _, op := range item.Operations() {
+			params := op.Parameters[:0]
+			for _, p := range op.Parameters {
+				if p.Value != nil && p.Value.In == openapi3.ParameterInHeader && strings.EqualFold(p.Value.Name, "Authorization") {
+					continue
+				}
+				params = append(params, p)
+			}
+			op.Parameters = params
+
+			// Converted formData bodies decode absent optional fields as null
+			if op.RequestBody == nil || op.RequestBody.Value == nil {
+				continue
+			}
+			for _, media := range op.RequestBody.Value.Content {
+				if media.Schema == nil || media.Schema.Value == nil {
+					continue
+				}
+				required := map[string]bool{}
+				for _, name := range media.Schema.Value.Required {
+					required[name] = true
+				}
+				for name, prop := range media.Schema.Value.Properties {
+					if !required[name] && prop.Value != nil {
+						prop.Value.Nullable = true
+					}
+				}
+			}
+		}
+	}
+
+	return legacy.NewRouter(doc3)
+}
+
+// OpenAPIValidator validates requests against the spec and, when
+// validateResponses is set (dev mode), logs responses that drift from it.
+// Routes missing from the spec (GraphQL, Swagger UI, ...) pass through untouched.
+func OpenAPIValidator(router routers.Router, validateResponses bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError:          true,
+				SkipSettingDefaults: true,
+				AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			abortWithError(c, badRequest("request does not match API schema").WithDetails(validationDetails(err)))
+			return
+		}
+
+		if !validateResponses {
+			c.Next()
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+
+		if rec.overflow || rec.Status() == http.StatusSwitchingProtocols ||
+			!strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+			return
+		}
+		out := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 rec.Status(),
+			Header:                 rec.Header(),
+			Options:                &openapi3filter.Options{MultiError: true},
+		}
+		out.SetBodyBytes(rec.body.Bytes())
+		if err := openapi3filter.ValidateResponse(c.Request.Context(), out); err != nil {
+			log.Printf("⚠️ response for %s %s does not match API schema: %v", c.Request.Method, route.Path, err)
+		}
+	}
+}
+
+// validationDetails flattens kin-openapi errors into field-level details
+func validationDetails(err error) []ValidationDetail {
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) {
+		details := []ValidationDetail{}
+		for _, e := range multi {
+			details = append(details, validationDetails(e)...)
+		}
+		return details
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) {
+		d := ValidationDetail{Reason: reqErr.Error()}
+		if reqErr.Err != nil {
+			d.Reason = reqErr.Err.Error()
+		}
+		switch {
+		case reqErr.Parameter != nil:
+			d.In = reqErr.Parameter.In
+			d.Name = reqErr.Parameter.Name
+		case reqErr.RequestBody != nil:
+			d.In = "body"
+		default:
+			d.In = "request"
+		}
+
+		var nested openapi3.MultiError
+		if errors.As(reqErr.Err, &nested) && len(nested) > 0 {
+			out := []ValidationDetail{}
+			for _, e := range nested {
+				nd := d
+				applySchemaError(&nd, e)
+				out = append(out, nd)
+			}
+			return out
+		}
+		applySchemaError(&d, reqErr.Err)
+		return []ValidationDetail{d}
+	}
+
+	d := ValidationDetail{In: "request", Reason: err.Error()}
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		d.In = "body"
+	}
+	applySchemaError(&d, err)
+	return []ValidationDetail{d}
+}
+
+func applySchemaError(d *ValidationDetail, err error) {
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		return
+	}
+	d.Reason = schemaErr.Reason
+	if ptr := schemaErr.JSONPointer(); len(ptr) > 0 {
+		if d.In == "body" && d.Name == "" {
+			d.Name = strings.Join(ptr, ".")
+		}
+	}
+	if schemaErr.SchemaField != "" {
+		d.SchemaPath = "#/" + schemaErr.SchemaField
+	}
+}
+
+// responseRecorder tees the response body (up to a cap) for validation
+type responseRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.tee(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.tee([]byte(s))
+	return io.WriteString(r.ResponseWriter, s)
+}
+
+func (r *responseRecorder) tee(b []byte) {
+	if r.overflow {
+		return
+	}
+	if r.body.Len()+len(b) > maxValidatedResponseBytes {
+		r.overflow = true
+		r.body.Reset()
+		return
+	}
+	r.body.Write(b)
+}
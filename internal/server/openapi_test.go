@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupValidatedRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	specRouter, err := loadOpenAPIRouter()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(OpenAPIValidator(specRouter, false))
+	r.GET("/books", func(c *gin.Context) { c.JSON(200, gin.H{"ok": true}) })
+	r.POST("/interactions", func(c *gin.Context) { c.JSON(200, gin.H{"ok": true}) })
+	r.GET("/unspecced", func(c *gin.Context) { c.JSON(200, gin.H{"ok": true}) })
+	return r
+}
+
+func TestOpenAPIValidatorRejectsBadQuery(t *testing.T) {
+	r := setupValidatedRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books?page=abc", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var body struct {
+		Error   string             `json:"error"`
+		Details []ValidationDetail `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(body.Details) != 1 || body.Details[0].In != "query" || body.Details[0].Name != "page" {
+		t.Fatalf("unexpected details: %+v", body.Details)
+	}
+}
+
+func TestOpenAPIValidatorRejectsMissingFormField(t *testing.T) {
+	r := setupValidatedRouter(t)
+
+	form := url.Values{"user_id": {"1"}, "action": {"like"}}
+	req := httptest.NewRequest(http.MethodPost, "/interactions", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var body struct {
+		Details []ValidationDetail `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(body.Details) != 1 || body.Details[0].In != "body" || body.Details[0].Name != "book_id" {
+		t.Fatalf("unexpected details: %+v", body.Details)
+	}
+}
+
+func TestOpenAPIValidatorPassesValidAndUnknownRoutes(t *testing.T) {
+	r := setupValidatedRouter(t)
+
+	for _, target := range []string{"/books?page=2&limit=10", "/unspecced?anything=goes"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", target, w.Code, w.Body.String())
+		}
+	}
+
+	form := url.Values{"user_id": {"1"}, "book_id": {"2"}, "action": {"like"}}
+	req := httptest.NewRequest(http.MethodPost, "/interactions", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestOpenAPIValidatorChecksJSONBodies(t *testing.T) {
+	r := setupValidatedRouter(t)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/interactions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post(`{"user_id": "1", "book_id": "2", "action": "rating", "rating": 4}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := post(`{"user_id": "1", "action": "like"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Details []ValidationDetail `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(body.Details) != 1 || body.Details[0].In != "body" || body.Details[0].Name != "book_id" {
+		t.Fatalf("unexpected details: %+v", body.Details)
+	}
+}
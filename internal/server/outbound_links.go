@@ -0,0 +1,302 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// outboundVendor is a shop or library that book payloads link out to.
+// Templates are tried in order; the first whose placeholders all have a
+// value for the book wins.
+type outboundVendor struct {
+	Key       string
+	Name      string
+	Templates []string
+}
+
+// outboundVendors starts as the built-in vendors; Run reloads it with
+// AMAZON_AFFILIATE_TAG and OUTBOUND_LINK_TEMPLATES, which replaces or adds
+// vendors ("key=template;key=template") and drops one given an empty template
+var outboundVendors = loadOutboundVendors("", "")
+
+// loadOutboundVendors builds the built-in vendors (Bookshop, Amazon tagged
+// with amazonTag, Open Library borrowing) and applies overrides
+func loadOutboundVendors(overrides, amazonTag string) []outboundVendor {
+	amazon := "https://www.amazon.com/s?k={query}&i=stripbooks"
+	if amazonTag != "" {
+		amazon += "&tag=" + url.QueryEscape(amazonTag)
+	}
+	vendors := []outboundVendor{
+		{Key: "bookshop", Name: "Bookshop.org", Templates: []string{"https://bookshop.org/search?keywords={query}"}},
+		{Key: "amazon", Name: "Amazon", Templates: []string{amazon}},
+		{Key: "openlibrary", Name: "Open Library", Templates: []string{
+			"https://openlibrary.org{open_library_key}",
+			"https://openlibrary.org/search?q={query}&mode=ebooks",
+		}},
+	}
+
+	for _, entry := range strings.Split(overrides, ";") {
+		key, template, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" {
+			continue
+		}
+		template = strings.TrimSpace(template)
+		i := 0
+		for i < len(vendors) && vendors[i].Key != key {
+			i++
+		}
+		switch {
+		case template == "" && i < len(vendors):
+			vendors = append(vendors[:i], vendors[i+1:]...)
+		case template == "":
+		case i < len(vendors):
+			vendors[i].Templates = []string{template}
+		default:
+			vendors = append(vendors, outboundVendor{Key: key, Name: key, Templates: []string{template}})
+		}
+	}
+	return vendors
+}
+
+// linkBook is what link templates are filled from
+type linkBook struct {
+	ID             int
+	UUID           string
+	Title          string
+	Author         string
+	OpenLibraryKey string
+}
+
+// expand fills the vendor's first usable template; ok is false when none is
+func (v outboundVendor) expand(b linkBook) (string, bool) {
+	query := strings.TrimSpace(b.Title + " " + b.Author)
+	values := map[string]string{
+		"{title}":            url.QueryEscape(b.Title),
+		"{author}":           url.QueryEscape(b.Author),
+		"{query}":            url.QueryEscape(query),
+		"{open_library_key}": b.OpenLibraryKey,
+	}
+	for _, template := range v.Templates {
+		link, usable := template, true
+		for placeholder, value := range values {
+			if !strings.Contains(link, placeholder) {
+				continue
+			}
+			if value == "" {
+				usable = false
+				break
+			}
+			link = strings.ReplaceAll(link, placeholder, value)
+		}
+		if usable {
+			return link, true
+		}
+	}
+	return "", false
+}
+
+// bookLinks lists the vendors that have a link for b, pointing at the
+// click-tracking redirect
+func bookLinks(b linkBook) []gin.H {
+	links := []gin.H{}
+	for _, v := range outboundVendors {
+		if _, ok := v.expand(b); ok {
+			links = append(links, gin.H{
+				"vendor": v.Key,
+				"name":   v.Name,
+				"url":    fmt.Sprintf("/out/%s/%s", b.UUID, v.Key),
+			})
+		}
+	}
+	return links
+}
+
+// includeBookLinks adds links (see bookLinks) to each book
+func includeBookLinks(ctx context.Context, books []map[string]interface{}, ids []interface{}) error {
+	rows, err := db.QueryContext(ctx,
+		"SELECT id, uuid, title, author, open_library_key FROM books WHERE id IN ("+placeholders(len(ids))+")", ids...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	byID := map[int]linkBook{}
+	for rows.Next() {
+		var b linkBook
+		var author, olKey sql.NullString
+		if err := rows.Scan(&b.ID, &b.UUID, &b.Title, &author, &olKey); err != nil {
+			return err
+		}
+		b.Author, b.OpenLibraryKey = author.String, olKey.String
+		byID[b.ID] = b
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, book := range books {
+		id, _ := book["id"].(int)
+		if b, ok := byID[id]; ok {
+			book["links"] = bookLinks(b)
+		} else {
+			book["links"] = []gin.H{}
+		}
+	}
+	return nil
+}
+
+// OutboundRedirectHandler godoc
+// @Summary Follow a purchase or borrow link
+// @Description Records the click (with the user when a Bearer token is sent) and redirects to the vendor. Book payloads carry these URLs in links.
+// @Tags Books
+// @Param Authorization header string false "Bearer token"
+// @Param book_id path string true "Book UUID or slug"
+// @Param vendor path string true "Vendor key, e.g. bookshop, amazon, openlibrary"
+// @Success 302
+// @Failure 404 {object} ErrorResponse
+// @Router /out/{book_id}/{vendor} [get]
+func OutboundRedirectHandler(c *gin.Context) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("book_id"), "book")
+	if !ok {
+		return
+	}
+	var vendor *outboundVendor
+	for i := range outboundVendors {
+		if outboundVendors[i].Key == strings.ToLower(c.Param("vendor")) {
+			vendor = &outboundVendors[i]
+		}
+	}
+	if vendor == nil {
+		abortWithError(c, notFound("vendor not found"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	b := linkBook{ID: bookID}
+	var author, olKey sql.NullString
+	err := db.QueryRowContext(ctx,
+		"SELECT title, author, open_library_key FROM books WHERE id = ?", bookID).Scan(&b.Title, &author, &olKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("book not found"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	b.Author, b.OpenLibraryKey = author.String, olKey.String
+	link, ok := vendor.expand(b)
+	if !ok {
+		abortWithError(c, notFound("no "+vendor.Name+" link for this book"))
+		return
+	}
+
+	var userID interface{}
+	if id := c.GetInt("auth_user_id"); id > 0 {
+		userID = id
+	}
+	// a lost click shouldn't cost the reader their link
+	if _, err := db.ExecContext(ctx,
+		"INSERT INTO outbound_clicks (organization_id, book_id, vendor, user_id) VALUES (?, ?, ?, ?)",
+		tenant.ID(ctx), bookID, vendor.Key, userID); err != nil {
+		log.Printf("⚠️ outbound click not recorded: %v", err)
+	}
+	c.Redirect(302, link)
+}
+
+// OutboundClicksHandler godoc
+// @Summary Purchase and borrow link clicks
+// @Description Clicks per vendor and the 10 most clicked books over the last days days.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param days query int false "Window in days (1-365)" default(30)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/outbound-clicks [get]
+func OutboundClicksHandler(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		abortWithError(c, badRequest("days must be between 1 and 365"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	orgID := tenant.ID(ctx)
+	rows, err := db.QueryContext(ctx, `
+		SELECT vendor, COUNT(*), COUNT(DISTINCT user_id)
+		FROM outbound_clicks
+		WHERE organization_id = ? AND created_at >= NOW() - INTERVAL ? DAY
+		GROUP BY vendor
+		ORDER BY COUNT(*) DESC, vendor`, orgID, days)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	vendors := []gin.H{}
+	for rows.Next() {
+		var vendor string
+		var clicks, users int
+		if err := rows.Scan(&vendor, &clicks, &users); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		vendors = append(vendors, gin.H{"vendor": vendor, "clicks": clicks, "users": users})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	top, err := db.QueryContext(ctx, `
+		SELECT b.id, b.uuid, b.slug, b.title, COUNT(*) AS clicks
+		FROM outbound_clicks oc
+		JOIN books b ON b.id = oc.book_id
+		WHERE oc.organization_id = ? AND oc.created_at >= NOW() - INTERVAL ? DAY
+		GROUP BY b.id, b.uuid, b.slug, b.title
+		ORDER BY clicks DESC, b.id
+		LIMIT 10`, orgID, days)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = top.Close() }()
+
+	books := []gin.H{}
+	for top.Next() {
+		var id, clicks int
+		var publicID, slug, title string
+		if err := top.Scan(&id, &publicID, &slug, &title, &clicks); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		books = append(books, gin.H{
+			"book":   gin.H{"uuid": publicID, "slug": slug, "title": title},
+			"clicks": clicks,
+		})
+	}
+	if err := top.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"days":      days,
+		"vendors":   vendors,
+		"top_books": books,
+	})
+}
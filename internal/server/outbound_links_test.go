@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoadOutboundVendors(t *testing.T) {
+	vendors := loadOutboundVendors("amazon=; indie = https://indie.example/search?t={title} ;bookshop=https://bookshop.org/a/42/{title}", "rec-20")
+	keys := []string{}
+	for _, v := range vendors {
+		keys = append(keys, v.Key)
+	}
+	if len(keys) != 3 || keys[0] != "bookshop" || keys[1] != "openlibrary" || keys[2] != "indie" {
+		t.Fatalf("unexpected vendors: %v", keys)
+	}
+
+	book := linkBook{ID: 3, Title: "The Hobbit", Author: "J.R.R. Tolkien"}
+	if link, _ := vendors[0].expand(book); link != "https://bookshop.org/a/42/The+Hobbit" {
+		t.Fatalf("unexpected bookshop link: %s", link)
+	}
+	// no Open Library key yet: fall back to search
+	if link, _ := vendors[1].expand(book); link != "https://openlibrary.org/search?q=The+Hobbit+J.R.R.+Tolkien&mode=ebooks" {
+		t.Fatalf("unexpected openlibrary link: %s", link)
+	}
+	book.OpenLibraryKey = "/works/OL262758W"
+	if link, _ := vendors[1].expand(book); link != "https://openlibrary.org/works/OL262758W" {
+		t.Fatalf("unexpected openlibrary link: %s", link)
+	}
+
+	amazon := loadOutboundVendors("", "rec-20")[1]
+	if link, _ := amazon.expand(book); link != "https://www.amazon.com/s?k=The+Hobbit+J.R.R.+Tolkien&i=stripbooks&tag=rec-20" {
+		t.Fatalf("unexpected amazon link: %s", link)
+	}
+}
+
+func TestOutboundRedirectHandler_RecordsClick(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT title, author, open_library_key FROM books WHERE id = \\?").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "author", "open_library_key"}).
+			AddRow("The Hobbit", "J.R.R. Tolkien", "/works/OL262758W"))
+	mock.ExpectExec("INSERT INTO outbound_clicks \\(organization_id, book_id, vendor, user_id\\)").
+		WithArgs(1, 3, "openlibrary", 5).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	// unknown vendor
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/out/:book_id/:vendor", asUser(5), OutboundRedirectHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/out/3/openlibrary", nil))
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "https://openlibrary.org/works/OL262758W" {
+		t.Fatalf("expected a redirect to Open Library, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/out/3/pigeonpost", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown vendor, got %d", w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// Popular books config
+const (
+	popularDefaultLimit = 10
+	popularMaxLimit     = 50
+	popularTTL          = time.Minute
+	popularMaxEntries   = 1000
+)
+
+// popularWindows maps ?window= to how far back interactions count (0 = all time)
+var popularWindows = map[string]time.Duration{
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"all": 0,
+}
+
+// popularCountKeys names each action's count in the response
+var popularCountKeys = map[string]string{
+	"like":   "likes",
+	"view":   "views",
+	"rating": "ratings",
+}
+
+// popularQuery is one parameter combination of /books/popular, and the key
+// its result is cached under
+type popularQuery struct {
+	orgID  int
+	window string
+	action string
+	genre  string
+	limit  int
+}
+
+// parsePopularQuery reads ?limit=, ?window=, ?action= and ?genre=
+func parsePopularQuery(c *gin.Context) (popularQuery, error) {
+	q := popularQuery{
+		orgID:  tenant.ID(c.Request.Context()),
+		window: strings.ToLower(strings.TrimSpace(c.DefaultQuery("window", "all"))),
+		action: strings.ToLower(strings.TrimSpace(c.DefaultQuery("action", "like"))),
+		limit:  popularDefaultLimit,
+	}
+	var err error
+	if q.genre, err = parseGenre(c); err != nil {
+		return popularQuery{}, err
+	}
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > popularMaxLimit {
+			return popularQuery{}, fmt.Errorf("limit must be between 1 and %d", popularMaxLimit)
+		}
+		q.limit = n
+	}
+	if _, ok := popularWindows[q.window]; !ok {
+		return popularQuery{}, fmt.Errorf("window must be 7d, 30d or all")
+	}
+	if _, ok := popularCountKeys[q.action]; !ok {
+		return popularQuery{}, fmt.Errorf("action must be like, view or rating")
+	}
+	return q, nil
+}
+
+// popularBook is one ranked book; Count is the number of q.action
+// interactions in the window
+type popularBook struct {
+	ID     int
+	UUID   string
+	Slug   string
+	Title  string
+	Author string
+	Count  int
+	// CoverID is the Open Library cover, if any
+	CoverID sql.NullInt64
+}
+
+type popularEntry struct {
+	books   []popularBook
+	expires time.Time
+}
+
+// popularCache keeps each parameter combination's ranking for popularTTL;
+// rankings move slowly and the windowed ones aggregate interactions
+type popularCache struct {
+	mu      sync.Mutex
+	entries map[popularQuery]popularEntry
+}
+
+var popularBooks = &popularCache{entries: map[popularQuery]popularEntry{}}
+
+// Get returns the cached ranking for q. On a miss it's read from
+// resultCache, so server processes share rankings, and computed when that
+// misses too.
+func (p *popularCache) Get(ctx context.Context, q popularQuery) ([]popularBook, error) {
+	now := time.Now()
+	p.mu.Lock()
+	entry, ok := p.entries[q]
+	p.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.books, nil
+	}
+
+	books, err := loadSharedPopularBooks(ctx, q, now)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.entries) >= popularMaxEntries {
+		for k, e := range p.entries {
+			if !now.Before(e.expires) {
+				delete(p.entries, k)
+			}
+		}
+		if len(p.entries) >= popularMaxEntries {
+			p.entries = map[popularQuery]popularEntry{}
+		}
+	}
+	p.entries[q] = popularEntry{books: books, expires: now.Add(popularTTL)}
+	return books, nil
+}
+
+// loadSharedPopularBooks is loadPopularBooks through resultCache when there
+// is one
+func loadSharedPopularBooks(ctx context.Context, q popularQuery, now time.Time) ([]popularBook, error) {
+	if resultCache == nil {
+		return loadPopularBooks(ctx, q, now)
+	}
+	key := fmt.Sprintf("popular:%d", q.orgID)
+	field := fmt.Sprintf("%s|%s|%s|%d", q.window, q.action, q.genre, q.limit)
+	if raw, ok := resultCache.Get(ctx, key, field); ok {
+		var books []popularBook
+		if err := json.Unmarshal(raw, &books); err == nil {
+			return books, nil
+		}
+	}
+
+	books, err := loadPopularBooks(ctx, q, now)
+	if err != nil {
+		return nil, err
+	}
+	if raw, err := json.Marshal(books); err == nil {
+		resultCache.Set(ctx, key, field, raw, popularTTL)
+	}
+	return books, nil
+}
+
+// loadPopularBooks ranks books by q.action interactions. All-time likes and
+// ratings read the running counters; views and windowed rankings aggregate
+// interactions.
+func loadPopularBooks(ctx context.Context, q popularQuery, now time.Time) ([]popularBook, error) {
+	sb := strings.Builder{}
+	var args []interface{}
+	if q.window == "all" && q.action != "view" {
+		column := "bc." + popularCountKeys[q.action]
+		sb.WriteString(`
+			SELECT b.id, b.uuid, b.slug, b.title, b.author, ` + column + `, b.cover_id
+			FROM book_counters bc
+			JOIN books b ON b.id = bc.book_id
+			WHERE bc.organization_id = ? AND ` + column + ` > 0 AND b.deleted_at IS NULL`)
+		args = append(args, q.orgID)
+		if q.genre != "" {
+			genreSQL, genreArgs := genreFilterSQL("b.id", q.genre)
+			sb.WriteString(genreSQL)
+			args = append(args, genreArgs...)
+		}
+		sb.WriteString(`
+			ORDER BY ` + column + ` DESC, b.id
+			LIMIT ?`)
+	} else {
+		sb.WriteString(`
+			SELECT b.id, b.uuid, b.slug, b.title, b.author, t.n, b.cover_id
+			FROM (
+				SELECT book_id, COUNT(*) AS n FROM interactions
+				WHERE organization_id = ? AND action = ? AND deleted_at IS NULL`)
+		args = append(args, q.orgID, q.action)
+		if d := popularWindows[q.window]; d > 0 {
+			sb.WriteString(" AND created_at >= ?")
+			args = append(args, now.Add(-d))
+		}
+		sb.WriteString(`
+				GROUP BY book_id
+			) t
+			JOIN books b ON b.id = t.book_id
+			WHERE b.deleted_at IS NULL`)
+		if q.genre != "" {
+			genreSQL, genreArgs := genreFilterSQL("b.id", q.genre)
+			sb.WriteString(genreSQL)
+			args = append(args, genreArgs...)
+		}
+		sb.WriteString(`
+			ORDER BY t.n DESC, b.id
+			LIMIT ?`)
+	}
+	args = append(args, q.limit)
+
+	rows, err := db.QueryContext(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	books := []popularBook{}
+	for rows.Next() {
+		var b popularBook
+		var author sql.NullString
+		if err := rows.Scan(&b.ID, &b.UUID, &b.Slug, &b.Title, &author, &b.Count, &b.CoverID); err != nil {
+			return nil, err
+		}
+		b.Author = author.String
+		books = append(books, b)
+	}
+	return books, rows.Err()
+}
+
+// PopularBooksHandler godoc
+// @Summary Most popular books
+// @Description Ranks books by one kind of interaction over a window. Each book carries its count under likes, views or ratings, after the action. Rankings are cached per parameter combination for up to a minute, shared by every server process through Redis when REDIS_URL is set.
+// @Tags Books
+// @Produce json
+// @Param limit query int false "Books to return, 1-50 (default 10)"
+// @Param window query string false "7d | 30d | all (default all)"
+// @Param action query string false "like | view | rating (default like)"
+// @Param genre query string false "Only books in this genre (a slug from GET /genres)"
+// @Param include query string false "Comma-separated expansions: author, genres, avg_rating, links"
+// @Success 200 {array} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /books/popular [get]
+func PopularBooksHandler(c *gin.Context) {
+	q, err := parsePopularQuery(c)
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	includes, err := parseIncludes(c.Query("include"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+
+	books, err := popularBooks.Get(c.Request.Context(), q)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	// fresh maps per request: includes and translations are added in place
+	popular := make([]map[string]interface{}, 0, len(books))
+	for _, b := range books {
+		popular = append(popular, gin.H{
+			"id":                       b.ID,
+			"uuid":                     b.UUID,
+			"slug":                     b.Slug,
+			"title":                    b.Title,
+			"author":                   b.Author,
+			"cover_url":                coverURL(b.CoverID),
+			popularCountKeys[q.action]: b.Count,
+		})
+	}
+
+	if err := applyIncludes(c.Request.Context(), popular, includes); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := localizeBooks(c, popular); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	hideInternalIDs(popular, "id")
+
+	c.JSON(200, popular)
+}
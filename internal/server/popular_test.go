@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestPopularBooksHandler_WindowActionGenre(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	popularBooks = &popularCache{entries: map[popularQuery]popularEntry{}}
+
+	// windowed rankings aggregate interactions; the second request is cached
+	mock.ExpectQuery("SELECT book_id, COUNT\\(\\*\\) AS n FROM interactions\\s+WHERE organization_id = \\? AND action = \\? AND deleted_at IS NULL AND created_at >= \\?\\s+GROUP BY book_id.+WHERE bg.book_id = b.id AND g.slug = \\?\\)\\s+ORDER BY t.n DESC, b.id\\s+LIMIT \\?").
+		WithArgs(1, "view", sqlmock.AnyArg(), "fantasy", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "n", "cover_id"}).
+			AddRow(4, "b-4", "dune-b4", "Dune", "Frank Herbert", 12, nil))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/popular", PopularBooksHandler)
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/books/popular?window=7d&action=view&genre=Fantasy&limit=3", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+		}
+		var body []map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid json: %v", err)
+		}
+		if len(body) != 1 || body[0]["views"] != float64(12) || body[0]["likes"] != nil {
+			t.Fatalf("unexpected body: %v", body)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestPopularBooksHandler_AllTimeRatingsUseCounters(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	popularBooks = &popularCache{entries: map[popularQuery]popularEntry{}}
+
+	mock.ExpectQuery("bc.ratings, b.cover_id\\s+FROM book_counters bc.+WHERE bc.organization_id = \\? AND bc.ratings > 0").
+		WithArgs(1, popularDefaultLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "ratings"}))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/popular", PopularBooksHandler)
+	req := httptest.NewRequest(http.MethodGet, "/books/popular?action=rating", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "[]" {
+		t.Fatalf("expected 200 [], got %d body=%s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestPopularBooksHandler_BadParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/popular", PopularBooksHandler)
+	for _, q := range []string{"limit=0", "limit=51", "limit=ten", "window=1y", "action=share", "genre=space-westerns"} {
+		req := httptest.NewRequest(http.MethodGet, "/books/popular?"+q, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/cache"
+	"github.com/YeswanthC7/bookrec/internal/ratelimit"
+)
+
+// rateLimiter holds the request buckets, in Redis when REDIS_URL is set (set
+// up in Run); nil turns rate limiting off
+var rateLimiter ratelimit.Limiter
+
+// ipLimit applies to every request from one client IP, and userLimit per
+// signed-in user to the routes that compute recommendations, whose joins
+// are the most expensive queries we run. Both are read in Run, after the
+// env file is loaded.
+var ipLimit, userLimit ratelimit.Limit
+
+// setUpRateLimits limits requests through shared (Redis) when there is one
+// and in this process otherwise, to RATE_LIMIT_IP_PER_MINUTE (default 600)
+// and RATE_LIMIT_IP_BURST (60) per IP and RATE_LIMIT_USER_PER_MINUTE (30)
+// and RATE_LIMIT_USER_BURST (10) per user
+func setUpRateLimits(shared cache.Cache) {
+	if redisCache, ok := shared.(*cache.Redis); ok {
+		rateLimiter = &ratelimit.Redis{Client: redisCache.Client}
+	} else {
+		rateLimiter = ratelimit.NewMemory()
+	}
+	ipLimit = ratelimit.LimitFromEnv("RATE_LIMIT_IP", ratelimit.Limit{PerMinute: 600, Burst: 60})
+	userLimit = ratelimit.LimitFromEnv("RATE_LIMIT_USER", ratelimit.Limit{PerMinute: 30, Burst: 10})
+}
+
+// trustedProxies are the proxies (IPs or CIDRs, TRUSTED_PROXIES,
+// comma-separated) whose X-Forwarded-For names the client. Without any the
+// client is whoever connected, so nobody can dodge the per-IP limit by
+// sending the header.
+func trustedProxies() []string {
+	return splitList(os.Getenv("TRUSTED_PROXIES"))
+}
+
+// RateLimitByIP refuses a client IP's requests with 429 once it runs out of
+// ipLimit. Health checks and metrics scrapes aren't limited.
+func RateLimitByIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.URL.Path == "/healthz" || c.Request.URL.Path == "/metrics" {
+			c.Next()
+			return
+		}
+		rateLimit(c, "ip:"+c.ClientIP(), ipLimit)
+	}
+}
+
+// RateLimitByUser refuses the caller's requests with 429 once they run out
+// of userLimit. It goes after AuthMiddleware.
+func RateLimitByUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rateLimit(c, "user:"+strconv.Itoa(c.GetInt("auth_user_id")), userLimit)
+	}
+}
+
+func rateLimit(c *gin.Context, key string, limit ratelimit.Limit) {
+	if rateLimiter == nil {
+		c.Next()
+		return
+	}
+	ok, retryAfter := rateLimiter.Allow(c.Request.Context(), key, limit)
+	if !ok {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		abortWithError(c, newAppError(http.StatusTooManyRequests, "too many requests, slow down").
+			WithDetails(gin.H{"retry_after": seconds}))
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/ratelimit"
+)
+
+// withRateLimits limits requests in memory for the test
+func withRateLimits(t *testing.T, ip, user ratelimit.Limit) {
+	t.Helper()
+	prevLimiter, prevIP, prevUser := rateLimiter, ipLimit, userLimit
+	rateLimiter, ipLimit, userLimit = ratelimit.NewMemory(), ip, user
+	t.Cleanup(func() { rateLimiter, ipLimit, userLimit = prevLimiter, prevIP, prevUser })
+}
+
+func TestRateLimitByIP(t *testing.T) {
+	withRateLimits(t, ratelimit.Limit{PerMinute: 1, Burst: 2}, ratelimit.Limit{})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	_ = r.SetTrustedProxies(trustedProxies())
+	r.Use(RateLimitByIP())
+	r.GET("/books", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	get := func(path, remote, forwarded string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remote + ":40000"
+		if forwarded != "" {
+			req.Header.Set("X-Forwarded-For", forwarded)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := get("/books", "10.0.0.1", ""); w.Code != http.StatusNoContent {
+			t.Fatalf("expected request %d within the burst, got %d", i+1, w.Code)
+		}
+	}
+	// without trusted proxies a forged X-Forwarded-For is still the same client
+	w := get("/books", "10.0.0.1", "203.0.113.9")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Fatalf("expected 429 with Retry-After, got %d %v", w.Code, w.Header())
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != "too_many_requests" {
+		t.Fatalf("unexpected body %s", w.Body.String())
+	}
+	if w := get("/books", "10.0.0.2", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected another client through, got %d", w.Code)
+	}
+	if w := get("/healthz", "10.0.0.1", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected health checks unlimited, got %d", w.Code)
+	}
+}
+
+func TestRateLimitByIP_TrustedProxy(t *testing.T) {
+	withRateLimits(t, ratelimit.Limit{PerMinute: 1, Burst: 1}, ratelimit.Limit{})
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.1")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		t.Fatalf("trusted proxies: %v", err)
+	}
+	r.Use(RateLimitByIP())
+	r.GET("/books", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	// clients behind the proxy each get their own bucket
+	for _, client := range []string{"203.0.113.1", "203.0.113.2"} {
+		req := httptest.NewRequest(http.MethodGet, "/books", nil)
+		req.RemoteAddr = "10.1.2.3:40000"
+		req.Header.Set("X-Forwarded-For", client)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("expected %s through, got %d", client, w.Code)
+		}
+	}
+}
+
+func TestRateLimitByUser(t *testing.T) {
+	withRateLimits(t, ratelimit.Limit{}, ratelimit.Limit{PerMinute: 30, Burst: 1})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	r.GET("/recommendations/7", asUser(7), RateLimitByUser(), ok)
+	r.GET("/recommendations/8", asUser(8), RateLimitByUser(), ok)
+
+	codes := []int{}
+	for _, path := range []string{"/recommendations/7", "/recommendations/7", "/recommendations/8"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		codes = append(codes, w.Code)
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "2" {
+			t.Fatalf("expected a token in 2 seconds, got Retry-After %q", w.Header().Get("Retry-After"))
+		}
+	}
+	if codes[0] != http.StatusNoContent || codes[1] != http.StatusTooManyRequests || codes[2] != http.StatusNoContent {
+		t.Fatalf("expected the second of user 7's requests refused, got %v", codes)
+	}
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// explainedRecommendations is recommendFor on db with a reason for each
+// book, ready to serve: the integer book ids are gone once they're explained
+func explainedRecommendations(ctx context.Context, userID int, mode string, filters bookFilters, weights hybridWeights) ([]gin.H, error) {
+	recs, err := recommendFor(ctx, db, userID, mode, filters, weights)
+	if err != nil {
+		return nil, err
+	}
+	if err := explainRecommendations(ctx, db, userID, mode, recs); err != nil {
+		return nil, err
+	}
+	hideInternalIDs(recs, "book_id")
+	return recs, nil
+}
+
+// explainRecommendations gives each of userID's recommendations a reason:
+// the book of theirs it's most tied to, with how (co-liking readers under
+// the collaborative mode, shared subjects under the content one, the author
+// under the authors one, whichever fits under the hybrid one). A book
+// nothing explains gets a null reason.
+func explainRecommendations(ctx context.Context, q querier, userID int, mode string, recs []gin.H) error {
+	if len(recs) == 0 {
+		return nil
+	}
+	ids := make([]interface{}, len(recs))
+	for i, rec := range recs {
+		ids[i] = rec["book_id"]
+		rec["reason"] = nil
+	}
+	var reasons map[int]gin.H
+	var err error
+	switch mode {
+	case recommendContent:
+		reasons, err = subjectReasons(ctx, q, userID, ids)
+	case recommendAuthors:
+		reasons, err = authorReasons(ctx, q, userID, ids)
+	case recommendHybrid:
+		reasons, err = hybridReasons(ctx, q, userID, ids)
+	default:
+		reasons, err = coLikedReasons(ctx, q, userID, ids)
+	}
+	if err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		if id, ok := rec["book_id"].(int); ok && reasons[id] != nil {
+			rec["reason"] = reasons[id]
+		}
+	}
+	return nil
+}
+
+// coLikedReasons picks, for each book, the one the user enjoyed (liked or
+// rated 4 or 5) that the most other readers enjoyed along with it
+func coLikedReasons(ctx context.Context, q querier, userID int, ids []interface{}) (map[int]gin.H, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT r.book_id, b.uuid, b.slug, b.title, COUNT(DISTINCT r.user_id) AS readers
+		FROM interactions mine
+		JOIN interactions l
+			ON l.book_id = mine.book_id
+			AND l.organization_id = mine.organization_id
+			AND l.user_id <> mine.user_id
+			AND (l.action = 'like' OR (l.action = 'rating' AND l.rating >= 4))
+			AND l.deleted_at IS NULL
+		JOIN interactions r
+			ON r.user_id = l.user_id
+			AND r.organization_id = l.organization_id
+			AND (r.action = 'like' OR (r.action = 'rating' AND r.rating >= 4))
+			AND r.deleted_at IS NULL
+		JOIN books b ON b.id = mine.book_id
+		WHERE mine.user_id = ?
+			AND (mine.action = 'like' OR (mine.action = 'rating' AND mine.rating >= 4))
+			AND mine.deleted_at IS NULL
+			AND r.book_id IN (`+placeholders(len(ids))+`)
+		GROUP BY r.book_id, b.id, b.uuid, b.slug, b.title
+		ORDER BY r.book_id, readers DESC, b.id`, append([]interface{}{userID}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	reasons := map[int]gin.H{}
+	for rows.Next() {
+		var bookID, readers int
+		var publicID, slug, title string
+		if err := rows.Scan(&bookID, &publicID, &slug, &title, &readers); err != nil {
+			return nil, err
+		}
+		if reasons[bookID] != nil {
+			continue
+		}
+		reasons[bookID] = gin.H{
+			"type":    "co_liked",
+			"book":    gin.H{"uuid": publicID, "slug": slug, "title": title},
+			"readers": readers,
+			"text":    "Because you liked " + title,
+		}
+	}
+	return reasons, rows.Err()
+}
+
+// reasonBook is a liked (or rated 4 or more) book and its lower-cased subjects
+type reasonBook struct {
+	publicID, slug, title string
+	subjects              map[string]bool
+}
+
+// subjectReasons picks, for each book, the liked book whose subjects are
+// most like its own (by the Jaccard similarity the content mode scores
+// with) and lists the subjects they share
+func subjectReasons(ctx context.Context, q querier, userID int, ids []interface{}) (map[int]gin.H, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT b.uuid, b.slug, b.title, b.subjects
+		FROM interactions i
+		JOIN books b ON b.id = i.book_id
+		WHERE i.user_id = ? AND (i.action = 'like' OR (i.action = 'rating' AND i.rating >= 4)) AND i.deleted_at IS NULL
+		ORDER BY b.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	liked := []reasonBook{}
+	for rows.Next() {
+		var b reasonBook
+		var subjects sql.NullString
+		if err := rows.Scan(&b.publicID, &b.slug, &b.title, &subjects); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		b.subjects = subjectSet(subjects)
+		liked = append(liked, b)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.QueryContext(ctx,
+		"SELECT id, subjects FROM books WHERE id IN ("+placeholders(len(ids))+")", ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	reasons := map[int]gin.H{}
+	for rows.Next() {
+		var id int
+		var raw sql.NullString
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, err
+		}
+		subjects := subjectSet(raw)
+		var best *reasonBook
+		var bestShared []string
+		bestScore := 0.0
+		for i := range liked {
+			shared := []string{}
+			for s := range subjects {
+				if liked[i].subjects[s] {
+					shared = append(shared, s)
+				}
+			}
+			if len(shared) == 0 {
+				continue
+			}
+			score := float64(len(shared)) / float64(len(subjects)+len(liked[i].subjects)-len(shared))
+			if score > bestScore {
+				best, bestShared, bestScore = &liked[i], shared, score
+			}
+		}
+		if best == nil {
+			continue
+		}
+		sort.Strings(bestShared)
+		reasons[id] = gin.H{
+			"type":     "subjects",
+			"book":     gin.H{"uuid": best.publicID, "slug": best.slug, "title": best.title},
+			"subjects": bestShared,
+			"text":     "Because you liked " + best.title + " (" + strings.Join(bestShared, ", ") + ")",
+		}
+	}
+	return reasons, rows.Err()
+}
+
+// subjectSet lower-cases a book's subjects JSON into a set, as the content
+// mode compares them
+func subjectSet(raw sql.NullString) map[string]bool {
+	var subjects []string
+	if raw.Valid && raw.String != "" {
+		_ = json.Unmarshal([]byte(raw.String), &subjects)
+	}
+	set := map[string]bool{}
+	for _, s := range subjects {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+			set[s] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/recommend"
+)
+
+// expectCoLiked answers the collaborative reasons query for user 2's
+// recommended books with rows
+func expectCoLiked(mock sqlmock.Sqlmock, rows *sqlmock.Rows, bookIDs ...driver.Value) {
+	mock.ExpectQuery("SELECT r.book_id, b.uuid, b.slug, b.title, COUNT\\(DISTINCT r.user_id\\) AS readers").
+		WithArgs(append([]driver.Value{2}, bookIDs...)...).
+		WillReturnRows(rows)
+}
+
+func coLikedRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"book_id", "uuid", "slug", "title", "readers"})
+}
+
+func TestRecommendationsHandler_Reasons(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT avoid_content_warnings, max_audience_rating FROM users WHERE id = \\?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"avoid_content_warnings", "max_audience_rating"}).AddRow("", nil))
+	mock.ExpectQuery("JOIN books b ON b.id = s.book_id").
+		WithArgs(2, recommend.Neighbors, 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "page_count", "score", "cover_id"}).
+			AddRow(8, "b-8", "matilda-b8", "Matilda", "Roald Dahl", 240, 2, nil).
+			AddRow(9, "b-9", "the-bfg-b9", "The BFG", "Roald Dahl", 208, 1, nil))
+	// Matilda's best tie is the book most readers enjoyed alongside it; the
+	// BFG has none
+	expectCoLiked(mock, coLikedRows().
+		AddRow(8, "b-3", "the-witches-b3", "The Witches", 4).
+		AddRow(8, "b-5", "danny-b5", "Danny", 2), 8, 9)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/recommendations/:user_id", asUser(2), RecommendationsHandler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recommendations/2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var recs []struct {
+		BookID int `json:"book_id"`
+		Reason *struct {
+			Type    string `json:"type"`
+			Book    gin.H  `json:"book"`
+			Readers int    `json:"readers"`
+			Text    string `json:"text"`
+		} `json:"reason"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &recs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(recs) != 2 || recs[0].Reason == nil || recs[1].Reason != nil {
+		t.Fatalf("expected a reason for Matilda only, got %s", w.Body.String())
+	}
+	if got := recs[0].Reason; got.Type != "co_liked" || got.Book["uuid"] != "b-3" || got.Readers != 4 ||
+		got.Text != "Because you liked The Witches" {
+		t.Fatalf("unexpected reason %+v", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// ShareRecommendationsHandler godoc
+// @Summary Share a snapshot of your current recommendations
+// @Description Freezes today's list; anyone with the link can view it at GET /recommendations/shared/{token} without an account. Each call creates a new snapshot.
+// @Tags Recommendations
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_id path string true "User UUID; must be the caller"
+// @Success 201 {object} map[string]interface{}
+// @Header 201 {string} Location "/recommendations/shared/{token}"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /recommendations/{user_id}/share [post]
+func ShareRecommendationsHandler(c *gin.Context) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("user_id"), "user")
+	if !ok {
+		return
+	}
+	if c.GetInt("auth_user_id") != userID {
+		abortWithError(c, forbidden("cannot share another user's recommendations"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	recs, err := loadRecommendations(ctx, db, userID, bookFilters{})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if len(recs) == 0 {
+		abortWithError(c, conflict("no recommendations to share yet — like a few books first"))
+		return
+	}
+	hideInternalIDs(recs, "book_id")
+	items, err := json.Marshal(recs)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	token, err := newURLToken()
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if _, err := db.ExecContext(ctx,
+		"INSERT INTO recommendation_snapshots (organization_id, user_id, token, items) VALUES (?, ?, ?, ?)",
+		tenant.ID(ctx), userID, token, string(items)); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	url := "/recommendations/shared/" + token
+	c.Header("Location", url)
+	c.JSON(201, gin.H{
+		"share_token": token,
+		"share_url":   url,
+		"data":        recs,
+	})
+}
+
+// SharedRecommendationsHandler godoc
+// @Summary View a shared recommendation snapshot
+// @Description No login needed. The list is exactly what was recommended when it was shared.
+// @Tags Recommendations
+// @Produce json
+// @Param token path string true "Share token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /recommendations/shared/{token} [get]
+func SharedRecommendationsHandler(c *gin.Context) {
+	var items, createdAt, userUUID, handle string
+	err := db.QueryRowContext(c.Request.Context(), `
+		SELECT s.items, s.created_at, u.uuid, u.handle
+		FROM recommendation_snapshots s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token = ?`, c.Param("token")).Scan(&items, &createdAt, &userUUID, &handle)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("shared recommendations not found"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	// snapshots taken before ids went private still carry book_id
+	var recs []gin.H
+	if err := json.Unmarshal([]byte(items), &recs); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	hideInternalIDs(recs, "book_id")
+
+	c.JSON(200, gin.H{
+		"user":       gin.H{"uuid": userUUID, "handle": handle},
+		"created_at": createdAt,
+		"data":       recs,
+	})
+}
+
+// DeleteSharedRecommendationsHandler godoc
+// @Summary Take down a shared recommendation snapshot (its owner only)
+// @Tags Recommendations
+// @Param Authorization header string true "Bearer token"
+// @Param token path string true "Share token"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /recommendations/shared/{token} [delete]
+func DeleteSharedRecommendationsHandler(c *gin.Context) {
+	res, err := db.ExecContext(c.Request.Context(),
+		"DELETE FROM recommendation_snapshots WHERE token = ? AND user_id = ?", c.Param("token"), c.GetInt("auth_user_id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		abortWithError(c, notFound("shared recommendations not found"))
+		return
+	}
+	c.Status(204)
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/store"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// redirectResources are the resources with redirects: their table and the
+// column holding the reference a redirect's Location uses
+var redirectResources = map[string]struct {
+	table     string
+	canonical string
+}{
+	"book": {"books", "slug"},
+	"user": {"users", "uuid"},
+}
+
+// redirectAway points every reference of sourceID (UUID, ID and, for books,
+// slug) at targetID. Run it in the transaction that merges the two.
+func redirectAway(ctx context.Context, tx *sql.Tx, resource string, sourceID, targetID int) error {
+	res := redirectResources[resource]
+	refs := []string{"uuid", "CAST(id AS CHAR)"}
+	if res.canonical != "uuid" {
+		refs = append(refs, res.canonical)
+	}
+	selects := []string{}
+	args := []interface{}{}
+	for _, ref := range refs {
+		selects = append(selects, "SELECT organization_id, ? AS resource, "+ref+" AS old_ref, ? AS target_id FROM "+res.table+" WHERE id = ?")
+		args = append(args, resource, targetID, sourceID)
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO redirects (organization_id, resource, old_ref, target_id)
+		SELECT * FROM (`+strings.Join(selects, " UNION ALL ")+`) refs
+		ON DUPLICATE KEY UPDATE target_id = VALUES(target_id)`, args...)
+	return err
+}
+
+// unredirect drops the redirects redirectAway added for sourceID
+func unredirect(ctx context.Context, tx *sql.Tx, resource string, sourceID, targetID int) error {
+	res := redirectResources[resource]
+	refs := "s.uuid, CAST(s.id AS CHAR)"
+	if res.canonical != "uuid" {
+		refs += ", s." + res.canonical
+	}
+	_, err := tx.ExecContext(ctx, `
+		DELETE r FROM redirects r
+		JOIN `+res.table+` s ON s.id = ?
+		WHERE r.resource = ? AND r.target_id = ? AND r.old_ref IN (`+refs+`)`,
+		sourceID, resource, targetID)
+	return err
+}
+
+// recordSlugChange keeps a renamed book's old slug working. The new slug
+// is live again, so a redirect away from it (an earlier rename) goes.
+func recordSlugChange(ctx context.Context, tx *sql.Tx, bookID int, oldSlug, newSlug string) error {
+	if oldSlug == newSlug {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO redirects (organization_id, resource, old_ref, target_id)
+		SELECT organization_id, 'book', ?, id FROM books WHERE id = ?
+		ON DUPLICATE KEY UPDATE target_id = VALUES(target_id)`, oldSlug, bookID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "DELETE FROM redirects WHERE resource = 'book' AND old_ref = ?", newSlug)
+	return err
+}
+
+// followRedirects answers a request for an old reference to resource (the
+// :id param) with a redirect to the same route on the current one: 301 for
+// GET and HEAD, 308 (method and body kept) otherwise. Anything else goes on
+// to the handler.
+func followRedirects(resource string) gin.HandlerFunc {
+	res := redirectResources[resource]
+	// shared-catalogue books have no organization
+	query := "SELECT t." + res.canonical + " FROM redirects r JOIN " + res.table + " t ON t.id = r.target_id" +
+		" WHERE r.resource = ? AND r.old_ref = ? AND " + tenant.BooksVisibleSQL("t")
+	return func(c *gin.Context) {
+		// a merged row's old integer id only redirects while integer ids resolve
+		if store.IsIntegerRef(c.Param("id")) && !store.LegacyIntegerIDs {
+			c.Next()
+			return
+		}
+		ctx := c.Request.Context()
+		var ref string
+		err := db.QueryRowContext(ctx, query, resource, c.Param("id"), tenant.ID(ctx)).Scan(&ref)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.Next()
+			return
+		}
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		location := strings.Replace(c.FullPath(), ":id", url.PathEscape(ref), 1)
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		status := http.StatusPermanentRedirect
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		c.Redirect(status, location)
+		c.Abort()
+	}
+}
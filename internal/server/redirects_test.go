@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/store"
+)
+
+func TestFollowRedirects(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// the merged duplicate's slug leads to the survivor
+	mock.ExpectQuery("SELECT t.slug FROM redirects r JOIN books t ON t.id = r.target_id WHERE r.resource = \\? AND r.old_ref = \\?").
+		WithArgs("book", "dune-0c1d2e3f", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"slug"}).AddRow("dune-1b4e28ba"))
+	mock.ExpectQuery("SELECT t.slug FROM redirects r").
+		WithArgs("book", "dune-1b4e28ba", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"slug"}))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/:id", followRedirects("book"), func(c *gin.Context) { c.JSON(200, gin.H{"id": c.Param("id")}) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/dune-0c1d2e3f?include=avg_rating", nil))
+	if w.Code != http.StatusMovedPermanently {
+		t.Fatalf("expected 301, got %d: %s", w.Code, w.Body.String())
+	}
+	if loc := w.Header().Get("Location"); loc != "/books/dune-1b4e28ba?include=avg_rating" {
+		t.Fatalf("unexpected Location %q", loc)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/dune-1b4e28ba", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected the current slug to reach the handler, got %d", w.Code)
+	}
+
+	// an old integer id isn't looked up unless integer ids resolve
+	store.LegacyIntegerIDs = false
+	defer func() { store.LegacyIntegerIDs = true }()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/7", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected an integer id to reach the handler, got %d", w.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestPatchBookHandler_Slug(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT slug FROM books WHERE id = \\? FOR UPDATE").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"slug"}).AddRow("dune-1b4e28ba"))
+	expectAuditSnapshot(mock, "book", `{"id": 5, "slug": "dune-1b4e28ba"}`)
+	mock.ExpectExec("UPDATE books SET slug = \\?, version = version \\+ 1 WHERE id = \\? AND version = \\?").
+		WithArgs("dune", 5, 3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO redirects \\(organization_id, resource, old_ref, target_id\\)\\s+SELECT organization_id, 'book', \\?, id FROM books WHERE id = \\?").
+		WithArgs("dune-1b4e28ba", 5).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("DELETE FROM redirects WHERE resource = 'book' AND old_ref = \\?").
+		WithArgs("dune").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectAudit(mock, AuditBookUpdate, "book", 5)
+	expectPublicRef(mock, "books", 5, "b-5")
+	mock.ExpectCommit()
+
+	if w := patchBook(t, `"3"`, `{"slug":"dune"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	if w := patchBook(t, `"3"`, `{"slug":"1965"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected an all-digit slug to be refused, got %d", w.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/cache"
+)
+
+// resultCache is shared by every server process (REDIS_URL, set up in Run);
+// nil when there is none
+var resultCache cache.Cache
+
+// recommendationCacheTTL is how long a user's recommendations are served
+// from resultCache (RECOMMENDATIONS_CACHE_SECONDS, read in Run by
+// loadRecommendationCacheTTL). Their own interactions and preference changes
+// drop them sooner; other readers' new likes only show up once the entry
+// expires.
+var recommendationCacheTTL = 5 * time.Minute
+
+// loadRecommendationCacheTTL reads RECOMMENDATIONS_CACHE_SECONDS, keeping
+// the default unless it's a positive whole number
+func loadRecommendationCacheTTL() {
+	if n, err := strconv.Atoi(os.Getenv("RECOMMENDATIONS_CACHE_SECONDS")); err == nil && n > 0 {
+		recommendationCacheTTL = time.Duration(n) * time.Second
+	}
+}
+
+// recommendationCacheKey groups every mode and filter combination of
+// userID's recommendations, so one delete invalidates them all
+func recommendationCacheKey(userID int) string {
+	return fmt.Sprintf("recs:%d", userID)
+}
+
+// cachedRecommendations is explainedRecommendations, through resultCache
+// when there is one
+func cachedRecommendations(ctx context.Context, userID int, mode string, filters bookFilters, weights hybridWeights) ([]gin.H, error) {
+	if resultCache == nil {
+		return explainedRecommendations(ctx, userID, mode, filters, weights)
+	}
+	key := recommendationCacheKey(userID)
+	// hybrid answers differ by weights too, so they're part of the field
+	fieldMode := mode
+	if mode == recommendHybrid {
+		fieldMode = fmt.Sprintf("%s(%g,%g,%g)", mode, weights.Collaborative, weights.Content, weights.Popularity)
+	}
+	field := fmt.Sprintf("%s|%s|%d|%d|%s", fieldMode, strings.Join(filters.formats, ","), filters.minPages, filters.maxPages, filters.genre)
+	if raw, ok := resultCache.Get(ctx, key, field); ok {
+		var recs []gin.H
+		if err := json.Unmarshal(raw, &recs); err == nil {
+			return recs, nil
+		}
+	}
+
+	recs, err := explainedRecommendations(ctx, userID, mode, filters, weights)
+	if err != nil {
+		return nil, err
+	}
+	if raw, err := json.Marshal(recs); err == nil {
+		resultCache.Set(ctx, key, field, raw, recommendationCacheTTL)
+	}
+	return recs, nil
+}
+
+// forgetRecommendations drops userID's cached recommendations after
+// something that changes them
+func forgetRecommendations(ctx context.Context, userID int) {
+	if resultCache != nil {
+		resultCache.Delete(ctx, recommendationCacheKey(userID))
+	}
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/recommend"
+)
+
+// mapCache is an in-memory cache.Cache
+type mapCache map[string]map[string][]byte
+
+func (m mapCache) Get(_ context.Context, key, field string) ([]byte, bool) {
+	value, ok := m[key][field]
+	return value, ok
+}
+
+func (m mapCache) Set(_ context.Context, key, field string, value []byte, _ time.Duration) {
+	if m[key] == nil {
+		m[key] = map[string][]byte{}
+	}
+	m[key][field] = value
+}
+
+func (m mapCache) Delete(_ context.Context, keys ...string) {
+	for _, k := range keys {
+		delete(m, k)
+	}
+}
+
+func TestRecommendationsHandler_SharedCache(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	shared := mapCache{}
+	resultCache = shared
+	defer func() { resultCache = nil }()
+
+	expectRecommendations := func() {
+		mock.ExpectQuery("SELECT avoid_content_warnings, max_audience_rating FROM users WHERE id = \\?").
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"avoid_content_warnings", "max_audience_rating"}).AddRow("", nil))
+		mock.ExpectQuery("JOIN books b ON b.id = s.book_id").
+			WithArgs(2, recommend.Neighbors, 2, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "page_count", "score", "cover_id"}).
+				AddRow(8, "b-8", "matilda-b8", "Matilda", "Roald Dahl", 240, 2, nil))
+		expectCoLiked(mock, coLikedRows(), 8)
+	}
+	expectUser := func() {
+		mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}
+	// computed, then served from the cache, then computed again once the
+	// user's entries are dropped
+	expectUser()
+	expectRecommendations()
+	expectUser()
+	expectUser()
+	expectRecommendations()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/recommendations/:user_id", asUser(2), RecommendationsHandler)
+	get := func() {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recommendations/2", nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"book_uuid":"b-8"`) {
+			t.Fatalf("expected 200 with Matilda, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	get()
+	if _, ok := shared.Get(context.Background(), "recs:2", "collaborative||0|0|"); !ok {
+		t.Fatalf("expected the recommendations cached, got %v", shared)
+	}
+	get()
+	forgetRecommendations(context.Background(), 2)
+	get()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestPopularBooksHandler_SharedCache(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	popularBooks = &popularCache{entries: map[popularQuery]popularEntry{}}
+	// another process already ranked these
+	resultCache = mapCache{"popular:1": {"all|like||10": []byte(`[{"ID":4,"UUID":"b-4","Slug":"dune-b4","Title":"Dune","Author":"Frank Herbert","Count":7}]`)}}
+	defer func() { resultCache = nil }()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/popular", PopularBooksHandler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/popular", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"likes":7`) {
+		t.Fatalf("expected Dune with 7 likes, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestRecommendationsHandler_SharedCacheHybridMode(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	shared := mapCache{}
+	resultCache = shared
+	defer func() { resultCache = nil }()
+
+	expectUser := func() {
+		mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}
+	// popularity alone: only the popular books are loaded, and the answer is
+	// cached under the weights it was blended with
+	expectUser()
+	mock.ExpectQuery("SELECT avoid_content_warnings, max_audience_rating FROM users WHERE id = \\?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"avoid_content_warnings", "max_audience_rating"}).AddRow("", nil))
+	mock.ExpectQuery("FROM book_counters bc[\\s\\S]+ORDER BY bc.likes DESC, b.id").
+		WithArgs(2, 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "page_count", "likes", "cover_id"}).
+			AddRow(8, "b-8", "the-hobbit-b8", "The Hobbit", "J.R.R. Tolkien", 310, 40, nil))
+	expectCoLiked(mock, coLikedRows(), 8)
+	mock.ExpectQuery("SELECT b.uuid, b.slug, b.title, b.subjects\\s+FROM interactions i").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "slug", "title", "subjects"}))
+	mock.ExpectQuery("SELECT id, subjects FROM books WHERE id IN \\(\\?\\)").
+		WithArgs(8).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subjects"}).AddRow(8, `["Dragons"]`))
+	expectUser()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/recommendations/:user_id", asUser(2), RecommendationsHandler)
+	get := func() {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recommendations/2?mode=hybrid&w_collaborative=0&w_content=0&w_popularity=1", nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"slug":"the-hobbit-b8"`) {
+			t.Fatalf("expected 200 with The Hobbit, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	get()
+	if _, ok := shared.Get(context.Background(), "recs:2", "hybrid(0,0,1)||0|0|"); !ok {
+		t.Fatalf("expected the recommendations cached with their weights, got %v", shared)
+	}
+	get()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
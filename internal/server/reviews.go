@@ -0,0 +1,318 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// reviewSorts are the orders GET /books/:id/reviews accepts
+var reviewSorts = map[string]string{
+	"helpful": "rv.helpful_votes DESC, rv.created_at DESC, rv.id DESC",
+	"newest":  "rv.created_at DESC, rv.id DESC",
+}
+
+func resolveReviewRef(ctx context.Context, raw string) (int, error) {
+	return resolveRef(ctx, "reviews", "", orgScope, raw)
+}
+
+// bookReview resolves :review_id and checks it's a review of bookID
+func bookReview(c *gin.Context, bookID int) (int, bool) {
+	reviewID, ok := resolveParam(c, resolveReviewRef, c.Param("review_id"), "review")
+	if !ok {
+		return 0, false
+	}
+	found, err := rowExists(c.Request.Context(), "SELECT 1 FROM reviews WHERE id = ? AND book_id = ? AND deleted_at IS NULL", reviewID, bookID)
+	if err != nil {
+		abortWithError(c, err)
+		return 0, false
+	}
+	if !found {
+		abortWithError(c, notFound("review not found"))
+		return 0, false
+	}
+	return reviewID, true
+}
+
+// ListBookReviewsHandler godoc
+// @Summary Reviews of a book, most helpful first
+// @Description sort=newest lists the most recent first instead. Each review has the reviewer's star rating of the book (null when they haven't rated it) and how many readers found it helpful. Reviews by users the caller blocked are left out; spoilers=hide also leaves out reviews flagged as spoilers.
+// @Tags Reviews
+// @Produce json
+// @Param Authorization header string false "Bearer token (to leave out blocked users)"
+// @Param id path string true "Book UUID or slug"
+// @Param sort query string false "helpful or newest" Enums(helpful, newest) default(helpful)
+// @Param spoilers query string false "hide to skip spoiler reviews"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /books/{id}/reviews [get]
+func ListBookReviewsHandler(c *gin.Context) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	order, ok := reviewSorts[c.DefaultQuery("sort", "helpful")]
+	if !ok {
+		abortWithError(c, badRequest("sort must be helpful or newest"))
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	where := " WHERE rv.book_id = ? AND rv.organization_id = ? AND rv.deleted_at IS NULL"
+	args := []interface{}{bookID, tenant.ID(ctx)}
+	if hideSpoilers(c) {
+		where += " AND rv.spoiler = FALSE"
+	}
+	if viewerID := c.GetInt("auth_user_id"); viewerID > 0 {
+		where += " AND " + notBlockedSQL("rv.user_id")
+		args = append(args, viewerID)
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM reviews rv"+where, args...).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT rv.uuid, rv.body, rv.spoiler, rv.helpful_votes, rv.created_at, r.rating,
+		       u.id, u.uuid, u.handle
+		FROM reviews rv
+		LEFT JOIN interactions r ON r.user_id = rv.user_id AND r.book_id = rv.book_id
+		     AND r.action = 'rating' AND r.deleted_at IS NULL
+		LEFT JOIN users u ON u.id = rv.user_id`+where+`
+		ORDER BY `+order+`
+		LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	reviews := []gin.H{}
+	for rows.Next() {
+		var helpful int
+		var spoiler bool
+		var publicID, body, createdAt string
+		var rating, authorID sql.NullInt64
+		var authorUUID, handle sql.NullString
+		if err := rows.Scan(&publicID, &body, &spoiler, &helpful, &createdAt, &rating,
+			&authorID, &authorUUID, &handle); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		reviews = append(reviews, gin.H{
+			"uuid":          publicID,
+			"author":        userRef(authorID, authorUUID, handle),
+			"rating":        nullableInt(rating),
+			"body":          body,
+			"spoiler":       spoiler,
+			"helpful_votes": helpful,
+			"created_at":    createdAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  reviews,
+	})
+}
+
+// CreateBookReviewHandler godoc
+// @Summary Review a book
+// @Description One review per reader and book; 409 if you've already reviewed it. Rate the book with POST /interactions (action=rating): the review shows your rating alongside.
+// @Tags Reviews
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Book UUID or slug"
+// @Param body formData string true "Review (max 10000 characters)"
+// @Param spoiler formData bool false "Discusses plot points"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /books/{id}/reviews [post]
+func CreateBookReviewHandler(c *gin.Context) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	body, err := parsePostBody(c.PostForm("body"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	if !screenText(c, "body", body) {
+		return
+	}
+	spoiler, err := parseSpoiler(c.PostForm("spoiler"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := c.GetInt("auth_user_id")
+	res, err := db.ExecContext(ctx,
+		"INSERT INTO reviews (organization_id, book_id, user_id, body, spoiler) VALUES (?, ?, ?, ?, ?)",
+		tenant.ID(ctx), bookID, userID, body, spoiler)
+	if dberr.Is(err, dberr.ErrDuplicate) {
+		abortWithError(c, conflict("you have already reviewed this book"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	id, _ := res.LastInsertId()
+	reviewID := int(id)
+	var publicID, bookUUID string
+	if err := db.QueryRowContext(ctx,
+		"SELECT rv.uuid, b.uuid FROM reviews rv JOIN books b ON b.id = rv.book_id WHERE rv.id = ?",
+		reviewID).Scan(&publicID, &bookUUID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"uuid":          publicID,
+		"book_uuid":     bookUUID,
+		"body":          body,
+		"spoiler":       spoiler,
+		"helpful_votes": 0,
+	})
+}
+
+// MarkReviewHelpfulHandler godoc
+// @Summary Mark a review as helpful
+// @Description Idempotent: marking a review you already marked returns 200 instead of 201. You can't mark your own.
+// @Tags Reviews
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Book UUID or slug"
+// @Param review_id path string true "Review UUID"
+// @Success 201 {object} map[string]interface{}
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /books/{id}/reviews/{review_id}/helpful [post]
+func MarkReviewHelpfulHandler(c *gin.Context) {
+	voteOnReview(c, true)
+}
+
+// UnmarkReviewHelpfulHandler godoc
+// @Summary Take back marking a review as helpful
+// @Description Idempotent: also 200 when you hadn't marked it.
+// @Tags Reviews
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Book UUID or slug"
+// @Param review_id path string true "Review UUID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /books/{id}/reviews/{review_id}/helpful [delete]
+func UnmarkReviewHelpfulHandler(c *gin.Context) {
+	voteOnReview(c, false)
+}
+
+// voteOnReview records or takes back the caller's helpful vote and keeps
+// the review's helpful_votes in step, in one transaction
+func voteOnReview(c *gin.Context, helpful bool) {
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	reviewID, ok := bookReview(c, bookID)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := c.GetInt("auth_user_id")
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var authorID int
+	if err := tx.QueryRowContext(ctx, "SELECT user_id FROM reviews WHERE id = ? FOR UPDATE", reviewID).Scan(&authorID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if helpful && authorID == userID {
+		abortWithError(c, badRequest("cannot mark your own review as helpful"))
+		return
+	}
+
+	query, delta := "INSERT IGNORE INTO review_votes (review_id, user_id) VALUES (?, ?)", 1
+	if !helpful {
+		query, delta = "DELETE FROM review_votes WHERE review_id = ? AND user_id = ?", -1
+	}
+	res, err := tx.ExecContext(ctx, query, reviewID, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	changed, _ := res.RowsAffected()
+	if changed > 0 {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE reviews SET helpful_votes = helpful_votes + ? WHERE id = ?", delta, reviewID); err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+	var votes int
+	if err := tx.QueryRowContext(ctx, "SELECT helpful_votes FROM reviews WHERE id = ?", reviewID).Scan(&votes); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	reviewUUID, err := publicRef(ctx, tx, "reviews", reviewID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	status := 200
+	if helpful && changed > 0 {
+		status = 201
+	}
+	c.JSON(status, gin.H{
+		"review_uuid":   reviewUUID,
+		"helpful":       helpful,
+		"helpful_votes": votes,
+	})
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+)
+
+func reviewsRouter(userID int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorMiddleware())
+	r.GET("/books/:id/reviews", ListBookReviewsHandler)
+	r.POST("/books/:id/reviews", asUser(userID), CreateBookReviewHandler)
+	r.POST("/books/:id/reviews/:review_id/helpful", asUser(userID), MarkReviewHelpfulHandler)
+	r.DELETE("/books/:id/reviews/:review_id/helpful", asUser(userID), UnmarkReviewHelpfulHandler)
+	return r
+}
+
+var reviewColumns = []string{"uuid", "body", "spoiler", "helpful_votes", "created_at", "rating", "user_id", "user_uuid", "handle"}
+
+func TestListBookReviewsHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM reviews rv WHERE rv.book_id = \\? AND rv.organization_id = \\? AND rv.deleted_at IS NULL AND rv.spoiler = FALSE").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("AND r.action = 'rating' AND r.deleted_at IS NULL[\\s\\S]+ORDER BY rv.helpful_votes DESC, rv.created_at DESC, rv.id DESC\\s+LIMIT \\? OFFSET \\?").
+		WithArgs(7, 1, 20, 0).
+		WillReturnRows(sqlmock.NewRows(reviewColumns).
+			AddRow("r-4", "A slow start, then superb.", false, 9, "2024-05-01 10:00:00", 5, 2, "u-2", "ada").
+			AddRow("r-3", "Not for me.", false, 0, "2024-05-02 10:00:00", nil, 3, "u-3", "bo"))
+
+	r := reviewsRouter(2)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/7/reviews?spoilers=hide", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Total int                      `json:"total"`
+		Data  []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if body.Total != 2 || len(body.Data) != 2 || body.Data[0]["rating"] != float64(5) || body.Data[1]["rating"] != nil ||
+		body.Data[0]["helpful_votes"] != float64(9) {
+		t.Fatalf("unexpected reviews %s", w.Body.String())
+	}
+
+	// an unknown sort is rejected after the book is found
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/7/reviews?sort=longest", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown sort, got %d", w.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestCreateBookReviewHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO reviews \\(organization_id, book_id, user_id, body, spoiler\\) VALUES \\(\\?, \\?, \\?, \\?, \\?\\)").
+		WithArgs(1, 7, 2, "A slow start, then superb.", true).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectQuery("SELECT rv.uuid, b.uuid FROM reviews rv JOIN books b ON b.id = rv.book_id WHERE rv.id = \\?").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "uuid"}).AddRow("r-4", "b-7"))
+	// a second review of the same book
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO reviews").
+		WithArgs(1, 7, 2, "Still superb.", false).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '2-7' for key 'reviews.uq_reviews_user_book'"})
+	// no body
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	r := reviewsRouter(2)
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/books/7/reviews", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(url.Values{"body": {" A slow start, then superb. "}, "spoiler": {"true"}})
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"uuid":"r-4"`) ||
+		!strings.Contains(w.Body.String(), `"book_uuid":"b-7"`) {
+		t.Fatalf("expected 201 with the review, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := post(url.Values{"body": {"Still superb."}}); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a second review, got %d", w.Code)
+	}
+	if w := post(url.Values{"body": {"  "}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a body, got %d", w.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestMarkReviewHelpfulHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectReview := func() {
+		mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+			WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		mock.ExpectQuery("SELECT 1 FROM reviews WHERE id = \\? AND organization_id = \\?").
+			WithArgs(4, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		mock.ExpectQuery("SELECT 1 FROM reviews WHERE id = \\? AND book_id = \\? AND deleted_at IS NULL").
+			WithArgs(4, 7).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}
+	expectReview()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id FROM reviews WHERE id = \\? FOR UPDATE").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(2))
+	mock.ExpectExec("INSERT IGNORE INTO review_votes \\(review_id, user_id\\) VALUES \\(\\?, \\?\\)").
+		WithArgs(4, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE reviews SET helpful_votes = helpful_votes \\+ \\? WHERE id = \\?").
+		WithArgs(1, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT helpful_votes FROM reviews WHERE id = \\?").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"helpful_votes"}).AddRow(10))
+	expectPublicRef(mock, "reviews", 4, "r-4")
+	mock.ExpectCommit()
+
+	w := httptest.NewRecorder()
+	reviewsRouter(3).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/books/7/reviews/4/helpful", nil))
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"helpful_votes":10`) {
+		t.Fatalf("expected 201 with the new count, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// the reviewer can't vote for their own review
+	expectReview()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id FROM reviews WHERE id = \\? FOR UPDATE").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(2))
+	mock.ExpectRollback()
+
+	w = httptest.NewRecorder()
+	reviewsRouter(2).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/books/7/reviews/4/helpful", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for your own review, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestUnmarkReviewHelpfulHandler_NotMarked(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT 1 FROM reviews WHERE id = \\? AND organization_id = \\?").
+		WithArgs(4, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT 1 FROM reviews WHERE id = \\? AND book_id = \\? AND deleted_at IS NULL").
+		WithArgs(4, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id FROM reviews WHERE id = \\? FOR UPDATE").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(2))
+	// nothing to take back, so the count is left alone
+	mock.ExpectExec("DELETE FROM review_votes WHERE review_id = \\? AND user_id = \\?").
+		WithArgs(4, 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT helpful_votes FROM reviews WHERE id = \\?").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"helpful_votes"}).AddRow(9))
+	expectPublicRef(mock, "reviews", 4, "r-4")
+	mock.ExpectCommit()
+
+	w := httptest.NewRecorder()
+	reviewsRouter(3).ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/books/7/reviews/4/helpful", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"helpful_votes":9`) {
+		t.Fatalf("expected 200 with the count unchanged, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"os"
+	"strconv"
+)
+
+// searchPopularityWeight scales how far likes and ratings lift a book in
+// relevance-sorted search against how well the book matches the query
+// (SEARCH_POPULARITY_WEIGHT, read in Run by loadSearchPopularityWeight; 0
+// ranks by the text match alone)
+var searchPopularityWeight = 1.0
+
+// loadSearchPopularityWeight reads SEARCH_POPULARITY_WEIGHT, keeping the
+// default unless it's a number, 0 or more
+func loadSearchPopularityWeight() {
+	if w, err := strconv.ParseFloat(os.Getenv("SEARCH_POPULARITY_WEIGHT"), 64); err == nil && w >= 0 {
+		searchPopularityWeight = w
+	}
+}
+
+// searchMatchSQL matches q against a book's title, author and subjects in
+// the FULLTEXT index from migration 000046 (MATCH has to name exactly the
+// indexed columns). Bind q.
+const searchMatchSQL = "MATCH(b.title, b.author, b.subjects_text) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+// relevanceSQL scores books aliased b for sort=relevance: the full-text
+// relevance of q (searchMatchSQL) plus popularity, which is ln(1 + likes +
+// ratings) plus the average rating out of 5, so a well-read book outranks an
+// obscure better match at the default weight. Without q only popularity
+// counts. Higher scores come first.
+func relevanceSQL(q string, orgID int) (string, []interface{}) {
+	score, args := "", []interface{}{}
+	if q != "" {
+		score = searchMatchSQL + " + "
+		args = append(args, q)
+	}
+	score += `? * COALESCE((
+		SELECT LN(1 + bc.likes + bc.ratings) + LEAST(COALESCE(bc.rating_sum / NULLIF(bc.ratings, 0), 0), 5) / 5
+		FROM book_counters bc
+		WHERE bc.organization_id = ? AND bc.book_id = b.id), 0)`
+	args = append(args, searchPopularityWeight, orgID)
+	return score, args
+}
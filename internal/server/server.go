@@ -0,0 +1,2104 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/YeswanthC7/bookrec/db/migrations"
+	"github.com/YeswanthC7/bookrec/internal/cache"
+	"github.com/YeswanthC7/bookrec/internal/contentwarnings"
+	"github.com/YeswanthC7/bookrec/internal/counters"
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+	"github.com/YeswanthC7/bookrec/internal/handlers"
+	"github.com/YeswanthC7/bookrec/internal/ids"
+	"github.com/YeswanthC7/bookrec/internal/ingest"
+	"github.com/YeswanthC7/bookrec/internal/library"
+	"github.com/YeswanthC7/bookrec/internal/metrics"
+	"github.com/YeswanthC7/bookrec/internal/models"
+	"github.com/YeswanthC7/bookrec/internal/recommend"
+	"github.com/YeswanthC7/bookrec/internal/store"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+	"github.com/YeswanthC7/bookrec/internal/tracing"
+
+	// Swagger
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+
+	_ "github.com/YeswanthC7/bookrec/docs"
+)
+
+// global DB handle for handlers
+var db *sql.DB
+
+// JWT config
+var jwtSecret []byte
+var jwtIssuer string
+
+// Refresh token config
+var refreshTokenTTL = 30 * 24 * time.Hour // 30 days
+
+type AuthClaims struct {
+	UserID int    `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	OrgID  int    `json:"org_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type LoginResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	User         gin.H  `json:"user"`
+}
+
+type RefreshResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutResponse struct {
+	Message string `json:"message"`
+}
+
+func generateToken(userID int, email string, role string, orgID int) (string, error) {
+	now := time.Now()
+	if role == "" {
+		role = "user"
+	}
+
+	claims := AuthClaims{
+		UserID: userID,
+		Email:  email,
+		Role:   role,
+		OrgID:  orgID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    jwtIssuer,
+			Subject:   fmt.Sprintf("%d", userID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(24 * time.Hour)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+func hashRefreshToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+func newRefreshToken() (plain string, tokenHash string, expiresAt time.Time, err error) {
+	// 32 bytes => 256-bit random
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	plain = base64.RawURLEncoding.EncodeToString(b)
+	tokenHash = hashRefreshToken(plain)
+	expiresAt = time.Now().Add(refreshTokenTTL)
+	return plain, tokenHash, expiresAt, nil
+}
+
+func insertRefreshToken(userID int, tokenHash string, expiresAt time.Time) error {
+	_, err := db.Exec(`
+		INSERT INTO refresh_tokens (user_id, token_hash, expires_at)
+		VALUES (?, ?, ?)`,
+		userID, tokenHash, expiresAt)
+	return err
+}
+
+func AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+			abortWithError(c, unauthorized("missing or invalid Authorization header"))
+			return
+		}
+
+		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+		token, err := jwt.ParseWithClaims(tokenStr, &AuthClaims{}, func(t *jwt.Token) (interface{}, error) {
+			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method")
+			}
+			return jwtSecret, nil
+		})
+		if err != nil {
+			abortWithError(c, unauthorized("invalid token"))
+			return
+		}
+
+		claims, ok := token.Claims.(*AuthClaims)
+		if !ok || !token.Valid {
+			abortWithError(c, unauthorized("invalid token claims"))
+			return
+		}
+
+		role := claims.Role
+		if role == "" {
+			role = "user"
+		}
+
+		// tokens are only good for the organization that issued them
+		// (tokens minted before multi-tenancy carry no org_id)
+		orgID := claims.OrgID
+		if orgID == 0 {
+			orgID = tenant.DefaultID
+		}
+		if orgID != tenant.ID(c.Request.Context()) {
+			abortWithError(c, unauthorized("token belongs to another organization"))
+			return
+		}
+
+		c.Set("auth_user_id", claims.UserID)
+		c.Set("auth_email", claims.Email)
+		c.Set("auth_role", role)
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware authenticates the caller when a token is sent and
+// lets anonymous requests through otherwise (auth_user_id stays 0)
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	auth := AuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+func RequireRole(required string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roleAny, ok := c.Get("auth_role")
+		if !ok {
+			abortWithError(c, unauthorized("unauthorized"))
+			return
+		}
+		role, _ := roleAny.(string)
+		if role != required {
+			abortWithError(c, forbidden("forbidden"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// @title BookRec API
+// @version 1.0
+// @description Backend for personalized book recommendation system
+// @host localhost:8080
+// @BasePath /
+
+// Run serves the API on addr with database until ctx is cancelled, then
+// shuts down gracefully (see serve). workers also runs the webhook
+// dispatcher and queued ingest runs in this process (see RunWorkers).
+func Run(ctx context.Context, database *sql.DB, addr string, workers bool) error {
+	// JWT env
+	jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+	if len(jwtSecret) == 0 {
+		return errors.New("JWT_SECRET is required")
+	}
+	jwtIssuer = os.Getenv("JWT_ISSUER")
+	if jwtIssuer == "" {
+		jwtIssuer = "bookrec"
+	}
+
+	// Optional refresh TTL override (hours)
+	if v := strings.TrimSpace(os.Getenv("REFRESH_TOKEN_TTL_HOURS")); v != "" {
+		if hours, err := strconv.Atoi(v); err == nil && hours > 0 {
+			refreshTokenTTL = time.Duration(hours) * time.Hour
+		}
+	}
+
+	provider, err := library.FromEnv()
+	if err != nil {
+		return fmt.Errorf("library provider setup: %w", err)
+	}
+	libraryProvider = provider
+	shared, err := cache.FromEnv()
+	if err != nil {
+		return fmt.Errorf("cache setup: %w", err)
+	}
+	resultCache = shared
+	loadRecommendationCacheTTL()
+	loadHybridWeights()
+	tenantBaseDomain = strings.ToLower(strings.TrimSpace(os.Getenv("TENANT_BASE_DOMAIN")))
+	signupInviteOnly = os.Getenv("SIGNUP_INVITE_ONLY") == "true"
+	store.LegacyIntegerIDs = os.Getenv("LEGACY_INTEGER_IDS") == "true"
+	if store.LegacyIntegerIDs {
+		log.Printf("⚠️  LEGACY_INTEGER_IDS is deprecated and will be removed on 2027-03-31; send UUIDs or slugs instead")
+	}
+	contentFilter = newContentFilterSet(os.Getenv("CONTENT_FILTER_TERMS"))
+	outboundVendors = loadOutboundVendors(os.Getenv("OUTBOUND_LINK_TEMPLATES"), os.Getenv("AMAZON_AFFILIATE_TAG"))
+	loadReadingWPM()
+	defaultLanguage = canonicalLanguage(os.Getenv("DEFAULT_LANGUAGE"))
+	loadCatalogStaleAfter()
+	loadSearchPopularityWeight()
+	loadShutdownTimeout()
+	loadCORSSettings()
+	setUpRateLimits(shared)
+	if ingestSchedule, err = ingest.ScheduleFromEnv(); err != nil {
+		return fmt.Errorf("ingest schedule: %w", err)
+	}
+	shutdownTracing, err := tracing.FromEnv(ctx)
+	if err != nil {
+		return fmt.Errorf("tracing setup: %w", err)
+	}
+	if shutdownTracing != nil {
+		defer func() {
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(flushCtx); err != nil {
+				log.Printf("⚠️  Flushing traces failed: %v", err)
+			}
+		}()
+	}
+	db = database
+	records = store.New(database)
+	prometheus.MustRegister(collectors.NewDBStatsCollector(database, metrics.Namespace), ingestCollector{})
+
+	r, err := newRouter()
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("server failed: %w", err)
+	}
+
+	// Live-update feeds; webhook deliveries, queued ingest runs and the
+	// nightly ingest schedule too unless a separate worker process handles them. They're stopped and waited for before Run returns,
+	// so the caller can close database.
+	ctx, stop := context.WithCancel(ctx)
+	background := []func(context.Context){trending.Run, userStats.Run, contentFilter.Run}
+	if workers {
+		background = append(background, runWebhookDispatcher, runIngestWorker, runIngestScheduler)
+	}
+	var running sync.WaitGroup
+	for _, run := range background {
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			run(ctx)
+		}()
+	}
+
+	log.Printf("✅ Listening on %s", addr)
+	err = serve(ctx, &http.Server{Handler: headAsGet(r)}, ln)
+	stop()
+	running.Wait()
+	return err
+}
+
+// RunWorkers sends queued webhook deliveries, runs queued ingest runs and
+// queues the scheduled ones until ctx is cancelled. Any number of processes
+// can run it side by side.
+func RunWorkers(ctx context.Context, database *sql.DB) error {
+	schedule, err := ingest.ScheduleFromEnv()
+	if err != nil {
+		return fmt.Errorf("ingest schedule: %w", err)
+	}
+	db, ingestSchedule = database, schedule
+	var running sync.WaitGroup
+	for _, run := range []func(context.Context){runWebhookDispatcher, runIngestWorker, runIngestScheduler} {
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			run(ctx)
+		}()
+	}
+	running.Wait()
+	return nil
+}
+
+// newRouter builds the engine with its middleware and every route. It
+// doesn't start the background workers; Run does. middleware runs ahead of
+// everything else on every route.
+func newRouter(middleware ...gin.HandlerFunc) (*gin.Engine, error) {
+	r := gin.New()
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+	r.Use(gin.Logger(), gin.CustomRecovery(recoverWithError), MetricsMiddleware())
+	r.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(func(req *http.Request) bool {
+		return req.URL.Path != "/metrics" && req.URL.Path != "/healthz"
+	})))
+	r.Use(middleware...)
+	configureMethodHandling(r)
+	r.Use(corsMiddleware())
+
+	r.Use(RateLimitByIP())
+
+	// Resolve the organization (X-Tenant header or subdomain) before anything reads it
+	r.Use(TenantMiddleware())
+
+	h := &handlers.Handlers{Store: records}
+
+	// Validate requests against the generated OpenAPI spec (opt out with
+	// OPENAPI_VALIDATE_REQUESTS=false). Response checks are for dev only.
+	if os.Getenv("OPENAPI_VALIDATE_REQUESTS") != "false" {
+		specRouter, err := loadOpenAPIRouter()
+		if err != nil {
+			return nil, fmt.Errorf("OpenAPI spec: %w", err)
+		}
+		r.Use(OpenAPIValidator(specRouter, os.Getenv("OPENAPI_VALIDATE_RESPONSES") == "true"))
+	}
+	r.Use(ErrorMiddleware())
+
+	// Routes
+	r.GET("/healthz", HealthHandler)
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	r.GET("/stats", AuthMiddleware(), RequireRole("admin"), StatsHandler)
+	r.GET("/stats/stream", AuthMiddleware(), RequireRole("admin"), StatsStreamHandler)
+
+	r.POST("/users", CreateUserHandler)
+	r.POST("/login", LoginHandler)
+	r.POST("/auth/register", CreateUserHandler)
+	r.POST("/auth/login", LoginHandler)
+
+	// Refresh + logout
+	r.POST("/refresh", RefreshHandler)
+	r.POST("/logout", LogoutHandler)
+	r.POST("/logout-all", AuthMiddleware(), LogoutAllHandler)
+
+	// Admin-only routes (role-based auth); readers act on their own
+	// resources, which each handler checks
+	r.GET("/admin/users", AuthMiddleware(), RequireRole("admin"), ListUsersHandler)
+	r.GET("/admin/jobs/stream", AuthMiddleware(), RequirePlatformAdmin(), JobsStreamHandler)
+	r.GET("/admin/catalog/status", AuthMiddleware(), RequirePlatformAdmin(), CatalogStatusHandler)
+	r.POST("/admin/ingest", AuthMiddleware(), RequirePlatformAdmin(), QueueIngestHandler)
+	r.GET("/admin/ingest/:job_id", AuthMiddleware(), RequirePlatformAdmin(), GetIngestRunHandler)
+	r.GET("/admin/export/interactions", AuthMiddleware(), RequireRole("admin"), ExportInteractionsHandler)
+	r.GET("/admin/export/books", AuthMiddleware(), RequireRole("admin"), ExportBooksHandler)
+	r.POST("/admin/books", AuthMiddleware(), RequireRole("admin"), CreateBookHandler)
+	r.PATCH("/admin/books/batch", AuthMiddleware(), RequireRole("admin"), BatchUpdateBooksHandler)
+	r.PATCH("/admin/books/:id", AuthMiddleware(), RequireRole("admin"), PatchBookHandler)
+	r.DELETE("/admin/books/:id", AuthMiddleware(), RequireRole("admin"), DeleteBookHandler)
+	r.POST("/admin/books/:id/merge", AuthMiddleware(), RequireRole("admin"), MergeBookHandler)
+	r.POST("/admin/book-merges/:id/undo", AuthMiddleware(), RequireRole("admin"), UndoBookMergeHandler)
+	r.POST("/admin/users/:id/merge", AuthMiddleware(), RequireRole("admin"), MergeUserHandler)
+	r.GET("/admin/analytics", AuthMiddleware(), RequireRole("admin"), AnalyticsHandler)
+	r.GET("/admin/outbound-clicks", AuthMiddleware(), RequireRole("admin"), OutboundClicksHandler)
+	r.GET("/admin/reports", AuthMiddleware(), RequireRole("admin"), ListBookReportsHandler)
+	r.POST("/admin/reports/:id/resolve", AuthMiddleware(), RequireRole("admin"), ResolveBookReportHandler)
+	r.GET("/admin/content-reports", AuthMiddleware(), RequireRole("admin"), ListContentReportsHandler)
+	r.POST("/admin/content-reports/:target_type/:target_id/resolve", AuthMiddleware(), RequireRole("admin"), ResolveContentReportsHandler)
+	r.GET("/admin/content-filter", AuthMiddleware(), RequireRole("admin"), ListFilterTermsHandler)
+	r.POST("/admin/content-filter", AuthMiddleware(), RequireRole("admin"), AddFilterTermHandler)
+	r.DELETE("/admin/content-filter/:id", AuthMiddleware(), RequireRole("admin"), RemoveFilterTermHandler)
+	r.GET("/admin/referrals", AuthMiddleware(), RequireRole("admin"), ReferralsHandler)
+	r.GET("/admin/audit-log", AuthMiddleware(), RequireRole("admin"), ListAuditLogHandler)
+	r.GET("/admin/deleted", AuthMiddleware(), RequireRole("admin"), ListDeletedHandler)
+	r.POST("/admin/books/:id/restore", AuthMiddleware(), RequireRole("admin"), RestoreBookHandler)
+	r.POST("/admin/users/:id/restore", AuthMiddleware(), RequireRole("admin"), RestoreUserHandler)
+	r.POST("/admin/lists/:id/restore", AuthMiddleware(), RequireRole("admin"), RestoreListHandler)
+	r.POST("/admin/interactions/:id/restore", AuthMiddleware(), RequireRole("admin"), RestoreInteractionHandler)
+
+	// Tenants (platform admins only)
+	r.POST("/admin/organizations", AuthMiddleware(), RequirePlatformAdmin(), CreateOrganizationHandler)
+	r.GET("/admin/organizations", AuthMiddleware(), RequirePlatformAdmin(), ListOrganizationsHandler)
+
+	// Outgoing webhooks (platform-admin-managed; they see every tenant's events)
+	webhooks := r.Group("/admin/webhooks", AuthMiddleware(), RequirePlatformAdmin())
+	webhooks.POST("", CreateWebhookHandler)
+	webhooks.GET("", ListWebhooksHandler)
+	webhooks.DELETE("/:id", DeleteWebhookHandler)
+	webhooks.GET("/:id/deliveries", WebhookDeliveriesHandler)
+
+	r.GET("/users", AuthMiddleware(), RequireRole("admin"), ListUsersHandler)
+	r.GET("/users/:id", OptionalAuthMiddleware(), followRedirects("user"), h.GetUser)
+	r.PATCH("/users/:id", AuthMiddleware(), UpdateUserHandler)
+	r.DELETE("/users/:id", AuthMiddleware(), DeleteUserHandler)
+	r.GET("/users/:id/history", AuthMiddleware(), UserHistoryHandler)
+	r.GET("/users/:id/stats", UserStatsHandler)
+	r.POST("/users/:id/invites", AuthMiddleware(), CreateInviteHandler)
+	r.GET("/users/:id/invites", AuthMiddleware(), ListInvitesHandler)
+
+	// Social graph
+	r.POST("/users/:id/follow", AuthMiddleware(), FollowUserHandler)
+	r.DELETE("/users/:id/follow", AuthMiddleware(), UnfollowUserHandler)
+	r.POST("/users/:id/block", AuthMiddleware(), BlockUserHandler)
+	r.DELETE("/users/:id/block", AuthMiddleware(), UnblockUserHandler)
+	r.GET("/users/:id/blocks", AuthMiddleware(), ListBlocksHandler)
+	r.GET("/users/:id/followers", ListFollowersHandler)
+	r.GET("/users/:id/following", ListFollowingHandler)
+	r.GET("/feed", AuthMiddleware(), ActivityFeedHandler)
+
+	// Reading lists
+	r.POST("/users/:id/lists", AuthMiddleware(), CreateListHandler)
+	r.GET("/users/:id/lists", OptionalAuthMiddleware(), ListUserListsHandler)
+	r.GET("/lists/:id", OptionalAuthMiddleware(), GetListHandler)
+	r.PATCH("/lists/:id", AuthMiddleware(), UpdateListHandler)
+	r.DELETE("/lists/:id", AuthMiddleware(), DeleteListHandler)
+	r.POST("/lists/:id/books", AuthMiddleware(), AddListBookHandler)
+	r.PATCH("/lists/:id/books/:book_id", AuthMiddleware(), MoveListBookHandler)
+	r.DELETE("/lists/:id/books/:book_id", AuthMiddleware(), RemoveListBookHandler)
+	r.GET("/lists/:id/history", OptionalAuthMiddleware(), ListHistoryHandler)
+	r.GET("/lists/:id/export", OptionalAuthMiddleware(), ExportListHandler)
+	r.POST("/lists/:id/share", AuthMiddleware(), CreateListShareHandler)
+	r.DELETE("/lists/:id/share", AuthMiddleware(), RevokeListShareHandler)
+	r.GET("/lists/shared/:token", SharedListHandler)
+
+	// List co-editors: owners/managers invite, invitees accept or decline
+	r.GET("/lists/:id/members", OptionalAuthMiddleware(), ListListMembersHandler)
+	r.POST("/lists/:id/members", AuthMiddleware(), InviteListMemberHandler)
+	r.PATCH("/lists/:id/members/:user_id", AuthMiddleware(), UpdateListMemberHandler)
+	r.DELETE("/lists/:id/members/:user_id", AuthMiddleware(), RemoveListMemberHandler)
+	r.POST("/lists/:id/invitation", AuthMiddleware(), AcceptListInvitationHandler)
+	r.DELETE("/lists/:id/invitation", AuthMiddleware(), DeclineListInvitationHandler)
+	r.GET("/users/:id/list-invitations", AuthMiddleware(), ListInvitationsHandler)
+
+	// Book clubs
+	r.POST("/groups", AuthMiddleware(), CreateGroupHandler)
+	r.GET("/groups", ListGroupsHandler)
+	r.GET("/groups/:id", GetGroupHandler)
+	r.PATCH("/groups/:id", AuthMiddleware(), UpdateGroupHandler)
+	r.DELETE("/groups/:id", AuthMiddleware(), DeleteGroupHandler)
+	r.GET("/groups/:id/members", ListGroupMembersHandler)
+	r.POST("/groups/:id/members", AuthMiddleware(), JoinGroupHandler)
+	r.PATCH("/groups/:id/members/:user_id", AuthMiddleware(), UpdateGroupMemberHandler)
+	r.DELETE("/groups/:id/members/:user_id", AuthMiddleware(), RemoveGroupMemberHandler)
+	r.GET("/groups/:id/picks", ListGroupPicksHandler)
+	r.POST("/groups/:id/picks", AuthMiddleware(), CreateGroupPickHandler)
+	r.GET("/groups/:id/recommendations", AuthMiddleware(), RateLimitByUser(), GroupRecommendationsHandler)
+	r.GET("/groups/:id/schedule", ListGroupMeetingsHandler)
+	r.POST("/groups/:id/schedule", AuthMiddleware(), CreateGroupMeetingHandler)
+	r.DELETE("/groups/:id/schedule/:meeting_id", AuthMiddleware(), DeleteGroupMeetingHandler)
+	r.GET("/groups/:id/threads", AuthMiddleware(), ListGroupThreadsHandler)
+	r.POST("/groups/:id/threads", AuthMiddleware(), CreateGroupThreadHandler)
+	r.GET("/groups/:id/threads/:thread_id", AuthMiddleware(), GetGroupThreadHandler)
+	r.POST("/groups/:id/threads/:thread_id/posts", AuthMiddleware(), CreateGroupPostHandler)
+
+	// Weekly digest email (sent by cmd/jobs/digest)
+	r.POST("/users/:id/digest", AuthMiddleware(), SubscribeDigestHandler)
+	r.DELETE("/users/:id/digest", AuthMiddleware(), UnsubscribeDigestHandler)
+	r.GET("/digest/unsubscribe", DigestTokenUnsubscribeHandler)
+	r.POST("/digest/unsubscribe", DigestTokenUnsubscribeHandler)
+
+	// Most active readers
+	r.GET("/leaderboard", LeaderboardHandler)
+	r.POST("/users/:id/leaderboard", AuthMiddleware(), LeaderboardOptInHandler)
+	r.DELETE("/users/:id/leaderboard", AuthMiddleware(), LeaderboardOptOutHandler)
+	r.GET("/users/:id/content-preferences", AuthMiddleware(), GetContentPreferencesHandler)
+	r.PUT("/users/:id/content-preferences", AuthMiddleware(), UpdateContentPreferencesHandler)
+	r.GET("/users/:id/shelves", AuthMiddleware(), ListShelvesHandler)
+	r.GET("/users/:id/shelves/:shelf", AuthMiddleware(), ListShelfBooksHandler)
+	r.PUT("/users/:id/shelves/:shelf/books/:book_id", AuthMiddleware(), ShelveBookHandler)
+	r.DELETE("/users/:id/shelves/:shelf/books/:book_id", AuthMiddleware(), UnshelveBookHandler)
+
+	r.GET("/genres", ListGenresHandler)
+	r.GET("/authors/popular", PopularAuthorsHandler)
+	r.GET("/authors/:id", GetAuthorHandler)
+	r.GET("/authors/:id/books", ListAuthorBooksHandler)
+	r.GET("/books", ListBooksHandler)
+	r.GET("/books/search", SearchBooksHandler)
+	r.GET("/books/popular", PopularBooksHandler)
+	r.GET("/books/compare", CompareBooksHandler)
+	r.GET("/books/isbn/:isbn", GetBookByISBNHandler)
+	r.GET("/books/:id/availability", BookAvailabilityHandler)
+	r.GET("/out/:book_id/:vendor", OptionalAuthMiddleware(), OutboundRedirectHandler)
+	r.GET("/books/:id", followRedirects("book"), GetBookHandler)
+	r.GET("/books/:id/translations", ListBookTranslationsHandler)
+	r.GET("/lookup/isbn/:raw", LookupISBNHandler)
+	r.PUT("/admin/books/:id/translations/:language", AuthMiddleware(), RequireRole("admin"), PutBookTranslationHandler)
+	r.DELETE("/admin/books/:id/translations/:language", AuthMiddleware(), RequireRole("admin"), DeleteBookTranslationHandler)
+	r.POST("/books/:id/report", AuthMiddleware(), ReportBookHandler)
+
+	// Book discussions (moderators are organization admins)
+	r.GET("/books/:id/threads", OptionalAuthMiddleware(), ListBookThreadsHandler)
+	r.POST("/books/:id/threads", AuthMiddleware(), CreateBookThreadHandler)
+	r.GET("/books/:id/threads/:thread_id", OptionalAuthMiddleware(), GetBookThreadHandler)
+	r.DELETE("/books/:id/threads/:thread_id", AuthMiddleware(), RequireRole("admin"), DeleteBookThreadHandler)
+	r.POST("/books/:id/threads/:thread_id/posts", AuthMiddleware(), CreateBookPostHandler)
+	r.DELETE("/books/:id/threads/:thread_id/posts/:post_id", AuthMiddleware(), RequireRole("admin"), DeleteBookPostHandler)
+	r.GET("/books/:id/reviews", OptionalAuthMiddleware(), ListBookReviewsHandler)
+	r.POST("/books/:id/reviews", AuthMiddleware(), CreateBookReviewHandler)
+	r.POST("/books/:id/reviews/:review_id/helpful", AuthMiddleware(), MarkReviewHelpfulHandler)
+	r.DELETE("/books/:id/reviews/:review_id/helpful", AuthMiddleware(), UnmarkReviewHelpfulHandler)
+
+	// Abuse reports on discussion content (books and clubs)
+	r.POST("/threads/:id/report", AuthMiddleware(), ReportThreadHandler)
+	r.POST("/posts/:id/report", AuthMiddleware(), ReportPostHandler)
+
+	// Feeds
+	r.GET("/feeds/new.xml", NewBooksFeedHandler)
+	r.GET("/feeds/trending.xml", TrendingBooksFeedHandler)
+
+	// Live updates
+	r.GET("/ws/trending", TrendingWSHandler)
+
+	// Protected
+	r.POST("/interactions", AuthMiddleware(), CreateInteractionHandler)
+	r.GET("/interactions/:id", AuthMiddleware(), h.GetInteraction)
+	r.DELETE("/interactions", AuthMiddleware(), DeleteBookInteractionHandler)
+	r.DELETE("/interactions/:id", AuthMiddleware(), DeleteInteractionHandler)
+
+	r.GET("/recommendations/:user_id", AuthMiddleware(), RateLimitByUser(), RecommendationsHandler)
+	r.POST("/recommendations/:user_id/share", AuthMiddleware(), RateLimitByUser(), ShareRecommendationsHandler)
+	r.GET("/recommendations/shared/:token", SharedRecommendationsHandler)
+	r.DELETE("/recommendations/shared/:token", AuthMiddleware(), DeleteSharedRecommendationsHandler)
+
+	// GraphQL
+	gql := GraphQLHandler()
+	r.POST("/graphql", OptionalAuthMiddleware(), gql)
+	r.GET("/graphql", OptionalAuthMiddleware(), gql)
+	r.GET("/graphql/playground", GraphQLPlaygroundHandler())
+
+	// Swagger UI
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// Embedded demo frontend
+	registerUI(r)
+
+	return r, nil
+}
+
+//
+// -------- Handlers with Swagger annotations --------
+//
+
+// latestMigration is the newest migration built into this binary
+var latestMigration = func() uint {
+	version, err := migrations.Latest()
+	if err != nil {
+		log.Printf("⚠️  Reading the embedded migrations failed: %v", err)
+	}
+	return version
+}()
+
+// HealthHandler godoc
+// @Summary Health Check
+// @Description Returns status of the server and its schema. schema_version is the last migration applied to the database (null when none has been or it can't be read) and schema_dirty marks one that failed halfway. schema_latest is the newest migration in this binary: a lower schema_version means bookrec migrate is pending.
+// @Tags System
+// @Success 200 {object} map[string]interface{}
+// @Router /healthz [get]
+func HealthHandler(c *gin.Context) {
+	health := gin.H{"status": "ok", "schema_version": nil, "schema_dirty": false, "schema_latest": latestMigration}
+	var version uint
+	var dirty bool
+	err := db.QueryRowContext(c.Request.Context(), "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	switch {
+	case err == nil:
+		health["schema_version"], health["schema_dirty"] = version, dirty
+	case !errors.Is(err, sql.ErrNoRows):
+		log.Printf("⚠️  Reading the schema version failed: %v", err)
+	}
+	c.JSON(http.StatusOK, health)
+}
+
+// createUserRequest is the form or JSON body of POST /users
+type createUserRequest struct {
+	Email      string `form:"email" json:"email" binding:"required,email,max=255"`
+	Handle     string `form:"handle" json:"handle" binding:"required,max=50"`
+	Password   string `form:"password" json:"password" binding:"required"`
+	InviteCode string `form:"invite_code" json:"invite_code"`
+}
+
+func (r *createUserRequest) normalize() {
+	r.Email = strings.TrimSpace(r.Email)
+	r.Handle = strings.TrimSpace(r.Handle)
+	r.InviteCode = strings.TrimSpace(r.InviteCode)
+}
+
+// CreateUserHandler godoc
+// @Summary Create a new user
+// @Description Registers a new user. The fields can be sent as a form or as a JSON object with the same names.
+// @Tags Users
+// @Accept x-www-form-urlencoded,mpfd,json
+// @Produce json
+// @Param email formData string true "Email"
+// @Param handle formData string true "Handle"
+// @Param password formData string true "Password"
+// @Param invite_code formData string false "Invite code from an existing user (required when signups are invite-only)"
+// @Success 201 {object} map[string]interface{}
+// @Header 201 {string} Location "/users/{uuid}"
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users [post]
+// @Router /auth/register [post]
+func CreateUserHandler(c *gin.Context) {
+	var req createUserRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	email, handle, password, inviteCode := req.Email, req.Handle, req.Password, req.InviteCode
+
+	if inviteCode == "" && signupInviteOnly {
+		abortWithError(c, badRequest("signups are invite-only; an invite_code is required"))
+		return
+	}
+	if !screenText(c, "handle", handle) {
+		return
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		abortWithError(c, newAppError(500, "failed to hash password"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var codeID, invitedBy, inviterUUID interface{}
+	if inviteCode != "" {
+		id, inviterID, err := redeemInvite(ctx, tx, inviteCode)
+		if errors.Is(err, errInviteInvalid) {
+			abortWithError(c, badRequest(err.Error()))
+			return
+		}
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if inviterUUID, err = publicRef(ctx, tx, "users", inviterID); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		codeID, invitedBy = id, inviterID
+	}
+
+	orgID := tenant.ID(ctx)
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO users (organization_id, email, handle, password_hash, invited_by, invite_code_id) VALUES (?, ?, ?, ?, ?, ?)",
+		orgID, email, handle, string(hashed), invitedBy, codeID)
+	if err != nil {
+		if dberr.Is(err, dberr.ErrDuplicate) {
+			abortWithError(c, conflict("Email already exists"))
+			return
+		}
+		abortWithError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	userID, _ := res.LastInsertId()
+	user, err := records.User(ctx, int(userID))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	emitEvent(c.Request.Context(), EventUserCreated, map[string]interface{}{
+		"user_id":         userID,
+		"user_uuid":       user.UUID,
+		"handle":          handle,
+		"organization_id": orgID,
+	})
+
+	c.Header("Location", fmt.Sprintf("/users/%s", user.UUID))
+	c.JSON(201, createdUser{User: user, InvitedBy: inviterUUID})
+}
+
+// createdUser is the answer to POST /users: the user and the uuid of who
+// invited them
+type createdUser struct {
+	models.User
+	InvitedBy interface{} `json:"invited_by"`
+}
+
+// loginRequest is the form or JSON body of POST /login. The email isn't checked for
+// format: a malformed one simply matches no account.
+type loginRequest struct {
+	Email    string `form:"email" json:"email" binding:"required"`
+	Password string `form:"password" json:"password" binding:"required"`
+}
+
+func (r *loginRequest) normalize() { r.Email = strings.TrimSpace(r.Email) }
+
+// LoginHandler godoc
+// @Summary Login and get tokens (access + refresh)
+// @Tags Auth
+// @Accept x-www-form-urlencoded,mpfd,json
+// @Produce json
+// @Param email formData string true "Email"
+// @Param password formData string true "Password"
+// @Success 200 {object} LoginResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /login [post]
+// @Router /auth/login [post]
+func LoginHandler(c *gin.Context) {
+	var req loginRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	email, password := req.Email, req.Password
+
+	// emails are unique per organization, so the tenant picks the account
+	orgID := tenant.ID(c.Request.Context())
+	var userID int
+	var publicID, passwordHash string
+	var role string
+	var disabledAt sql.NullTime
+	if err := db.QueryRow("SELECT id, uuid, password_hash, role, disabled_at FROM users WHERE email = ? AND organization_id = ? AND deleted_at IS NULL", email, orgID).
+		Scan(&userID, &publicID, &passwordHash, &role, &disabledAt); err != nil {
+		abortWithError(c, unauthorized("invalid credentials"))
+		return
+	}
+	if role == "" {
+		role = "user"
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); err != nil {
+		abortWithError(c, unauthorized("invalid credentials"))
+		return
+	}
+	// merged accounts are disabled; the surviving account signs in instead
+	if disabledAt.Valid {
+		abortWithError(c, forbidden("account is disabled"))
+		return
+	}
+
+	accessToken, err := generateToken(userID, email, role, orgID)
+	if err != nil {
+		abortWithError(c, newAppError(500, "failed to generate access token"))
+		return
+	}
+
+	refreshPlain, refreshHash, refreshExp, err := newRefreshToken()
+	if err != nil {
+		abortWithError(c, newAppError(500, "failed to generate refresh token"))
+		return
+	}
+	if err := insertRefreshToken(userID, refreshHash, refreshExp); err != nil {
+		abortWithError(c, newAppError(500, "failed to store refresh token"))
+		return
+	}
+
+	c.JSON(200, LoginResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshPlain,
+		User:         gin.H{"uuid": publicID, "email": email, "role": role},
+	})
+}
+
+// refreshTokenRequest is the form or JSON body of POST /refresh and POST
+// /logout
+type refreshTokenRequest struct {
+	RefreshToken string `form:"refresh_token" json:"refresh_token" binding:"required"`
+}
+
+func (r *refreshTokenRequest) normalize() { r.RefreshToken = strings.TrimSpace(r.RefreshToken) }
+
+// RefreshHandler godoc
+// @Summary Refresh tokens (rotates refresh token every call)
+// @Tags Auth
+// @Accept x-www-form-urlencoded,mpfd,json
+// @Produce json
+// @Param refresh_token formData string true "Refresh token"
+// @Success 200 {object} RefreshResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /refresh [post]
+func RefreshHandler(c *gin.Context) {
+	var req refreshTokenRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	refreshToken := req.RefreshToken
+
+	tokenHash := hashRefreshToken(refreshToken)
+	now := time.Now()
+
+	tx, err := db.Begin()
+	if err != nil {
+		abortWithError(c, newAppError(500, "failed to start transaction"))
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Lock the token row so rotation is race-safe
+	var rowID int
+	var userID int
+	var expiresAt time.Time
+	var revokedAt sql.NullTime
+	if err := tx.QueryRow(`
+		SELECT id, user_id, expires_at, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = ?
+		LIMIT 1
+		FOR UPDATE`, tokenHash).Scan(&rowID, &userID, &expiresAt, &revokedAt); err != nil {
+		abortWithError(c, unauthorized("invalid refresh token"))
+		return
+	}
+
+	if revokedAt.Valid {
+		abortWithError(c, unauthorized("refresh token revoked"))
+		return
+	}
+	if now.After(expiresAt) {
+		_, _ = tx.Exec(`UPDATE refresh_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, now, rowID)
+		_ = tx.Commit()
+		abortWithError(c, unauthorized("refresh token expired"))
+		return
+	}
+
+	// Load user email + role + organization for JWT claims
+	var email string
+	var role string
+	var orgID int
+	if err := tx.QueryRow(`SELECT email, role, organization_id FROM users WHERE id = ? AND deleted_at IS NULL`, userID).Scan(&email, &role, &orgID); err != nil {
+		abortWithError(c, unauthorized("invalid refresh token user"))
+		return
+	}
+	if orgID != tenant.ID(c.Request.Context()) {
+		abortWithError(c, unauthorized("invalid refresh token"))
+		return
+	}
+	if role == "" {
+		role = "user"
+	}
+
+	// Revoke old token (must affect 1 row)
+	res, err := tx.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = ?
+		WHERE id = ? AND revoked_at IS NULL`, now, rowID)
+	if err != nil {
+		abortWithError(c, newAppError(500, "failed to rotate refresh token"))
+		return
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		abortWithError(c, unauthorized("refresh token revoked"))
+		return
+	}
+
+	// Insert rotated refresh token
+	newPlain, newHash, newExp, err := newRefreshToken()
+	if err != nil {
+		abortWithError(c, newAppError(500, "failed to generate new refresh token"))
+		return
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO refresh_tokens (user_id, token_hash, expires_at)
+		VALUES (?, ?, ?)`, userID, newHash, newExp); err != nil {
+		abortWithError(c, newAppError(500, "failed to store new refresh token"))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		abortWithError(c, newAppError(500, "failed to commit transaction"))
+		return
+	}
+
+	accessToken, err := generateToken(userID, email, role, orgID)
+	if err != nil {
+		abortWithError(c, newAppError(500, "failed to generate access token"))
+		return
+	}
+
+	c.JSON(200, RefreshResponse{
+		AccessToken:  accessToken,
+		RefreshToken: newPlain,
+	})
+}
+
+// LogoutHandler godoc
+// @Summary Logout (revoke refresh token)
+// @Tags Auth
+// @Accept x-www-form-urlencoded,mpfd,json
+// @Produce json
+// @Param refresh_token formData string true "Refresh token"
+// @Success 200 {object} LogoutResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /logout [post]
+func LogoutHandler(c *gin.Context) {
+	var req refreshTokenRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	refreshToken := req.RefreshToken
+
+	tokenHash := hashRefreshToken(refreshToken)
+
+	res, err := db.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE token_hash = ? AND revoked_at IS NULL`, tokenHash)
+	if err != nil {
+		abortWithError(c, newAppError(500, "failed to revoke refresh token"))
+		return
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		abortWithError(c, unauthorized("invalid refresh token"))
+		return
+	}
+
+	c.JSON(200, LogoutResponse{Message: "Logged out"})
+}
+
+// LogoutAllHandler godoc
+// @Summary Logout from all sessions (revoke all refresh tokens for current user)
+// @Tags Auth
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} LogoutResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /logout-all [post]
+func LogoutAllHandler(c *gin.Context) {
+	authUserIDAny, ok := c.Get("auth_user_id")
+	if !ok {
+		abortWithError(c, unauthorized("unauthorized"))
+		return
+	}
+	userID, ok := authUserIDAny.(int)
+	if !ok || userID <= 0 {
+		abortWithError(c, unauthorized("unauthorized"))
+		return
+	}
+
+	_, err := db.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE user_id = ? AND revoked_at IS NULL`, userID)
+	if err != nil {
+		abortWithError(c, newAppError(500, "failed to revoke refresh tokens"))
+		return
+	}
+
+	c.JSON(200, LogoutResponse{Message: "Logged out from all sessions"})
+}
+
+// encodeIDCursor packs the id of the last item on a page ordered by id, for
+// GET /users and GET /books
+func encodeIDCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+func decodeIDCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(raw))
+}
+
+// ListUsersHandler godoc
+// @Summary List the organization's users (paginated, oldest first)
+// @Description Page with page and limit, or pass next_cursor back as cursor, which keeps its place while users sign up or leave; next_cursor is null on the last page and page is null when a cursor was given. total counts all the organization's users. Admins only, as the list includes emails.
+// @Tags Users
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Param cursor query string false "Opaque cursor from a previous page; page is ignored"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /users [get]
+func ListUsersHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var afterID int
+	if cursor := c.Query("cursor"); cursor != "" {
+		var err error
+		if afterID, err = decodeIDCursor(cursor); err != nil {
+			abortWithError(c, badRequest("invalid cursor"))
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	where := " WHERE organization_id = ? AND deleted_at IS NULL"
+	args := []interface{}{tenant.ID(ctx)}
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	var pageOut interface{} = page
+	offset := (page - 1) * limit
+	if c.Query("cursor") != "" {
+		where += " AND id > ?"
+		args = append(args, afterID)
+		pageOut, offset = nil, 0
+	}
+	// one extra row tells us whether there is another page
+	args = append(args, limit+1, offset)
+	rows, err := db.QueryContext(ctx,
+		"SELECT id, uuid, email, handle, created_at FROM users"+where+" ORDER BY id LIMIT ? OFFSET ?", args...)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	users := []map[string]interface{}{}
+	var next interface{}
+	lastID := 0
+	for rows.Next() {
+		var id int
+		var publicID, email, handle, createdAt string
+		if err := rows.Scan(&id, &publicID, &email, &handle, &createdAt); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if len(users) == limit {
+			next = encodeIDCursor(lastID)
+			break
+		}
+		lastID = id
+		users = append(users, gin.H{
+			"uuid":       publicID,
+			"email":      email,
+			"handle":     handle,
+			"created_at": createdAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"page":        pageOut,
+		"limit":       limit,
+		"total":       total,
+		"data":        users,
+		"next_cursor": next,
+	})
+}
+
+// updateUserRequest is the form or JSON body of PATCH /users/:id; fields
+// left out are kept
+type updateUserRequest struct {
+	Email  *string `form:"email" json:"email" binding:"omitempty,email,max=255"`
+	Handle *string `form:"handle" json:"handle" binding:"omitempty,min=1,max=50"`
+}
+
+func (r *updateUserRequest) normalize() {
+	for _, field := range []*string{r.Email, r.Handle} {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+		}
+	}
+}
+
+// UpdateUserHandler godoc
+// @Summary Change an account's handle or email (the account itself or an admin)
+// @Description The fields can be sent as a form or as a JSON object; fields left out are kept.
+// @Tags Users
+// @Accept x-www-form-urlencoded,mpfd,json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID"
+// @Param handle formData string false "New handle (max 50 characters)"
+// @Param email formData string false "New email; signs in from now on"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users/{id} [patch]
+func UpdateUserHandler(c *gin.Context) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+	if c.GetInt("auth_user_id") != userID && c.GetString("auth_role") != "admin" {
+		abortWithError(c, forbidden("cannot update another user"))
+		return
+	}
+	var req updateUserRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	if req.Email == nil && req.Handle == nil {
+		abortWithError(c, badRequest("handle or email is required"))
+		return
+	}
+	if req.Handle != nil && !screenText(c, "handle", *req.Handle) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	before, err := auditSnapshot(ctx, tx, "user", userID)
+	if err == nil {
+		_, err = tx.ExecContext(ctx,
+			"UPDATE users SET email = COALESCE(?, email), handle = COALESCE(?, handle) WHERE id = ?",
+			req.Email, req.Handle, userID)
+		if dberr.Is(err, dberr.ErrDuplicate) {
+			abortWithError(c, conflict("Email already exists"))
+			return
+		}
+	}
+	if err == nil {
+		err = recordAudit(c, tx, AuditUserUpdate, "user", userID, before)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	user, err := records.User(ctx, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, user)
+}
+
+// ListBooksHandler godoc
+// @Summary List books (paginated)
+// @Description Page with page and limit, or pass next_cursor back as cursor: it continues after the page's last book, so deep pages stay fast. next_cursor is null on the last page and page is null when a cursor was given.
+// @Tags Books
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Opaque cursor from a previous page; page is ignored"
+// @Param format query string false "Comma-separated formats (print, ebook, audiobook); books in any of them"
+// @Param min_pages query int false "Only books with at least this many pages"
+// @Param max_pages query int false "Only books with at most this many pages"
+// @Param genre query string false "Only books in this genre (a slug from GET /genres)"
+// @Param include query string false "Comma-separated expansions: author, genres, avg_rating, links"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /books [get]
+func ListBooksHandler(c *gin.Context) {
+	includes, err := parseIncludes(c.Query("include"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	filters, err := parseBookFilters(c)
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+
+	pageStr := c.DefaultQuery("page", "1")
+	limitStr := c.DefaultQuery("limit", "20")
+
+	page, _ := strconv.Atoi(pageStr)
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ := strconv.Atoi(limitStr)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	filterSQL, filterArgs := filters.sql("")
+	args := append([]interface{}{tenant.ID(c.Request.Context())}, filterArgs...)
+	var pageOut interface{} = page
+	if cursor := c.Query("cursor"); cursor != "" {
+		afterID, err := decodeIDCursor(cursor)
+		if err != nil {
+			abortWithError(c, badRequest("invalid cursor"))
+			return
+		}
+		filterSQL += " AND id > ?"
+		args = append(args, afterID)
+		pageOut, offset = nil, 0
+	}
+	query := `
+        SELECT id, uuid, slug, title, author, published_year, formats, page_count, content_warnings, audience_rating, cover_id, language, publisher
+        FROM books
+        WHERE ` + tenant.BooksVisibleSQL("") + ` AND merged_into IS NULL` + filterSQL + `
+        ORDER BY id
+        LIMIT ? OFFSET ?;
+    `
+	// one extra row tells us whether there is another page
+	args = append(args, limit+1, offset)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	books := []map[string]interface{}{}
+	var next interface{}
+	lastID := 0
+	for rows.Next() {
+		var id int
+		var publicID, slug, title, available, warnings string
+		var author sql.NullString
+		var year, pages, cover sql.NullInt64
+		var audience, lang, publisher sql.NullString
+		if err := rows.Scan(&id, &publicID, &slug, &title, &author, &year, &available, &pages, &warnings, &audience, &cover, &lang, &publisher); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if len(books) == limit {
+			next = encodeIDCursor(lastID)
+			break
+		}
+		lastID = id
+		books = append(books, gin.H{
+			"id":               id,
+			"uuid":             publicID,
+			"slug":             slug,
+			"title":            title,
+			"author":           author.String,
+			"year":             year.Int64,
+			"formats":          splitFormats(available),
+			"page_count":       nullableInt(pages),
+			"reading_hours":    readingHours(pages),
+			"language":         nullableString(lang),
+			"publisher":        nullableString(publisher),
+			"content_warnings": contentwarnings.Split(warnings),
+			"audience_rating":  nullableString(audience),
+			"cover_url":        coverURL(cover),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	if err := applyIncludes(c.Request.Context(), books, includes); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := localizeBooks(c, books); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	hideInternalIDs(books, "id")
+
+	c.JSON(200, gin.H{
+		"page":        pageOut,
+		"limit":       limit,
+		"data":        books,
+		"next_cursor": next,
+	})
+}
+
+// GetBookHandler godoc
+// @Summary Get a book by slug or UUID
+// @Description The full record: author_id (see GET /authors/{id}; null when the book has no author record), subjects, open_library_key and published year, edition metadata (language as a BCP 47 tag and publisher, null when unknown; isbns, each known edition's isbn13 with its isbn10, null for 979 ISBNs), plus stats with the organization's like, rating, view and review counts and avg_rating (null when unrated). links lists purchase and borrow links (see GET /out/{book_id}/{vendor}). version (also the ETag) is what PATCH /admin/books/{id} expects in If-Match. reading_hours estimates reading time from page_count at the configured words per minute. cover_url is the Open Library cover image, or null. With a translation matching Accept-Language (or DEFAULT_LANGUAGE), title, description and language come from it, and original_title and original_language keep the catalogue's. An old reference (a merged duplicate's slug or UUID, or a previous slug) answers 301 to the current one.
+// @Tags Books
+// @Produce json
+// @Param id path string true "Book slug or UUID"
+// @Param Accept-Language header string false "Preferred languages for translated metadata"
+// @Success 200 {object} map[string]interface{}
+// @Header 200 {string} ETag "Quoted book version, e.g. \"3\""
+// @Failure 404 {object} ErrorResponse
+// @Router /books/{id} [get]
+func GetBookHandler(c *gin.Context) {
+	id, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	writeBook(c, id)
+}
+
+// writeBook answers with the full record of the book with id, as
+// GET /books/{id} does
+func writeBook(c *gin.Context, id int) {
+	var year, pages, cover, authorID sql.NullInt64
+	var publicID, slug, title, formats string
+	var author, olKey, audience, subjectsJSON, lang, publisher sql.NullString
+	var warnings string
+	var version int
+	if err := db.QueryRowContext(c.Request.Context(), `
+		SELECT uuid, slug, title, author, author_id, published_year, open_library_key, subjects, formats, page_count, content_warnings, audience_rating, version, cover_id, language, publisher
+		FROM books WHERE id = ?`, id).
+		Scan(&publicID, &slug, &title, &author, &authorID, &year, &olKey, &subjectsJSON, &formats, &pages, &warnings, &audience, &version, &cover, &lang, &publisher); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	subjects := []string{}
+	if subjectsJSON.Valid && subjectsJSON.String != "" {
+		// malformed subjects are shown as none, as with ?include=genres
+		_ = json.Unmarshal([]byte(subjectsJSON.String), &subjects)
+	}
+	stats, err := records.BookStats(c.Request.Context(), id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	isbns, err := loadBookISBNs(c.Request.Context(), id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	book := gin.H{
+		"id":               id,
+		"uuid":             publicID,
+		"slug":             slug,
+		"title":            title,
+		"author":           author.String,
+		"author_id":        nullableInt(authorID),
+		"year":             year.Int64,
+		"open_library_key": nullableString(olKey),
+		"subjects":         subjects,
+		"formats":          splitFormats(formats),
+		"page_count":       nullableInt(pages),
+		"reading_hours":    readingHours(pages),
+		"language":         nullableString(lang),
+		"publisher":        nullableString(publisher),
+		"isbns":            isbns,
+		"content_warnings": contentwarnings.Split(warnings),
+		"audience_rating":  nullableString(audience),
+		"cover_url":        coverURL(cover),
+		"links":            bookLinks(linkBook{ID: id, UUID: publicID, Title: title, Author: author.String, OpenLibraryKey: olKey.String}),
+		"stats":            stats,
+		"version":          version,
+	}
+	if err := localizeBooks(c, []map[string]interface{}{book}); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	delete(book, "id")
+	c.Header("ETag", bookETag(version))
+	c.JSON(200, book)
+}
+
+// oppositeActions pairs the actions a reader can't both have on a book
+var oppositeActions = map[string]string{"like": "dislike", "dislike": "like"}
+
+// createInteractionRequest is the form or JSON body of POST /interactions
+type createInteractionRequest struct {
+	UserID     string `form:"user_id" json:"user_id" binding:"required"`
+	BookID     string `form:"book_id" json:"book_id" binding:"required"`
+	Action     string `form:"action" json:"action" binding:"required,oneof=like view rating dislike"`
+	Rating     *int   `form:"rating" json:"rating" binding:"omitempty,min=1,max=5"`
+	Visibility string `form:"visibility" json:"visibility" binding:"oneof=public private"`
+}
+
+func (r *createInteractionRequest) normalize() {
+	if r.Visibility == "" {
+		r.Visibility = "public"
+	}
+}
+
+// CreateInteractionHandler godoc
+// @Summary Record interaction
+// @Description The fields can be sent as a form or as a JSON object with the same names, e.g. {"user_id": "3f2c9a1e-8b7d-4c6a-9e5f-1d2b3c4a5e6f", "book_id": "the-hobbit", "action": "rating", "rating": 5}. A reader has one interaction per book and action: posting one they already have updates its rating and visibility and answers 200, and one they deleted is recorded again. A dislike keeps the book, and books like it, out of the reader's recommendations; liking a book takes back a dislike of it and the other way round.
+// @Tags Interactions
+// @Accept x-www-form-urlencoded,mpfd,json
+// @Produce json
+// @Param user_id formData string true "User UUID"
+// @Param book_id formData string true "Book UUID or slug"
+// @Param action formData string true "Action" Enums(like, view, rating, dislike)
+// @Param rating formData int false "Rating" minimum(1) maximum(5)
+// @Param visibility formData string false "public (default) shows likes and ratings in followers' feeds; private hides them"
+// @Success 200 {object} map[string]interface{}
+// @Success 201 {object} map[string]interface{}
+// @Header 201 {string} Location "/interactions/{uuid}"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /interactions [post]
+func CreateInteractionHandler(c *gin.Context) {
+	var req createInteractionRequest
+	if !bindRequest(c, &req) {
+		return
+	}
+	userID, bookID, action, visibility := req.UserID, req.BookID, req.Action, req.Visibility
+
+	// Enforce token user == form user_id (prevents spoofing)
+	authUserIDAny, exists := c.Get("auth_user_id")
+	if !exists {
+		abortWithError(c, unauthorized("unauthorized"))
+		return
+	}
+	authUserID, ok := authUserIDAny.(int)
+	if !ok {
+		abortWithError(c, unauthorized("unauthorized"))
+		return
+	}
+
+	uid, err := strconv.Atoi(userID)
+	if ids.IsUUID(userID) {
+		uid, err = resolveUserRef(c.Request.Context(), userID)
+	}
+	if err != nil || uid <= 0 {
+		abortWithError(c, badRequest("invalid user_id"))
+		return
+	}
+	if uid != authUserID {
+		abortWithError(c, forbidden("cannot create interaction for another user"))
+		return
+	}
+
+	bid, ok := resolveParam(c, resolveBookRef, bookID, "book")
+	if !ok {
+		return
+	}
+
+	var score sql.NullInt64
+	if req.Rating != nil {
+		score = sql.NullInt64{Int64: int64(*req.Rating), Valid: true}
+	}
+
+	// the interaction and the book's counters commit together
+	ctx := c.Request.Context()
+	orgID := tenant.ID(ctx)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// a reader has one row per book and action (migration 000047): posting
+	// it again updates that row, or brings it back if it was deleted
+	var interactionID int64
+	var previous sql.NullInt64
+	var deleted bool
+	execErr := tx.QueryRowContext(ctx, `
+		SELECT id, rating, deleted_at IS NOT NULL FROM interactions
+		WHERE user_id = ? AND book_id = ? AND action = ?
+		FOR UPDATE`, uid, bid, action).Scan(&interactionID, &previous, &deleted)
+	existing := execErr == nil
+	if errors.Is(execErr, sql.ErrNoRows) {
+		execErr = nil
+	}
+	created := !existing || deleted
+	if execErr == nil {
+		switch {
+		case !existing:
+			var res sql.Result
+			res, execErr = tx.ExecContext(ctx, `
+            INSERT INTO interactions (organization_id, user_id, book_id, action, rating, visibility)
+            VALUES (?, ?, ?, ?, ?, ?)`,
+				orgID, uid, bid, action, score, visibility)
+			if execErr == nil {
+				interactionID, _ = res.LastInsertId()
+			}
+		case deleted:
+			_, execErr = tx.ExecContext(ctx, `
+            UPDATE interactions
+            SET rating = ?, visibility = ?, created_at = CURRENT_TIMESTAMP, deleted_at = NULL, deleted_by = NULL
+            WHERE id = ?`,
+				score, visibility, interactionID)
+		default:
+			_, execErr = tx.ExecContext(ctx,
+				"UPDATE interactions SET rating = ?, visibility = ? WHERE id = ?", score, visibility, interactionID)
+		}
+	}
+	// liking a book takes back a dislike of it, and the other way round
+	var unliked bool
+	if opposite, ok := oppositeActions[action]; ok && execErr == nil {
+		var res sql.Result
+		res, execErr = tx.ExecContext(ctx, `
+            UPDATE interactions SET deleted_at = CURRENT_TIMESTAMP, deleted_by = ?
+            WHERE user_id = ? AND book_id = ? AND action = ? AND deleted_at IS NULL`,
+			uid, uid, bid, opposite)
+		if execErr == nil {
+			n, _ := res.RowsAffected()
+			unliked = n > 0 && opposite == "like"
+		}
+	}
+	switch {
+	case execErr != nil:
+	case unliked:
+		execErr = counters.Recount(ctx, tx, bid)
+	case created:
+		execErr = counters.Bump(ctx, tx, orgID, bid, action, score)
+	case previous != score:
+		// a re-rating replaces the old score in the book's totals
+		execErr = counters.Recount(ctx, tx, bid)
+	}
+	if execErr == nil {
+		execErr = tx.Commit()
+	}
+
+	if execErr != nil {
+		// the book was checked above, so this is a user deleted in between
+		if dberr.Is(execErr, dberr.ErrMissingReference) {
+			abortWithError(c, notFound("user or book not found"))
+			return
+		}
+		abortWithError(c, execErr)
+		return
+	}
+
+	forgetRecommendations(ctx, uid)
+	interaction, err := records.Interaction(c.Request.Context(), int(interactionID))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if !created {
+		c.JSON(200, interaction)
+		return
+	}
+
+	var ratingValue interface{}
+	if score.Valid {
+		ratingValue = int(score.Int64)
+	}
+	emitEvent(c.Request.Context(), EventInteractionCreated, map[string]interface{}{
+		"uuid":            interaction.UUID,
+		"user_id":         uid,
+		"user_uuid":       interaction.UserUUID,
+		"book_id":         bid,
+		"book_uuid":       interaction.BookUUID,
+		"action":          action,
+		"rating":          ratingValue,
+		"visibility":      visibility,
+		"organization_id": orgID,
+	})
+	c.Header("Location", fmt.Sprintf("/interactions/%s", interaction.UUID))
+	c.JSON(201, interaction)
+}
+
+// UserHistoryHandler godoc
+// @Summary Get user interaction history (newest first)
+// @Description Pass next_cursor back as cursor for the next page; it is null on the last page. since keeps interactions recorded at or after a time, so a client can sync incrementally by paging until next_cursor is null and passing the newest created_at it has seen next time (items at that exact time come again; dedupe by uuid).
+// @Tags Users
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID"
+// @Param limit query int false "Limit (max 100)" default(50)
+// @Param cursor query string false "Opaque cursor from a previous page"
+// @Param since query string false "Only interactions at or after this RFC 3339 time"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/history [get]
+func UserHistoryHandler(c *gin.Context) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+	if c.GetInt("auth_user_id") != userID && c.GetString("auth_role") != "admin" {
+		abortWithError(c, forbidden("cannot read another user's history"))
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	query := `
+        SELECT i.id, i.uuid, b.uuid, b.slug, i.action, i.rating, i.created_at,
+               b.title, b.author
+        FROM interactions i
+        JOIN books b ON b.id = i.book_id
+        WHERE i.user_id = ? AND i.deleted_at IS NULL AND b.deleted_at IS NULL`
+	args := []interface{}{userID}
+
+	if raw := strings.TrimSpace(c.Query("since")); raw != "" {
+		since, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			abortWithError(c, badRequest("since must be an RFC 3339 time, e.g. 2026-10-01T00:00:00Z"))
+			return
+		}
+		query += " AND i.created_at >= ?"
+		args = append(args, since)
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		before, beforeID, err := decodeKeysetCursor(cursor)
+		if err != nil {
+			abortWithError(c, badRequest("invalid cursor"))
+			return
+		}
+		query += " AND (i.created_at < ? OR (i.created_at = ? AND i.id < ?))"
+		args = append(args, before, before, beforeID)
+	}
+	// one extra row tells us whether there is another page
+	query += " ORDER BY i.created_at DESC, i.id DESC LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := db.QueryContext(c.Request.Context(), query, args...)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	history := []map[string]interface{}{}
+	var lastCreated time.Time
+	var lastID int
+	hasMore := false
+	for rows.Next() {
+		if len(history) == limit {
+			hasMore = true
+			break
+		}
+		var id int
+		var publicID, bookUUID, slug, action string
+		var rating sql.NullInt64
+		var createdAt time.Time
+		var title string
+		var author sql.NullString
+
+		if err := rows.Scan(&id, &publicID, &bookUUID, &slug, &action, &rating, &createdAt, &title, &author); err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		var ratingValue interface{}
+		if rating.Valid {
+			ratingValue = rating.Int64
+		}
+
+		history = append(history, gin.H{
+			"uuid":       publicID,
+			"book_uuid":  bookUUID,
+			"slug":       slug,
+			"title":      title,
+			"author":     author.String,
+			"action":     action,
+			"rating":     ratingValue,
+			"created_at": createdAt,
+		})
+		lastCreated, lastID = createdAt, id
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	var next interface{}
+	if hasMore {
+		next = encodeKeysetCursor(lastCreated, lastID)
+	}
+	c.JSON(200, gin.H{
+		"limit":       limit,
+		"data":        history,
+		"next_cursor": next,
+	})
+}
+
+// RecommendationsHandler godoc
+// @Summary Get recommended books for a user
+// @Description Each book comes with a reason: the user's book it's most tied to (co_liked: the one the most readers enjoyed along with it; subjects, under mode=content: the liked book whose subjects are closest, and the ones they share; popular, under mode=hybrid, when neither applies) and a text such as "Because you liked The Hobbit", or null when nothing explains it. With REDIS_URL set, each user's recommendations are cached for RECOMMENDATIONS_CACHE_SECONDS (default 300) and dropped when they record or delete an interaction or change their content preferences.
+// @Tags Recommendations
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_id path string true "User UUID"
+// @Param format query string false "Comma-separated formats (print, ebook, audiobook); only books in one of them"
+// @Param min_pages query int false "Only books with at least this many pages"
+// @Param max_pages query int false "Only books with at most this many pages"
+// @Param genre query string false "Only books in this genre (a slug from GET /genres)"
+// @Param mode query string false "collaborative (default): books liked by readers who share your likes | content: books whose subjects resemble the ones you liked | authors: more by the authors whose books you liked | hybrid: collaborative, content and organization-wide popularity scores blended, so readers with few or no likes still get recommendations"
+// @Param w_collaborative query number false "hybrid: weight of the collaborative score (default HYBRID_WEIGHT_COLLABORATIVE, 0.6)"
+// @Param w_content query number false "hybrid: weight of the content score (default HYBRID_WEIGHT_CONTENT, 0.3)"
+// @Param w_popularity query number false "hybrid: weight of the popularity score (default HYBRID_WEIGHT_POPULARITY, 0.1)"
+// @Success 200 {array} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /recommendations/{user_id} [get]
+func RecommendationsHandler(c *gin.Context) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("user_id"), "user")
+	if !ok {
+		return
+	}
+	if c.GetInt("auth_user_id") != userID && c.GetString("auth_role") != "admin" {
+		abortWithError(c, forbidden("cannot read another user's recommendations"))
+		return
+	}
+	filters, err := parseBookFilters(c)
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+
+	mode, err := parseRecommendMode(c.Query("mode"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	weights := defaultHybridWeights
+	if mode == recommendHybrid {
+		if weights, err = parseHybridWeights(c); err != nil {
+			abortWithError(c, badRequest(err.Error()))
+			return
+		}
+	}
+
+	recs, err := cachedRecommendations(c.Request.Context(), userID, mode, filters, weights)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if len(recs) == 0 {
+		c.JSON(200, gin.H{"message": "No recommendations yet — like a few books first!"})
+		return
+	}
+
+	c.JSON(200, recs)
+}
+
+// querier runs reads on a *sql.DB or inside a *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// rowExists reports whether query returns a row
+func rowExists(ctx context.Context, query string, args ...interface{}) (bool, error) {
+	var one int
+	err := db.QueryRowContext(ctx, query, args...).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// loadRecommendations returns userID's top 10 books enjoyed by the readers
+// who share most of their likes and high ratings (recommend.ScoresSQL),
+// skipping anything they've already interacted with or shelved.
+// filters narrows the candidates (format, length); the user's content
+// preferences are applied on top.
+func loadRecommendations(ctx context.Context, q querier, userID int, filters bookFilters) ([]gin.H, error) {
+	prefs, err := loadContentPreferences(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	filters.avoidWarnings, filters.maxAudience = prefs.AvoidWarnings, prefs.MaxAudienceRating
+	filterSQL, filterArgs := filters.sql("b")
+	query := `
+        SELECT b.id, b.uuid, b.slug, b.title, b.author, b.page_count, s.score, b.cover_id
+        FROM (` + recommend.ScoresSQL + `) s
+        JOIN books b ON b.id = s.book_id
+        WHERE b.deleted_at IS NULL` + filterSQL + `
+        ORDER BY s.score DESC, s.strongest DESC, b.id
+        LIMIT 10;
+    `
+	rows, err := q.QueryContext(ctx, query, append(recommend.Args(userID), filterArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	recs := []gin.H{}
+	for rows.Next() {
+		var id, score int
+		var publicID, slug, title string
+		var author sql.NullString
+		var pages, cover sql.NullInt64
+		if err := rows.Scan(&id, &publicID, &slug, &title, &author, &pages, &score, &cover); err != nil {
+			return nil, err
+		}
+		recs = append(recs, gin.H{
+			"book_id":       id,
+			"book_uuid":     publicID,
+			"slug":          slug,
+			"title":         title,
+			"author":        author.String,
+			"page_count":    nullableInt(pages),
+			"reading_hours": readingHours(pages),
+			"cover_url":     coverURL(cover),
+			"score":         score,
+		})
+	}
+	return recs, rows.Err()
+}
+
+// bookCursor is where a page of GET /books/search ended: the last book's
+// sort key and id, under the sort it was for
+type bookCursor struct {
+	Sort string
+	Key  float64
+	ID   int
+}
+
+func (c bookCursor) encode() string {
+	raw := c.Sort + "|" + strconv.FormatFloat(c.Key, 'g', -1, 64) + "|" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeBookCursor(cursor string) (bookCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return bookCursor{}, err
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return bookCursor{}, fmt.Errorf("malformed cursor")
+	}
+	key, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return bookCursor{}, err
+	}
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return bookCursor{}, err
+	}
+	return bookCursor{Sort: parts[0], Key: key, ID: id}, nil
+}
+
+// SearchBooksHandler godoc
+// @Summary Search books (filters + pagination)
+// @Description Page with page and limit, or pass next_cursor back as cursor with the same query, filters and sort: it continues after the page's last book, so deep pages stay fast. next_cursor is null on the last page and page is null when a cursor was given.
+// @Tags Books
+// @Produce json
+// @Param q query string false "Full-text query over title, author and subjects"
+// @Param author query string false "Author filter (partial match)"
+// @Param year_from query int false "Published year from"
+// @Param year_to query int false "Published year to"
+// @Param format query string false "Comma-separated formats (print, ebook, audiobook); books in any of them"
+// @Param min_pages query int false "Only books with at least this many pages"
+// @Param max_pages query int false "Only books with at most this many pages"
+// @Param genre query string false "Only books in this genre (a slug from GET /genres)"
+// @Param sort query string false "Sort: year | popularity | relevance (default relevance: full-text relevance blended with likes and ratings, weighted by SEARCH_POPULARITY_WEIGHT); newest and popular are accepted for year and popularity"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Param cursor query string false "Opaque cursor from a previous page of the same search; page is ignored"
+// @Param include query string false "Comma-separated expansions: author, genres, avg_rating, links"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /books/search [get]
+func SearchBooksHandler(c *gin.Context) {
+	includes, err := parseIncludes(c.Query("include"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	filters, err := parseBookFilters(c)
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+	filterSQL, filterArgs := filters.sql("b")
+
+	q := strings.TrimSpace(c.Query("q"))
+	author := strings.TrimSpace(c.Query("author"))
+	sort := strings.TrimSpace(c.DefaultQuery("sort", "relevance"))
+	switch sort {
+	case "newest":
+		sort = "year"
+	case "popular":
+		sort = "popularity"
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	yearFromStr := strings.TrimSpace(c.Query("year_from"))
+	yearToStr := strings.TrimSpace(c.Query("year_to"))
+	yearFrom, _ := strconv.Atoi(yearFromStr)
+	yearTo, _ := strconv.Atoi(yearToStr)
+
+	var after *bookCursor
+	if raw := c.Query("cursor"); raw != "" {
+		cur, err := decodeBookCursor(raw)
+		if err != nil {
+			abortWithError(c, badRequest("invalid cursor"))
+			return
+		}
+		if cur.Sort != sort {
+			abortWithError(c, badRequest("cursor is from a search with another sort"))
+			return
+		}
+		after = &cur
+	}
+
+	orgID := tenant.ID(c.Request.Context())
+
+	// every sort orders by a key, newest or highest first, then by id, which
+	// also positions a cursor among books with the same key
+	var keySQL, joinSQL string
+	var keyArgs, joinArgs []interface{}
+	switch sort {
+	case "year":
+		keySQL = "COALESCE(b.published_year, 0)"
+	case "popularity":
+		keySQL = "COUNT(i.id)"
+		joinSQL = `
+			LEFT JOIN interactions i
+				ON i.book_id = b.id AND i.action = 'like' AND i.organization_id = ? AND i.deleted_at IS NULL`
+		joinArgs = []interface{}{orgID}
+	default:
+		keySQL, keyArgs = relevanceSQL(q, orgID)
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(`
+		SELECT b.id, b.uuid, b.slug, b.title, b.author, b.published_year, b.formats, b.page_count, b.content_warnings, b.audience_rating, ` + keySQL + ` AS sort_key, b.cover_id, b.language, b.publisher
+		FROM books b` + joinSQL + `
+		WHERE ` + tenant.BooksVisibleSQL("b") + ` AND b.merged_into IS NULL
+	`)
+	args := append([]interface{}{}, keyArgs...)
+	args = append(args, joinArgs...)
+	args = append(args, orgID)
+
+	// Filters
+	if q != "" {
+		sb.WriteString(" AND " + searchMatchSQL)
+		args = append(args, q)
+	}
+	if author != "" {
+		sb.WriteString(" AND b.author LIKE ?")
+		args = append(args, "%"+author+"%")
+	}
+	if yearFromStr != "" && yearFrom > 0 {
+		sb.WriteString(" AND b.published_year >= ?")
+		args = append(args, yearFrom)
+	}
+	if yearToStr != "" && yearTo > 0 {
+		sb.WriteString(" AND b.published_year <= ?")
+		args = append(args, yearTo)
+	}
+	sb.WriteString(filterSQL)
+	args = append(args, filterArgs...)
+
+	// Keyset: the books after the cursor's
+	afterSQL := "(" + keySQL + " < ? OR (" + keySQL + " = ? AND b.id < ?))"
+	if sort == "popularity" {
+		sb.WriteString(" GROUP BY b.id, b.uuid, b.slug, b.title, b.author, b.published_year, b.formats, b.page_count, b.content_warnings, b.audience_rating, b.cover_id, b.language, b.publisher")
+		if after != nil {
+			sb.WriteString(" HAVING " + afterSQL)
+		}
+	} else if after != nil {
+		sb.WriteString(" AND " + afterSQL)
+	}
+	if after != nil {
+		args = append(args, keyArgs...)
+		args = append(args, after.Key)
+		args = append(args, keyArgs...)
+		args = append(args, after.Key, after.ID)
+		offset = 0
+	}
+	sb.WriteString(" ORDER BY sort_key DESC, b.id DESC")
+
+	// Pagination; one extra row tells us whether there is another page
+	sb.WriteString(" LIMIT ? OFFSET ?")
+	args = append(args, limit+1, offset)
+
+	rows, err := db.Query(sb.String(), args...)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	data := []map[string]interface{}{}
+	var next interface{}
+	var last bookCursor
+	for rows.Next() {
+		var id int
+		var publicID, slug, title, available, warnings string
+		var author sql.NullString
+		var year, pages, cover sql.NullInt64
+		var audience, lang, publisher sql.NullString
+		var key float64
+		if err := rows.Scan(&id, &publicID, &slug, &title, &author, &year, &available, &pages, &warnings, &audience, &key, &cover, &lang, &publisher); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if len(data) == limit {
+			next = last.encode()
+			break
+		}
+		last = bookCursor{Sort: sort, Key: key, ID: id}
+		book := gin.H{
+			"id":               id,
+			"uuid":             publicID,
+			"slug":             slug,
+			"title":            title,
+			"author":           author.String,
+			"year":             year.Int64,
+			"formats":          splitFormats(available),
+			"page_count":       nullableInt(pages),
+			"reading_hours":    readingHours(pages),
+			"language":         nullableString(lang),
+			"publisher":        nullableString(publisher),
+			"content_warnings": contentwarnings.Split(warnings),
+			"audience_rating":  nullableString(audience),
+			"cover_url":        coverURL(cover),
+		}
+		if sort == "popularity" {
+			book["likes"] = int(key)
+		}
+		data = append(data, book)
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	if err := applyIncludes(c.Request.Context(), data, includes); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := localizeBooks(c, data); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	hideInternalIDs(data, "id")
+
+	var pageOut interface{} = page
+	if after != nil {
+		pageOut = nil
+	}
+	c.JSON(200, gin.H{
+		"page":        pageOut,
+		"limit":       limit,
+		"sort":        sort,
+		"data":        data,
+		"next_cursor": next,
+	})
+}
@@ -0,0 +1,510 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+)
+
+func setupRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	// minimal routes to test
+	r.GET("/healthz", HealthHandler)
+	r.GET("/stats", StatsHandler)
+	r.GET("/books", ListBooksHandler)
+	r.GET("/books/search", SearchBooksHandler)
+
+	return r
+}
+
+func TestHealthHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(12, false))
+	// a database that can't be read is still reported, without a version
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+		WillReturnError(errors.New("connection refused"))
+
+	r := setupRouter()
+	for _, want := range []any{float64(12), nil} {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+
+		var body map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid json: %v", err)
+		}
+
+		if body["status"] != "ok" {
+			t.Fatalf("expected status=ok, got %v", body["status"])
+		}
+		if body["schema_version"] != want || body["schema_dirty"] != false || body["schema_latest"] != float64(latestMigration) || latestMigration == 0 {
+			t.Fatalf("expected schema version %v of %d, got %v", want, latestMigration, body)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestListBooksHandler(t *testing.T) {
+	// mock DB
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// Expect list query with limit+offset args
+	mock.ExpectQuery("SELECT id, uuid, slug, title, author, published_year, formats, page_count, content_warnings, audience_rating, cover_id, language, publisher\\s+FROM books").
+		WithArgs(1, 3, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "published_year", "formats", "page_count", "content_warnings", "audience_rating", "cover_id", "language", "publisher"}).
+			AddRow(1, "b-1", "book-a-b1", "Book A", "Author A", 2001, "print", 320, "", nil, 6979861, nil, nil).
+			AddRow(2, "b-2", "book-b-b2", "Book B", "Author B", 2002, "print,ebook", nil, "", nil, nil, nil, nil))
+
+	r := setupRouter()
+	req := httptest.NewRequest(http.MethodGet, "/books?page=1&limit=2", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+	// books without a cover ID have no cover
+	if !strings.Contains(w.Body.String(), `"cover_url":"https://covers.openlibrary.org/b/id/6979861-M.jpg"`) ||
+		!strings.Contains(w.Body.String(), `"cover_url":null`) {
+		t.Fatalf("unexpected covers: %s", w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestSearchBooksHandler_Relevance(t *testing.T) {
+	// mock DB
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// its relevance plus popularity, the full-text filter, then limit + offset
+	mock.ExpectQuery("SELECT .+MATCH.+FROM book_counters bc.+AS sort_key, b.cover_id, b.language, b.publisher\\s+FROM books b.+AND MATCH\\(b.title, b.author, b.subjects_text\\) AGAINST \\(\\? IN NATURAL LANGUAGE MODE\\) ORDER BY sort_key DESC, b.id DESC LIMIT").
+		WithArgs("harry", searchPopularityWeight, 1, 1, "harry", 6, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "published_year", "formats", "page_count", "content_warnings", "audience_rating", "sort_key", "cover_id", "language", "publisher"}).
+			AddRow(10, "b-10", "harry-something-b10", "Harry Something", "Some Author", 2000, "audiobook", nil, "", nil, 1.5, nil, nil, nil))
+
+	r := setupRouter()
+	req := httptest.NewRequest(http.MethodGet, "/books/search?q=harry&page=1&limit=5", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestSearchBooksHandler_SortAliases(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("COUNT\\(i.id\\) AS sort_key.+AND MATCH\\(b.title, b.author, b.subjects_text\\) AGAINST.+ORDER BY sort_key DESC").
+		WithArgs(1, 1, "dragons", 21, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "published_year", "formats", "page_count", "content_warnings", "audience_rating", "sort_key", "cover_id", "language", "publisher"}).
+			AddRow(3, "b-3", "the-hobbit-b3", "The Hobbit", "J.R.R. Tolkien", 1937, "print", 310, "", nil, 12, nil, nil, nil))
+	mock.ExpectQuery("COALESCE\\(b.published_year, 0\\) AS sort_key.+AND MATCH\\(b.title, b.author, b.subjects_text\\) AGAINST.+ORDER BY sort_key DESC").
+		WithArgs(1, "dragons", 21, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "published_year", "formats", "page_count", "content_warnings", "audience_rating", "sort_key", "cover_id", "language", "publisher"}))
+
+	r := setupRouter()
+	// popular and newest predate popularity and year
+	for _, sort := range []string{"popular", "year"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/search?q=dragons&sort="+sort, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("sort=%s: expected 200, got %d body=%s", sort, w.Code, w.Body.String())
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestListBooksHandler_NullColumns(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// ingested books can lack an author and a year
+	mock.ExpectQuery("SELECT id, uuid, slug, title, author, published_year").
+		WithArgs(1, 21, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "published_year", "formats", "page_count", "content_warnings", "audience_rating", "cover_id", "language", "publisher"}).
+			AddRow(1, "b-1", "anonymous-b1", "Anonymous", nil, nil, "print", nil, "", nil, nil, nil, nil))
+
+	r := setupRouter()
+	req := httptest.NewRequest(http.MethodGet, "/books", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data []map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(body.Data) != 1 || body.Data[0]["author"] != "" || body.Data[0]["year"] != float64(0) {
+		t.Fatalf("expected the book with empty author and year, got %v", body.Data)
+	}
+}
+
+func TestListBooksHandler_RowError(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// the connection drops after the first row: the response must not be a
+	// silently truncated page
+	mock.ExpectQuery("SELECT id, uuid, slug, title, author, published_year").
+		WithArgs(1, 21, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "published_year", "formats", "page_count", "content_warnings", "audience_rating", "cover_id", "language", "publisher"}).
+			AddRow(1, "b-1", "book-a-b1", "Book A", "Author A", 2001, "print", nil, "", nil, nil, nil, nil).
+			AddRow(2, "b-2", "book-b-b2", "Book B", "Author B", 2002, "print", nil, "", nil, nil, nil, nil).
+			RowError(1, errors.New("connection reset")))
+
+	r := setupRouter()
+	req := httptest.NewRequest(http.MethodGet, "/books", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d body=%s", w.Code, w.Body.String())
+	}
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	// the cause is logged, not sent
+	if body["code"] != "internal_server_error" || strings.Contains(w.Body.String(), "connection reset") {
+		t.Fatalf("expected a generic 500 envelope, got %v", body)
+	}
+}
+
+func TestPopularBooksHandler_NullAuthor(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	popularBooks = &popularCache{entries: map[popularQuery]popularEntry{}}
+
+	mock.ExpectQuery("SELECT b.id, b.uuid, b.slug, b.title, b.author, bc.likes, b.cover_id\\s+FROM book_counters").
+		WithArgs(1, popularDefaultLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "likes", "cover_id"}).
+			AddRow(3, "b-3", "anonymous-b3", "Anonymous", nil, 7, nil))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/popular", PopularBooksHandler)
+	req := httptest.NewRequest(http.MethodGet, "/books/popular", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+// Ensure db is treated as *sql.DB even when mocked
+var _ *sql.DB = db
+
+func TestUpdateUserHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectUser := func() {
+		mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+			WithArgs(5, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}
+	// someone else's account, then nothing to change, then a blank handle
+	expectUser()
+	expectUser()
+	expectUser()
+	// a new handle; the email is kept
+	expectUser()
+	mock.ExpectBegin()
+	expectAuditSnapshot(mock, "user", `{"id": 5, "handle": "ann"}`)
+	mock.ExpectExec("UPDATE users SET email = COALESCE\\(\\?, email\\), handle = COALESCE\\(\\?, handle\\) WHERE id = \\?").
+		WithArgs(nil, "annie", 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, AuditUserUpdate, "user", 5)
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT uuid, email, handle, role, created_at FROM users WHERE id = \\?").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "email", "handle", "role", "created_at"}).
+			AddRow("u-5", "a@example.com", "annie", "user", "2026-01-01 00:00:00"))
+	// an email another account has
+	expectUser()
+	mock.ExpectBegin()
+	expectAuditSnapshot(mock, "user", `{"id": 5}`)
+	mock.ExpectExec("UPDATE users SET email").
+		WithArgs("b@example.com", nil, 5).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectRollback()
+
+	gin.SetMode(gin.TestMode)
+	for _, tc := range []struct {
+		caller int
+		body   string
+		want   int
+	}{
+		{caller: 9, body: `{"handle": "annie"}`, want: http.StatusForbidden},
+		{caller: 5, body: `{}`, want: http.StatusBadRequest},
+		{caller: 5, body: `{"handle": "  "}`, want: http.StatusBadRequest},
+		{caller: 5, body: `{"handle": " annie "}`, want: http.StatusOK},
+		{caller: 5, body: `{"email": "b@example.com"}`, want: http.StatusConflict},
+	} {
+		r := gin.New()
+		r.PATCH("/users/:id", asUser(tc.caller), UpdateUserHandler)
+		req := httptest.NewRequest(http.MethodPatch, "/users/5", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Fatalf("%d %s: expected %d, got %d: %s", tc.caller, tc.body, tc.want, w.Code, w.Body.String())
+		}
+		if tc.want == http.StatusOK && !strings.Contains(w.Body.String(), `"handle":"annie"`) {
+			t.Fatalf("expected the updated user, got %s", w.Body.String())
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestListUsersHandler_Pages(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	columns := []string{"id", "uuid", "email", "handle", "created_at"}
+	// page 2 of 2 per page: the extra row means there's more
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM users WHERE organization_id = \\? AND deleted_at IS NULL").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectQuery("SELECT id, uuid, email, handle, created_at FROM users WHERE organization_id = \\? AND deleted_at IS NULL ORDER BY id LIMIT \\? OFFSET \\?").
+		WithArgs(1, 3, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(3, "u-3", "c@example.com", "cat", "2026-01-03").
+			AddRow(4, "u-4", "d@example.com", "dan", "2026-01-04").
+			AddRow(5, "u-5", "e@example.com", "eve", "2026-01-05"))
+	// continuing from that page's cursor, which reaches the end
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM users").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectQuery("SELECT id, uuid, email, handle, created_at FROM users WHERE organization_id = \\? AND deleted_at IS NULL AND id > \\? ORDER BY id").
+		WithArgs(1, 4, 3, 0).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(5, "u-5", "e@example.com", "eve", "2026-01-05"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users", ListUsersHandler)
+	get := func(target string) map[string]interface{} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", target, w.Code, w.Body.String())
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return body
+	}
+
+	first := get("/users?page=2&limit=2")
+	cursor, _ := first["next_cursor"].(string)
+	if first["total"] != float64(5) || first["page"] != float64(2) || len(first["data"].([]interface{})) != 2 || cursor == "" {
+		t.Fatalf("unexpected page %v", first)
+	}
+	rest := get("/users?limit=2&cursor=" + cursor)
+	if rest["page"] != nil || rest["next_cursor"] != nil || len(rest["data"].([]interface{})) != 1 {
+		t.Fatalf("unexpected last page %v", rest)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users?cursor=%21%21", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad cursor, got %d", w.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestListBooksHandler_Cursor(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	columns := []string{"id", "uuid", "slug", "title", "author", "published_year", "formats", "page_count", "content_warnings", "audience_rating", "cover_id", "language", "publisher"}
+	mock.ExpectQuery("FROM books\\s+WHERE .* AND merged_into IS NULL\\s+ORDER BY id").
+		WithArgs(1, 2, 0).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "b-1", "a-b1", "A", "X", 2001, "print", nil, "", nil, nil, nil, nil).
+			AddRow(7, "b-7", "b-b7", "B", "Y", 2002, "print", nil, "", nil, nil, nil, nil))
+	mock.ExpectQuery("FROM books\\s+WHERE .* AND merged_into IS NULL AND id > \\?\\s+ORDER BY id").
+		WithArgs(1, 1, 2, 0).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(7, "b-7", "b-b7", "B", "Y", 2002, "print", nil, "", nil, nil, nil, nil))
+
+	r := setupRouter()
+	get := func(target string) map[string]interface{} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", target, w.Code, w.Body.String())
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return body
+	}
+	first := get("/books?limit=1")
+	cursor, _ := first["next_cursor"].(string)
+	if cursor == "" || len(first["data"].([]interface{})) != 1 {
+		t.Fatalf("unexpected first page %v", first)
+	}
+	rest := get("/books?limit=1&page=5&cursor=" + cursor)
+	if rest["page"] != nil || rest["next_cursor"] != nil || len(rest["data"].([]interface{})) != 1 {
+		t.Fatalf("unexpected last page %v", rest)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestSearchBooksHandler_Cursor(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	columns := []string{"id", "uuid", "slug", "title", "author", "published_year", "formats", "page_count", "content_warnings", "audience_rating", "sort_key", "cover_id", "language", "publisher"}
+	// popularity continues among the grouped rows
+	mock.ExpectQuery("GROUP BY .+ HAVING \\(COUNT\\(i.id\\) < \\? OR \\(COUNT\\(i.id\\) = \\? AND b.id < \\?\\)\\) ORDER BY sort_key DESC, b.id DESC").
+		WithArgs(1, 1, float64(12), float64(12), 3, 21, 0).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(2, "b-2", "b-b2", "B", "Y", 1950, "print", nil, "", nil, 4, nil, nil, nil))
+	// year continues in the WHERE clause
+	mock.ExpectQuery("AND \\(COALESCE\\(b.published_year, 0\\) < \\? OR \\(COALESCE\\(b.published_year, 0\\) = \\? AND b.id < \\?\\)\\) ORDER BY sort_key DESC").
+		WithArgs(1, float64(1937), float64(1937), 3, 21, 0).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	r := setupRouter()
+	for _, tc := range []struct {
+		sort string
+		key  float64
+	}{{"popularity", 12}, {"year", 1937}} {
+		cursor := bookCursor{Sort: tc.sort, Key: tc.key, ID: 3}.encode()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/search?sort="+tc.sort+"&cursor="+cursor, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("sort=%s: expected 200, got %d: %s", tc.sort, w.Code, w.Body.String())
+		}
+		if tc.sort == "popularity" && !strings.Contains(w.Body.String(), `"likes":4`) {
+			t.Fatalf("expected likes from the sort key, got %s", w.Body.String())
+		}
+	}
+
+	// a cursor only continues the sort it came from
+	w := httptest.NewRecorder()
+	cursor := bookCursor{Sort: "year", Key: 1937, ID: 3}.encode()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/search?sort=popularity&cursor="+cursor, nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestBookCursorRoundTrip(t *testing.T) {
+	want := bookCursor{Sort: "relevance", Key: 2.0794415416798357, ID: 42}
+	got, err := decodeBookCursor(want.encode())
+	if err != nil || got != want {
+		t.Fatalf("decodeBookCursor = %+v, %v; want %+v", got, err, want)
+	}
+	for _, bad := range []string{"!!", "cmVsZXZhbmNl", "eWVhcnx4fDE"} {
+		if _, err := decodeBookCursor(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
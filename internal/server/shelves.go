@@ -0,0 +1,290 @@
+package server
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// shelves are a reader's reading statuses, in reading order
+var shelves = []string{"want-to-read", "reading", "read"}
+
+func validShelf(shelf string) bool {
+	for _, s := range shelves {
+		if s == shelf {
+			return true
+		}
+	}
+	return false
+}
+
+// shelfOwner resolves :id and checks it is the caller, or an admin when
+// readOnly is set; it also checks :shelf when there is one
+func shelfOwner(c *gin.Context, readOnly bool) (int, bool) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return 0, false
+	}
+	if c.GetInt("auth_user_id") != userID && !(readOnly && c.GetString("auth_role") == "admin") {
+		abortWithError(c, forbidden("cannot access another user's shelves"))
+		return 0, false
+	}
+	if shelf := c.Param("shelf"); shelf != "" && !validShelf(shelf) {
+		abortWithError(c, badRequest("shelf must be want-to-read, reading or read"))
+		return 0, false
+	}
+	return userID, true
+}
+
+// ShelveBookHandler godoc
+// @Summary Put a book on one of your shelves
+// @Description A book is on at most one of your shelves, so this also moves it from the one it was on. Shelved books, like those you've interacted with, are left out of your recommendations.
+// @Tags Shelves
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID; must be the caller"
+// @Param shelf path string true "Shelf" Enums(want-to-read, reading, read)
+// @Param book_id path string true "Book slug or UUID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/shelves/{shelf}/books/{book_id} [put]
+func ShelveBookHandler(c *gin.Context) {
+	userID, ok := shelfOwner(c, false)
+	if !ok {
+		return
+	}
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("book_id"), "book")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	shelf := c.Param("shelf")
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO shelf_books (user_id, book_id, shelf)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE shelf = VALUES(shelf)`, userID, bookID, shelf); err != nil {
+		if dberr.Is(err, dberr.ErrMissingReference) {
+			abortWithError(c, notFound("book not found"))
+			return
+		}
+		abortWithError(c, err)
+		return
+	}
+	forgetRecommendations(ctx, userID)
+	userUUID, err := publicRef(ctx, db, "users", userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	bookUUID, err := publicRef(ctx, db, "books", bookID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"user_uuid": userUUID,
+		"book_uuid": bookUUID,
+		"shelf":     shelf,
+	})
+}
+
+// UnshelveBookHandler godoc
+// @Summary Take a book off one of your shelves
+// @Tags Shelves
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID; must be the caller"
+// @Param shelf path string true "Shelf" Enums(want-to-read, reading, read)
+// @Param book_id path string true "Book slug or UUID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/shelves/{shelf}/books/{book_id} [delete]
+func UnshelveBookHandler(c *gin.Context) {
+	userID, ok := shelfOwner(c, false)
+	if !ok {
+		return
+	}
+	bookID, ok := resolveParam(c, resolveBookRef, c.Param("book_id"), "book")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	res, err := db.ExecContext(ctx,
+		"DELETE FROM shelf_books WHERE user_id = ? AND book_id = ? AND shelf = ?", userID, bookID, c.Param("shelf"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		abortWithError(c, notFound("book is not on this shelf"))
+		return
+	}
+	forgetRecommendations(ctx, userID)
+	c.Status(204)
+}
+
+// ListShelvesHandler godoc
+// @Summary A user's shelves
+// @Description Every shelf, in reading order, with how many books are on it. The user's own, or any user's for admins.
+// @Tags Shelves
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/shelves [get]
+func ListShelvesHandler(c *gin.Context) {
+	userID, ok := shelfOwner(c, true)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	rows, err := db.QueryContext(ctx, `
+		SELECT s.shelf, COUNT(*)
+		FROM shelf_books s
+		JOIN books b ON b.id = s.book_id
+		WHERE s.user_id = ? AND `+tenant.BooksVisibleSQL("b")+`
+		GROUP BY s.shelf`, userID, tenant.ID(ctx))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var shelf string
+		var n int
+		if err := rows.Scan(&shelf, &n); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		counts[shelf] = n
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	data := make([]gin.H, 0, len(shelves))
+	for _, shelf := range shelves {
+		data = append(data, gin.H{"shelf": shelf, "books": counts[shelf]})
+	}
+	c.JSON(200, gin.H{"data": data})
+}
+
+// ListShelfBooksHandler godoc
+// @Summary The books on one of a user's shelves (most recently shelved first)
+// @Description The user's own, or any user's for admins.
+// @Tags Shelves
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID"
+// @Param shelf path string true "Shelf" Enums(want-to-read, reading, read)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/shelves/{shelf} [get]
+func ListShelfBooksHandler(c *gin.Context) {
+	userID, ok := shelfOwner(c, true)
+	if !ok {
+		return
+	}
+	shelf := c.Param("shelf")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	orgID := tenant.ID(ctx)
+	var total int
+	if err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM shelf_books s
+		JOIN books b ON b.id = s.book_id
+		WHERE s.user_id = ? AND s.shelf = ? AND `+tenant.BooksVisibleSQL("b"),
+		userID, shelf, orgID).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT b.id, b.uuid, b.slug, b.title, b.author, b.page_count, b.cover_id, s.updated_at
+		FROM shelf_books s
+		JOIN books b ON b.id = s.book_id
+		WHERE s.user_id = ? AND s.shelf = ? AND `+tenant.BooksVisibleSQL("b")+`
+		ORDER BY s.updated_at DESC, b.id DESC
+		LIMIT ? OFFSET ?`, userID, shelf, orgID, limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	books := []map[string]interface{}{}
+	for rows.Next() {
+		var id int
+		var publicID, slug, title string
+		var author sql.NullString
+		var pages, cover sql.NullInt64
+		var shelvedAt time.Time
+		if err := rows.Scan(&id, &publicID, &slug, &title, &author, &pages, &cover, &shelvedAt); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		books = append(books, gin.H{
+			"id":            id,
+			"uuid":          publicID,
+			"slug":          slug,
+			"title":         title,
+			"author":        nullableString(author),
+			"page_count":    nullableInt(pages),
+			"reading_hours": readingHours(pages),
+			"cover_url":     coverURL(cover),
+			"shelved_at":    shelvedAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := localizeBooks(c, books); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	hideInternalIDs(books, "id")
+
+	c.JSON(200, gin.H{
+		"shelf": shelf,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  books,
+	})
+}
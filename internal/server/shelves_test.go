@@ -0,0 +1,174 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func shelvesRouter(userID int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorMiddleware())
+	r.GET("/users/:id/shelves", asUser(userID), ListShelvesHandler)
+	r.GET("/users/:id/shelves/:shelf", asUser(userID), ListShelfBooksHandler)
+	r.PUT("/users/:id/shelves/:shelf/books/:book_id", asUser(userID), ShelveBookHandler)
+	r.DELETE("/users/:id/shelves/:shelf/books/:book_id", asUser(userID), UnshelveBookHandler)
+	return r
+}
+
+func TestShelveBookHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO shelf_books \\(user_id, book_id, shelf\\)\\s+VALUES \\(\\?, \\?, \\?\\)\\s+ON DUPLICATE KEY UPDATE shelf = VALUES\\(shelf\\)").
+		WithArgs(2, 7, "reading").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	expectPublicRef(mock, "users", 2, "u-2")
+	expectPublicRef(mock, "books", 7, "b-7")
+	// an unknown shelf, and someone else's
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	r := shelvesRouter(2)
+	put := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, target, nil))
+		return w
+	}
+
+	w := put("/users/2/shelves/reading/books/7")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"book_uuid":"b-7"`) {
+		t.Fatalf("expected 200 on the reading shelf, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := put("/users/2/shelves/abandoned/books/7"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown shelf, got %d", w.Code)
+	}
+	if w := put("/users/3/shelves/read/books/7"); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another user, got %d", w.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestUnshelveBookHandler_NotOnShelf(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectExec("DELETE FROM shelf_books WHERE user_id = \\? AND book_id = \\? AND shelf = \\?").
+		WithArgs(2, 7, "read").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	w := httptest.NewRecorder()
+	shelvesRouter(2).ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/2/shelves/read/books/7", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestListShelvesHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT s.shelf, COUNT\\(\\*\\)\\s+FROM shelf_books s").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"shelf", "count"}).AddRow("read", 4).AddRow("want-to-read", 2))
+
+	w := httptest.NewRecorder()
+	shelvesRouter(2).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/2/shelves", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	// every shelf, empty ones included, in reading order
+	want := `{"data":[{"books":2,"shelf":"want-to-read"},{"books":0,"shelf":"reading"},{"books":4,"shelf":"read"}]}`
+	if w.Body.String() != want {
+		t.Fatalf("expected %s, got %s", want, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestListShelfBooksHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\)\\s+FROM shelf_books s[\\s\\S]+WHERE s.user_id = \\? AND s.shelf = \\?").
+		WithArgs(2, "want-to-read", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("ORDER BY s.updated_at DESC, b.id DESC\\s+LIMIT \\? OFFSET \\?").
+		WithArgs(2, "want-to-read", 1, 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "slug", "title", "author", "page_count", "cover_id", "updated_at"}).
+			AddRow(9, "b-9", "emma", "Emma", "Jane Austen", 474, nil, time.Now()))
+
+	w := httptest.NewRecorder()
+	shelvesRouter(2).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/2/shelves/want-to-read?page=2&limit=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Total int                      `json:"total"`
+		Data  []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if body.Total != 3 || len(body.Data) != 1 || body.Data[0]["slug"] != "emma" || body.Data[0]["shelved_at"] == nil {
+		t.Fatalf("unexpected page %s", w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,496 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/counters"
+	"github.com/YeswanthC7/bookrec/internal/softdelete"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// deletedListMaxLimit caps GET /admin/deleted pages
+const deletedListMaxLimit = 100
+
+// restoreActions are the audit actions of POST /admin/<resource>s/:id/restore
+var restoreActions = map[string]string{
+	"book":        AuditBookRestore,
+	"user":        AuditUserRestore,
+	"list":        AuditListRestore,
+	"interaction": AuditInteractionRestore,
+}
+
+// userOwnedTables are soft-deleted and restored along with their user
+var userOwnedTables = []string{"lists", "interactions", "reviews"}
+
+// deletedScope is the resolveRef scope of a resource's rows whether deleted
+// or not; alias qualifies its column. Like editableBooksSQL, only the default
+// organization reaches shared catalogue books. Bind tenant.ID(ctx).
+func deletedScope(ctx context.Context, resource, alias string) string {
+	if resource == "book" && tenant.ID(ctx) == tenant.DefaultID {
+		return tenant.BooksScopeSQL(alias)
+	}
+	if alias != "" {
+		return alias + "." + orgScope
+	}
+	return orgScope
+}
+
+// resolveDeletedRef resolves a reference to a resource row that may be
+// soft-deleted
+func resolveDeletedRef(resource string) func(context.Context, string) (int, error) {
+	slugColumn := ""
+	if resource == "book" {
+		slugColumn = "slug"
+	}
+	return func(ctx context.Context, raw string) (int, error) {
+		return resolveRef(ctx, softdelete.Tables[resource], slugColumn, deletedScope(ctx, resource, ""), raw)
+	}
+}
+
+// recountUserBooks recounts every book userID interacted with, after their
+// interactions were deleted or restored in bulk
+func recountUserBooks(ctx context.Context, tx *sql.Tx, userID int) error {
+	bookIDs, err := lockedIDs(ctx, tx,
+		"SELECT DISTINCT book_id FROM interactions WHERE user_id = ? ORDER BY book_id", userID)
+	if err != nil {
+		return err
+	}
+	recount := make([]int, len(bookIDs))
+	for i, id := range bookIDs {
+		recount[i] = int(id)
+	}
+	return counters.Recount(ctx, tx, recount...)
+}
+
+// DeleteBookHandler godoc
+// @Summary Delete a book (Admin)
+// @Description The book is soft-deleted: it drops out of listings, search and recommendations right away, can be restored with POST /admin/books/{id}/restore, and is removed for good by the purge job after the retention window.
+// @Tags Admin
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Book UUID or slug"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/books/{id} [delete]
+func DeleteBookHandler(c *gin.Context) {
+	id, ok := resolveParam(c, resolveBookRef, c.Param("id"), "book")
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// other tenants see shared catalogue books but can't delete them
+	var one int
+	err = tx.QueryRowContext(ctx,
+		"SELECT 1 FROM books WHERE id = ? AND "+editableBooksSQL(ctx)+" FOR UPDATE", id, tenant.ID(ctx)).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("book not found"))
+		return
+	}
+	var before interface{}
+	if err == nil {
+		before, err = auditSnapshot(ctx, tx, "book", id)
+	}
+	if err == nil {
+		_, err = softdelete.Delete(ctx, tx, "books", id, c.GetInt("auth_user_id"))
+	}
+	if err == nil {
+		err = recordAudit(c, tx, AuditBookDelete, "book", id, before)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// DeleteUserHandler godoc
+// @Summary Delete an account (the account itself or an admin)
+// @Description The account is soft-deleted along with its lists, interactions and reviews: it can no longer sign in, its refresh tokens are revoked and it stops receiving the digest. An admin can restore it with POST /admin/users/{id}/restore until the purge job removes it; its email stays taken until then.
+// @Tags Users
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id} [delete]
+func DeleteUserHandler(c *gin.Context) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+	if c.GetInt("auth_user_id") != userID && c.GetString("auth_role") != "admin" {
+		abortWithError(c, forbidden("cannot delete another user"))
+		return
+	}
+	ctx := c.Request.Context()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	before, err := auditSnapshot(ctx, tx, "user", userID)
+	if err == nil {
+		_, err = softdelete.Delete(ctx, tx, "users", userID, c.GetInt("auth_user_id"))
+	}
+	for _, table := range userOwnedTables {
+		if err == nil {
+			_, err = softdelete.Cascade(ctx, tx, table, "user_id", "users", userID)
+		}
+	}
+	if err == nil {
+		err = recountUserBooks(ctx, tx, userID)
+	}
+	if err == nil {
+		_, err = tx.ExecContext(ctx,
+			"UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = ? AND revoked_at IS NULL", userID)
+	}
+	if err == nil {
+		_, err = tx.ExecContext(ctx, "UPDATE users SET email_digest = FALSE WHERE id = ?", userID)
+	}
+	if err == nil {
+		err = recordAudit(c, tx, AuditUserDelete, "user", userID, before)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// DeleteInteractionHandler godoc
+// @Summary Delete an interaction (its owner or an admin)
+// @Description The interaction is soft-deleted and no longer counts towards the book's likes and ratings. An admin can restore it with POST /admin/interactions/{id}/restore until the purge job removes it.
+// @Tags Interactions
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Interaction UUID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /interactions/{id} [delete]
+func DeleteInteractionHandler(c *gin.Context) {
+	id, ok := resolveParam(c, resolveInteractionRef, c.Param("id"), "interaction")
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var ownerID, bookID int
+	if err := tx.QueryRowContext(ctx,
+		"SELECT user_id, book_id FROM interactions WHERE id = ? FOR UPDATE", id).Scan(&ownerID, &bookID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	// as with GET, other users' interactions are reported as missing
+	if c.GetInt("auth_user_id") != ownerID && c.GetString("auth_role") != "admin" {
+		abortWithError(c, notFound("interaction not found"))
+		return
+	}
+
+	_, err = softdelete.Delete(ctx, tx, "interactions", id, c.GetInt("auth_user_id"))
+	if err == nil {
+		err = counters.Recount(ctx, tx, bookID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	forgetRecommendations(ctx, ownerID)
+	c.Status(204)
+}
+
+// DeleteBookInteractionHandler godoc
+// @Summary Delete your interaction with a book, e.g. unlike it
+// @Description Soft-deletes the authenticated user's interaction with the book for that action, like DELETE /interactions/{id}. Posting the action again records it anew.
+// @Tags Interactions
+// @Param Authorization header string true "Bearer token"
+// @Param book_id query string true "Book UUID or slug"
+// @Param action query string false "Action" Enums(like, view, rating, dislike) default(like)
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /interactions [delete]
+func DeleteBookInteractionHandler(c *gin.Context) {
+	action := c.DefaultQuery("action", "like")
+	if action != "like" && action != "view" && action != "rating" && action != "dislike" {
+		abortWithError(c, badRequest("action must be like, view, rating or dislike"))
+		return
+	}
+	if c.Query("book_id") == "" {
+		abortWithError(c, badRequest("book_id is required"))
+		return
+	}
+	bookID, ok := resolveParam(c, resolveBookRef, c.Query("book_id"), "book")
+	if !ok {
+		return
+	}
+	userID := c.GetInt("auth_user_id")
+	ctx := c.Request.Context()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		SELECT id FROM interactions
+		WHERE user_id = ? AND book_id = ? AND action = ? AND deleted_at IS NULL
+		FOR UPDATE`, userID, bookID, action).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("interaction not found"))
+		return
+	}
+	if err == nil {
+		_, err = softdelete.Delete(ctx, tx, "interactions", id, userID)
+	}
+	if err == nil {
+		err = counters.Recount(ctx, tx, bookID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	forgetRecommendations(ctx, userID)
+	c.Status(204)
+}
+
+// ListDeletedHandler godoc
+// @Summary List soft-deleted books, users, lists or interactions (Admin)
+// @Description Newest deletions first, with who deleted each row. Rows drop off once the purge job removes them.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param resource query string true "book, user, list or interaction"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit (max 100)" default(50)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/deleted [get]
+func ListDeletedHandler(c *gin.Context) {
+	resource := c.Query("resource")
+	table, ok := softdelete.Tables[resource]
+	if !ok {
+		abortWithError(c, badRequest("resource must be book, user, list or interaction"))
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > deletedListMaxLimit {
+		limit = 50
+	}
+	offset := (page - 1) * limit
+
+	ctx := c.Request.Context()
+	where := " WHERE t.deleted_at IS NOT NULL AND " + deletedScope(ctx, resource, "t")
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table+" t"+where, tenant.ID(ctx)).Scan(&total); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT t.uuid, t.deleted_at, u.uuid
+		FROM `+table+` t
+		LEFT JOIN users u ON u.id = t.deleted_by`+where+`
+		ORDER BY t.deleted_at DESC, t.id DESC
+		LIMIT ? OFFSET ?`, tenant.ID(ctx), limit, offset)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	deleted := []gin.H{}
+	for rows.Next() {
+		var publicID, deletedAt string
+		var deletedByUUID sql.NullString
+		if err := rows.Scan(&publicID, &deletedAt, &deletedByUUID); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		// deleted_by is cleared when the deleting account is purged
+		var by interface{}
+		if deletedByUUID.Valid {
+			by = gin.H{"uuid": deletedByUUID.String}
+		}
+		deleted = append(deleted, gin.H{
+			"uuid":       publicID,
+			"deleted_at": deletedAt,
+			"deleted_by": by,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"resource": resource,
+		"page":     page,
+		"limit":    limit,
+		"total":    total,
+		"data":     deleted,
+	})
+}
+
+// RestoreBookHandler godoc
+// @Summary Restore a soft-deleted book (Admin)
+// @Tags Admin
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Book UUID or slug"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/books/{id}/restore [post]
+func RestoreBookHandler(c *gin.Context) {
+	restoreDeleted(c, "book")
+}
+
+// RestoreUserHandler godoc
+// @Summary Restore a soft-deleted account (Admin)
+// @Description Brings back the lists, interactions and reviews deleted along with it, but not ones the user had deleted before. The user signs in again to get new tokens.
+// @Tags Admin
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/users/{id}/restore [post]
+func RestoreUserHandler(c *gin.Context) {
+	restoreDeleted(c, "user")
+}
+
+// RestoreListHandler godoc
+// @Summary Restore a soft-deleted reading list (Admin)
+// @Tags Admin
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "List UUID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/lists/{id}/restore [post]
+func RestoreListHandler(c *gin.Context) {
+	restoreDeleted(c, "list")
+}
+
+// RestoreInteractionHandler godoc
+// @Summary Restore a soft-deleted interaction (Admin)
+// @Tags Admin
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Interaction UUID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/interactions/{id}/restore [post]
+func RestoreInteractionHandler(c *gin.Context) {
+	restoreDeleted(c, "interaction")
+}
+
+// restoreDeleted clears :id's deleted mark, re-counting the books whose
+// likes and ratings come back with it, and audits the restore
+func restoreDeleted(c *gin.Context, resource string) {
+	id, ok := resolveParam(c, resolveDeletedRef(resource), c.Param("id"), resource)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	before, err := auditSnapshot(ctx, tx, resource, id)
+	if err == nil && resource == "user" {
+		// while the user's deleted_at still tells cascaded rows apart
+		for _, table := range userOwnedTables {
+			if err == nil {
+				_, err = softdelete.Uncascade(ctx, tx, table, "user_id", "users", id)
+			}
+		}
+	}
+	var restored bool
+	if err == nil {
+		restored, err = softdelete.Restore(ctx, tx, softdelete.Tables[resource], id)
+	}
+	if err == nil && !restored {
+		abortWithError(c, conflict(resource+" is not deleted"))
+		return
+	}
+	switch {
+	case err == nil && resource == "user":
+		err = recountUserBooks(ctx, tx, id)
+	case err == nil && resource == "interaction":
+		var bookID int
+		err = tx.QueryRowContext(ctx, "SELECT book_id FROM interactions WHERE id = ?", id).Scan(&bookID)
+		if err == nil {
+			err = counters.Recount(ctx, tx, bookID)
+		}
+	}
+	if err == nil {
+		err = recordAudit(c, tx, restoreActions[resource], resource, id, before)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(204)
+}
@@ -0,0 +1,291 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeleteUserHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// someone else's account
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\? AND organization_id = \\? AND deleted_at IS NULL").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	// their own
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	expectAuditSnapshot(mock, "user", `{"id": 5, "deleted_at": null}`)
+	mock.ExpectExec("UPDATE users SET deleted_at = CURRENT_TIMESTAMP, deleted_by = \\? WHERE id = \\?").
+		WithArgs(5, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, table := range []string{"lists", "interactions", "reviews"} {
+		mock.ExpectExec("UPDATE " + table + " t JOIN users o ON o.id = t.user_id\\s+SET t.deleted_at = o.deleted_at").
+			WithArgs(5).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+	}
+	mock.ExpectQuery("SELECT DISTINCT book_id FROM interactions WHERE user_id = \\?").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id"}).AddRow(3))
+	mock.ExpectExec("DELETE FROM book_counters WHERE book_id = \\?").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_counters .+AND deleted_at IS NULL AND book_id = \\?").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\) WHERE user_id = \\?").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET email_digest = FALSE WHERE id = \\?").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, AuditUserDelete, "user", 5)
+	mock.ExpectCommit()
+
+	gin.SetMode(gin.TestMode)
+	for _, tc := range []struct {
+		caller int
+		want   int
+	}{
+		{caller: 9, want: http.StatusForbidden},
+		{caller: 5, want: http.StatusNoContent},
+	} {
+		r := gin.New()
+		r.DELETE("/users/:id", asUser(tc.caller), DeleteUserHandler)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/5", nil))
+		if w.Code != tc.want {
+			t.Fatalf("caller %d: expected %d, got %d: %s", tc.caller, tc.want, w.Code, w.Body.String())
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestDeleteInteractionHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectLoad := func() {
+		mock.ExpectQuery("SELECT 1 FROM interactions WHERE id = \\? AND organization_id = \\? AND deleted_at IS NULL").
+			WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT user_id, book_id FROM interactions WHERE id = \\? FOR UPDATE").
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "book_id"}).AddRow(5, 3))
+	}
+	// the first caller isn't the owner
+	expectLoad()
+	mock.ExpectRollback()
+	expectLoad()
+	mock.ExpectExec("UPDATE interactions SET deleted_at = CURRENT_TIMESTAMP, deleted_by = \\? WHERE id = \\?").
+		WithArgs(5, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM book_counters WHERE book_id = \\?").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_counters").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	gin.SetMode(gin.TestMode)
+	for _, tc := range []struct {
+		caller int
+		want   int
+	}{
+		{caller: 9, want: http.StatusNotFound},
+		{caller: 5, want: http.StatusNoContent},
+	} {
+		r := gin.New()
+		r.DELETE("/interactions/:id", asUser(tc.caller), DeleteInteractionHandler)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/interactions/7", nil))
+		if w.Code != tc.want {
+			t.Fatalf("caller %d: expected %d, got %d: %s", tc.caller, tc.want, w.Code, w.Body.String())
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestDeleteBookInteractionHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectFind := func(rows *sqlmock.Rows) {
+		mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT id FROM interactions\\s+WHERE user_id = \\? AND book_id = \\? AND action = \\? AND deleted_at IS NULL\\s+FOR UPDATE").
+			WithArgs(5, 3, "like").
+			WillReturnRows(rows)
+	}
+	expectFind(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectExec("UPDATE interactions SET deleted_at = CURRENT_TIMESTAMP, deleted_by = \\? WHERE id = \\?").
+		WithArgs(5, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM book_counters WHERE book_id = \\?").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_counters").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// unliking again finds nothing
+	expectFind(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.DELETE("/interactions", asUser(5), DeleteBookInteractionHandler)
+	for _, tc := range []struct {
+		target string
+		want   int
+	}{
+		{"/interactions?book_id=3", http.StatusNoContent},
+		{"/interactions?book_id=3&action=like", http.StatusNotFound},
+		{"/interactions?book_id=3&action=bookmark", http.StatusBadRequest},
+		{"/interactions", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, tc.target, nil))
+		if w.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d: %s", tc.target, tc.want, w.Code, w.Body.String())
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestRestoreUserHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	// deleted users still resolve here
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\? AND organization_id = \\?$").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	expectAuditSnapshot(mock, "user", `{"id": 5, "deleted_at": "2026-10-01 12:00:00"}`)
+	for _, table := range []string{"lists", "interactions", "reviews"} {
+		mock.ExpectExec("UPDATE " + table + " t JOIN users o ON o.id = t.user_id\\s+SET t.deleted_at = NULL").
+			WithArgs(5).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+	}
+	mock.ExpectExec("UPDATE users SET deleted_at = NULL, deleted_by = NULL WHERE id = \\? AND deleted_at IS NOT NULL").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT DISTINCT book_id FROM interactions WHERE user_id = \\?").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id"}).AddRow(3))
+	mock.ExpectExec("DELETE FROM book_counters WHERE book_id = \\?").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_counters").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, AuditUserRestore, "user", 5)
+	mock.ExpectCommit()
+
+	// restoring it again
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	expectAuditSnapshot(mock, "user", `{"id": 5, "deleted_at": null}`)
+	for _, table := range []string{"lists", "interactions", "reviews"} {
+		mock.ExpectExec("UPDATE " + table + " t JOIN users o").
+			WithArgs(5).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec("UPDATE users SET deleted_at = NULL").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin/users/:id/restore", RestoreUserHandler)
+	for _, want := range []int{http.StatusNoContent, http.StatusConflict} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/users/5/restore", nil))
+		if w.Code != want {
+			t.Fatalf("expected %d, got %d: %s", want, w.Code, w.Body.String())
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestListDeletedHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM lists t WHERE t.deleted_at IS NOT NULL AND t.organization_id = \\?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("FROM lists t\\s+LEFT JOIN users u ON u.id = t.deleted_by WHERE t.deleted_at IS NOT NULL AND t.organization_id = \\?\\s+ORDER BY t.deleted_at DESC").
+		WithArgs(1, 50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "deleted_at", "uuid"}).
+			AddRow("0b7e2f4c-5d1a-4e8b-9c3f-6a2d1e0f9b8c", "2026-10-01 12:00:00", "8a1d2c3b-4e5f-4a6b-8c7d-9e0f1a2b3c4d"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin/deleted", ListDeletedHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/deleted?resource=list", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Total int `json:"total"`
+		Data  []struct {
+			UUID      string `json:"uuid"`
+			DeletedBy struct {
+				UUID string `json:"uuid"`
+			} `json:"deleted_by"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Total != 1 || len(body.Data) != 1 || body.Data[0].UUID != "0b7e2f4c-5d1a-4e8b-9c3f-6a2d1e0f9b8c" ||
+		body.Data[0].DeletedBy.UUID != "8a1d2c3b-4e5f-4a6b-8c7d-9e0f1a2b3c4d" {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/deleted?resource=thread", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// statsTopGenres is how many genres /stats ranks
+const statsTopGenres = 5
+
+// Stats is the body of GET /stats and of each /stats/stream event
+type Stats struct {
+	Users                int            `json:"users"`
+	Books                int            `json:"books"`
+	Interactions         int            `json:"interactions"`
+	InteractionsByAction map[string]int `json:"interactions_by_action"`
+	NewThisWeek          NewThisWeek    `json:"new_this_week"`
+	ActiveUsers          ActiveUsers    `json:"active_users"`
+	TopGenres            []GenreCount   `json:"top_genres"`
+}
+
+// NewThisWeek counts users and books created in the last 7 days
+type NewThisWeek struct {
+	Users int `json:"users"`
+	Books int `json:"books"`
+}
+
+// ActiveUsers counts distinct users with an interaction in the last 1, 7
+// and 30 days
+type ActiveUsers struct {
+	Day   int `json:"day"`
+	Week  int `json:"week"`
+	Month int `json:"month"`
+}
+
+// StatsHandler godoc
+// @Summary System stats: counts, interactions by action, new this week, active users, top genres
+// @Description new_this_week covers the last 7 days; active_users counts distinct users with an interaction in the last 1, 7 and 30 days; top_genres ranks the 5 genres with the most likes and ratings. Everything is scoped to the caller's organization. Admins only.
+// @Tags System
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} Stats
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /stats [get]
+func StatsHandler(c *gin.Context) {
+	stats, err := loadStats(c.Request.Context())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, stats)
+}
+
+// loadStats gathers the tenant's stats (shared by /stats and
+// /stats/stream). Any query failure is returned rather than reported as
+// zeros.
+func loadStats(ctx context.Context) (*Stats, error) {
+	orgID := tenant.ID(ctx)
+	now := time.Now()
+	weekAgo := now.AddDate(0, 0, -7)
+	stats := &Stats{
+		InteractionsByAction: map[string]int{"view": 0, "like": 0, "rating": 0, "dislike": 0},
+		TopGenres:            []GenreCount{},
+	}
+
+	if err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(created_at >= ?), 0)
+		FROM users
+		WHERE organization_id = ? AND deleted_at IS NULL`, weekAgo, orgID).
+		Scan(&stats.Users, &stats.NewThisWeek.Users); err != nil {
+		return nil, err
+	}
+	if err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(created_at >= ?), 0)
+		FROM books
+		WHERE `+tenant.BooksVisibleSQL(""), weekAgo, orgID).
+		Scan(&stats.Books, &stats.NewThisWeek.Books); err != nil {
+		return nil, err
+	}
+
+	actions, err := db.QueryContext(ctx, `
+		SELECT action, COUNT(*)
+		FROM interactions
+		WHERE organization_id = ? AND deleted_at IS NULL
+		GROUP BY action`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = actions.Close() }()
+	for actions.Next() {
+		var action string
+		var n int
+		if err := actions.Scan(&action, &n); err != nil {
+			return nil, err
+		}
+		stats.InteractionsByAction[action] = n
+		stats.Interactions += n
+	}
+	if err := actions.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT CASE WHEN created_at >= ? THEN user_id END),
+		       COUNT(DISTINCT CASE WHEN created_at >= ? THEN user_id END),
+		       COUNT(DISTINCT user_id)
+		FROM interactions
+		WHERE organization_id = ? AND deleted_at IS NULL AND created_at >= ?`,
+		now.AddDate(0, 0, -1), weekAgo, orgID, now.AddDate(0, 0, -30)).
+		Scan(&stats.ActiveUsers.Day, &stats.ActiveUsers.Week, &stats.ActiveUsers.Month); err != nil {
+		return nil, err
+	}
+
+	// engagement comes from the running counters rather than aggregating
+	// every interaction on each poll of /stats/stream
+	genres, err := db.QueryContext(ctx, `
+		SELECT g.slug, SUM(bc.likes + bc.ratings) AS interactions
+		FROM book_counters bc
+		JOIN books b ON b.id = bc.book_id
+		JOIN book_genres bg ON bg.book_id = b.id
+		JOIN genres g ON g.id = bg.genre_id
+		WHERE bc.organization_id = ? AND b.deleted_at IS NULL
+		GROUP BY g.slug
+		HAVING interactions > 0
+		ORDER BY interactions DESC, g.slug
+		LIMIT ?`, orgID, statsTopGenres)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = genres.Close() }()
+	for genres.Next() {
+		var g GenreCount
+		if err := genres.Scan(&g.Genre, &g.Interactions); err != nil {
+			return nil, err
+		}
+		stats.TopGenres = append(stats.TopGenres, g)
+	}
+	if err := genres.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectStatsQueries queues the queries loadStats runs, in order
+func expectStatsQueries(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\), COALESCE\\(SUM\\(created_at >= \\?\\), 0\\)\\s+FROM users\\s+WHERE organization_id = \\? AND deleted_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "new"}).AddRow(2, 1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\), COALESCE\\(SUM\\(created_at >= \\?\\), 0\\)\\s+FROM books").
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "new"}).AddRow(80, 3))
+	mock.ExpectQuery("SELECT action, COUNT\\(\\*\\)\\s+FROM interactions\\s+WHERE organization_id = \\? AND deleted_at IS NULL\\s+GROUP BY action").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"action", "count"}).AddRow("view", 3).AddRow("like", 2))
+	mock.ExpectQuery("COUNT\\(DISTINCT user_id\\)\\s+FROM interactions").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), int64(1), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "week", "month"}).AddRow(1, 2, 2))
+	mock.ExpectQuery("FROM book_counters bc\\s+JOIN books b ON b.id = bc.book_id\\s+JOIN book_genres bg ON bg.book_id = b.id\\s+JOIN genres g").
+		WithArgs(int64(1), statsTopGenres).
+		WillReturnRows(sqlmock.NewRows([]string{"genre", "interactions"}).AddRow("fantasy", 2).AddRow("poetry", 1))
+}
+
+func TestStatsHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectStatsQueries(mock)
+
+	r := setupRouter()
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+
+	var body Stats
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	want := Stats{
+		Users:                2,
+		Books:                80,
+		Interactions:         5,
+		InteractionsByAction: map[string]int{"view": 3, "like": 2, "rating": 0, "dislike": 0},
+		NewThisWeek:          NewThisWeek{Users: 1, Books: 3},
+		ActiveUsers:          ActiveUsers{Day: 1, Week: 2, Month: 2},
+		TopGenres:            []GenreCount{{Genre: "fantasy", Interactions: 2}, {Genre: "poetry", Interactions: 1}},
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Fatalf("unexpected stats response:\n%+v\nwant\n%+v", body, want)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+// a failing query is a 500, not a row of zeros
+func TestStatsHandler_DBError(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("FROM users").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "new"}).AddRow(2, 1))
+	mock.ExpectQuery("FROM books").
+		WillReturnError(errors.New("connection reset"))
+
+	r := setupRouter()
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d body=%s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+)
+
+func postForm(r http.Handler, target string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateUserHandler_CreatedWithLocation(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users").WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT uuid, email, handle, role, created_at FROM users WHERE id = \\?").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "email", "handle", "role", "created_at"}).
+			AddRow("6f1c2b9e-0c1d-4a52-9d7e-1c0f5b2a9e11", "a@example.com", "ann", "user", time.Now()))
+	mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/users", CreateUserHandler)
+
+	w := postForm(r, "/users", url.Values{"email": {"a@example.com"}, "handle": {"ann"}, "password": {"pw"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if loc := w.Header().Get("Location"); loc != "/users/6f1c2b9e-0c1d-4a52-9d7e-1c0f5b2a9e11" {
+		t.Fatalf("unexpected Location: %q", loc)
+	}
+	if !strings.Contains(w.Body.String(), `"handle":"ann"`) {
+		t.Fatalf("expected created user in body, got %s", w.Body.String())
+	}
+}
+
+func TestCreateUserHandler_DuplicateIsConflict(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/users", CreateUserHandler)
+
+	w := postForm(r, "/users", url.Values{"email": {"a@example.com"}, "handle": {"ann"}, "password": {"pw"}})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCreateInteractionHandler_UnknownBook(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM books WHERE id = \\?").
+		WithArgs(99, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/interactions", func(c *gin.Context) { c.Set("auth_user_id", 1) }, CreateInteractionHandler)
+
+	w := postForm(r, "/interactions", url.Values{"user_id": {"1"}, "book_id": {"99"}, "action": {"like"}})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestRecommendationsHandler_UnknownUser(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(42, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/recommendations/:user_id", RecommendationsHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recommendations/42", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
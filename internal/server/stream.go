@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SSE polling intervals
+var (
+	statsStreamInterval = 5 * time.Second
+	jobsStreamInterval  = 2 * time.Second
+)
+
+// recentJobRuns is how many runs the jobs stream tracks
+const recentJobRuns = 20
+
+// JobRun mirrors a row of job_runs
+type JobRun struct {
+	ID         int        `json:"id"`
+	Job        string     `json:"job"`
+	Status     string     `json:"status"`
+	Processed  int        `json:"processed"`
+	Total      int        `json:"total"`
+	Message    string     `json:"message,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+func sseHeaders(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable proxy buffering (nginx)
+}
+
+// StatsStreamHandler godoc
+// @Summary Live system stats (Server-Sent Events)
+// @Description Emits a "stats" event (the body of GET /stats) immediately and whenever it changes (checked on every interaction and every few seconds). Admins only.
+// @Tags System
+// @Produce text/event-stream
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {string} string "event stream"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /stats/stream [get]
+func StatsStreamHandler(c *gin.Context) {
+	sseHeaders(c)
+	ctx := c.Request.Context()
+
+	sub, unsubscribe := events.Subscribe(16)
+	defer unsubscribe()
+
+	ticker := time.NewTicker(statsStreamInterval)
+	defer ticker.Stop()
+
+	var last *Stats
+	first := true
+	c.Stream(func(w io.Writer) bool {
+		if !first {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-shuttingDown:
+				return false
+			case <-ticker.C:
+			case <-sub:
+			}
+		}
+		first = false
+
+		stats, err := loadStats(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			_ = c.Error(err)
+			c.SSEvent("error", errorBody(err))
+			return true
+		}
+		if reflect.DeepEqual(stats, last) {
+			return true
+		}
+		last = stats
+		c.SSEvent("stats", stats)
+		return true
+	})
+}
+
+// JobsStreamHandler godoc
+// @Summary Live job progress (Server-Sent Events)
+// @Description Emits a "job" event for each recent job run (ingestion, similarity build, ...) whenever its progress changes.
+// @Tags Admin
+// @Produce text/event-stream
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {string} string "event stream"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/jobs/stream [get]
+func JobsStreamHandler(c *gin.Context) {
+	sseHeaders(c)
+	ctx := c.Request.Context()
+
+	ticker := time.NewTicker(jobsStreamInterval)
+	defer ticker.Stop()
+
+	seen := map[int]time.Time{}
+	first := true
+	c.Stream(func(w io.Writer) bool {
+		if !first {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-shuttingDown:
+				return false
+			case <-ticker.C:
+			}
+		}
+		first = false
+
+		runs, err := loadRecentJobRuns(ctx, recentJobRuns)
+		if err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			_ = c.Error(err)
+			c.SSEvent("error", errorBody(err))
+			return true
+		}
+		// oldest first so clients apply updates in order
+		for i := len(runs) - 1; i >= 0; i-- {
+			run := runs[i]
+			if prev, ok := seen[run.ID]; ok && prev.Equal(run.UpdatedAt) {
+				continue
+			}
+			seen[run.ID] = run.UpdatedAt
+			c.SSEvent("job", run)
+		}
+		return true
+	})
+}
+
+func loadRecentJobRuns(ctx context.Context, limit int) ([]JobRun, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, job, status, processed, total, message, started_at, updated_at, finished_at
+		FROM job_runs
+		ORDER BY id DESC
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	runs := []JobRun{}
+	for rows.Next() {
+		var run JobRun
+		var message sql.NullString
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&run.ID, &run.Job, &run.Status, &run.Processed, &run.Total,
+			&message, &run.StartedAt, &run.UpdatedAt, &finishedAt); err != nil {
+			return nil, err
+		}
+		run.Message = message.String
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestStatsStreamHandler_EmitsStats(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectStatsQueries(mock)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/stats/stream", StatsStreamHandler)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/stats/stream", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+
+	// first event: "event:stats" then "data:{...}"
+	scanner := bufio.NewScanner(resp.Body)
+	var lines []string
+	for scanner.Scan() && len(lines) < 2 {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) < 2 || lines[0] != "event:stats" || !strings.Contains(lines[1], `"books":80`) {
+		t.Fatalf("expected a stats event, got %q", lines)
+	}
+	cancel()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
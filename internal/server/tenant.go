@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/dberr"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// tenantHeader names the organization explicitly (takes precedence over subdomains)
+const tenantHeader = "X-Tenant"
+
+var orgSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// tenantBaseDomain enables "<slug>.<base>" resolution when set (e.g.
+// "bookrec.example.com"); Run reads it from TENANT_BASE_DOMAIN
+var tenantBaseDomain string
+
+// orgCache maps organization slugs to ids; organizations are never renamed
+// or deleted, so entries don't expire.
+var orgCache sync.Map
+
+// tenantSlug picks the requested organization slug from the header or subdomain
+func tenantSlug(c *gin.Context) string {
+	if v := strings.ToLower(strings.TrimSpace(c.GetHeader(tenantHeader))); v != "" {
+		return v
+	}
+	if tenantBaseDomain == "" {
+		return ""
+	}
+	host := strings.ToLower(c.Request.Host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if sub, ok := strings.CutSuffix(host, "."+tenantBaseDomain); ok && !strings.Contains(sub, ".") {
+		return sub
+	}
+	return ""
+}
+
+func lookupOrganization(ctx context.Context, slug string) (int, error) {
+	if id, ok := orgCache.Load(slug); ok {
+		return id.(int), nil
+	}
+	var id int
+	if err := db.QueryRowContext(ctx, "SELECT id FROM organizations WHERE slug = ?", slug).Scan(&id); err != nil {
+		return 0, err
+	}
+	orgCache.Store(slug, id)
+	return id, nil
+}
+
+// TenantMiddleware scopes the request to an organization. Requests that don't
+// name one use the default organization.
+func TenantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		slug := tenantSlug(c)
+		if slug == "" {
+			c.Next()
+			return
+		}
+
+		id, err := lookupOrganization(c.Request.Context(), slug)
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithError(c, notFound("unknown tenant"))
+			return
+		}
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.Set("tenant_id", id)
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// RequirePlatformAdmin allows admins of the default organization only; they
+// manage tenants and the shared catalogue.
+func RequirePlatformAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_role") != "admin" || tenant.ID(c.Request.Context()) != tenant.DefaultID {
+			abortWithError(c, forbidden("forbidden"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// CreateOrganizationHandler godoc
+// @Summary Create an organization (tenant)
+// @Tags Admin
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param slug formData string true "URL-safe identifier used in X-Tenant / subdomains"
+// @Param name formData string true "Display name"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/organizations [post]
+func CreateOrganizationHandler(c *gin.Context) {
+	slug := strings.ToLower(strings.TrimSpace(c.PostForm("slug")))
+	name := strings.TrimSpace(c.PostForm("name"))
+	if !orgSlugPattern.MatchString(slug) {
+		abortWithError(c, badRequest("slug must be lowercase letters, digits, and dashes (max 63)"))
+		return
+	}
+	if name == "" {
+		abortWithError(c, badRequest("name is required"))
+		return
+	}
+
+	res, err := db.ExecContext(c.Request.Context(),
+		"INSERT INTO organizations (slug, name) VALUES (?, ?)", slug, name)
+	if err != nil {
+		if dberr.Is(err, dberr.ErrDuplicate) {
+			abortWithError(c, conflict("slug already exists"))
+			return
+		}
+		abortWithError(c, err)
+		return
+	}
+	id, _ := res.LastInsertId()
+
+	var publicID, createdAt string
+	if err := db.QueryRowContext(c.Request.Context(),
+		"SELECT uuid, created_at FROM organizations WHERE id = ?", id).Scan(&publicID, &createdAt); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Header("Location", "/admin/organizations/"+publicID)
+	c.JSON(201, gin.H{
+		"uuid":       publicID,
+		"slug":       slug,
+		"name":       name,
+		"created_at": createdAt,
+	})
+}
+
+// ListOrganizationsHandler godoc
+// @Summary List organizations (tenants)
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {array} map[string]interface{}
+// @Router /admin/organizations [get]
+func ListOrganizationsHandler(c *gin.Context) {
+	rows, err := db.QueryContext(c.Request.Context(), `
+		SELECT o.uuid, o.slug, o.name, o.created_at,
+		       (SELECT COUNT(*) FROM users u WHERE u.organization_id = o.id)
+		FROM organizations o
+		ORDER BY o.id`)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	orgs := []map[string]interface{}{}
+	for rows.Next() {
+		var users int
+		var publicID, slug, name, createdAt string
+		if err := rows.Scan(&publicID, &slug, &name, &createdAt, &users); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		orgs = append(orgs, gin.H{
+			"uuid":       publicID,
+			"slug":       slug,
+			"name":       name,
+			"created_at": createdAt,
+			"users":      users,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, orgs)
+}
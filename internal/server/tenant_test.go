@@ -0,0 +1,142 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+func TestTenantMiddleware_ScopesQueries(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT id FROM organizations WHERE slug = \\?").
+		WithArgs("riverside").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM users WHERE organization_id = \\?").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT id, uuid, email, handle, created_at FROM users WHERE organization_id = \\?").
+		WithArgs(5, 21, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "email", "handle", "created_at"}))
+	mock.ExpectQuery("SELECT id FROM organizations WHERE slug = \\?").
+		WithArgs("nowhere").
+		WillReturnError(sqlmock.ErrCancelled)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(TenantMiddleware())
+	r.GET("/users", ListUsersHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(tenantHeader, "Riverside")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// lookup failures other than "no such slug" are server errors
+	req = httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(tenantHeader, "nowhere")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestTenantMiddleware_UnknownTenant(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT id FROM organizations WHERE slug = \\?").
+		WithArgs("ghost").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	prev := tenantBaseDomain
+	tenantBaseDomain = "bookrec.example.com"
+	defer func() { tenantBaseDomain = prev }()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(TenantMiddleware())
+	r.GET("/healthz", HealthHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Host = "ghost.bookrec.example.com:8080"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+
+	// the bare base domain is the default organization
+	req = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Host = "bookrec.example.com"
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestAuthMiddleware_RejectsOtherTenantToken(t *testing.T) {
+	prev := jwtSecret
+	jwtSecret = []byte("test-secret")
+	defer func() { jwtSecret = prev }()
+
+	token, err := generateToken(7, "a@example.com", "user", 2)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if c.Query("org") == "2" {
+			c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), 2))
+		}
+		c.Next()
+	})
+	r.GET("/me", AuthMiddleware(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, tc := range []struct {
+		url  string
+		want int
+	}{
+		{"/me", http.StatusUnauthorized},
+		{"/me?org=2", http.StatusNoContent},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d", tc.url, tc.want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// Trending config
+const (
+	trendingSize     = 10
+	trendingInterval = 30 * time.Second
+)
+
+// Websocket timings
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = (wsPongWait * 9) / 10
+)
+
+// TrendingBook is one entry of the trending list
+type TrendingBook struct {
+	ID     int    `json:"-"`
+	UUID   string `json:"uuid"`
+	Slug   string `json:"slug"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Likes  int    `json:"likes"`
+}
+
+// trendingTracker periodically recomputes the default organization's
+// trending list and publishes EventTrendingEntered for books that weren't on
+// the previous list. Other tenants compute theirs on demand.
+type trendingTracker struct {
+	mu      sync.RWMutex
+	current []TrendingBook
+}
+
+var trending = &trendingTracker{}
+
+// Snapshot returns the most recently computed trending list
+func (t *trendingTracker) Snapshot() []TrendingBook {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make([]TrendingBook, len(t.current))
+	copy(out, t.current)
+	return out
+}
+
+// Run refreshes the list every trendingInterval until ctx is cancelled
+func (t *trendingTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(trendingInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.refresh(ctx); err != nil {
+			log.Printf("⚠️ trending refresh failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *trendingTracker) refresh(ctx context.Context) error {
+	books, err := loadTrendingBooks(ctx, "", trendingSize)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	prev := map[int]bool{}
+	for _, b := range t.current {
+		prev[b.ID] = true
+	}
+	// the very first computation seeds the list without announcing everything
+	initial := t.current == nil
+	t.current = books
+	t.mu.Unlock()
+
+	if initial {
+		return nil
+	}
+	for rank, b := range books {
+		if prev[b.ID] {
+			continue
+		}
+		events.Publish(EventTrendingEntered, map[string]interface{}{
+			"book_id":         b.ID,
+			"uuid":            b.UUID,
+			"slug":            b.Slug,
+			"title":           b.Title,
+			"author":          b.Author,
+			"likes":           b.Likes,
+			"rank":            rank + 1,
+			"organization_id": tenant.DefaultID,
+		})
+	}
+	return nil
+}
+
+// loadTrendingBooks returns the tenant's most liked books of yesterday and
+// today (UTC), optionally restricted to books in the genre slug.
+// Yesterday comes from the nightly book_daily_stats rollup, so only today's
+// interactions are scanned.
+func loadTrendingBooks(ctx context.Context, genre string, limit int) ([]TrendingBook, error) {
+	orgID := tenant.ID(ctx)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := today.AddDate(0, 0, -1).Format(time.DateOnly)
+
+	sb := strings.Builder{}
+	sb.WriteString(`
+		SELECT b.id, b.uuid, b.slug, b.title, COALESCE(b.author, ''), SUM(t.likes) AS likes
+		FROM (
+			SELECT book_id, likes FROM book_daily_stats
+			WHERE organization_id = ? AND day = ? AND likes > 0
+			UNION ALL
+			SELECT book_id, COUNT(*) FROM interactions
+			WHERE organization_id = ? AND action = 'like' AND created_at >= ? AND deleted_at IS NULL
+			GROUP BY book_id
+		) t
+		JOIN books b ON b.id = t.book_id
+		WHERE 1=1`)
+	args := []interface{}{orgID, yesterday, orgID, today}
+	if genre != "" {
+		genreSQL, genreArgs := genreFilterSQL("b.id", genre)
+		sb.WriteString(genreSQL)
+		args = append(args, genreArgs...)
+	}
+	sb.WriteString(`
+		GROUP BY b.id, b.uuid, b.slug, b.title, b.author
+		ORDER BY likes DESC, b.id DESC
+		LIMIT ?`)
+	args = append(args, limit)
+
+	rows, err := db.QueryContext(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	books := []TrendingBook{}
+	for rows.Next() {
+		var b TrendingBook
+		if err := rows.Scan(&b.ID, &b.UUID, &b.Slug, &b.Title, &b.Author, &b.Likes); err != nil {
+			return nil, err
+		}
+		books = append(books, b)
+	}
+	return books, rows.Err()
+}
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		return origin == "" || originAllowed(origin) // no Origin: not a browser
+	},
+}
+
+// TrendingWSHandler godoc
+// @Summary Live trending feed (WebSocket)
+// @Description Upgrades to a WebSocket. Sends a "snapshot" message with the current trending list, then "like" (public likes only) and "trending.entered" messages as they happen.
+// @Tags Books
+// @Success 101 {string} string "Switching Protocols"
+// @Router /ws/trending [get]
+func TrendingWSHandler(c *gin.Context) {
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade already wrote an HTTP error response
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	sub, unsubscribe := events.Subscribe(64)
+	defer unsubscribe()
+
+	// Read pump: we don't expect client messages, but reading is required
+	// to process pongs and notice disconnects.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.SetReadLimit(512)
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	write := func(v interface{}) error {
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return conn.WriteJSON(v)
+	}
+
+	orgID := tenant.ID(c.Request.Context())
+	snapshot := trending.Snapshot()
+	if orgID != tenant.DefaultID {
+		if snapshot, err = loadTrendingBooks(c.Request.Context(), "", trendingSize); err != nil {
+			_ = c.Error(err)
+			_ = write(gin.H{"type": "error", "error": errorBody(err)})
+			return
+		}
+	}
+	if err := write(gin.H{"type": "snapshot", "data": snapshot, "at": time.Now().UTC()}); err != nil {
+		return
+	}
+
+	ping := time.NewTicker(wsPingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-shuttingDown:
+			// tell the client to reconnect, likely to another instance
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(wsWriteWait))
+			return
+		case <-ping.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case ev, ok := <-sub:
+			if !ok {
+				return
+			}
+			msg, forward := trendingMessage(ev, orgID)
+			if !forward {
+				continue
+			}
+			if err := write(msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// trendingMessage maps bus events onto the websocket protocol, dropping
+// events from other organizations. Anyone can listen, so private likes
+// aren't forwarded.
+func trendingMessage(ev Event, orgID int) (gin.H, bool) {
+	if id, _ := ev.Data["organization_id"].(int); id != orgID {
+		return nil, false
+	}
+	switch ev.Type {
+	case EventInteractionCreated:
+		if ev.Data["action"] != "like" || ev.Data["visibility"] != "public" {
+			return nil, false
+		}
+		return gin.H{"type": "like", "data": publicEventData(ev.Data), "at": ev.At}, true
+	case EventTrendingEntered:
+		return gin.H{"type": EventTrendingEntered, "data": publicEventData(ev.Data), "at": ev.At}, true
+	}
+	return nil, false
+}
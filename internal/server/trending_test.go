@@ -0,0 +1,72 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+func TestEventBus_PublishSubscribe(t *testing.T) {
+	bus := newEventBus()
+	sub, unsubscribe := bus.Subscribe(1)
+
+	bus.Publish(EventInteractionCreated, map[string]interface{}{"action": "like"})
+	// buffer is full: this one is dropped instead of blocking
+	bus.Publish(EventInteractionCreated, map[string]interface{}{"action": "view"})
+
+	ev := <-sub
+	if ev.Type != EventInteractionCreated || ev.Data["action"] != "like" {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+
+	unsubscribe()
+	if _, ok := <-sub; ok {
+		t.Fatalf("expected channel to be closed after unsubscribe")
+	}
+	unsubscribe() // idempotent
+}
+
+func TestTrendingWSHandler_ForwardsLikes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ws/trending", TrendingWSHandler)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/trending"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var msg map[string]any
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read snapshot: %v", err)
+	}
+	if msg["type"] != "snapshot" {
+		t.Fatalf("expected snapshot first, got %v", msg["type"])
+	}
+
+	// views, private likes and other tenants' likes are not forwarded;
+	// public likes are
+	events.Publish(EventInteractionCreated, map[string]interface{}{"action": "view", "book_id": 1, "visibility": "public", "organization_id": tenant.DefaultID})
+	events.Publish(EventInteractionCreated, map[string]interface{}{"action": "like", "book_id": 4, "user_id": 7, "visibility": "private", "organization_id": tenant.DefaultID})
+	events.Publish(EventInteractionCreated, map[string]interface{}{"action": "like", "book_id": 3, "visibility": "public", "organization_id": 2})
+	events.Publish(EventInteractionCreated, map[string]interface{}{"action": "like", "book_id": 2, "book_uuid": "b-2", "visibility": "public", "organization_id": tenant.DefaultID})
+
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read like: %v", err)
+	}
+	data, _ := msg["data"].(map[string]any)
+	if msg["type"] != "like" || data["book_uuid"] != "b-2" || data["book_id"] != nil || data["organization_id"] != nil {
+		t.Fatalf("unexpected message: %v", msg)
+	}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uiFiles is the demo frontend (plain HTML/JS/CSS, no build step)
+//
+//go:embed ui
+var uiFiles embed.FS
+
+// registerUI serves the embedded demo UI: the page at / and its assets
+// under /ui/. The separate web/ app remains the real frontend.
+func registerUI(r *gin.Engine) {
+	assets, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		panic(err) // the directory is embedded at build time
+	}
+	index, err := fs.ReadFile(assets, "index.html")
+	if err != nil {
+		panic(err)
+	}
+
+	r.GET("/", func(c *gin.Context) {
+		c.Header("Cache-Control", "no-cache")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	})
+	r.StaticFS("/ui", http.FS(assets))
+}
@@ -0,0 +1,232 @@
+// Minimal BookRec demo UI, served by the API server itself. Talks to the
+// same endpoints as any other client; tokens live in localStorage.
+(function () {
+  "use strict";
+
+  const pageSize = 20;
+  const store = window.localStorage;
+  const $ = (id) => document.getElementById(id);
+
+  let session = JSON.parse(store.getItem("bookrec.session") || "null");
+  let view = { mode: "browse", page: 1, q: "", sort: "relevance" };
+  const liked = new Set();
+
+  function saveSession(s) {
+    session = s;
+    if (s) store.setItem("bookrec.session", JSON.stringify(s));
+    else store.removeItem("bookrec.session");
+    renderAccount();
+  }
+
+  function flash(text, isError) {
+    const el = $("flash");
+    el.textContent = text;
+    el.className = isError ? "error" : "";
+    el.hidden = !text;
+  }
+
+  function form(fields) {
+    const body = new URLSearchParams();
+    for (const [k, v] of Object.entries(fields)) body.append(k, v);
+    return body;
+  }
+
+  async function refresh() {
+    const res = await fetch("/refresh", {
+      method: "POST",
+      body: form({ refresh_token: session.refresh_token }),
+    });
+    if (!res.ok) return false;
+    const data = await res.json();
+    saveSession({ ...session, access_token: data.access_token, refresh_token: data.refresh_token });
+    return true;
+  }
+
+  // api calls path and returns parsed JSON; retries once after a token refresh
+  async function api(path, opts = {}, retried = false) {
+    const headers = { Accept: "application/json" };
+    if (session) headers.Authorization = "Bearer " + session.access_token;
+    const res = await fetch(path, { ...opts, headers });
+    if (res.status === 401 && session && !retried && (await refresh())) {
+      return api(path, opts, true);
+    }
+    const data = await res.json().catch(() => ({}));
+    if (!res.ok) throw new Error(data.error || res.status + " " + res.statusText);
+    return data;
+  }
+
+  function bookItem(book, opts = {}) {
+    const li = document.createElement("li");
+    const text = document.createElement("div");
+    const title = document.createElement("strong");
+    title.textContent = book.title;
+    const meta = document.createElement("div");
+    meta.className = "meta";
+    meta.textContent = [book.author, book.year || "", opts.extra || ""].filter(Boolean).join(" · ");
+    text.append(title, meta);
+    li.append(text);
+
+    const ref = book.uuid || book.book_uuid;
+    if (session && ref) {
+      const btn = document.createElement("button");
+      btn.type = "button";
+      btn.textContent = liked.has(ref) ? "Liked" : "Like";
+      btn.classList.toggle("liked", liked.has(ref));
+      btn.disabled = liked.has(ref);
+      btn.addEventListener("click", () => like(ref, btn));
+      li.append(btn);
+    }
+    return li;
+  }
+
+  function renderList(el, books, extra) {
+    el.replaceChildren(...books.map((b) => bookItem(b, { extra: extra && extra(b) })));
+  }
+
+  async function loadResults() {
+    try {
+      let data;
+      const params = new URLSearchParams({ page: view.page, limit: pageSize });
+      if (view.mode === "search") {
+        params.set("q", view.q);
+        params.set("sort", view.sort);
+        data = await api("/books/search?" + params);
+        $("results-title").textContent = view.q ? `Results for "${view.q}"` : "Results";
+      } else {
+        data = await api("/books?" + params);
+        $("results-title").textContent = "Books";
+      }
+      renderList($("results"), data.data, (b) => (b.likes !== undefined ? b.likes + " likes" : ""));
+      $("page").textContent = "Page " + data.page;
+      $("prev").disabled = data.page <= 1;
+      $("next").disabled = data.data.length < pageSize;
+    } catch (e) {
+      flash(e.message, true);
+    }
+  }
+
+  async function loadPopular() {
+    try {
+      renderList($("popular"), await api("/books/popular"), (b) => b.likes + " likes");
+    } catch (e) {
+      flash(e.message, true);
+    }
+  }
+
+  async function loadRecommendations() {
+    $("recs").replaceChildren();
+    $("recs-hint").hidden = false;
+    if (!session) return;
+    try {
+      const data = await api("/recommendations/" + encodeURIComponent(session.user.uuid));
+      // an object with a message means "nothing yet"
+      if (!Array.isArray(data)) {
+        $("recs-hint").textContent = data.message;
+        return;
+      }
+      $("recs-hint").hidden = true;
+      renderList($("recs"), data, (r) => (r.reason ? r.reason.text : "score " + r.score));
+    } catch (e) {
+      flash(e.message, true);
+    }
+  }
+
+  async function like(bookRef, btn) {
+    btn.disabled = true;
+    try {
+      await api("/interactions", {
+        method: "POST",
+        body: form({ user_id: session.user.uuid, book_id: bookRef, action: "like" }),
+      });
+      liked.add(bookRef);
+      btn.textContent = "Liked";
+      btn.classList.add("liked");
+      loadPopular();
+      loadRecommendations();
+    } catch (e) {
+      btn.disabled = false;
+      flash(e.message, true);
+    }
+  }
+
+  function renderAccount() {
+    $("login-form").hidden = !!session;
+    $("signup-form").hidden = true;
+    $("whoami").hidden = !session;
+    $("whoami-email").textContent = session ? session.user.email : "";
+    $("recs-hint").textContent = "Log in and like a few books to get recommendations.";
+  }
+
+  async function login(email, password) {
+    const data = await api("/login", { method: "POST", body: form({ email, password }) });
+    saveSession({ access_token: data.access_token, refresh_token: data.refresh_token, user: data.user });
+    flash("");
+    loadResults();
+    loadRecommendations();
+  }
+
+  $("login-form").addEventListener("submit", async (ev) => {
+    ev.preventDefault();
+    const f = ev.target;
+    try {
+      await login(f.email.value, f.password.value);
+    } catch (e) {
+      flash(e.message, true);
+    }
+  });
+
+  $("signup-form").addEventListener("submit", async (ev) => {
+    ev.preventDefault();
+    const f = ev.target;
+    try {
+      await api("/users", {
+        method: "POST",
+        body: form({ email: f.email.value, handle: f.handle.value, password: f.password.value }),
+      });
+      await login(f.email.value, f.password.value);
+    } catch (e) {
+      flash(e.message, true);
+    }
+  });
+
+  $("signup-toggle").addEventListener("click", () => {
+    $("login-form").hidden = true;
+    $("signup-form").hidden = false;
+  });
+  $("login-toggle").addEventListener("click", renderAccount);
+
+  $("logout").addEventListener("click", async () => {
+    try {
+      await api("/logout", { method: "POST", body: form({ refresh_token: session.refresh_token }) });
+    } catch (e) {
+      // the session is dropped locally either way
+    }
+    liked.clear();
+    saveSession(null);
+    loadResults();
+    loadRecommendations();
+  });
+
+  $("search-form").addEventListener("submit", (ev) => {
+    ev.preventDefault();
+    view = { mode: "search", page: 1, q: ev.target.q.value.trim(), sort: ev.target.sort.value };
+    loadResults();
+  });
+  $("browse").addEventListener("click", () => {
+    view = { mode: "browse", page: 1, q: "", sort: "relevance" };
+    loadResults();
+  });
+  $("prev").addEventListener("click", () => {
+    view.page = Math.max(1, view.page - 1);
+    loadResults();
+  });
+  $("next").addEventListener("click", () => {
+    view.page += 1;
+    loadResults();
+  });
+
+  renderAccount();
+  loadResults();
+  loadPopular();
+  loadRecommendations();
+})();
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterUI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	registerUI(r)
+
+	for _, tc := range []struct {
+		path, contentType, contains string
+	}{
+		{"/", "text/html", `<script src="/ui/app.js">`},
+		{"/ui/app.js", "javascript", "/books/search"},
+		{"/ui/style.css", "text/css", ".books"},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", tc.path, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, tc.contentType) {
+			t.Fatalf("%s: unexpected content type %q", tc.path, ct)
+		}
+		if !strings.Contains(w.Body.String(), tc.contains) {
+			t.Fatalf("%s: body missing %q", tc.path, tc.contains)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ui/missing.js", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown asset, got %d", w.Code)
+	}
+}
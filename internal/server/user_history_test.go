@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserHistoryHandler_SinceAndKeysetPagination(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC)
+	older := newer.Add(-time.Hour)
+	cols := []string{"id", "uuid", "book_uuid", "slug", "action", "rating", "created_at", "title", "author"}
+
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("WHERE i.user_id = \\? AND i.deleted_at IS NULL AND b.deleted_at IS NULL AND i.created_at >= \\? ORDER BY i.created_at DESC, i.id DESC LIMIT \\?").
+		WithArgs(2, since, 2).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow(9, "i-9", "b-3", "dune-b-3", "rating", 5, newer, "Dune", "Frank Herbert").
+			AddRow(8, "i-8", "b-4", "emma-b-4", "like", nil, older, "Emma", "Jane Austen"))
+	mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("AND i.created_at >= \\? AND \\(i.created_at < \\? OR \\(i.created_at = \\? AND i.id < \\?\\)\\)").
+		WithArgs(2, since, newer, newer, 9, 2).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow(8, "i-8", "b-4", "emma-b-4", "like", nil, older, "Emma", "Jane Austen"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users/:id/history", asUser(2), UserHistoryHandler)
+
+	type page struct {
+		Data       []map[string]any `json:"data"`
+		NextCursor *string          `json:"next_cursor"`
+	}
+	get := func(path string) page {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		var p page
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatalf("invalid json: %v", err)
+		}
+		return p
+	}
+
+	first := get("/users/2/history?limit=1&since=2026-10-01T00:00:00Z")
+	if len(first.Data) != 1 || first.Data[0]["uuid"] != "i-9" || first.NextCursor == nil {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
+
+	second := get("/users/2/history?limit=1&since=2026-10-01T00:00:00Z&cursor=" + *first.NextCursor)
+	if len(second.Data) != 1 || second.Data[0]["uuid"] != "i-8" || second.NextCursor != nil {
+		t.Fatalf("unexpected last page: %+v", second)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestUserHistoryHandler_BadSinceAndCursor(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users/:id/history", asUser(2), UserHistoryHandler)
+
+	for _, q := range []string{"since=yesterday", "cursor=not-a-cursor"} {
+		mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/2/history?"+q, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", q, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestUserHistoryHandler_OtherUsersForbidden(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users/:id/history", asUser(3), UserHistoryHandler)
+	r.GET("/recommendations/:user_id", asUser(3), RecommendationsHandler)
+
+	for _, path := range []string{"/users/2/history", "/recommendations/2"} {
+		mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("%s: expected 403, got %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
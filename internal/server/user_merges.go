@@ -0,0 +1,250 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/YeswanthC7/bookrec/internal/counters"
+)
+
+// keepEarliestRatingSQL gives the row of each rated book that survives the
+// merge (the earliest across both accounts, preferring ones not deleted) the
+// latest score, as the dedupe job does
+const keepEarliestRatingSQL = `
+	UPDATE interactions k
+	JOIN (
+		SELECT DISTINCT
+			FIRST_VALUE(id) OVER (PARTITION BY book_id ORDER BY deleted_at IS NOT NULL, created_at, id) AS keep_id,
+			FIRST_VALUE(rating) OVER (PARTITION BY book_id ORDER BY deleted_at IS NOT NULL, created_at DESC, id DESC) AS latest
+		FROM interactions
+		WHERE user_id IN (?, ?) AND action = 'rating'
+	) d ON d.keep_id = k.id
+	SET k.rating = d.latest
+	WHERE NOT (k.rating <=> d.latest)`
+
+// dropDuplicateInteractionsSQL removes all but that row per (book, action),
+// so moving the rest doesn't break the unique key
+const dropDuplicateInteractionsSQL = `
+	DELETE i FROM interactions i
+	JOIN (
+		SELECT id, ROW_NUMBER() OVER (PARTITION BY book_id, action ORDER BY deleted_at IS NOT NULL, created_at, id) AS rn
+		FROM interactions
+		WHERE user_id IN (?, ?)
+	) d ON d.id = i.id
+	WHERE d.rn > 1`
+
+// userMergeKeys are user columns that are part of a primary key. The
+// source's rows move unless the target already has the same one, in which
+// case the target's is kept and the source's dropped.
+var userMergeKeys = []struct {
+	table  string
+	column string
+}{
+	{"list_members", "user_id"},
+	{"group_members", "user_id"},
+	{"follows", "follower_id"},
+	{"follows", "followee_id"},
+	{"user_blocks", "blocker_id"},
+	{"user_blocks", "blocked_id"},
+	{"shelf_books", "user_id"},
+	{"reviews", "user_id"},
+}
+
+// userMergeCounts is what a user merge moved
+type userMergeCounts struct {
+	Interactions        int64 `json:"interactions"`
+	DroppedInteractions int64 `json:"dropped_interactions"`
+	Lists               int64 `json:"lists"`
+	Groups              int64 `json:"groups"`
+}
+
+// moveUserRows re-points sourceID's interactions, lists, list and group
+// memberships, follows and blocks to targetID, collapses the interactions
+// both accounts had and recounts the books they touched
+func moveUserRows(ctx context.Context, tx *sql.Tx, sourceID, targetID int) (userMergeCounts, error) {
+	var moved userMergeCounts
+
+	bookIDs, err := lockedIDs(ctx, tx,
+		"SELECT DISTINCT book_id FROM interactions WHERE user_id = ? ORDER BY book_id", sourceID)
+	if err != nil {
+		return moved, err
+	}
+	if len(bookIDs) > 0 {
+		if _, err := tx.ExecContext(ctx, keepEarliestRatingSQL, sourceID, targetID); err != nil {
+			return moved, err
+		}
+		res, err := tx.ExecContext(ctx, dropDuplicateInteractionsSQL, sourceID, targetID)
+		if err != nil {
+			return moved, err
+		}
+		moved.DroppedInteractions, _ = res.RowsAffected()
+		res, err = tx.ExecContext(ctx, "UPDATE interactions SET user_id = ? WHERE user_id = ?", targetID, sourceID)
+		if err != nil {
+			return moved, err
+		}
+		moved.Interactions, _ = res.RowsAffected()
+
+		recount := make([]int, len(bookIDs))
+		for i, id := range bookIDs {
+			recount[i] = int(id)
+		}
+		if err := counters.Recount(ctx, tx, recount...); err != nil {
+			return moved, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, "UPDATE lists SET user_id = ? WHERE user_id = ?", targetID, sourceID)
+	if err != nil {
+		return moved, err
+	}
+	moved.Lists, _ = res.RowsAffected()
+	res, err = tx.ExecContext(ctx, "UPDATE reading_groups SET owner_id = ? WHERE owner_id = ?", targetID, sourceID)
+	if err != nil {
+		return moved, err
+	}
+	moved.Groups, _ = res.RowsAffected()
+
+	// follows and blocks between the two accounts would point at the target
+	// from itself
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM follows
+		WHERE (follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)`,
+		sourceID, targetID, targetID, sourceID); err != nil {
+		return moved, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM user_blocks
+		WHERE (blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)`,
+		sourceID, targetID, targetID, sourceID); err != nil {
+		return moved, err
+	}
+	for _, k := range userMergeKeys {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE IGNORE "+k.table+" SET "+k.column+" = ? WHERE "+k.column+" = ?", targetID, sourceID); err != nil {
+			return moved, err
+		}
+		if _, err := tx.ExecContext(ctx,
+			"DELETE FROM "+k.table+" WHERE "+k.column+" = ?", sourceID); err != nil {
+			return moved, err
+		}
+	}
+	// the target can't be a member of a list it now owns
+	if _, err := tx.ExecContext(ctx, `
+		DELETE m FROM list_members m
+		JOIN lists l ON l.id = m.list_id
+		WHERE m.user_id = ? AND l.user_id = ?`, targetID, targetID); err != nil {
+		return moved, err
+	}
+	return moved, nil
+}
+
+// MergeUserHandler godoc
+// @Summary Merge a duplicate account into another
+// @Description In one transaction, moves the duplicate's interactions, lists, list and group memberships, the groups it owns, follows, blocks, shelved books and reviews to the target, then disables the duplicate; GET /users/{id} on it redirects (301) to the target. Interactions both accounts have with a book collapse to the earliest, keeping the latest rating; memberships, shelved books and reviews the target already has are kept as they are. The duplicate's refresh tokens are revoked; access tokens it already holds stay valid until they expire.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Duplicate user UUID"
+// @Param into query string true "Surviving user UUID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /admin/users/{id}/merge [post]
+func MergeUserHandler(c *gin.Context) {
+	sourceID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+	if c.Query("into") == "" {
+		abortWithError(c, badRequest("into is required"))
+		return
+	}
+	targetID, ok := resolveParam(c, resolveUserRef, c.Query("into"), "target user")
+	if !ok {
+		return
+	}
+	if sourceID == targetID {
+		abortWithError(c, badRequest("cannot merge a user into itself"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// lock both accounts (source first, like book merges)
+	var sourceDisabled, targetDisabled sql.NullTime
+	err = tx.QueryRowContext(ctx, "SELECT disabled_at FROM users WHERE id = ? FOR UPDATE", sourceID).Scan(&sourceDisabled)
+	if err == nil {
+		err = tx.QueryRowContext(ctx, "SELECT disabled_at FROM users WHERE id = ? FOR UPDATE", targetID).Scan(&targetDisabled)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		abortWithError(c, notFound("user not found"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if sourceDisabled.Valid {
+		abortWithError(c, conflict("user is already disabled"))
+		return
+	}
+	if targetDisabled.Valid {
+		abortWithError(c, conflict("target user is disabled"))
+		return
+	}
+
+	before, err := auditSnapshot(ctx, tx, "user", sourceID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	moved, err := moveUserRows(ctx, tx, sourceID, targetID)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE refresh_tokens SET revoked_at = NOW()
+			WHERE user_id = ? AND revoked_at IS NULL`, sourceID)
+	}
+	if err == nil {
+		// a disabled account gets no more digests either
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users SET disabled_at = CURRENT_TIMESTAMP, merged_into = ?, email_digest = FALSE
+			WHERE id = ?`, targetID, sourceID)
+	}
+	if err == nil {
+		err = redirectAway(ctx, tx, "user", sourceID, targetID)
+	}
+	if err == nil {
+		err = recordAudit(c, tx, AuditUserMerge, "user", sourceID, before)
+	}
+	var refs []string
+	if err == nil {
+		refs, err = publicRefs(ctx, tx, "users", sourceID, targetID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"source_uuid": refs[0],
+		"target_uuid": refs[1],
+		"moved":       moved,
+		"disabled":    true,
+		"links": gin.H{
+			"target": "/users/" + refs[1],
+		},
+	})
+}
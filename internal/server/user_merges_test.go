@@ -0,0 +1,177 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMergeUserHandler(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	for _, id := range []int{5, 9} {
+		mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+			WithArgs(id, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}
+	mock.ExpectBegin()
+	for _, id := range []int{5, 9} {
+		mock.ExpectQuery("SELECT disabled_at FROM users WHERE id = \\? FOR UPDATE").
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"disabled_at"}).AddRow(nil))
+	}
+	expectAuditSnapshot(mock, "user", `{"id": 5, "disabled_at": null}`)
+	mock.ExpectQuery("SELECT DISTINCT book_id FROM interactions WHERE user_id = \\?").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id"}).AddRow(3).AddRow(4))
+	mock.ExpectExec("UPDATE interactions k\\s+JOIN").
+		WithArgs(5, 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// both accounts liked book 3; the later like goes before the move
+	mock.ExpectExec("DELETE i FROM interactions i\\s+JOIN").
+		WithArgs(5, 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE interactions SET user_id = \\? WHERE user_id = \\?").
+		WithArgs(9, 5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	for _, id := range []int{3, 4} {
+		mock.ExpectExec("DELETE FROM book_counters WHERE book_id = \\?").WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO book_counters").WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec("UPDATE lists SET user_id = \\? WHERE user_id = \\?").
+		WithArgs(9, 5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE reading_groups SET owner_id = \\? WHERE owner_id = \\?").
+		WithArgs(9, 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM follows\\s+WHERE \\(follower_id = \\? AND followee_id = \\?\\)").
+		WithArgs(5, 9, 9, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM user_blocks\\s+WHERE \\(blocker_id = \\? AND blocked_id = \\?\\)").
+		WithArgs(5, 9, 9, 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, k := range userMergeKeys {
+		mock.ExpectExec("UPDATE IGNORE "+k.table+" SET "+k.column+" = \\? WHERE "+k.column+" = \\?").
+			WithArgs(9, 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM " + k.table + " WHERE " + k.column + " = \\?").
+			WithArgs(5).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec("DELETE m FROM list_members m").
+		WithArgs(9, 9).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\)").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET disabled_at = CURRENT_TIMESTAMP, merged_into = \\?, email_digest = FALSE").
+		WithArgs(9, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO redirects").
+		WithArgs("user", 9, 5, "user", 9, 5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	expectAudit(mock, AuditUserMerge, "user", 5)
+	expectPublicRef(mock, "users", 5, "u-5")
+	expectPublicRef(mock, "users", 9, "u-9")
+	mock.ExpectCommit()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin/users/:id/merge", asUser(1), MergeUserHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/users/5/merge?into=9", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		TargetUUID string         `json:"target_uuid"`
+		Moved      map[string]int `json:"moved"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if body.TargetUUID != "u-9" || body.Moved["interactions"] != 2 || body.Moved["dropped_interactions"] != 1 || body.Moved["lists"] != 2 {
+		t.Fatalf("unexpected summary: %+v", body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestMergeUserHandler_TargetDisabled(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	for _, id := range []int{5, 9} {
+		mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+			WithArgs(id, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT disabled_at FROM users WHERE id = \\? FOR UPDATE").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"disabled_at"}).AddRow(nil))
+	mock.ExpectQuery("SELECT disabled_at FROM users WHERE id = \\? FOR UPDATE").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"disabled_at"}).AddRow(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)))
+	mock.ExpectRollback()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin/users/:id/merge", asUser(1), MergeUserHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/users/5/merge?into=9", nil))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestMergeUserHandler_Self(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+			WithArgs(5, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin/users/:id/merge", asUser(1), MergeUserHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/users/5/merge?into=5", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// User stats config
+const (
+	userStatsTTL        = 10 * time.Minute
+	userStatsMaxEntries = 10000
+	userStatsMonths     = 12
+	userStatsTopGenres  = 10
+)
+
+// UserStatsMonth is one point of a user's monthly activity series
+type UserStatsMonth struct {
+	Month        string         `json:"month" example:"2026-10"`
+	Interactions map[string]int `json:"interactions"`
+}
+
+// UserStats is the body of GET /users/{id}/stats
+type UserStats struct {
+	UserUUID      string           `json:"user_uuid"`
+	Counts        map[string]int   `json:"counts"`
+	AverageRating *float64         `json:"average_rating" extensions:"x-nullable"`
+	Genres        []GenreCount     `json:"genres"`
+	Monthly       []UserStatsMonth `json:"monthly"`
+	ComputedAt    time.Time        `json:"computed_at"`
+}
+
+type userStatsEntry struct {
+	stats   *UserStats
+	expires time.Time
+}
+
+// userStatsCache keeps computed stats for userStatsTTL. Run drops a user's
+// entry as soon as they record an interaction; the TTL covers events the bus
+// dropped and writes from other processes.
+type userStatsCache struct {
+	mu      sync.Mutex
+	entries map[int]userStatsEntry
+}
+
+var userStats = &userStatsCache{entries: map[int]userStatsEntry{}}
+
+// Get returns cached stats for userID, computing them on a miss
+func (s *userStatsCache) Get(ctx context.Context, userID int) (*UserStats, error) {
+	now := time.Now()
+	s.mu.Lock()
+	entry, ok := s.entries[userID]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.stats, nil
+	}
+
+	stats, err := loadUserStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) >= userStatsMaxEntries {
+		for id, e := range s.entries {
+			if !now.Before(e.expires) {
+				delete(s.entries, id)
+			}
+		}
+		if len(s.entries) >= userStatsMaxEntries {
+			s.entries = map[int]userStatsEntry{}
+		}
+	}
+	s.entries[userID] = userStatsEntry{stats: stats, expires: now.Add(userStatsTTL)}
+	return stats, nil
+}
+
+// Invalidate forgets userID's stats
+func (s *userStatsCache) Invalidate(userID int) {
+	s.mu.Lock()
+	delete(s.entries, userID)
+	s.mu.Unlock()
+}
+
+// Run invalidates entries on interaction.created until ctx is cancelled
+func (s *userStatsCache) Run(ctx context.Context) {
+	ch, unsubscribe := events.Subscribe(256)
+	defer unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-ch:
+			if ev.Type != EventInteractionCreated {
+				continue
+			}
+			if userID, ok := ev.Data["user_id"].(int); ok {
+				s.Invalidate(userID)
+			}
+		}
+	}
+}
+
+// loadUserStats aggregates userID's interactions, leaving out those marked
+// private. Genres only weigh likes and ratings.
+func loadUserStats(ctx context.Context, userID int) (*UserStats, error) {
+	publicID, err := publicRef(ctx, db, "users", userID)
+	if err != nil {
+		return nil, err
+	}
+	stats := &UserStats{
+		UserUUID:   publicID,
+		Counts:     map[string]int{"view": 0, "like": 0, "rating": 0, "dislike": 0},
+		Genres:     []GenreCount{},
+		ComputedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	counts, err := db.QueryContext(ctx, `
+		SELECT action, COUNT(*), AVG(rating)
+		FROM interactions
+		WHERE user_id = ? AND visibility = 'public' AND deleted_at IS NULL
+		GROUP BY action`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = counts.Close() }()
+	for counts.Next() {
+		var action string
+		var n int
+		var avg sql.NullFloat64
+		if err := counts.Scan(&action, &n, &avg); err != nil {
+			return nil, err
+		}
+		stats.Counts[action] = n
+		if action == "rating" && avg.Valid {
+			stats.AverageRating = &avg.Float64
+		}
+	}
+	if err := counts.Err(); err != nil {
+		return nil, err
+	}
+
+	genres, err := db.QueryContext(ctx, `
+		SELECT g.slug, COUNT(*) AS interactions
+		FROM interactions i
+		JOIN book_genres bg ON bg.book_id = i.book_id
+		JOIN genres g ON g.id = bg.genre_id
+		WHERE i.user_id = ? AND i.visibility = 'public' AND i.action IN ('like', 'rating') AND i.deleted_at IS NULL
+		GROUP BY g.slug
+		ORDER BY interactions DESC, g.slug
+		LIMIT ?`, userID, userStatsTopGenres)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = genres.Close() }()
+	for genres.Next() {
+		var g GenreCount
+		if err := genres.Scan(&g.Genre, &g.Interactions); err != nil {
+			return nil, err
+		}
+		stats.Genres = append(stats.Genres, g)
+	}
+	if err := genres.Err(); err != nil {
+		return nil, err
+	}
+
+	// one zero-filled point per month, oldest first, ending with this month
+	thisMonth := time.Date(stats.ComputedAt.Year(), stats.ComputedAt.Month(), 1, 0, 0, 0, 0, time.UTC)
+	first := thisMonth.AddDate(0, -(userStatsMonths - 1), 0)
+	index := map[string]*UserStatsMonth{}
+	stats.Monthly = make([]UserStatsMonth, userStatsMonths)
+	for i := range stats.Monthly {
+		m := &stats.Monthly[i]
+		m.Month = first.AddDate(0, i, 0).Format("2006-01")
+		m.Interactions = map[string]int{"view": 0, "like": 0, "rating": 0, "dislike": 0}
+		index[m.Month] = m
+	}
+
+	monthly, err := db.QueryContext(ctx, `
+		SELECT DATE_FORMAT(created_at, '%Y-%m') AS month, action, COUNT(*)
+		FROM interactions
+		WHERE user_id = ? AND visibility = 'public' AND created_at >= ? AND deleted_at IS NULL
+		GROUP BY month, action`, userID, first)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = monthly.Close() }()
+	for monthly.Next() {
+		var month, action string
+		var n int
+		if err := monthly.Scan(&month, &action, &n); err != nil {
+			return nil, err
+		}
+		if m, ok := index[month]; ok {
+			m.Interactions[action] = n
+		}
+	}
+	if err := monthly.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// UserStatsHandler godoc
+// @Summary A user's reading statistics
+// @Description Interaction counts by action, average rating given, top genres (by likes and ratings) and a 12-month activity series. Interactions marked private are left out. Cached for up to 10 minutes; recording an interaction refreshes the user's stats.
+// @Tags Users
+// @Produce json
+// @Param id path string true "User UUID"
+// @Success 200 {object} UserStats
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/stats [get]
+func UserStatsHandler(c *gin.Context) {
+	userID, ok := resolveParam(c, resolveUserRef, c.Param("id"), "user")
+	if !ok {
+		return
+	}
+	stats, err := userStats.Get(c.Request.Context(), userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(200, stats)
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserStatsHandler_CachesUntilInvalidated(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	userStats = &userStatsCache{entries: map[int]userStatsEntry{}}
+
+	expectResolve := func() {
+		mock.ExpectQuery("SELECT 1 FROM users WHERE id = \\?").
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}
+	expectStats := func() {
+		expectPublicRef(mock, "users", 2, "u-2")
+		mock.ExpectQuery("SELECT action, COUNT\\(\\*\\), AVG\\(rating\\)\\s+FROM interactions\\s+WHERE user_id = \\? AND visibility = 'public'").
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"action", "count", "avg"}).
+				AddRow("like", 5, nil).
+				AddRow("rating", 2, 4.5))
+		mock.ExpectQuery("JOIN book_genres bg ON bg.book_id = i.book_id\\s+JOIN genres g").
+			WithArgs(2, userStatsTopGenres).
+			WillReturnRows(sqlmock.NewRows([]string{"genre", "interactions"}).AddRow("fantasy", 4))
+		mock.ExpectQuery("SELECT DATE_FORMAT\\(created_at, '%Y-%m'\\) AS month, action, COUNT\\(\\*\\)").
+			WithArgs(2, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"month", "action", "count"}))
+	}
+
+	// computed, then served from the cache, then recomputed once invalidated
+	expectResolve()
+	expectStats()
+	expectResolve()
+	expectResolve()
+	expectStats()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users/:id/stats", UserStatsHandler)
+
+	get := func() UserStats {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/2/stats", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var stats UserStats
+		if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+			t.Fatalf("bad json: %v", err)
+		}
+		return stats
+	}
+
+	stats := get()
+	if stats.Counts["like"] != 5 || stats.Counts["view"] != 0 {
+		t.Fatalf("unexpected counts: %v", stats.Counts)
+	}
+	if stats.AverageRating == nil || *stats.AverageRating != 4.5 {
+		t.Fatalf("unexpected average rating: %v", stats.AverageRating)
+	}
+	if len(stats.Genres) != 1 || len(stats.Monthly) != userStatsMonths {
+		t.Fatalf("unexpected genres or series: %+v", stats)
+	}
+	get()
+	userStats.Invalidate(2)
+	get()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,455 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Webhook event types (interaction.created is shared with the live event bus)
+const (
+	EventUserCreated     = "user.created"
+	EventIngestCompleted = "ingest.completed"
+)
+
+var webhookEventTypes = []string{EventUserCreated, EventInteractionCreated, EventIngestCompleted}
+
+// Delivery tuning
+const (
+	webhookPollInterval = 2 * time.Second
+	webhookBatchSize    = 20
+	webhookMaxAttempts  = 6
+	webhookTimeout      = 10 * time.Second
+	// a claimed delivery is invisible to other dispatchers for this long
+	webhookClaimLease = time.Minute
+)
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// WebhookResponse is returned when a webhook is registered (the only time the secret is shown)
+type WebhookResponse struct {
+	ID     int      `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"`
+	Active bool     `json:"active"`
+}
+
+// emitEvent publishes to live subscribers and enqueues webhook deliveries.
+// Webhook failures are logged, never surfaced to the request that caused them.
+func emitEvent(ctx context.Context, eventType string, data map[string]interface{}) {
+	events.Publish(eventType, data)
+	if err := enqueueWebhookEvent(ctx, eventType, data); err != nil {
+		log.Printf("⚠️ webhook enqueue failed for %s: %v", eventType, err)
+	}
+}
+
+// enqueueWebhookEvent creates one pending delivery per active webhook subscribed to eventType
+func enqueueWebhookEvent(ctx context.Context, eventType string, data map[string]interface{}) error {
+	payload, err := json.Marshal(gin.H{
+		"type":       eventType,
+		"created_at": time.Now().UTC(),
+		"data":       publicEventData(data),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+		SELECT id, ?, ?
+		FROM webhooks
+		WHERE active = 1 AND FIND_IN_SET(?, events) > 0`,
+		eventType, string(payload), eventType)
+	return err
+}
+
+// signWebhookPayload returns the X-BookRec-Signature value for body
+func signWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the wait before retry n (1-based): 30s, 1m, 2m, 4m, ...
+func webhookBackoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	return 30 * time.Second * time.Duration(1<<(attempt-1))
+}
+
+func parseWebhookEvents(raw string) ([]string, error) {
+	known := map[string]bool{}
+	for _, e := range webhookEventTypes {
+		known[e] = true
+	}
+
+	seen := map[string]bool{}
+	out := []string{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" || seen[part] {
+			continue
+		}
+		if !known[part] {
+			return nil, fmt.Errorf("unknown event type: %s", part)
+		}
+		seen[part] = true
+		out = append(out, part)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("events required (one or more of: %s)", strings.Join(webhookEventTypes, ", "))
+	}
+	return out, nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateWebhookHandler godoc
+// @Summary Register a webhook
+// @Description Deliveries are POSTed as JSON with X-BookRec-Event, X-BookRec-Delivery and X-BookRec-Signature (HMAC-SHA256 of the body using the secret) headers.
+// @Tags Webhooks
+// @Accept x-www-form-urlencoded,mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param url formData string true "Target URL (http or https)"
+// @Param events formData string true "Comma-separated: user.created, interaction.created, ingest.completed"
+// @Param secret formData string false "Signing secret (generated when omitted)"
+// @Success 201 {object} WebhookResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/webhooks [post]
+func CreateWebhookHandler(c *gin.Context) {
+	rawURL := strings.TrimSpace(c.PostForm("url"))
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		abortWithError(c, badRequest("url must be an absolute http(s) URL"))
+		return
+	}
+
+	eventList, err := parseWebhookEvents(c.PostForm("events"))
+	if err != nil {
+		abortWithError(c, badRequest(err.Error()))
+		return
+	}
+
+	secret := strings.TrimSpace(c.PostForm("secret"))
+	if secret == "" {
+		if secret, err = newWebhookSecret(); err != nil {
+			abortWithError(c, newAppError(500, "failed to generate secret"))
+			return
+		}
+	}
+
+	res, err := db.Exec(`INSERT INTO webhooks (url, secret, events) VALUES (?, ?, ?)`,
+		rawURL, secret, strings.Join(eventList, ","))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	id, _ := res.LastInsertId()
+
+	c.JSON(201, WebhookResponse{ID: int(id), URL: rawURL, Events: eventList, Secret: secret, Active: true})
+}
+
+// ListWebhooksHandler godoc
+// @Summary List registered webhooks
+// @Tags Webhooks
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {array} WebhookResponse
+// @Router /admin/webhooks [get]
+func ListWebhooksHandler(c *gin.Context) {
+	rows, err := db.Query(`SELECT id, url, events, active FROM webhooks ORDER BY id`)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	hooks := []WebhookResponse{}
+	for rows.Next() {
+		var h WebhookResponse
+		var eventList string
+		if err := rows.Scan(&h.ID, &h.URL, &eventList, &h.Active); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		h.Events = strings.Split(eventList, ",")
+		hooks = append(hooks, h)
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, hooks)
+}
+
+// DeleteWebhookHandler godoc
+// @Summary Delete a webhook (and its delivery log)
+// @Tags Webhooks
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/webhooks/{id} [delete]
+func DeleteWebhookHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		abortWithError(c, badRequest("invalid webhook id"))
+		return
+	}
+
+	res, err := db.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		abortWithError(c, notFound("webhook not found"))
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Webhook deleted"})
+}
+
+// WebhookDeliveriesHandler godoc
+// @Summary Delivery log for a webhook (most recent first)
+// @Tags Webhooks
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Webhook ID"
+// @Param limit query int false "Limit (max 100)" default(50)
+// @Success 200 {array} map[string]interface{}
+// @Router /admin/webhooks/{id}/deliveries [get]
+func WebhookDeliveriesHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		abortWithError(c, badRequest("invalid webhook id"))
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	rows, err := db.Query(`
+		SELECT id, event_type, status, attempts, last_status_code, last_error,
+		       next_attempt_at, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = ?
+		ORDER BY id DESC
+		LIMIT ?`, id, limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	deliveries := []map[string]interface{}{}
+	for rows.Next() {
+		var deliveryID, attempts int
+		var eventType, status string
+		var statusCode sql.NullInt64
+		var lastError sql.NullString
+		var nextAttemptAt, createdAt time.Time
+		var deliveredAt sql.NullTime
+		if err := rows.Scan(&deliveryID, &eventType, &status, &attempts, &statusCode, &lastError,
+			&nextAttemptAt, &createdAt, &deliveredAt); err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		d := gin.H{
+			"id":               deliveryID,
+			"event_type":       eventType,
+			"status":           status,
+			"attempts":         attempts,
+			"last_status_code": nil,
+			"last_error":       nil,
+			"created_at":       createdAt,
+			"delivered_at":     nil,
+		}
+		if statusCode.Valid {
+			d["last_status_code"] = statusCode.Int64
+		}
+		if lastError.Valid {
+			d["last_error"] = lastError.String
+		}
+		if deliveredAt.Valid {
+			d["delivered_at"] = deliveredAt.Time
+		}
+		if status == "pending" {
+			d["next_attempt_at"] = nextAttemptAt
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(200, deliveries)
+}
+
+// webhookDelivery is a claimed pending delivery
+type webhookDelivery struct {
+	ID        int
+	EventType string
+	Payload   []byte
+	Attempts  int
+	URL       string
+	Secret    string
+}
+
+// runWebhookDispatcher sends due deliveries until ctx is cancelled
+func runWebhookDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		due, err := claimDueDeliveries(ctx)
+		if err != nil {
+			log.Printf("⚠️ webhook dispatcher: %v", err)
+			continue
+		}
+		for _, d := range due {
+			deliverWebhook(ctx, d)
+		}
+	}
+}
+
+// claimDueDeliveries pushes next_attempt_at forward on due rows before sending,
+// so concurrent dispatchers (multiple server instances) don't double-send.
+func claimDueDeliveries(ctx context.Context) ([]webhookDelivery, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT d.id, d.event_type, d.payload, d.attempts, w.url, w.secret
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= ? AND w.active = 1
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT ?`, time.Now(), webhookBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []webhookDelivery{}
+	for rows.Next() {
+		var d webhookDelivery
+		if err := rows.Scan(&d.ID, &d.EventType, &d.Payload, &d.Attempts, &d.URL, &d.Secret); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		candidates = append(candidates, d)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	claimed := []webhookDelivery{}
+	now := time.Now()
+	for _, d := range candidates {
+		res, err := db.ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET next_attempt_at = ?
+			WHERE id = ? AND status = 'pending' AND next_attempt_at <= ?`,
+			now.Add(webhookClaimLease), d.ID, now)
+		if err != nil {
+			return claimed, err
+		}
+		if affected, _ := res.RowsAffected(); affected == 1 {
+			claimed = append(claimed, d)
+		}
+	}
+	return claimed, nil
+}
+
+// deliverWebhook POSTs one delivery and records the outcome
+func deliverWebhook(ctx context.Context, d webhookDelivery) {
+	statusCode, sendErr := sendWebhook(ctx, d)
+	attempts := d.Attempts + 1
+
+	var code interface{}
+	if statusCode > 0 {
+		code = statusCode
+	}
+
+	if sendErr == nil {
+		_, err := db.ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET status = 'succeeded', attempts = ?, last_status_code = ?, last_error = NULL, delivered_at = ?
+			WHERE id = ?`, attempts, code, time.Now(), d.ID)
+		if err != nil {
+			log.Printf("⚠️ webhook delivery %d: %v", d.ID, err)
+		}
+		return
+	}
+
+	msg := sendErr.Error()
+	if len(msg) > 512 {
+		msg = msg[:512]
+	}
+	status := "pending"
+	if attempts >= webhookMaxAttempts {
+		status = "failed"
+	}
+	_, err := db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, last_status_code = ?, last_error = ?, next_attempt_at = ?
+		WHERE id = ?`, status, attempts, code, msg, time.Now().Add(webhookBackoff(attempts)), d.ID)
+	if err != nil {
+		log.Printf("⚠️ webhook delivery %d: %v", d.ID, err)
+	}
+}
+
+func sendWebhook(ctx context.Context, d webhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "BookRec-Webhooks/1.0")
+	req.Header.Set("X-BookRec-Event", d.EventType)
+	req.Header.Set("X-BookRec-Delivery", strconv.Itoa(d.ID))
+	req.Header.Set("X-BookRec-Signature", signWebhookPayload(d.Secret, d.Payload))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
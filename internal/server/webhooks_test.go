@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestParseWebhookEvents(t *testing.T) {
+	got, err := parseWebhookEvents(" user.created,interaction.created,user.created ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != EventUserCreated || got[1] != EventInteractionCreated {
+		t.Fatalf("unexpected events: %v", got)
+	}
+
+	if _, err := parseWebhookEvents("book.deleted"); err == nil {
+		t.Fatalf("expected error for unknown event")
+	}
+	if _, err := parseWebhookEvents(" , "); err == nil {
+		t.Fatalf("expected error for empty events")
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	if webhookBackoff(1) != 30*time.Second || webhookBackoff(3) != 2*time.Minute {
+		t.Fatalf("unexpected backoff: %v %v", webhookBackoff(1), webhookBackoff(3))
+	}
+}
+
+func TestDeliverWebhook_SignsAndRecordsSuccess(t *testing.T) {
+	payload := []byte(`{"type":"user.created","data":{"user_id":1}}`)
+
+	var gotSig, gotEvent string
+	var gotBody []byte
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get("X-BookRec-Signature")
+		gotEvent = r.Header.Get("X-BookRec-Event")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer target.Close()
+
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectExec("UPDATE webhook_deliveries\\s+SET status = 'succeeded'").
+		WithArgs(1, http.StatusNoContent, sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	deliverWebhook(context.Background(), webhookDelivery{
+		ID: 7, EventType: EventUserCreated, Payload: payload, URL: target.URL, Secret: "s3cret",
+	})
+
+	if gotEvent != EventUserCreated || string(gotBody) != string(payload) {
+		t.Fatalf("unexpected request: event=%q body=%s", gotEvent, gotBody)
+	}
+	if gotSig != signWebhookPayload("s3cret", payload) {
+		t.Fatalf("signature mismatch: %s", gotSig)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestDeliverWebhook_SchedulesRetryOnFailure(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer target.Close()
+
+	var mock sqlmock.Sqlmock
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectExec("UPDATE webhook_deliveries\\s+SET status = \\?").
+		WithArgs("pending", 2, http.StatusBadGateway, "unexpected status 502", sqlmock.AnyArg(), 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	deliverWebhook(context.Background(), webhookDelivery{
+		ID: 9, EventType: EventUserCreated, Payload: []byte(`{}`), Attempts: 1, URL: target.URL, Secret: "s",
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+// Package softdelete marks users, books, lists and interactions deleted
+// (deleted_at) instead of removing them. Reads leave marked rows out with
+// LiveSQL, Restore brings one back, and Purge removes the ones marked
+// longer ago than the retention window (cmd/jobs/purge).
+package softdelete
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Tables are the soft-deletable tables by resource name
+var Tables = map[string]string{
+	"user":        "users",
+	"book":        "books",
+	"list":        "lists",
+	"interaction": "interactions",
+}
+
+// PurgeOrder empties the rows that point at others first, so a purge
+// doesn't cascade into rows it hasn't counted yet
+var PurgeOrder = []string{"interaction", "list", "book", "user"}
+
+// Execer is satisfied by *sql.DB and *sql.Tx
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// LiveSQL leaves out soft-deleted rows of alias ("" for an unaliased table)
+func LiveSQL(alias string) string {
+	if alias == "" {
+		return "deleted_at IS NULL"
+	}
+	return alias + ".deleted_at IS NULL"
+}
+
+// Delete marks a row deleted by byUserID. It reports false when the row is
+// missing or already deleted.
+func Delete(ctx context.Context, db Execer, table string, id, byUserID int) (bool, error) {
+	res, err := db.ExecContext(ctx,
+		"UPDATE "+table+" SET deleted_at = CURRENT_TIMESTAMP, deleted_by = ? WHERE id = ? AND deleted_at IS NULL",
+		byUserID, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Restore clears a row's deleted mark. It reports false when the row is
+// missing or wasn't deleted.
+func Restore(ctx context.Context, db Execer, table string, id int) (bool, error) {
+	res, err := db.ExecContext(ctx,
+		"UPDATE "+table+" SET deleted_at = NULL, deleted_by = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Cascade marks the live rows of table that ownerColumn points at a deleted
+// ownerTable row with the owner's deleted_at, so Uncascade brings back
+// exactly those and not ones deleted on their own
+func Cascade(ctx context.Context, db Execer, table, ownerColumn, ownerTable string, ownerID int) (int64, error) {
+	res, err := db.ExecContext(ctx, `
+		UPDATE `+table+` t JOIN `+ownerTable+` o ON o.id = t.`+ownerColumn+`
+		SET t.deleted_at = o.deleted_at, t.deleted_by = o.deleted_by
+		WHERE o.id = ? AND o.deleted_at IS NOT NULL AND t.deleted_at IS NULL`, ownerID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Uncascade undoes Cascade. Call it before restoring the owner, while its
+// deleted_at still matches.
+func Uncascade(ctx context.Context, db Execer, table, ownerColumn, ownerTable string, ownerID int) (int64, error) {
+	res, err := db.ExecContext(ctx, `
+		UPDATE `+table+` t JOIN `+ownerTable+` o ON o.id = t.`+ownerColumn+`
+		SET t.deleted_at = NULL, t.deleted_by = NULL
+		WHERE o.id = ? AND t.deleted_at = o.deleted_at`, ownerID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Purge removes up to batch rows of table deleted before cutoff and reports
+// how many went. Call it until it returns 0.
+func Purge(ctx context.Context, db Execer, table string, cutoff time.Time, batch int) (int64, error) {
+	res, err := db.ExecContext(ctx,
+		"DELETE FROM "+table+" WHERE deleted_at < ? ORDER BY id LIMIT ?", cutoff, batch)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,98 @@
+package softdelete
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestLiveSQL(t *testing.T) {
+	if got := LiveSQL("b"); got != "b.deleted_at IS NULL" {
+		t.Fatalf("unexpected sql: %s", got)
+	}
+	if got := LiveSQL(""); got != "deleted_at IS NULL" {
+		t.Fatalf("unexpected sql: %s", got)
+	}
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectExec("UPDATE lists SET deleted_at = CURRENT_TIMESTAMP, deleted_by = \\? WHERE id = \\? AND deleted_at IS NULL").
+		WithArgs(2, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// already deleted
+	mock.ExpectExec("UPDATE lists SET deleted_at = CURRENT_TIMESTAMP").
+		WithArgs(2, 7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE lists SET deleted_at = NULL, deleted_by = NULL WHERE id = \\? AND deleted_at IS NOT NULL").
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx := context.Background()
+	if ok, err := Delete(ctx, db, "lists", 7, 2); err != nil || !ok {
+		t.Fatalf("delete: %v, %v", ok, err)
+	}
+	if ok, err := Delete(ctx, db, "lists", 7, 2); err != nil || ok {
+		t.Fatalf("expected a second delete to change nothing: %v, %v", ok, err)
+	}
+	if ok, err := Restore(ctx, db, "lists", 7); err != nil || !ok {
+		t.Fatalf("restore: %v, %v", ok, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestCascade(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectExec("UPDATE lists t JOIN users o ON o.id = t.user_id\\s+SET t.deleted_at = o.deleted_at, t.deleted_by = o.deleted_by\\s+WHERE o.id = \\? AND o.deleted_at IS NOT NULL AND t.deleted_at IS NULL").
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE lists t JOIN users o ON o.id = t.user_id\\s+SET t.deleted_at = NULL, t.deleted_by = NULL\\s+WHERE o.id = \\? AND t.deleted_at = o.deleted_at").
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	ctx := context.Background()
+	if n, err := Cascade(ctx, db, "lists", "user_id", "users", 3); err != nil || n != 2 {
+		t.Fatalf("cascade: %d, %v", n, err)
+	}
+	if n, err := Uncascade(ctx, db, "lists", "user_id", "users", 3); err != nil || n != 2 {
+		t.Fatalf("uncascade: %d, %v", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	cutoff := time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec("DELETE FROM books WHERE deleted_at < \\? ORDER BY id LIMIT \\?").
+		WithArgs(cutoff, 500).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	n, err := Purge(context.Background(), db, "books", cutoff, 500)
+	if err != nil || n != 3 {
+		t.Fatalf("purge: %d, %v", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
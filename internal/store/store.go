@@ -0,0 +1,192 @@
+// Package store looks up users, books and interactions for the HTTP API:
+// resolving the UUIDs and slugs clients send, and loading the records.
+// The server and internal/handlers read through the Store interface, so
+// handlers can be tested against a fake; SQL is the implementation over
+// *sql.DB. References only resolve in the organization on ctx (tenant.ID).
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/YeswanthC7/bookrec/internal/ids"
+	"github.com/YeswanthC7/bookrec/internal/models"
+	"github.com/YeswanthC7/bookrec/internal/softdelete"
+	"github.com/YeswanthC7/bookrec/internal/tenant"
+)
+
+// ErrNotFound means a reference or id matched nothing in the organization
+var ErrNotFound = errors.New("not found")
+
+// Store is what handlers read users, books and interactions through.
+// Resolve* map a client-supplied UUID or slug (books) to the internal id.
+type Store interface {
+	ResolveUser(ctx context.Context, raw string) (int, error)
+	ResolveBook(ctx context.Context, raw string) (int, error)
+	ResolveInteraction(ctx context.Context, raw string) (int, error)
+	User(ctx context.Context, id int) (models.User, error)
+	Interaction(ctx context.Context, id int) (models.Interaction, error)
+	BookStats(ctx context.Context, bookID int) (models.BookStats, error)
+}
+
+// Querier runs reads on a *sql.DB or inside a *sql.Tx
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// OrgScope is the ResolveRef scope for tables owned by exactly one organization
+const OrgScope = "organization_id = ?"
+
+// LiveScope is OrgScope for soft-deletable tables: deleted rows are "not found"
+var LiveScope = OrgScope + " AND " + softdelete.LiveSQL("")
+
+// SQL is the Store over a database
+type SQL struct {
+	q Querier
+}
+
+// New returns the Store reading from q, usually the server's *sql.DB
+func New(q Querier) *SQL {
+	return &SQL{q: q}
+}
+
+// LegacyIntegerIDs makes ResolveRef accept bare integer ids as well, for
+// clients that haven't moved to UUIDs yet. It is deprecated and will be
+// removed on 2027-03-31; Run sets it from LEGACY_INTEGER_IDS.
+var LegacyIntegerIDs bool
+
+// ResolveRef maps a client-supplied reference to the internal integer id.
+// UUIDs are looked up on the uuid column and other values on slugColumn when
+// set; bare integers are only accepted with LegacyIntegerIDs.
+// scope restricts matches to the request's tenant; its single placeholder
+// is bound to tenant.ID(ctx), so other organizations' rows are ErrNotFound.
+func ResolveRef(ctx context.Context, q Querier, table, slugColumn, scope, raw string) (int, error) {
+	orgID := tenant.ID(ctx)
+	var query string
+	switch {
+	case ids.IsUUID(raw):
+		query = "SELECT id FROM " + table + " WHERE uuid = ? AND " + scope
+	case LegacyIntegerIDs && IsIntegerRef(raw):
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			return 0, ErrNotFound
+		}
+		var one int
+		err = q.QueryRowContext(ctx, "SELECT 1 FROM "+table+" WHERE id = ? AND "+scope, id, orgID).Scan(&one)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		if err != nil {
+			return 0, err
+		}
+		return id, nil
+	case slugColumn != "":
+		query = "SELECT id FROM " + table + " WHERE " + slugColumn + " = ? AND " + scope
+	default:
+		return 0, ErrNotFound
+	}
+
+	var id int
+	err := q.QueryRowContext(ctx, query, raw, orgID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	return id, err
+}
+
+// IsIntegerRef reports whether s is a bare integer id, the kind of reference
+// only LegacyIntegerIDs accepts
+func IsIntegerRef(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveUser resolves a live user in the organization
+func (s *SQL) ResolveUser(ctx context.Context, raw string) (int, error) {
+	return ResolveRef(ctx, s.q, "users", "", LiveScope, raw)
+}
+
+// ResolveBook resolves a book the organization can see, by slug too
+func (s *SQL) ResolveBook(ctx context.Context, raw string) (int, error) {
+	return ResolveRef(ctx, s.q, "books", "slug", tenant.BooksVisibleSQL(""), raw)
+}
+
+// ResolveInteraction resolves a live interaction in the organization
+func (s *SQL) ResolveInteraction(ctx context.Context, raw string) (int, error) {
+	return ResolveRef(ctx, s.q, "interactions", "", LiveScope, raw)
+}
+
+// User loads an account by id, deleted or not
+func (s *SQL) User(ctx context.Context, id int) (models.User, error) {
+	u := models.User{ID: id}
+	err := s.q.QueryRowContext(ctx,
+		"SELECT uuid, email, handle, role, created_at FROM users WHERE id = ?", id).
+		Scan(&u.UUID, &u.Email, &u.Handle, &u.Role, &u.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, ErrNotFound
+	}
+	return u, err
+}
+
+// Interaction loads an interaction by id with its user's and book's UUIDs
+func (s *SQL) Interaction(ctx context.Context, id int) (models.Interaction, error) {
+	in := models.Interaction{ID: id}
+	var rating sql.NullInt64
+	err := s.q.QueryRowContext(ctx, `
+		SELECT i.uuid, i.user_id, u.uuid, i.book_id, b.uuid, i.action, i.rating, i.visibility, i.created_at
+		FROM interactions i
+		JOIN users u ON u.id = i.user_id
+		JOIN books b ON b.id = i.book_id
+		WHERE i.id = ?`, id).
+		Scan(&in.UUID, &in.UserID, &in.UserUUID, &in.BookID, &in.BookUUID, &in.Action, &rating, &in.Visibility, &in.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return in, ErrNotFound
+	}
+	if err != nil {
+		return in, err
+	}
+	if rating.Valid {
+		in.Rating = &rating.Int64
+	}
+	return in, nil
+}
+
+// BookStats counts a book's likes, ratings, views and reviews in the
+// tenant on ctx. Likes and ratings come from book_counters; views aren't
+// counted there, so they're aggregated from interactions.
+func (s *SQL) BookStats(ctx context.Context, bookID int) (models.BookStats, error) {
+	orgID := tenant.ID(ctx)
+	var stats models.BookStats
+	var avg sql.NullFloat64
+	err := s.q.QueryRowContext(ctx, `
+		SELECT likes, ratings, rating_sum / NULLIF(ratings, 0)
+		FROM book_counters
+		WHERE organization_id = ? AND book_id = ?`, orgID, bookID).Scan(&stats.Likes, &stats.Ratings, &avg)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return stats, err
+	}
+	if avg.Valid {
+		stats.AvgRating = &avg.Float64
+	}
+	if err := s.q.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM interactions
+		WHERE organization_id = ? AND book_id = ? AND action = 'view' AND deleted_at IS NULL`, orgID, bookID).Scan(&stats.Views); err != nil {
+		return stats, err
+	}
+	if err := s.q.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM reviews WHERE organization_id = ? AND book_id = ? AND deleted_at IS NULL", orgID, bookID).Scan(&stats.Reviews); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
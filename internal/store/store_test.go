@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestResolveRef(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	s := New(db)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT id FROM books WHERE slug = \\?").
+		WithArgs("the-hobbit-1b4e28ba", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	if id, err := s.ResolveBook(ctx, "the-hobbit-1b4e28ba"); err != nil || id != 3 {
+		t.Fatalf("expected the slug resolved to 3, got %d, %v", id, err)
+	}
+
+	mock.ExpectQuery("SELECT id FROM users WHERE uuid = \\? AND organization_id = \\? AND deleted_at IS NULL").
+		WithArgs("6f1c2b9e-0c1d-4a52-9d7e-1c0f5b2a9e11", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	if _, err := s.ResolveUser(ctx, "6f1c2b9e-0c1d-4a52-9d7e-1c0f5b2a9e11"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected an unknown uuid not found, got %v", err)
+	}
+
+	// bare integer ids aren't references, whatever they'd match
+	if _, err := s.ResolveInteraction(ctx, "7"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a bare id not found, got %v", err)
+	}
+	mock.ExpectQuery("SELECT id FROM books WHERE slug = \\?").
+		WithArgs("42", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	if _, err := s.ResolveBook(ctx, "42"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a bare book id not found, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestResolveRef_LegacyIntegerIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	LegacyIntegerIDs = true
+	defer func() { LegacyIntegerIDs = false }()
+	s := New(db)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT 1 FROM interactions WHERE id = \\?").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	if id, err := s.ResolveInteraction(ctx, "7"); err != nil || id != 7 {
+		t.Fatalf("expected a bare id accepted, got %d, %v", id, err)
+	}
+	// no query for ids that can't exist
+	if _, err := s.ResolveInteraction(ctx, "0"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected id 0 not found, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestInteraction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	columns := []string{"uuid", "user_id", "user_uuid", "book_id", "book_uuid", "action", "rating", "visibility", "created_at"}
+	mock.ExpectQuery("FROM interactions i\\s+JOIN users u ON u.id = i.user_id").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("i-5", 2, "u-2", 3, "b-3", "rating", 4, "private", "2026-01-02 03:04:05"))
+	mock.ExpectQuery("FROM interactions i\\s+JOIN users u ON u.id = i.user_id").
+		WithArgs(6).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	in, err := New(db).Interaction(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("Interaction: %v", err)
+	}
+	if in.ID != 5 || in.UserID != 2 || in.BookUUID != "b-3" || in.Rating == nil || *in.Rating != 4 || in.Visibility != "private" {
+		t.Fatalf("unexpected interaction %+v", in)
+	}
+	if _, err := New(db).Interaction(context.Background(), 6); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a missing interaction not found, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
+
+func TestBookStats_NoCounters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("FROM book_counters").
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"likes", "ratings", "avg"}))
+	mock.ExpectQuery("FROM interactions").
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("FROM reviews").
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	stats, err := New(db).BookStats(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("BookStats: %v", err)
+	}
+	if stats.Likes != 0 || stats.Ratings != 0 || stats.AvgRating != nil {
+		t.Fatalf("expected an unliked, unrated book, got %+v", stats)
+	}
+}
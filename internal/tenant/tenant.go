@@ -0,0 +1,48 @@
+// Package tenant carries the organization a request is scoped to. The HTTP
+// layer resolves it (header or subdomain) and stores it on the request
+// context; queries read it back so users, interactions, and popularity
+// never leak across organizations.
+package tenant
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/YeswanthC7/bookrec/internal/softdelete"
+)
+
+// DefaultID is the organization created by the migration. Requests that
+// don't name a tenant, and tokens issued before multi-tenancy, map to it.
+const DefaultID = 1
+
+type ctxKey struct{}
+
+// WithID returns ctx scoped to organization id
+func WithID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// ID returns the organization ctx is scoped to (DefaultID when unset)
+func ID(ctx context.Context) int {
+	if id, ok := ctx.Value(ctxKey{}).(int); ok && id > 0 {
+		return id
+	}
+	return DefaultID
+}
+
+// BooksVisibleSQL filters books to the shared catalogue (organization_id
+// NULL) plus the tenant's own titles, leaving out soft-deleted ones. Bind
+// ID(ctx) for the placeholder.
+func BooksVisibleSQL(alias string) string {
+	return fmt.Sprintf("(%s AND %s)", BooksScopeSQL(alias), softdelete.LiveSQL(alias))
+}
+
+// BooksScopeSQL is BooksVisibleSQL with soft-deleted books kept, for
+// restoring them
+func BooksScopeSQL(alias string) string {
+	col := "organization_id"
+	if alias != "" {
+		col = alias + ".organization_id"
+	}
+	return fmt.Sprintf("(%s IS NULL OR %s = ?)", col, col)
+}
@@ -0,0 +1,27 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+)
+
+func TestID(t *testing.T) {
+	if got := ID(context.Background()); got != DefaultID {
+		t.Fatalf("expected default tenant, got %d", got)
+	}
+	if got := ID(WithID(context.Background(), 7)); got != 7 {
+		t.Fatalf("expected tenant 7, got %d", got)
+	}
+}
+
+func TestBooksVisibleSQL(t *testing.T) {
+	if got := BooksVisibleSQL("b"); got != "((b.organization_id IS NULL OR b.organization_id = ?) AND b.deleted_at IS NULL)" {
+		t.Fatalf("unexpected sql: %s", got)
+	}
+	if got := BooksVisibleSQL(""); got != "((organization_id IS NULL OR organization_id = ?) AND deleted_at IS NULL)" {
+		t.Fatalf("unexpected sql: %s", got)
+	}
+	if got := BooksScopeSQL(""); got != "(organization_id IS NULL OR organization_id = ?)" {
+		t.Fatalf("unexpected sql: %s", got)
+	}
+}
@@ -0,0 +1,79 @@
+// Package tracing sends OpenTelemetry traces of server requests, and the SQL
+// they run, to an OTLP collector (Jaeger, Tempo, ...) when one is
+// configured with the standard OTEL_EXPORTER_OTLP_* variables.
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"os"
+	"strings"
+
+	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName names the service in traces unless OTEL_SERVICE_NAME does
+const ServiceName = "bookrec"
+
+// FromEnv installs the global tracer provider exporting over OTLP/HTTP to
+// OTEL_EXPORTER_OTLP_ENDPOINT (e.g. http://localhost:4318) or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT; the exporter also reads the other
+// OTEL_EXPORTER_OTLP_* variables (headers, timeout, ...) and the sampler
+// follows OTEL_TRACES_SAMPLER. It returns a shutdown that flushes spans
+// still buffered. Without an endpoint, nothing is installed, spans are
+// no-ops and FromEnv returns a nil shutdown.
+func FromEnv(ctx context.Context) (func(context.Context) error, error) {
+	if strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")) == "" &&
+		strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")) == "" {
+		return nil, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(),
+		resource.NewSchemaless(semconv.ServiceName(serviceName())))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+func serviceName() string {
+	if name := strings.TrimSpace(os.Getenv("OTEL_SERVICE_NAME")); name != "" {
+		return name
+	}
+	return ServiceName
+}
+
+// OpenDB opens a database on c whose queries, statements and transactions
+// are spans of the trace in their context. Queries without one (background
+// jobs, most CLI commands) aren't traced, or each would start a trace.
+func OpenDB(c driver.Connector) *sql.DB {
+	return otelsql.OpenDB(c,
+		otelsql.WithAttributes(semconv.DBSystemNameMySQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnectorConnect: true,
+			OmitRows:             true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}))
+}
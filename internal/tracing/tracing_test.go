@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// dsnConnector opens connections from a registered driver by DSN, as
+// sql.Open would
+type dsnConnector struct {
+	dsn string
+	drv driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.drv.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.drv }
+
+func TestFromEnvWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	shutdown, err := FromEnv(context.Background())
+	if err != nil || shutdown != nil {
+		t.Fatalf("expected no tracing without an endpoint, got %v", err)
+	}
+}
+
+func TestOpenDBTracesQueriesInATrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	mockDB, mock, err := sqlmock.NewWithDSN("tracing-test")
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer func() { _ = mockDB.Close() }()
+	db := OpenDB(dsnConnector{dsn: "tracing-test", drv: mockDB.Driver()})
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM books").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(40))
+	mock.ExpectQuery("SELECT title FROM books").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"title"}).AddRow("Dune"))
+
+	var n int
+	if err := db.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM books").Scan(&n); err != nil {
+		t.Fatalf("untraced query: %v", err)
+	}
+	if spans := recorder.Ended(); len(spans) != 0 {
+		t.Fatalf("expected no spans outside a trace, got %d", len(spans))
+	}
+
+	ctx, request := provider.Tracer("test").Start(context.Background(), "GET /recommendations/:user_id")
+	var title string
+	if err := db.QueryRowContext(ctx, "SELECT title FROM books WHERE id = ?", 1).Scan(&title); err != nil {
+		t.Fatalf("traced query: %v", err)
+	}
+	request.End()
+
+	var query sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "sql.conn.query" {
+			query = span
+		}
+	}
+	if query == nil || query.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Fatalf("expected a query span under the request, got %v", recorder.Ended())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet sql expectations: %v", err)
+	}
+}
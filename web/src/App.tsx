@@ -30,14 +30,15 @@ export default function App() {
 
   const [email, setEmail] = useState("test@example.com");
   const [password, setPassword] = useState("pass123");
-  const [userId, setUserId] = useState<number>(1);
+  const [userId, setUserId] = useState<string>("");
 
   useEffect(() => {
     (async () => {
       try {
         const [health, stats, books, popular, search] = await Promise.all([
           getHealth(),
-          getStats(),
+          // admins only; anyone else just doesn't see them
+          getStats().catch(() => undefined),
           listBooks({ page: 1, limit: 5 }),
           listPopularBooks(),
           searchBooks({ q: "harry", page: 1, limit: 5, sort: "relevance" }),
@@ -55,8 +56,8 @@ export default function App() {
     setMsg("");
     try {
       const res = await login(email, password);
-      const maybeId = (res.user as any)?.id;
-      if (typeof maybeId === "number") setUserId(maybeId);
+      const maybeId = (res.user as any)?.uuid;
+      if (typeof maybeId === "string") setUserId(maybeId);
       setMsg("Logged in ✅");
     } catch (e: any) {
       setError(e?.message ?? "Login failed");
@@ -119,10 +120,9 @@ export default function App() {
 
         <input
           value={userId}
-          onChange={(e) => setUserId(Number(e.target.value))}
-          placeholder="user_id"
-          type="number"
-          style={{ width: 110 }}
+          onChange={(e) => setUserId(e.target.value)}
+          placeholder="user uuid"
+          style={{ width: 300 }}
         />
         <button onClick={onRecs}>Get recommendations</button>
 
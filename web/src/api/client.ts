@@ -76,10 +76,14 @@ api.interceptors.response.use(
 export type HealthResponse = { status: string };
 
 export type Book = {
-  id: number;
+  uuid: string;
+  slug: string;
   title: string;
   author: string;
   year: number;
+  language: string | null;
+  publisher: string | null;
+  cover_url: string | null;
 };
 
 export type Paginated<T> = {
@@ -91,10 +95,12 @@ export type Paginated<T> = {
 };
 
 export type PopularBook = {
-  id: number;
+  uuid: string;
+  slug: string;
   title: string;
   author: string;
   likes: number;
+  cover_url: string | null;
 };
 
 export type LoginResponse = {
@@ -160,8 +166,8 @@ export async function logout(): Promise<LogoutResponse> {
   return data;
 }
 
-export async function getRecommendations(userId: number) {
-  const { data } = await api.get(`/recommendations/${userId}`);
+export async function getRecommendations(userId: string) {
+  const { data } = await api.get(`/recommendations/${encodeURIComponent(userId)}`);
   return data;
 }
 
@@ -39,7 +39,7 @@ export async function booksSearch(params: {
   author?: string;
   year_from?: number;
   year_to?: number;
-  sort?: "newest" | "popular" | "relevance" | string;
+  sort?: "year" | "popularity" | "relevance" | string;
   page?: number;
   limit?: number;
 }) {
@@ -110,8 +110,8 @@ export async function usersCreate(email: string, handle: string, password: strin
   return data;
 }
 
-export async function userHistory(userId: number) {
-  const { data } = await api.get(`/users/${userId}/history`);
+export async function userHistory(userId: number, cursor?: string) {
+  const { data } = await api.get(`/users/${userId}/history`, { params: { cursor } });
   return data;
 }
 